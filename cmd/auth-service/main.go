@@ -56,6 +56,7 @@ func main() {
 
 	// Initialize service and handler
 	authService := auth.NewService(db.DB, jwtSecret)
+	authService.SetTokenLifetimes(durationEnv("ACCESS_TOKEN_TTL", auth.DefaultAccessTokenTTL), durationEnv("REFRESH_TOKEN_TTL", auth.DefaultRefreshTokenTTL))
 	authHandler := auth.NewHandler(authService)
 
 	// Configure OAuth if credentials are provided
@@ -67,6 +68,20 @@ func main() {
 		log.Println("Google OAuth not configured (missing client ID or secret)")
 	}
 
+	// Configure OpenID Connect providers (Keycloak, Okta, ...) if a config file is provided
+	if oidcConfigFile := os.Getenv("OIDC_CONFIG_FILE"); oidcConfigFile != "" {
+		configs, err := auth.LoadOIDCConfig(oidcConfigFile)
+		if err != nil {
+			log.Fatalf("Failed to load OIDC providers: %v", err)
+		}
+		var providers []*auth.OIDCProvider
+		for _, config := range configs {
+			providers = append(providers, auth.NewOIDCProvider(config, &http.Client{Timeout: 10 * time.Second}))
+		}
+		authHandler.SetOIDCManager(auth.NewOIDCManager(providers...))
+		log.Printf("OIDC configured with %d provider(s)", len(providers))
+	}
+
 	// Setup routes
 	mux := http.NewServeMux()
 
@@ -77,14 +92,35 @@ func main() {
 	})
 	mux.HandleFunc("POST /api/auth/login", authHandler.Login)
 	mux.HandleFunc("GET /api/auth/verify", authHandler.VerifyToken)
+	mux.HandleFunc("POST /api/auth/refresh", authHandler.Refresh)
+	mux.HandleFunc("POST /api/auth/login/2fa", authHandler.CompleteTwoFactorLogin)
+	mux.HandleFunc("POST /api/auth/login/2fa/setup", authHandler.BeginLoginEnrollment)
 
 	// OAuth endpoints
 	mux.HandleFunc("GET /api/auth/google/login", authHandler.GoogleLogin)
 	mux.HandleFunc("GET /api/auth/google/callback", authHandler.GoogleCallback)
+	mux.HandleFunc("GET /api/auth/oidc/providers", authHandler.ListOIDCProviders)
+	mux.HandleFunc("GET /api/auth/oidc/{provider}/login", authHandler.OIDCLogin)
+	mux.HandleFunc("GET /api/auth/oidc/{provider}/callback", authHandler.OIDCCallback)
 
 	// Protected endpoints (require authentication)
 	authMiddleware := middleware.AuthMiddleware(authService)
 	mux.Handle("GET /api/auth/me", authMiddleware(http.HandlerFunc(authHandler.GetMe)))
+	mux.Handle("GET /api/auth/sessions", authMiddleware(http.HandlerFunc(authHandler.ListSessions)))
+	mux.Handle("POST /api/auth/logout", authMiddleware(http.HandlerFunc(authHandler.Logout)))
+	mux.Handle("POST /api/auth/logout-all", authMiddleware(http.HandlerFunc(authHandler.LogoutEverywhere)))
+
+	// Personal access tokens (authenticated users)
+	mux.Handle("GET /api/auth/tokens", authMiddleware(http.HandlerFunc(authHandler.ListAPITokens)))
+	mux.Handle("POST /api/auth/tokens", authMiddleware(http.HandlerFunc(authHandler.CreateAPIToken)))
+	mux.Handle("DELETE /api/auth/tokens/{id}", authMiddleware(http.HandlerFunc(authHandler.RevokeAPIToken)))
+
+	// Two-factor authentication (authenticated users)
+	mux.Handle("GET /api/auth/2fa", authMiddleware(http.HandlerFunc(authHandler.GetTwoFactorStatus)))
+	mux.Handle("POST /api/auth/2fa/enroll", authMiddleware(http.HandlerFunc(authHandler.BeginTOTPEnrollment)))
+	mux.Handle("POST /api/auth/2fa/enable", authMiddleware(http.HandlerFunc(authHandler.EnableTOTP)))
+	mux.Handle("POST /api/auth/2fa/disable", authMiddleware(http.HandlerFunc(authHandler.DisableTOTP)))
+	mux.Handle("POST /api/auth/2fa/recovery-codes", authMiddleware(http.HandlerFunc(authHandler.RegenerateRecoveryCodes)))
 
 	// Admin-only endpoints
 	adminOnly := middleware.AdminOnlyMiddleware
@@ -92,6 +128,15 @@ func main() {
 	mux.Handle("POST /api/users", authMiddleware(adminOnly(http.HandlerFunc(authHandler.CreateUser))))
 	mux.Handle("PUT /api/users/{id}", authMiddleware(adminOnly(http.HandlerFunc(authHandler.UpdateUser))))
 	mux.Handle("DELETE /api/users/{id}", authMiddleware(adminOnly(http.HandlerFunc(authHandler.DeleteUser))))
+	mux.Handle("DELETE /api/users/{id}/2fa", authMiddleware(adminOnly(http.HandlerFunc(authHandler.ResetUserTwoFactor))))
+	mux.Handle("GET /api/auth/2fa/policies", authMiddleware(adminOnly(http.HandlerFunc(authHandler.ListTwoFactorPolicies))))
+	mux.Handle("PUT /api/auth/2fa/policies/{role}", authMiddleware(adminOnly(http.HandlerFunc(authHandler.SetTwoFactorPolicy))))
+	mux.Handle("GET /api/service-accounts", authMiddleware(adminOnly(http.HandlerFunc(authHandler.ListServiceAccounts))))
+	mux.Handle("POST /api/service-accounts", authMiddleware(adminOnly(http.HandlerFunc(authHandler.CreateServiceAccount))))
+	mux.Handle("GET /api/service-accounts/{id}", authMiddleware(adminOnly(http.HandlerFunc(authHandler.GetServiceAccount))))
+	mux.Handle("DELETE /api/service-accounts/{id}", authMiddleware(adminOnly(http.HandlerFunc(authHandler.DeactivateServiceAccount))))
+	mux.Handle("POST /api/service-accounts/{id}/tokens", authMiddleware(adminOnly(http.HandlerFunc(authHandler.CreateServiceAccountToken))))
+	mux.Handle("DELETE /api/service-accounts/{id}/tokens/{tokenId}", authMiddleware(adminOnly(http.HandlerFunc(authHandler.RevokeServiceAccountToken))))
 
 	// Workspace sharing endpoints (authenticated users)
 	mux.Handle("GET /api/users/shareable", authMiddleware(http.HandlerFunc(authHandler.ListShareableUsers)))
@@ -100,6 +145,12 @@ func main() {
 	mux.Handle("DELETE /api/workspaces/{workspaceId}/shares/{userId}", authMiddleware(http.HandlerFunc(authHandler.RemoveWorkspaceShare)))
 	mux.Handle("GET /api/workspaces/shared-with-me", authMiddleware(http.HandlerFunc(authHandler.GetMySharedWorkspaces)))
 
+	// Workspace membership endpoints (roles are checked per workspace)
+	mux.Handle("GET /api/workspaces/{workspaceId}/members", authMiddleware(http.HandlerFunc(authHandler.ListWorkspaceMembers)))
+	mux.Handle("GET /api/workspaces/{workspaceId}/members/me", authMiddleware(http.HandlerFunc(authHandler.GetMyWorkspaceRole)))
+	mux.Handle("PUT /api/workspaces/{workspaceId}/members/{userId}", authMiddleware(http.HandlerFunc(authHandler.SetWorkspaceMember)))
+	mux.Handle("DELETE /api/workspaces/{workspaceId}/members/{userId}", authMiddleware(http.HandlerFunc(authHandler.RemoveWorkspaceMember)))
+
 	// Apply CORS middleware
 	handler := middleware.CORS(mux)
 
@@ -136,3 +187,16 @@ func main() {
 
 	log.Println("Server exited")
 }
+
+// durationEnv reads a duration such as "15m" or "720h" from the environment
+func durationEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Fatalf("Invalid %s %q: %v", name, value, err)
+	}
+	return d
+}
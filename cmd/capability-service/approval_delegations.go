@@ -0,0 +1,128 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// handleListApprovalDelegations lists delegations given or received by the
+// authenticated user. Admins may list another user's with ?user_id=, or every
+// delegation with ?user_id=all. ?active=true leaves out revoked and expired
+// delegations.
+func (s *Server) handleListApprovalDelegations(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r)
+	if userParam := r.URL.Query().Get("user_id"); userParam != "" {
+		otherID := 0
+		if userParam != "all" {
+			var err error
+			if otherID, err = strconv.Atoi(userParam); err != nil {
+				http.Error(w, "Invalid user_id", http.StatusBadRequest)
+				return
+			}
+		}
+		if otherID != userID && !requestIsAdmin(r) {
+			http.Error(w, "Only admins can list other users' approval delegations", http.StatusForbidden)
+			return
+		}
+		userID = otherID
+	}
+	activeOnly := r.URL.Query().Get("active") == "true"
+
+	delegations, err := s.approvalRepo.ListDelegations(userID, activeOnly)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list approval delegations: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"delegations": delegations,
+	})
+}
+
+// handleCreateApprovalDelegation hands the authenticated user's approve/reject
+// rights to another user until ends_at. Admins may delegate on behalf of
+// another user with delegator_id.
+func (s *Server) handleCreateApprovalDelegation(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateApprovalDelegationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID := requestUserID(r)
+
+	if req.DelegatorID == 0 || !requestIsAdmin(r) {
+		req.DelegatorID = userID
+	}
+	if req.DelegateID == 0 || req.DelegateID == req.DelegatorID {
+		http.Error(w, "delegate_id is required and must differ from delegator_id", http.StatusBadRequest)
+		return
+	}
+	startsAt := time.Now()
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	if !req.EndsAt.After(startsAt) || !req.EndsAt.After(time.Now()) {
+		http.Error(w, "ends_at must be in the future and after starts_at", http.StatusBadRequest)
+		return
+	}
+	for _, stage := range req.Stages {
+		if !models.IsValidStage(stage) {
+			http.Error(w, fmt.Sprintf("Invalid stage: %s", stage), http.StatusBadRequest)
+			return
+		}
+	}
+
+	delegation, err := s.approvalRepo.CreateDelegation(req, userID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create approval delegation: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(delegation)
+}
+
+// handleRevokeApprovalDelegation ends a delegation early. Only the delegator
+// or an admin may revoke it.
+func (s *Server) handleRevokeApprovalDelegation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid approval delegation ID", http.StatusBadRequest)
+		return
+	}
+
+	delegation, err := s.approvalRepo.GetDelegation(id)
+	if err != nil {
+		http.Error(w, "Approval delegation not found", http.StatusNotFound)
+		return
+	}
+	if delegation.DelegatorID != requestUserID(r) && !requestIsAdmin(r) {
+		http.Error(w, "Only the delegator or an admin can revoke an approval delegation", http.StatusForbidden)
+		return
+	}
+
+	delegation, err = s.approvalRepo.RevokeDelegation(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to revoke approval delegation: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delegation)
+}
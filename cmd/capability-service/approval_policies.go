@@ -0,0 +1,255 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/jareynolds/intentr/pkg/approvalpolicy"
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// writeApprovalVoteError writes a vote refused by the approval policy as JSON.
+// It returns false when err is some other error.
+func writeApprovalVoteError(w http.ResponseWriter, err error) bool {
+	var voteErr *models.ApprovalVoteError
+	if !errors.As(err, &voteErr) {
+		return false
+	}
+	status := http.StatusConflict
+	if voteErr.Code == models.VoteErrorRoleNotEligible || voteErr.Code == models.VoteErrorNoDelegation {
+		status = http.StatusForbidden
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(voteErr)
+	return true
+}
+
+// writeWorkspaceAccessError writes a 403 response for a *models.WorkspaceAccessError
+// and reports whether err was one
+func writeWorkspaceAccessError(w http.ResponseWriter, err error) bool {
+	var accessErr *models.WorkspaceAccessError
+	if !errors.As(err, &accessErr) {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":         "workspace-role-required",
+		"message":       accessErr.Error(),
+		"workspace_id":  accessErr.WorkspaceID,
+		"role":          accessErr.Role,
+		"required_role": accessErr.RequiredRole,
+	})
+	return true
+}
+
+// requireWorkspaceOwner writes a 403 response and returns false unless the
+// authenticated user owns the workspace. Only admins own the settings that
+// apply to every workspace (an empty workspaceID).
+func (s *Server) requireWorkspaceOwner(w http.ResponseWriter, r *http.Request, workspaceID string) bool {
+	if requestIsAdmin(r) {
+		return true
+	}
+	if workspaceID == "" {
+		http.Error(w, "Only admins can change settings for every workspace", http.StatusForbidden)
+		return false
+	}
+	if err := s.workspaceRepo.Require(workspaceID, requestUserID(r), models.WorkspaceRoleOwner); err != nil {
+		if !writeWorkspaceAccessError(w, err) {
+			http.Error(w, fmt.Sprintf("Failed to check workspace role: %v", err), http.StatusInternalServerError)
+		}
+		return false
+	}
+	return true
+}
+
+// requireWorkspaceViewer writes a 403 response and returns false unless the
+// authenticated user can view the workspace
+func (s *Server) requireWorkspaceViewer(w http.ResponseWriter, r *http.Request, workspaceID string) bool {
+	if err := s.workspaceRepo.Require(workspaceID, requestUserID(r), models.WorkspaceRoleViewer); err != nil {
+		if !writeWorkspaceAccessError(w, err) {
+			http.Error(w, fmt.Sprintf("Failed to check workspace role: %v", err), http.StatusInternalServerError)
+		}
+		return false
+	}
+	return true
+}
+
+// requireCapabilityViewer writes an error response and returns false unless
+// the authenticated user can view the capability's workspace
+func (s *Server) requireCapabilityViewer(w http.ResponseWriter, r *http.Request, capabilityID int) bool {
+	capability, err := s.capRepo.GetRef(capabilityID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get capability: %v", err), http.StatusInternalServerError)
+		return false
+	}
+	return s.requireWorkspaceViewer(w, r, capability.WorkspaceID)
+}
+
+// publishApprovalDecision announces an approval request once its policy has decided it
+func (s *Server) publishApprovalDecision(approval *models.CapabilityApproval) {
+	switch approval.Status {
+	case models.ApprovalStatusApproved:
+		s.publishApprovalWebhook(models.WebhookEventApprovalApproved, approval)
+	case models.ApprovalStatusRejected:
+		s.publishApprovalWebhook(models.WebhookEventApprovalRejected, approval)
+	}
+}
+
+// viewableWorkspaces returns a check of whether the user can view a
+// workspace, remembering each answer
+func (s *Server) viewableWorkspaces(userID int) func(workspaceID string) bool {
+	viewable := map[string]bool{}
+	return func(workspaceID string) bool {
+		canView, checked := viewable[workspaceID]
+		if !checked {
+			role, err := s.workspaceRepo.Role(workspaceID, userID)
+			if err != nil {
+				log.Printf("[viewableWorkspaces] FAILED to get role of user %d in workspace %q: %v", userID, workspaceID, err)
+			}
+			canView = err == nil && role.Allows(models.WorkspaceRoleViewer)
+			viewable[workspaceID] = canView
+		}
+		return canView
+	}
+}
+
+// handleListApprovalPolicies lists the policies of a workspace, or without
+// workspace_id those of every workspace the user can view
+func (s *Server) handleListApprovalPolicies(w http.ResponseWriter, r *http.Request) {
+	workspaceID := r.URL.Query().Get("workspace_id")
+	if workspaceID != "" && !s.requireWorkspaceViewer(w, r, workspaceID) {
+		return
+	}
+
+	policies, err := s.approvalRepo.ListPolicies(workspaceID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list approval policies: %v", err), http.StatusInternalServerError)
+		return
+	}
+	canView := s.viewableWorkspaces(requestUserID(r))
+	kept := policies[:0]
+	for _, p := range policies {
+		if canView(p.WorkspaceID) {
+			kept = append(kept, p)
+		}
+	}
+	policies = kept
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"workspace_id": workspaceID,
+		"policies":     policies,
+		"default":      models.DefaultApprovalPolicy(),
+	})
+}
+
+// handleSaveApprovalPolicy creates or replaces the policy for a workspace and
+// stage. It requires an owner of the workspace, or an admin for policies that
+// apply to every workspace.
+func (s *Server) handleSaveApprovalPolicy(w http.ResponseWriter, r *http.Request) {
+	var req models.SaveApprovalPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if req.Stage == "" {
+		req.Stage = "all"
+	}
+	if req.RequiredApprovals == 0 {
+		req.RequiredApprovals = 1
+	}
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if !s.requireWorkspaceOwner(w, r, req.WorkspaceID) {
+		return
+	}
+	err := approvalpolicy.Validate(models.ApprovalPolicy{
+		Stage:             req.Stage,
+		RequiredApprovals: req.RequiredApprovals,
+		EligibleRoles:     req.EligibleRoles,
+		ApprovalChain:     req.ApprovalChain,
+		VetoRoles:         req.VetoRoles,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	policy, err := s.approvalRepo.SavePolicy(req, requestUserID(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to save approval policy: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+func (s *Server) handleDeleteApprovalPolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid approval policy ID", http.StatusBadRequest)
+		return
+	}
+
+	policy, err := s.approvalRepo.GetPolicy(id)
+	if err != nil {
+		http.Error(w, "Approval policy not found", http.StatusNotFound)
+		return
+	}
+	if !s.requireWorkspaceOwner(w, r, policy.WorkspaceID) {
+		return
+	}
+
+	if err := s.approvalRepo.DeletePolicy(id); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to delete approval policy: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetApprovalVotes returns the individual votes on an approval request
+// and how far they are from satisfying its policy
+func (s *Server) handleGetApprovalVotes(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid approval ID", http.StatusBadRequest)
+		return
+	}
+
+	approval, err := s.approvalRepo.GetApprovalByID(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get approval: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if !s.requireCapabilityViewer(w, r, approval.CapabilityID) {
+		return
+	}
+
+	votes, err := s.approvalRepo.GetVotes(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get approval votes: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(votes)
+}
@@ -0,0 +1,168 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jareynolds/intentr/pkg/approvalsla"
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// publishApprovalSLAWebhook announces a reminder or overdue flag sent by the SLA scheduler
+func (s *Server) publishApprovalSLAWebhook(action string, approval models.PendingApprovalAge) {
+	eventType := models.WebhookEventApprovalEscalated
+	summary := fmt.Sprintf("%s approval for %q is overdue", approval.Stage, approval.CapabilityName)
+	switch action {
+	case approvalsla.ActionRemind:
+		eventType = models.WebhookEventApprovalReminder
+		summary = fmt.Sprintf("%s approval for %q has been pending for %.0f hours", approval.Stage, approval.CapabilityName, approval.AgeHours)
+	case approvalsla.ActionEscalate:
+		summary += fmt.Sprintf(" and was escalated to %s", approval.EscalatedToRole)
+	}
+	s.publishWebhook(eventType, approval.WorkspaceID, summary, map[string]interface{}{
+		"action":   action,
+		"approval": approval,
+	})
+}
+
+// handleListApprovalSLAs lists the SLAs of a workspace, or without
+// workspace_id those of every workspace the user can view
+func (s *Server) handleListApprovalSLAs(w http.ResponseWriter, r *http.Request) {
+	workspaceID := r.URL.Query().Get("workspace_id")
+	if workspaceID != "" && !s.requireWorkspaceViewer(w, r, workspaceID) {
+		return
+	}
+
+	slas, err := s.approvalRepo.ListSLAs(workspaceID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list approval SLAs: %v", err), http.StatusInternalServerError)
+		return
+	}
+	canView := s.viewableWorkspaces(requestUserID(r))
+	kept := slas[:0]
+	for _, sla := range slas {
+		if canView(sla.WorkspaceID) {
+			kept = append(kept, sla)
+		}
+	}
+	slas = kept
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"workspace_id": workspaceID,
+		"slas":         slas,
+	})
+}
+
+// handleSaveApprovalSLA creates or replaces the SLA for a workspace and stage.
+// SLAs escalate to global roles, so only admins may change them.
+func (s *Server) handleSaveApprovalSLA(w http.ResponseWriter, r *http.Request) {
+	if !requestIsAdmin(r) {
+		http.Error(w, "Only admins can change approval SLAs", http.StatusForbidden)
+		return
+	}
+
+	var req models.SaveApprovalSLARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if req.Stage == "" {
+		req.Stage = "all"
+	}
+	if req.Stage != "all" && !models.IsValidStage(req.Stage) {
+		http.Error(w, fmt.Sprintf("Invalid stage: %s", req.Stage), http.StatusBadRequest)
+		return
+	}
+	if req.DueHours <= 0 {
+		http.Error(w, "due_hours must be positive", http.StatusBadRequest)
+		return
+	}
+	if req.RemindAfterHours != nil && (*req.RemindAfterHours <= 0 || *req.RemindAfterHours >= req.DueHours) {
+		http.Error(w, "remind_after_hours must be positive and less than due_hours", http.StatusBadRequest)
+		return
+	}
+
+	sla, err := s.approvalRepo.SaveSLA(req, requestUserID(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to save approval SLA: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sla)
+}
+
+func (s *Server) handleDeleteApprovalSLA(w http.ResponseWriter, r *http.Request) {
+	if !requestIsAdmin(r) {
+		http.Error(w, "Only admins can change approval SLAs", http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid approval SLA ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.approvalRepo.DeleteSLA(id); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to delete approval SLA: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetApprovalAging lists pending capability approval requests by age
+// bucket, leaving out workspaces the user cannot view
+func (s *Server) handleGetApprovalAging(w http.ResponseWriter, r *http.Request) {
+	workspaceID := r.URL.Query().Get("workspace_id")
+	if workspaceID != "" && !s.requireWorkspaceViewer(w, r, workspaceID) {
+		return
+	}
+
+	approvals, err := s.approvalRepo.ListPendingApprovalAges(workspaceID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get pending approvals: %v", err), http.StatusInternalServerError)
+		return
+	}
+	approvals = s.viewableApprovalAges(approvals, requestUserID(r))
+
+	now := time.Now()
+	response := models.ApprovalAgingResponse{
+		WorkspaceID: workspaceID,
+		GeneratedAt: now,
+		Buckets:     approvalsla.GroupByAge(approvals, now),
+	}
+	for _, b := range response.Buckets {
+		response.TotalCount += b.Count
+		response.OverdueCount += b.Overdue
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// viewableApprovalAges keeps the approvals in workspaces the user can view
+func (s *Server) viewableApprovalAges(approvals []models.PendingApprovalAge, userID int) []models.PendingApprovalAge {
+	canView := s.viewableWorkspaces(userID)
+	kept := approvals[:0]
+	for _, a := range approvals {
+		if canView(a.WorkspaceID) {
+			kept = append(kept, a)
+		}
+	}
+	return kept
+}
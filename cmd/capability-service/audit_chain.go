@@ -0,0 +1,180 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jareynolds/intentr/internal/auth"
+	"github.com/jareynolds/intentr/pkg/auditchain"
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// complianceRole is the global role that, besides admin, may verify and
+// export the audit trail
+const complianceRole = "compliance"
+
+// requireAuditor writes a 403 response and returns false unless the
+// authenticated user is an admin or has the compliance role
+func requireAuditor(w http.ResponseWriter, r *http.Request) bool {
+	claims := r.Context().Value("claims").(*auth.Claims)
+	if claims.IsAdmin() || claims.Role == complianceRole {
+		return true
+	}
+	http.Error(w, "Only admins and compliance users can read the audit trail", http.StatusForbidden)
+	return false
+}
+
+// loadAuditSigningKey reads the audit export signing key from
+// AUDIT_SIGNING_KEY (a base64 Ed25519 seed). Without it a key is generated,
+// and exports signed before a restart can no longer be checked against the
+// published key.
+func loadAuditSigningKey() ed25519.PrivateKey {
+	if encoded := os.Getenv("AUDIT_SIGNING_KEY"); encoded != "" {
+		key, err := auditchain.LoadKey(encoded)
+		if err != nil {
+			log.Fatalf("Failed to load AUDIT_SIGNING_KEY: %v", err)
+		}
+		return key
+	}
+	key, _, err := auditchain.GenerateKey()
+	if err != nil {
+		log.Fatalf("Failed to generate audit signing key: %v", err)
+	}
+	log.Printf("Warning: AUDIT_SIGNING_KEY is not set, audit exports are signed with a temporary key")
+	return key
+}
+
+// parseAuditTime accepts RFC 3339 timestamps and YYYY-MM-DD dates. A date
+// used as an end bound covers that whole day.
+func parseAuditTime(value string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD or RFC 3339", value)
+	}
+	if end {
+		t = t.Add(24 * time.Hour)
+	}
+	return t, nil
+}
+
+// handleVerifyAuditChain walks the audit hash chain of ?workspace_id= and
+// reports the first broken link
+func (s *Server) handleVerifyAuditChain(w http.ResponseWriter, r *http.Request) {
+	if !requireAuditor(w, r) {
+		return
+	}
+	workspaceID := r.URL.Query().Get("workspace_id")
+
+	// Read the head first; records linked after it are left for the next run
+	headSeq, headHash, err := s.auditRepo.Head(workspaceID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to verify audit chain: %v", err), http.StatusInternalServerError)
+		return
+	}
+	records, err := s.auditRepo.ListRecords(workspaceID, nil, nil)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to verify audit chain: %v", err), http.StatusInternalServerError)
+		return
+	}
+	for len(records) > 0 && records[len(records)-1].Seq > headSeq {
+		records = records[:len(records)-1]
+	}
+
+	firstBreak := auditchain.Verify(records, auditchain.Link{}, &auditchain.Link{Seq: headSeq, Hash: headHash})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.AuditChainVerification{
+		WorkspaceID: workspaceID,
+		Verified:    firstBreak == nil,
+		RecordCount: len(records),
+		HeadSeq:     headSeq,
+		HeadHash:    headHash,
+		FirstBreak:  firstBreak,
+		VerifiedAt:  time.Now().UTC(),
+	})
+}
+
+// handleExportAuditChain returns the audit records of ?workspace_id= recorded
+// between ?from= and ?to= (default now), signed with the service's key
+func (s *Server) handleExportAuditChain(w http.ResponseWriter, r *http.Request) {
+	if !requireAuditor(w, r) {
+		return
+	}
+	query := r.URL.Query()
+	workspaceID := query.Get("workspace_id")
+
+	if query.Get("from") == "" {
+		http.Error(w, "from is required", http.StatusBadRequest)
+		return
+	}
+	from, err := parseAuditTime(query.Get("from"), false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	to := time.Now().UTC()
+	if query.Get("to") != "" {
+		if to, err = parseAuditTime(query.Get("to"), true); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if !to.After(from) {
+		http.Error(w, "to must be after from", http.StatusBadRequest)
+		return
+	}
+
+	records, err := s.auditRepo.ListRecords(workspaceID, &from, &to)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to export audit chain: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	export := models.AuditExport{
+		WorkspaceID: workspaceID,
+		From:        from,
+		To:          to,
+		GeneratedAt: time.Now().UTC(),
+		RecordCount: len(records),
+		Records:     records,
+	}
+	if len(records) > 0 {
+		start := auditchain.Link{Seq: records[0].Seq - 1, Hash: records[0].PrevHash}
+		export.FirstBreak = auditchain.Verify(records, start, nil)
+	}
+	export.ChainVerified = export.FirstBreak == nil
+	if err := auditchain.Sign(s.auditKey, &export); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to sign audit export: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-%s-%s-%s.json"`,
+		workspaceID, from.Format("20060102"), to.Format("20060102")))
+	json.NewEncoder(w).Encode(export)
+}
+
+// handleGetAuditSigningKey publishes the public key that audit exports are signed with
+func (s *Server) handleGetAuditSigningKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"algorithm":  auditchain.SignatureAlgorithm,
+		"public_key": auditchain.PublicKey(s.auditKey),
+	})
+}
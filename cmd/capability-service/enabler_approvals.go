@@ -0,0 +1,344 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// Enabler approval handlers mirror the capability approval handlers in
+// main.go: /enabler-approvals/... for requests and decisions, and
+// /enablers/{id}/approvals and /enablers/{id}/audit-log for history.
+
+func enablerApprovalIDFromPath(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid approval ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// requireEnablerViewer writes an error response and returns false unless the
+// authenticated user can view the enabler's workspace
+func (s *Server) requireEnablerViewer(w http.ResponseWriter, r *http.Request, enablerID int) bool {
+	enabler, err := s.enablerRepo.GetRef(enablerID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get enabler: %v", err), http.StatusInternalServerError)
+		return false
+	}
+	return s.requireWorkspaceViewer(w, r, enabler.WorkspaceID)
+}
+
+// publishEnablerApprovalWebhook announces an approval decision on an enabler or requirement
+func (s *Server) publishEnablerApprovalWebhook(eventType string, approval *models.EnablerApproval) {
+	enabler, err := s.enablerRepo.GetRef(approval.EnablerID)
+	if err != nil {
+		log.Printf("[publishEnablerApprovalWebhook] FAILED to load enabler %d: %v", approval.EnablerID, err)
+		return
+	}
+
+	subject := fmt.Sprintf("%s %q", enabler.EnablerID, enabler.Name)
+	data := map[string]interface{}{
+		"enabler_approval": approval,
+		"enabler": map[string]interface{}{
+			"id":            enabler.ID,
+			"enabler_id":    enabler.EnablerID,
+			"name":          enabler.Name,
+			"capability_id": enabler.CapabilityID,
+		},
+	}
+	if approval.RequirementID != nil {
+		if req, err := s.enablerRepo.GetRequirementByID(*approval.RequirementID); err == nil {
+			subject = fmt.Sprintf("requirement %s of %s", req.RequirementID, subject)
+			data["requirement"] = map[string]interface{}{
+				"id":             req.ID,
+				"requirement_id": req.RequirementID,
+				"name":           req.Name,
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("%s approval for %s was %s", approval.Stage, subject, approval.Status)
+	if approval.Feedback != nil && *approval.Feedback != "" {
+		summary += ": " + *approval.Feedback
+	}
+	s.publishWebhook(eventType, enabler.WorkspaceID, summary, data)
+}
+
+func (s *Server) publishEnablerApprovalDecision(approval *models.EnablerApproval) {
+	switch models.ApprovalStatus(approval.Status) {
+	case models.ApprovalStatusApproved:
+		s.publishEnablerApprovalWebhook(models.WebhookEventApprovalApproved, approval)
+	case models.ApprovalStatusRejected:
+		s.publishEnablerApprovalWebhook(models.WebhookEventApprovalRejected, approval)
+	}
+}
+
+func (s *Server) handleRequestEnablerApproval(w http.ResponseWriter, r *http.Request) {
+	var req models.RequestEnablerApprovalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Validate stage
+	if !models.IsValidStage(req.Stage) {
+		http.Error(w, "Invalid stage. Must be one of: specification, definition, design, execution", http.StatusBadRequest)
+		return
+	}
+
+	userID := requestUserID(r)
+
+	approval, err := s.approvalRepo.RequestEnablerApproval(req.EnablerID, req.RequirementID, req.Stage, userID)
+	if err != nil {
+		if writeWorkspaceAccessError(w, err) || writeStateTransitionError(w, err) {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to request approval: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(approval)
+}
+
+func (s *Server) handleGetPendingEnablerApprovals(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r)
+
+	approvals, err := s.approvalRepo.GetPendingEnablerApprovals()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get pending approvals: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := models.PendingEnablerApprovalsResponse{
+		Approvals:  make([]models.EnablerApprovalResponse, len(approvals)),
+		TotalCount: len(approvals),
+		ByStage:    make(map[string]int),
+	}
+
+	// Enrich each approval with enabler, capability and requirement names
+	for i, a := range approvals {
+		response.ByStage[a.Stage]++
+		item := models.EnablerApprovalResponse{
+			Approval:    &approvals[i],
+			CanWithdraw: a.RequestedBy == userID,
+		}
+		if enabler, err := s.enablerRepo.GetRef(a.EnablerID); err == nil {
+			item.EnablerName = enabler.Name
+			item.CanApprove = s.canApproveIn(enabler.WorkspaceID, userID)
+			item.CanReject = item.CanApprove
+			if cap, err := s.capRepo.GetRef(enabler.CapabilityID); err == nil {
+				item.CapabilityName = cap.Name
+			}
+		}
+		if a.RequirementID != nil {
+			if req, err := s.enablerRepo.GetRequirementByID(*a.RequirementID); err == nil {
+				item.RequirementName = req.Name
+			}
+		}
+		response.Approvals[i] = item
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) handleGetEnablerApproval(w http.ResponseWriter, r *http.Request) {
+	id, ok := enablerApprovalIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	approval, err := s.approvalRepo.GetEnablerApprovalByID(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get approval: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if !s.requireEnablerViewer(w, r, approval.EnablerID) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(approval)
+}
+
+func (s *Server) handleApproveEnabler(w http.ResponseWriter, r *http.Request) {
+	id, ok := enablerApprovalIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ApprovalDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		// Feedback is optional for approval, so ignore decode errors
+		req = models.ApprovalDecisionRequest{}
+	}
+
+	userID := requestUserID(r)
+
+	approval, err := s.approvalRepo.ApproveEnabler(id, userID, req.OnBehalfOf, req.Feedback)
+	if err != nil {
+		if writeApprovalVoteError(w, err) || writeWorkspaceAccessError(w, err) || writeStateTransitionError(w, err) {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to approve: %v", err), http.StatusInternalServerError)
+		return
+	}
+	s.publishEnablerApprovalDecision(approval)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(approval)
+}
+
+func (s *Server) handleRejectEnabler(w http.ResponseWriter, r *http.Request) {
+	id, ok := enablerApprovalIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ApprovalDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if req.Feedback == "" {
+		http.Error(w, "Feedback is required when rejecting", http.StatusBadRequest)
+		return
+	}
+
+	userID := requestUserID(r)
+
+	approval, err := s.approvalRepo.RejectEnabler(id, userID, req.OnBehalfOf, req.Feedback)
+	if err != nil {
+		if writeApprovalVoteError(w, err) || writeWorkspaceAccessError(w, err) || writeStateTransitionError(w, err) {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to reject: %v", err), http.StatusInternalServerError)
+		return
+	}
+	s.publishEnablerApprovalDecision(approval)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(approval)
+}
+
+func (s *Server) handleWithdrawEnabler(w http.ResponseWriter, r *http.Request) {
+	id, ok := enablerApprovalIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	userID := requestUserID(r)
+
+	approval, err := s.approvalRepo.WithdrawEnabler(id, userID)
+	if err != nil {
+		if writeStateTransitionError(w, err) {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to withdraw: %v", err), http.StatusInternalServerError)
+		return
+	}
+	s.publishEnablerApprovalWebhook(models.WebhookEventApprovalWithdrawn, approval)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(approval)
+}
+
+func (s *Server) handleGetEnablerApprovalVotes(w http.ResponseWriter, r *http.Request) {
+	id, ok := enablerApprovalIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	approval, err := s.approvalRepo.GetEnablerApprovalByID(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get approval: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if !s.requireEnablerViewer(w, r, approval.EnablerID) {
+		return
+	}
+
+	votes, err := s.approvalRepo.GetEnablerVotes(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get approval votes: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(votes)
+}
+
+func (s *Server) handleGetEnablerApprovalHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid enabler ID", http.StatusBadRequest)
+		return
+	}
+	if !s.requireEnablerViewer(w, r, id) {
+		return
+	}
+
+	approvals, err := s.approvalRepo.GetEnablerApprovalHistory(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get approval history: %v", err), http.StatusInternalServerError)
+		return
+	}
+	auditLog, err := s.approvalRepo.GetEnablerAuditLog(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get audit log: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := models.EnablerApprovalHistoryResponse{
+		EnablerID: id,
+		Approvals: approvals,
+		AuditLog:  auditLog,
+	}
+	if enabler, err := s.enablerRepo.GetByID(id); err == nil {
+		response.EnablerName = enabler.Name
+		response.ApprovalStatus = enabler.ApprovalStatus
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) handleGetEnablerAuditLog(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid enabler ID", http.StatusBadRequest)
+		return
+	}
+	if !s.requireEnablerViewer(w, r, id) {
+		return
+	}
+
+	logs, err := s.approvalRepo.GetEnablerAuditLog(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get audit log: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"enabler_id": id,
+		"audit_log":  logs,
+	})
+}
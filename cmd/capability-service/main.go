@@ -10,7 +10,9 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -21,9 +23,16 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/jareynolds/intentr/internal/auth"
+	"github.com/jareynolds/intentr/pkg/approvalsla"
+	"github.com/jareynolds/intentr/pkg/changefeed"
 	"github.com/jareynolds/intentr/pkg/database"
+	"github.com/jareynolds/intentr/pkg/idalloc"
+	"github.com/jareynolds/intentr/pkg/middleware"
 	"github.com/jareynolds/intentr/pkg/models"
 	"github.com/jareynolds/intentr/pkg/repository"
+	"github.com/jareynolds/intentr/pkg/statemodel"
+	"github.com/jareynolds/intentr/pkg/webhook"
 )
 
 type Server struct {
@@ -32,6 +41,14 @@ type Server struct {
 	enablerRepo     *repository.EnablerRepository
 	criteriaRepo    *repository.AcceptanceCriteriaRepository
 	entityStateRepo *repository.EntityStateRepository
+	changeEventRepo *repository.ChangeEventRepository
+	changeFeed      *changefeed.Feed // nil when LISTEN is unavailable; streams poll instead
+	webhookRepo     *repository.WebhookRepository
+	webhooks        *webhook.Dispatcher
+	ids             *idalloc.Allocator
+	auditRepo       *repository.AuditChainRepository
+	auditKey        ed25519.PrivateKey // Signs audit exports
+	workspaceRepo   *repository.WorkspaceMembershipRepository
 }
 
 func main() {
@@ -70,14 +87,48 @@ func main() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
+	idSequenceRepo := repository.NewIDSequenceRepository(db.DB)
+	webhookRepo := repository.NewWebhookRepository(db.DB)
+
+	changeFeed, err := changefeed.Listen(dsn)
+	if err != nil {
+		log.Printf("Warning: change feed notifications unavailable, event streams will poll: %v", err)
+	}
+
 	server := &Server{
 		capRepo:         repository.NewCapabilityRepository(db.DB),
 		approvalRepo:    repository.NewApprovalRepository(db.DB),
 		enablerRepo:     repository.NewEnablerRepository(db.DB),
 		criteriaRepo:    repository.NewAcceptanceCriteriaRepository(db.DB),
 		entityStateRepo: repository.NewEntityStateRepository(db.DB),
+		changeEventRepo: repository.NewChangeEventRepository(db.DB),
+		changeFeed:      changeFeed,
+		webhookRepo:     webhookRepo,
+		webhooks:        webhook.NewDispatcher(webhookRepo),
+		ids:             idalloc.New(idSequenceRepo, idSequenceRepo),
+		auditRepo:       repository.NewAuditChainRepository(db.DB),
+		auditKey:        loadAuditSigningKey(),
+		workspaceRepo:   repository.NewWorkspaceMembershipRepository(db.DB),
+	}
+
+	// Approvals are requested and decided as the authenticated user, whose
+	// workspace role is checked
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		log.Fatal("JWT_SECRET environment variable is required")
+	}
+	authenticate := middleware.AuthMiddleware(auth.NewService(db.DB, jwtSecret))
+	requireAuth := func(next http.HandlerFunc) http.HandlerFunc {
+		return authenticate(next).ServeHTTP
 	}
 
+	dispatchCtx, stopDispatch := context.WithCancel(context.Background())
+	go server.webhooks.Run(dispatchCtx)
+
+	slaScheduler := approvalsla.NewScheduler(server.approvalRepo)
+	slaScheduler.Notify = server.publishApprovalSLAWebhook
+	go slaScheduler.Run(dispatchCtx)
+
 	mux := http.NewServeMux()
 
 	// Enable CORS
@@ -85,7 +136,7 @@ func main() {
 		return func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Last-Event-ID")
 
 			if r.Method == "OPTIONS" {
 				w.WriteHeader(http.StatusOK)
@@ -193,8 +244,8 @@ func main() {
 	}))
 
 	// Approval endpoints - static paths first to avoid conflicts
-	mux.HandleFunc("POST /approvals/request", corsMiddleware(server.handleRequestApproval))
-	mux.HandleFunc("GET /approvals/pending", corsMiddleware(server.handleGetPendingApprovals))
+	mux.HandleFunc("POST /approvals/request", corsMiddleware(requireAuth(server.handleRequestApproval)))
+	mux.HandleFunc("GET /approvals/pending", corsMiddleware(requireAuth(server.handleGetPendingApprovals)))
 	mux.HandleFunc("GET /approvals/rules", corsMiddleware(server.handleGetWorkflowRules))
 	mux.HandleFunc("OPTIONS /approvals/request", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -207,16 +258,16 @@ func main() {
 	}))
 
 	// Role permissions endpoint - separate path to avoid conflict with /approvals/{id}
-	mux.HandleFunc("GET /approval-permissions/{role}", corsMiddleware(server.handleGetUserPermissions))
+	mux.HandleFunc("GET /approval-permissions/{role}", corsMiddleware(requireAuth(server.handleGetUserPermissions)))
 	mux.HandleFunc("OPTIONS /approval-permissions/{role}", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
 	// Approval action endpoints with numeric ID
 	mux.HandleFunc("GET /approvals/{id}", corsMiddleware(server.handleGetApproval))
-	mux.HandleFunc("POST /approvals/{id}/approve", corsMiddleware(server.handleApprove))
-	mux.HandleFunc("POST /approvals/{id}/reject", corsMiddleware(server.handleReject))
-	mux.HandleFunc("POST /approvals/{id}/withdraw", corsMiddleware(server.handleWithdraw))
+	mux.HandleFunc("POST /approvals/{id}/approve", corsMiddleware(requireAuth(server.handleApprove)))
+	mux.HandleFunc("POST /approvals/{id}/reject", corsMiddleware(requireAuth(server.handleReject)))
+	mux.HandleFunc("POST /approvals/{id}/withdraw", corsMiddleware(requireAuth(server.handleWithdraw)))
 	mux.HandleFunc("OPTIONS /approvals/{id}", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
@@ -229,9 +280,102 @@ func main() {
 	mux.HandleFunc("OPTIONS /approvals/{id}/withdraw", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
+	mux.HandleFunc("GET /approvals/{id}/votes", corsMiddleware(requireAuth(server.handleGetApprovalVotes)))
+	mux.HandleFunc("OPTIONS /approvals/{id}/votes", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
 
-	mux.HandleFunc("GET /capabilities/{id}/approvals", corsMiddleware(server.handleGetApprovalHistory))
-	mux.HandleFunc("GET /capabilities/{id}/audit-log", corsMiddleware(server.handleGetAuditLog))
+	// Enabler approval endpoints (enablers and individual requirements)
+	mux.HandleFunc("POST /enabler-approvals/request", corsMiddleware(requireAuth(server.handleRequestEnablerApproval)))
+	mux.HandleFunc("OPTIONS /enabler-approvals/request", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("GET /enabler-approvals/pending", corsMiddleware(requireAuth(server.handleGetPendingEnablerApprovals)))
+	mux.HandleFunc("OPTIONS /enabler-approvals/pending", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("GET /enabler-approvals/{id}", corsMiddleware(requireAuth(server.handleGetEnablerApproval)))
+	mux.HandleFunc("OPTIONS /enabler-approvals/{id}", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("POST /enabler-approvals/{id}/approve", corsMiddleware(requireAuth(server.handleApproveEnabler)))
+	mux.HandleFunc("OPTIONS /enabler-approvals/{id}/approve", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("POST /enabler-approvals/{id}/reject", corsMiddleware(requireAuth(server.handleRejectEnabler)))
+	mux.HandleFunc("OPTIONS /enabler-approvals/{id}/reject", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("POST /enabler-approvals/{id}/withdraw", corsMiddleware(requireAuth(server.handleWithdrawEnabler)))
+	mux.HandleFunc("OPTIONS /enabler-approvals/{id}/withdraw", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("GET /enabler-approvals/{id}/votes", corsMiddleware(requireAuth(server.handleGetEnablerApprovalVotes)))
+	mux.HandleFunc("OPTIONS /enabler-approvals/{id}/votes", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("GET /enablers/{id}/approvals", corsMiddleware(requireAuth(server.handleGetEnablerApprovalHistory)))
+	mux.HandleFunc("OPTIONS /enablers/{id}/approvals", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("GET /enablers/{id}/audit-log", corsMiddleware(requireAuth(server.handleGetEnablerAuditLog)))
+	mux.HandleFunc("OPTIONS /enablers/{id}/audit-log", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Approval policy endpoints (quorum, ordered approval chains, veto roles)
+	mux.HandleFunc("GET /approval-policies", corsMiddleware(requireAuth(server.handleListApprovalPolicies)))
+	mux.HandleFunc("PUT /approval-policies", corsMiddleware(requireAuth(server.handleSaveApprovalPolicy)))
+	mux.HandleFunc("OPTIONS /approval-policies", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("DELETE /approval-policies/{id}", corsMiddleware(requireAuth(server.handleDeleteApprovalPolicy)))
+	mux.HandleFunc("OPTIONS /approval-policies/{id}", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Audit trail endpoints (hash chain verification and signed export)
+	mux.HandleFunc("GET /audit/verify", corsMiddleware(requireAuth(server.handleVerifyAuditChain)))
+	mux.HandleFunc("OPTIONS /audit/verify", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("GET /audit/export", corsMiddleware(requireAuth(server.handleExportAuditChain)))
+	mux.HandleFunc("OPTIONS /audit/export", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("GET /audit/signing-key", corsMiddleware(server.handleGetAuditSigningKey))
+	mux.HandleFunc("OPTIONS /audit/signing-key", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Approval delegation endpoints (time-bounded hand-over of approve/reject rights)
+	mux.HandleFunc("GET /approval-delegations", corsMiddleware(requireAuth(server.handleListApprovalDelegations)))
+	mux.HandleFunc("POST /approval-delegations", corsMiddleware(requireAuth(server.handleCreateApprovalDelegation)))
+	mux.HandleFunc("OPTIONS /approval-delegations", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("DELETE /approval-delegations/{id}", corsMiddleware(requireAuth(server.handleRevokeApprovalDelegation)))
+	mux.HandleFunc("OPTIONS /approval-delegations/{id}", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Approval SLA endpoints (reminders, overdue escalation, aging report)
+	mux.HandleFunc("GET /approval-slas", corsMiddleware(requireAuth(server.handleListApprovalSLAs)))
+	mux.HandleFunc("PUT /approval-slas", corsMiddleware(requireAuth(server.handleSaveApprovalSLA)))
+	mux.HandleFunc("OPTIONS /approval-slas", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("DELETE /approval-slas/{id}", corsMiddleware(requireAuth(server.handleDeleteApprovalSLA)))
+	mux.HandleFunc("OPTIONS /approval-slas/{id}", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("GET /approvals/aging", corsMiddleware(requireAuth(server.handleGetApprovalAging)))
+	mux.HandleFunc("OPTIONS /approvals/aging", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	mux.HandleFunc("GET /capabilities/{id}/approvals", corsMiddleware(requireAuth(server.handleGetApprovalHistory)))
+	mux.HandleFunc("GET /capabilities/{id}/audit-log", corsMiddleware(requireAuth(server.handleGetAuditLog)))
 	mux.HandleFunc("OPTIONS /capabilities/{id}/approvals", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
@@ -263,6 +407,14 @@ func main() {
 	mux.HandleFunc("OPTIONS /state/storycard", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
+	mux.HandleFunc("GET /state/events/{workspaceId}", corsMiddleware(requireAuth(server.handleStreamWorkspaceEvents)))
+	mux.HandleFunc("OPTIONS /state/events/{workspaceId}", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("GET /state/transitions", corsMiddleware(server.handleGetStateTransitions))
+	mux.HandleFunc("OPTIONS /state/transitions", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
 	mux.HandleFunc("GET /state/history/{entityType}/{entityId}", corsMiddleware(server.handleGetStateHistory))
 	mux.HandleFunc("OPTIONS /state/history/{entityType}/{entityId}", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -295,11 +447,11 @@ func main() {
 	mux.HandleFunc("OPTIONS /state/phase/{workspaceId}/{phase}", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
-	mux.HandleFunc("POST /state/phase/{workspaceId}/{phase}/approve", corsMiddleware(server.handleApprovePhase))
+	mux.HandleFunc("POST /state/phase/{workspaceId}/{phase}/approve", corsMiddleware(requireAuth(server.handleApprovePhase)))
 	mux.HandleFunc("OPTIONS /state/phase/{workspaceId}/{phase}/approve", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
-	mux.HandleFunc("POST /state/phase/{workspaceId}/{phase}/revoke", corsMiddleware(server.handleRevokePhaseApproval))
+	mux.HandleFunc("POST /state/phase/{workspaceId}/{phase}/revoke", corsMiddleware(requireAuth(server.handleRevokePhaseApproval)))
 	mux.HandleFunc("OPTIONS /state/phase/{workspaceId}/{phase}/revoke", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
@@ -308,6 +460,35 @@ func main() {
 		w.WriteHeader(http.StatusOK)
 	}))
 
+	// Webhook endpoints
+	mux.HandleFunc("GET /webhooks", corsMiddleware(requireAuth(server.handleListWebhooks)))
+	mux.HandleFunc("POST /webhooks", corsMiddleware(requireAuth(server.handleCreateWebhook)))
+	mux.HandleFunc("OPTIONS /webhooks", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("GET /webhooks/events", corsMiddleware(requireAuth(server.handleGetWebhookEventTypes)))
+	mux.HandleFunc("OPTIONS /webhooks/events", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("GET /webhooks/{id}", corsMiddleware(requireAuth(server.handleGetWebhook)))
+	mux.HandleFunc("PUT /webhooks/{id}", corsMiddleware(requireAuth(server.handleUpdateWebhook)))
+	mux.HandleFunc("DELETE /webhooks/{id}", corsMiddleware(requireAuth(server.handleDeleteWebhook)))
+	mux.HandleFunc("OPTIONS /webhooks/{id}", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("POST /webhooks/{id}/ping", corsMiddleware(requireAuth(server.handlePingWebhook)))
+	mux.HandleFunc("OPTIONS /webhooks/{id}/ping", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("GET /webhooks/{id}/deliveries", corsMiddleware(requireAuth(server.handleGetWebhookDeliveries)))
+	mux.HandleFunc("OPTIONS /webhooks/{id}/deliveries", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	mux.HandleFunc("POST /webhooks/deliveries/{deliveryId}/replay", corsMiddleware(requireAuth(server.handleReplayWebhookDelivery)))
+	mux.HandleFunc("OPTIONS /webhooks/deliveries/{deliveryId}/replay", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
 	httpServer := &http.Server{
 		Addr:         fmt.Sprintf(":%s", port),
 		Handler:      mux,
@@ -315,6 +496,10 @@ func main() {
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	if changeFeed != nil {
+		// Ends open event streams so Shutdown does not wait on them
+		httpServer.RegisterOnShutdown(func() { changeFeed.Close() })
+	}
 
 	go func() {
 		log.Printf("Capability service starting on port %s", port)
@@ -332,6 +517,7 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	stopDispatch()
 	httpServer.SetKeepAlivesEnabled(false)
 	if err := httpServer.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
@@ -382,6 +568,16 @@ func (s *Server) handleCreateCapability(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Capabilities created without an ID get the next free CAP- ID
+	if req.CapabilityID == "" {
+		id, err := s.ids.Next("", idalloc.PrefixCapability)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to allocate capability ID: %v", err), http.StatusInternalServerError)
+			return
+		}
+		req.CapabilityID = id
+	}
+
 	// For now, use a default user ID (1 = admin)
 	// In production, this should come from authentication middleware
 	userID := 1
@@ -442,6 +638,27 @@ func (s *Server) handleDeleteCapability(w http.ResponseWriter, r *http.Request)
 
 // Approval Handlers
 
+// requestUserID returns the authenticated user of a request wrapped in requireAuth
+func requestUserID(r *http.Request) int {
+	return r.Context().Value("claims").(*auth.Claims).UserID
+}
+
+// requestIsAdmin reports whether the authenticated user of a request wrapped
+// in requireAuth is an admin
+func requestIsAdmin(r *http.Request) bool {
+	return r.Context().Value("claims").(*auth.Claims).IsAdmin()
+}
+
+// canApproveIn reports whether a user may decide approvals in a workspace
+func (s *Server) canApproveIn(workspaceID string, userID int) bool {
+	role, err := s.workspaceRepo.Role(workspaceID, userID)
+	if err != nil {
+		log.Printf("[canApproveIn] FAILED to get role of user %d in workspace %q: %v", userID, workspaceID, err)
+		return false
+	}
+	return role.Allows(models.WorkspaceRoleApprover)
+}
+
 func (s *Server) handleRequestApproval(w http.ResponseWriter, r *http.Request) {
 	var req models.RequestApprovalRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -455,12 +672,13 @@ func (s *Server) handleRequestApproval(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// For now, use a default user ID (1 = admin)
-	// In production, this should come from authentication middleware
-	userID := 1
+	userID := requestUserID(r)
 
 	approval, err := s.approvalRepo.RequestApproval(req.CapabilityID, req.Stage, userID)
 	if err != nil {
+		if writeWorkspaceAccessError(w, err) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("Failed to request approval: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -471,6 +689,8 @@ func (s *Server) handleRequestApproval(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleGetPendingApprovals(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r)
+
 	approvals, err := s.approvalRepo.GetPendingApprovals()
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get pending approvals: %v", err), http.StatusInternalServerError)
@@ -492,17 +712,18 @@ func (s *Server) handleGetPendingApprovals(w http.ResponseWriter, r *http.Reques
 	// Enrich each approval with capability name and permissions
 	for i, a := range approvals {
 		cap, _ := s.capRepo.GetByID(a.CapabilityID)
-		capName := ""
+		capName, workspaceID := "", ""
 		if cap != nil {
-			capName = cap.Name
+			capName, workspaceID = cap.Name, cap.WorkspaceID
 		}
+		canDecide := s.canApproveIn(workspaceID, userID)
 
 		response.Approvals[i] = models.ApprovalResponse{
 			Approval:       &approvals[i],
 			CapabilityName: capName,
-			CanApprove:     true,  // Would check user permissions in production
-			CanReject:      true,  // Would check user permissions in production
-			CanWithdraw:    false, // Only requester can withdraw
+			CanApprove:     canDecide,
+			CanReject:      canDecide,
+			CanWithdraw:    a.RequestedBy == userID,
 		}
 	}
 
@@ -545,14 +766,17 @@ func (s *Server) handleApprove(w http.ResponseWriter, r *http.Request) {
 		req = models.ApprovalDecisionRequest{}
 	}
 
-	// For now, use a default user ID (1 = admin)
-	userID := 1
+	userID := requestUserID(r)
 
-	approval, err := s.approvalRepo.Approve(id, userID, req.Feedback)
+	approval, err := s.approvalRepo.Approve(id, userID, req.OnBehalfOf, req.Feedback)
 	if err != nil {
+		if writeApprovalVoteError(w, err) || writeWorkspaceAccessError(w, err) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("Failed to approve: %v", err), http.StatusInternalServerError)
 		return
 	}
+	s.publishApprovalDecision(approval)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(approval)
@@ -580,14 +804,17 @@ func (s *Server) handleReject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// For now, use a default user ID (1 = admin)
-	userID := 1
+	userID := requestUserID(r)
 
-	approval, err := s.approvalRepo.Reject(id, userID, req.Feedback)
+	approval, err := s.approvalRepo.Reject(id, userID, req.OnBehalfOf, req.Feedback)
 	if err != nil {
+		if writeApprovalVoteError(w, err) || writeWorkspaceAccessError(w, err) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("Failed to reject: %v", err), http.StatusInternalServerError)
 		return
 	}
+	s.publishApprovalDecision(approval)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(approval)
@@ -604,14 +831,14 @@ func (s *Server) handleWithdraw(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// For now, use a default user ID (1 = admin)
-	userID := 1
+	userID := requestUserID(r)
 
 	approval, err := s.approvalRepo.Withdraw(id, userID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to withdraw: %v", err), http.StatusInternalServerError)
 		return
 	}
+	s.publishApprovalWebhook(models.WebhookEventApprovalWithdrawn, approval)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(approval)
@@ -627,6 +854,9 @@ func (s *Server) handleGetApprovalHistory(w http.ResponseWriter, r *http.Request
 		http.Error(w, "Invalid capability ID", http.StatusBadRequest)
 		return
 	}
+	if !s.requireCapabilityViewer(w, r, id) {
+		return
+	}
 
 	approvals, err := s.approvalRepo.GetApprovalHistory(id)
 	if err != nil {
@@ -666,6 +896,9 @@ func (s *Server) handleGetAuditLog(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid capability ID", http.StatusBadRequest)
 		return
 	}
+	if !s.requireCapabilityViewer(w, r, id) {
+		return
+	}
 
 	logs, err := s.approvalRepo.GetAuditLog(id)
 	if err != nil {
@@ -702,6 +935,24 @@ func (s *Server) handleGetUserPermissions(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// With a user, include the rights delegated to them. Only admins may look
+	// at another user's delegations.
+	if userParam := r.URL.Query().Get("user_id"); userParam != "" {
+		userID, err := strconv.Atoi(userParam)
+		if err != nil {
+			http.Error(w, "Invalid user_id", http.StatusBadRequest)
+			return
+		}
+		if userID != requestUserID(r) && !requestIsAdmin(r) {
+			http.Error(w, "Only admins can view another user's delegations", http.StatusForbidden)
+			return
+		}
+		if err := s.approvalRepo.AddDelegatedPermissions(permissions, userID); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get delegated permissions: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(permissions)
 }
@@ -759,6 +1010,16 @@ func (s *Server) handleCreateEnabler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Enablers created without an ID get the next free ENB- ID for their workspace
+	if req.EnablerID == "" {
+		id, err := s.ids.Next(req.WorkspaceID, idalloc.PrefixEnabler)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to allocate enabler ID: %v", err), http.StatusInternalServerError)
+			return
+		}
+		req.EnablerID = id
+	}
+
 	userID := 1 // Default user ID
 
 	enabler, err := s.enablerRepo.Create(req, userID)
@@ -882,6 +1143,21 @@ func (s *Server) handleCreateRequirement(w http.ResponseWriter, r *http.Request)
 	}
 
 	req.EnablerID = enablerID
+
+	// Requirements created without an ID get the next free FR- or NFR- ID
+	if req.RequirementID == "" {
+		prefix := idalloc.PrefixFunctional
+		if req.RequirementType == "non_functional" {
+			prefix = idalloc.PrefixNonFunctional
+		}
+		id, err := s.ids.Next("", prefix)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to allocate requirement ID: %v", err), http.StatusInternalServerError)
+			return
+		}
+		req.RequirementID = id
+	}
+
 	userID := 1 // Default user ID
 
 	requirement, err := s.enablerRepo.CreateRequirement(req, userID)
@@ -946,6 +1222,10 @@ func (s *Server) handleDeleteRequirement(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := s.enablerRepo.DeleteRequirement(id); err != nil {
+		if errors.Is(err, repository.ErrRequirementHasApprovalHistory) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, fmt.Sprintf("Failed to delete requirement: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -1260,6 +1540,7 @@ func (s *Server) handleUpdateCapabilityState(w http.ResponseWriter, r *http.Requ
 
 	userID := 1 // Default user ID
 
+	previous, _ := s.entityStateRepo.GetCapabilityState(capabilityID)
 	state, err := s.entityStateRepo.UpdateCapabilityState(capabilityID, req, &userID)
 	if err != nil {
 		// Check if it's an optimistic lock error
@@ -1267,9 +1548,15 @@ func (s *Server) handleUpdateCapabilityState(w http.ResponseWriter, r *http.Requ
 			http.Error(w, err.Error(), http.StatusConflict)
 			return
 		}
+		if writeStateTransitionError(w, err) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("Failed to update capability state: %v", err), http.StatusInternalServerError)
 		return
 	}
+	if previous != nil {
+		s.publishStateWebhooks(previous, state, req.ChangeReason)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(state)
@@ -1307,15 +1594,22 @@ func (s *Server) handleUpdateEnablerState(w http.ResponseWriter, r *http.Request
 
 	userID := 1 // Default user ID
 
+	previous, _ := s.entityStateRepo.GetEnablerState(enablerID)
 	state, err := s.entityStateRepo.UpdateEnablerState(enablerID, req, &userID)
 	if err != nil {
 		if _, ok := err.(*models.OptimisticLockError); ok {
 			http.Error(w, err.Error(), http.StatusConflict)
 			return
 		}
+		if writeStateTransitionError(w, err) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("Failed to update enabler state: %v", err), http.StatusInternalServerError)
 		return
 	}
+	if previous != nil {
+		s.publishStateWebhooks(previous, state, req.ChangeReason)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(state)
@@ -1359,15 +1653,22 @@ func (s *Server) handleUpdateStoryCardState(w http.ResponseWriter, r *http.Reque
 
 	userID := 1 // Default user ID
 
+	previous, _ := s.entityStateRepo.GetStoryCardState(cardID)
 	state, err := s.entityStateRepo.UpdateStoryCardState(cardID, req, &userID)
 	if err != nil {
 		if _, ok := err.(*models.OptimisticLockError); ok {
 			http.Error(w, err.Error(), http.StatusConflict)
 			return
 		}
+		if writeStateTransitionError(w, err) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("Failed to update story card state: %v", err), http.StatusInternalServerError)
 		return
 	}
+	if previous != nil {
+		s.publishStateWebhooks(previous, state, req.ChangeReason)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(state)
@@ -1393,6 +1694,142 @@ func (s *Server) handleCreateStoryCard(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(card)
 }
 
+// handleStreamWorkspaceEvents streams a workspace's change feed (entity state
+// changes, phase approvals and story card moves) as server-sent events. A
+// reconnecting client resumes after the ID in its Last-Event-ID header or
+// lastEventId query parameter; a new client only receives events from now on.
+// The user must be able to view the workspace.
+func (s *Server) handleStreamWorkspaceEvents(w http.ResponseWriter, r *http.Request) {
+	workspaceID := r.PathValue("workspaceId")
+	if workspaceID == "" {
+		http.Error(w, "Workspace ID is required", http.StatusBadRequest)
+		return
+	}
+	if err := s.workspaceRepo.Require(workspaceID, requestUserID(r), models.WorkspaceRoleViewer); err != nil {
+		if !writeWorkspaceAccessError(w, err) {
+			http.Error(w, fmt.Sprintf("Failed to check workspace role: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	var afterID int64
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		afterID = id
+	} else {
+		id, err := s.changeEventRepo.LatestID(workspaceID)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read change feed: %v", err), http.StatusInternalServerError)
+			return
+		}
+		afterID = id
+	}
+
+	// Streams outlive the server's WriteTimeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before the first read so nothing committed in between is missed
+	var wake <-chan struct{}
+	interval := 25 * time.Second // Keep-alive, and a re-read in case a notification was lost
+	if s.changeFeed != nil {
+		var cancel func()
+		wake, cancel = s.changeFeed.Subscribe(workspaceID)
+		defer cancel()
+	} else {
+		interval = 2 * time.Second
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	const batch = 100
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for {
+			events, err := s.changeEventRepo.ListSince(workspaceID, afterID, batch)
+			if err != nil {
+				// The client reconnects and resumes from the last ID it received
+				log.Printf("[handleStreamWorkspaceEvents] FAILED to read events for %s: %v", workspaceID, err)
+				return
+			}
+			for _, event := range events {
+				if err := changefeed.WriteEvent(w, event); err != nil {
+					return
+				}
+				afterID = event.ID
+			}
+			if len(events) > 0 {
+				if err := rc.Flush(); err != nil {
+					return
+				}
+			}
+			if len(events) < batch {
+				break
+			}
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case _, ok := <-wake:
+			if !ok {
+				return // Shutting down
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeStateTransitionError writes a *models.StateTransitionError as a JSON
+// 409 naming the rule that blocked the change. It reports whether err was one.
+func writeStateTransitionError(w http.ResponseWriter, err error) bool {
+	var transitionErr *models.StateTransitionError
+	if !errors.As(err, &transitionErr) {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(transitionErr)
+	return true
+}
+
+// handleGetStateTransitions returns the INTENT state machine: the allowed
+// transitions for each state dimension and the guards checked on every update
+func (s *Server) handleGetStateTransitions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"stages":      statemodel.Stages,
+		"transitions": statemodel.Transitions,
+		"guards":      statemodel.Guards,
+	})
+}
+
 func (s *Server) handleGetStateHistory(w http.ResponseWriter, r *http.Request) {
 	entityType := r.PathValue("entityType")
 	entityID := r.PathValue("entityId")
@@ -1433,11 +1870,18 @@ func (s *Server) handleSyncCapabilityFromFile(w http.ResponseWriter, r *http.Req
 
 	userID := 1 // Default user ID
 
+	previous, _ := s.entityStateRepo.GetCapabilityState(cap.CapabilityID)
 	result, err := s.entityStateRepo.UpsertCapabilityFromFile(cap, &userID)
 	if err != nil {
+		if writeStateTransitionError(w, err) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("Failed to sync capability: %v", err), http.StatusInternalServerError)
 		return
 	}
+	if previous != nil {
+		s.publishStateWebhooks(previous, result, "Synced from file")
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(result)
@@ -1456,13 +1900,21 @@ func (s *Server) handleSyncEnablerFromFile(w http.ResponseWriter, r *http.Reques
 
 	userID := 1 // Default user ID
 
+	previous, _ := s.entityStateRepo.GetEnablerState(enabler.EnablerID)
 	result, err := s.entityStateRepo.UpsertEnablerFromFile(enabler, &userID)
 	if err != nil {
 		log.Printf("[handleSyncEnablerFromFile] FAILED to upsert enabler %s: %v", enabler.EnablerID, err)
+		if writeStateTransitionError(w, err) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("Failed to sync enabler: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	if previous != nil {
+		s.publishStateWebhooks(previous, result, "Synced from file")
+	}
+
 	log.Printf("[handleSyncEnablerFromFile] SUCCESS - Upserted enabler %s with id=%d, approval_status=%s",
 		result.EnablerID, result.ID, result.ApprovalStatus)
 
@@ -1483,13 +1935,21 @@ func (s *Server) handleSyncStoryCardFromFile(w http.ResponseWriter, r *http.Requ
 
 	userID := 1 // Default user ID
 
+	previous, _ := s.entityStateRepo.GetStoryCardState(storyCard.CardID)
 	result, err := s.entityStateRepo.UpsertStoryCard(storyCard, &userID)
 	if err != nil {
 		log.Printf("[handleSyncStoryCardFromFile] FAILED to upsert card %s: %v", storyCard.CardID, err)
+		if writeStateTransitionError(w, err) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("Failed to sync story card: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	if previous != nil {
+		s.publishStateWebhooks(previous, result, "Synced from file")
+	}
+
 	log.Printf("[handleSyncStoryCardFromFile] SUCCESS - Upserted card %s with id=%d, lifecycle_state=%s",
 		result.CardID, result.ID, result.LifecycleState)
 
@@ -1677,13 +2137,20 @@ func (s *Server) handleApprovePhase(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := 1 // Default user ID
+	userID := requestUserID(r)
+	if err := s.workspaceRepo.Require(workspaceID, userID, models.WorkspaceRoleApprover); err != nil {
+		if !writeWorkspaceAccessError(w, err) {
+			http.Error(w, fmt.Sprintf("Failed to approve phase: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
 
 	approval, err := s.entityStateRepo.ApprovePhase(workspaceID, phase, &userID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to approve phase: %v", err), http.StatusInternalServerError)
 		return
 	}
+	s.publishPhaseWebhook(models.WebhookEventPhaseApproved, approval)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(approval)
@@ -1698,13 +2165,22 @@ func (s *Server) handleRevokePhaseApproval(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	userID := 1 // Default user ID
+	userID := requestUserID(r)
+	if err := s.workspaceRepo.Require(workspaceID, userID, models.WorkspaceRoleApprover); err != nil {
+		if !writeWorkspaceAccessError(w, err) {
+			http.Error(w, fmt.Sprintf("Failed to revoke phase approval: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
 
 	approval, err := s.entityStateRepo.RevokePhaseApproval(workspaceID, phase, &userID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to revoke phase approval: %v", err), http.StatusInternalServerError)
 		return
 	}
+	if approval.ID != 0 { // Zero when there was nothing to revoke
+		s.publishPhaseWebhook(models.WebhookEventPhaseRevoked, approval)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(approval)
@@ -0,0 +1,417 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/jareynolds/intentr/pkg/statemodel"
+	"github.com/jareynolds/intentr/pkg/webhook"
+)
+
+// =====================================================
+// Webhook publishing
+// =====================================================
+
+// publishWebhook queues an event for the workspace's webhook subscriptions.
+// Failures are logged and never fail the request that caused the event.
+func (s *Server) publishWebhook(eventType, workspaceID, summary string, data interface{}) {
+	if s.webhookRepo == nil || workspaceID == "" {
+		return
+	}
+	event, err := webhook.NewEvent(eventType, workspaceID, summary, data)
+	if err != nil {
+		log.Printf("[publishWebhook] FAILED to build %s event: %v", eventType, err)
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("[publishWebhook] FAILED to encode %s event: %v", eventType, err)
+		return
+	}
+	n, err := s.webhookRepo.Enqueue(event, payload)
+	if err != nil {
+		log.Printf("[publishWebhook] FAILED to queue %s event: %v", eventType, err)
+		return
+	}
+	if n > 0 {
+		s.webhooks.Wake()
+	}
+}
+
+// publishApprovalWebhook announces an approval decision on a capability
+func (s *Server) publishApprovalWebhook(eventType string, approval *models.CapabilityApproval) {
+	cap, err := s.capRepo.GetRef(approval.CapabilityID)
+	if err != nil {
+		log.Printf("[publishApprovalWebhook] FAILED to load capability %d: %v", approval.CapabilityID, err)
+		return
+	}
+
+	summary := fmt.Sprintf("%s approval for %s %q was %s", approval.Stage, cap.CapabilityID, cap.Name, approval.Status)
+	if approval.Feedback != nil && *approval.Feedback != "" {
+		summary += ": " + *approval.Feedback
+	}
+	s.publishWebhook(eventType, cap.WorkspaceID, summary, map[string]interface{}{
+		"approval": approval,
+		"capability": map[string]interface{}{
+			"id":            cap.ID,
+			"capability_id": cap.CapabilityID,
+			"name":          cap.Name,
+		},
+	})
+}
+
+// publishStateWebhooks announces a state update of a capability, enabler or
+// story card. state.stage_changed is sent as well when the workflow stage moved.
+func (s *Server) publishStateWebhooks(previous, current models.EntityState, reason string) {
+	before, after := stateOf(previous), stateOf(current)
+	if before == after {
+		return
+	}
+
+	label := current.GetEntityID()
+	if name := entityName(current); name != "" {
+		label += fmt.Sprintf(" %q", name)
+	}
+	var changes []string
+	for _, field := range statemodel.Fields {
+		if before.Get(field) != after.Get(field) {
+			changes = append(changes, fmt.Sprintf("%s %s → %s", field, before.Get(field), after.Get(field)))
+		}
+	}
+
+	data := map[string]interface{}{
+		"entity_type":   current.GetEntityType(),
+		"entity_id":     current.GetEntityID(),
+		"name":          entityName(current),
+		"previous":      before,
+		"current":       after,
+		"change_reason": reason,
+		"version":       current.GetVersion(),
+	}
+	workspaceID := current.GetWorkspaceID()
+	s.publishWebhook(models.WebhookEventStateUpdated, workspaceID,
+		fmt.Sprintf("%s %s: %s", current.GetEntityType(), label, strings.Join(changes, ", ")), data)
+
+	if before.WorkflowStage != after.WorkflowStage {
+		s.publishWebhook(models.WebhookEventStageChanged, workspaceID,
+			fmt.Sprintf("%s %s moved from %s to %s", current.GetEntityType(), label, before.WorkflowStage, after.WorkflowStage), data)
+	}
+}
+
+// publishPhaseWebhook announces a phase approval or revocation
+func (s *Server) publishPhaseWebhook(eventType string, approval *models.PhaseApproval) {
+	verb := "approved"
+	if eventType == models.WebhookEventPhaseRevoked {
+		verb = "revoked"
+	}
+	s.publishWebhook(eventType, approval.WorkspaceID,
+		fmt.Sprintf("%s phase %s for workspace %s", approval.Phase, verb, approval.WorkspaceID), approval)
+}
+
+func stateOf(e models.EntityState) statemodel.State {
+	return statemodel.State{
+		LifecycleState: e.GetLifecycleState(),
+		WorkflowStage:  e.GetWorkflowStage(),
+		StageStatus:    e.GetStageStatus(),
+		ApprovalStatus: e.GetApprovalStatus(),
+	}
+}
+
+func entityName(e models.EntityState) string {
+	switch v := e.(type) {
+	case *models.Capability:
+		return v.Name
+	case *models.Enabler:
+		return v.Name
+	case *models.StoryCard:
+		return v.Title
+	}
+	return ""
+}
+
+// =====================================================
+// Webhook Handlers
+// =====================================================
+
+// validateWebhook checks the URL, event filter and format of a subscription.
+// URLs on the service's own host or network are refused.
+func validateWebhook(ctx context.Context, rawURL string, events []string, format string) error {
+	if err := webhook.CheckURL(ctx, rawURL); err != nil {
+		return err
+	}
+	for _, e := range events {
+		known := e == "*"
+		for _, t := range models.WebhookEventTypes {
+			if e == t {
+				known = true
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown event type %q; expected one of %s", e, strings.Join(models.WebhookEventTypes, ", "))
+		}
+	}
+	if !webhook.ValidFormat(format) {
+		return fmt.Errorf("format must be json, slack or teams")
+	}
+	return nil
+}
+
+// webhookFromPath loads the subscription named by the {id} path value, which
+// only owners of its workspace may manage
+func (s *Server) webhookFromPath(w http.ResponseWriter, r *http.Request) (*models.WebhookSubscription, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	sub, err := s.webhookRepo.GetSubscription(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get webhook: %v", err), http.StatusNotFound)
+		return nil, false
+	}
+	if !s.requireWorkspaceOwner(w, r, sub.WorkspaceID) {
+		return nil, false
+	}
+	return sub, true
+}
+
+func (s *Server) handleGetWebhookEventTypes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"events":  models.WebhookEventTypes,
+		"formats": []string{models.WebhookFormatJSON, models.WebhookFormatSlack, models.WebhookFormatTeams},
+	})
+}
+
+func (s *Server) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	workspaceID := r.URL.Query().Get("workspace_id")
+	if workspaceID == "" {
+		http.Error(w, "workspace_id is required", http.StatusBadRequest)
+		return
+	}
+	if !s.requireWorkspaceOwner(w, r, workspaceID) {
+		return
+	}
+
+	webhooks, err := s.webhookRepo.ListSubscriptions(workspaceID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list webhooks: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"workspace_id": workspaceID,
+		"webhooks":     webhooks,
+	})
+}
+
+func (s *Server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if req.WorkspaceID == "" || req.Name == "" {
+		http.Error(w, "workspace_id and name are required", http.StatusBadRequest)
+		return
+	}
+	if !s.requireWorkspaceOwner(w, r, req.WorkspaceID) {
+		return
+	}
+	if req.Format == "" {
+		req.Format = models.WebhookFormatJSON
+	}
+	if err := validateWebhook(r.Context(), req.URL, req.Events, req.Format); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Secret == "" {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		req.Secret = secret
+	}
+
+	userID := requestUserID(r)
+
+	sub, err := s.webhookRepo.CreateSubscription(req, &userID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create webhook: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sub)
+}
+
+func (s *Server) handleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.webhookFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub)
+}
+
+func (s *Server) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	current, ok := s.webhookFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	rawURL, events, format := current.URL, current.Events, current.Format
+	if req.URL != nil {
+		rawURL = *req.URL
+	}
+	if req.Events != nil {
+		events = *req.Events
+	}
+	if req.Format != nil {
+		format = *req.Format
+	}
+	if err := validateWebhook(r.Context(), rawURL, events, format); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sub, err := s.webhookRepo.UpdateSubscription(current.ID, req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update webhook: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub)
+}
+
+func (s *Server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.webhookFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.webhookRepo.DeleteSubscription(sub.ID); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to delete webhook: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePingWebhook queues a ping event for one subscription to test its endpoint
+func (s *Server) handlePingWebhook(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.webhookFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	event, err := webhook.NewEvent(models.WebhookEventPing, sub.WorkspaceID,
+		fmt.Sprintf("Webhook %q is set up for workspace %s", sub.Name, sub.WorkspaceID),
+		map[string]interface{}{"webhook_id": sub.ID})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	delivery, err := s.webhookRepo.EnqueueFor(sub.ID, event, payload)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to queue ping: %v", err), http.StatusInternalServerError)
+		return
+	}
+	s.webhooks.Wake()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// handleGetWebhookDeliveries returns the delivery log of a subscription,
+// newest first, optionally filtered by ?status=
+func (s *Server) handleGetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.webhookFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	limit := 50 // Default limit
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 500 {
+		limit = l
+	}
+
+	deliveries, err := s.webhookRepo.ListDeliveries(sub.ID, r.URL.Query().Get("status"), limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get webhook deliveries: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"webhook_id": sub.ID,
+		"deliveries": deliveries,
+	})
+}
+
+// handleReplayWebhookDelivery queues a delivery again as a new delivery
+func (s *Server) handleReplayWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("deliveryId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	original, err := s.webhookRepo.GetDelivery(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get delivery: %v", err), http.StatusNotFound)
+		return
+	}
+	sub, err := s.webhookRepo.GetSubscription(original.SubscriptionID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get webhook: %v", err), http.StatusNotFound)
+		return
+	}
+	if !s.requireWorkspaceOwner(w, r, sub.WorkspaceID) {
+		return
+	}
+
+	delivery, err := s.webhookRepo.ReplayDelivery(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to replay delivery: %v", err), http.StatusInternalServerError)
+		return
+	}
+	s.webhooks.Wake()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
+}
@@ -10,6 +10,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -18,7 +19,14 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/jareynolds/intentr/internal/auth"
 	"github.com/jareynolds/intentr/internal/integration"
+	"github.com/jareynolds/intentr/pkg/changefeed"
+	"github.com/jareynolds/intentr/pkg/database"
+	"github.com/jareynolds/intentr/pkg/middleware"
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/jareynolds/intentr/pkg/repository"
+	"github.com/jareynolds/intentr/pkg/vault"
 )
 
 func main() {
@@ -37,6 +45,76 @@ func main() {
 	service := integration.NewService(figmaToken)
 	handler := integration.NewHandler(service)
 
+	// Requests may only name paths inside the workspaces root
+	if root := os.Getenv("WORKSPACES_ROOT"); root != "" {
+		handler.SetWorkspacesRoot(root)
+	}
+
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		log.Fatal("JWT_SECRET environment variable is required")
+	}
+
+	// The database holds the users, tokens and workspace roles that requests
+	// are authorized against
+	dbHost := os.Getenv("DB_HOST")
+	if dbHost == "" {
+		dbHost = "localhost"
+	}
+	dbPort := os.Getenv("DB_PORT")
+	if dbPort == "" {
+		dbPort = "5432"
+	}
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		dbHost, dbPort, os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"))
+	db, err := database.NewPostgresDB(dsn)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	// Integration credentials are stored encrypted with the vault's master key
+	credentialVault, err := vault.FromEnv()
+	if errors.Is(err, vault.ErrNoMasterKey) {
+		log.Println("Warning: INTENTR_MASTER_KEY not set. Integration credentials cannot be saved.")
+	} else if err != nil {
+		log.Fatalf("Failed to load credential vault: %v", err)
+	} else {
+		// Credentials saved before the vault existed are encrypted before any
+		// request can read them
+		encrypted, err := repository.NewIntegrationRepository(db.DB, credentialVault).EncryptPlaintextIntegrations()
+		if err != nil {
+			log.Fatalf("Failed to encrypt stored integration credentials: %v", err)
+		}
+		if encrypted > 0 {
+			log.Printf("Encrypted %d integrations stored in plain text", encrypted)
+		}
+	}
+	handler.UseDatabase(db.DB, credentialVault)
+
+	// Workspaces from before workspace roles existed get the owners their
+	// .intentrworkspace files name
+	if _, err := handler.ClaimWorkspaceOwners(); err != nil {
+		log.Printf("Warning: failed to record workspace owners: %v", err)
+	}
+
+	// Exported enablers' GitHub issues follow their lifecycle states
+	changeFeed, err := changefeed.Listen(dsn)
+	if err != nil {
+		log.Printf("Warning: change feed notifications unavailable, GitHub issue sync will poll: %v", err)
+	} else {
+		defer changeFeed.Close()
+	}
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	go handler.WatchEnablerLifecycle(watchCtx, changeFeed)
+
+	// Every route but the health check requires authentication
+	authenticate := middleware.AuthMiddleware(auth.NewService(db.DB, jwtSecret))
+	requireAuth := func(next http.HandlerFunc) http.HandlerFunc {
+		return authenticate(next).ServeHTTP
+	}
+
 	// CORS middleware
 	corsMiddleware := func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
@@ -53,164 +131,211 @@ func main() {
 		}
 	}
 
+	// Workspace file endpoints check the paths named in the request and the
+	// caller's role in their workspace
+	viewer := func(next http.HandlerFunc) http.HandlerFunc {
+		return handler.RequireWorkspaceRole(models.WorkspaceRoleViewer, next)
+	}
+	contributor := func(next http.HandlerFunc) http.HandlerFunc {
+		return handler.RequireWorkspaceRole(models.WorkspaceRoleContributor, next)
+	}
+
 	// Setup routes
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /health", corsMiddleware(handler.HandleHealth))
 	mux.HandleFunc("OPTIONS /health", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("GET /figma/files/{fileKey}", corsMiddleware(handler.HandleGetFile))
+	mux.HandleFunc("GET /figma/files/{fileKey}", corsMiddleware(requireAuth(handler.HandleGetFile)))
 	mux.HandleFunc("OPTIONS /figma/files/{fileKey}", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("GET /figma/files/{fileKey}/comments", corsMiddleware(handler.HandleGetComments))
+	mux.HandleFunc("GET /figma/files/{fileKey}/comments", corsMiddleware(requireAuth(handler.HandleGetComments)))
 	mux.HandleFunc("OPTIONS /figma/files/{fileKey}/comments", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /analyze-integration", corsMiddleware(handler.HandleAnalyzeIntegration))
+	mux.HandleFunc("GET /credentials", corsMiddleware(requireAuth(handler.HandleListCredentials)))
+	mux.HandleFunc("OPTIONS /credentials", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	mux.HandleFunc("PUT /credentials/{name}", corsMiddleware(requireAuth(handler.HandleSaveCredentials)))
+	mux.HandleFunc("DELETE /credentials/{name}", corsMiddleware(requireAuth(handler.HandleDeleteCredentials)))
+	mux.HandleFunc("OPTIONS /credentials/{name}", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	mux.HandleFunc("POST /analyze-integration", corsMiddleware(requireAuth(viewer(handler.HandleAnalyzeIntegration))))
 	mux.HandleFunc("OPTIONS /analyze-integration", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /test-connection", corsMiddleware(handler.HandleTestConnection))
+	mux.HandleFunc("POST /test-connection", corsMiddleware(requireAuth(handler.HandleTestConnection)))
 	mux.HandleFunc("OPTIONS /test-connection", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /analyze-connection-error", corsMiddleware(handler.HandleAnalyzeConnectionError))
+	mux.HandleFunc("POST /analyze-connection-error", corsMiddleware(requireAuth(handler.HandleAnalyzeConnectionError)))
 	mux.HandleFunc("OPTIONS /analyze-connection-error", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /fetch-resources", corsMiddleware(handler.HandleFetchResources))
+	mux.HandleFunc("POST /fetch-resources", corsMiddleware(requireAuth(handler.HandleFetchResources)))
 	mux.HandleFunc("OPTIONS /fetch-resources", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /suggest-resources", corsMiddleware(handler.HandleSuggestResources))
+	mux.HandleFunc("POST /suggest-resources", corsMiddleware(requireAuth(handler.HandleSuggestResources)))
 	mux.HandleFunc("OPTIONS /suggest-resources", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /fetch-files", corsMiddleware(handler.HandleFetchFiles))
+	mux.HandleFunc("POST /fetch-files", corsMiddleware(requireAuth(handler.HandleFetchFiles)))
 	mux.HandleFunc("OPTIONS /fetch-files", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /fetch-file-meta", corsMiddleware(handler.HandleFetchFileMeta))
+	mux.HandleFunc("POST /fetch-file-meta", corsMiddleware(requireAuth(handler.HandleFetchFileMeta)))
 	mux.HandleFunc("OPTIONS /fetch-file-meta", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /fetch-jira-epics", corsMiddleware(handler.HandleFetchJiraEpics))
+	mux.HandleFunc("POST /github/create-repo", corsMiddleware(requireAuth(handler.HandleCreateGitHubRepo)))
+	mux.HandleFunc("OPTIONS /github/create-repo", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	mux.HandleFunc("POST /fetch-jira-epics", corsMiddleware(requireAuth(handler.HandleFetchJiraEpics)))
 	mux.HandleFunc("OPTIONS /fetch-jira-epics", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /import-jira-epics", corsMiddleware(handler.HandleImportJiraEpics))
+	mux.HandleFunc("POST /import-jira-epics", corsMiddleware(requireAuth(contributor(handler.HandleImportJiraEpics))))
 	mux.HandleFunc("OPTIONS /import-jira-epics", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("GET /specifications/list", corsMiddleware(handler.HandleListSpecifications))
+	mux.HandleFunc("POST /fetch-epics", corsMiddleware(requireAuth(handler.HandleFetchEpics)))
+	mux.HandleFunc("OPTIONS /fetch-epics", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	mux.HandleFunc("POST /import-epics", corsMiddleware(requireAuth(contributor(handler.HandleImportEpics))))
+	mux.HandleFunc("OPTIONS /import-epics", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+
+	// Two-way sync of capabilities and enablers with their Jira issues
+	mux.HandleFunc("GET /jira/links", corsMiddleware(requireAuth(handler.HandleListJiraLinks)))
+	mux.HandleFunc("POST /jira/links", corsMiddleware(requireAuth(handler.HandleCreateJiraLink)))
+	mux.HandleFunc("OPTIONS /jira/links", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	mux.HandleFunc("DELETE /jira/links/{id}", corsMiddleware(requireAuth(handler.HandleDeleteJiraLink)))
+	mux.HandleFunc("OPTIONS /jira/links/{id}", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	mux.HandleFunc("POST /jira/sync", corsMiddleware(requireAuth(handler.HandleJiraSync)))
+	mux.HandleFunc("OPTIONS /jira/sync", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	mux.HandleFunc("POST /github/export-issues", corsMiddleware(requireAuth(contributor(handler.HandleExportGitHubIssues))))
+	mux.HandleFunc("OPTIONS /github/export-issues", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	mux.HandleFunc("POST /ids/allocate", corsMiddleware(requireAuth(contributor(handler.HandleAllocateIDs))))
+	mux.HandleFunc("OPTIONS /ids/allocate", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	mux.HandleFunc("GET /specifications/list", corsMiddleware(requireAuth(viewer(handler.HandleListSpecifications))))
 	mux.HandleFunc("OPTIONS /specifications/list", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /specifications/analyze", corsMiddleware(handler.HandleAnalyzeSpecifications))
+	mux.HandleFunc("POST /specifications/analyze", corsMiddleware(requireAuth(viewer(handler.HandleAnalyzeSpecifications))))
 	mux.HandleFunc("OPTIONS /specifications/analyze", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /specifications/generate-diagram", corsMiddleware(handler.HandleGenerateDiagram))
+	mux.HandleFunc("POST /specifications/lint", corsMiddleware(requireAuth(viewer(handler.HandleLintSpecifications))))
+	mux.HandleFunc("OPTIONS /specifications/lint", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	mux.HandleFunc("POST /specifications/rename-id", corsMiddleware(requireAuth(contributor(handler.HandleRenameID))))
+	mux.HandleFunc("OPTIONS /specifications/rename-id", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	mux.HandleFunc("POST /graph", corsMiddleware(requireAuth(viewer(handler.HandleGraph))))
+	mux.HandleFunc("OPTIONS /graph", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	mux.HandleFunc("POST /graph/closure", corsMiddleware(requireAuth(viewer(handler.HandleGraphClosure))))
+	mux.HandleFunc("OPTIONS /graph/closure", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	mux.HandleFunc("POST /graph/order", corsMiddleware(requireAuth(viewer(handler.HandleGraphOrder))))
+	mux.HandleFunc("OPTIONS /graph/order", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	mux.HandleFunc("POST /graph/cycles", corsMiddleware(requireAuth(viewer(handler.HandleGraphCycles))))
+	mux.HandleFunc("OPTIONS /graph/cycles", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	mux.HandleFunc("POST /graph/blast-radius", corsMiddleware(requireAuth(viewer(handler.HandleGraphBlastRadius))))
+	mux.HandleFunc("OPTIONS /graph/blast-radius", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	mux.HandleFunc("POST /specifications/generate-diagram", corsMiddleware(requireAuth(viewer(handler.HandleGenerateDiagram))))
 	mux.HandleFunc("OPTIONS /specifications/generate-diagram", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /analyze-application", corsMiddleware(handler.HandleAnalyzeApplication))
+	mux.HandleFunc("POST /analyze-application", corsMiddleware(requireAuth(viewer(handler.HandleAnalyzeApplication))))
 	mux.HandleFunc("OPTIONS /analyze-application", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /export-ideation", corsMiddleware(handler.HandleExportIdeation))
+	mux.HandleFunc("POST /export-ideation", corsMiddleware(requireAuth(contributor(handler.HandleExportIdeation))))
 	mux.HandleFunc("OPTIONS /export-ideation", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("GET /folders/list", corsMiddleware(handler.HandleListFolders))
+	mux.HandleFunc("GET /folders/list", corsMiddleware(requireAuth(viewer(handler.HandleListFolders))))
 	mux.HandleFunc("OPTIONS /folders/list", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /folders/create", corsMiddleware(handler.HandleCreateFolder))
+	mux.HandleFunc("POST /folders/create", corsMiddleware(requireAuth(viewer(handler.HandleCreateFolder))))
 	mux.HandleFunc("OPTIONS /folders/create", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /folders/ensure-workspace-structure", corsMiddleware(handler.HandleEnsureWorkspaceStructure))
+	mux.HandleFunc("POST /folders/ensure-workspace-structure", corsMiddleware(requireAuth(contributor(handler.HandleEnsureWorkspaceStructure))))
 	mux.HandleFunc("OPTIONS /folders/ensure-workspace-structure", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /folders/move-to-deleted", corsMiddleware(handler.HandleMoveToDeleted))
+	mux.HandleFunc("POST /folders/move-to-deleted", corsMiddleware(requireAuth(contributor(handler.HandleMoveToDeleted))))
 	mux.HandleFunc("OPTIONS /folders/move-to-deleted", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /workspace/init-files", corsMiddleware(handler.HandleInitWorkspaceFiles))
+	mux.HandleFunc("POST /workspace/init-files", corsMiddleware(requireAuth(contributor(handler.HandleInitWorkspaceFiles))))
 	mux.HandleFunc("OPTIONS /workspace/init-files", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /workspace/set-ai-principles", corsMiddleware(handler.HandleSetActiveAIPrinciples))
+	mux.HandleFunc("POST /workspace/set-ai-principles", corsMiddleware(requireAuth(contributor(handler.HandleSetActiveAIPrinciples))))
 	mux.HandleFunc("OPTIONS /workspace/set-ai-principles", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /capability-files", corsMiddleware(handler.HandleCapabilityFiles))
+	mux.HandleFunc("POST /capability-files", corsMiddleware(requireAuth(viewer(handler.HandleCapabilityFiles))))
 	mux.HandleFunc("OPTIONS /capability-files", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /enabler-files", corsMiddleware(handler.HandleEnablerFiles))
+	mux.HandleFunc("POST /enabler-files", corsMiddleware(requireAuth(viewer(handler.HandleEnablerFiles))))
 	mux.HandleFunc("OPTIONS /enabler-files", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /test-scenario-files", corsMiddleware(handler.HandleTestScenarioFiles))
+	mux.HandleFunc("POST /test-scenario-files", corsMiddleware(requireAuth(viewer(handler.HandleTestScenarioFiles))))
 	mux.HandleFunc("OPTIONS /test-scenario-files", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /save-capability", corsMiddleware(handler.HandleSaveCapability))
+	mux.HandleFunc("POST /save-capability", corsMiddleware(requireAuth(contributor(handler.HandleSaveCapability))))
 	mux.HandleFunc("OPTIONS /save-capability", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /update-capability-storyboard", corsMiddleware(handler.HandleUpdateCapabilityStoryboard))
+	mux.HandleFunc("POST /update-capability-storyboard", corsMiddleware(requireAuth(contributor(handler.HandleUpdateCapabilityStoryboard))))
 	mux.HandleFunc("OPTIONS /update-capability-storyboard", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /update-enabler-capability", corsMiddleware(handler.HandleUpdateEnablerCapability))
+	mux.HandleFunc("POST /update-enabler-capability", corsMiddleware(requireAuth(contributor(handler.HandleUpdateEnablerCapability))))
 	mux.HandleFunc("OPTIONS /update-enabler-capability", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /delete-capability", corsMiddleware(handler.HandleDeleteCapability))
+	mux.HandleFunc("POST /delete-capability", corsMiddleware(requireAuth(contributor(handler.HandleDeleteCapability))))
 	mux.HandleFunc("OPTIONS /delete-capability", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /delete-enabler", corsMiddleware(handler.HandleDeleteEnabler))
+	mux.HandleFunc("POST /delete-enabler", corsMiddleware(requireAuth(contributor(handler.HandleDeleteEnabler))))
 	mux.HandleFunc("OPTIONS /delete-enabler", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /delete-specification", corsMiddleware(handler.HandleDeleteSpecification))
+	mux.HandleFunc("POST /delete-specification", corsMiddleware(requireAuth(contributor(handler.HandleDeleteSpecification))))
 	mux.HandleFunc("OPTIONS /delete-specification", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /story-files", corsMiddleware(handler.HandleStoryFiles))
+	mux.HandleFunc("POST /story-files", corsMiddleware(requireAuth(viewer(handler.HandleStoryFiles))))
 	mux.HandleFunc("OPTIONS /story-files", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /ideation-files", corsMiddleware(handler.HandleIdeationFiles))
+	mux.HandleFunc("POST /ideation-files", corsMiddleware(requireAuth(viewer(handler.HandleIdeationFiles))))
 	mux.HandleFunc("OPTIONS /ideation-files", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /analyze-storyboard", corsMiddleware(handler.HandleAnalyzeStoryboard))
+	mux.HandleFunc("POST /analyze-storyboard", corsMiddleware(requireAuth(viewer(handler.HandleAnalyzeStoryboard))))
 	mux.HandleFunc("OPTIONS /analyze-storyboard", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /ai-chat", corsMiddleware(handler.HandleAIChat))
+	mux.HandleFunc("POST /ai-chat", corsMiddleware(requireAuth(viewer(handler.HandleAIChat))))
 	mux.HandleFunc("OPTIONS /ai-chat", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /generate-code", corsMiddleware(handler.HandleGenerateCode))
+	mux.HandleFunc("POST /generate-code", corsMiddleware(requireAuth(contributor(handler.HandleGenerateCode))))
 	mux.HandleFunc("OPTIONS /generate-code", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /generate-code-cli", corsMiddleware(handler.HandleGenerateCodeCLI))
+	mux.HandleFunc("POST /generate-code-cli", corsMiddleware(requireAuth(contributor(handler.HandleGenerateCodeCLI))))
 	mux.HandleFunc("OPTIONS /generate-code-cli", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
 	// Background job-based code generation endpoints
-	mux.HandleFunc("POST /generate-code-job", corsMiddleware(handler.HandleStartCodeGenerationJob))
+	mux.HandleFunc("POST /generate-code-job", corsMiddleware(requireAuth(contributor(handler.HandleStartCodeGenerationJob))))
 	mux.HandleFunc("OPTIONS /generate-code-job", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("GET /generate-code-status/", corsMiddleware(handler.HandleGetCodeGenerationJobStatus))
+	mux.HandleFunc("GET /generate-code-status/", corsMiddleware(requireAuth(viewer(handler.HandleGetCodeGenerationJobStatus))))
 	mux.HandleFunc("OPTIONS /generate-code-status/", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /generate-code-cancel/", corsMiddleware(handler.HandleCancelCodeGenerationJob))
+	mux.HandleFunc("POST /generate-code-cancel/", corsMiddleware(requireAuth(contributor(handler.HandleCancelCodeGenerationJob))))
 	mux.HandleFunc("OPTIONS /generate-code-cancel/", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /code-files", corsMiddleware(handler.HandleCodeFiles))
+	mux.HandleFunc("POST /code-files", corsMiddleware(requireAuth(viewer(handler.HandleCodeFiles))))
 	mux.HandleFunc("OPTIONS /code-files", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /run-app", corsMiddleware(handler.HandleRunApp))
+	mux.HandleFunc("POST /run-app", corsMiddleware(requireAuth(contributor(handler.HandleRunApp))))
 	mux.HandleFunc("OPTIONS /run-app", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /stop-app", corsMiddleware(handler.HandleStopApp))
+	mux.HandleFunc("POST /stop-app", corsMiddleware(requireAuth(contributor(handler.HandleStopApp))))
 	mux.HandleFunc("OPTIONS /stop-app", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /check-app-status", corsMiddleware(handler.HandleCheckAppStatus))
+	mux.HandleFunc("POST /check-app-status", corsMiddleware(requireAuth(viewer(handler.HandleCheckAppStatus))))
 	mux.HandleFunc("OPTIONS /check-app-status", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /activate-ai-preset", corsMiddleware(handler.HandleActivateAIPreset))
+	mux.HandleFunc("POST /activate-ai-preset", corsMiddleware(requireAuth(contributor(handler.HandleActivateAIPreset))))
 	mux.HandleFunc("OPTIONS /activate-ai-preset", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /save-specifications", corsMiddleware(handler.HandleSaveSpecifications))
+	mux.HandleFunc("POST /save-specifications", corsMiddleware(requireAuth(contributor(handler.HandleSaveSpecifications))))
 	mux.HandleFunc("OPTIONS /save-specifications", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /read-specification", corsMiddleware(handler.HandleReadSpecification))
+	mux.HandleFunc("POST /read-specification", corsMiddleware(requireAuth(viewer(handler.HandleReadSpecification))))
 	mux.HandleFunc("OPTIONS /read-specification", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /read-storyboard-files", corsMiddleware(handler.HandleReadStoryboardFiles))
+	mux.HandleFunc("POST /read-storyboard-files", corsMiddleware(requireAuth(viewer(handler.HandleReadStoryboardFiles))))
 	mux.HandleFunc("OPTIONS /read-storyboard-files", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /analyze-capabilities", corsMiddleware(handler.HandleAnalyzeCapabilities))
+	mux.HandleFunc("POST /analyze-capabilities", corsMiddleware(requireAuth(viewer(handler.HandleAnalyzeCapabilities))))
 	mux.HandleFunc("OPTIONS /analyze-capabilities", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /analyze-conception", corsMiddleware(handler.HandleAnalyzeConception))
+	mux.HandleFunc("POST /analyze-conception", corsMiddleware(requireAuth(viewer(handler.HandleAnalyzeConception))))
 	mux.HandleFunc("OPTIONS /analyze-conception", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /save-image", corsMiddleware(handler.HandleSaveImage))
+	mux.HandleFunc("POST /save-image", corsMiddleware(requireAuth(contributor(handler.HandleSaveImage))))
 	mux.HandleFunc("OPTIONS /save-image", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
 
 	// Test Scenario routes
-	mux.HandleFunc("POST /save-test-scenario", corsMiddleware(handler.HandleSaveTestScenario))
+	mux.HandleFunc("POST /save-test-scenario", corsMiddleware(requireAuth(contributor(handler.HandleSaveTestScenario))))
 	mux.HandleFunc("OPTIONS /save-test-scenario", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /delete-test-scenario", corsMiddleware(handler.HandleDeleteTestScenario))
+	mux.HandleFunc("POST /delete-test-scenario", corsMiddleware(requireAuth(contributor(handler.HandleDeleteTestScenario))))
 	mux.HandleFunc("OPTIONS /delete-test-scenario", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /list-test-scenarios", corsMiddleware(handler.HandleListTestScenarios))
+	mux.HandleFunc("POST /list-test-scenarios", corsMiddleware(requireAuth(viewer(handler.HandleListTestScenarios))))
 	mux.HandleFunc("OPTIONS /list-test-scenarios", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
 
 	// File reading route (for AI analysis)
-	mux.HandleFunc("POST /read-file", corsMiddleware(handler.HandleReadFile))
+	mux.HandleFunc("POST /read-file", corsMiddleware(requireAuth(viewer(handler.HandleReadFile))))
 	mux.HandleFunc("OPTIONS /read-file", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
 
 	// INTENT Epic routes (Scaled Agile With AI)
-	mux.HandleFunc("POST /epic-files", corsMiddleware(handler.HandleEpicFiles))
+	mux.HandleFunc("POST /epic-files", corsMiddleware(requireAuth(viewer(handler.HandleEpicFiles))))
 	mux.HandleFunc("OPTIONS /epic-files", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /save-epic", corsMiddleware(handler.HandleSaveEpic))
+	mux.HandleFunc("POST /save-epic", corsMiddleware(requireAuth(contributor(handler.HandleSaveEpic))))
 	mux.HandleFunc("OPTIONS /save-epic", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /delete-epic", corsMiddleware(handler.HandleDeleteEpic))
+	mux.HandleFunc("POST /delete-epic", corsMiddleware(requireAuth(contributor(handler.HandleDeleteEpic))))
 	mux.HandleFunc("OPTIONS /delete-epic", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
 
 	// INTENT Theme/Vision routes
-	mux.HandleFunc("POST /theme-files", corsMiddleware(handler.HandleThemeFiles))
+	mux.HandleFunc("POST /theme-files", corsMiddleware(requireAuth(viewer(handler.HandleThemeFiles))))
 	mux.HandleFunc("OPTIONS /theme-files", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /save-theme", corsMiddleware(handler.HandleSaveTheme))
+	mux.HandleFunc("POST /save-theme", corsMiddleware(requireAuth(contributor(handler.HandleSaveTheme))))
 	mux.HandleFunc("OPTIONS /save-theme", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /delete-theme", corsMiddleware(handler.HandleDeleteTheme))
+	mux.HandleFunc("POST /delete-theme", corsMiddleware(requireAuth(contributor(handler.HandleDeleteTheme))))
 	mux.HandleFunc("OPTIONS /delete-theme", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
 
 	// INTENT Feature routes
-	mux.HandleFunc("POST /feature-files", corsMiddleware(handler.HandleFeatureFiles))
+	mux.HandleFunc("POST /feature-files", corsMiddleware(requireAuth(viewer(handler.HandleFeatureFiles))))
 	mux.HandleFunc("OPTIONS /feature-files", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /save-feature", corsMiddleware(handler.HandleSaveFeature))
+	mux.HandleFunc("POST /save-feature", corsMiddleware(requireAuth(contributor(handler.HandleSaveFeature))))
 	mux.HandleFunc("OPTIONS /save-feature", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /delete-feature", corsMiddleware(handler.HandleDeleteFeature))
+	mux.HandleFunc("POST /delete-feature", corsMiddleware(requireAuth(contributor(handler.HandleDeleteFeature))))
 	mux.HandleFunc("OPTIONS /delete-feature", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
 
 	// Workspace configuration routes
-	mux.HandleFunc("POST /workspace-config/save", corsMiddleware(handler.HandleSaveWorkspaceConfig))
+	mux.HandleFunc("POST /workspace-config/save", corsMiddleware(requireAuth(contributor(handler.HandleSaveWorkspaceConfig))))
 	mux.HandleFunc("OPTIONS /workspace-config/save", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("GET /workspace-config/scan", corsMiddleware(handler.HandleScanWorkspaces))
+	mux.HandleFunc("GET /workspace-config/scan", corsMiddleware(requireAuth(handler.HandleScanWorkspaces)))
 	mux.HandleFunc("OPTIONS /workspace-config/scan", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("GET /workspace-config", corsMiddleware(handler.HandleGetWorkspaceConfig))
+	mux.HandleFunc("GET /workspace-config", corsMiddleware(requireAuth(viewer(handler.HandleGetWorkspaceConfig))))
 	mux.HandleFunc("OPTIONS /workspace-config", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
 
 	// SyncCode2Spec routes (Reverse-to-Design)
-	mux.HandleFunc("POST /sync-code-to-spec", corsMiddleware(handler.HandleSyncCode2Spec))
+	mux.HandleFunc("POST /sync-code-to-spec", corsMiddleware(requireAuth(contributor(handler.HandleSyncCode2Spec))))
 	mux.HandleFunc("OPTIONS /sync-code-to-spec", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /get-code-diff", corsMiddleware(handler.HandleGetCodeDiff))
+	mux.HandleFunc("POST /get-code-diff", corsMiddleware(requireAuth(viewer(handler.HandleGetCodeDiff))))
 	mux.HandleFunc("OPTIONS /get-code-diff", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
-	mux.HandleFunc("POST /apply-spec-update", corsMiddleware(handler.HandleApplySpecUpdate))
+	mux.HandleFunc("POST /apply-spec-update", corsMiddleware(requireAuth(contributor(handler.HandleApplySpecUpdate))))
 	mux.HandleFunc("OPTIONS /apply-spec-update", corsMiddleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
 
 	// Create server
@@ -0,0 +1,73 @@
+// IntentR - Copyright 2025 James Reynolds
+//
+// Lint mode - checks a workspace's specification files and reports
+// rule violations as file:line: severity: message.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jareynolds/intentr/pkg/spec/lint"
+)
+
+// runLint implements "intentrcli lint [workspace]" and returns the exit code
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "Print issues as JSON")
+	listRules := fs.Bool("rules", false, "List available rules and exit")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: intentrcli lint [-json] [-rules] [workspace]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *listRules {
+		for _, r := range lint.Rules {
+			fmt.Printf("%-32s %-8s %s\n", r.ID, r.Severity, r.Description)
+		}
+		return 0
+	}
+
+	root := "."
+	if fs.NArg() > 0 {
+		root = fs.Arg(0)
+	}
+
+	cfg, err := lint.LoadConfig(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	issues, err := lint.Run(root, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if *jsonOutput {
+		if issues == nil {
+			issues = []lint.Issue{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(issues)
+	} else {
+		counts := map[lint.Severity]int{}
+		for _, issue := range issues {
+			fmt.Println(issue)
+			counts[issue.Severity]++
+		}
+		fmt.Printf("%d error(s), %d warning(s), %d info\n",
+			counts[lint.SeverityError], counts[lint.SeverityWarning], counts[lint.SeverityInfo])
+	}
+
+	if lint.HasErrors(issues) {
+		return 1
+	}
+	return 0
+}
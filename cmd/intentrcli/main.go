@@ -28,6 +28,20 @@ var (
 )
 
 func main() {
+	// Subcommands run without loading the LLM configuration
+	if len(os.Args) > 1 && os.Args[1] == "lint" {
+		os.Exit(runLint(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "rename-id" {
+		os.Exit(runRenameID(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "vault-keygen" {
+		os.Exit(runVaultKeygen(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "vault-rotate" {
+		os.Exit(runVaultRotate(os.Args[2:]))
+	}
+
 	// Parse command line flags
 	showVersion := flag.Bool("version", false, "Show version information")
 	showHelp := flag.Bool("help", false, "Show help information")
@@ -193,6 +207,23 @@ USAGE:
   intentrcli [OPTIONS]
   intentrcli -p "prompt"
   echo "prompt" | intentrcli
+  intentrcli lint [-json] [-rules] [workspace]
+  intentrcli rename-id [-dry-run] [-json] OLD-ID NEW-ID [workspace]
+  intentrcli vault-keygen
+  intentrcli vault-rotate [-json]
+
+COMMANDS:
+  lint            Check workspace specifications for missing Metadata fields,
+                  duplicate CAP-/ENB- IDs and dangling Storyboard References.
+                  Exits 1 when any error-level issue is found. Rules are
+                  configured in .intentrworkspace under customSettings.lint
+  rename-id       Change a CAP-/ENB-/FR-/NFR-/TS- ID in spec file names and
+                  every reference in the workspace. Database rows are renamed
+                  by the integration service's /specifications/rename-id
+  vault-keygen    Print a new random master key for INTENTR_MASTER_KEY
+  vault-rotate    Re-wrap the stored integration credentials' data keys with
+                  INTENTR_MASTER_KEY, unwrapping them with the old keys listed
+                  in INTENTR_PREVIOUS_MASTER_KEYS; uses the DB_* variables
 
 OPTIONS:
   -version        Show version information
@@ -239,6 +270,16 @@ EXAMPLES:
   # Initialize configuration
   intentrcli -init
 
+  # Lint a workspace's specifications
+  intentrcli lint workspaces/my-project
+
+  # Preview renumbering a capability
+  intentrcli rename-id -dry-run CAP-000012 CAP-000040 workspaces/my-project
+
+  # Rotate the credential vault's master key
+  NEW_KEY=$(intentrcli vault-keygen)
+  INTENTR_MASTER_KEY=$NEW_KEY INTENTR_PREVIOUS_MASTER_KEYS=$OLD_KEY intentrcli vault-rotate
+
 For more information: https://github.com/intentr/intentrcli
 `, version)
 }
@@ -0,0 +1,71 @@
+// IntentR - Copyright 2025 James Reynolds
+//
+// Rename mode - changes a CAP/ENB/FR/NFR/TS ID in every specification file
+// of a workspace, renaming the spec file itself where its name carries the ID.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jareynolds/intentr/pkg/spec/rename"
+)
+
+// runRenameID implements "intentrcli rename-id OLD NEW [workspace]" and returns the exit code
+func runRenameID(args []string) int {
+	fs := flag.NewFlagSet("rename-id", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "List the changes without writing them")
+	jsonOutput := fs.Bool("json", false, "Print the changes as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: intentrcli rename-id [-dry-run] [-json] OLD-ID NEW-ID [workspace]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return 2
+	}
+	oldID, newID := fs.Arg(0), fs.Arg(1)
+	root := "."
+	if fs.NArg() > 2 {
+		root = fs.Arg(2)
+	}
+
+	plan, err := rename.PlanRename(root, oldID, newID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(plan)
+	} else {
+		for _, f := range plan.Files {
+			if f.NewPath != "" {
+				fmt.Printf("%s -> %s\n", f.Path, f.NewPath)
+			} else {
+				fmt.Println(f.Path)
+			}
+			for _, l := range f.Lines {
+				fmt.Printf("  %d: - %s\n", l.Line, l.Before)
+				fmt.Printf("  %d: + %s\n", l.Line, l.After)
+			}
+		}
+		fmt.Printf("%d file(s) reference %s\n", len(plan.Files), oldID)
+	}
+
+	if *dryRun {
+		return 0
+	}
+	if err := plan.Apply(root); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,89 @@
+// IntentR - Copyright 2025 James Reynolds
+//
+// Vault mode - generates master keys and re-wraps the stored integration
+// credentials' data keys after the master key changes.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jareynolds/intentr/pkg/database"
+	"github.com/jareynolds/intentr/pkg/repository"
+	"github.com/jareynolds/intentr/pkg/vault"
+)
+
+// runVaultKeygen implements "intentrcli vault-keygen" and returns the exit code
+func runVaultKeygen(args []string) int {
+	fs := flag.NewFlagSet("vault-keygen", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: intentrcli vault-keygen")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	key, err := vault.GenerateKey()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Println(key)
+	return 0
+}
+
+// runVaultRotate implements "intentrcli vault-rotate" and returns the exit code.
+// The new master key is read from INTENTR_MASTER_KEY (or _FILE) and the old
+// ones from INTENTR_PREVIOUS_MASTER_KEYS; the database from DB_HOST, DB_PORT,
+// DB_USER, DB_PASSWORD and DB_NAME, like the services.
+func runVaultRotate(args []string) int {
+	fs := flag.NewFlagSet("vault-rotate", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "Print the result as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: intentrcli vault-rotate [-json]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	v, err := vault.FromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	dbHost := os.Getenv("DB_HOST")
+	if dbHost == "" {
+		dbHost = "localhost"
+	}
+	dbPort := os.Getenv("DB_PORT")
+	if dbPort == "" {
+		dbPort = "5432"
+	}
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		dbHost, dbPort, os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"))
+	db, err := database.NewPostgresDB(dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	result, err := repository.NewIntegrationRepository(db.DB, v).RotateMasterKey()
+	if result != nil {
+		if *jsonOutput {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(result)
+		} else {
+			fmt.Printf("Master key %s: %d re-wrapped, %d encrypted, %d unchanged\n",
+				v.CurrentKeyID(), result.Rewrapped, result.Encrypted, result.Unchanged)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,42 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Command mock-jira runs a local stub of the Jira REST API for trying epic
+// import and Jira sync without a Jira site. Save a Jira integration with
+// domain http://localhost:9091 and the email and API token given by the
+// flags. Its issues are kept in memory.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jareynolds/intentr/pkg/jirasync/jiratest"
+)
+
+func main() {
+	addr := flag.String("addr", ":9091", "listen address")
+	email := flag.String("email", "user@example.com", "email accepted for basic auth")
+	token := flag.String("token", "mock-token", "API token accepted for basic auth")
+	project := flag.String("project", "DEMO", "project key of the seeded epics")
+	epics := flag.String("epics", "Checkout,Order history,Saved payment methods", "comma-separated summaries of the seeded epics")
+	flag.Parse()
+
+	server := jiratest.NewServer(*email, *token)
+	for i, summary := range strings.Split(*epics, ",") {
+		if summary = strings.TrimSpace(summary); summary != "" {
+			server.AddIssue(fmt.Sprintf("%s-%d", *project, i+1), summary)
+		}
+	}
+
+	log.Printf("Mock Jira listening on %s (project %s, user %s)", *addr, *project, *email)
+	log.Fatal(http.ListenAndServe(*addr, server))
+}
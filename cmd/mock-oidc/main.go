@@ -0,0 +1,56 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Command mock-oidc runs a local OpenID Connect provider for trying the
+// auth-service's OIDC login without Keycloak or Okta. Every login signs in
+// as the user given by the flags.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jareynolds/intentr/internal/auth/oidctest"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "listen address")
+	issuer := flag.String("issuer", "http://localhost:9090", "issuer URL, as reached by the auth-service")
+	clientID := flag.String("client-id", "intentr", "client ID")
+	clientSecret := flag.String("client-secret", "intentr-secret", "client secret")
+	subject := flag.String("sub", "mock-user", "subject of the signed-in user")
+	email := flag.String("email", "user@example.com", "email of the signed-in user")
+	name := flag.String("name", "Mock User", "name of the signed-in user")
+	groups := flag.String("groups", "", "comma-separated groups claim of the signed-in user")
+	flag.Parse()
+
+	provider, err := oidctest.NewProvider(*issuer, *clientID, *clientSecret)
+	if err != nil {
+		log.Fatalf("Failed to create provider: %v", err)
+	}
+
+	claims := map[string]interface{}{
+		"sub":            *subject,
+		"email":          *email,
+		"email_verified": true,
+		"name":           *name,
+	}
+	if *groups != "" {
+		var list []interface{}
+		for _, g := range strings.Split(*groups, ",") {
+			list = append(list, strings.TrimSpace(g))
+		}
+		claims["groups"] = list
+	}
+	provider.SetClaims(claims)
+
+	log.Printf("Mock OIDC provider %s listening on %s (client %s, user %s)", *issuer, *addr, *clientID, *email)
+	log.Fatal(http.ListenAndServe(*addr, provider))
+}
@@ -6,13 +6,11 @@ toolchain go1.24.7
 
 require (
 	github.com/golang-jwt/jwt/v5 v5.3.0
+	github.com/google/uuid v1.6.0
 	github.com/lib/pq v1.10.9
 	golang.org/x/crypto v0.44.0
 	golang.org/x/oauth2 v0.33.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
-require (
-	cloud.google.com/go/compute/metadata v0.3.0 // indirect
-	github.com/google/uuid v1.6.0 // indirect
-)
+require cloud.google.com/go/compute/metadata v0.3.0 // indirect
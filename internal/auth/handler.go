@@ -20,6 +20,7 @@ import (
 type Handler struct {
 	service     *Service
 	oauthConfig *OAuthConfig
+	oidc        *OIDCManager
 }
 
 // NewHandler creates a new auth handler
@@ -57,7 +58,7 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.service.Authenticate(req.Email, req.Password)
+	resp, challenge, err := h.service.Authenticate(req.Email, req.Password, r.UserAgent(), r.RemoteAddr)
 	if err == ErrInvalidCredentials {
 		respondError(w, http.StatusUnauthorized, "Invalid email or password")
 		return
@@ -68,10 +69,84 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if challenge != nil {
+		respondJSON(w, http.StatusOK, challenge)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// Refresh exchanges a refresh token for a new access token and refresh token
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.RefreshToken == "" {
+		respondError(w, http.StatusBadRequest, "Refresh token is required")
+		return
+	}
+
+	resp, err := h.service.Refresh(req.RefreshToken, r.UserAgent(), r.RemoteAddr)
+	if err == ErrInvalidToken || err == ErrRefreshTokenReused {
+		respondError(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+		return
+	}
+	if err != nil {
+		log.Printf("Refresh error: %v", err)
+		respondError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
 	respondJSON(w, http.StatusOK, resp)
 }
 
-// VerifyToken verifies a JWT token
+// Logout ends the current session
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(*Claims)
+
+	if claims.SessionID != 0 {
+		if err := h.service.Logout(claims.UserID, claims.SessionID); err != nil {
+			log.Printf("Logout error: %v", err)
+			respondError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutEverywhere ends all of the current user's sessions
+func (h *Handler) LogoutEverywhere(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(*Claims)
+
+	if err := h.service.LogoutEverywhere(claims.UserID); err != nil {
+		log.Printf("Logout everywhere error: %v", err)
+		respondError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListSessions returns the current user's active sessions
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(*Claims)
+
+	sessions, err := h.service.ListSessions(claims.UserID, claims.SessionID)
+	if err != nil {
+		log.Printf("List sessions error: %v", err)
+		respondError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, sessions)
+}
+
+// VerifyToken verifies a JWT or API token
 func (h *Handler) VerifyToken(w http.ResponseWriter, r *http.Request) {
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
@@ -86,7 +161,7 @@ func (h *Handler) VerifyToken(w http.ResponseWriter, r *http.Request) {
 	}
 
 	token := parts[1]
-	claims, err := h.service.VerifyToken(token)
+	claims, err := h.service.VerifyBearerToken(token, r.RemoteAddr)
 	if err != nil {
 		respondError(w, http.StatusUnauthorized, "Invalid or expired token")
 		return
@@ -282,23 +357,25 @@ func (h *Handler) GoogleCallback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Generate JWT token
-	token, err := h.service.GenerateTokenFromCredentials(user.ID, user.Email, user.Role)
+	if !user.IsActive {
+		respondError(w, http.StatusUnauthorized, "User account is deactivated")
+		return
+	}
+
+	// Start a session, unless the user needs a second factor first
+	resp, challenge, err := h.service.startLogin(user, r.UserAgent(), r.RemoteAddr)
 	if err != nil {
-		log.Printf("Failed to generate token: %v", err)
+		log.Printf("Failed to start session: %v", err)
 		respondError(w, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
-	// Update last login
-	if err := h.service.UpdateLastLogin(user.ID); err != nil {
-		log.Printf("Failed to update last login: %v", err)
+	if challenge != nil {
+		respondJSON(w, http.StatusOK, challenge)
+		return
 	}
 
-	respondJSON(w, http.StatusOK, &LoginResponse{
-		Token: token,
-		User:  *user,
-	})
+	respondJSON(w, http.StatusOK, resp)
 }
 
 // ListShareableUsers returns users that can be shared with (excludes current user)
@@ -317,16 +394,17 @@ func (h *Handler) ListShareableUsers(w http.ResponseWriter, r *http.Request) {
 
 // GetWorkspaceShares returns all shares for a workspace
 func (h *Handler) GetWorkspaceShares(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(*Claims)
+
 	workspaceID := r.PathValue("workspaceId")
 	if workspaceID == "" {
 		respondError(w, http.StatusBadRequest, "Workspace ID is required")
 		return
 	}
 
-	shares, err := h.service.GetWorkspaceShares(workspaceID)
+	shares, err := h.service.GetWorkspaceShares(workspaceID, claims.UserID)
 	if err != nil {
-		log.Printf("Get workspace shares error: %v", err)
-		respondError(w, http.StatusInternalServerError, "Internal server error")
+		respondWorkspaceMemberError(w, "Get workspace shares", err)
 		return
 	}
 
@@ -365,8 +443,7 @@ func (h *Handler) UpdateWorkspaceShares(w http.ResponseWriter, r *http.Request)
 
 	shares, err := h.service.UpdateWorkspaceShares(workspaceID, req.UserIDs, claims.UserID)
 	if err != nil {
-		log.Printf("Update workspace shares error: %v", err)
-		respondError(w, http.StatusInternalServerError, "Internal server error")
+		respondWorkspaceMemberError(w, "Update workspace shares", err)
 		return
 	}
 
@@ -375,6 +452,8 @@ func (h *Handler) UpdateWorkspaceShares(w http.ResponseWriter, r *http.Request)
 
 // RemoveWorkspaceShare removes a specific user's access to a workspace
 func (h *Handler) RemoveWorkspaceShare(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(*Claims)
+
 	workspaceID := r.PathValue("workspaceId")
 	userIDStr := r.PathValue("userId")
 
@@ -389,14 +468,13 @@ func (h *Handler) RemoveWorkspaceShare(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.RemoveWorkspaceShare(workspaceID, userID)
-	if err == ErrUserNotFound {
+	err = h.service.RemoveWorkspaceShare(workspaceID, userID, claims.UserID)
+	if err == ErrMemberNotFound {
 		respondError(w, http.StatusNotFound, "Share not found")
 		return
 	}
 	if err != nil {
-		log.Printf("Remove workspace share error: %v", err)
-		respondError(w, http.StatusInternalServerError, "Internal server error")
+		respondWorkspaceMemberError(w, "Remove workspace share", err)
 		return
 	}
 
@@ -31,10 +31,31 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
-// LoginResponse represents a login response with JWT token
+// LoginResponse represents a login response with a short-lived JWT access
+// token and the refresh token that renews it
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token         string    `json:"token"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+	RefreshToken  string    `json:"refreshToken"`
+	User          User      `json:"user"`
+	RecoveryCodes []string  `json:"recoveryCodes,omitempty"` // Set when two-factor login was enrolled during login
+}
+
+// RefreshRequest represents a request to renew an access token
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Session represents a login session, renewed by its refresh token
+type Session struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"userId"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	RefreshedAt *time.Time `json:"refreshedAt,omitempty"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	UserAgent   string     `json:"userAgent,omitempty"`
+	IPAddress   string     `json:"ipAddress,omitempty"`
+	Current     bool       `json:"current"`
 }
 
 // CreateUserRequest represents a request to create a new user
@@ -72,6 +93,7 @@ type WorkspaceShare struct {
 	SharedWithUserID    int       `json:"sharedWithUserId"`
 	SharedWithUserEmail string    `json:"sharedWithUserEmail"`
 	SharedWithUserName  string    `json:"sharedWithUserName"`
+	Role                string    `json:"role"`
 	SharedByUserID      int       `json:"sharedByUserId"`
 	CreatedAt           time.Time `json:"createdAt"`
 	UpdatedAt           time.Time `json:"updatedAt"`
@@ -88,3 +110,122 @@ type ShareableUser struct {
 type UpdateWorkspaceSharesRequest struct {
 	UserIDs []int `json:"userIds"`
 }
+
+// WorkspaceMember is a user's role in a workspace
+type WorkspaceMember struct {
+	WorkspaceID string    `json:"workspaceId"`
+	UserID      int       `json:"userId"`
+	Email       string    `json:"email"`
+	Name        string    `json:"name"`
+	Role        string    `json:"role"`
+	GrantedBy   *int      `json:"grantedBy,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// SetWorkspaceMemberRequest sets a member's workspace role
+type SetWorkspaceMemberRequest struct {
+	Role string `json:"role"`
+}
+
+// MyWorkspaceRole is the current user's role in a workspace. Empty means no
+// access; an unmanaged workspace predates workspace roles, has no members and
+// is only open to admins.
+type MyWorkspaceRole struct {
+	WorkspaceID string `json:"workspaceId"`
+	Role        string `json:"role"`
+	Managed     bool   `json:"managed"`
+}
+
+// APIToken is a personal access token or service account token. The token
+// itself is only returned once, in CreateAPITokenResponse.
+type APIToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"userId"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // Start of the token, to recognise it
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	LastUsedIP string     `json:"lastUsedIp,omitempty"`
+	CreatedBy  *int       `json:"createdBy,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreateAPITokenRequest represents a request to create an API token
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays *int     `json:"expiresInDays,omitempty"` // Defaults to 90; 0 never expires (service accounts only)
+}
+
+// CreateAPITokenResponse carries a new API token; the token cannot be retrieved again
+type CreateAPITokenResponse struct {
+	Token    string   `json:"token"`
+	APIToken APIToken `json:"apiToken"`
+}
+
+// ServiceAccount is a non-human user that authenticates with API tokens only
+type ServiceAccount struct {
+	User
+	Description string     `json:"description,omitempty"`
+	CreatedBy   *int       `json:"createdBy,omitempty"`
+	Tokens      []APIToken `json:"tokens,omitempty"`
+}
+
+// CreateServiceAccountRequest represents a request to create a service account
+type CreateServiceAccountRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Role        string `json:"role"`
+}
+
+// TwoFactorChallenge is returned by login instead of tokens when a second
+// step is needed. With SetupRequired the user's role requires two-factor
+// login and the user enrolls before completing the login.
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool      `json:"twoFactorRequired"`
+	SetupRequired     bool      `json:"setupRequired"`
+	ChallengeToken    string    `json:"challengeToken"`
+	ExpiresAt         time.Time `json:"expiresAt"`
+}
+
+// TwoFactorLoginRequest completes a login with a TOTP code or a recovery code
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challengeToken"`
+	Code           string `json:"code,omitempty"`
+	RecoveryCode   string `json:"recoveryCode,omitempty"`
+}
+
+// TwoFactorCodeRequest carries a TOTP code confirming a two-factor change
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// TOTPEnrollment is the shared secret of a new enrollment, shown once
+type TOTPEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauthUrl"` // For QR codes
+}
+
+// TwoFactorStatus describes a user's two-factor setup
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	Required               bool       `json:"required"` // The user's role requires it
+	RecoveryCodesRemaining int        `json:"recoveryCodesRemaining"`
+	LockedUntil            *time.Time `json:"lockedUntil,omitempty"`
+}
+
+// RecoveryCodesResponse carries new recovery codes, shown once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// TwoFactorPolicy records whether a role must use two-factor login
+type TwoFactorPolicy struct {
+	Role      string    `json:"role"`
+	Required  bool      `json:"required"`
+	UpdatedBy *int      `json:"updatedBy,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
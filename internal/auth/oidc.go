@@ -0,0 +1,618 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrUnknownProvider  = errors.New("unknown OIDC provider")
+	ErrInvalidOIDCState = errors.New("invalid or expired OIDC login state")
+	ErrOIDCLoginDenied  = errors.New("OIDC login denied")
+)
+
+// oidcLoginTTL is how long a started login can be completed
+const oidcLoginTTL = 10 * time.Minute
+
+// jwksMinRefresh limits how often an unknown key ID refetches the JWKS
+var jwksMinRefresh = time.Minute
+
+// idTokenAlgorithms are the ID token signing algorithms accepted. Symmetric
+// algorithms are never accepted, so a token cannot be signed with a key
+// derived from the public JWKS.
+var idTokenAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// OIDCRoleMapping maps a value of the role claim to an IntentR role
+type OIDCRoleMapping struct {
+	Value string `yaml:"value" json:"value"`
+	Role  string `yaml:"role" json:"role"`
+}
+
+// OIDCProviderConfig configures an OpenID Connect provider
+type OIDCProviderConfig struct {
+	Name         string            `yaml:"name" json:"name"` // Used in URLs, e.g. "keycloak"
+	DisplayName  string            `yaml:"displayName" json:"displayName"`
+	Issuer       string            `yaml:"issuer" json:"issuer"`
+	ClientID     string            `yaml:"clientId" json:"clientId"`
+	ClientSecret string            `yaml:"clientSecret" json:"-"`
+	RedirectURL  string            `yaml:"redirectUrl" json:"redirectUrl"`
+	Scopes       []string          `yaml:"scopes" json:"scopes"`             // Defaults to openid, email, profile
+	RoleClaim    string            `yaml:"roleClaim" json:"roleClaim"`       // Dotted path, e.g. "realm_access.roles" or "groups"
+	RoleMappings []OIDCRoleMapping `yaml:"roleMappings" json:"roleMappings"` // First match wins
+	DefaultRole  string            `yaml:"defaultRole" json:"defaultRole"`   // Role when no mapping matches; empty denies the login
+}
+
+// OIDCProviderInfo is what the login page shows of a provider
+type OIDCProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// OIDCIdentity is a user as asserted by a validated ID token
+type OIDCIdentity struct {
+	Provider      string                 `json:"provider"`
+	Subject       string                 `json:"subject"`
+	Email         string                 `json:"email"`
+	EmailVerified bool                   `json:"emailVerified"`
+	Name          string                 `json:"name"`
+	Role          string                 `json:"role"`
+	SyncRole      bool                   `json:"syncRole"` // The provider is the source of the user's role
+	Claims        map[string]interface{} `json:"-"`
+}
+
+var providerNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// LoadOIDCConfig reads provider configurations from a YAML file with a
+// top-level "providers" list. ${NAME} references are replaced with
+// environment variables, which keeps client secrets out of the file.
+func LoadOIDCConfig(path string) ([]OIDCProviderConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OIDC config: %w", err)
+	}
+
+	var file struct {
+		Providers []OIDCProviderConfig `yaml:"providers"`
+	}
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), &file); err != nil {
+		return nil, fmt.Errorf("failed to parse OIDC config: %w", err)
+	}
+
+	seen := map[string]bool{}
+	for i, p := range file.Providers {
+		if err := p.validate(); err != nil {
+			return nil, fmt.Errorf("OIDC provider %d: %w", i+1, err)
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("OIDC provider %q is configured twice", p.Name)
+		}
+		seen[p.Name] = true
+	}
+
+	return file.Providers, nil
+}
+
+func (c OIDCProviderConfig) validate() error {
+	switch {
+	case !providerNamePattern.MatchString(c.Name):
+		return fmt.Errorf("name %q must be lowercase letters, digits and dashes", c.Name)
+	case c.Name == "google":
+		return errors.New(`name "google" is reserved for the built-in Google login`)
+	case c.Issuer == "":
+		return errors.New("issuer is required")
+	case c.ClientID == "":
+		return errors.New("clientId is required")
+	case c.RedirectURL == "":
+		return errors.New("redirectUrl is required")
+	}
+	return nil
+}
+
+// MapRole returns the IntentR role for ID token claims. Without a role claim
+// every user gets the default role ("user" if unset).
+func (c OIDCProviderConfig) MapRole(claims map[string]interface{}) (string, bool) {
+	if c.RoleClaim == "" {
+		if c.DefaultRole == "" {
+			return "user", true
+		}
+		return c.DefaultRole, true
+	}
+
+	values := claimValues(claims, c.RoleClaim)
+	for _, mapping := range c.RoleMappings {
+		for _, v := range values {
+			if v == mapping.Value {
+				return mapping.Role, true
+			}
+		}
+	}
+	return c.DefaultRole, c.DefaultRole != ""
+}
+
+// claimValues returns the string values at a dotted claim path. Lists yield
+// each string element.
+func claimValues(claims map[string]interface{}, path string) []string {
+	var current interface{} = claims
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = m[part]
+	}
+
+	switch v := current.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// oidcDiscovery is the part of the provider metadata the client uses
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCProvider is an OpenID Connect provider. Its metadata is discovered on
+// first use, so an unreachable provider does not stop the service starting.
+type OIDCProvider struct {
+	Config OIDCProviderConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	oauth     *oauth2.Config
+	keys      map[string]crypto.PublicKey
+	keysAt    time.Time
+}
+
+// NewOIDCProvider creates a provider. client is used for discovery, token
+// and JWKS requests; nil uses http.DefaultClient.
+func NewOIDCProvider(config OIDCProviderConfig, client *http.Client) *OIDCProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &OIDCProvider{Config: config, client: client}
+}
+
+// getJSON fetches a JSON document from the provider
+func (p *OIDCProvider) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// discover fetches the provider metadata once
+func (p *OIDCProvider) discover(ctx context.Context) (*oauth2.Config, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.oauth != nil {
+		return p.oauth, nil
+	}
+
+	var d oidcDiscovery
+	url := strings.TrimSuffix(p.Config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, url, &d); err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+	if d.Issuer != p.Config.Issuer {
+		return nil, fmt.Errorf("OIDC discovery failed: issuer %q does not match %q", d.Issuer, p.Config.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("OIDC discovery failed: metadata is missing endpoints")
+	}
+
+	p.discovery = &d
+	p.oauth = &oauth2.Config{
+		ClientID:     p.Config.ClientID,
+		ClientSecret: p.Config.ClientSecret,
+		RedirectURL:  p.Config.RedirectURL,
+		Scopes:       p.Config.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  d.AuthorizationEndpoint,
+			TokenURL: d.TokenEndpoint,
+		},
+	}
+	return p.oauth, nil
+}
+
+// jsonWebKey is a public key of a JWKS
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// signingKey returns the provider key with the given ID. An unknown ID
+// refetches the JWKS, at most once a minute, to pick up rotated keys.
+func (p *OIDCProvider) signingKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lookup := func() crypto.PublicKey {
+		if kid == "" && len(p.keys) == 1 {
+			for _, key := range p.keys {
+				return key
+			}
+		}
+		return p.keys[kid]
+	}
+	if key := lookup(); key != nil {
+		return key, nil
+	}
+	if time.Since(p.keysAt) < jwksMinRefresh {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, p.discovery.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	p.keys = map[string]crypto.PublicKey{}
+	p.keysAt = time.Now()
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		p.keys[k.Kid] = key
+	}
+
+	if key := lookup(); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// VerifyIDToken validates an ID token's signature against the provider's
+// JWKS, its issuer, audience, lifetime and nonce, and returns its claims
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (jwt.MapClaims, error) {
+	if _, err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.signingKey(ctx, kid)
+	},
+		jwt.WithValidMethods(idTokenAlgorithms),
+		jwt.WithIssuer(p.discovery.Issuer),
+		jwt.WithAudience(p.Config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	// A token for several audiences must have been issued to this client
+	if aud, _ := claims.GetAudience(); len(aud) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.Config.ClientID {
+			return nil, errors.New("invalid ID token: authorized party is not this client")
+		}
+	}
+	if claimNonce, _ := claims["nonce"].(string); claimNonce != nonce {
+		return nil, errors.New("invalid ID token: nonce does not match")
+	}
+	return claims, nil
+}
+
+// identity builds the identity of validated ID token claims
+func (p *OIDCProvider) identity(claims jwt.MapClaims) (*OIDCIdentity, error) {
+	id := &OIDCIdentity{Provider: p.Config.Name, Claims: claims, SyncRole: p.Config.RoleClaim != ""}
+	id.Subject, _ = claims["sub"].(string)
+	id.Email, _ = claims["email"].(string)
+	id.EmailVerified, _ = claims["email_verified"].(bool)
+	id.Name, _ = claims["name"].(string)
+	if id.Name == "" {
+		id.Name, _ = claims["preferred_username"].(string)
+	}
+	if id.Name == "" {
+		id.Name = id.Email
+	}
+
+	if id.Subject == "" {
+		return nil, errors.New("invalid ID token: missing subject")
+	}
+	if id.Email == "" {
+		return nil, fmt.Errorf("%w: the provider did not return an email address", ErrOIDCLoginDenied)
+	}
+
+	role, ok := p.Config.MapRole(claims)
+	if !ok {
+		return nil, fmt.Errorf("%w: no role is mapped for this user", ErrOIDCLoginDenied)
+	}
+	id.Role = role
+	return id, nil
+}
+
+// oidcLogin is a login started by AuthCodeURL and waiting for its callback
+type oidcLogin struct {
+	provider string
+	verifier string
+	nonce    string
+	expires  time.Time
+}
+
+// OIDCManager holds the configured OIDC providers and the logins in progress
+type OIDCManager struct {
+	mu        sync.Mutex
+	providers map[string]*OIDCProvider
+	names     []string
+	pending   map[string]oidcLogin // By state
+}
+
+// NewOIDCManager creates a manager for the given providers
+func NewOIDCManager(providers ...*OIDCProvider) *OIDCManager {
+	m := &OIDCManager{providers: map[string]*OIDCProvider{}, pending: map[string]oidcLogin{}}
+	for _, p := range providers {
+		m.providers[p.Config.Name] = p
+		m.names = append(m.names, p.Config.Name)
+	}
+	return m
+}
+
+// Providers lists the configured providers in configuration order
+func (m *OIDCManager) Providers() []OIDCProviderInfo {
+	infos := []OIDCProviderInfo{}
+	for _, name := range m.names {
+		p := m.providers[name]
+		display := p.Config.DisplayName
+		if display == "" {
+			display = name
+		}
+		infos = append(infos, OIDCProviderInfo{Name: name, DisplayName: display})
+	}
+	return infos
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// AuthCodeURL starts a login with a provider and returns the URL to send
+// the browser to. The login uses PKCE (S256) and a nonce bound to the state.
+func (m *OIDCManager) AuthCodeURL(ctx context.Context, name string) (string, error) {
+	p, ok := m.providers[name]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+	config, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	m.mu.Lock()
+	now := time.Now()
+	for s, login := range m.pending {
+		if now.After(login.expires) {
+			delete(m.pending, s)
+		}
+	}
+	m.pending[state] = oidcLogin{provider: name, verifier: verifier, nonce: nonce, expires: now.Add(oidcLoginTTL)}
+	m.mu.Unlock()
+
+	return config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier), oauth2.SetAuthURLParam("nonce", nonce)), nil
+}
+
+// Exchange completes a login: it checks the state, redeems the code with
+// the PKCE verifier and validates the ID token
+func (m *OIDCManager) Exchange(ctx context.Context, name, code, state string) (*OIDCIdentity, error) {
+	p, ok := m.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	m.mu.Lock()
+	login, ok := m.pending[state]
+	delete(m.pending, state)
+	m.mu.Unlock()
+	if !ok || login.provider != name || time.Now().After(login.expires) {
+		return nil, ErrInvalidOIDCState
+	}
+
+	config, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := config.Exchange(context.WithValue(ctx, oauth2.HTTPClient, p.client), code, oauth2.VerifierOption(login.verifier))
+	if err != nil {
+		return nil, fmt.Errorf("code exchange failed: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("code exchange failed: no ID token in the response")
+	}
+
+	claims, err := p.VerifyIDToken(ctx, rawIDToken, login.nonce)
+	if err != nil {
+		return nil, err
+	}
+	return p.identity(claims)
+}
+
+// LoginWithOIDC logs in with a validated OIDC identity. The user is found by
+// their provider identity, then by verified email address, and is created
+// otherwise. When the provider maps roles, the user's role follows it. As
+// with Authenticate, a user who needs a second factor gets a challenge
+// instead of a session.
+func (s *Service) LoginWithOIDC(identity *OIDCIdentity, userAgent, remoteAddr string) (*LoginResponse, *TwoFactorChallenge, error) {
+	var userID int
+	err := s.db.QueryRow(`
+		SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2
+	`, identity.Provider, identity.Subject).Scan(&userID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, nil, fmt.Errorf("database error: %w", err)
+	}
+
+	var user *User
+	if err == nil {
+		user, err = s.GetUserByID(userID)
+	} else {
+		user, err = s.GetUserByEmail(identity.Email)
+		if err == nil && !identity.EmailVerified {
+			return nil, nil, fmt.Errorf("%w: the provider has not verified %s", ErrOIDCLoginDenied, identity.Email)
+		}
+		if err == ErrUserNotFound {
+			user, err = s.CreateOAuthUser(&CreateUserRequest{
+				Email: identity.Email,
+				Name:  identity.Name,
+				Role:  identity.Role,
+			}, identity.Provider, identity.Subject)
+		}
+		if err == nil {
+			_, err = s.db.Exec(`
+				INSERT INTO user_identities (user_id, provider, subject, email)
+				VALUES ($1, $2, $3, $4)
+			`, user.ID, identity.Provider, identity.Subject, identity.Email)
+			if err != nil {
+				err = fmt.Errorf("database error: %w", err)
+			}
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !user.IsActive {
+		return nil, nil, fmt.Errorf("%w: the account is deactivated", ErrOIDCLoginDenied)
+	}
+	if identity.SyncRole && user.Role != identity.Role {
+		if user, err = s.UpdateUser(user.ID, &UpdateUserRequest{Role: &identity.Role}); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	_, err = s.db.Exec(`
+		UPDATE user_identities SET last_login_at = CURRENT_TIMESTAMP, email = $3
+		WHERE provider = $1 AND subject = $2
+	`, identity.Provider, identity.Subject, identity.Email)
+	if err != nil {
+		return nil, nil, fmt.Errorf("database error: %w", err)
+	}
+
+	return s.startLogin(user, userAgent, remoteAddr)
+}
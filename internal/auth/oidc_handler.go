@@ -0,0 +1,102 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package auth
+
+import (
+	"errors"
+	"log"
+	"net/http"
+)
+
+// SetOIDCManager sets the OpenID Connect providers of the handler
+func (h *Handler) SetOIDCManager(manager *OIDCManager) {
+	h.oidc = manager
+}
+
+// ListOIDCProviders returns the OIDC providers the login page offers
+func (h *Handler) ListOIDCProviders(w http.ResponseWriter, r *http.Request) {
+	if h.oidc == nil {
+		respondJSON(w, http.StatusOK, []OIDCProviderInfo{})
+		return
+	}
+	respondJSON(w, http.StatusOK, h.oidc.Providers())
+}
+
+// OIDCLogin initiates the login flow of an OIDC provider
+func (h *Handler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if h.oidc == nil {
+		respondError(w, http.StatusNotFound, "OIDC provider not found")
+		return
+	}
+
+	url, err := h.oidc.AuthCodeURL(r.Context(), r.PathValue("provider"))
+	if errors.Is(err, ErrUnknownProvider) {
+		respondError(w, http.StatusNotFound, "OIDC provider not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to initiate OIDC login: %v", err)
+		respondError(w, http.StatusBadGateway, "Failed to initiate OIDC login")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"url": url,
+	})
+}
+
+// OIDCCallback completes the login flow of an OIDC provider
+func (h *Handler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if h.oidc == nil {
+		respondError(w, http.StatusNotFound, "OIDC provider not found")
+		return
+	}
+
+	code := r.URL.Query().Get("code")
+	state := r.URL.Query().Get("state")
+	if code == "" || state == "" {
+		respondError(w, http.StatusBadRequest, "Missing code or state parameter")
+		return
+	}
+
+	identity, err := h.oidc.Exchange(r.Context(), r.PathValue("provider"), code, state)
+	switch {
+	case errors.Is(err, ErrUnknownProvider):
+		respondError(w, http.StatusNotFound, "OIDC provider not found")
+		return
+	case errors.Is(err, ErrInvalidOIDCState):
+		respondError(w, http.StatusUnauthorized, "Invalid state parameter")
+		return
+	case errors.Is(err, ErrOIDCLoginDenied):
+		respondError(w, http.StatusForbidden, err.Error())
+		return
+	case err != nil:
+		log.Printf("OIDC login failed: %v", err)
+		respondError(w, http.StatusUnauthorized, "Failed to verify the provider's response")
+		return
+	}
+
+	resp, challenge, err := h.service.LoginWithOIDC(identity, r.UserAgent(), r.RemoteAddr)
+	if errors.Is(err, ErrOIDCLoginDenied) {
+		respondError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("OIDC login failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	if challenge != nil {
+		respondJSON(w, http.StatusOK, challenge)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,231 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jareynolds/intentr/internal/auth/oidctest"
+)
+
+func newTestOIDC(t *testing.T) (*oidctest.Server, *OIDCProvider, *OIDCManager) {
+	t.Helper()
+	srv := oidctest.NewServer("intentr", "secret")
+	t.Cleanup(srv.Close)
+	srv.SetClaims(map[string]interface{}{
+		"sub":            "u-1",
+		"email":          "ada@example.com",
+		"email_verified": true,
+		"name":           "Ada",
+		"groups":         []interface{}{"staff", "intentr-admins"},
+	})
+
+	provider := NewOIDCProvider(OIDCProviderConfig{
+		Name:         "keycloak",
+		Issuer:       srv.URL,
+		ClientID:     "intentr",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:6173/auth/oidc/keycloak/callback",
+		RoleClaim:    "groups",
+		RoleMappings: []OIDCRoleMapping{{Value: "intentr-admins", Role: "admin"}},
+		DefaultRole:  "user",
+	}, srv.Client())
+	return srv, provider, NewOIDCManager(provider)
+}
+
+// authorize follows a login URL to the provider and returns the callback's
+// code and state
+func authorize(t *testing.T, m *OIDCManager, name string) (string, string) {
+	t.Helper()
+	authURL, err := m.AuthCodeURL(context.Background(), name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || callback.Query().Get("code") == "" {
+		t.Fatalf("authorize redirected to %q", resp.Header.Get("Location"))
+	}
+	return callback.Query().Get("code"), callback.Query().Get("state")
+}
+
+func TestOIDCLogin(t *testing.T) {
+	_, _, m := newTestOIDC(t)
+	ctx := context.Background()
+
+	code, state := authorize(t, m, "keycloak")
+	identity, err := m.Exchange(ctx, "keycloak", code, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Subject != "u-1" || identity.Email != "ada@example.com" || !identity.EmailVerified || identity.Role != "admin" || !identity.SyncRole {
+		t.Errorf("Exchange() = %+v, want u-1 ada@example.com as admin", identity)
+	}
+
+	if _, err := m.Exchange(ctx, "keycloak", code, state); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Errorf("Exchange() replay = %v, want ErrInvalidOIDCState", err)
+	}
+	if _, err := m.Exchange(ctx, "okta", code, state); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("Exchange(okta) = %v, want ErrUnknownProvider", err)
+	}
+}
+
+func TestOIDCProvidersAreIsolated(t *testing.T) {
+	_, keycloak, _ := newTestOIDC(t)
+	other := oidctest.NewServer("intentr", "secret")
+	defer other.Close()
+	okta := NewOIDCProvider(OIDCProviderConfig{
+		Name: "okta", Issuer: other.URL, ClientID: "intentr", ClientSecret: "secret", RedirectURL: "http://localhost/cb",
+	}, other.Client())
+	m := NewOIDCManager(keycloak, okta)
+
+	if got := m.Providers(); len(got) != 2 || got[0].Name != "keycloak" || got[1].DisplayName != "okta" {
+		t.Errorf("Providers() = %+v, want keycloak then okta", got)
+	}
+
+	code, state := authorize(t, m, "keycloak")
+	if _, err := m.Exchange(context.Background(), "okta", code, state); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Errorf("Exchange() with another provider's state = %v, want ErrInvalidOIDCState", err)
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	srv, provider, _ := newTestOIDC(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		sign   func(jwt.MapClaims) (string, error)
+		ok     bool
+	}{
+		{name: "valid", claims: jwt.MapClaims{"sub": "u-1", "nonce": "n"}, ok: true},
+		{name: "wrong nonce", claims: jwt.MapClaims{"sub": "u-1", "nonce": "other"}},
+		{name: "other audience", claims: jwt.MapClaims{"sub": "u-1", "nonce": "n", "aud": "someone-else"}},
+		{name: "other authorized party", claims: jwt.MapClaims{"sub": "u-1", "nonce": "n", "aud": []string{"intentr", "api"}, "azp": "api"}},
+		{name: "other issuer", claims: jwt.MapClaims{"sub": "u-1", "nonce": "n", "iss": "https://evil.example.com"}},
+		{name: "expired", claims: jwt.MapClaims{"sub": "u-1", "nonce": "n", "exp": time.Now().Add(-time.Hour).Unix()}},
+		{name: "symmetric algorithm", claims: jwt.MapClaims{"sub": "u-1", "nonce": "n", "iss": srv.URL, "aud": "intentr", "exp": time.Now().Add(time.Hour).Unix()},
+			sign: func(c jwt.MapClaims) (string, error) {
+				return jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString([]byte("secret"))
+			}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sign := srv.SignIDToken
+			if tt.sign != nil {
+				sign = tt.sign
+			}
+			raw, err := sign(tt.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = provider.VerifyIDToken(ctx, raw, "n")
+			if (err == nil) != tt.ok {
+				t.Errorf("VerifyIDToken() = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenKeyRotation(t *testing.T) {
+	srv, provider, _ := newTestOIDC(t)
+	ctx := context.Background()
+
+	raw, _ := srv.SignIDToken(jwt.MapClaims{"sub": "u-1"})
+	if _, err := provider.VerifyIDToken(ctx, raw, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.RotateKey(); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ = srv.SignIDToken(jwt.MapClaims{"sub": "u-1"})
+
+	if _, err := provider.VerifyIDToken(ctx, raw, ""); err == nil {
+		t.Error("VerifyIDToken() refetched the JWKS within jwksMinRefresh")
+	}
+
+	defer func(d time.Duration) { jwksMinRefresh = d }(jwksMinRefresh)
+	jwksMinRefresh = 0
+	if _, err := provider.VerifyIDToken(ctx, raw, ""); err != nil {
+		t.Errorf("VerifyIDToken() after key rotation = %v", err)
+	}
+}
+
+func TestMapRole(t *testing.T) {
+	config := OIDCProviderConfig{
+		RoleClaim: "realm_access.roles",
+		RoleMappings: []OIDCRoleMapping{
+			{Value: "intentr-admin", Role: "admin"},
+			{Value: "intentr-owner", Role: "product_owner"},
+		},
+	}
+	claims := func(roles ...interface{}) map[string]interface{} {
+		return map[string]interface{}{"realm_access": map[string]interface{}{"roles": roles}}
+	}
+
+	if role, ok := config.MapRole(claims("intentr-owner", "intentr-admin")); !ok || role != "admin" {
+		t.Errorf("MapRole() = %q, %v, want the first mapping, admin", role, ok)
+	}
+	if role, ok := config.MapRole(claims("offline_access")); ok {
+		t.Errorf("MapRole() without a match or default role = %q, want denied", role)
+	}
+	config.DefaultRole = "user"
+	if role, ok := config.MapRole(claims()); !ok || role != "user" {
+		t.Errorf("MapRole() = %q, %v, want the default role", role, ok)
+	}
+	if role, ok := (OIDCProviderConfig{}).MapRole(nil); !ok || role != "user" {
+		t.Errorf("MapRole() without a role claim = %q, %v, want user", role, ok)
+	}
+}
+
+func TestLoadOIDCConfig(t *testing.T) {
+	t.Setenv("TEST_OKTA_SECRET", "s3cret")
+	path := filepath.Join(t.TempDir(), "oidc.yaml")
+	os.WriteFile(path, []byte(`
+providers:
+  - name: okta
+    displayName: Company SSO
+    issuer: https://example.okta.com
+    clientId: intentr
+    clientSecret: ${TEST_OKTA_SECRET}
+    redirectUrl: http://localhost:6173/auth/oidc/okta/callback
+    roleClaim: groups
+    roleMappings:
+      - value: admins
+        role: admin
+`), 0o600)
+
+	configs, err := LoadOIDCConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 1 || configs[0].ClientSecret != "s3cret" || configs[0].RoleMappings[0].Role != "admin" {
+		t.Errorf("LoadOIDCConfig() = %+v", configs)
+	}
+
+	os.WriteFile(path, []byte("providers:\n  - name: google\n    issuer: x\n    clientId: y\n    redirectUrl: z\n"), 0o600)
+	if _, err := LoadOIDCConfig(path); err == nil {
+		t.Error("LoadOIDCConfig() accepted the reserved name google")
+	}
+}
@@ -15,41 +15,72 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/jareynolds/intentr/pkg/repository"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var (
-	ErrInvalidCredentials = errors.New("invalid email or password")
-	ErrUserNotFound       = errors.New("user not found")
-	ErrUserExists         = errors.New("user with this email already exists")
-	ErrInvalidToken       = errors.New("invalid token")
-	ErrUnauthorized       = errors.New("unauthorized")
+	ErrInvalidCredentials  = errors.New("invalid email or password")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrUserExists          = errors.New("user with this email already exists")
+	ErrInvalidToken        = errors.New("invalid token")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrTokenNotFound       = errors.New("API token not found")
+	ErrInvalidTokenRequest = errors.New("invalid token request")
+)
+
+// Default token lifetimes
+const (
+	DefaultAccessTokenTTL  = 15 * time.Minute
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
 )
 
 // Service handles authentication operations
 type Service struct {
-	db        *sql.DB
-	jwtSecret []byte
+	db              *sql.DB
+	jwtSecret       []byte
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	workspaces      *repository.WorkspaceMembershipRepository
 }
 
 // NewService creates a new auth service
 func NewService(db *sql.DB, jwtSecret string) *Service {
 	return &Service{
-		db:        db,
-		jwtSecret: []byte(jwtSecret),
+		db:              db,
+		jwtSecret:       []byte(jwtSecret),
+		accessTokenTTL:  DefaultAccessTokenTTL,
+		refreshTokenTTL: DefaultRefreshTokenTTL,
+		workspaces:      repository.NewWorkspaceMembershipRepository(db),
 	}
 }
 
-// Claims represents JWT claims
+// SetTokenLifetimes sets how long access tokens and idle refresh tokens are valid
+func (s *Service) SetTokenLifetimes(access, refresh time.Duration) {
+	s.accessTokenTTL = access
+	s.refreshTokenTTL = refresh
+}
+
+// Claims represents JWT claims. Access tokens carry their session and the
+// user's token version at issue time. Claims of API tokens are built by
+// VerifyAPIToken and carry the token's ID and scopes; JWTs have neither.
 type Claims struct {
-	UserID int    `json:"userId"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+	UserID       int      `json:"userId"`
+	Email        string   `json:"email"`
+	Role         string   `json:"role"`
+	SessionID    int      `json:"sid,omitempty"`
+	TokenVersion int      `json:"ver"`
+	TokenID      int      `json:"tokenId,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// Authenticate authenticates a user and returns a JWT token
-func (s *Service) Authenticate(email, password string) (*LoginResponse, error) {
+// Authenticate authenticates a user and starts a session for the client.
+// When the user has two-factor login, or their role requires it, no session
+// is started; the returned challenge is completed by CompleteTwoFactorLogin.
+func (s *Service) Authenticate(email, password, userAgent, remoteAddr string) (*LoginResponse, *TwoFactorChallenge, error) {
 	var user User
 	err := s.db.QueryRow(`
 		SELECT id, email, password_hash, name, role, created_at, updated_at, last_login, is_active
@@ -68,54 +99,66 @@ func (s *Service) Authenticate(email, password string) (*LoginResponse, error) {
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, ErrInvalidCredentials
+		return nil, nil, ErrInvalidCredentials
 	}
 	if err != nil {
-		return nil, fmt.Errorf("database error: %w", err)
+		return nil, nil, fmt.Errorf("database error: %w", err)
 	}
 
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return nil, ErrInvalidCredentials
+		return nil, nil, ErrInvalidCredentials
 	}
 
-	// Update last login
-	_, err = s.db.Exec("UPDATE users SET last_login = $1 WHERE id = $2", time.Now(), user.ID)
+	return s.startLogin(&user, userAgent, remoteAddr)
+}
+
+// startLogin finishes any login once the user's first factor is checked,
+// whether a password or an external identity provider. A user who needs a
+// second factor gets a challenge instead of a session.
+func (s *Service) startLogin(user *User, userAgent, remoteAddr string) (*LoginResponse, *TwoFactorChallenge, error) {
+	challenge, err := s.loginChallenge(user)
 	if err != nil {
-		// Log error but don't fail authentication
-		fmt.Printf("Warning: failed to update last login: %v\n", err)
+		return nil, nil, err
+	}
+	if challenge != nil {
+		return nil, challenge, nil
 	}
 
-	// Generate JWT token
-	token, err := s.GenerateToken(&user)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+	// Update last login
+	if err := s.UpdateLastLogin(user.ID); err != nil {
+		// Log error but don't fail authentication
+		fmt.Printf("Warning: failed to update last login: %v\n", err)
 	}
 
-	return &LoginResponse{
-		Token: token,
-		User:  user,
-	}, nil
+	resp, err := s.StartSession(user, userAgent, remoteAddr)
+	return resp, nil, err
 }
 
-// GenerateToken generates a JWT token for a user
-func (s *Service) GenerateToken(user *User) (string, error) {
+// GenerateToken generates a short-lived JWT access token for a user's session
+func (s *Service) GenerateToken(user *User, sessionID, tokenVersion int) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.accessTokenTTL)
 	claims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   user.Role,
+		UserID:       user.ID,
+		Email:        user.Email,
+		Role:         user.Role,
+		SessionID:    sessionID,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.jwtSecret)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.jwtSecret)
+	return token, expiresAt, err
 }
 
-// VerifyToken verifies a JWT token and returns the claims
+// VerifyToken verifies a JWT token and returns the claims. A token is also
+// rejected once its user is deleted, deactivated or has its role changed,
+// when its session is revoked, and after the user logs out everywhere.
 func (s *Service) VerifyToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -128,11 +171,21 @@ func (s *Service) VerifyToken(tokenString string) (*Claims, error) {
 		return nil, ErrInvalidToken
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	// Access tokens have no audience; two-factor challenges do
+	if len(claims.Audience) > 0 {
+		return nil, ErrInvalidToken
 	}
 
-	return nil, ErrInvalidToken
+	if err := s.checkRevoked(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
 }
 
 // GetUserByID retrieves a user by ID
@@ -164,11 +217,12 @@ func (s *Service) GetUserByID(id int) (*User, error) {
 	return &user, nil
 }
 
-// ListUsers retrieves all users (admin only)
+// ListUsers retrieves all users except service accounts (admin only)
 func (s *Service) ListUsers() ([]User, error) {
 	rows, err := s.db.Query(`
 		SELECT id, email, name, role, created_at, updated_at, last_login, is_active
 		FROM users
+		WHERE id NOT IN (SELECT user_id FROM service_accounts)
 		ORDER BY created_at DESC
 	`)
 	if err != nil {
@@ -238,13 +292,19 @@ func (s *Service) CreateUser(req *CreateUserRequest) (*User, error) {
 	return &user, nil
 }
 
-// UpdateUser updates a user (admin only)
+// UpdateUser updates a user (admin only). Changing the email, role, status
+// or password rejects the user's access tokens from then on; deactivating
+// the user or changing the password also ends their sessions.
 func (s *Service) UpdateUser(id int, req *UpdateUserRequest) (*User, error) {
 	// Build dynamic update query
 	query := "UPDATE users SET updated_at = CURRENT_TIMESTAMP"
 	args := []interface{}{}
 	argCount := 1
 
+	if req.Email != nil || req.Role != nil || req.IsActive != nil || req.Password != nil {
+		query += ", token_version = token_version + 1"
+	}
+
 	if req.Email != nil {
 		query += fmt.Sprintf(", email = $%d", argCount)
 		args = append(args, *req.Email)
@@ -278,8 +338,14 @@ func (s *Service) UpdateUser(id int, req *UpdateUserRequest) (*User, error) {
 	query += fmt.Sprintf(" WHERE id = $%d RETURNING id, email, name, role, created_at, updated_at, last_login, is_active", argCount)
 	args = append(args, id)
 
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	var user User
-	err := s.db.QueryRow(query, args...).Scan(
+	err = tx.QueryRow(query, args...).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Name,
@@ -297,10 +363,21 @@ func (s *Service) UpdateUser(id int, req *UpdateUserRequest) (*User, error) {
 		return nil, fmt.Errorf("database error: %w", err)
 	}
 
+	if req.Password != nil || !user.IsActive {
+		if err := revokeSessions(tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return &user, nil
 }
 
-// DeleteUser deletes a user (admin only)
+// DeleteUser deletes a user (admin only). Their sessions and API tokens are
+// deleted with them, and VerifyToken rejects their access tokens.
 func (s *Service) DeleteUser(id int) error {
 	result, err := s.db.Exec("DELETE FROM users WHERE id = $1", id)
 	if err != nil {
@@ -393,22 +470,13 @@ func (s *Service) UpdateLastLogin(userID int) error {
 	return nil
 }
 
-// GenerateTokenFromCredentials generates a JWT token from user credentials
-func (s *Service) GenerateTokenFromCredentials(userID int, email, role string) (string, error) {
-	user := &User{
-		ID:    userID,
-		Email: email,
-		Role:  role,
-	}
-	return s.GenerateToken(user)
-}
-
 // ListShareableUsers returns all active users that can be shared with (excluding the current user)
 func (s *Service) ListShareableUsers(excludeUserID int) ([]ShareableUser, error) {
 	rows, err := s.db.Query(`
 		SELECT id, email, name
 		FROM users
 		WHERE is_active = true AND id != $1
+		  AND id NOT IN (SELECT user_id FROM service_accounts)
 		ORDER BY name ASC
 	`, excludeUserID)
 	if err != nil {
@@ -429,16 +497,21 @@ func (s *Service) ListShareableUsers(excludeUserID int) ([]ShareableUser, error)
 	return users, nil
 }
 
-// GetWorkspaceShares returns all shares for a workspace
-func (s *Service) GetWorkspaceShares(workspaceID string) ([]WorkspaceShare, error) {
+// GetWorkspaceShares returns the members a workspace is shared with: all
+// members except its owners
+func (s *Service) GetWorkspaceShares(workspaceID string, callerID int) ([]WorkspaceShare, error) {
+	if err := s.requireWorkspaceRole(workspaceID, callerID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+
 	rows, err := s.db.Query(`
-		SELECT ws.id, ws.workspace_id, ws.shared_with_user_id, u.email, u.name,
-		       ws.shared_by_user_id, ws.created_at, ws.updated_at
-		FROM workspace_shares ws
-		JOIN users u ON ws.shared_with_user_id = u.id
-		WHERE ws.workspace_id = $1
-		ORDER BY ws.created_at DESC
-	`, workspaceID)
+		SELECT m.id, m.workspace_id, m.user_id, u.email, u.name, m.role,
+		       COALESCE(m.granted_by, 0), m.created_at, m.updated_at
+		FROM workspace_memberships m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.workspace_id = $1 AND m.role != $2
+		ORDER BY m.created_at DESC
+	`, workspaceID, models.WorkspaceRoleOwner)
 	if err != nil {
 		return nil, fmt.Errorf("database error: %w", err)
 	}
@@ -453,6 +526,7 @@ func (s *Service) GetWorkspaceShares(workspaceID string) ([]WorkspaceShare, erro
 			&share.SharedWithUserID,
 			&share.SharedWithUserEmail,
 			&share.SharedWithUserName,
+			&share.Role,
 			&share.SharedByUserID,
 			&share.CreatedAt,
 			&share.UpdatedAt,
@@ -466,13 +540,14 @@ func (s *Service) GetWorkspaceShares(workspaceID string) ([]WorkspaceShare, erro
 	return shares, nil
 }
 
-// GetWorkspacesSharedWithUser returns all workspace IDs shared with a specific user
+// GetWorkspacesSharedWithUser returns all workspace IDs a user is a member of
+// without owning them
 func (s *Service) GetWorkspacesSharedWithUser(userID int) ([]string, error) {
 	rows, err := s.db.Query(`
-		SELECT DISTINCT workspace_id
-		FROM workspace_shares
-		WHERE shared_with_user_id = $1
-	`, userID)
+		SELECT workspace_id
+		FROM workspace_memberships
+		WHERE user_id = $1 AND role != $2
+	`, userID, models.WorkspaceRoleOwner)
 	if err != nil {
 		return nil, fmt.Errorf("database error: %w", err)
 	}
@@ -490,26 +565,36 @@ func (s *Service) GetWorkspacesSharedWithUser(userID int) ([]string, error) {
 	return workspaceIDs, nil
 }
 
-// UpdateWorkspaceShares updates the list of users a workspace is shared with
+// UpdateWorkspaceShares updates the list of users a workspace is shared with.
+// Newly shared users become contributors, users who stay keep their role and
+// owners are not affected. Only owners may share a workspace.
 func (s *Service) UpdateWorkspaceShares(workspaceID string, userIDs []int, sharedByUserID int) ([]WorkspaceShare, error) {
-	tx, err := s.db.Begin()
+	tx, err := s.beginMemberChange(workspaceID, sharedByUserID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, err
 	}
 	defer tx.Rollback()
 
-	// Delete existing shares for this workspace
-	_, err = tx.Exec("DELETE FROM workspace_shares WHERE workspace_id = $1", workspaceID)
+	// Remove members that are no longer shared with
+	keep := make([]int64, len(userIDs))
+	for i, id := range userIDs {
+		keep[i] = int64(id)
+	}
+	_, err = tx.Exec(`
+		DELETE FROM workspace_memberships
+		WHERE workspace_id = $1 AND role != $2 AND NOT (user_id = ANY($3))
+	`, workspaceID, models.WorkspaceRoleOwner, pq.Array(keep))
 	if err != nil {
 		return nil, fmt.Errorf("failed to delete existing shares: %w", err)
 	}
 
-	// Insert new shares
+	// Add new members
 	for _, userID := range userIDs {
 		_, err = tx.Exec(`
-			INSERT INTO workspace_shares (workspace_id, shared_with_user_id, shared_by_user_id)
-			VALUES ($1, $2, $3)
-		`, workspaceID, userID, sharedByUserID)
+			INSERT INTO workspace_memberships (workspace_id, user_id, role, granted_by)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (workspace_id, user_id) DO NOTHING
+		`, workspaceID, userID, models.WorkspaceRoleContributor, sharedByUserID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to insert share for user %d: %w", userID, err)
 		}
@@ -520,27 +605,10 @@ func (s *Service) UpdateWorkspaceShares(workspaceID string, userIDs []int, share
 	}
 
 	// Return updated shares
-	return s.GetWorkspaceShares(workspaceID)
+	return s.GetWorkspaceShares(workspaceID, sharedByUserID)
 }
 
 // RemoveWorkspaceShare removes a specific user's access to a workspace
-func (s *Service) RemoveWorkspaceShare(workspaceID string, userID int) error {
-	result, err := s.db.Exec(`
-		DELETE FROM workspace_shares
-		WHERE workspace_id = $1 AND shared_with_user_id = $2
-	`, workspaceID, userID)
-	if err != nil {
-		return fmt.Errorf("database error: %w", err)
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("error checking rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return ErrUserNotFound
-	}
-
-	return nil
+func (s *Service) RemoveWorkspaceShare(workspaceID string, userID int, callerID int) error {
+	return s.RemoveWorkspaceMember(workspaceID, userID, callerID)
 }
@@ -0,0 +1,275 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrRefreshTokenReused is returned when a refresh token that was already
+// rotated is presented again; the session it belonged to is revoked
+var ErrRefreshTokenReused = errors.New("refresh token was already used")
+
+// Refresh tokens are random like API tokens and stored the same way
+const refreshTokenPrefix = "intentr_rt_"
+
+// execer is satisfied by *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// revokeSessions ends all active sessions of a user
+func revokeSessions(q execer, userID int) error {
+	_, err := q.Exec(`
+		UPDATE sessions SET is_active = false, revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND is_active = true
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return nil
+}
+
+// checkRevoked rejects access token claims whose user is gone, inactive or
+// changed since the token was issued, or whose session has ended
+func (s *Service) checkRevoked(claims *Claims) error {
+	var isActive bool
+	var tokenVersion int
+	var role string
+	var sessionActive sql.NullBool
+	err := s.db.QueryRow(`
+		SELECT u.is_active, u.token_version, u.role, s.is_active
+		FROM users u
+		LEFT JOIN sessions s ON s.id = $2 AND s.user_id = u.id
+		WHERE u.id = $1
+	`, claims.UserID, claims.SessionID).Scan(&isActive, &tokenVersion, &role, &sessionActive)
+	if err == sql.ErrNoRows {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+
+	if !isActive || tokenVersion != claims.TokenVersion || role != claims.Role {
+		return ErrInvalidToken
+	}
+	if claims.SessionID != 0 && !(sessionActive.Valid && sessionActive.Bool) {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// StartSession starts a session for an authenticated user and returns its
+// access and refresh tokens
+func (s *Service) StartSession(user *User, userAgent, remoteAddr string) (*LoginResponse, error) {
+	refreshToken, err := generateAPIToken(refreshTokenPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessionID, tokenVersion int
+	err = s.db.QueryRow(`
+		INSERT INTO sessions (user_id, token_hash, expires_at, user_agent, ip_address)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, (SELECT token_version FROM users WHERE id = $1)
+	`, user.ID, hashAPIToken(refreshToken), time.Now().Add(s.refreshTokenTTL), userAgent, clientIP(remoteAddr)).Scan(&sessionID, &tokenVersion)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	token, expiresAt, err := s.GenerateToken(user, sessionID, tokenVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &LoginResponse{
+		Token:        token,
+		ExpiresAt:    expiresAt,
+		RefreshToken: refreshToken,
+		User:         *user,
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh
+// token. The old refresh token stops working; presenting it again revokes
+// the session, since only a copy of it can still be in use.
+func (s *Service) Refresh(refreshToken, userAgent, remoteAddr string) (*LoginResponse, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hash := hashAPIToken(refreshToken)
+	var sessionID int
+	var current, sessionActive bool
+	var expiresAt time.Time
+	var user User
+	var tokenVersion int
+	err = tx.QueryRow(`
+		SELECT s.id, s.token_hash = $1, s.is_active, s.expires_at,
+		       u.id, u.email, u.name, u.role, u.created_at, u.updated_at, u.last_login, u.is_active, u.token_version
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.token_hash = $1 OR s.previous_token_hash = $1
+		FOR UPDATE OF s
+	`, hash).Scan(
+		&sessionID,
+		&current,
+		&sessionActive,
+		&expiresAt,
+		&user.ID,
+		&user.Email,
+		&user.Name,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.LastLogin,
+		&user.IsActive,
+		&tokenVersion,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	if !sessionActive || !time.Now().Before(expiresAt) || !user.IsActive {
+		return nil, ErrInvalidToken
+	}
+
+	if !current {
+		if _, err := tx.Exec(`
+			UPDATE sessions SET is_active = false, revoked_at = CURRENT_TIMESTAMP WHERE id = $1
+		`, sessionID); err != nil {
+			return nil, fmt.Errorf("failed to revoke session: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		log.Printf("Warning: refresh token reuse for user %d, session %d revoked", user.ID, sessionID)
+		return nil, ErrRefreshTokenReused
+	}
+
+	next, err := generateAPIToken(refreshTokenPrefix)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(`
+		UPDATE sessions
+		SET previous_token_hash = token_hash, token_hash = $1, refreshed_at = CURRENT_TIMESTAMP,
+		    expires_at = $2, user_agent = $3, ip_address = $4
+		WHERE id = $5
+	`, hashAPIToken(next), time.Now().Add(s.refreshTokenTTL), userAgent, clientIP(remoteAddr), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	token, accessExpiresAt, err := s.GenerateToken(&user, sessionID, tokenVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &LoginResponse{
+		Token:        token,
+		ExpiresAt:    accessExpiresAt,
+		RefreshToken: next,
+		User:         user,
+	}, nil
+}
+
+// Logout ends a session of a user
+func (s *Service) Logout(userID, sessionID int) error {
+	_, err := s.db.Exec(`
+		UPDATE sessions SET is_active = false, revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND is_active = true
+	`, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	return nil
+}
+
+// LogoutEverywhere ends all sessions of a user and rejects every access
+// token issued to them so far. API tokens are not affected.
+func (s *Service) LogoutEverywhere(userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE users SET token_version = token_version + 1 WHERE id = $1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	if err := revokeSessions(tx, userID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListSessions returns the active sessions of a user, marking currentID
+func (s *Service) ListSessions(userID, currentID int) ([]Session, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, created_at, refreshed_at, expires_at,
+		       COALESCE(user_agent, ''), COALESCE(ip_address, '')
+		FROM sessions
+		WHERE user_id = $1 AND is_active = true AND expires_at > CURRENT_TIMESTAMP
+		ORDER BY COALESCE(refreshed_at, created_at) DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var session Session
+		err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.CreatedAt,
+			&session.RefreshedAt,
+			&session.ExpiresAt,
+			&session.UserAgent,
+			&session.IPAddress,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		session.Current = session.ID == currentID
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
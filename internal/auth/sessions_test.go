@@ -0,0 +1,145 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jareynolds/intentr/internal/testdb"
+)
+
+// newSessionService returns a Service on a test database and a session of a
+// new user
+func newSessionService(t *testing.T) (*Service, *LoginResponse) {
+	t.Helper()
+	db := testdb.Open(t)
+	s := NewService(db, "secret")
+
+	user, err := s.GetUserByID(testdb.CreateUser(t, db, "member", "user"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	login, err := s.StartSession(user, "test", "192.0.2.1:1234")
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	return s, login
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	s, login := newSessionService(t)
+
+	refreshed, err := s.Refresh(login.RefreshToken, "test", "192.0.2.1:1234")
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if refreshed.RefreshToken == login.RefreshToken {
+		t.Error("Refresh() returned the same refresh token")
+	}
+	claims, err := s.VerifyToken(refreshed.Token)
+	if err != nil {
+		t.Fatalf("VerifyToken(refreshed) error = %v", err)
+	}
+	original, err := s.VerifyToken(login.Token)
+	if err != nil {
+		t.Fatalf("VerifyToken(original) error = %v", err)
+	}
+	if claims.SessionID != original.SessionID {
+		t.Errorf("refreshed session = %d, want %d", claims.SessionID, original.SessionID)
+	}
+
+	// The rotated token keeps the session going
+	if _, err := s.Refresh(refreshed.RefreshToken, "test", "192.0.2.1:1234"); err != nil {
+		t.Errorf("Refresh(rotated) error = %v", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	s, login := newSessionService(t)
+
+	refreshed, err := s.Refresh(login.RefreshToken, "test", "192.0.2.1:1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replaying the rotated token means a copy of it is in use elsewhere
+	if _, err := s.Refresh(login.RefreshToken, "test", "198.51.100.7:4321"); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("Refresh(replayed) error = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := s.Refresh(refreshed.RefreshToken, "test", "192.0.2.1:1234"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Refresh(new) after reuse error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := s.VerifyToken(refreshed.Token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyToken() after reuse error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestTokenVersionBumpRejectsAccessTokens(t *testing.T) {
+	s, login := newSessionService(t)
+	claims, err := s.VerifyToken(login.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Changing only the name keeps outstanding tokens
+	name := "Renamed"
+	if _, err := s.UpdateUser(claims.UserID, &UpdateUserRequest{Name: &name}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.VerifyToken(login.Token); err != nil {
+		t.Errorf("VerifyToken() after a name change error = %v", err)
+	}
+
+	role := "admin"
+	if _, err := s.UpdateUser(claims.UserID, &UpdateUserRequest{Role: &role}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.VerifyToken(login.Token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyToken() after a role change error = %v, want ErrInvalidToken", err)
+	}
+
+	// Tokens issued after the bump carry the new version
+	refreshed, err := s.Refresh(login.RefreshToken, "test", "192.0.2.1:1234")
+	if err != nil {
+		t.Fatalf("Refresh() after a role change error = %v", err)
+	}
+	if _, err := s.VerifyToken(refreshed.Token); err != nil {
+		t.Errorf("VerifyToken(refreshed) error = %v", err)
+	}
+
+	if err := s.LogoutEverywhere(claims.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.VerifyToken(refreshed.Token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyToken() after LogoutEverywhere error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := s.Refresh(refreshed.RefreshToken, "test", "192.0.2.1:1234"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Refresh() after LogoutEverywhere error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestRefreshRejectsDeactivatedUser(t *testing.T) {
+	s, login := newSessionService(t)
+	claims, err := s.VerifyToken(login.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Deactivated without going through UpdateUser, so the session is still active
+	if _, err := s.db.Exec(`UPDATE users SET is_active = false WHERE id = $1`, claims.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Refresh(login.RefreshToken, "test", "192.0.2.1:1234"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Refresh() for a deactivated user error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := s.VerifyToken(login.Token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyToken() for a deactivated user error = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,210 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// respondTokenError maps token and service account errors to responses
+func respondTokenError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidTokenRequest):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrTokenNotFound):
+		respondError(w, http.StatusNotFound, "API token not found")
+	case errors.Is(err, ErrUserNotFound):
+		respondError(w, http.StatusNotFound, "Service account not found")
+	case errors.Is(err, ErrUserExists):
+		respondError(w, http.StatusConflict, "A service account with this name already exists")
+	default:
+		log.Printf("%s error: %v", action, err)
+		respondError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
+
+// pathID parses an integer path value
+func pathID(r *http.Request, name string) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	return id, err == nil
+}
+
+// ListAPITokens returns the current user's API tokens
+func (h *Handler) ListAPITokens(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(*Claims)
+
+	tokens, err := h.service.ListAPITokens(claims.UserID)
+	if err != nil {
+		respondTokenError(w, "List API tokens", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tokens)
+}
+
+// CreateAPIToken creates a personal access token for the current user. Tokens
+// are created from a login session only, never from another token.
+func (h *Handler) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(*Claims)
+	if claims.TokenID != 0 {
+		respondError(w, http.StatusForbidden, "API tokens cannot create API tokens")
+		return
+	}
+
+	var req CreateAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	resp, err := h.service.CreatePersonalAccessToken(claims.UserID, &req)
+	if err != nil {
+		respondTokenError(w, "Create API token", err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, resp)
+}
+
+// RevokeAPIToken revokes one of the current user's API tokens
+func (h *Handler) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(*Claims)
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Invalid token ID")
+		return
+	}
+
+	if err := h.service.RevokeAPIToken(claims.UserID, id); err != nil {
+		respondTokenError(w, "Revoke API token", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListServiceAccounts returns all service accounts (admin only)
+func (h *Handler) ListServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	accounts, err := h.service.ListServiceAccounts()
+	if err != nil {
+		respondTokenError(w, "List service accounts", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, accounts)
+}
+
+// CreateServiceAccount creates a service account (admin only)
+func (h *Handler) CreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(*Claims)
+
+	var req CreateServiceAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Name == "" {
+		respondError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	account, err := h.service.CreateServiceAccount(&req, claims.UserID)
+	if err != nil {
+		respondTokenError(w, "Create service account", err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, account)
+}
+
+// GetServiceAccount returns a service account with its tokens (admin only)
+func (h *Handler) GetServiceAccount(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Invalid service account ID")
+		return
+	}
+
+	account, err := h.service.GetServiceAccount(id)
+	if err != nil {
+		respondTokenError(w, "Get service account", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, account)
+}
+
+// DeactivateServiceAccount deactivates a service account and revokes its
+// tokens (admin only)
+func (h *Handler) DeactivateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Invalid service account ID")
+		return
+	}
+
+	if err := h.service.DeactivateServiceAccount(id); err != nil {
+		respondTokenError(w, "Deactivate service account", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateServiceAccountToken creates a token for a service account (admin only)
+func (h *Handler) CreateServiceAccountToken(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(*Claims)
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Invalid service account ID")
+		return
+	}
+
+	var req CreateAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	resp, err := h.service.CreateServiceAccountToken(id, &req, claims.UserID)
+	if err != nil {
+		respondTokenError(w, "Create service account token", err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, resp)
+}
+
+// RevokeServiceAccountToken revokes a service account token (admin only)
+func (h *Handler) RevokeServiceAccountToken(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Invalid service account ID")
+		return
+	}
+	tokenID, ok := pathID(r, "tokenId")
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Invalid token ID")
+		return
+	}
+
+	if err := h.service.RevokeAPIToken(id, tokenID); err != nil {
+		respondTokenError(w, "Revoke service account token", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
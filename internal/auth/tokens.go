@@ -0,0 +1,496 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// API tokens are opaque random strings, recognised by their prefix. Only
+// their SHA-256 hash is stored: they carry 256 bits of randomness, so unlike
+// passwords they do not need a slow hash.
+const (
+	APITokenPrefix            = "intentr_"
+	personalAccessTokenPrefix = APITokenPrefix + "pat_"
+	serviceAccountTokenPrefix = APITokenPrefix + "sat_"
+
+	displayPrefixLength = 16 // Characters of a token kept to recognise it
+
+	DefaultTokenExpiryDays = 90
+	MaxTokenExpiryDays     = 365
+
+	serviceAccountEmailDomain = "service-accounts.intentr.local"
+
+	// lastUsedInterval limits how often using a token writes last_used_at
+	lastUsedInterval = time.Minute
+)
+
+// Token scopes. write includes read and admin includes both; admin is only
+// granted to tokens of admin users.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+// Scopes lists the valid token scopes
+var Scopes = []string{ScopeRead, ScopeWrite, ScopeAdmin}
+
+var scopeRank = map[string]int{ScopeRead: 1, ScopeWrite: 2, ScopeAdmin: 3}
+
+// HasScope reports whether the claims allow scope. JWT sessions are not
+// scoped and allow everything their role allows.
+func (c *Claims) HasScope(scope string) bool {
+	if c.TokenID == 0 {
+		return true
+	}
+	for _, s := range c.Scopes {
+		if scopeRank[s] >= scopeRank[scope] {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin reports whether the claims are an admin's, with the admin scope
+// for API tokens
+func (c *Claims) IsAdmin() bool {
+	return c.Role == "admin" && c.HasScope(ScopeAdmin)
+}
+
+// ScopeForMethod returns the scope an API token needs to make a request
+// with the given HTTP method
+func ScopeForMethod(method string) string {
+	switch method {
+	case "GET", "HEAD", "OPTIONS":
+		return ScopeRead
+	default:
+		return ScopeWrite
+	}
+}
+
+// IsAPIToken reports whether a bearer token is an API token rather than a JWT
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// clientIP strips the port from a request's remote address
+func clientIP(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
+
+// hashAPIToken returns the stored form of an API token
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateAPIToken returns a new random token with the given prefix
+func generateAPIToken(prefix string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+// validateScopes checks requested scopes against the token owner's role and
+// defaults them to read
+func validateScopes(scopes []string, role string) ([]string, error) {
+	if len(scopes) == 0 {
+		return []string{ScopeRead}, nil
+	}
+	for _, scope := range scopes {
+		if _, ok := scopeRank[scope]; !ok {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidTokenRequest, scope)
+		}
+		if scope == ScopeAdmin && role != "admin" {
+			return nil, fmt.Errorf("%w: only admin users can have admin tokens", ErrInvalidTokenRequest)
+		}
+	}
+	return scopes, nil
+}
+
+// tokenExpiry returns the expiry time of a token created now. Tokens without
+// an expiry are only allowed for service accounts.
+func tokenExpiry(expiresInDays *int, allowNoExpiry bool) (*time.Time, error) {
+	days := DefaultTokenExpiryDays
+	if expiresInDays != nil {
+		days = *expiresInDays
+	}
+	if days == 0 && allowNoExpiry {
+		return nil, nil
+	}
+	if days < 1 || days > MaxTokenExpiryDays {
+		return nil, fmt.Errorf("%w: expiresInDays must be between 1 and %d", ErrInvalidTokenRequest, MaxTokenExpiryDays)
+	}
+	expiresAt := time.Now().Add(time.Duration(days) * 24 * time.Hour)
+	return &expiresAt, nil
+}
+
+// VerifyBearerToken verifies the bearer token of a request, an API token or
+// a JWT, and returns its claims
+func (s *Service) VerifyBearerToken(token, remoteAddr string) (*Claims, error) {
+	if IsAPIToken(token) {
+		return s.VerifyAPIToken(token, remoteAddr)
+	}
+	return s.VerifyToken(token)
+}
+
+// VerifyAPIToken verifies an API token and returns claims for its user,
+// recording when and from where it was used
+func (s *Service) VerifyAPIToken(token, remoteAddr string) (*Claims, error) {
+	var claims Claims
+	var scopes pq.StringArray
+	var expiresAt, revokedAt, lastUsedAt *time.Time
+	var lastUsedIP string
+	var isActive bool
+	err := s.db.QueryRow(`
+		SELECT t.id, t.user_id, t.scopes, t.expires_at, t.revoked_at, t.last_used_at,
+		       COALESCE(t.last_used_ip, ''), u.email, u.role, u.is_active
+		FROM api_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1
+	`, hashAPIToken(token)).Scan(
+		&claims.TokenID,
+		&claims.UserID,
+		&scopes,
+		&expiresAt,
+		&revokedAt,
+		&lastUsedAt,
+		&lastUsedIP,
+		&claims.Email,
+		&claims.Role,
+		&isActive,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	remoteAddr = clientIP(remoteAddr)
+	now := time.Now()
+	if revokedAt != nil || (expiresAt != nil && !now.Before(*expiresAt)) || !isActive {
+		return nil, ErrInvalidToken
+	}
+	claims.Scopes = scopes
+
+	if lastUsedAt == nil || now.Sub(*lastUsedAt) >= lastUsedInterval || lastUsedIP != remoteAddr {
+		_, err = s.db.Exec("UPDATE api_tokens SET last_used_at = $1, last_used_ip = $2 WHERE id = $3", now, remoteAddr, claims.TokenID)
+		if err != nil {
+			// Log error but don't fail authentication
+			fmt.Printf("Warning: failed to update token last used: %v\n", err)
+		}
+	}
+
+	return &claims, nil
+}
+
+// createAPIToken creates a token for userID
+func (s *Service) createAPIToken(userID int, req *CreateAPITokenRequest, createdBy int, prefix string, allowNoExpiry bool) (*CreateAPITokenResponse, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidTokenRequest)
+	}
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	scopes, err := validateScopes(req.Scopes, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt, err := tokenExpiry(req.ExpiresInDays, allowNoExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := generateAPIToken(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := CreateAPITokenResponse{Token: token}
+	t := &resp.APIToken
+	err = s.db.QueryRow(`
+		INSERT INTO api_tokens (user_id, name, token_prefix, token_hash, scopes, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, user_id, name, token_prefix, scopes, expires_at, created_by, created_at
+	`, userID, req.Name, token[:displayPrefixLength], hashAPIToken(token), pq.Array(scopes), expiresAt, createdBy).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.Name,
+		&t.Prefix,
+		(*pq.StringArray)(&t.Scopes),
+		&t.ExpiresAt,
+		&t.CreatedBy,
+		&t.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// CreatePersonalAccessToken creates a token that acts as userID
+func (s *Service) CreatePersonalAccessToken(userID int, req *CreateAPITokenRequest) (*CreateAPITokenResponse, error) {
+	return s.createAPIToken(userID, req, userID, personalAccessTokenPrefix, false)
+}
+
+// ListAPITokens returns the tokens of a user, newest first, including
+// revoked and expired ones
+func (s *Service) ListAPITokens(userID int) ([]APIToken, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, name, token_prefix, scopes, expires_at, revoked_at,
+		       last_used_at, COALESCE(last_used_ip, ''), created_by, created_at
+		FROM api_tokens
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []APIToken{}
+	for rows.Next() {
+		var t APIToken
+		err := rows.Scan(
+			&t.ID,
+			&t.UserID,
+			&t.Name,
+			&t.Prefix,
+			(*pq.StringArray)(&t.Scopes),
+			&t.ExpiresAt,
+			&t.RevokedAt,
+			&t.LastUsedAt,
+			&t.LastUsedIP,
+			&t.CreatedBy,
+			&t.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		tokens = append(tokens, t)
+	}
+
+	return tokens, nil
+}
+
+// RevokeAPIToken revokes a token of userID. It takes effect on the token's
+// next use.
+func (s *Service) RevokeAPIToken(userID, tokenID int) error {
+	result, err := s.db.Exec(`
+		UPDATE api_tokens SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrTokenNotFound
+	}
+
+	return nil
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// CreateServiceAccount creates a service account (admin only). Service
+// accounts have no password and authenticate with their API tokens only.
+func (s *Service) CreateServiceAccount(req *CreateServiceAccountRequest, createdBy int) (*ServiceAccount, error) {
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(req.Name), "-"), "-")
+	if slug == "" {
+		return nil, fmt.Errorf("%w: name must contain letters or digits", ErrInvalidTokenRequest)
+	}
+
+	role := req.Role
+	if role == "" {
+		role = "user"
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	account := ServiceAccount{Description: req.Description, CreatedBy: &createdBy}
+	user := &account.User
+	err = tx.QueryRow(`
+		INSERT INTO users (email, password_hash, name, role, is_active)
+		VALUES ($1, '', $2, $3, true)
+		RETURNING id, email, name, role, created_at, updated_at, last_login, is_active
+	`, slug+"@"+serviceAccountEmailDomain, req.Name, role).Scan(
+		&user.ID,
+		&user.Email,
+		&user.Name,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.LastLogin,
+		&user.IsActive,
+	)
+	if err != nil {
+		if err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"` {
+			return nil, ErrUserExists
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO service_accounts (user_id, description, created_by)
+		VALUES ($1, $2, $3)
+	`, user.ID, req.Description, createdBy)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &account, nil
+}
+
+const serviceAccountColumns = `
+	SELECT u.id, u.email, u.name, u.role, u.created_at, u.updated_at, u.last_login, u.is_active,
+	       COALESCE(sa.description, ''), sa.created_by
+	FROM service_accounts sa
+	JOIN users u ON u.id = sa.user_id`
+
+func scanServiceAccount(row interface{ Scan(...interface{}) error }) (*ServiceAccount, error) {
+	var account ServiceAccount
+	err := row.Scan(
+		&account.ID,
+		&account.Email,
+		&account.Name,
+		&account.Role,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+		&account.LastLogin,
+		&account.IsActive,
+		&account.Description,
+		&account.CreatedBy,
+	)
+	return &account, err
+}
+
+// ListServiceAccounts returns all service accounts (admin only)
+func (s *Service) ListServiceAccounts() ([]ServiceAccount, error) {
+	rows, err := s.db.Query(serviceAccountColumns + ` ORDER BY u.name ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []ServiceAccount{}
+	for rows.Next() {
+		account, err := scanServiceAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		accounts = append(accounts, *account)
+	}
+
+	return accounts, nil
+}
+
+// GetServiceAccount returns a service account with its tokens
+func (s *Service) GetServiceAccount(id int) (*ServiceAccount, error) {
+	account, err := scanServiceAccount(s.db.QueryRow(serviceAccountColumns+` WHERE u.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	account.Tokens, err = s.ListAPITokens(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// CreateServiceAccountToken creates a token for an active service account
+// (admin only). Service account tokens may be created without an expiry.
+func (s *Service) CreateServiceAccountToken(id int, req *CreateAPITokenRequest, createdBy int) (*CreateAPITokenResponse, error) {
+	account, err := s.GetServiceAccount(id)
+	if err != nil {
+		return nil, err
+	}
+	if !account.IsActive {
+		return nil, ErrUserNotFound
+	}
+	return s.createAPIToken(id, req, createdBy, serviceAccountTokenPrefix, true)
+}
+
+// DeactivateServiceAccount deactivates a service account and revokes its
+// tokens (admin only). The account is kept so the changes it made stay
+// attributed to it.
+func (s *Service) DeactivateServiceAccount(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE users SET is_active = false, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND id IN (SELECT user_id FROM service_accounts)
+	`, id)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	_, err = tx.Exec(`
+		UPDATE api_tokens SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, id)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims Claims
+		scope  string
+		want   bool
+	}{
+		{"jwt session", Claims{}, ScopeAdmin, true},
+		{"read token reads", Claims{TokenID: 1, Scopes: []string{ScopeRead}}, ScopeRead, true},
+		{"read token cannot write", Claims{TokenID: 1, Scopes: []string{ScopeRead}}, ScopeWrite, false},
+		{"write token reads", Claims{TokenID: 1, Scopes: []string{ScopeWrite}}, ScopeRead, true},
+		{"write token is not admin", Claims{TokenID: 1, Scopes: []string{ScopeRead, ScopeWrite}}, ScopeAdmin, false},
+		{"admin token writes", Claims{TokenID: 1, Scopes: []string{ScopeAdmin}}, ScopeWrite, true},
+		{"unscoped token", Claims{TokenID: 1}, ScopeRead, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.claims.HasScope(tt.scope); got != tt.want {
+				t.Errorf("HasScope(%q) = %v, want %v", tt.scope, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	if got, err := validateScopes(nil, "user"); err != nil || len(got) != 1 || got[0] != ScopeRead {
+		t.Errorf("validateScopes(nil) = %v, %v, want [read]", got, err)
+	}
+	if _, err := validateScopes([]string{"delete"}, "admin"); !errors.Is(err, ErrInvalidTokenRequest) {
+		t.Errorf("validateScopes(unknown) = %v, want ErrInvalidTokenRequest", err)
+	}
+	if _, err := validateScopes([]string{ScopeAdmin}, "user"); !errors.Is(err, ErrInvalidTokenRequest) {
+		t.Errorf("validateScopes(admin) for a user = %v, want ErrInvalidTokenRequest", err)
+	}
+	if _, err := validateScopes([]string{ScopeAdmin}, "admin"); err != nil {
+		t.Errorf("validateScopes(admin) for an admin = %v", err)
+	}
+}
+
+func TestTokenExpiry(t *testing.T) {
+	zero, days := 0, 400
+	if _, err := tokenExpiry(&zero, false); err == nil {
+		t.Error("tokenExpiry(0) allowed a personal access token that never expires")
+	}
+	if got, err := tokenExpiry(&zero, true); err != nil || got != nil {
+		t.Errorf("tokenExpiry(0) for a service account = %v, %v, want no expiry", got, err)
+	}
+	if _, err := tokenExpiry(&days, true); err == nil {
+		t.Errorf("tokenExpiry(%d) allowed more than %d days", days, MaxTokenExpiryDays)
+	}
+	if got, err := tokenExpiry(nil, false); err != nil || got == nil {
+		t.Errorf("tokenExpiry(nil) = %v, %v, want the default expiry", got, err)
+	}
+}
+
+func TestGenerateAPIToken(t *testing.T) {
+	a, err := generateAPIToken(personalAccessTokenPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := generateAPIToken(personalAccessTokenPrefix)
+	if a == b {
+		t.Error("generateAPIToken() returned the same token twice")
+	}
+	if !IsAPIToken(a) || !strings.HasPrefix(a, personalAccessTokenPrefix) {
+		t.Errorf("generateAPIToken() = %q, want the %q prefix", a, personalAccessTokenPrefix)
+	}
+	if IsAPIToken("eyJhbGciOiJIUzI1NiJ9.e30.sig") {
+		t.Error("IsAPIToken() accepted a JWT")
+	}
+	if h := hashAPIToken(a); len(h) != 64 || h == hashAPIToken(b) {
+		t.Errorf("hashAPIToken() = %q, want distinct SHA-256 hex digests", h)
+	}
+}
+
+func TestGenerateToken(t *testing.T) {
+	s := NewService(nil, "secret")
+	s.SetTokenLifetimes(5*time.Minute, time.Hour)
+
+	token, expiresAt, err := s.GenerateToken(&User{ID: 7, Email: "a@example.com", Role: "admin"}, 42, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Until(expiresAt); d <= 4*time.Minute || d > 5*time.Minute {
+		t.Errorf("GenerateToken() expires in %v, want the 5m access token lifetime", d)
+	}
+
+	claims := &Claims{}
+	if _, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) { return []byte("secret"), nil }); err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != 7 || claims.SessionID != 42 || claims.TokenVersion != 3 || claims.TokenID != 0 {
+		t.Errorf("GenerateToken() claims = %+v, want user 7, session 42, version 3", claims)
+	}
+}
@@ -0,0 +1,144 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package auth
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 test key of RFC 6238 appendix B
+var rfc6238Secret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B lists 8-digit codes; these are their last 6 digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		if got := totpCode([]byte("12345678901234567890"), tt.unix/totpPeriod); got != tt.want {
+			t.Errorf("totpCode(T=%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / totpPeriod
+
+	got, ok := verifyTOTP(rfc6238Secret, "050471", now, 0)
+	if !ok || got != step {
+		t.Fatalf("verifyTOTP(current code) = %d, %v, want step %d", got, ok, step)
+	}
+	if _, ok := verifyTOTP(rfc6238Secret, "050471", now, step); ok {
+		t.Error("verifyTOTP() accepted a code that was already used")
+	}
+	if _, ok := verifyTOTP(rfc6238Secret, "050471", now.Add(totpPeriod*time.Second), 0); !ok {
+		t.Error("verifyTOTP() rejected the previous step's code")
+	}
+	if _, ok := verifyTOTP(rfc6238Secret, "050471", now.Add(3*totpPeriod*time.Second), 0); ok {
+		t.Error("verifyTOTP() accepted a code outside the drift window")
+	}
+	if _, ok := verifyTOTP(rfc6238Secret, "123456", now, 0); ok {
+		t.Error("verifyTOTP() accepted a wrong code")
+	}
+	if _, ok := verifyTOTP(rfc6238Secret, "", now, 0); ok {
+		t.Error("verifyTOTP() accepted an empty code")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	u, err := url.Parse(totpURI("a@example.com", rfc6238Secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Query().Get("secret") != rfc6238Secret || u.Query().Get("issuer") != totpIssuer {
+		t.Errorf("totpURI() = %s", u)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("generateRecoveryCodes() returned %d codes, want %d", len(codes), recoveryCodeCount)
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' || seen[code] {
+			t.Errorf("generateRecoveryCodes() returned %q", code)
+		}
+		seen[code] = true
+	}
+
+	if hashRecoveryCode(codes[0]) != hashRecoveryCode(strings.ToUpper(strings.ReplaceAll(codes[0], "-", " "))) {
+		t.Error("hashRecoveryCode() depends on case or separators")
+	}
+}
+
+func TestSealTOTPSecret(t *testing.T) {
+	s := NewService(nil, "secret")
+	sealed, err := s.sealTOTPSecret(rfc6238Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sealed, rfc6238Secret) {
+		t.Error("sealTOTPSecret() stored the secret in plain text")
+	}
+	if got, err := s.openTOTPSecret(sealed); err != nil || got != rfc6238Secret {
+		t.Errorf("openTOTPSecret() = %q, %v", got, err)
+	}
+	if _, err := NewService(nil, "other").openTOTPSecret(sealed); err == nil {
+		t.Error("openTOTPSecret() opened a secret sealed with another key")
+	}
+}
+
+func TestChallengeTokens(t *testing.T) {
+	s := NewService(nil, "secret")
+
+	challenge, err := s.issueChallenge(7, challengeSetup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !challenge.TwoFactorRequired || !challenge.SetupRequired {
+		t.Errorf("issueChallenge(setup) = %+v", challenge)
+	}
+
+	claims, err := s.parseChallenge(challenge.ChallengeToken)
+	if err != nil || claims.UserID != 7 || claims.Purpose != challengeSetup {
+		t.Errorf("parseChallenge() = %+v, %v", claims, err)
+	}
+	if _, err := s.VerifyToken(challenge.ChallengeToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyToken(challenge) = %v, want ErrInvalidToken", err)
+	}
+
+	access, _, err := s.GenerateToken(&User{ID: 7, Role: "admin"}, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.parseChallenge(access); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("parseChallenge(access token) = %v, want ErrInvalidToken", err)
+	}
+	if _, err := NewService(nil, "other").parseChallenge(challenge.ChallengeToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("parseChallenge(other key) = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,504 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorRequired    = errors.New("two-factor authentication is required for this role")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	ErrTwoFactorLocked      = errors.New("too many failed two-factor codes")
+)
+
+const (
+	// challengeTTL is how long the second login step can be completed
+	challengeTTL = 5 * time.Minute
+	// challengeAudience marks challenge tokens; VerifyToken rejects tokens
+	// with an audience, so a challenge is never an access token
+	challengeAudience = "intentr-2fa"
+
+	challengeLogin = "login"
+	challengeSetup = "setup"
+
+	// After maxTwoFactorFailures wrong codes in a row the second step is
+	// locked for twoFactorLockout
+	maxTwoFactorFailures = 5
+	twoFactorLockout     = 15 * time.Minute
+)
+
+// challengeClaims are the claims of a challenge token
+type challengeClaims struct {
+	UserID  int    `json:"userId"`
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// issueChallenge returns a challenge for the second login step
+func (s *Service) issueChallenge(userID int, purpose string) (*TwoFactorChallenge, error) {
+	expiresAt := time.Now().Add(challengeTTL)
+	claims := challengeClaims{
+		UserID:  userID,
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{challengeAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.jwtSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign challenge: %w", err)
+	}
+
+	return &TwoFactorChallenge{
+		TwoFactorRequired: true,
+		SetupRequired:     purpose == challengeSetup,
+		ChallengeToken:    token,
+		ExpiresAt:         expiresAt,
+	}, nil
+}
+
+// parseChallenge verifies a challenge token
+func (s *Service) parseChallenge(token string) (*challengeClaims, error) {
+	claims := &challengeClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		return s.jwtSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(challengeAudience))
+	if err != nil || claims.UserID == 0 {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// twoFactorRequiredForRole reports whether a role must use two-factor login
+func (s *Service) twoFactorRequiredForRole(role string) (bool, error) {
+	var required bool
+	err := s.db.QueryRow(`
+		SELECT required FROM two_factor_policies WHERE role = $1
+	`, role).Scan(&required)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("database error: %w", err)
+	}
+	return required, nil
+}
+
+// twoFactorEnabled reports whether a user has confirmed a TOTP enrollment
+func (s *Service) twoFactorEnabled(userID int) (bool, error) {
+	var enabled bool
+	err := s.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM user_totp WHERE user_id = $1 AND enabled_at IS NOT NULL)
+	`, userID).Scan(&enabled)
+	if err != nil {
+		return false, fmt.Errorf("database error: %w", err)
+	}
+	return enabled, nil
+}
+
+// loginChallenge returns the challenge a user needs after the password
+// step, or nil when the password is enough
+func (s *Service) loginChallenge(user *User) (*TwoFactorChallenge, error) {
+	enabled, err := s.twoFactorEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return s.issueChallenge(user.ID, challengeLogin)
+	}
+
+	required, err := s.twoFactorRequiredForRole(user.Role)
+	if err != nil {
+		return nil, err
+	}
+	if required {
+		return s.issueChallenge(user.ID, challengeSetup)
+	}
+	return nil, nil
+}
+
+// BeginTOTPEnrollment creates a new TOTP secret for a user. It replaces an
+// unconfirmed enrollment and takes effect once EnableTOTP confirms a code.
+func (s *Service) BeginTOTPEnrollment(userID int) (*TOTPEnrollment, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, err := s.twoFactorEnabled(userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.sealTOTPSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO user_totp (user_id, secret_encrypted)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret_encrypted = EXCLUDED.secret_encrypted, last_used_step = 0,
+		    created_at = CURRENT_TIMESTAMP
+		WHERE user_totp.enabled_at IS NULL
+	`, userID, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	return &TOTPEnrollment{
+		Secret:     secret,
+		OTPAuthURL: totpURI(user.Email, secret),
+	}, nil
+}
+
+// BeginTOTPEnrollmentWithChallenge enrolls a user whose role requires
+// two-factor login during the login itself
+func (s *Service) BeginTOTPEnrollmentWithChallenge(challengeToken string) (*TOTPEnrollment, error) {
+	claims, err := s.parseChallenge(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != challengeSetup {
+		return nil, ErrInvalidToken
+	}
+	return s.BeginTOTPEnrollment(claims.UserID)
+}
+
+// verifySecondFactor checks a TOTP code or, unless pending, a recovery
+// code. With pending the code is checked against an unconfirmed enrollment.
+// Failures count towards the lockout; a success resets the count.
+func (s *Service) verifySecondFactor(userID int, code, recoveryCode string, pending bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var sealed string
+	var enabledAt, lockedUntil sql.NullTime
+	var lastStep int64
+	var failures int
+	err = tx.QueryRow(`
+		SELECT secret_encrypted, enabled_at, last_used_step, failed_attempts, locked_until
+		FROM user_totp
+		WHERE user_id = $1
+		FOR UPDATE
+	`, userID).Scan(&sealed, &enabledAt, &lastStep, &failures, &lockedUntil)
+	if err == sql.ErrNoRows {
+		return ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if enabledAt.Valid == pending {
+		if pending {
+			return ErrTwoFactorEnabled
+		}
+		return ErrTwoFactorNotEnabled
+	}
+	if lockedUntil.Valid && time.Now().Before(lockedUntil.Time) {
+		return ErrTwoFactorLocked
+	}
+
+	ok := false
+	switch {
+	case code != "":
+		secret, err := s.openTOTPSecret(sealed)
+		if err != nil {
+			return err
+		}
+		var step int64
+		if step, ok = verifyTOTP(secret, code, time.Now(), lastStep); ok {
+			lastStep = step
+		}
+	case recoveryCode != "" && !pending:
+		result, err := tx.Exec(`
+			UPDATE user_recovery_codes SET used_at = CURRENT_TIMESTAMP
+			WHERE id = (
+				SELECT id FROM user_recovery_codes
+				WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+				LIMIT 1
+			)
+		`, userID, hashRecoveryCode(recoveryCode))
+		if err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error checking rows affected: %w", err)
+		}
+		ok = rowsAffected == 1
+	}
+
+	if !ok {
+		failures++
+		var lock *time.Time
+		if failures >= maxTwoFactorFailures {
+			until := time.Now().Add(twoFactorLockout)
+			lock, failures = &until, 0
+			log.Printf("Warning: two-factor login locked for user %d after %d failed codes", userID, maxTwoFactorFailures)
+		}
+		if _, err := tx.Exec(`
+			UPDATE user_totp SET failed_attempts = $1, locked_until = $2 WHERE user_id = $3
+		`, failures, lock, userID); err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		if lock != nil {
+			return ErrTwoFactorLocked
+		}
+		return ErrInvalidTwoFactorCode
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE user_totp
+		SET failed_attempts = 0, locked_until = NULL, last_used_step = $1,
+		    enabled_at = COALESCE(enabled_at, CURRENT_TIMESTAMP)
+		WHERE user_id = $2
+	`, lastStep, userID); err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if pending {
+		// A confirmed enrollment starts with a fresh set of recovery codes;
+		// the caller issues them
+		if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// replaceRecoveryCodes issues new recovery codes, invalidating the old ones
+func (s *Service) replaceRecoveryCodes(userID int) ([]string, error) {
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	for _, code := range codes {
+		if _, err := tx.Exec(`
+			INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)
+		`, userID, hashRecoveryCode(code)); err != nil {
+			return nil, fmt.Errorf("database error: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return codes, nil
+}
+
+// EnableTOTP confirms an enrollment with a first code and returns the
+// user's recovery codes
+func (s *Service) EnableTOTP(userID int, code string) ([]string, error) {
+	if err := s.verifySecondFactor(userID, code, "", true); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(userID)
+}
+
+// DisableTOTP removes a user's second factor after checking a current code.
+// Users whose role requires two-factor login cannot disable it.
+func (s *Service) DisableTOTP(userID int, code string) error {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	required, err := s.twoFactorRequiredForRole(user.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrTwoFactorRequired
+	}
+
+	if err := s.verifySecondFactor(userID, code, "", false); err != nil {
+		return err
+	}
+	return s.ResetTwoFactor(userID)
+}
+
+// ResetTwoFactor removes a user's second factor and recovery codes, for
+// admins helping users who lost their device
+func (s *Service) ResetTwoFactor(userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces a user's recovery codes after checking
+// a current code
+func (s *Service) RegenerateRecoveryCodes(userID int, code string) ([]string, error) {
+	if err := s.verifySecondFactor(userID, code, "", false); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(userID)
+}
+
+// GetTwoFactorStatus returns a user's two-factor setup
+func (s *Service) GetTwoFactorStatus(userID int) (*TwoFactorStatus, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &TwoFactorStatus{}
+	if status.Required, err = s.twoFactorRequiredForRole(user.Role); err != nil {
+		return nil, err
+	}
+
+	var lockedUntil sql.NullTime
+	err = s.db.QueryRow(`
+		SELECT t.enabled_at IS NOT NULL, t.locked_until,
+		       (SELECT COUNT(*) FROM user_recovery_codes c WHERE c.user_id = t.user_id AND c.used_at IS NULL)
+		FROM user_totp t
+		WHERE t.user_id = $1
+	`, userID).Scan(&status.Enabled, &lockedUntil, &status.RecoveryCodesRemaining)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if lockedUntil.Valid && time.Now().Before(lockedUntil.Time) {
+		status.LockedUntil = &lockedUntil.Time
+	}
+
+	return status, nil
+}
+
+// CompleteTwoFactorLogin finishes a login started by Authenticate,
+// LoginWithOIDC or the Google callback and starts a session. A setup challenge confirms the enrollment begun with
+// BeginTOTPEnrollmentWithChallenge and returns the new recovery codes.
+func (s *Service) CompleteTwoFactorLogin(req *TwoFactorLoginRequest, userAgent, remoteAddr string) (*LoginResponse, error) {
+	claims, err := s.parseChallenge(req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var recoveryCodes []string
+	switch claims.Purpose {
+	case challengeLogin:
+		err = s.verifySecondFactor(claims.UserID, req.Code, req.RecoveryCode, false)
+	case challengeSetup:
+		recoveryCodes, err = s.EnableTOTP(claims.UserID, req.Code)
+	default:
+		err = ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.GetUserByID(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := s.UpdateLastLogin(user.ID); err != nil {
+		log.Printf("Warning: failed to update last login: %v", err)
+	}
+
+	resp, err := s.StartSession(user, userAgent, remoteAddr)
+	if err != nil {
+		return nil, err
+	}
+	resp.RecoveryCodes = recoveryCodes
+	return resp, nil
+}
+
+// ListTwoFactorPolicies returns the per-role two-factor policies
+func (s *Service) ListTwoFactorPolicies() ([]TwoFactorPolicy, error) {
+	rows, err := s.db.Query(`
+		SELECT role, required, updated_by, updated_at
+		FROM two_factor_policies
+		ORDER BY role
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []TwoFactorPolicy{}
+	for rows.Next() {
+		var policy TwoFactorPolicy
+		if err := rows.Scan(&policy.Role, &policy.Required, &policy.UpdatedBy, &policy.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+// SetTwoFactorPolicy sets whether a role must use two-factor login. Users of
+// the role without a second factor enroll at their next login.
+func (s *Service) SetTwoFactorPolicy(role string, required bool, updatedBy int) (*TwoFactorPolicy, error) {
+	policy := &TwoFactorPolicy{Role: role, Required: required}
+	err := s.db.QueryRow(`
+		INSERT INTO two_factor_policies (role, required, updated_by, updated_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (role) DO UPDATE
+		SET required = EXCLUDED.required, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at
+		RETURNING updated_by, updated_at
+	`, role, required, updatedBy).Scan(&policy.UpdatedBy, &policy.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return policy, nil
+}
@@ -0,0 +1,246 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// respondTwoFactorError maps two-factor errors to responses
+func respondTwoFactorError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidToken):
+		respondError(w, http.StatusUnauthorized, "Invalid or expired login challenge")
+	case errors.Is(err, ErrInvalidTwoFactorCode):
+		respondError(w, http.StatusUnauthorized, "Invalid two-factor code")
+	case errors.Is(err, ErrInvalidCredentials):
+		respondError(w, http.StatusUnauthorized, "Invalid email or password")
+	case errors.Is(err, ErrTwoFactorLocked):
+		respondError(w, http.StatusTooManyRequests, "Too many failed two-factor codes, try again later")
+	case errors.Is(err, ErrTwoFactorEnabled), errors.Is(err, ErrTwoFactorNotEnabled):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrTwoFactorRequired):
+		respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, ErrUserNotFound):
+		respondError(w, http.StatusNotFound, "User not found")
+	default:
+		log.Printf("%s error: %v", action, err)
+		respondError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
+
+// sessionClaims returns the claims of a login session; two-factor settings
+// cannot be changed with an API token
+func sessionClaims(w http.ResponseWriter, r *http.Request) (*Claims, bool) {
+	claims := r.Context().Value("claims").(*Claims)
+	if claims.TokenID != 0 {
+		respondError(w, http.StatusForbidden, "API tokens cannot change two-factor settings")
+		return nil, false
+	}
+	return claims, true
+}
+
+// decodeCodeRequest decodes a request carrying a TOTP code
+func decodeCodeRequest(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return "", false
+	}
+	if req.Code == "" {
+		respondError(w, http.StatusBadRequest, "Code is required")
+		return "", false
+	}
+	return req.Code, true
+}
+
+// CompleteTwoFactorLogin completes a login with a TOTP or recovery code
+func (h *Handler) CompleteTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.ChallengeToken == "" || (req.Code == "" && req.RecoveryCode == "") {
+		respondError(w, http.StatusBadRequest, "Challenge token and a code or recovery code are required")
+		return
+	}
+
+	resp, err := h.service.CompleteTwoFactorLogin(&req, r.UserAgent(), r.RemoteAddr)
+	if err != nil {
+		respondTwoFactorError(w, "Two-factor login", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// BeginLoginEnrollment starts TOTP enrollment during a login that requires it
+func (h *Handler) BeginLoginEnrollment(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	enrollment, err := h.service.BeginTOTPEnrollmentWithChallenge(req.ChallengeToken)
+	if err != nil {
+		respondTwoFactorError(w, "Two-factor enrollment", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, enrollment)
+}
+
+// GetTwoFactorStatus returns the current user's two-factor setup
+func (h *Handler) GetTwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(*Claims)
+
+	status, err := h.service.GetTwoFactorStatus(claims.UserID)
+	if err != nil {
+		respondTwoFactorError(w, "Two-factor status", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, status)
+}
+
+// BeginTOTPEnrollment creates a TOTP secret for the current user
+func (h *Handler) BeginTOTPEnrollment(w http.ResponseWriter, r *http.Request) {
+	claims, ok := sessionClaims(w, r)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.service.BeginTOTPEnrollment(claims.UserID)
+	if err != nil {
+		respondTwoFactorError(w, "Two-factor enrollment", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, enrollment)
+}
+
+// EnableTOTP confirms the current user's enrollment with a first code
+func (h *Handler) EnableTOTP(w http.ResponseWriter, r *http.Request) {
+	claims, ok := sessionClaims(w, r)
+	if !ok {
+		return
+	}
+	code, ok := decodeCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.service.EnableTOTP(claims.UserID, code)
+	if err != nil {
+		respondTwoFactorError(w, "Enable two-factor", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTP removes the current user's second factor
+func (h *Handler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	claims, ok := sessionClaims(w, r)
+	if !ok {
+		return
+	}
+	code, ok := decodeCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DisableTOTP(claims.UserID, code); err != nil {
+		respondTwoFactorError(w, "Disable two-factor", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes
+func (h *Handler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	claims, ok := sessionClaims(w, r)
+	if !ok {
+		return
+	}
+	code, ok := decodeCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(claims.UserID, code)
+	if err != nil {
+		respondTwoFactorError(w, "Regenerate recovery codes", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// ResetUserTwoFactor removes a user's second factor (admin only)
+func (h *Handler) ResetUserTwoFactor(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.service.ResetTwoFactor(id); err != nil {
+		respondTwoFactorError(w, "Reset two-factor", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListTwoFactorPolicies returns the per-role two-factor policies (admin only)
+func (h *Handler) ListTwoFactorPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := h.service.ListTwoFactorPolicies()
+	if err != nil {
+		respondTwoFactorError(w, "List two-factor policies", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, policies)
+}
+
+// SetTwoFactorPolicy sets whether a role must use two-factor login (admin only)
+func (h *Handler) SetTwoFactorPolicy(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(*Claims)
+
+	role := strings.TrimSpace(r.PathValue("role"))
+	if role == "" {
+		respondError(w, http.StatusBadRequest, "Role is required")
+		return
+	}
+
+	var req struct {
+		Required bool `json:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	policy, err := h.service.SetTwoFactorPolicy(role, req.Required, claims.UserID)
+	if err != nil {
+		respondTwoFactorError(w, "Set two-factor policy", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, policy)
+}
@@ -0,0 +1,209 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+var (
+	ErrInvalidWorkspaceRole = errors.New("invalid workspace role")
+	ErrMemberNotFound       = errors.New("workspace member not found")
+	ErrLastWorkspaceOwner   = errors.New("a workspace needs at least one owner")
+)
+
+// requireWorkspaceRole returns a *models.WorkspaceAccessError unless the
+// user's role in the workspace includes the required role
+func (s *Service) requireWorkspaceRole(workspaceID string, userID int, required models.WorkspaceRole) error {
+	return s.workspaces.Require(workspaceID, userID, required)
+}
+
+// beginWorkspaceTx starts a transaction holding a lock on a workspace's
+// members, so that concurrent changes cannot remove its last owner
+func (s *Service) beginWorkspaceTx(workspaceID string) (*sql.Tx, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('workspace_memberships:' || $1))", workspaceID); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return tx, nil
+}
+
+// beginMemberChange starts a transaction that changes a workspace's members.
+// Changes to one workspace are serialized, and only its owners may make them.
+// Workspaces get their first owner when they are created, so a workspace
+// without members can only be managed by admins.
+func (s *Service) beginMemberChange(workspaceID string, callerID int) (*sql.Tx, error) {
+	tx, err := s.beginWorkspaceTx(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.requireWorkspaceRole(workspaceID, callerID, models.WorkspaceRoleOwner); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// checkOwnerRemains returns ErrLastWorkspaceOwner if a change left the
+// workspace without owners
+func checkOwnerRemains(tx *sql.Tx, workspaceID string) error {
+	var owners int
+	err := tx.QueryRow(`
+		SELECT COUNT(*) FROM workspace_memberships WHERE workspace_id = $1 AND role = $2
+	`, workspaceID, models.WorkspaceRoleOwner).Scan(&owners)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if owners == 0 {
+		return ErrLastWorkspaceOwner
+	}
+	return nil
+}
+
+// ListWorkspaceMembers returns a workspace's members; the caller must be able
+// to view the workspace
+func (s *Service) ListWorkspaceMembers(workspaceID string, callerID int) ([]WorkspaceMember, error) {
+	if err := s.requireWorkspaceRole(workspaceID, callerID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT m.workspace_id, m.user_id, u.email, u.name, m.role, m.granted_by, m.created_at, m.updated_at
+		FROM workspace_memberships m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.workspace_id = $1
+		ORDER BY u.name ASC
+	`, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	defer rows.Close()
+
+	members := []WorkspaceMember{}
+	for rows.Next() {
+		var m WorkspaceMember
+		var grantedBy sql.NullInt64
+		if err := rows.Scan(&m.WorkspaceID, &m.UserID, &m.Email, &m.Name, &m.Role, &grantedBy, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		if grantedBy.Valid {
+			id := int(grantedBy.Int64)
+			m.GrantedBy = &id
+		}
+		members = append(members, m)
+	}
+
+	return members, nil
+}
+
+// GetMyWorkspaceRole returns the current user's role in a workspace
+func (s *Service) GetMyWorkspaceRole(workspaceID string, userID int) (*MyWorkspaceRole, error) {
+	role, err := s.workspaces.Role(workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &MyWorkspaceRole{WorkspaceID: workspaceID, Role: string(role)}
+	err = s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM workspace_memberships WHERE workspace_id = $1)", workspaceID).Scan(&resp.Managed)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return resp, nil
+}
+
+// SetWorkspaceMember adds a user to a workspace or changes their role
+func (s *Service) SetWorkspaceMember(workspaceID string, userID int, role string, callerID int) error {
+	if !models.IsValidWorkspaceRole(role) {
+		return ErrInvalidWorkspaceRole
+	}
+
+	tx, err := s.beginMemberChange(workspaceID, callerID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO workspace_memberships (workspace_id, user_id, role, granted_by)
+		SELECT $1, id, $3, $4 FROM users WHERE id = $2 AND is_active = true
+		ON CONFLICT (workspace_id, user_id)
+		DO UPDATE SET role = EXCLUDED.role, granted_by = EXCLUDED.granted_by, updated_at = CURRENT_TIMESTAMP
+	`, workspaceID, userID, role, callerID)
+	if err != nil {
+		return fmt.Errorf("failed to set workspace member: %w", err)
+	}
+
+	var exists bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM workspace_memberships WHERE workspace_id = $1 AND user_id = $2)
+	`, workspaceID, userID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+
+	if err := checkOwnerRemains(tx, workspaceID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RemoveWorkspaceMember removes a user from a workspace. Owners may remove
+// anyone and members may leave, but the last owner cannot.
+func (s *Service) RemoveWorkspaceMember(workspaceID string, userID int, callerID int) error {
+	var tx *sql.Tx
+	var err error
+	if userID == callerID {
+		tx, err = s.beginWorkspaceTx(workspaceID)
+	} else {
+		tx, err = s.beginMemberChange(workspaceID, callerID)
+	}
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		DELETE FROM workspace_memberships WHERE workspace_id = $1 AND user_id = $2
+	`, workspaceID, userID)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrMemberNotFound
+	}
+
+	if err := checkOwnerRemains(tx, workspaceID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// respondWorkspaceMemberError maps workspace membership errors to responses
+func respondWorkspaceMemberError(w http.ResponseWriter, action string, err error) {
+	var accessErr *models.WorkspaceAccessError
+	switch {
+	case errors.As(err, &accessErr):
+		respondError(w, http.StatusForbidden, accessErr.Error())
+	case errors.Is(err, ErrInvalidWorkspaceRole):
+		respondError(w, http.StatusBadRequest, "Role must be viewer, contributor, approver or owner")
+	case errors.Is(err, ErrLastWorkspaceOwner):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrMemberNotFound):
+		respondError(w, http.StatusNotFound, "Member not found")
+	case errors.Is(err, ErrUserNotFound):
+		respondError(w, http.StatusNotFound, "User not found")
+	default:
+		log.Printf("%s error: %v", action, err)
+		respondError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
+
+// ListWorkspaceMembers returns a workspace's members and their roles
+func (h *Handler) ListWorkspaceMembers(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(*Claims)
+
+	members, err := h.service.ListWorkspaceMembers(r.PathValue("workspaceId"), claims.UserID)
+	if err != nil {
+		respondWorkspaceMemberError(w, "List workspace members", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, members)
+}
+
+// GetMyWorkspaceRole returns the current user's role in a workspace
+func (h *Handler) GetMyWorkspaceRole(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(*Claims)
+
+	role, err := h.service.GetMyWorkspaceRole(r.PathValue("workspaceId"), claims.UserID)
+	if err != nil {
+		respondWorkspaceMemberError(w, "Get workspace role", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, role)
+}
+
+// SetWorkspaceMember adds a member to a workspace or changes their role
+// (workspace owners only)
+func (h *Handler) SetWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(*Claims)
+
+	userID, ok := pathID(r, "userId")
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req SetWorkspaceMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	workspaceID := r.PathValue("workspaceId")
+	if err := h.service.SetWorkspaceMember(workspaceID, userID, req.Role, claims.UserID); err != nil {
+		respondWorkspaceMemberError(w, "Set workspace member", err)
+		return
+	}
+
+	members, err := h.service.ListWorkspaceMembers(workspaceID, claims.UserID)
+	if err != nil {
+		respondWorkspaceMemberError(w, "List workspace members", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, members)
+}
+
+// RemoveWorkspaceMember removes a member from a workspace (workspace owners,
+// or the member themselves)
+func (h *Handler) RemoveWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(*Claims)
+
+	userID, ok := pathID(r, "userId")
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.service.RemoveWorkspaceMember(r.PathValue("workspaceId"), userID, claims.UserID); err != nil {
+		respondWorkspaceMemberError(w, "Remove workspace member", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -31,7 +31,6 @@ var (
 type ChatRequest struct {
 	Message       string        `json:"message"`
 	WorkspacePath string        `json:"workspacePath"`
-	APIKey        string        `json:"apiKey,omitempty"`
 	History       []ChatMessage `json:"history,omitempty"`
 	AIPreset      int           `json:"aiPreset,omitempty"`
 }
@@ -62,14 +61,11 @@ func (h *Handler) HandleAIChat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get API key - try request first, then environment
-	apiKey := req.APIKey
-	if apiKey == "" {
-		apiKey = os.Getenv("ANTHROPIC_API_KEY")
-	}
-	if apiKey == "" {
+	// Get API key - the user's stored key, then environment
+	apiKey, err := h.anthropicKey(r)
+	if err != nil {
 		json.NewEncoder(w).Encode(ChatResponse{
-			Error: "No Anthropic API key provided. Please set it in Settings or configure ANTHROPIC_API_KEY environment variable.",
+			Error: err.Error(),
 		})
 		return
 	}
@@ -373,7 +369,6 @@ func processClaudeResponse(response string, workspacePath string) string {
 // GenerateCodeRequest represents a code generation request
 type GenerateCodeRequest struct {
 	WorkspacePath    string `json:"workspacePath"`
-	APIKey           string `json:"apiKey,omitempty"`
 	AIPreset         int    `json:"aiPreset"`
 	UIFramework      string `json:"uiFramework,omitempty"`
 	AdditionalPrompt string `json:"additionalPrompt,omitempty"`
@@ -970,13 +965,10 @@ func (h *Handler) HandleGenerateCode(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get API key
-	apiKey := req.APIKey
-	if apiKey == "" {
-		apiKey = os.Getenv("ANTHROPIC_API_KEY")
-	}
-	if apiKey == "" {
+	apiKey, err := h.anthropicKey(r)
+	if err != nil {
 		json.NewEncoder(w).Encode(ChatResponse{
-			Error: "No Anthropic API key provided.",
+			Error: err.Error(),
 		})
 		return
 	}
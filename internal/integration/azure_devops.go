@@ -0,0 +1,441 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// AzureDevOpsIntegration is the name of the Azure DevOps integration
+const AzureDevOpsIntegration = "Azure DevOps"
+
+// azureDevOpsAPIVersion is the REST API version requests are made with
+const azureDevOpsAPIVersion = "7.1"
+
+// azureDevOpsClient calls the Azure DevOps REST API of one organization with
+// a personal access token
+type azureDevOpsClient struct {
+	orgURL     string // e.g. https://dev.azure.com/contoso
+	pat        string
+	httpClient *http.Client
+}
+
+// azureDevOpsPAT returns the personal access token of Azure DevOps credentials
+func azureDevOpsPAT(credentials map[string]string) (string, bool) {
+	return getCredential(credentials, "access_token", "personal_access_token", "pat", "PAT", "token", "api_key")
+}
+
+// newAzureDevOpsClient creates an Azure DevOps client from stored
+// credentials. The organization may be given by name or by URL.
+func newAzureDevOpsClient(credentials map[string]string) (*azureDevOpsClient, error) {
+	pat, ok := azureDevOpsPAT(credentials)
+	if !ok {
+		return nil, fmt.Errorf("personal access token not found in credentials. Expected one of: access_token, personal_access_token, pat, token. Got fields: %v", getCredentialKeys(credentials))
+	}
+	org, ok := getCredential(credentials, "organization", "org", "organization_url", "organizationUrl", "base_url", "baseUrl", "url")
+	if !ok {
+		return nil, fmt.Errorf("organization not found in credentials (e.g., contoso or https://dev.azure.com/contoso). Got fields: %v", getCredentialKeys(credentials))
+	}
+	orgURL := strings.TrimSuffix(org, "/")
+	if !strings.HasPrefix(orgURL, "https://") && !strings.HasPrefix(orgURL, "http://") {
+		orgURL = "https://dev.azure.com/" + url.PathEscape(orgURL)
+	}
+	return &azureDevOpsClient{
+		orgURL:     orgURL,
+		pat:        pat,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// isAzureDevOpsURL reports whether a URL is of an Azure DevOps Services
+// organization, which takes personal access tokens with basic auth
+func isAzureDevOpsURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return host == "dev.azure.com" || strings.HasSuffix(host, ".visualstudio.com")
+}
+
+// do sends a request to a path of the organization and decodes the JSON
+// response into out
+func (c *azureDevOpsClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.orgURL+path+sep+"api-version="+azureDevOpsAPIVersion, reader)
+	if err != nil {
+		return err
+	}
+	// Personal access tokens are the password of basic auth with no user
+	httpReq.SetBasicAuth("", c.pat)
+	httpReq.Header.Set("Accept", "application/json")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to call Azure DevOps: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Azure DevOps API error (%d): %s", resp.StatusCode, string(data))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// projectPath returns the URL path of a project, by name or ID
+func (c *azureDevOpsClient) projectPath(project string) string {
+	return "/" + url.PathEscape(project)
+}
+
+// fetchAzureDevOpsResources fetches the projects of the organization, or
+// with resource_type "repository" the Git repositories of the project given
+// as parent_id
+func fetchAzureDevOpsResources(ctx context.Context, req FetchResourcesRequest) (*FetchResourcesResponse, error) {
+	client, err := newAzureDevOpsClient(req.Credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ResourceType == "repository" {
+		if req.ParentID == "" {
+			return nil, fmt.Errorf("parent_id (the project) is required to list repositories")
+		}
+		repos, err := client.repositories(ctx, req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		return &FetchResourcesResponse{IntegrationName: AzureDevOpsIntegration, Resources: repos}, nil
+	}
+
+	var projects struct {
+		Value []struct {
+			ID             string `json:"id"`
+			Name           string `json:"name"`
+			Description    string `json:"description"`
+			State          string `json:"state"`
+			Visibility     string `json:"visibility"`
+			LastUpdateTime string `json:"lastUpdateTime"`
+		} `json:"value"`
+	}
+	if err := client.do(ctx, "GET", "/_apis/projects?$top=100", nil, &projects); err != nil {
+		return nil, fmt.Errorf("failed to fetch Azure DevOps projects: %w", err)
+	}
+
+	resources := make([]IntegrationResource, len(projects.Value))
+	for i, project := range projects.Value {
+		resources[i] = IntegrationResource{
+			ID:          project.ID,
+			Name:        project.Name,
+			Type:        "project",
+			Description: project.Description,
+			URL:         client.orgURL + client.projectPath(project.Name),
+			Metadata: map[string]interface{}{
+				"state":      project.State,
+				"visibility": project.Visibility,
+				"updated_at": project.LastUpdateTime,
+			},
+		}
+	}
+
+	return &FetchResourcesResponse{
+		IntegrationName: AzureDevOpsIntegration,
+		Resources:       resources,
+	}, nil
+}
+
+// repositories lists the Git repositories of a project
+func (c *azureDevOpsClient) repositories(ctx context.Context, project string) ([]IntegrationResource, error) {
+	var repos struct {
+		Value []struct {
+			ID            string `json:"id"`
+			Name          string `json:"name"`
+			WebURL        string `json:"webUrl"`
+			RemoteURL     string `json:"remoteUrl"`
+			DefaultBranch string `json:"defaultBranch"`
+			Size          int64  `json:"size"`
+		} `json:"value"`
+	}
+	if err := c.do(ctx, "GET", c.projectPath(project)+"/_apis/git/repositories", nil, &repos); err != nil {
+		return nil, fmt.Errorf("failed to fetch Azure DevOps repositories: %w", err)
+	}
+
+	resources := make([]IntegrationResource, len(repos.Value))
+	for i, repo := range repos.Value {
+		resources[i] = IntegrationResource{
+			ID:     repo.ID,
+			Name:   repo.Name,
+			Type:   "repository",
+			URL:    repo.WebURL,
+			Parent: project,
+			Metadata: map[string]interface{}{
+				"clone_url":      repo.RemoteURL,
+				"default_branch": strings.TrimPrefix(repo.DefaultBranch, "refs/heads/"),
+				"size":           repo.Size,
+			},
+		}
+	}
+	return resources, nil
+}
+
+// fetchAzureDevOpsFiles fetches the files at the root of a repository
+// (resource_type "repository"), or for a project its boards (resource_type
+// "boards") or its most recently changed work items
+func fetchAzureDevOpsFiles(ctx context.Context, req FetchFilesRequest) (*FetchFilesResponse, error) {
+	client, err := newAzureDevOpsClient(req.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	if req.ResourceID == "" {
+		return nil, fmt.Errorf("resource_id is required")
+	}
+
+	switch req.ResourceType {
+	case "repository":
+		return client.repositoryFiles(ctx, req.ResourceID)
+
+	case "boards":
+		var boards struct {
+			Value []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+				URL  string `json:"url"`
+			} `json:"value"`
+		}
+		if err := client.do(ctx, "GET", client.projectPath(req.ResourceID)+"/_apis/work/boards", nil, &boards); err != nil {
+			return nil, fmt.Errorf("failed to fetch Azure DevOps boards: %w", err)
+		}
+		files := make([]IntegrationFile, len(boards.Value))
+		for i, board := range boards.Value {
+			files[i] = IntegrationFile{
+				ID:   board.ID,
+				Name: board.Name,
+				Type: "board",
+				URL:  client.orgURL + client.projectPath(req.ResourceID) + "/_boards/board/" + url.PathEscape(board.Name),
+				Metadata: map[string]interface{}{
+					"api_url": board.URL,
+				},
+			}
+		}
+		return &FetchFilesResponse{
+			IntegrationName: AzureDevOpsIntegration,
+			ResourceID:      req.ResourceID,
+			ResourceName:    req.ResourceID,
+			Files:           files,
+		}, nil
+	}
+
+	items, err := client.workItems(ctx, req.ResourceID, "", 100)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]IntegrationFile, len(items))
+	for i, item := range items {
+		files[i] = IntegrationFile{
+			ID:        item.ID,
+			Name:      fmt.Sprintf("%s %s - %s", item.IssueType, item.Key, item.Summary),
+			Type:      "work_item",
+			URL:       item.URL,
+			CreatedAt: item.Created,
+			UpdatedAt: item.Updated,
+			Metadata: map[string]interface{}{
+				"key":            item.Key,
+				"state":          item.Status,
+				"work_item_type": item.IssueType,
+			},
+		}
+	}
+	return &FetchFilesResponse{
+		IntegrationName: AzureDevOpsIntegration,
+		ResourceID:      req.ResourceID,
+		ResourceName:    req.ResourceID,
+		Files:           files,
+	}, nil
+}
+
+// repositoryFiles lists the files at the root of a repository's default branch
+func (c *azureDevOpsClient) repositoryFiles(ctx context.Context, repoID string) (*FetchFilesResponse, error) {
+	var repo struct {
+		Name   string `json:"name"`
+		WebURL string `json:"webUrl"`
+	}
+	if err := c.do(ctx, "GET", "/_apis/git/repositories/"+url.PathEscape(repoID), nil, &repo); err != nil {
+		return nil, fmt.Errorf("failed to fetch Azure DevOps repository: %w", err)
+	}
+
+	var items struct {
+		Value []struct {
+			ObjectID      string `json:"objectId"`
+			GitObjectType string `json:"gitObjectType"` // blob or tree
+			Path          string `json:"path"`
+			URL           string `json:"url"`
+		} `json:"value"`
+	}
+	path := "/_apis/git/repositories/" + url.PathEscape(repoID) + "/items?scopePath=%2F&recursionLevel=OneLevel"
+	if err := c.do(ctx, "GET", path, nil, &items); err != nil {
+		return nil, fmt.Errorf("failed to fetch Azure DevOps repository items: %w", err)
+	}
+
+	files := make([]IntegrationFile, 0, len(items.Value))
+	for _, item := range items.Value {
+		if item.GitObjectType != "blob" {
+			continue
+		}
+		files = append(files, IntegrationFile{
+			ID:   item.Path,
+			Name: strings.TrimPrefix(item.Path, "/"),
+			Type: "file",
+			URL:  repo.WebURL + "?path=" + url.QueryEscape(item.Path),
+			Metadata: map[string]interface{}{
+				"path":         item.Path,
+				"object_id":    item.ObjectID,
+				"download_url": item.URL,
+			},
+		})
+	}
+
+	return &FetchFilesResponse{
+		IntegrationName: AzureDevOpsIntegration,
+		ResourceID:      repoID,
+		ResourceName:    repo.Name,
+		Files:           files,
+	}, nil
+}
+
+// workItemTypePattern matches work item type names, which are interpolated
+// into WIQL
+var workItemTypePattern = regexp.MustCompile(`^[\w .-]+$`)
+
+// workItemFields are the work item fields read for files and imports
+var workItemFields = []string{
+	"System.Title", "System.WorkItemType", "System.State", "System.Description", "System.Tags",
+	"System.CreatedDate", "System.ChangedDate", "Microsoft.VSTS.Common.Priority",
+}
+
+// htmlTagPattern matches the tags of the HTML work item descriptions are
+// stored as
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// workItems returns up to top work items of a project, most recently
+// changed first, optionally only those of one work item type. Work items
+// are returned in the shape of imported epics.
+func (c *azureDevOpsClient) workItems(ctx context.Context, project, workItemType string, top int) ([]Epic, error) {
+	query := "SELECT [System.Id] FROM WorkItems WHERE [System.TeamProject] = @project"
+	if workItemType != "" {
+		if !workItemTypePattern.MatchString(workItemType) {
+			return nil, fmt.Errorf("invalid work item type %q", workItemType)
+		}
+		query += fmt.Sprintf(" AND [System.WorkItemType] = '%s'", workItemType)
+	}
+	query += " ORDER BY [System.ChangedDate] DESC"
+
+	var result struct {
+		WorkItems []struct {
+			ID int `json:"id"`
+		} `json:"workItems"`
+	}
+	path := fmt.Sprintf("%s/_apis/wit/wiql?$top=%d", c.projectPath(project), top)
+	if err := c.do(ctx, "POST", path, map[string]string{"query": query}, &result); err != nil {
+		return nil, fmt.Errorf("failed to query Azure DevOps work items: %w", err)
+	}
+	if len(result.WorkItems) == 0 {
+		return []Epic{}, nil
+	}
+
+	ids := make([]string, len(result.WorkItems))
+	for i, item := range result.WorkItems {
+		ids[i] = fmt.Sprintf("%d", item.ID)
+	}
+	var items struct {
+		Value []struct {
+			ID     int                    `json:"id"`
+			Fields map[string]interface{} `json:"fields"`
+		} `json:"value"`
+	}
+	path = fmt.Sprintf("%s/_apis/wit/workitems?ids=%s&fields=%s", c.projectPath(project), strings.Join(ids, ","), strings.Join(workItemFields, ","))
+	if err := c.do(ctx, "GET", path, nil, &items); err != nil {
+		return nil, fmt.Errorf("failed to fetch Azure DevOps work items: %w", err)
+	}
+
+	epics := make([]Epic, len(items.Value))
+	for i, item := range items.Value {
+		field := func(name string) string {
+			if v, ok := item.Fields[name]; ok && v != nil {
+				return fmt.Sprint(v)
+			}
+			return ""
+		}
+		var labels []string
+		for _, tag := range strings.Split(field("System.Tags"), ";") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				labels = append(labels, tag)
+			}
+		}
+		description := htmlTagPattern.ReplaceAllString(strings.ReplaceAll(field("System.Description"), "<br>", "\n"), "")
+
+		epics[i] = Epic{
+			ID:          fmt.Sprintf("%d", item.ID),
+			Key:         fmt.Sprintf("%d", item.ID),
+			Summary:     field("System.Title"),
+			Description: strings.TrimSpace(description),
+			Status:      field("System.State"),
+			Priority:    azureDevOpsPriority(field("Microsoft.VSTS.Common.Priority")),
+			Labels:      labels,
+			Created:     field("System.CreatedDate"),
+			Updated:     field("System.ChangedDate"),
+			URL:         fmt.Sprintf("%s%s/_workitems/edit/%d", c.orgURL, c.projectPath(project), item.ID),
+			IssueType:   field("System.WorkItemType"),
+			Metadata: map[string]interface{}{
+				"azure_devops_id": item.ID,
+				"project":         project,
+			},
+		}
+	}
+	return epics, nil
+}
+
+// azureDevOpsPriority names a work item's numeric priority, 1 being the
+// highest
+func azureDevOpsPriority(priority string) string {
+	switch priority {
+	case "1":
+		return "High"
+	case "2":
+		return "Medium"
+	case "3":
+		return "Low"
+	case "4":
+		return "Lowest"
+	}
+	return ""
+}
@@ -0,0 +1,194 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+func init() {
+	RegisterProvider(figmaProvider{}, "Figma")
+	RegisterProvider(gitHubProvider{})
+	RegisterProvider(jiraProvider{})
+	RegisterProvider(gitLabProvider{})
+	RegisterProvider(azureDevOpsProvider{})
+}
+
+// figmaProvider integrates Figma teams and design files
+type figmaProvider struct{ BaseProvider }
+
+func (figmaProvider) Name() string { return "Figma API" }
+
+func (figmaProvider) TestConnection(ctx context.Context, baseURL string, credentials map[string]string) (*TestConnectionResponse, error) {
+	accessToken, ok := getCredential(credentials, "access_token", "token", "api_key")
+	if !ok {
+		return nil, fmt.Errorf("access_token not found in credentials. Got fields: %v", getCredentialKeys(credentials))
+	}
+	if baseURL == "" {
+		baseURL = "https://api.figma.com/v1/me"
+	}
+	return probeConnection(ctx, baseURL, func(r *http.Request) { r.Header.Set("X-Figma-Token", accessToken) }), nil
+}
+
+func (figmaProvider) FetchResources(ctx context.Context, req FetchResourcesRequest) (*FetchResourcesResponse, error) {
+	return fetchFigmaResources(ctx, req)
+}
+
+func (figmaProvider) FetchFiles(ctx context.Context, req FetchFilesRequest) (*FetchFilesResponse, error) {
+	return fetchFigmaFiles(ctx, req)
+}
+
+func (figmaProvider) FetchFileMeta(ctx context.Context, req FetchFileMetaRequest) (map[string]interface{}, error) {
+	return fetchFigmaFileMeta(ctx, req)
+}
+
+// gitHubProvider integrates GitHub repositories
+type gitHubProvider struct{ BaseProvider }
+
+func (gitHubProvider) Name() string { return GitHubIntegration }
+
+func (gitHubProvider) TestConnection(ctx context.Context, baseURL string, credentials map[string]string) (*TestConnectionResponse, error) {
+	token, ok := githubToken(credentials)
+	if !ok {
+		return nil, fmt.Errorf("access_token not found in credentials. Got fields: %v", getCredentialKeys(credentials))
+	}
+	if baseURL == "" {
+		baseURL = "https://api.github.com/user"
+	}
+	return probeConnection(ctx, baseURL, func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }), nil
+}
+
+func (gitHubProvider) FetchResources(ctx context.Context, req FetchResourcesRequest) (*FetchResourcesResponse, error) {
+	return fetchGitHubResources(ctx, req)
+}
+
+func (gitHubProvider) FetchFiles(ctx context.Context, req FetchFilesRequest) (*FetchFilesResponse, error) {
+	return fetchGitHubFiles(ctx, req)
+}
+
+// jiraProvider integrates Jira projects and epics
+type jiraProvider struct{ BaseProvider }
+
+func (jiraProvider) Name() string { return JiraIntegration }
+
+func (jiraProvider) TestConnection(ctx context.Context, baseURL string, credentials map[string]string) (*TestConnectionResponse, error) {
+	// A site given only as the URL under test still identifies the client
+	if _, ok := credentials["base_url"]; !ok && baseURL != "" {
+		withSite := map[string]string{"base_url": baseURL}
+		for key, value := range credentials {
+			withSite[key] = value
+		}
+		credentials = withSite
+	}
+	client, err := jiraClient(credentials)
+	if err != nil {
+		return nil, err
+	}
+	if baseURL == "" {
+		baseURL = client.BaseURL + "/rest/api/3/myself"
+	}
+	return probeConnection(ctx, baseURL, func(r *http.Request) { r.SetBasicAuth(client.Email, client.APIToken) }), nil
+}
+
+func (jiraProvider) FetchResources(ctx context.Context, req FetchResourcesRequest) (*FetchResourcesResponse, error) {
+	return fetchJiraResources(ctx, req)
+}
+
+func (jiraProvider) FetchFiles(ctx context.Context, req FetchFilesRequest) (*FetchFilesResponse, error) {
+	return fetchJiraFiles(ctx, req)
+}
+
+func (jiraProvider) FetchEpics(ctx context.Context, req FetchEpicsRequest) ([]Epic, error) {
+	resp, err := FetchJiraEpics(ctx, FetchJiraEpicsRequest{ProjectKey: req.Project, Credentials: req.Credentials})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Epics, nil
+}
+
+// gitLabProvider integrates GitLab projects, issues and group epics
+type gitLabProvider struct{ BaseProvider }
+
+func (gitLabProvider) Name() string { return GitLabIntegration }
+
+func (gitLabProvider) TestConnection(ctx context.Context, baseURL string, credentials map[string]string) (*TestConnectionResponse, error) {
+	client, err := newGitLabClient(credentials)
+	if err != nil {
+		return nil, err
+	}
+	if baseURL == "" {
+		baseURL = client.baseURL + "/api/v4/user"
+	}
+	return probeConnection(ctx, baseURL, func(r *http.Request) { r.Header.Set("PRIVATE-TOKEN", client.token) }), nil
+}
+
+func (gitLabProvider) FetchResources(ctx context.Context, req FetchResourcesRequest) (*FetchResourcesResponse, error) {
+	return fetchGitLabResources(ctx, req)
+}
+
+func (gitLabProvider) FetchFiles(ctx context.Context, req FetchFilesRequest) (*FetchFilesResponse, error) {
+	return fetchGitLabFiles(ctx, req)
+}
+
+func (gitLabProvider) FetchEpics(ctx context.Context, req FetchEpicsRequest) ([]Epic, error) {
+	client, err := newGitLabClient(req.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	return fetchGitLabEpics(ctx, client, req.Project)
+}
+
+// azureDevOpsProvider integrates Azure DevOps projects, repositories, boards
+// and work items
+type azureDevOpsProvider struct{ BaseProvider }
+
+func (azureDevOpsProvider) Name() string { return AzureDevOpsIntegration }
+
+// MatchesURL claims Azure DevOps URLs, which take personal access tokens as
+// the password of basic auth rather than as bearer tokens
+func (azureDevOpsProvider) MatchesURL(rawURL string) bool {
+	return isAzureDevOpsURL(rawURL)
+}
+
+func (azureDevOpsProvider) TestConnection(ctx context.Context, baseURL string, credentials map[string]string) (*TestConnectionResponse, error) {
+	pat, ok := azureDevOpsPAT(credentials)
+	if !ok {
+		return nil, fmt.Errorf("personal access token not found in credentials. Got fields: %v", getCredentialKeys(credentials))
+	}
+	if baseURL == "" {
+		client, err := newAzureDevOpsClient(credentials)
+		if err != nil {
+			return nil, err
+		}
+		baseURL = client.orgURL + "/_apis/projects?$top=1&api-version=" + azureDevOpsAPIVersion
+	}
+	return probeConnection(ctx, baseURL, func(r *http.Request) { r.SetBasicAuth("", pat) }), nil
+}
+
+func (azureDevOpsProvider) FetchResources(ctx context.Context, req FetchResourcesRequest) (*FetchResourcesResponse, error) {
+	return fetchAzureDevOpsResources(ctx, req)
+}
+
+func (azureDevOpsProvider) FetchFiles(ctx context.Context, req FetchFilesRequest) (*FetchFilesResponse, error) {
+	return fetchAzureDevOpsFiles(ctx, req)
+}
+
+func (azureDevOpsProvider) FetchEpics(ctx context.Context, req FetchEpicsRequest) ([]Epic, error) {
+	client, err := newAzureDevOpsClient(req.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	workItemType := req.WorkItemType
+	if workItemType == "" {
+		workItemType = "Epic"
+	}
+	return client.workItems(ctx, req.Project, workItemType, 200)
+}
@@ -348,6 +348,10 @@ func (h *Handler) HandleGetCodeGenerationJobStatus(w http.ResponseWriter, r *htt
 		})
 		return
 	}
+	// Jobs are visible to those who can view their workspace
+	if !h.confinePath(w, r, job.WorkspacePath) {
+		return
+	}
 
 	snapshot := job.GetSnapshot()
 
@@ -399,6 +403,10 @@ func (h *Handler) HandleCancelCodeGenerationJob(w http.ResponseWriter, r *http.R
 		})
 		return
 	}
+	// Only contributors to the job's workspace may cancel it
+	if !h.confinePath(w, r, job.WorkspacePath) {
+		return
+	}
 
 	job.Cancel()
 
@@ -0,0 +1,215 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package integration
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/jareynolds/intentr/internal/auth"
+	"github.com/jareynolds/intentr/pkg/vault"
+)
+
+// Integration credentials (API keys, tokens, passwords) are stored per user
+// in the encrypted credential vault and referenced by integration name, so
+// the browser never holds or sends them after they are saved.
+const (
+	// AnthropicIntegration is the stored integration holding the user's
+	// Anthropic API key, in its AnthropicKeyField field
+	AnthropicIntegration = "Anthropic"
+	AnthropicKeyField    = "api_key"
+)
+
+var (
+	ErrNoCredentials        = errors.New("no stored credentials for integration")
+	ErrNoAnthropicKey       = errors.New("no Anthropic API key configured: save one in Settings or set ANTHROPIC_API_KEY")
+	errNoCredentialDatabase = errors.New("credential storage requires a database")
+)
+
+// storedCredentials returns the fields of the requesting user's stored
+// integration
+func (h *Handler) storedCredentials(r *http.Request, integrationName string) (map[string]string, error) {
+	claims, ok := r.Context().Value("claims").(*auth.Claims)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	if h.integrations == nil {
+		return nil, errNoCredentialDatabase
+	}
+
+	integration, err := h.integrations.GetIntegration(claims.UserID, integrationName)
+	if err != nil {
+		return nil, err
+	}
+	if integration == nil || len(integration.Fields) == 0 {
+		return nil, fmt.Errorf("%w %q", ErrNoCredentials, integrationName)
+	}
+	return integration.Fields, nil
+}
+
+// requireCredentials returns the stored credentials of an integration, or
+// writes an error response and returns false
+func (h *Handler) requireCredentials(w http.ResponseWriter, r *http.Request, integrationName string) (map[string]string, bool) {
+	credentials, err := h.storedCredentials(r, integrationName)
+	if err != nil {
+		writeCredentialError(w, "requireCredentials", err)
+		return nil, false
+	}
+	return credentials, true
+}
+
+// anthropicKey returns the requesting user's stored Anthropic API key, or the
+// service's ANTHROPIC_API_KEY if they have none
+func (h *Handler) anthropicKey(r *http.Request) (string, error) {
+	credentials, err := h.storedCredentials(r, AnthropicIntegration)
+	if err != nil && !errors.Is(err, ErrNoCredentials) && !errors.Is(err, errNoCredentialDatabase) {
+		return "", err
+	}
+	if key := credentials[AnthropicKeyField]; key != "" {
+		return key, nil
+	}
+	if key := os.Getenv("ANTHROPIC_API_KEY"); key != "" {
+		return key, nil
+	}
+	return "", ErrNoAnthropicKey
+}
+
+// requireAnthropicKey returns the Anthropic API key for a request, or writes
+// an error response and returns false
+func (h *Handler) requireAnthropicKey(w http.ResponseWriter, r *http.Request) (string, bool) {
+	key, err := h.anthropicKey(r)
+	if err != nil {
+		writeCredentialError(w, "requireAnthropicKey", err)
+		return "", false
+	}
+	return key, true
+}
+
+// writeCredentialError maps credential lookup errors to responses
+func writeCredentialError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case errors.Is(err, ErrNoCredentials), errors.Is(err, ErrNoAnthropicKey):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, vault.ErrNoMasterKey), errors.Is(err, errNoCredentialDatabase):
+		http.Error(w, "credential vault is not configured: set INTENTR_MASTER_KEY", http.StatusServiceUnavailable)
+	default:
+		log.Printf("[%s] FAILED to load credentials: %v", action, err)
+		http.Error(w, "failed to load stored credentials", http.StatusInternalServerError)
+	}
+}
+
+// SaveCredentialsRequest stores an integration's credentials. Fields sent
+// empty keep their stored value, so a form can be saved without re-entering
+// secrets it never received; fields left out are removed.
+type SaveCredentialsRequest struct {
+	ProviderURL string            `json:"provider_url"`
+	Fields      map[string]string `json:"fields"`
+}
+
+// HandleListCredentials handles GET /credentials. It lists the user's stored
+// integrations with their field names but never their values.
+func (h *Handler) HandleListCredentials(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*auth.Claims)
+	if !ok {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+	if h.integrations == nil {
+		writeCredentialError(w, "HandleListCredentials", errNoCredentialDatabase)
+		return
+	}
+
+	summaries, err := h.integrations.ListIntegrations(claims.UserID)
+	if err != nil {
+		writeCredentialError(w, "HandleListCredentials", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"integrations": summaries,
+	})
+}
+
+// HandleSaveCredentials handles PUT /credentials/{name}
+func (h *Handler) HandleSaveCredentials(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*auth.Claims)
+	if !ok {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+	if h.integrations == nil {
+		writeCredentialError(w, "HandleSaveCredentials", errNoCredentialDatabase)
+		return
+	}
+
+	name := strings.TrimSpace(r.PathValue("name"))
+	if name == "" {
+		http.Error(w, "integration name is required", http.StatusBadRequest)
+		return
+	}
+
+	var req SaveCredentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.integrations.GetIntegration(claims.UserID, name)
+	if err != nil && !errors.Is(err, vault.ErrNoMasterKey) {
+		writeCredentialError(w, "HandleSaveCredentials", err)
+		return
+	}
+	fields := make(map[string]string, len(req.Fields))
+	for field, value := range req.Fields {
+		if value == "" && existing != nil {
+			value = existing.Fields[field]
+		}
+		fields[field] = value
+	}
+
+	if err := h.integrations.SaveIntegration(claims.UserID, name, req.ProviderURL, fields); err != nil {
+		writeCredentialError(w, "HandleSaveCredentials", err)
+		return
+	}
+	log.Printf("[HandleSaveCredentials] Stored %d credential field(s) of %q for user %d", len(fields), name, claims.UserID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleDeleteCredentials handles DELETE /credentials/{name}
+func (h *Handler) HandleDeleteCredentials(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*auth.Claims)
+	if !ok {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+	if h.integrations == nil {
+		writeCredentialError(w, "HandleDeleteCredentials", errNoCredentialDatabase)
+		return
+	}
+
+	err := h.integrations.DeleteIntegration(claims.UserID, r.PathValue("name"))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "integration not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeCredentialError(w, "HandleDeleteCredentials", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
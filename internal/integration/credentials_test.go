@@ -0,0 +1,50 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jareynolds/intentr/internal/auth"
+)
+
+func TestAnthropicKey(t *testing.T) {
+	h := NewHandler(nil)
+	r := httptest.NewRequest(http.MethodPost, "/ai-chat", nil)
+	r = r.WithContext(context.WithValue(r.Context(), "claims", &auth.Claims{UserID: 1}))
+
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	if _, err := h.anthropicKey(r); !errors.Is(err, ErrNoAnthropicKey) {
+		t.Errorf("anthropicKey(no key) = %v, want ErrNoAnthropicKey", err)
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "sk-env")
+	if key, err := h.anthropicKey(r); err != nil || key != "sk-env" {
+		t.Errorf("anthropicKey(env) = %q, %v", key, err)
+	}
+}
+
+func TestRequireCredentialsWithoutDatabase(t *testing.T) {
+	h := NewHandler(nil)
+	r := httptest.NewRequest(http.MethodPost, "/fetch-resources", strings.NewReader(`{}`))
+	r = r.WithContext(context.WithValue(r.Context(), "claims", &auth.Claims{UserID: 1}))
+	w := httptest.NewRecorder()
+
+	if _, ok := h.requireCredentials(w, r, "GitHub"); ok {
+		t.Fatal("requireCredentials() succeeded without a credential store")
+	}
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("requireCredentials() status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
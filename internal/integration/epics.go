@@ -0,0 +1,213 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/jareynolds/intentr/internal/auth"
+	"github.com/jareynolds/intentr/pkg/idalloc"
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// FetchEpicsRequest is the request for fetching the epics of a Jira project,
+// a GitLab group or an Azure DevOps project
+type FetchEpicsRequest struct {
+	IntegrationName string            `json:"integration_name"`         // Jira, GitLab or Azure DevOps
+	Project         string            `json:"project"`                  // Jira project key, GitLab group ID or path, or Azure DevOps project
+	WorkItemType    string            `json:"work_item_type,omitempty"` // Azure DevOps only; defaults to "Epic"
+	Credentials     map[string]string `json:"-"`                        // Loaded from the credential vault
+}
+
+// FetchEpicsResponse is the response containing the epics of a project
+type FetchEpicsResponse struct {
+	IntegrationName string `json:"integration_name"`
+	Project         string `json:"project"`
+	Epics           []Epic `json:"epics"`
+	Total           int    `json:"total"`
+}
+
+// FetchEpics fetches the epics that can be imported as capabilities, such as
+// Jira epics, GitLab group epics or Azure DevOps work items of one type
+func FetchEpics(ctx context.Context, req FetchEpicsRequest) (*FetchEpicsResponse, error) {
+	provider, err := lookupProvider(req.IntegrationName)
+	if err != nil {
+		return nil, err
+	}
+	epics, err := provider.FetchEpics(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FetchEpicsResponse{
+		IntegrationName: req.IntegrationName,
+		Project:         req.Project,
+		Epics:           epics,
+		Total:           len(epics),
+	}, nil
+}
+
+// importEpic writes an epic of source as a new capability in the workspace's
+// definition folder, returning the capability ID and file name
+func (h *Handler) importEpic(workspacePath string, epic Epic, source string) (string, string, error) {
+	// Reserve the next free capability ID for this workspace
+	capID, err := h.allocateID(workspacePath, idalloc.PrefixCapability)
+	if err != nil {
+		return "", "", fmt.Errorf("Failed to allocate capability ID: %v", err)
+	}
+
+	content := generateCapabilityMarkdown(capID, epic, source, mapEpicStatusToINTENT(epic.Status), mapEpicPriorityToINTENT(epic.Priority))
+
+	// Generate filename (format: CAP-XXXXXX.md)
+	filename := fmt.Sprintf("%s.md", capID)
+	filePath := filepath.Join(workspacePath, "definition", filename)
+	if _, err := os.Stat(filePath); err == nil {
+		return "", "", fmt.Errorf("File already exists: %s", filename)
+	}
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		return "", "", fmt.Errorf("Failed to write file: %v", err)
+	}
+	return capID, filename, nil
+}
+
+// linkImportedJiraEpic links a capability imported from a Jira epic to the
+// epic for Jira sync. The epic's summary and status are recorded, so the
+// first sync pulls what changed in Jira since the import.
+func (h *Handler) linkImportedJiraEpic(workspaceID, capID string, epic Epic, userID *int) {
+	if h.jiraLinks == nil || workspaceID == "" {
+		return
+	}
+	_, err := h.jiraLinks.CreateLink(models.JiraSyncLink{
+		WorkspaceID:    workspaceID,
+		EntityType:     models.EntityTypeCapability,
+		EntityID:       capID,
+		IssueKey:       epic.Key,
+		LastSummary:    epic.Summary,
+		LastJiraStatus: epic.Status,
+		CreatedBy:      userID,
+	})
+	if err != nil {
+		log.Printf("[linkImportedJiraEpic] FAILED to link %s to %s: %v", capID, epic.Key, err)
+	}
+}
+
+// HandleFetchEpics handles POST /fetch-epics
+func (h *Handler) HandleFetchEpics(w http.ResponseWriter, r *http.Request) {
+	var req FetchEpicsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.IntegrationName == "" || req.Project == "" {
+		http.Error(w, "integration_name and project are required", http.StatusBadRequest)
+		return
+	}
+
+	credentials, ok := h.requireCredentials(w, r, req.IntegrationName)
+	if !ok {
+		return
+	}
+	req.Credentials = credentials
+
+	resp, err := FetchEpics(r.Context(), req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to fetch epics: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ImportEpicsRequest is the request to import epics fetched with
+// /fetch-epics as capabilities
+type ImportEpicsRequest struct {
+	WorkspacePath   string `json:"workspace_path"`
+	IntegrationName string `json:"integration_name"`
+	Epics           []Epic `json:"epics"`
+}
+
+// ImportedEpic is an epic imported as a capability
+type ImportedEpic struct {
+	Key          string `json:"key"`
+	CapabilityID string `json:"capability_id"`
+	Filename     string `json:"filename"`
+}
+
+// EpicImportError is an epic that could not be imported
+type EpicImportError struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+// ImportEpicsResponse is the response from importing epics
+type ImportEpicsResponse struct {
+	Imported      []ImportedEpic    `json:"imported"`
+	Errors        []EpicImportError `json:"errors"`
+	TotalImported int               `json:"total_imported"`
+	TotalErrors   int               `json:"total_errors"`
+}
+
+// HandleImportEpics handles POST /import-epics. Capabilities imported from
+// Jira epics are linked to them for Jira sync, as with /import-jira-epics.
+func (h *Handler) HandleImportEpics(w http.ResponseWriter, r *http.Request) {
+	var req ImportEpicsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.WorkspacePath == "" {
+		http.Error(w, "workspace_path is required", http.StatusBadRequest)
+		return
+	}
+	if req.IntegrationName == "" {
+		http.Error(w, "integration_name is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Epics) == 0 {
+		http.Error(w, "at least one epic is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Join(req.WorkspacePath, "definition"), 0755); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create definitions directory: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	workspaceID := workspaceIDForPath(req.WorkspacePath)
+	var userID *int
+	if claims, ok := r.Context().Value("claims").(*auth.Claims); ok {
+		userID = &claims.UserID
+	}
+
+	response := ImportEpicsResponse{Imported: []ImportedEpic{}, Errors: []EpicImportError{}}
+	for _, epic := range req.Epics {
+		capID, filename, err := h.importEpic(req.WorkspacePath, epic, req.IntegrationName)
+		if err != nil {
+			response.Errors = append(response.Errors, EpicImportError{Key: epic.Key, Error: err.Error()})
+			continue
+		}
+		response.Imported = append(response.Imported, ImportedEpic{Key: epic.Key, CapabilityID: capID, Filename: filename})
+		if req.IntegrationName == JiraIntegration {
+			h.linkImportedJiraEpic(workspaceID, capID, epic, userID)
+		}
+	}
+	response.TotalImported = len(response.Imported)
+	response.TotalErrors = len(response.Errors)
+	log.Printf("[HandleImportEpics] Imported %d of %d %s epics into %s", response.TotalImported, len(req.Epics), req.IntegrationName, req.WorkspacePath)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,146 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jareynolds/intentr/pkg/githubissues"
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/jareynolds/intentr/pkg/spec"
+)
+
+// GitHubIntegration is the stored integration the GitHub exporter uses by
+// default
+const GitHubIntegration = "GitHub"
+
+// githubRepoPattern matches "owner/repo"
+var githubRepoPattern = regexp.MustCompile(`^[\w.-]+/[\w.-]+$`)
+
+// enablerLifecycle returns an enabler's lifecycle state from the database,
+// falling back to a lifecycle-valued Status field in its spec
+func (h *Handler) enablerLifecycle(e *spec.Enabler) string {
+	if h.state != nil {
+		if state, err := h.state.GetEnablerState(e.ID); err == nil {
+			return state.LifecycleState
+		}
+	}
+	switch status := models.LifecycleState(strings.ToLower(e.Status)); status {
+	case models.LifecycleStateDraft, models.LifecycleStateActive, models.LifecycleStateImplemented,
+		models.LifecycleStateMaintained, models.LifecycleStateRetired:
+		return string(status)
+	}
+	return ""
+}
+
+// enablerSpecFiles returns the enabler specs of a workspace, relative to it
+func enablerSpecFiles(workspacePath string) []string {
+	var files []string
+	for _, folder := range []string{"definition", "specifications"} {
+		entries, err := os.ReadDir(filepath.Join(workspacePath, folder))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && spec.IsEnablerFile(entry.Name()) {
+				files = append(files, filepath.Join(folder, entry.Name()))
+			}
+		}
+	}
+	return files
+}
+
+// HandleExportGitHubIssues handles POST /github/export-issues. It publishes
+// the enablers of a workspace as issues with the user's stored GitHub
+// credentials, recording each new issue in its enabler's spec, and closes or
+// reopens existing issues to match their enablers' lifecycle states. Once an
+// issue is recorded, WatchEnablerLifecycle keeps its state in step without
+// another export.
+func (h *Handler) HandleExportGitHubIssues(w http.ResponseWriter, r *http.Request) {
+	var req models.GitHubExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.WorkspacePath == "" {
+		http.Error(w, "workspace_path is required", http.StatusBadRequest)
+		return
+	}
+	if req.Repository != "" && !githubRepoPattern.MatchString(req.Repository) {
+		http.Error(w, "repository must be owner/repo", http.StatusBadRequest)
+		return
+	}
+
+	if req.IntegrationName == "" {
+		req.IntegrationName = GitHubIntegration
+	}
+	credentials, ok := h.requireCredentials(w, r, req.IntegrationName)
+	if !ok {
+		return
+	}
+	token, ok := githubToken(credentials)
+	if !ok {
+		http.Error(w, fmt.Sprintf("access_token not found in credentials. Got fields: %v", getCredentialKeys(credentials)), http.StatusBadRequest)
+		return
+	}
+	client := githubissues.NewClient(h.githubAPIURL, token)
+
+	only := map[string]bool{}
+	for _, id := range req.EnablerIDs {
+		only[id] = true
+	}
+
+	resp := models.GitHubExportResponse{Repository: req.Repository, Results: []models.GitHubExportResult{}}
+	for _, file := range enablerSpecFiles(req.WorkspacePath) {
+		path := filepath.Join(req.WorkspacePath, file)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("[HandleExportGitHubIssues] FAILED to read %s: %v", path, err)
+			continue
+		}
+		e := spec.ParseEnabler(string(content))
+		if e.ID == "" || (len(only) > 0 && !only[e.ID]) {
+			continue
+		}
+
+		result := githubissues.Export(r.Context(), client, e, req.Repository, h.enablerLifecycle(e), req.ProjectID)
+		result.File = file
+		// A new issue is recorded in the spec even if adding it to the
+		// project failed, so the next export does not open another
+		if updated := e.Doc.String(); updated != string(content) {
+			if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+				log.Printf("[HandleExportGitHubIssues] FAILED to record %s in %s: %v", result.Issue, path, err)
+				result.Result = models.GitHubExportError
+				result.Error = fmt.Sprintf("opened %s but failed to record it in the spec: %v", result.Issue, err)
+			}
+		}
+
+		switch result.Result {
+		case models.GitHubExportCreated:
+			resp.Created++
+		case models.GitHubExportUpdated:
+			resp.Updated++
+		case models.GitHubExportError:
+			resp.Errors++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	log.Printf("[HandleExportGitHubIssues] %s: %d enablers, %d created, %d updated, %d errors",
+		req.WorkspacePath, len(resp.Results), resp.Created, resp.Updated, resp.Errors)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
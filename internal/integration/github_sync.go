@@ -0,0 +1,179 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jareynolds/intentr/pkg/changefeed"
+	"github.com/jareynolds/intentr/pkg/githubissues"
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/jareynolds/intentr/pkg/spec"
+	"github.com/jareynolds/intentr/pkg/statemodel"
+)
+
+// lifecycleSyncInterval is how often WatchEnablerLifecycle reads the change
+// feed when no notification wakes it
+const lifecycleSyncInterval = time.Minute
+
+// lifecycleSyncBatch is how many change events WatchEnablerLifecycle reads
+// at a time
+const lifecycleSyncBatch = 100
+
+// WatchEnablerLifecycle closes and reopens the GitHub issues of enablers as
+// their lifecycle states change, until ctx is done. It follows the change
+// feed from its newest event, woken by feed, or polling if feed is nil.
+// Issues are synced with the GitHub credentials of the user who changed the
+// state; enablers that were never exported are left alone. Every replica
+// may run it, as syncing an issue twice changes nothing.
+func (h *Handler) WatchEnablerLifecycle(ctx context.Context, feed *changefeed.Feed) {
+	if h.changeEvents == nil {
+		return
+	}
+
+	var wake <-chan struct{}
+	if feed != nil {
+		var cancel func()
+		wake, cancel = feed.SubscribeAll()
+		defer cancel()
+	}
+	ticker := time.NewTicker(lifecycleSyncInterval)
+	defer ticker.Stop()
+
+	lastID := int64(-1) // Not yet known
+	for {
+		if lastID < 0 {
+			id, err := h.changeEvents.LatestIDAll()
+			if err != nil {
+				log.Printf("[WatchEnablerLifecycle] FAILED to read the change feed: %v", err)
+			} else {
+				lastID = id
+			}
+		} else {
+			lastID = h.syncLifecycleChanges(ctx, lastID)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-wake:
+			if !ok {
+				wake = nil // Feed closed; keep polling
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncLifecycleChanges syncs the issues of the enablers whose lifecycle
+// state changed after afterID, returning the last event read
+func (h *Handler) syncLifecycleChanges(ctx context.Context, afterID int64) int64 {
+	for ctx.Err() == nil {
+		events, err := h.changeEvents.ListAllSince(afterID, lifecycleSyncBatch)
+		if err != nil {
+			log.Printf("[WatchEnablerLifecycle] FAILED to read the change feed: %v", err)
+			return afterID
+		}
+		for _, event := range events {
+			afterID = event.ID
+			change, ok := enablerLifecycleChange(event)
+			if !ok {
+				continue
+			}
+			if err := h.syncLifecycleChange(ctx, event.WorkspaceID, change); err != nil {
+				log.Printf("[WatchEnablerLifecycle] FAILED to sync the issue of %s: %v", change.EntityID, err)
+			}
+		}
+		if len(events) < lifecycleSyncBatch {
+			break
+		}
+	}
+	return afterID
+}
+
+// enablerLifecycleChange returns the state change an event reports if it is
+// a change of an enabler's lifecycle state
+func enablerLifecycleChange(event models.ChangeEvent) (models.EntityStateChange, bool) {
+	var change models.EntityStateChange
+	if event.EventType != models.ChangeEventEntityState || event.EntityType != models.EntityTypeEnabler {
+		return change, false
+	}
+	if err := json.Unmarshal(event.Payload, &change); err != nil {
+		log.Printf("[WatchEnablerLifecycle] ignoring malformed change event %d: %v", event.ID, err)
+		return change, false
+	}
+	return change, change.FieldChanged == statemodel.FieldLifecycleState && change.EntityID != ""
+}
+
+// syncLifecycleChange syncs the issue of an enabler whose lifecycle state
+// changed with the GitHub credentials of the user who changed it
+func (h *Handler) syncLifecycleChange(ctx context.Context, workspaceID string, change models.EntityStateChange) error {
+	folder := h.workspaceFolder(workspaceID)
+	if folder == "" {
+		return nil // Not a workspace of this service
+	}
+	e, err := findEnablerSpec(folder, change.EntityID)
+	if err != nil {
+		return err
+	}
+	if e == nil || e.Doc.FieldValue(githubissues.IssueField) == "" {
+		return nil // Never exported
+	}
+
+	if change.ChangedBy == nil || h.integrations == nil {
+		return fmt.Errorf("no user to sync %s as", e.Doc.FieldValue(githubissues.IssueField))
+	}
+	integration, err := h.integrations.GetIntegration(*change.ChangedBy, GitHubIntegration)
+	if err != nil {
+		return err
+	}
+	if integration == nil {
+		return fmt.Errorf("%w %q for user %d", ErrNoCredentials, GitHubIntegration, *change.ChangedBy)
+	}
+	token, ok := githubToken(integration.Fields)
+	if !ok {
+		return fmt.Errorf("access_token not found in the %s credentials of user %d", GitHubIntegration, *change.ChangedBy)
+	}
+
+	return syncEnablerIssue(ctx, githubissues.NewClient(h.githubAPIURL, token), e, change.NewValue)
+}
+
+// syncEnablerIssue brings an exported enabler's issue up to date with its
+// lifecycle state
+func syncEnablerIssue(ctx context.Context, client *githubissues.Client, e *spec.Enabler, lifecycle string) error {
+	result := githubissues.Export(ctx, client, e, "", lifecycle, "")
+	if result.Result == models.GitHubExportError {
+		return fmt.Errorf("%s", result.Error)
+	}
+	if result.Result == models.GitHubExportUpdated {
+		log.Printf("[WatchEnablerLifecycle] %s is %s: %s is now %s", e.ID, lifecycle, result.Issue, result.State)
+	}
+	return nil
+}
+
+// findEnablerSpec returns the spec of an enabler in a workspace, or nil if
+// it has none
+func findEnablerSpec(workspacePath, enablerID string) (*spec.Enabler, error) {
+	for _, file := range enablerSpecFiles(workspacePath) {
+		content, err := os.ReadFile(filepath.Join(workspacePath, file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		if e := spec.ParseEnabler(string(content)); e.ID == enablerID {
+			return e, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,127 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jareynolds/intentr/pkg/githubissues"
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+const exportedEnablerSpec = `# Markdown Export
+
+## Metadata
+
+- **Name**: Markdown Export
+- **Type**: Enabler
+- **ID**: ENB-673305
+- **GitHub Issue**: acme/app#7
+
+## Purpose
+
+Exports storyboards to markdown.
+`
+
+func TestEnablerLifecycleChange(t *testing.T) {
+	payload := func(field string) json.RawMessage {
+		data, _ := json.Marshal(models.EntityStateChange{EntityType: "enabler", EntityID: "ENB-673305", FieldChanged: field, NewValue: "implemented"})
+		return data
+	}
+	tests := []struct {
+		name  string
+		event models.ChangeEvent
+		want  bool
+	}{
+		{"enabler lifecycle", models.ChangeEvent{EventType: models.ChangeEventEntityState, EntityType: "enabler", Payload: payload("lifecycle_state")}, true},
+		{"enabler stage", models.ChangeEvent{EventType: models.ChangeEventEntityState, EntityType: "enabler", Payload: payload("workflow_stage")}, false},
+		{"capability lifecycle", models.ChangeEvent{EventType: models.ChangeEventEntityState, EntityType: "capability", Payload: payload("lifecycle_state")}, false},
+		{"phase approval", models.ChangeEvent{EventType: models.ChangeEventPhaseApproval, Payload: json.RawMessage(`{}`)}, false},
+		{"malformed", models.ChangeEvent{EventType: models.ChangeEventEntityState, EntityType: "enabler", Payload: json.RawMessage(`[`)}, false},
+	}
+	for _, tt := range tests {
+		change, ok := enablerLifecycleChange(tt.event)
+		if ok != tt.want {
+			t.Errorf("%s: enablerLifecycleChange() = %v, want %v", tt.name, ok, tt.want)
+		}
+		if ok && (change.EntityID != "ENB-673305" || change.NewValue != "implemented") {
+			t.Errorf("%s: enablerLifecycleChange() = %+v", tt.name, change)
+		}
+	}
+}
+
+func TestSyncEnablerIssueFollowsLifecycle(t *testing.T) {
+	root := t.TempDir()
+	h := NewHandler(nil)
+	h.SetWorkspacesRoot(root)
+	for folder, id := range map[string]string{"other": "ws-2", "app": "ws-1"} {
+		if err := os.MkdirAll(filepath.Join(root, folder, "definition"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, folder, ".intentrworkspace"), []byte(`{"id":"`+id+`"}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "app", "definition", "ENB-673305.md"), []byte(exportedEnablerSpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	folder := h.workspaceFolder("ws-1")
+	if folder != filepath.Join(root, "app") {
+		t.Fatalf("workspaceFolder(ws-1) = %q", folder)
+	}
+	e, err := findEnablerSpec(folder, "ENB-673305")
+	if err != nil || e == nil {
+		t.Fatalf("findEnablerSpec() = %v, %v", e, err)
+	}
+
+	// Changes of enablers in other workspaces, or never exported, need no credentials
+	change := models.EntityStateChange{EntityType: "enabler", EntityID: "ENB-673305", FieldChanged: "lifecycle_state", NewValue: "implemented"}
+	if err := h.syncLifecycleChange(context.Background(), "ws-2", change); err != nil {
+		t.Errorf("syncLifecycleChange(other workspace) error = %v", err)
+	}
+	if err := h.syncLifecycleChange(context.Background(), "ws-1", change); err == nil {
+		t.Error("syncLifecycleChange() without credentials succeeded")
+	}
+
+	issue := githubissues.Issue{Number: 7, Title: "Markdown Export", Body: githubissues.IssueBody(e), State: githubissues.StateOpen}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/app/issues/7" {
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodPatch {
+			var update githubissues.IssueUpdate
+			json.NewDecoder(r.Body).Decode(&update)
+			issue.State = update.State
+		}
+		json.NewEncoder(w).Encode(issue)
+	}))
+	defer server.Close()
+	client := githubissues.NewClient(server.URL, "token")
+
+	if err := syncEnablerIssue(context.Background(), client, e, "implemented"); err != nil {
+		t.Fatalf("syncEnablerIssue(implemented) error = %v", err)
+	}
+	if issue.State != githubissues.StateClosed {
+		t.Errorf("issue state after implemented = %q, want closed", issue.State)
+	}
+	if err := syncEnablerIssue(context.Background(), client, e, "active"); err != nil {
+		t.Fatalf("syncEnablerIssue(active) error = %v", err)
+	}
+	if issue.State != githubissues.StateOpen {
+		t.Errorf("issue state after active = %q, want open", issue.State)
+	}
+}
@@ -0,0 +1,331 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GitLabIntegration is the name of the GitLab integration
+const GitLabIntegration = "GitLab"
+
+// gitLabClient calls the GitLab REST API (v4) of gitlab.com or a
+// self-managed instance with a personal access token
+type gitLabClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// newGitLabClient creates a GitLab client from stored GitLab credentials.
+// The instance defaults to gitlab.com.
+func newGitLabClient(credentials map[string]string) (*gitLabClient, error) {
+	token, ok := getCredential(credentials, "access_token", "personal_access_token", "private_token", "token", "api_key", "pat", "PAT")
+	if !ok {
+		return nil, fmt.Errorf("access_token not found in credentials. Expected one of: access_token, personal_access_token, private_token, token. Got fields: %v", getCredentialKeys(credentials))
+	}
+	baseURL := "https://gitlab.com"
+	if instance, ok := getCredential(credentials, "base_url", "baseUrl", "instance", "instance_url", "host", "domain", "url"); ok {
+		baseURL = siteURL(instance)
+	}
+	return &gitLabClient{
+		baseURL:    strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/api/v4"),
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// get fetches an API path, relative to /api/v4, and decodes the JSON response
+func (c *gitLabClient) get(ctx context.Context, path string, out interface{}) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v4"+path, nil)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("PRIVATE-TOKEN", c.token)
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to call GitLab: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("GitLab API error (%d): %s", resp.StatusCode, string(body))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// gitLabID escapes a numeric ID or a "group/project" path for use in a URL
+func gitLabID(id string) string {
+	return url.PathEscape(id)
+}
+
+// fetchGitLabResources fetches the projects the user is a member of, or
+// with resource_type "group" the groups, which hold epics
+func fetchGitLabResources(ctx context.Context, req FetchResourcesRequest) (*FetchResourcesResponse, error) {
+	client, err := newGitLabClient(req.Credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ResourceType == "group" {
+		var groups []struct {
+			ID          int    `json:"id"`
+			FullPath    string `json:"full_path"`
+			FullName    string `json:"full_name"`
+			Description string `json:"description"`
+			WebURL      string `json:"web_url"`
+		}
+		if err := client.get(ctx, "/groups?min_access_level=10&per_page=100", &groups); err != nil {
+			return nil, fmt.Errorf("failed to fetch GitLab groups: %w", err)
+		}
+		resources := make([]IntegrationResource, len(groups))
+		for i, group := range groups {
+			resources[i] = IntegrationResource{
+				ID:          fmt.Sprintf("%d", group.ID),
+				Name:        group.FullName,
+				Type:        "group",
+				Description: group.Description,
+				URL:         group.WebURL,
+				Metadata: map[string]interface{}{
+					"full_path": group.FullPath,
+				},
+			}
+		}
+		return &FetchResourcesResponse{IntegrationName: GitLabIntegration, Resources: resources}, nil
+	}
+
+	var projects []struct {
+		ID                int    `json:"id"`
+		PathWithNamespace string `json:"path_with_namespace"`
+		Description       string `json:"description"`
+		WebURL            string `json:"web_url"`
+		HTTPURLToRepo     string `json:"http_url_to_repo"`
+		DefaultBranch     string `json:"default_branch"`
+		Visibility        string `json:"visibility"`
+		LastActivityAt    string `json:"last_activity_at"`
+		Namespace         struct {
+			ID       int    `json:"id"`
+			Kind     string `json:"kind"`
+			FullPath string `json:"full_path"`
+		} `json:"namespace"`
+	}
+	if err := client.get(ctx, "/projects?membership=true&order_by=last_activity_at&per_page=100", &projects); err != nil {
+		return nil, fmt.Errorf("failed to fetch GitLab projects: %w", err)
+	}
+
+	resources := make([]IntegrationResource, len(projects))
+	for i, project := range projects {
+		resources[i] = IntegrationResource{
+			ID:          fmt.Sprintf("%d", project.ID),
+			Name:        project.PathWithNamespace,
+			Type:        "project",
+			Description: project.Description,
+			URL:         project.WebURL,
+			Metadata: map[string]interface{}{
+				"visibility":     project.Visibility,
+				"default_branch": project.DefaultBranch,
+				"updated_at":     project.LastActivityAt,
+				"clone_url":      project.HTTPURLToRepo,
+				"namespace_id":   project.Namespace.ID,
+				"namespace_kind": project.Namespace.Kind,
+				"namespace_path": project.Namespace.FullPath,
+			},
+		}
+	}
+
+	return &FetchResourcesResponse{
+		IntegrationName: GitLabIntegration,
+		Resources:       resources,
+	}, nil
+}
+
+// fetchGitLabFiles fetches the root of a project's repository tree, or with
+// resource_type "issues" the project's issues, or with resource_type "group"
+// the group's epics
+func fetchGitLabFiles(ctx context.Context, req FetchFilesRequest) (*FetchFilesResponse, error) {
+	client, err := newGitLabClient(req.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	if req.ResourceID == "" {
+		return nil, fmt.Errorf("resource_id is required")
+	}
+
+	switch req.ResourceType {
+	case "group", "epics":
+		epics, err := fetchGitLabEpics(ctx, client, req.ResourceID)
+		if err != nil {
+			return nil, err
+		}
+		files := make([]IntegrationFile, len(epics))
+		for i, epic := range epics {
+			files[i] = IntegrationFile{
+				ID:        epic.ID,
+				Name:      fmt.Sprintf("%s - %s", epic.Key, epic.Summary),
+				Type:      "epic",
+				URL:       epic.URL,
+				CreatedAt: epic.Created,
+				UpdatedAt: epic.Updated,
+				Metadata:  map[string]interface{}{"key": epic.Key, "state": epic.Status},
+			}
+		}
+		return &FetchFilesResponse{
+			IntegrationName: GitLabIntegration,
+			ResourceID:      req.ResourceID,
+			ResourceName:    fmt.Sprintf("Group %s", req.ResourceID),
+			Files:           files,
+		}, nil
+
+	case "issues":
+		var issues []struct {
+			ID         int      `json:"id"`
+			IID        int      `json:"iid"`
+			Title      string   `json:"title"`
+			State      string   `json:"state"`
+			Labels     []string `json:"labels"`
+			WebURL     string   `json:"web_url"`
+			CreatedAt  string   `json:"created_at"`
+			UpdatedAt  string   `json:"updated_at"`
+			References struct {
+				Full string `json:"full"`
+			} `json:"references"`
+		}
+		path := fmt.Sprintf("/projects/%s/issues?state=all&order_by=updated_at&per_page=100", gitLabID(req.ResourceID))
+		if err := client.get(ctx, path, &issues); err != nil {
+			return nil, fmt.Errorf("failed to fetch GitLab issues: %w", err)
+		}
+		files := make([]IntegrationFile, len(issues))
+		for i, issue := range issues {
+			files[i] = IntegrationFile{
+				ID:        fmt.Sprintf("%d", issue.ID),
+				Name:      fmt.Sprintf("#%d - %s", issue.IID, issue.Title),
+				Type:      "issue",
+				URL:       issue.WebURL,
+				CreatedAt: issue.CreatedAt,
+				UpdatedAt: issue.UpdatedAt,
+				Metadata: map[string]interface{}{
+					"key":    issue.References.Full,
+					"state":  issue.State,
+					"labels": issue.Labels,
+				},
+			}
+		}
+		return &FetchFilesResponse{
+			IntegrationName: GitLabIntegration,
+			ResourceID:      req.ResourceID,
+			ResourceName:    fmt.Sprintf("Project %s", req.ResourceID),
+			Files:           files,
+		}, nil
+	}
+
+	var project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+		WebURL            string `json:"web_url"`
+		DefaultBranch     string `json:"default_branch"`
+	}
+	if err := client.get(ctx, "/projects/"+gitLabID(req.ResourceID), &project); err != nil {
+		return nil, fmt.Errorf("failed to fetch GitLab project: %w", err)
+	}
+
+	var tree []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Type string `json:"type"` // blob or tree
+		Path string `json:"path"`
+	}
+	path := fmt.Sprintf("/projects/%s/repository/tree?per_page=100", gitLabID(req.ResourceID))
+	if project.DefaultBranch != "" {
+		path += "&ref=" + url.QueryEscape(project.DefaultBranch)
+	}
+	if err := client.get(ctx, path, &tree); err != nil {
+		return nil, fmt.Errorf("failed to fetch GitLab repository tree: %w", err)
+	}
+
+	files := make([]IntegrationFile, 0, len(tree))
+	for _, entry := range tree {
+		if entry.Type != "blob" {
+			continue
+		}
+		files = append(files, IntegrationFile{
+			ID:   entry.Path,
+			Name: entry.Name,
+			Type: "file",
+			URL:  fmt.Sprintf("%s/-/blob/%s/%s", project.WebURL, project.DefaultBranch, entry.Path),
+			Metadata: map[string]interface{}{
+				"path":         entry.Path,
+				"blob_id":      entry.ID,
+				"download_url": fmt.Sprintf("%s/api/v4/projects/%s/repository/files/%s/raw?ref=%s", client.baseURL, gitLabID(req.ResourceID), url.PathEscape(entry.Path), url.QueryEscape(project.DefaultBranch)),
+			},
+		})
+	}
+
+	return &FetchFilesResponse{
+		IntegrationName: GitLabIntegration,
+		ResourceID:      req.ResourceID,
+		ResourceName:    project.PathWithNamespace,
+		Files:           files,
+	}, nil
+}
+
+// fetchGitLabEpics fetches the epics of a group, by ID or full path
+func fetchGitLabEpics(ctx context.Context, client *gitLabClient, group string) ([]Epic, error) {
+	var gitLabEpics []struct {
+		ID          int      `json:"id"`
+		IID         int      `json:"iid"`
+		Title       string   `json:"title"`
+		Description string   `json:"description"`
+		State       string   `json:"state"` // opened or closed
+		Labels      []string `json:"labels"`
+		WebURL      string   `json:"web_url"`
+		CreatedAt   string   `json:"created_at"`
+		UpdatedAt   string   `json:"updated_at"`
+		References  struct {
+			Full string `json:"full"`
+		} `json:"references"`
+	}
+	path := fmt.Sprintf("/groups/%s/epics?order_by=created_at&sort=desc&per_page=100", gitLabID(group))
+	if err := client.get(ctx, path, &gitLabEpics); err != nil {
+		return nil, fmt.Errorf("failed to fetch GitLab epics: %w", err)
+	}
+
+	epics := make([]Epic, len(gitLabEpics))
+	for i, epic := range gitLabEpics {
+		key := epic.References.Full
+		if key == "" {
+			key = fmt.Sprintf("%s&%d", group, epic.IID)
+		}
+		epics[i] = Epic{
+			ID:          fmt.Sprintf("%d", epic.ID),
+			Key:         key,
+			Summary:     epic.Title,
+			Description: epic.Description,
+			Status:      epic.State,
+			Labels:      epic.Labels,
+			Created:     epic.CreatedAt,
+			Updated:     epic.UpdatedAt,
+			URL:         epic.WebURL,
+			IssueType:   "Epic",
+			Metadata: map[string]interface{}{
+				"gitlab_id":  epic.ID,
+				"gitlab_iid": epic.IID,
+			},
+		}
+	}
+	return epics, nil
+}
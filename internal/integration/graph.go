@@ -0,0 +1,214 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jareynolds/intentr/pkg/graph"
+)
+
+// GraphRequest represents a request against the dependency graph of a workspace
+type GraphRequest struct {
+	WorkspacePath string `json:"workspacePath"`
+	WorkspaceID   string `json:"workspaceId"`         // Optional; must match the workspace at WorkspacePath
+	ID            string `json:"id"`                  // Entity to start from (closure and blast radius)
+	Direction     string `json:"direction,omitempty"` // upstream, downstream or both (closure only)
+}
+
+// loadGraph decodes a GraphRequest and builds the workspace's dependency
+// graph from its specs and, when a database is configured, the dependency
+// tables. It writes the error response itself and returns ok=false on failure.
+func (h *Handler) loadGraph(w http.ResponseWriter, r *http.Request, needID bool) (*graph.Graph, GraphRequest, bool) {
+	var req GraphRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return nil, req, false
+	}
+
+	if req.WorkspacePath == "" {
+		http.Error(w, "workspacePath is required", http.StatusBadRequest)
+		return nil, req, false
+	}
+	if needID && req.ID == "" {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return nil, req, false
+	}
+
+	// If path contains "workspaces/", extract the relative part
+	workspacePath := req.WorkspacePath
+	if idx := strings.Index(workspacePath, "workspaces/"); idx != -1 {
+		workspacePath = workspacePath[idx:]
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get working directory: %v", err), http.StatusInternalServerError)
+		return nil, req, false
+	}
+
+	fullPath := filepath.Join(cwd, workspacePath)
+	if !h.confinePath(w, r, fullPath) {
+		return nil, req, false
+	}
+	// Database relationships are those of the workspace the path is in,
+	// which RequireWorkspaceRole has checked the user may view
+	workspaceID := workspaceIDForPath(fullPath)
+	if req.WorkspaceID != "" && req.WorkspaceID != workspaceID {
+		http.Error(w, "workspaceId does not match the workspace at workspacePath", http.StatusBadRequest)
+		return nil, req, false
+	}
+
+	g := graph.New()
+	if err := graph.LoadSpecs(g, fullPath); err != nil {
+		http.Error(w, fmt.Sprintf("failed to read specifications: %v", err), http.StatusInternalServerError)
+		return nil, req, false
+	}
+	if h.deps != nil && workspaceID != "" {
+		edges, err := h.deps.ListEdges(workspaceID)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to load dependencies: %v", err), http.StatusInternalServerError)
+			return nil, req, false
+		}
+		graph.AddDependencies(g, edges)
+	}
+
+	if needID {
+		if _, ok := g.Node(req.ID); !ok {
+			http.Error(w, fmt.Sprintf("%s not found in workspace", req.ID), http.StatusNotFound)
+			return nil, req, false
+		}
+	}
+	return g, req, true
+}
+
+// HandleGraph handles POST /graph
+// It returns every node and edge of the workspace dependency graph
+func (h *Handler) HandleGraph(w http.ResponseWriter, r *http.Request) {
+	g, _, ok := h.loadGraph(w, r, false)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"nodes":    g.Nodes(),
+		"edges":    g.Edges(),
+		"database": h.deps != nil,
+	})
+}
+
+// HandleGraphClosure handles POST /graph/closure
+// It returns everything an entity transitively depends on and/or everything
+// that transitively depends on it
+func (h *Handler) HandleGraphClosure(w http.ResponseWriter, r *http.Request) {
+	g, req, ok := h.loadGraph(w, r, true)
+	if !ok {
+		return
+	}
+
+	resp := map[string]interface{}{"success": true, "id": req.ID}
+	switch req.Direction {
+	case "upstream":
+		resp["upstream"] = nonNilReached(g.Upstream(req.ID))
+	case "downstream":
+		resp["downstream"] = nonNilReached(g.Downstream(req.ID))
+	case "", "both":
+		resp["upstream"] = nonNilReached(g.Upstream(req.ID))
+		resp["downstream"] = nonNilReached(g.Downstream(req.ID))
+	default:
+		http.Error(w, "direction must be upstream, downstream or both", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// HandleGraphOrder handles POST /graph/order
+// It returns a topological implementation order; entities on or behind a
+// cycle are listed as blocked
+func (h *Handler) HandleGraphOrder(w http.ResponseWriter, r *http.Request) {
+	g, _, ok := h.loadGraph(w, r, false)
+	if !ok {
+		return
+	}
+
+	order, blocked := g.Order()
+	if order == nil {
+		order = []string{}
+	}
+	if blocked == nil {
+		blocked = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"order":   order,
+		"blocked": blocked,
+	})
+}
+
+// HandleGraphCycles handles POST /graph/cycles
+func (h *Handler) HandleGraphCycles(w http.ResponseWriter, r *http.Request) {
+	g, _, ok := h.loadGraph(w, r, false)
+	if !ok {
+		return
+	}
+
+	cycles := g.Cycles()
+	if cycles == nil {
+		cycles = [][]string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"acyclic": len(cycles) == 0,
+		"cycles":  cycles,
+	})
+}
+
+// HandleGraphBlastRadius handles POST /graph/blast-radius
+// It returns every entity affected by changing the given capability or enabler
+func (h *Handler) HandleGraphBlastRadius(w http.ResponseWriter, r *http.Request) {
+	g, req, ok := h.loadGraph(w, r, true)
+	if !ok {
+		return
+	}
+
+	affected := nonNilReached(g.BlastRadius(req.ID))
+	counts := map[string]int{}
+	for _, a := range affected {
+		counts[a.Kind]++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":      true,
+		"id":           req.ID,
+		"affected":     affected,
+		"capabilities": counts[graph.KindCapability],
+		"enablers":     counts[graph.KindEnabler],
+	})
+}
+
+func nonNilReached(reached []graph.Reached) []graph.Reached {
+	if reached == nil {
+		return []graph.Reached{}
+	}
+	return reached
+}
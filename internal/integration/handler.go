@@ -9,8 +9,10 @@
 package integration
 
 import (
+	"database/sql"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -21,20 +23,68 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/jareynolds/intentr/internal/auth"
+	"github.com/jareynolds/intentr/pkg/diagram"
+	"github.com/jareynolds/intentr/pkg/graph"
+	"github.com/jareynolds/intentr/pkg/idalloc"
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/jareynolds/intentr/pkg/repository"
+	"github.com/jareynolds/intentr/pkg/spec"
+	"github.com/jareynolds/intentr/pkg/spec/lint"
+	"github.com/jareynolds/intentr/pkg/spec/rename"
+	"github.com/jareynolds/intentr/pkg/vault"
 )
 
 // Handler handles HTTP requests for the integration service
 type Handler struct {
 	service *Service
+	ids     *idalloc.Allocator
+	state   *repository.EntityStateRepository // nil when no database is configured
+	deps    *repository.DependencyRepository  // nil when no database is configured
+
+	workspaces   workspaceRoles                        // nil when no database is configured, when only admins get access
+	integrations *repository.IntegrationRepository // Users' stored credentials; nil when no database is configured
+	jiraLinks    *repository.JiraSyncRepository    // nil when no database is configured
+	changeEvents *repository.ChangeEventRepository // nil when no database is configured
+	githubAPIURL string                            // GitHub API base URL; "" means api.github.com
+
+	workspacesRoot string // Workspace folders live here; requests may not name paths outside it
 }
 
 // NewHandler creates a new handler
 func NewHandler(service *Service) *Handler {
 	return &Handler{
 		service: service,
+		ids:     idalloc.New(nil, idalloc.FileSource{}),
+
+		workspacesRoot: DefaultWorkspacesRoot,
 	}
 }
 
+// SetWorkspacesRoot sets the folder workspace folders live in
+func (h *Handler) SetWorkspacesRoot(root string) {
+	h.workspacesRoot = root
+}
+
+// UseDatabase makes ID allocation also check the capability tables and
+// reserve numbers in Postgres, so IDs stay unique across service instances,
+// makes ID renames update the matching database rows, adds the
+// dependency tables to the /graph endpoints, and stores users' integration
+// credentials encrypted with the vault. Without a vault, credentials saved in
+// plain text before the vault existed can still be read but none can be saved;
+// the integration service encrypts those at startup once it has a vault.
+func (h *Handler) UseDatabase(db *sql.DB, v *vault.Vault) {
+	seq := repository.NewIDSequenceRepository(db)
+	h.ids = idalloc.New(seq, idalloc.FileSource{}, seq)
+	h.state = repository.NewEntityStateRepository(db)
+	h.deps = repository.NewDependencyRepository(db)
+	h.workspaces = repository.NewWorkspaceMembershipRepository(db)
+	h.integrations = repository.NewIntegrationRepository(db, v)
+	h.jiraLinks = repository.NewJiraSyncRepository(db)
+	h.changeEvents = repository.NewChangeEventRepository(db)
+}
+
 // HandleGetFile handles GET /figma/files/{fileKey}
 func (h *Handler) HandleGetFile(w http.ResponseWriter, r *http.Request) {
 	fileKey := r.PathValue("fileKey")
@@ -83,7 +133,6 @@ func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
 type AnalyzeIntegrationRequest struct {
 	ProviderURL  string `json:"provider_url"`
 	ProviderName string `json:"provider_name"`
-	AnthropicKey string `json:"anthropic_key"`
 }
 
 // HandleAnalyzeIntegration handles POST /analyze-integration
@@ -104,13 +153,13 @@ func (h *Handler) HandleAnalyzeIntegration(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if req.AnthropicKey == "" {
-		http.Error(w, "anthropic_key is required", http.StatusBadRequest)
+	apiKey, ok := h.requireAnthropicKey(w, r)
+	if !ok {
 		return
 	}
 
 	// Create Anthropic client with the provided API key
-	client := NewAnthropicClient(req.AnthropicKey)
+	client := NewAnthropicClient(apiKey)
 
 	// Analyze the integration
 	analysis, err := client.AnalyzeIntegrationAPI(r.Context(), req.ProviderURL, req.ProviderName)
@@ -125,8 +174,9 @@ func (h *Handler) HandleAnalyzeIntegration(w http.ResponseWriter, r *http.Reques
 
 // TestConnectionRequest represents the request body for testing a connection
 type TestConnectionRequest struct {
-	BaseURL     string            `json:"base_url"`
-	Credentials map[string]string `json:"credentials"`
+	BaseURL         string            `json:"base_url"`
+	Credentials     map[string]string `json:"credentials,omitempty"`      // Credentials being entered, before they are saved
+	IntegrationName string            `json:"integration_name,omitempty"` // Otherwise, the stored integration's credentials
 }
 
 // TestConnectionResponse represents the response from a connection test
@@ -146,92 +196,80 @@ func (h *Handler) HandleTestConnection(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.BaseURL == "" {
+	if req.BaseURL == "" && req.IntegrationName == "" {
 		http.Error(w, "base_url is required", http.StatusBadRequest)
 		return
 	}
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+	// Saved credentials are tested by integration name; fields sent empty
+	// keep their stored value, like when they are saved
+	if req.IntegrationName != "" {
+		stored, err := h.storedCredentials(r, req.IntegrationName)
+		if err != nil && !errors.Is(err, ErrNoCredentials) {
+			writeCredentialError(w, "HandleTestConnection", err)
+			return
+		}
+		if req.Credentials == nil {
+			req.Credentials = map[string]string{}
+		}
+		for key, value := range stored {
+			if _, entered := req.Credentials[key]; !entered || req.Credentials[key] == "" {
+				req.Credentials[key] = value
+			}
+		}
 	}
 
-	// Create request to test the URL
-	testReq, err := http.NewRequestWithContext(r.Context(), "GET", req.BaseURL, nil)
-	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(TestConnectionResponse{
-			Success:      false,
-			ErrorMessage: fmt.Sprintf("Invalid URL: %v", err),
-		})
-		return
+	// Registered providers test their own credentials, by integration name or
+	// by a URL they claim
+	provider, ok := LookupProvider(req.IntegrationName)
+	if !ok && req.BaseURL != "" {
+		provider, ok = providerForURL(req.BaseURL)
 	}
-
-	// Add credentials as headers if provided
-	for key, value := range req.Credentials {
-		// Map common credential field names to headers
-		switch strings.ToLower(key) {
-		case "api_key", "apikey", "api-key":
-			testReq.Header.Set("Authorization", "Bearer "+value)
-			testReq.Header.Set("X-API-Key", value)
-		case "bearer_token", "token", "access_token":
-			testReq.Header.Set("Authorization", "Bearer "+value)
-		case "username":
-			// Will be combined with password for Basic Auth
-			if pwd, ok := req.Credentials["password"]; ok {
-				auth := base64.StdEncoding.EncodeToString([]byte(value + ":" + pwd))
-				testReq.Header.Set("Authorization", "Basic "+auth)
-			}
-		default:
-			// For custom headers, add them directly
-			if strings.HasPrefix(strings.ToLower(key), "header_") {
-				headerName := strings.TrimPrefix(key, "header_")
-				testReq.Header.Set(headerName, value)
+	if ok {
+		response, err := provider.TestConnection(r.Context(), req.BaseURL, req.Credentials)
+		if err == nil || !errors.Is(err, ErrNotSupported) {
+			if err != nil {
+				response = &TestConnectionResponse{ErrorMessage: err.Error()}
 			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(response)
+			return
 		}
 	}
 
-	// Set common headers
-	testReq.Header.Set("User-Agent", "IntentR-Integration-Test/1.0")
-	testReq.Header.Set("Accept", "application/json")
-
-	// Make the request
-	resp, err := client.Do(testReq)
-	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(TestConnectionResponse{
-			Success:      false,
-			ErrorMessage: fmt.Sprintf("Connection failed: %v", err),
-		})
+	if req.BaseURL == "" {
+		http.Error(w, "base_url is required", http.StatusBadRequest)
 		return
 	}
-	defer resp.Body.Close()
 
-	// Read response body (limited to 10KB for safety)
-	bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 10240))
-	bodyStr := string(bodyBytes)
-
-	// Extract response headers
-	respHeaders := make(map[string]string)
-	for key, values := range resp.Header {
-		if len(values) > 0 {
-			respHeaders[key] = values[0]
+	// Add credentials as headers if provided
+	response := probeConnection(r.Context(), req.BaseURL, func(testReq *http.Request) {
+		for key, value := range req.Credentials {
+			// Map common credential field names to headers
+			switch strings.ToLower(key) {
+			case "api_key", "apikey", "api-key":
+				testReq.Header.Set("Authorization", "Bearer "+value)
+				testReq.Header.Set("X-API-Key", value)
+			case "bearer_token", "token", "access_token", "personal_access_token", "pat":
+				testReq.Header.Set("Authorization", "Bearer "+value)
+			case "private_token", "private-token":
+				// GitLab
+				testReq.Header.Set("PRIVATE-TOKEN", value)
+			case "username":
+				// Will be combined with password for Basic Auth
+				if pwd, ok := req.Credentials["password"]; ok {
+					auth := base64.StdEncoding.EncodeToString([]byte(value + ":" + pwd))
+					testReq.Header.Set("Authorization", "Basic "+auth)
+				}
+			default:
+				// For custom headers, add them directly
+				if strings.HasPrefix(strings.ToLower(key), "header_") {
+					headerName := strings.TrimPrefix(key, "header_")
+					testReq.Header.Set(headerName, value)
+				}
+			}
 		}
-	}
-
-	// Determine if successful (2xx status codes)
-	success := resp.StatusCode >= 200 && resp.StatusCode < 300
-
-	response := TestConnectionResponse{
-		Success:         success,
-		StatusCode:      resp.StatusCode,
-		ResponseHeaders: respHeaders,
-		ResponseBody:    bodyStr,
-	}
-
-	if !success {
-		response.ErrorMessage = fmt.Sprintf("HTTP %d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))
-	}
+	})
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
@@ -244,7 +282,6 @@ type AnalyzeConnectionErrorRequest struct {
 	ConnectionResult map[string]interface{} `json:"connection_result"`
 	CurrentFields    []map[string]interface{} `json:"current_fields"`
 	CurrentValues    map[string]string      `json:"current_values"`
-	AnthropicKey     string                 `json:"anthropic_key"`
 }
 
 // AnalyzeConnectionErrorResponse represents the AI analysis of connection errors
@@ -268,13 +305,13 @@ func (h *Handler) HandleAnalyzeConnectionError(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	if req.AnthropicKey == "" {
-		http.Error(w, "anthropic_key is required", http.StatusBadRequest)
+	apiKey, ok := h.requireAnthropicKey(w, r)
+	if !ok {
 		return
 	}
 
 	// Create Anthropic client
-	client := NewAnthropicClient(req.AnthropicKey)
+	client := NewAnthropicClient(apiKey)
 
 	// Build the prompt for analyzing the connection error
 	currentFieldsJSON, _ := json.MarshalIndent(req.CurrentFields, "", "  ")
@@ -373,10 +410,11 @@ func (h *Handler) HandleFetchResources(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(req.Credentials) == 0 {
-		http.Error(w, "credentials are required", http.StatusBadRequest)
+	credentials, ok := h.requireCredentials(w, r, req.IntegrationName)
+	if !ok {
 		return
 	}
+	req.Credentials = credentials
 
 	// Fetch resources from the integration
 	response, err := FetchResources(r.Context(), req)
@@ -395,7 +433,6 @@ type SuggestResourcesRequest struct {
 	WorkspaceDesc   string                  `json:"workspace_description"`
 	IntegrationName string                  `json:"integration_name"`
 	Resources       []IntegrationResource   `json:"resources"`
-	AnthropicKey    string                  `json:"anthropic_key"`
 }
 
 // SuggestResourcesResponse represents AI suggestions for which resources to integrate
@@ -425,8 +462,8 @@ func (h *Handler) HandleSuggestResources(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if req.AnthropicKey == "" {
-		http.Error(w, "anthropic_key is required", http.StatusBadRequest)
+	apiKey, ok := h.requireAnthropicKey(w, r)
+	if !ok {
 		return
 	}
 
@@ -436,7 +473,7 @@ func (h *Handler) HandleSuggestResources(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Create Anthropic client
-	client := NewAnthropicClient(req.AnthropicKey)
+	client := NewAnthropicClient(apiKey)
 
 	// Generate AI suggestions
 	suggestions, err := client.SuggestResources(r.Context(), req)
@@ -467,10 +504,11 @@ func (h *Handler) HandleFetchFiles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(req.Credentials) == 0 {
-		http.Error(w, "credentials are required", http.StatusBadRequest)
+	credentials, ok := h.requireCredentials(w, r, req.IntegrationName)
+	if !ok {
 		return
 	}
+	req.Credentials = credentials
 
 	// Fetch files from the integration resource
 	response, err := FetchFiles(r.Context(), req)
@@ -483,6 +521,38 @@ func (h *Handler) HandleFetchFiles(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// HandleCreateGitHubRepo handles POST /github/create-repo
+func (h *Handler) HandleCreateGitHubRepo(w http.ResponseWriter, r *http.Request) {
+	var req CreateGitHubRepoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	if req.IntegrationName == "" {
+		req.IntegrationName = "GitHub"
+	}
+	credentials, ok := h.requireCredentials(w, r, req.IntegrationName)
+	if !ok {
+		return
+	}
+	req.Credentials = credentials
+
+	response, err := CreateGitHubRepo(r.Context(), req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to create repository: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 // HandleFetchFileMeta handles POST /fetch-file-meta
 func (h *Handler) HandleFetchFileMeta(w http.ResponseWriter, r *http.Request) {
 	var req FetchFileMetaRequest
@@ -501,10 +571,11 @@ func (h *Handler) HandleFetchFileMeta(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(req.Credentials) == 0 {
-		http.Error(w, "credentials are required", http.StatusBadRequest)
+	credentials, ok := h.requireCredentials(w, r, req.IntegrationName)
+	if !ok {
 		return
 	}
+	req.Credentials = credentials
 
 	// Fetch file metadata from the integration
 	response, err := FetchFileMeta(r.Context(), req)
@@ -554,6 +625,9 @@ func (h *Handler) HandleListSpecifications(w http.ResponseWriter, r *http.Reques
 		}
 		workspacePath = filepath.Join(cwd, workspace, "specifications")
 	}
+	if !h.confinePath(w, r, workspacePath) {
+		return
+	}
 
 	fmt.Printf("[ListSpecifications] Looking for specifications in: %s\n", workspacePath)
 
@@ -626,10 +700,188 @@ func (h *Handler) HandleListSpecifications(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(response)
 }
 
+// LintSpecificationsRequest represents the request for linting a workspace's specifications
+type LintSpecificationsRequest struct {
+	WorkspacePath string `json:"workspacePath"`
+}
+
+// HandleLintSpecifications handles POST /specifications/lint
+// Rules and severities come from the lint entry of the workspace's .intentrworkspace customSettings
+func (h *Handler) HandleLintSpecifications(w http.ResponseWriter, r *http.Request) {
+	var req LintSpecificationsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.WorkspacePath == "" {
+		http.Error(w, "workspacePath is required", http.StatusBadRequest)
+		return
+	}
+
+	// If path contains "workspaces/", extract the relative part
+	workspacePath := req.WorkspacePath
+	if idx := strings.Index(workspacePath, "workspaces/"); idx != -1 {
+		workspacePath = workspacePath[idx:]
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get working directory: %v", err), http.StatusInternalServerError)
+		return
+	}
+	root := filepath.Join(cwd, workspacePath)
+	if !h.confinePath(w, r, root) {
+		return
+	}
+
+	cfg, err := lint.LoadConfig(root)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid lint configuration: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	issues, err := lint.Run(root, cfg)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to lint specifications: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if issues == nil {
+		issues = []lint.Issue{}
+	}
+
+	counts := map[lint.Severity]int{}
+	for _, issue := range issues {
+		counts[issue.Severity]++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"passed":   !lint.HasErrors(issues),
+		"issues":   issues,
+		"errors":   counts[lint.SeverityError],
+		"warnings": counts[lint.SeverityWarning],
+		"infos":    counts[lint.SeverityInfo],
+		"rules":    lint.Rules,
+	})
+}
+
+// RenameIDRequest represents the request for renaming a CAP/ENB/FR/NFR/TS ID
+type RenameIDRequest struct {
+	WorkspacePath string `json:"workspacePath"`
+	OldID         string `json:"oldId"`
+	NewID         string `json:"newId"`
+	DryRun        bool   `json:"dryRun"`
+}
+
+// HandleRenameID handles POST /specifications/rename-id
+// It renames the spec file, rewrites every reference in the workspace and,
+// when a database is configured, updates the capability/enabler/state rows.
+// With dryRun set it only reports the files and rows that would change.
+func (h *Handler) HandleRenameID(w http.ResponseWriter, r *http.Request) {
+	var req RenameIDRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.WorkspacePath == "" {
+		http.Error(w, "workspacePath is required", http.StatusBadRequest)
+		return
+	}
+	if req.OldID == "" || req.NewID == "" {
+		http.Error(w, "oldId and newId are required", http.StatusBadRequest)
+		return
+	}
+	if err := rename.Validate(req.OldID, req.NewID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// If path contains "workspaces/", extract the relative part
+	workspacePath := req.WorkspacePath
+	if idx := strings.Index(workspacePath, "workspaces/"); idx != -1 {
+		workspacePath = workspacePath[idx:]
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get working directory: %v", err), http.StatusInternalServerError)
+		return
+	}
+	root := filepath.Join(cwd, workspacePath)
+	if !h.confinePath(w, r, root) {
+		return
+	}
+
+	plan, err := rename.PlanRename(root, req.OldID, req.NewID)
+	if err != nil {
+		writeRenameError(w, "failed to plan rename", err)
+		return
+	}
+
+	// Rows are always previewed first so a conflict in the database is
+	// reported before any file is touched
+	var userID *int
+	if claims, ok := r.Context().Value("claims").(*auth.Claims); ok {
+		userID = &claims.UserID
+	}
+	workspaceID := workspaceIDForPath(root)
+	if h.state != nil && workspaceID == "" {
+		http.Error(w, "workspacePath is not inside a workspace", http.StatusBadRequest)
+		return
+	}
+	rows := []models.IDRenameRow{}
+	if h.state != nil {
+		if rows, err = h.state.RenameEntityID(workspaceID, req.OldID, req.NewID, userID, true); err != nil {
+			writeRenameError(w, "failed to check database rows", err)
+			return
+		}
+	}
+
+	// Files are renamed first and put back if the database rename fails
+	if !req.DryRun {
+		if err := plan.Apply(root); err != nil {
+			writeRenameError(w, "failed to rename files", err)
+			return
+		}
+		if h.state != nil {
+			if rows, err = h.state.RenameEntityID(workspaceID, req.OldID, req.NewID, userID, false); err != nil {
+				if revertErr := plan.Revert(root); revertErr != nil {
+					log.Printf("[HandleRenameID] FAILED to restore files after database error: %v", revertErr)
+				}
+				writeRenameError(w, "failed to rename database rows", err)
+				return
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"dryRun":   req.DryRun,
+		"oldId":    req.OldID,
+		"newId":    req.NewID,
+		"files":    plan.Files,
+		"rows":     rows,
+		"database": h.state != nil,
+	})
+}
+
+// writeRenameError reports an ID conflict as 409 and anything else as 500
+func writeRenameError(w http.ResponseWriter, msg string, err error) {
+	var conflict *models.IDConflictError
+	if errors.As(err, &conflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	http.Error(w, fmt.Sprintf("%s: %v", msg, err), http.StatusInternalServerError)
+}
+
 // AnalyzeSpecificationsRequest represents the request for analyzing specifications
 type AnalyzeSpecificationsRequest struct {
-	Files        []SpecificationFile `json:"files"`
-	AnthropicKey string              `json:"anthropic_key"`
+	Files []SpecificationFile `json:"files"`
 }
 
 // CapabilitySpec represents a parsed capability
@@ -677,11 +929,7 @@ func (h *Handler) HandleAnalyzeSpecifications(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	// Note: AnthropicKey is optional now - we parse relationships directly from markdown
-	// if req.AnthropicKey == "" {
-	// 	http.Error(w, "anthropic_key is required", http.StatusBadRequest)
-	// 	return
-	// }
+	// Note: the Anthropic key is optional now - we parse relationships directly from markdown
 
 	// Parse files to extract capabilities and enablers with their relationships
 	var capabilities []CapabilitySpec
@@ -744,8 +992,13 @@ func (h *Handler) HandleAnalyzeSpecifications(w http.ResponseWriter, r *http.Req
 	}
 
 	// Fall back to original full AI analysis if no CAP/ENB files found
+	apiKey, ok := h.requireAnthropicKey(w, r)
+	if !ok {
+		return
+	}
+
 	// Create Anthropic client
-	client := NewAnthropicClient(req.AnthropicKey)
+	client := NewAnthropicClient(apiKey)
 
 	// Prepare the prompt for Claude
 	var filesContent strings.Builder
@@ -894,17 +1147,24 @@ Return ONLY the JSON object, no additional text.`)
 }
 
 // GenerateDiagramRequest represents the request for generating diagrams
+// capability-map, enabler-dependencies and state-model are built locally
+// from the specs; any other diagram_type is generated by the LLM
 type GenerateDiagramRequest struct {
-	Files        []SpecificationFile `json:"files"`
-	AnthropicKey string              `json:"anthropic_key"`
-	DiagramType  string              `json:"diagram_type"`
-	Prompt       string              `json:"prompt"`
+	Files         []SpecificationFile `json:"files"`
+	WorkspacePath string              `json:"workspacePath,omitempty"` // Read specs from the workspace instead of files
+	WorkspaceID   string              `json:"workspaceId,omitempty"`   // Database relationships and stages; the user must be able to view it
+	DiagramType   string              `json:"diagram_type"`
+	Format        string              `json:"format,omitempty"`   // mermaid (default), plantuml or dot
+	Beautify      bool                `json:"beautify,omitempty"` // Pass a locally built diagram through the LLM
+	Prompt        string              `json:"prompt"`
 }
 
 // GenerateDiagramResponse represents the generated diagram
 type GenerateDiagramResponse struct {
 	Diagram     string `json:"diagram"`
 	DiagramType string `json:"diagram_type"`
+	Format      string `json:"format,omitempty"`
+	Generator   string `json:"generator,omitempty"` // local, or llm when the LLM produced or beautified it
 }
 
 // HandleGenerateDiagram handles POST /specifications/generate-diagram
@@ -921,13 +1181,18 @@ func (h *Handler) HandleGenerateDiagram(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if kind, ok := diagram.ParseKind(req.DiagramType); ok {
+		h.generateLocalDiagram(w, r, req, kind)
+		return
+	}
+
 	if len(req.Files) == 0 {
 		http.Error(w, "files are required", http.StatusBadRequest)
 		return
 	}
 
-	if req.AnthropicKey == "" {
-		http.Error(w, "anthropic_key is required", http.StatusBadRequest)
+	apiKey, ok := h.requireAnthropicKey(w, r)
+	if !ok {
 		return
 	}
 
@@ -937,7 +1202,7 @@ func (h *Handler) HandleGenerateDiagram(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Create Anthropic client
-	client := NewAnthropicClient(req.AnthropicKey)
+	client := NewAnthropicClient(apiKey)
 
 	// Prepare the prompt for Claude
 	var filesContent strings.Builder
@@ -959,7 +1224,20 @@ func (h *Handler) HandleGenerateDiagram(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Clean up the response - extract just the Mermaid code
+	result := GenerateDiagramResponse{
+		Diagram:     extractDiagramCode(response),
+		DiagramType: req.DiagramType,
+		Format:      string(diagram.FormatMermaid),
+		Generator:   "llm",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// extractDiagramCode returns the contents of the first code block in an LLM
+// response, preferring a ```mermaid block, or the whole response if it has none
+func extractDiagramCode(response string) string {
 	diagram := response
 
 	// Try to extract Mermaid code block if present
@@ -988,20 +1266,124 @@ func (h *Handler) HandleGenerateDiagram(w http.ResponseWriter, r *http.Request)
 			}
 		}
 	}
+	return diagram
+}
 
-	result := GenerateDiagramResponse{
-		Diagram:     diagram,
-		DiagramType: req.DiagramType,
+// generateLocalDiagram builds a diagram from the parsed specs and, when a
+// database is configured, the dependency tables and entity stages. The LLM
+// is only involved when the caller asks to beautify the result.
+func (h *Handler) generateLocalDiagram(w http.ResponseWriter, r *http.Request, req GenerateDiagramRequest, kind diagram.Kind) {
+	format, ok := diagram.ParseFormat(req.Format)
+	if !ok {
+		http.Error(w, "format must be mermaid, plantuml or dot", http.StatusBadRequest)
+		return
+	}
+
+	g := graph.New()
+	switch {
+	case req.WorkspacePath != "":
+		// If path contains "workspaces/", extract the relative part
+		workspacePath := req.WorkspacePath
+		if idx := strings.Index(workspacePath, "workspaces/"); idx != -1 {
+			workspacePath = workspacePath[idx:]
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to get working directory: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if !h.confinePath(w, r, filepath.Join(cwd, workspacePath)) {
+			return
+		}
+		pathWorkspaceID := workspaceIDForPath(filepath.Join(cwd, workspacePath))
+		if req.WorkspaceID == "" {
+			req.WorkspaceID = pathWorkspaceID
+		} else if req.WorkspaceID != pathWorkspaceID {
+			http.Error(w, "workspaceId does not match the workspace at workspacePath", http.StatusBadRequest)
+			return
+		}
+		if err := graph.LoadSpecs(g, filepath.Join(cwd, workspacePath)); err != nil {
+			http.Error(w, fmt.Sprintf("failed to read specifications: %v", err), http.StatusInternalServerError)
+			return
+		}
+	case len(req.Files) > 0:
+		for _, file := range req.Files {
+			graph.AddSpec(g, file.Filename, filepath.Base(file.Filename), file.Content)
+		}
+	case kind != diagram.KindStateModel:
+		http.Error(w, "files or workspacePath is required", http.StatusBadRequest)
+		return
+	}
+
+	// Relationships and stages come from the database only for a workspace
+	// the user may view; without one the diagram shows the specs alone
+	if req.WorkspaceID != "" && (h.deps != nil || h.state != nil) {
+		if _, ok := h.requireWorkspaceID(w, r, req.WorkspaceID, models.WorkspaceRoleViewer); !ok {
+			return
+		}
+	}
+
+	if h.deps != nil && req.WorkspaceID != "" && kind != diagram.KindStateModel {
+		edges, err := h.deps.ListEdges(req.WorkspaceID)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to load dependencies: %v", err), http.StatusInternalServerError)
+			return
+		}
+		graph.AddDependencies(g, edges)
+	}
+
+	var stages map[string][]string
+	if h.state != nil && req.WorkspaceID != "" && kind == diagram.KindStateModel {
+		state, err := h.state.GetAllStateByWorkspace(req.WorkspaceID)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to load entity state: %v", err), http.StatusInternalServerError)
+			return
+		}
+		stages = make(map[string][]string)
+		for _, c := range state.Capabilities {
+			stages[c.WorkflowStage] = append(stages[c.WorkflowStage], c.CapabilityID)
+		}
+		for _, e := range state.Enablers {
+			stages[e.WorkflowStage] = append(stages[e.WorkflowStage], e.EnablerID)
+		}
+	}
+
+	out, err := diagram.Generate(g, kind, format, stages)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to generate diagram: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	generator := "local"
+	if req.Beautify {
+		apiKey, ok := h.requireAnthropicKey(w, r)
+		if !ok {
+			return
+		}
+		prompt := fmt.Sprintf("Here is a %s diagram in %s syntax:\n\n```\n%s```\n\n"+
+			"Improve its layout and readability. Keep every node and relationship, do not invent new ones, "+
+			"and keep the same syntax. Return ONLY the diagram code.\n%s", kind, format, out, req.Prompt)
+		response, err := NewAnthropicClient(apiKey).SendMessage(r.Context(), prompt)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to beautify diagram: %v", err), http.StatusInternalServerError)
+			return
+		}
+		out = extractDiagramCode(response)
+		generator = "llm"
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
+	json.NewEncoder(w).Encode(GenerateDiagramResponse{
+		Diagram:     out,
+		DiagramType: string(kind),
+		Format:      string(format),
+		Generator:   generator,
+	})
 }
 
 // AnalyzeApplicationRequest represents the request for analyzing an application
 type AnalyzeApplicationRequest struct {
 	WorkspacePath string `json:"workspacePath"`
-	APIKey        string `json:"apiKey"`
 	AIPreset      int    `json:"aiPreset"`
 	Prompt        string `json:"prompt"`
 }
@@ -1032,9 +1414,10 @@ func (h *Handler) HandleAnalyzeApplication(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if req.APIKey == "" {
+	apiKey, err := h.anthropicKey(r)
+	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(AnalyzeApplicationResponse{Error: "apiKey is required"})
+		json.NewEncoder(w).Encode(AnalyzeApplicationResponse{Error: err.Error()})
 		return
 	}
 
@@ -1125,7 +1508,7 @@ Create all necessary markdown files for capabilities, storyboards, dependencies,
 	fullPrompt := fmt.Sprintf("%s%s\n\n%s", req.Prompt, formatInstructions, specsContent.String())
 
 	// Create Anthropic client and send request
-	client := NewAnthropicClient(req.APIKey)
+	client := NewAnthropicClient(apiKey)
 	var response string
 	if len(images) > 0 {
 		// Use multimodal API with images
@@ -1411,11 +1794,11 @@ type ListFoldersResponse struct {
 func (h *Handler) HandleListFolders(w http.ResponseWriter, r *http.Request) {
 	path := r.URL.Query().Get("path")
 	if path == "" {
-		path = "workspaces"
+		path = h.workspacesRoot
 	}
 
 	// Ensure path is within workspaces directory for security
-	if !strings.HasPrefix(path, "workspaces") {
+	if h.withinWorkspacesRoot(path) != nil {
 		http.Error(w, "path must be within workspaces directory", http.StatusBadRequest)
 		return
 	}
@@ -1449,7 +1832,7 @@ func (h *Handler) HandleListFolders(w http.ResponseWriter, r *http.Request) {
 
 	// Get parent path
 	parentPath := filepath.Dir(path)
-	if parentPath == "." || !strings.HasPrefix(parentPath, "workspaces") {
+	if parentPath == "." || h.withinWorkspacesRoot(parentPath) != nil {
 		parentPath = ""
 	}
 
@@ -1482,15 +1865,15 @@ func (h *Handler) HandleCreateFolder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Ensure path is within workspaces directory for security
-	if !strings.HasPrefix(req.Path, "workspaces") {
-		http.Error(w, "path must be within workspaces directory", http.StatusBadRequest)
-		return
-	}
-
 	// Create the full folder path
 	fullPath := filepath.Join(req.Path, req.Name)
 
+	// The parent folder may only be viewable, such as the workspaces root;
+	// the new folder needs a contributor
+	if !h.requirePathRole(w, r, fullPath, models.WorkspaceRoleContributor) {
+		return
+	}
+
 	// Create the folder
 	if err := os.MkdirAll(fullPath, 0755); err != nil {
 		http.Error(w, fmt.Sprintf("failed to create folder: %v", err), http.StatusInternalServerError)
@@ -1543,7 +1926,7 @@ func (h *Handler) HandleEnsureWorkspaceStructure(w http.ResponseWriter, r *http.
 	}
 
 	// Ensure path is within workspaces directory for security
-	if !strings.HasPrefix(req.Path, "workspaces") {
+	if h.withinWorkspacesRoot(req.Path) != nil {
 		http.Error(w, "path must be within workspaces directory", http.StatusBadRequest)
 		return
 	}
@@ -1774,8 +2157,12 @@ func (h *Handler) HandleMoveToDeleted(w http.ResponseWriter, r *http.Request) {
 		sourcePath = sourcePath[idx:]
 	}
 
-	// Build full source path
+	// Build full source path. Archiving removes the workspace for all of
+	// its members, so only its owners may.
 	fullSourcePath := filepath.Join(cwd, sourcePath)
+	if !h.requirePathRole(w, r, fullSourcePath, models.WorkspaceRoleOwner) {
+		return
+	}
 
 	// Check if source exists
 	if _, err := os.Stat(fullSourcePath); os.IsNotExist(err) {
@@ -1866,6 +2253,9 @@ func (h *Handler) HandleInitWorkspaceFiles(w http.ResponseWriter, r *http.Reques
 
 	// Build workspace path
 	workspacePath := filepath.Join(cwd, req.WorkspacePath)
+	if !h.confinePath(w, r, workspacePath) {
+		return
+	}
 
 	// Ensure workspace directory exists
 	if err := os.MkdirAll(workspacePath, 0755); err != nil {
@@ -1950,6 +2340,9 @@ func (h *Handler) HandleSetActiveAIPrinciples(w http.ResponseWriter, r *http.Req
 	aiPrinciplesPath := filepath.Join(cwd, "AI_Principles")
 	workspacePath := filepath.Join(cwd, req.WorkspacePath)
 	workspaceCodeRulesPath := filepath.Join(workspacePath, "CODE_RULES")
+	if !h.confinePath(w, r, workspaceCodeRulesPath) {
+		return
+	}
 
 	presetFileName := fmt.Sprintf("AI-Policy-Preset%d.md", req.PresetLevel)
 	srcPath := filepath.Join(aiPrinciplesPath, presetFileName)
@@ -2039,6 +2432,9 @@ func (h *Handler) HandleCapabilityFiles(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	specsPath := filepath.Join(cwd, workspacePath, "definition")
+	if !h.confinePath(w, r, specsPath) {
+		return
+	}
 
 	// Check if definition folder exists
 	if _, err := os.Stat(specsPath); os.IsNotExist(err) {
@@ -2053,8 +2449,6 @@ func (h *Handler) HandleCapabilityFiles(w http.ResponseWriter, r *http.Request)
 	// - Files starting with: capability*, CAP*, capabilities*
 	// - Files containing: -capability (for numeric ID format like 112001-capability.md)
 	var capabilities []FileCapability
-	prefixPatterns := []string{"capability", "CAP", "capabilities"}
-	containsPatterns := []string{"-capability", "-CAPABILITY"}
 
 	err = filepath.Walk(specsPath, func(path string, info os.FileInfo, walkErr error) error {
 		if walkErr != nil {
@@ -2067,33 +2461,7 @@ func (h *Handler) HandleCapabilityFiles(w http.ResponseWriter, r *http.Request)
 
 		// Check if file matches any pattern (case-insensitive)
 		filename := info.Name()
-		filenameUpper := strings.ToUpper(filename)
-		matched := false
-
-		// Check prefix patterns (starts with)
-		for _, pattern := range prefixPatterns {
-			if strings.HasPrefix(filenameUpper, strings.ToUpper(pattern)) {
-				matched = true
-				break
-			}
-		}
-
-		// Check contains patterns (for numeric ID format like 112001-capability.md)
-		if !matched {
-			for _, pattern := range containsPatterns {
-				if strings.Contains(filenameUpper, strings.ToUpper(pattern)) {
-					matched = true
-					break
-				}
-			}
-		}
-
-		if !matched {
-			return nil
-		}
-
-		// Only process markdown files
-		if !strings.HasSuffix(strings.ToLower(filename), ".md") {
+		if !spec.IsCapabilityFile(filename) {
 			return nil
 		}
 
@@ -2233,6 +2601,9 @@ func (h *Handler) HandleEnablerFiles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	specsPath := filepath.Join(cwd, workspacePath, "definition")
+	if !h.confinePath(w, r, specsPath) {
+		return
+	}
 
 	// Check if definition folder exists
 	if _, err := os.Stat(specsPath); os.IsNotExist(err) {
@@ -2247,8 +2618,6 @@ func (h *Handler) HandleEnablerFiles(w http.ResponseWriter, r *http.Request) {
 	// - Files starting with: ENB-*, enabler*
 	// - Files containing: -enabler (for numeric ID format like 112106-enabler.md)
 	var enablers []FileEnabler
-	prefixPatterns := []string{"ENB-", "enabler"}
-	containsPatterns := []string{"-enabler", "-ENABLER"}
 
 	err = filepath.Walk(specsPath, func(path string, info os.FileInfo, walkErr error) error {
 		if walkErr != nil {
@@ -2261,33 +2630,7 @@ func (h *Handler) HandleEnablerFiles(w http.ResponseWriter, r *http.Request) {
 
 		// Check if file matches any pattern (case-insensitive)
 		filename := info.Name()
-		filenameUpper := strings.ToUpper(filename)
-		matched := false
-
-		// Check prefix patterns (starts with)
-		for _, pattern := range prefixPatterns {
-			if strings.HasPrefix(filenameUpper, strings.ToUpper(pattern)) {
-				matched = true
-				break
-			}
-		}
-
-		// Check contains patterns (for numeric ID format like 112106-enabler.md)
-		if !matched {
-			for _, pattern := range containsPatterns {
-				if strings.Contains(filenameUpper, strings.ToUpper(pattern)) {
-					matched = true
-					break
-				}
-			}
-		}
-
-		if !matched {
-			return nil
-		}
-
-		// Only process markdown files
-		if !strings.HasSuffix(strings.ToLower(filename), ".md") {
+		if !spec.IsEnablerFile(filename) {
 			return nil
 		}
 
@@ -2352,6 +2695,9 @@ func (h *Handler) HandleTestScenarioFiles(w http.ResponseWriter, r *http.Request
 		return
 	}
 	testPath := filepath.Join(cwd, workspacePath, "test")
+	if !h.confinePath(w, r, testPath) {
+		return
+	}
 
 	// Check if test folder exists
 	if _, err := os.Stat(testPath); os.IsNotExist(err) {
@@ -2434,72 +2780,31 @@ func (h *Handler) HandleTestScenarioFiles(w http.ResponseWriter, r *http.Request
 
 // parseMarkdownTestScenario parses a markdown file to extract test scenario information
 func parseMarkdownTestScenario(filename, path, content string) FileTestScenario {
+	ts := spec.ParseTestScenario(content)
 	scenario := FileTestScenario{
 		Filename: filename,
 		Path:     path,
+		Name:     ts.Name,
+		Status:   ts.Status,
+		Priority: ts.Priority,
 		Fields:   make(map[string]string),
 	}
 
-	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-
-		// Extract title from first # heading
-		if strings.HasPrefix(trimmedLine, "# ") && scenario.Name == "" {
-			scenario.Name = strings.TrimPrefix(trimmedLine, "# ")
-			continue
-		}
-
-		// Parse ID field
-		if strings.Contains(trimmedLine, "**ID**:") || strings.Contains(trimmedLine, "**Scenario ID**:") {
-			idPattern := regexp.MustCompile(`TS-\d+`)
-			if match := idPattern.FindString(trimmedLine); match != "" {
-				scenario.ScenarioID = match
-				scenario.Fields["ID"] = match
-			}
-			continue
-		}
-
-		// Parse Enabler ID field - extract full value after colon (supports both ENB-123456 and ENB-TEXT-NAME-123 formats)
-		if strings.Contains(trimmedLine, "**Enabler ID**:") || strings.Contains(trimmedLine, "**Enabler**:") {
-			enablerId := trimmedLine
-			enablerId = strings.TrimPrefix(enablerId, "- ")
-			if idx := strings.Index(enablerId, "**Enabler ID**:"); idx != -1 {
-				enablerId = enablerId[idx+len("**Enabler ID**:"):]
-			} else if idx := strings.Index(enablerId, "**Enabler**:"); idx != -1 {
-				enablerId = enablerId[idx+len("**Enabler**:"):]
-			}
-			enablerId = strings.TrimSpace(enablerId)
-			if enablerId != "" {
-				scenario.EnablerID = enablerId
-				scenario.Fields["EnablerID"] = enablerId
-			}
-			continue
-		}
-
-		// Parse Status field
-		if strings.Contains(trimmedLine, "**Status**:") {
-			status := trimmedLine
-			status = strings.TrimPrefix(status, "- ")
-			if idx := strings.Index(status, "**Status**:"); idx != -1 {
-				status = status[idx+len("**Status**:"):]
-			}
-			scenario.Status = strings.TrimSpace(status)
-			scenario.Fields["Status"] = scenario.Status
-			continue
-		}
-
-		// Parse Priority field
-		if strings.Contains(trimmedLine, "**Priority**:") {
-			priority := trimmedLine
-			priority = strings.TrimPrefix(priority, "- ")
-			if idx := strings.Index(priority, "**Priority**:"); idx != -1 {
-				priority = priority[idx+len("**Priority**:"):]
-			}
-			scenario.Priority = strings.TrimSpace(priority)
-			scenario.Fields["Priority"] = scenario.Priority
-			continue
-		}
+	idPattern := regexp.MustCompile(`TS-\d+`)
+	if match := idPattern.FindString(ts.ID); match != "" {
+		scenario.ScenarioID = match
+		scenario.Fields["ID"] = match
+	}
+	// Enabler ID supports both ENB-123456 and ENB-TEXT-NAME-123 formats
+	if ts.EnablerID != "" {
+		scenario.EnablerID = ts.EnablerID
+		scenario.Fields["EnablerID"] = ts.EnablerID
+	}
+	if _, ok := ts.Doc.Field("Status"); ok {
+		scenario.Fields["Status"] = ts.Status
+	}
+	if _, ok := ts.Doc.Field("Priority"); ok {
+		scenario.Fields["Priority"] = ts.Priority
 	}
 
 	// If no ID found, generate from filename
@@ -2524,321 +2829,49 @@ func parseMarkdownTestScenario(filename, path, content string) FileTestScenario
 
 // parseMarkdownEnabler parses a markdown file to extract enabler information
 func parseMarkdownEnabler(filename, path, content string) FileEnabler {
+	e := spec.ParseEnabler(content)
 	enabler := FileEnabler{
-		Filename: filename,
-		Path:     path,
-		Fields:   make(map[string]string),
-	}
-
-	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-
-		// Extract title from first # heading
-		if strings.HasPrefix(trimmedLine, "# ") && enabler.Name == "" {
-			enabler.Name = strings.TrimPrefix(trimmedLine, "# ")
-			continue
-		}
-
-		// Parse metadata fields
-		// Handle various formats: **Field:** value, - **Field**: value, Field: value
-		if strings.HasPrefix(trimmedLine, "**ID**:") || strings.HasPrefix(trimmedLine, "- **ID**:") ||
-			strings.HasPrefix(trimmedLine, "**ID:**") || strings.HasPrefix(trimmedLine, "- **ID:**") {
-			id := trimmedLine
-			id = strings.TrimPrefix(id, "- ")
-			id = strings.TrimPrefix(id, "**ID**:")
-			id = strings.TrimPrefix(id, "**ID:**")
-			enabler.EnablerID = strings.TrimSpace(id)
-			enabler.Fields["ID"] = enabler.EnablerID
-			continue
-		}
-
-		if strings.HasPrefix(trimmedLine, "**Status**:") || strings.HasPrefix(trimmedLine, "- **Status**:") ||
-			strings.HasPrefix(trimmedLine, "**Status:**") || strings.HasPrefix(trimmedLine, "- **Status:**") {
-			status := trimmedLine
-			status = strings.TrimPrefix(status, "- ")
-			status = strings.TrimPrefix(status, "**Status**:")
-			status = strings.TrimPrefix(status, "**Status:**")
-			enabler.Status = strings.TrimSpace(status)
-			enabler.Fields["Status"] = enabler.Status
-			continue
-		}
-
-		if strings.HasPrefix(trimmedLine, "**Owner**:") || strings.HasPrefix(trimmedLine, "- **Owner**:") ||
-			strings.HasPrefix(trimmedLine, "**Owner:**") || strings.HasPrefix(trimmedLine, "- **Owner:**") {
-			owner := trimmedLine
-			owner = strings.TrimPrefix(owner, "- ")
-			owner = strings.TrimPrefix(owner, "**Owner**:")
-			owner = strings.TrimPrefix(owner, "**Owner:**")
-			enabler.Owner = strings.TrimSpace(owner)
-			enabler.Fields["Owner"] = enabler.Owner
-			continue
-		}
-
-		if strings.HasPrefix(trimmedLine, "**Priority**:") || strings.HasPrefix(trimmedLine, "- **Priority**:") ||
-			strings.HasPrefix(trimmedLine, "**Priority:**") || strings.HasPrefix(trimmedLine, "- **Priority:**") {
-			priority := trimmedLine
-			priority = strings.TrimPrefix(priority, "- ")
-			priority = strings.TrimPrefix(priority, "**Priority**:")
-			priority = strings.TrimPrefix(priority, "**Priority:**")
-			enabler.Priority = strings.TrimSpace(priority)
-			enabler.Fields["Priority"] = enabler.Priority
-			continue
-		}
-
-		// Parse **Capability ID**: CAP-XXXXXX format (preferred - the actual ID)
-		if strings.HasPrefix(trimmedLine, "**Capability ID**:") || strings.HasPrefix(trimmedLine, "- **Capability ID**:") ||
-			strings.HasPrefix(trimmedLine, "**Capability ID:**") || strings.HasPrefix(trimmedLine, "- **Capability ID:**") {
-			capId := trimmedLine
-			capId = strings.TrimPrefix(capId, "- ")
-			capId = strings.TrimPrefix(capId, "**Capability ID**:")
-			capId = strings.TrimPrefix(capId, "**Capability ID:**")
-			enabler.CapabilityID = strings.TrimSpace(capId)
-			enabler.Fields["Capability ID"] = enabler.CapabilityID
-			continue
-		}
-
-		// Parse **Capability**: format (name, only use for ID if Capability ID not already set)
-		if strings.HasPrefix(trimmedLine, "**Capability**:") || strings.HasPrefix(trimmedLine, "- **Capability**:") ||
-			strings.HasPrefix(trimmedLine, "**Capability:**") || strings.HasPrefix(trimmedLine, "- **Capability:**") {
-			cap := trimmedLine
-			cap = strings.TrimPrefix(cap, "- ")
-			cap = strings.TrimPrefix(cap, "**Capability**:")
-			cap = strings.TrimPrefix(cap, "**Capability:**")
-			cap = strings.TrimSpace(cap)
-			enabler.Fields["Capability"] = cap
-			// Only set CapabilityID from Capability name if not already set from Capability ID field
-			if enabler.CapabilityID == "" {
-				// Extract capability ID from format "Name (CAP-XXXXXX)"
-				if idx := strings.LastIndex(cap, "("); idx != -1 {
-					capId := strings.TrimSuffix(strings.TrimPrefix(cap[idx:], "("), ")")
-					enabler.CapabilityID = strings.TrimSpace(capId)
-				} else {
-					enabler.CapabilityID = cap
-				}
-			}
-			continue
-		}
-
-		// Parse Created By field
-		if strings.HasPrefix(trimmedLine, "**Created By**:") || strings.HasPrefix(trimmedLine, "- **Created By**:") ||
-			strings.HasPrefix(trimmedLine, "**Created By:**") || strings.HasPrefix(trimmedLine, "- **Created By:**") {
-			createdBy := trimmedLine
-			createdBy = strings.TrimPrefix(createdBy, "- ")
-			createdBy = strings.TrimPrefix(createdBy, "**Created By**:")
-			createdBy = strings.TrimPrefix(createdBy, "**Created By:**")
-			enabler.CreatedBy = strings.TrimSpace(createdBy)
-			enabler.Fields["Created By"] = enabler.CreatedBy
-			continue
-		}
-
-		// NOTE: INTENT State Model fields (lifecycle_state, workflow_stage, stage_status, approval_status)
-		// are NOT parsed from markdown. They are stored in the DATABASE only.
-	}
-
-	// Extract Description from ## Description section
-	descIdx := strings.Index(content, "## Description")
-	if descIdx != -1 {
-		afterDesc := content[descIdx+len("## Description"):]
-		nextSectionIdx := strings.Index(afterDesc, "\n## ")
-		if nextSectionIdx == -1 {
-			nextSectionIdx = len(afterDesc)
-		}
-		enabler.Description = strings.TrimSpace(afterDesc[:nextSectionIdx])
-		enabler.Fields["Description"] = enabler.Description
-	}
-
-	// Extract purpose from ## Purpose section
-	purposeIdx := strings.Index(content, "## Purpose")
-	if purposeIdx != -1 {
-		afterPurpose := content[purposeIdx+len("## Purpose"):]
-		nextSectionIdx := strings.Index(afterPurpose, "\n## ")
-		if nextSectionIdx == -1 {
-			nextSectionIdx = len(afterPurpose)
-		}
-		enabler.Purpose = strings.TrimSpace(afterPurpose[:nextSectionIdx])
-		enabler.Fields["Purpose"] = enabler.Purpose
-	}
-
-	// Extract Technical Specifications from ## Technical Specifications section
-	techSpecIdx := strings.Index(content, "## Technical Specifications")
-	if techSpecIdx != -1 {
-		afterTechSpec := content[techSpecIdx+len("## Technical Specifications"):]
-		nextSectionIdx := strings.Index(afterTechSpec, "\n## ")
-		if nextSectionIdx == -1 {
-			nextSectionIdx = len(afterTechSpec)
-		}
-		enabler.TechnicalSpecs = strings.TrimSpace(afterTechSpec[:nextSectionIdx])
-		enabler.Fields["Technical Specifications"] = enabler.TechnicalSpecs
-	}
-
-	// Extract Type from ## Type section
-	typeIdx := strings.Index(content, "## Type")
-	if typeIdx != -1 {
-		afterType := content[typeIdx+len("## Type"):]
-		nextSectionIdx := strings.Index(afterType, "\n## ")
-		if nextSectionIdx == -1 {
-			nextSectionIdx = len(afterType)
-		}
-		enabler.EnablerType = strings.TrimSpace(afterType[:nextSectionIdx])
-		enabler.Fields["Type"] = enabler.EnablerType
-	}
-
-	// Extract Responsibility from ## Responsibility section
-	respIdx := strings.Index(content, "## Responsibility")
-	if respIdx != -1 {
-		afterResp := content[respIdx+len("## Responsibility"):]
-		nextSectionIdx := strings.Index(afterResp, "\n## ")
-		if nextSectionIdx == -1 {
-			nextSectionIdx = len(afterResp)
-		}
-		enabler.Responsibility = strings.TrimSpace(afterResp[:nextSectionIdx])
-		enabler.Fields["Responsibility"] = enabler.Responsibility
-	}
-
-	// Extract Public Interface from ## Public Interface section
-	pubIntIdx := strings.Index(content, "## Public Interface")
-	if pubIntIdx != -1 {
-		afterPubInt := content[pubIntIdx+len("## Public Interface"):]
-		nextSectionIdx := strings.Index(afterPubInt, "\n## ")
-		if nextSectionIdx == -1 {
-			nextSectionIdx = len(afterPubInt)
-		}
-		enabler.PublicInterface = strings.TrimSpace(afterPubInt[:nextSectionIdx])
-		enabler.Fields["Public Interface"] = enabler.PublicInterface
-	}
-
-	// Extract Internal Design from ## Internal Design section
-	intDesignIdx := strings.Index(content, "## Internal Design")
-	if intDesignIdx != -1 {
-		afterIntDesign := content[intDesignIdx+len("## Internal Design"):]
-		nextSectionIdx := strings.Index(afterIntDesign, "\n## ")
-		if nextSectionIdx == -1 {
-			nextSectionIdx = len(afterIntDesign)
-		}
-		enabler.InternalDesign = strings.TrimSpace(afterIntDesign[:nextSectionIdx])
-		enabler.Fields["Internal Design"] = enabler.InternalDesign
-	}
-
-	// Extract Dependencies from ## Dependencies section
-	depsIdx := strings.Index(content, "## Dependencies")
-	if depsIdx != -1 {
-		afterDeps := content[depsIdx+len("## Dependencies"):]
-		nextSectionIdx := strings.Index(afterDeps, "\n## ")
-		if nextSectionIdx == -1 {
-			nextSectionIdx = len(afterDeps)
-		}
-		enabler.Dependencies = strings.TrimSpace(afterDeps[:nextSectionIdx])
-		enabler.Fields["Dependencies"] = enabler.Dependencies
-	}
-
-	// Extract Configuration from ## Configuration section
-	configIdx := strings.Index(content, "## Configuration")
-	if configIdx != -1 {
-		afterConfig := content[configIdx+len("## Configuration"):]
-		nextSectionIdx := strings.Index(afterConfig, "\n## ")
-		if nextSectionIdx == -1 {
-			nextSectionIdx = len(afterConfig)
-		}
-		enabler.Configuration = strings.TrimSpace(afterConfig[:nextSectionIdx])
-		enabler.Fields["Configuration"] = enabler.Configuration
-	}
-
-	// Extract Data Contracts from ## Data Contracts section
-	dataContractsIdx := strings.Index(content, "## Data Contracts")
-	if dataContractsIdx != -1 {
-		afterDataContracts := content[dataContractsIdx+len("## Data Contracts"):]
-		nextSectionIdx := strings.Index(afterDataContracts, "\n## ")
-		if nextSectionIdx == -1 {
-			nextSectionIdx = len(afterDataContracts)
-		}
-		enabler.DataContracts = strings.TrimSpace(afterDataContracts[:nextSectionIdx])
-		enabler.Fields["Data Contracts"] = enabler.DataContracts
-	}
-
-	// Extract Operational Requirements from ## Operational Requirements section
-	opReqIdx := strings.Index(content, "## Operational Requirements")
-	if opReqIdx != -1 {
-		afterOpReq := content[opReqIdx+len("## Operational Requirements"):]
-		nextSectionIdx := strings.Index(afterOpReq, "\n## ")
-		if nextSectionIdx == -1 {
-			nextSectionIdx = len(afterOpReq)
-		}
-		enabler.OperationalRequirements = strings.TrimSpace(afterOpReq[:nextSectionIdx])
-		enabler.Fields["Operational Requirements"] = enabler.OperationalRequirements
-	}
-
-	// Extract Security Controls from ## Security Controls section
-	securityIdx := strings.Index(content, "## Security Controls")
-	if securityIdx != -1 {
-		afterSecurity := content[securityIdx+len("## Security Controls"):]
-		nextSectionIdx := strings.Index(afterSecurity, "\n## ")
-		if nextSectionIdx == -1 {
-			nextSectionIdx = len(afterSecurity)
-		}
-		enabler.SecurityControls = strings.TrimSpace(afterSecurity[:nextSectionIdx])
-		enabler.Fields["Security Controls"] = enabler.SecurityControls
-	}
-
-	// Extract Testing Strategy from ## Testing Strategy section
-	testingIdx := strings.Index(content, "## Testing Strategy")
-	if testingIdx != -1 {
-		afterTesting := content[testingIdx+len("## Testing Strategy"):]
-		nextSectionIdx := strings.Index(afterTesting, "\n## ")
-		if nextSectionIdx == -1 {
-			nextSectionIdx = len(afterTesting)
-		}
-		enabler.TestingStrategy = strings.TrimSpace(afterTesting[:nextSectionIdx])
-		enabler.Fields["Testing Strategy"] = enabler.TestingStrategy
-	}
-
-	// Extract Observability from ## Observability section
-	obsIdx := strings.Index(content, "## Observability")
-	if obsIdx != -1 {
-		afterObs := content[obsIdx+len("## Observability"):]
-		nextSectionIdx := strings.Index(afterObs, "\n## ")
-		if nextSectionIdx == -1 {
-			nextSectionIdx = len(afterObs)
-		}
-		enabler.Observability = strings.TrimSpace(afterObs[:nextSectionIdx])
-		enabler.Fields["Observability"] = enabler.Observability
-	}
-
-	// Extract Deployment from ## Deployment section
-	deployIdx := strings.Index(content, "## Deployment")
-	if deployIdx != -1 {
-		afterDeploy := content[deployIdx+len("## Deployment"):]
-		nextSectionIdx := strings.Index(afterDeploy, "\n## ")
-		if nextSectionIdx == -1 {
-			nextSectionIdx = len(afterDeploy)
-		}
-		enabler.Deployment = strings.TrimSpace(afterDeploy[:nextSectionIdx])
-		enabler.Fields["Deployment"] = enabler.Deployment
-	}
-
-	// Extract Runbook from ## Runbook section
-	runbookIdx := strings.Index(content, "## Runbook")
-	if runbookIdx != -1 {
-		afterRunbook := content[runbookIdx+len("## Runbook"):]
-		nextSectionIdx := strings.Index(afterRunbook, "\n## ")
-		if nextSectionIdx == -1 {
-			nextSectionIdx = len(afterRunbook)
-		}
-		enabler.Runbook = strings.TrimSpace(afterRunbook[:nextSectionIdx])
-		enabler.Fields["Runbook"] = enabler.Runbook
-	}
-
-	// Extract Cost Profile from ## Cost Profile section
-	costIdx := strings.Index(content, "## Cost Profile")
-	if costIdx != -1 {
-		afterCost := content[costIdx+len("## Cost Profile"):]
-		nextSectionIdx := strings.Index(afterCost, "\n## ")
-		if nextSectionIdx == -1 {
-			nextSectionIdx = len(afterCost)
-		}
-		enabler.CostProfile = strings.TrimSpace(afterCost[:nextSectionIdx])
-		enabler.Fields["Cost Profile"] = enabler.CostProfile
-	}
+		Filename:                filename,
+		Path:                    path,
+		Name:                    e.Name,
+		EnablerID:               e.ID,
+		Status:                  e.Status,
+		Owner:                   e.Owner,
+		Priority:                e.Priority,
+		CapabilityID:            e.CapabilityID,
+		CreatedBy:               e.CreatedBy,
+		Description:             e.Sections["Description"],
+		Purpose:                 e.Sections["Purpose"],
+		TechnicalSpecs:          e.Sections["Technical Specifications"],
+		EnablerType:             e.Sections["Type"],
+		Responsibility:          e.Sections["Responsibility"],
+		PublicInterface:         e.Sections["Public Interface"],
+		InternalDesign:          e.Sections["Internal Design"],
+		Dependencies:            e.Sections["Dependencies"],
+		Configuration:           e.Sections["Configuration"],
+		DataContracts:           e.Sections["Data Contracts"],
+		OperationalRequirements: e.Sections["Operational Requirements"],
+		SecurityControls:        e.Sections["Security Controls"],
+		TestingStrategy:         e.Sections["Testing Strategy"],
+		Observability:           e.Sections["Observability"],
+		Deployment:              e.Sections["Deployment"],
+		Runbook:                 e.Sections["Runbook"],
+		CostProfile:             e.Sections["Cost Profile"],
+		Fields:                  make(map[string]string),
+	}
+
+	// Expose the metadata fields that were present in the file
+	for _, name := range []string{"ID", "Status", "Owner", "Priority", "Capability ID", "Capability", "Created By"} {
+		if value, ok := e.Doc.Field(name); ok {
+			enabler.Fields[name] = value
+		}
+	}
+	for title, body := range e.Sections {
+		enabler.Fields[title] = body
+	}
+
+	// NOTE: INTENT State Model fields (lifecycle_state, workflow_stage, stage_status, approval_status)
+	// are NOT parsed from markdown. They are stored in the DATABASE only.
 
 	// Always use filename as source of truth for enabler ID if filename starts with ENB-
 	// This handles cases where metadata ID is truncated (e.g., ENB-MANAGE instead of ENB-MANAGE-REACT-COMPONENT-NAVIGATION-FLOW-18)
@@ -2899,6 +2932,9 @@ func (h *Handler) HandleUpdateCapabilityStoryboard(w http.ResponseWriter, r *htt
 
 	// Translate host path to container path if running in Docker
 	filePath := translatePathForDocker(req.Path)
+	if !h.confinePath(w, r, filePath) {
+		return
+	}
 
 	// Read existing file content
 	existingContent, err := os.ReadFile(filePath)
@@ -2907,66 +2943,15 @@ func (h *Handler) HandleUpdateCapabilityStoryboard(w http.ResponseWriter, r *htt
 		return
 	}
 
-	content := string(existingContent)
-	lines := strings.Split(content, "\n")
-	var newLines []string
-	storyboardFieldFound := false
-	inMetadata := false
-
-	for i, line := range lines {
-		// Check if we're in the metadata section
-		if strings.HasPrefix(line, "## Metadata") {
-			inMetadata = true
-		} else if strings.HasPrefix(line, "## ") && !strings.HasPrefix(line, "## Metadata") {
-			// If we're leaving metadata and haven't found storyboard field, add it before this section
-			if inMetadata && !storyboardFieldFound {
-				newLines = append(newLines, fmt.Sprintf("- **Storyboard Reference**: %s", req.StoryboardReference))
-				storyboardFieldFound = true
-			}
-			inMetadata = false
-		}
-
-		// Check for existing Storyboard Reference field
-		if strings.Contains(line, "**Storyboard Reference**") || strings.Contains(line, "**Storyboard**:") {
-			// Replace the storyboard reference line
-			newLines = append(newLines, fmt.Sprintf("- **Storyboard Reference**: %s", req.StoryboardReference))
-			storyboardFieldFound = true
-		} else {
-			newLines = append(newLines, line)
-		}
-
-		// If we reach the end of metadata and haven't added the field, add it before the next section
-		if inMetadata && i < len(lines)-1 && strings.HasPrefix(lines[i+1], "## ") && !storyboardFieldFound {
-			newLines = append(newLines, fmt.Sprintf("- **Storyboard Reference**: %s", req.StoryboardReference))
-			storyboardFieldFound = true
-		}
-	}
-
-	// If we still haven't added the storyboard reference, add it after the Metadata header
-	if !storyboardFieldFound {
-		// Find metadata section and add after it
-		for i, line := range newLines {
-			if strings.HasPrefix(line, "## Metadata") {
-				// Find the end of metadata items (lines starting with "- **")
-				insertIdx := i + 1
-				for j := i + 1; j < len(newLines); j++ {
-					if strings.HasPrefix(newLines[j], "- **") {
-						insertIdx = j + 1
-					} else if strings.HasPrefix(newLines[j], "## ") {
-						break
-					}
-				}
-				// Insert the storyboard reference
-				newContent := append(newLines[:insertIdx], append([]string{fmt.Sprintf("- **Storyboard Reference**: %s", req.StoryboardReference)}, newLines[insertIdx:]...)...)
-				newLines = newContent
-				storyboardFieldFound = true
-				break
-			}
-		}
+	// Update the field in place so the rest of the file is left untouched
+	doc := spec.Parse(string(existingContent))
+	if _, ok := doc.Field("Storyboard Reference"); !ok {
+		doc.RemoveField("Storyboard") // Legacy field name
 	}
+	doc.SetField("Storyboard Reference", req.StoryboardReference)
 
 	// Write updated content back to file
-	newContent := strings.Join(newLines, "\n")
+	newContent := doc.String()
 	if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
 		http.Error(w, fmt.Sprintf("failed to write file: %v", err), http.StatusInternalServerError)
 		return
@@ -3002,6 +2987,9 @@ func (h *Handler) HandleUpdateEnablerCapability(w http.ResponseWriter, r *http.R
 
 	// Translate host path to container path if running in Docker
 	filePath := translatePathForDocker(req.Path)
+	if !h.confinePath(w, r, filePath) {
+		return
+	}
 
 	// Read existing file content
 	existingContent, err := os.ReadFile(filePath)
@@ -3010,47 +2998,12 @@ func (h *Handler) HandleUpdateEnablerCapability(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	content := string(existingContent)
-	lines := strings.Split(content, "\n")
-	var newLines []string
-	capabilityFieldFound := false
-	inMetadata := false
-
-	capabilityValue := fmt.Sprintf("%s (%s)", req.CapabilityName, req.CapabilityId)
-
-	for i, line := range lines {
-		// Check if we're in the metadata section
-		if strings.HasPrefix(line, "## Metadata") {
-			inMetadata = true
-		} else if strings.HasPrefix(line, "## ") && !strings.HasPrefix(line, "## Metadata") {
-			// Exiting metadata section - if we haven't found the field, add it before this line
-			if inMetadata && !capabilityFieldFound {
-				newLines = append(newLines, fmt.Sprintf("- **Capability**: %s", capabilityValue))
-				capabilityFieldFound = true
-			}
-			inMetadata = false
-		}
-
-		// Check for existing Capability field (case-insensitive)
-		lineLower := strings.ToLower(line)
-		if inMetadata && (strings.HasPrefix(lineLower, "- **capability**:") || strings.HasPrefix(lineLower, "- **capability:**")) {
-			// Replace existing Capability field
-			newLines = append(newLines, fmt.Sprintf("- **Capability**: %s", capabilityValue))
-			capabilityFieldFound = true
-			continue
-		}
-
-		newLines = append(newLines, line)
-
-		// If we're at the end of the file and still in metadata and haven't added the field
-		if i == len(lines)-1 && inMetadata && !capabilityFieldFound {
-			newLines = append(newLines, fmt.Sprintf("- **Capability**: %s", capabilityValue))
-			capabilityFieldFound = true
-		}
-	}
+	// Update the field in place so the rest of the file is left untouched
+	doc := spec.Parse(string(existingContent))
+	doc.SetField("Capability", fmt.Sprintf("%s (%s)", req.CapabilityName, req.CapabilityId))
 
 	// Write updated content back to file
-	newContent := strings.Join(newLines, "\n")
+	newContent := doc.String()
 	if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
 		http.Error(w, fmt.Sprintf("failed to write file: %v", err), http.StatusInternalServerError)
 		return
@@ -3115,8 +3068,121 @@ func (h *Handler) HandleSaveCapability(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "path is required", http.StatusBadRequest)
 		return
 	}
+	if !h.confinePath(w, r, req.Path) {
+		return
+	}
 
-	// Build the markdown content following INTENT Capability template
+	// Keep the ID already in the file, or reserve a new one for new capabilities
+	existing, readErr := os.ReadFile(req.Path)
+	if req.CapabilityID == "" && readErr == nil {
+		req.CapabilityID = spec.ParseCapability(string(existing)).ID
+	}
+	if req.CapabilityID == "" {
+		id, err := h.allocateID(workspaceDirForSpec(req.Path), idalloc.PrefixCapability)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to allocate capability ID: %v", err), http.StatusInternalServerError)
+			return
+		}
+		req.CapabilityID = id
+	}
+
+	// New files are generated from the INTENT Capability template. Existing files
+	// are edited in place so hand-written sections survive the save.
+	content := renderCapabilityMarkdown(req)
+	if readErr == nil {
+		content = mergeCapabilityMarkdown(string(existing), req)
+	}
+
+	// Write to file
+	log.Printf("HandleSaveCapability: Writing %d bytes to %s", len(content), req.Path)
+	if err := os.WriteFile(req.Path, []byte(content), 0644); err != nil {
+		log.Printf("HandleSaveCapability: Failed to write file: %v", err)
+		http.Error(w, fmt.Sprintf("failed to save file: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("HandleSaveCapability: Successfully saved capability to %s", req.Path)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":      true,
+		"message":      "Capability saved successfully",
+		"capabilityId": req.CapabilityID,
+	})
+}
+
+// capabilitySection is one template section of a capability file
+type capabilitySection struct {
+	parent string // "" for top-level ## sections
+	title  string
+	body   string
+}
+
+// capabilitySections returns the template sections for a save request, with
+// placeholders for fields left empty
+func capabilitySections(req SaveCapabilityRequest) []capabilitySection {
+	orPlaceholder := func(text, placeholder string) string {
+		if text != "" {
+			return text
+		}
+		return placeholder
+	}
+
+	// NOTE: State fields (lifecycle_state, workflow_stage, stage_status, approval_status)
+	// are stored in the DATABASE only, not in markdown files.
+	// The database is the single source of truth for state.
+	return []capabilitySection{
+		{"Business Context", "Description", orPlaceholder(req.Description, "_No description provided._")},
+		{"Business Context", "Value Proposition", orPlaceholder(req.ValueProposition, "_Define the value this capability delivers._")},
+		{"Business Context", "Success Metrics", formatListItems(req.SuccessMetrics, "- _Define success metrics_")},
+		{"User Perspective", "User Scenarios", orPlaceholder(req.UserScenarios, "_Define user scenarios._")},
+		{"Boundaries", "In Scope", formatListItems(req.InScope, "- _Define what is included_")},
+		{"Boundaries", "Out of Scope", formatListItems(req.OutOfScope, "- _Define what is excluded_")},
+		{"Dependencies", "Upstream Dependencies", formatListItems(req.UpstreamDependencies, "- _None defined_")},
+		{"Dependencies", "Downstream Dependencies", formatListItems(req.DownstreamDependencies, "- _None defined_")},
+		{"", "Acceptance Criteria", formatChecklist(req.AcceptanceCriteria, "- [ ] _Define acceptance criteria_")},
+	}
+}
+
+// formatListItems renders one "- item" line per non-empty input line
+func formatListItems(text, placeholder string) string {
+	if text == "" {
+		return placeholder
+	}
+	var items []string
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "*") {
+			line = "- " + line
+		}
+		items = append(items, line)
+	}
+	return strings.Join(items, "\n")
+}
+
+// formatChecklist renders one "- [ ] item" line per non-empty input line
+func formatChecklist(text, placeholder string) string {
+	if text == "" {
+		return placeholder
+	}
+	var items []string
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "[") {
+			line = "- [ ] " + line
+		}
+		items = append(items, line)
+	}
+	return strings.Join(items, "\n")
+}
+
+// renderCapabilityMarkdown builds a new capability file following the INTENT Capability template
+func renderCapabilityMarkdown(req SaveCapabilityRequest) string {
 	var content strings.Builder
 	content.WriteString(fmt.Sprintf("# %s\n\n", req.Name))
 
@@ -3135,166 +3201,54 @@ func (h *Handler) HandleSaveCapability(w http.ResponseWriter, r *http.Request) {
 	}
 	content.WriteString("\n")
 
-	// NOTE: State fields (lifecycle_state, workflow_stage, stage_status, approval_status)
-	// are stored in the DATABASE only, not in markdown files.
-	// The database is the single source of truth for state.
-
-	// Business Context section
-	content.WriteString("## Business Context\n\n")
-	content.WriteString("### Description\n")
-	if req.Description != "" {
-		content.WriteString(req.Description + "\n\n")
-	} else {
-		content.WriteString("_No description provided._\n\n")
-	}
-
-	content.WriteString("### Value Proposition\n")
-	if req.ValueProposition != "" {
-		content.WriteString(req.ValueProposition + "\n\n")
-	} else {
-		content.WriteString("_Define the value this capability delivers._\n\n")
-	}
-
-	content.WriteString("### Success Metrics\n")
-	if req.SuccessMetrics != "" {
-		// Split by newlines and format as list items
-		metrics := strings.Split(strings.TrimSpace(req.SuccessMetrics), "\n")
-		for _, metric := range metrics {
-			metric = strings.TrimSpace(metric)
-			if metric != "" {
-				if !strings.HasPrefix(metric, "-") && !strings.HasPrefix(metric, "*") {
-					content.WriteString("- " + metric + "\n")
-				} else {
-					content.WriteString(metric + "\n")
-				}
-			}
+	parent := ""
+	for _, section := range capabilitySections(req) {
+		if section.parent == "" {
+			content.WriteString(fmt.Sprintf("## %s\n", section.title))
+			content.WriteString(section.body + "\n\n")
+			continue
 		}
-		content.WriteString("\n")
-	} else {
-		content.WriteString("- _Define success metrics_\n\n")
+		if section.parent != parent {
+			content.WriteString(fmt.Sprintf("## %s\n\n", section.parent))
+			parent = section.parent
+		}
+		content.WriteString(fmt.Sprintf("### %s\n", section.title))
+		content.WriteString(section.body + "\n\n")
 	}
 
-	// User Perspective section
-	content.WriteString("## User Perspective\n\n")
-	content.WriteString("### User Scenarios\n")
-	if req.UserScenarios != "" {
-		content.WriteString(req.UserScenarios + "\n\n")
-	} else {
-		content.WriteString("_Define user scenarios._\n\n")
-	}
+	// Note: We don't append req.Content here anymore - all structured fields
+	// are already written above. Appending req.Content caused duplicate sections.
+	return content.String()
+}
 
-	// Boundaries section
-	content.WriteString("## Boundaries\n\n")
-	content.WriteString("### In Scope\n")
-	if req.InScope != "" {
-		lines := strings.Split(strings.TrimSpace(req.InScope), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line != "" {
-				if !strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "*") {
-					content.WriteString("- " + line + "\n")
-				} else {
-					content.WriteString(line + "\n")
-				}
-			}
-		}
-		content.WriteString("\n")
-	} else {
-		content.WriteString("- _Define what is included_\n\n")
+// mergeCapabilityMarkdown applies a save request to an existing capability
+// file. Only the title, the template's metadata fields and the template
+// sections are rewritten; anything else in the file is kept byte-for-byte.
+func mergeCapabilityMarkdown(existing string, req SaveCapabilityRequest) string {
+	doc := spec.Parse(existing)
+	doc.SetTitle(req.Name)
+	if req.CapabilityID != "" {
+		doc.SetField("ID", req.CapabilityID)
 	}
-
-	content.WriteString("### Out of Scope\n")
-	if req.OutOfScope != "" {
-		lines := strings.Split(strings.TrimSpace(req.OutOfScope), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line != "" {
-				if !strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "*") {
-					content.WriteString("- " + line + "\n")
-				} else {
-					content.WriteString(line + "\n")
-				}
-			}
-		}
-		content.WriteString("\n")
-	} else {
-		content.WriteString("- _Define what is excluded_\n\n")
+	doc.SetField("Name", req.Name)
+	if _, ok := doc.Field("Type"); !ok {
+		doc.SetField("Type", "Capability")
 	}
-
-	// Dependencies section
-	content.WriteString("## Dependencies\n\n")
-	content.WriteString("### Upstream Dependencies\n")
-	if req.UpstreamDependencies != "" {
-		lines := strings.Split(strings.TrimSpace(req.UpstreamDependencies), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line != "" {
-				if !strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "*") {
-					content.WriteString("- " + line + "\n")
-				} else {
-					content.WriteString(line + "\n")
-				}
-			}
-		}
-		content.WriteString("\n")
-	} else {
-		content.WriteString("- _None defined_\n\n")
+	if req.StoryboardReference != "" {
+		doc.SetField("Storyboard Reference", req.StoryboardReference)
 	}
-
-	content.WriteString("### Downstream Dependencies\n")
-	if req.DownstreamDependencies != "" {
-		lines := strings.Split(strings.TrimSpace(req.DownstreamDependencies), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line != "" {
-				if !strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "*") {
-					content.WriteString("- " + line + "\n")
-				} else {
-					content.WriteString(line + "\n")
-				}
-			}
-		}
-		content.WriteString("\n")
-	} else {
-		content.WriteString("- _None defined_\n\n")
+	if req.PrimaryPersona != "" {
+		doc.SetField("Primary Persona", req.PrimaryPersona)
 	}
 
-	// Acceptance Criteria section
-	content.WriteString("## Acceptance Criteria\n")
-	if req.AcceptanceCriteria != "" {
-		lines := strings.Split(strings.TrimSpace(req.AcceptanceCriteria), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line != "" {
-				if !strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "[") {
-					content.WriteString("- [ ] " + line + "\n")
-				} else {
-					content.WriteString(line + "\n")
-				}
-			}
+	for _, section := range capabilitySections(req) {
+		if section.parent == "" {
+			doc.SetSectionBody(section.title, 2, section.body)
+		} else {
+			doc.SetSubsectionBody(section.parent, section.title, section.body)
 		}
-		content.WriteString("\n")
-	} else {
-		content.WriteString("- [ ] _Define acceptance criteria_\n\n")
-	}
-
-	// Note: We don't append req.Content here anymore - all structured fields
-	// are already written above. Appending req.Content caused duplicate sections.
-
-	// Write to file
-	log.Printf("HandleSaveCapability: Writing %d bytes to %s", len(content.String()), req.Path)
-	if err := os.WriteFile(req.Path, []byte(content.String()), 0644); err != nil {
-		log.Printf("HandleSaveCapability: Failed to write file: %v", err)
-		http.Error(w, fmt.Sprintf("failed to save file: %v", err), http.StatusInternalServerError)
-		return
 	}
-
-	log.Printf("HandleSaveCapability: Successfully saved capability to %s", req.Path)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": "Capability saved successfully",
-	})
+	return doc.String()
 }
 
 // DeleteCapabilityRequest represents the request to delete a capability file
@@ -3317,6 +3271,9 @@ func (h *Handler) HandleDeleteCapability(w http.ResponseWriter, r *http.Request)
 
 	// Translate host path to container path if running in Docker
 	filePath := translatePathForDocker(req.Path)
+	if !h.confinePath(w, r, filePath) {
+		return
+	}
 
 	// Verify file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
@@ -3358,6 +3315,9 @@ func (h *Handler) HandleDeleteEnabler(w http.ResponseWriter, r *http.Request) {
 
 	// Translate host path to container path if running in Docker
 	filePath := translatePathForDocker(req.Path)
+	if !h.confinePath(w, r, filePath) {
+		return
+	}
 
 	// Verify file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
@@ -3421,6 +3381,9 @@ func (h *Handler) HandleReadFile(w http.ResponseWriter, r *http.Request) {
 		}
 		filePath = filepath.Join(cwd, relativePath)
 	}
+	if !h.confinePath(w, r, filePath) {
+		return
+	}
 
 	// Read the file
 	content, err := os.ReadFile(filePath)
@@ -3472,11 +3435,6 @@ func (h *Handler) HandleSaveTestScenario(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if req.ScenarioID == "" {
-		http.Error(w, "scenarioId is required", http.StatusBadRequest)
-		return
-	}
-
 	// Handle path translation for Docker environments
 	workspacePath := req.WorkspacePath
 
@@ -3491,50 +3449,100 @@ func (h *Handler) HandleSaveTestScenario(w http.ResponseWriter, r *http.Request)
 		workspacePath = filepath.Join(cwd, relativePath)
 	}
 
-	// Create test folder if it doesn't exist
+	// Both paths are checked before anything is created or read under them
 	testFolder := filepath.Join(workspacePath, "test")
+	if !h.confinePath(w, r, workspacePath) || !h.confinePath(w, r, testFolder) {
+		return
+	}
+
+	// Create test folder if it doesn't exist
 	if err := os.MkdirAll(testFolder, 0755); err != nil {
 		http.Error(w, fmt.Sprintf("failed to create test folder: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	// New scenarios without an ID get the next free TS- ID for the workspace
+	if req.ScenarioID == "" {
+		id, err := h.allocateID(workspacePath, idalloc.PrefixTestScenario)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to allocate scenario ID: %v", err), http.StatusInternalServerError)
+			return
+		}
+		req.ScenarioID = id
+	}
+
 	// Generate filename from scenario ID
 	filename := fmt.Sprintf("%s.md", req.ScenarioID)
 	filePath := filepath.Join(testFolder, filename)
+	if !h.confinePath(w, r, filePath) {
+		return
+	}
 
-	// Build markdown content
-	var content strings.Builder
-	content.WriteString(fmt.Sprintf("# %s\n\n", req.ScenarioName))
+	// Existing scenarios are edited in place so hand-written notes survive the save
+	content := renderTestScenarioMarkdown(req)
+	if existing, err := os.ReadFile(filePath); err == nil {
+		content = mergeTestScenarioMarkdown(string(existing), req)
+	}
 
-	// Metadata section
-	content.WriteString("## Metadata\n\n")
-	content.WriteString(fmt.Sprintf("- **ID**: %s\n", req.ScenarioID))
-	content.WriteString(fmt.Sprintf("- **Type**: Test Scenario\n"))
-	content.WriteString(fmt.Sprintf("- **Enabler ID**: %s\n", req.EnablerID))
-	content.WriteString(fmt.Sprintf("- **Enabler Name**: %s\n", req.EnablerName))
-	content.WriteString(fmt.Sprintf("- **Feature**: %s\n", req.Feature))
-	content.WriteString(fmt.Sprintf("- **Priority**: %s\n", req.Priority))
-	content.WriteString(fmt.Sprintf("- **Status**: %s\n", req.Status))
-	content.WriteString(fmt.Sprintf("- **Automation**: %s\n", req.Automation))
+	// Write the file
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		http.Error(w, fmt.Sprintf("failed to write test scenario file: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":    true,
+		"message":    "Test scenario saved successfully",
+		"path":       filePath,
+		"filename":   filename,
+		"scenarioId": req.ScenarioID,
+	})
+}
+
+// testScenarioFields returns the metadata fields written for a test scenario, in template order
+func testScenarioFields(req SaveTestScenarioRequest) [][2]string {
+	fields := [][2]string{
+		{"ID", req.ScenarioID},
+		{"Type", "Test Scenario"},
+		{"Enabler ID", req.EnablerID},
+		{"Enabler Name", req.EnablerName},
+		{"Feature", req.Feature},
+		{"Priority", req.Priority},
+		{"Status", req.Status},
+		{"Automation", req.Automation},
+	}
 
 	// Requirements
 	if len(req.RequirementIDs) > 0 {
-		content.WriteString(fmt.Sprintf("- **Requirement IDs**: %s\n", strings.Join(req.RequirementIDs, ", ")))
+		fields = append(fields, [2]string{"Requirement IDs", strings.Join(req.RequirementIDs, ", ")})
 	}
 
 	// Tags
 	if len(req.Tags) > 0 {
-		content.WriteString(fmt.Sprintf("- **Tags**: %s\n", strings.Join(req.Tags, ", ")))
+		fields = append(fields, [2]string{"Tags", strings.Join(req.Tags, ", ")})
 	}
 
 	// Execution info
 	if req.LastExecuted != "" {
-		content.WriteString(fmt.Sprintf("- **Last Executed**: %s\n", req.LastExecuted))
+		fields = append(fields, [2]string{"Last Executed", req.LastExecuted})
 	}
 	if req.ExecutionTime > 0 {
-		content.WriteString(fmt.Sprintf("- **Execution Time**: %.2fms\n", req.ExecutionTime))
+		fields = append(fields, [2]string{"Execution Time", fmt.Sprintf("%.2fms", req.ExecutionTime)})
 	}
+	return fields
+}
+
+// renderTestScenarioMarkdown builds a new test scenario file
+func renderTestScenarioMarkdown(req SaveTestScenarioRequest) string {
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("# %s\n\n", req.ScenarioName))
 
+	// Metadata section
+	content.WriteString("## Metadata\n\n")
+	for _, field := range testScenarioFields(req) {
+		content.WriteString(fmt.Sprintf("- **%s**: %s\n", field[0], field[1]))
+	}
 	content.WriteString("\n")
 
 	// Gherkin section
@@ -3542,20 +3550,25 @@ func (h *Handler) HandleSaveTestScenario(w http.ResponseWriter, r *http.Request)
 	content.WriteString("```gherkin\n")
 	content.WriteString(req.Gherkin)
 	content.WriteString("\n```\n")
+	return content.String()
+}
 
-	// Write the file
-	if err := os.WriteFile(filePath, []byte(content.String()), 0644); err != nil {
-		http.Error(w, fmt.Sprintf("failed to write test scenario file: %v", err), http.StatusInternalServerError)
-		return
+// mergeTestScenarioMarkdown applies a save request to an existing test scenario file
+func mergeTestScenarioMarkdown(existing string, req SaveTestScenarioRequest) string {
+	ts := spec.ParseTestScenario(existing)
+	ts.Doc.SetTitle(req.ScenarioName)
+	for _, field := range testScenarioFields(req) {
+		ts.Doc.SetField(field[0], field[1])
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success":  true,
-		"message":  "Test scenario saved successfully",
-		"path":     filePath,
-		"filename": filename,
-	})
+	// Lists left empty are dropped, as a new scenario would not have them
+	if len(req.RequirementIDs) == 0 {
+		ts.Doc.RemoveField("Requirement IDs")
+	}
+	if len(req.Tags) == 0 {
+		ts.Doc.RemoveField("Tags")
+	}
+	ts.SetGherkin(req.Gherkin)
+	return ts.Doc.String()
 }
 
 // DeleteTestScenarioRequest represents the request to delete a test scenario
@@ -3600,6 +3613,9 @@ func (h *Handler) HandleDeleteTestScenario(w http.ResponseWriter, r *http.Reques
 	testFolder := filepath.Join(workspacePath, "test")
 	filename := fmt.Sprintf("%s.md", req.ScenarioID)
 	filePath := filepath.Join(testFolder, filename)
+	if !h.confinePath(w, r, filePath) {
+		return
+	}
 
 	// Verify file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
@@ -3661,6 +3677,9 @@ func (h *Handler) HandleListTestScenarios(w http.ResponseWriter, r *http.Request
 
 	// Build the test folder path
 	testFolder := filepath.Join(workspacePath, "test")
+	if !h.confinePath(w, r, testFolder) {
+		return
+	}
 
 	// Check if test folder exists
 	if _, err := os.Stat(testFolder); os.IsNotExist(err) {
@@ -3706,103 +3725,51 @@ func (h *Handler) HandleListTestScenarios(w http.ResponseWriter, r *http.Request
 
 // parseTestScenarioFromContent extracts test scenario information from markdown content
 func parseTestScenarioFromContent(filename, content string) map[string]interface{} {
+	ts := spec.ParseTestScenario(content)
 	scenario := make(map[string]interface{})
 
-	// Extract name from first # heading
-	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, "# ") {
-			scenario["name"] = strings.TrimPrefix(line, "# ")
-			scenario["name"] = strings.TrimSpace(scenario["name"].(string))
-			break
-		}
+	// Name from first # heading, falling back to the filename
+	scenario["name"] = ts.Name
+	if ts.Name == "" {
+		scenario["name"] = strings.TrimSuffix(filename, ".md")
 	}
 
-	// If no heading found, use filename
-	if scenario["name"] == nil || scenario["name"] == "" {
-		baseName := strings.TrimSuffix(filename, ".md")
-		scenario["name"] = baseName
-	}
-
-	// Extract metadata fields using regex
-	idPattern := regexp.MustCompile(`\*\*ID\*\*:\s*(.+)`)
-	enablerIDPattern := regexp.MustCompile(`\*\*Enabler ID\*\*:\s*(.+)`)
-	enablerNamePattern := regexp.MustCompile(`\*\*Enabler Name\*\*:\s*(.+)`)
-	featurePattern := regexp.MustCompile(`\*\*Feature\*\*:\s*(.+)`)
-	priorityPattern := regexp.MustCompile(`\*\*Priority\*\*:\s*(.+)`)
-	statusPattern := regexp.MustCompile(`\*\*Status\*\*:\s*(.+)`)
-	automationPattern := regexp.MustCompile(`\*\*Automation\*\*:\s*(.+)`)
-	requirementIDsPattern := regexp.MustCompile(`\*\*Requirement IDs\*\*:\s*(.+)`)
-	tagsPattern := regexp.MustCompile(`\*\*Tags\*\*:\s*(.+)`)
-	lastExecutedPattern := regexp.MustCompile(`\*\*Last Executed\*\*:\s*(.+)`)
-	executionTimePattern := regexp.MustCompile(`\*\*Execution Time\*\*:\s*(.+)`)
-
-	if match := idPattern.FindStringSubmatch(content); len(match) > 1 {
-		scenario["id"] = strings.TrimSpace(match[1])
-	} else {
-		// Try to extract ID from filename
+	// Try to extract ID from filename when the metadata has none
+	scenario["id"] = ts.Doc.FieldValue("ID")
+	if scenario["id"] == "" {
 		scenario["id"] = strings.TrimSuffix(filename, ".md")
 	}
 
-	if match := enablerIDPattern.FindStringSubmatch(content); len(match) > 1 {
-		scenario["enablerId"] = strings.TrimSpace(match[1])
-	}
-
-	if match := enablerNamePattern.FindStringSubmatch(content); len(match) > 1 {
-		scenario["enablerName"] = strings.TrimSpace(match[1])
+	optional := map[string]string{
+		"enablerId":    ts.Doc.FieldValue("Enabler ID"),
+		"enablerName":  ts.EnablerName,
+		"feature":      ts.Feature,
+		"priority":     ts.Priority,
+		"status":       ts.Status,
+		"automation":   ts.Automation,
+		"lastExecuted": ts.LastExecuted,
+		"gherkin":      ts.Gherkin,
 	}
-
-	if match := featurePattern.FindStringSubmatch(content); len(match) > 1 {
-		scenario["feature"] = strings.TrimSpace(match[1])
-	}
-
-	if match := priorityPattern.FindStringSubmatch(content); len(match) > 1 {
-		scenario["priority"] = strings.TrimSpace(match[1])
-	}
-
-	if match := statusPattern.FindStringSubmatch(content); len(match) > 1 {
-		scenario["status"] = strings.TrimSpace(match[1])
-	}
-
-	if match := automationPattern.FindStringSubmatch(content); len(match) > 1 {
-		scenario["automation"] = strings.TrimSpace(match[1])
-	}
-
-	if match := requirementIDsPattern.FindStringSubmatch(content); len(match) > 1 {
-		reqIds := strings.Split(strings.TrimSpace(match[1]), ",")
-		var trimmedIds []string
-		for _, id := range reqIds {
-			trimmedIds = append(trimmedIds, strings.TrimSpace(id))
+	for key, value := range optional {
+		if value != "" {
+			scenario[key] = value
 		}
-		scenario["requirementIds"] = trimmedIds
 	}
 
-	if match := tagsPattern.FindStringSubmatch(content); len(match) > 1 {
-		tags := strings.Split(strings.TrimSpace(match[1]), ",")
-		var trimmedTags []string
-		for _, tag := range tags {
-			trimmedTags = append(trimmedTags, strings.TrimSpace(tag))
-		}
-		scenario["tags"] = trimmedTags
+	if ts.RequirementIDs != nil {
+		scenario["requirementIds"] = ts.RequirementIDs
 	}
-
-	if match := lastExecutedPattern.FindStringSubmatch(content); len(match) > 1 {
-		scenario["lastExecuted"] = strings.TrimSpace(match[1])
+	if ts.Tags != nil {
+		scenario["tags"] = ts.Tags
 	}
 
-	if match := executionTimePattern.FindStringSubmatch(content); len(match) > 1 {
-		timeStr := strings.TrimSuffix(strings.TrimSpace(match[1]), "ms")
+	if ts.ExecutionTime != "" {
+		timeStr := strings.TrimSuffix(ts.ExecutionTime, "ms")
 		if t, err := strconv.ParseFloat(timeStr, 64); err == nil {
 			scenario["executionTime"] = t
 		}
 	}
 
-	// Extract Gherkin content
-	gherkinPattern := regexp.MustCompile("(?s)```gherkin\\s*(.+?)```")
-	if match := gherkinPattern.FindStringSubmatch(content); len(match) > 1 {
-		scenario["gherkin"] = strings.TrimSpace(match[1])
-	}
-
 	return scenario
 }
 
@@ -3940,158 +3907,66 @@ func parseMarkdownCapabilities(filename, path, content string) []FileCapability
 	// Filter out empty blocks (content before first heading)
 	var filteredBlocks []struct {
 		name    string
-		content []string
-	}
-	for _, block := range capabilityBlocks {
-		if block.name != "" {
-			filteredBlocks = append(filteredBlocks, block)
-		}
-	}
-
-	// If no named blocks found, treat entire file as single capability
-	if len(filteredBlocks) == 0 {
-		cap := parseSingleCapability(filename, path, content, "")
-		return []FileCapability{cap}
-	}
-
-	// Parse each block as a separate capability
-	for _, block := range filteredBlocks {
-		blockContent := strings.Join(block.content, "\n")
-		cap := parseSingleCapability(filename, path, blockContent, block.name)
-		capabilities = append(capabilities, cap)
-	}
-
-	return capabilities
-}
-
-// parseSingleCapability parses content for a single capability
-func parseSingleCapability(filename, path, content, name string) FileCapability {
-	cap := FileCapability{
-		Filename: filename,
-		Path:     path,
-		Content:  content,
-		Name:     name,
-		Fields:   make(map[string]string),
-	}
-
-	lines := strings.Split(content, "\n")
-	var currentSection string
-	var sectionContent []string
-
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-
-		// Check for section headers (## heading)
-		if strings.HasPrefix(trimmedLine, "## ") {
-			// Save previous section
-			if currentSection != "" && len(sectionContent) > 0 {
-				cap.Fields[currentSection] = strings.TrimSpace(strings.Join(sectionContent, "\n"))
-			}
-			currentSection = strings.TrimPrefix(trimmedLine, "## ")
-			sectionContent = []string{}
-			continue
-		}
-
-		// Check for subsection headers (### heading)
-		if strings.HasPrefix(trimmedLine, "### ") {
-			// Save previous section
-			if currentSection != "" && len(sectionContent) > 0 {
-				cap.Fields[currentSection] = strings.TrimSpace(strings.Join(sectionContent, "\n"))
-			}
-			currentSection = strings.TrimPrefix(trimmedLine, "### ")
-			sectionContent = []string{}
-			continue
-		}
-
-		// Look for specific field patterns
-		// Handle various status formats: **Status:** value, Status: value, - **Status**: value
-		// Make pattern matching case-insensitive
-		trimmedLineLower := strings.ToLower(trimmedLine)
-		if strings.HasPrefix(trimmedLineLower, "**status:**") || strings.HasPrefix(trimmedLineLower, "**status**:") ||
-			strings.HasPrefix(trimmedLineLower, "status:") ||
-			strings.HasPrefix(trimmedLineLower, "- **status**:") || strings.HasPrefix(trimmedLineLower, "- **status:**") {
-			status := trimmedLine
-			status = strings.TrimPrefix(status, "- ")
-			// Remove all possible status prefixes (case-insensitive approach)
-			for _, prefix := range []string{"**Status:**", "**status:**", "**Status**:", "**status**:", "Status:", "status:"} {
-				if strings.HasPrefix(status, prefix) {
-					status = strings.TrimPrefix(status, prefix)
-					break
-				}
-			}
-			cap.Status = strings.TrimSpace(status)
-			continue
-		}
-
-		if strings.HasPrefix(trimmedLine, "**Description:**") || strings.HasPrefix(trimmedLine, "Description:") {
-			desc := strings.TrimPrefix(trimmedLine, "**Description:**")
-			desc = strings.TrimPrefix(desc, "Description:")
-			cap.Description = strings.TrimSpace(desc)
-			continue
-		}
-
-		// Look for Storyboard Reference field
-		if strings.HasPrefix(trimmedLineLower, "**storyboard reference:**") || strings.HasPrefix(trimmedLineLower, "**storyboard reference**:") ||
-			strings.HasPrefix(trimmedLineLower, "storyboard reference:") ||
-			strings.HasPrefix(trimmedLineLower, "- **storyboard reference**:") || strings.HasPrefix(trimmedLineLower, "- **storyboard reference:**") {
-			storyRef := trimmedLine
-			storyRef = strings.TrimPrefix(storyRef, "- ")
-			// Remove all possible storyboard reference prefixes
-			for _, prefix := range []string{"**Storyboard Reference:**", "**storyboard reference:**", "**Storyboard Reference**:", "**storyboard reference**:", "Storyboard Reference:", "storyboard reference:"} {
-				if strings.HasPrefix(storyRef, prefix) {
-					storyRef = strings.TrimPrefix(storyRef, prefix)
-					break
-				}
-			}
-			cap.Fields["Storyboard Reference"] = strings.TrimSpace(storyRef)
-			continue
-		}
-
-		// Look for Primary Persona field
-		if strings.HasPrefix(trimmedLineLower, "**primary persona:**") || strings.HasPrefix(trimmedLineLower, "**primary persona**:") ||
-			strings.HasPrefix(trimmedLineLower, "primary persona:") ||
-			strings.HasPrefix(trimmedLineLower, "- **primary persona**:") || strings.HasPrefix(trimmedLineLower, "- **primary persona:**") {
-			persona := trimmedLine
-			persona = strings.TrimPrefix(persona, "- ")
-			for _, prefix := range []string{"**Primary Persona:**", "**primary persona:**", "**Primary Persona**:", "**primary persona**:", "Primary Persona:", "primary persona:"} {
-				if strings.HasPrefix(persona, prefix) {
-					persona = strings.TrimPrefix(persona, prefix)
-					break
-				}
-			}
-			cap.Fields["Primary Persona"] = strings.TrimSpace(persona)
-			continue
+		content []string
+	}
+	for _, block := range capabilityBlocks {
+		if block.name != "" {
+			filteredBlocks = append(filteredBlocks, block)
 		}
+	}
 
-		// NOTE: INTENT State Model fields (lifecycle_state, workflow_stage, stage_status, approval_status)
-		// are NOT parsed from markdown. They are stored in the DATABASE only.
+	// If no named blocks found, treat entire file as single capability
+	if len(filteredBlocks) == 0 {
+		cap := parseSingleCapability(filename, path, content, "")
+		return []FileCapability{cap}
+	}
 
-		// Add line to current section content
-		if currentSection != "" {
-			sectionContent = append(sectionContent, line)
-		} else if cap.Description == "" && trimmedLine != "" && !strings.HasPrefix(trimmedLine, "#") {
-			// Use first non-empty, non-header line as description if not set
-			cap.Description = trimmedLine
-		}
+	// Parse each block as a separate capability
+	for _, block := range filteredBlocks {
+		blockContent := strings.Join(block.content, "\n")
+		cap := parseSingleCapability(filename, path, blockContent, block.name)
+		capabilities = append(capabilities, cap)
 	}
 
-	// Save last section
-	if currentSection != "" && len(sectionContent) > 0 {
-		cap.Fields[currentSection] = strings.TrimSpace(strings.Join(sectionContent, "\n"))
+	return capabilities
+}
+
+// parseSingleCapability parses content for a single capability
+func parseSingleCapability(filename, path, content, name string) FileCapability {
+	c := spec.ParseCapability(content)
+	cap := FileCapability{
+		Filename:    filename,
+		Path:        path,
+		Content:     content,
+		Name:        name,
+		Status:      c.Status,
+		Description: c.Description,
+		Fields:      c.Sections,
 	}
 
-	// If Description wasn't set from inline format, try to get it from Fields
-	if cap.Description == "" {
-		if desc, ok := cap.Fields["Description"]; ok && desc != "" {
-			cap.Description = desc
-		}
+	if c.StoryboardReference != "" {
+		cap.Fields["Storyboard Reference"] = c.StoryboardReference
+	}
+	if c.PrimaryPersona != "" {
+		cap.Fields["Primary Persona"] = c.PrimaryPersona
 	}
 
+	// NOTE: INTENT State Model fields (lifecycle_state, workflow_stage, stage_status, approval_status)
+	// are NOT parsed from markdown. They are stored in the DATABASE only.
+
 	// Default name from filename if not found
+	if cap.Name == "" {
+		cap.Name = c.Name
+	}
 	if cap.Name == "" {
 		cap.Name = strings.TrimSuffix(filename, filepath.Ext(filename))
 	}
 
+	if c.ID != "" {
+		cap.CapabilityID = c.ID
+		cap.Fields["ID"] = c.ID
+	}
+
 	// Always use filename as source of truth for capability ID if filename starts with CAP-
 	// This handles cases where metadata ID is missing or truncated
 	if strings.HasPrefix(strings.ToUpper(filename), "CAP-") {
@@ -4238,6 +4113,9 @@ func (h *Handler) HandleStoryFiles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	specsPath = filepath.Join(cwd, workspacePath, "conception")
+	if !h.confinePath(w, r, specsPath) {
+		return
+	}
 
 	// Check if conception folder exists
 	if _, err := os.Stat(specsPath); os.IsNotExist(err) {
@@ -4250,7 +4128,6 @@ func (h *Handler) HandleStoryFiles(w http.ResponseWriter, r *http.Request) {
 
 	// Find story files matching patterns: story*, STORY*, SB-*
 	var stories []FileStory
-	patterns := []string{"story", "STORY", "SB-"}
 
 	err = filepath.Walk(specsPath, func(path string, info os.FileInfo, walkErr error) error {
 		if walkErr != nil {
@@ -4263,21 +4140,7 @@ func (h *Handler) HandleStoryFiles(w http.ResponseWriter, r *http.Request) {
 
 		// Check if file matches any pattern (case-insensitive)
 		filename := info.Name()
-		filenameUpper := strings.ToUpper(filename)
-		matched := false
-		for _, pattern := range patterns {
-			if strings.HasPrefix(filenameUpper, strings.ToUpper(pattern)) {
-				matched = true
-				break
-			}
-		}
-
-		if !matched {
-			return nil
-		}
-
-		// Only process markdown files
-		if !strings.HasSuffix(strings.ToLower(filename), ".md") {
+		if !spec.IsStoryFile(filename) {
 			return nil
 		}
 
@@ -4465,73 +4328,26 @@ func parseMultipleStories(filename, path, content string) []FileStory {
 
 // parseSingleStory parses content for a single story
 func parseSingleStory(filename, path, content, title string) FileStory {
+	st := spec.ParseStory(content)
 	story := FileStory{
-		ID:       fmt.Sprintf("file-%s-%d", strings.TrimSuffix(filename, filepath.Ext(filename)), time.Now().UnixNano()),
-		Filename: filename,
-		Path:     path,
-		Content:  content,
-		Title:    title,
-		Fields:   make(map[string]string),
+		ID:          fmt.Sprintf("file-%s-%d", strings.TrimSuffix(filename, filepath.Ext(filename)), time.Now().UnixNano()),
+		Filename:    filename,
+		Path:        path,
+		Content:     content,
+		Title:       title,
+		Status:      st.Status,
+		Description: st.Description,
+		Fields:      st.Sections,
 	}
 
-	lines := strings.Split(content, "\n")
-	var currentSection string
-	var sectionContent []string
-
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-
-		// Check for section headers (## or ### heading)
-		if strings.HasPrefix(trimmedLine, "### ") {
-			if currentSection != "" && len(sectionContent) > 0 {
-				story.Fields[currentSection] = strings.TrimSpace(strings.Join(sectionContent, "\n"))
-			}
-			currentSection = strings.TrimPrefix(trimmedLine, "### ")
-			sectionContent = []string{}
-			continue
-		}
-
-		// Look for specific field patterns
-		if strings.HasPrefix(trimmedLine, "**Status:**") || strings.HasPrefix(trimmedLine, "Status:") {
-			status := strings.TrimPrefix(trimmedLine, "**Status:**")
-			status = strings.TrimPrefix(status, "Status:")
-			story.Status = strings.TrimSpace(status)
-			continue
-		}
-
-		if strings.HasPrefix(trimmedLine, "**Description:**") || strings.HasPrefix(trimmedLine, "Description:") {
-			desc := strings.TrimPrefix(trimmedLine, "**Description:**")
-			desc = strings.TrimPrefix(desc, "Description:")
-			story.Description = strings.TrimSpace(desc)
-			continue
-		}
-
-		// Parse Card ID field - this is the primary identifier used by Storyboard
-		// Handle various formats: "**Card ID**:", "Card ID:", "- **Card ID**:", etc.
-		if strings.Contains(trimmedLine, "**Card ID**:") || strings.Contains(trimmedLine, "Card ID:") {
-			cardID := trimmedLine
-			// Remove bullet point prefix if present
-			cardID = strings.TrimPrefix(cardID, "- ")
-			cardID = strings.TrimPrefix(cardID, "* ")
-			// Remove bold markers and field name
-			cardID = strings.TrimPrefix(cardID, "**Card ID**:")
-			cardID = strings.TrimPrefix(cardID, "Card ID:")
-			story.Fields["Card ID"] = strings.TrimSpace(cardID)
-			continue
-		}
-
-		// Add line to current section content
-		if currentSection != "" {
-			sectionContent = append(sectionContent, line)
-		} else if story.Description == "" && trimmedLine != "" && !strings.HasPrefix(trimmedLine, "#") {
-			story.Description = trimmedLine
-		}
+	// Card ID is the primary identifier used by Storyboard
+	if st.CardID != "" {
+		story.Fields["Card ID"] = st.CardID
 	}
 
-	if currentSection != "" && len(sectionContent) > 0 {
-		story.Fields[currentSection] = strings.TrimSpace(strings.Join(sectionContent, "\n"))
+	if story.Title == "" {
+		story.Title = st.Title
 	}
-
 	if story.Title == "" {
 		story.Title = strings.TrimSuffix(filename, filepath.Ext(filename))
 	}
@@ -4591,6 +4407,9 @@ func (h *Handler) HandleIdeationFiles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	specsPath = filepath.Join(cwd, workspacePath, "conception")
+	if !h.confinePath(w, r, specsPath) {
+		return
+	}
 
 	// Check if conception folder exists
 	if _, err := os.Stat(specsPath); os.IsNotExist(err) {
@@ -4743,7 +4562,6 @@ func parseIdeationFile(filename, path, content string) FileIdeation {
 // AnalyzeStoryboardRequest represents the request for storyboard analysis
 type AnalyzeStoryboardRequest struct {
 	WorkspacePath string `json:"workspacePath"`
-	APIKey        string `json:"apiKey"`
 	ForceRegenerate bool   `json:"forceRegenerate"`
 }
 
@@ -4813,10 +4631,11 @@ func (h *Handler) HandleAnalyzeStoryboard(w http.ResponseWriter, r *http.Request
 	}
 
 	// Need API key for regeneration
-	if req.APIKey == "" {
+	apiKey, err := h.anthropicKey(r)
+	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(StoryboardAnalysisResult{
-			Error: "apiKey is required for analysis",
+			Error: err.Error(),
 		})
 		return
 	}
@@ -4928,7 +4747,7 @@ Here are the files to analyze:
 Remember: Return ONLY the JSON object, nothing else.`, filesContent.String())
 
 	// Call Claude API
-	client := NewAnthropicClient(req.APIKey)
+	client := NewAnthropicClient(apiKey)
 	response, err := client.SendMessage(r.Context(), prompt)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
@@ -5038,6 +4857,9 @@ func (h *Handler) HandleSaveImage(w http.ResponseWriter, r *http.Request) {
 
 	// Save the image file
 	filePath := filepath.Join(specificationsPath, req.FileName)
+	if !h.confinePath(w, r, filePath) {
+		return
+	}
 	if err := os.WriteFile(filePath, imageBytes, 0644); err != nil {
 		http.Error(w, fmt.Sprintf("failed to write image file: %v", err), http.StatusInternalServerError)
 		return
@@ -5203,7 +5025,6 @@ Only return the JSON, no other text.`, conceptionContent, fileCount)
 func (h *Handler) HandleAnalyzeConception(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		WorkspacePath        string   `json:"workspacePath"`
-		AnthropicKey         string   `json:"anthropic_key"`
 		ExistingCapabilities []string `json:"existingCapabilities"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -5216,8 +5037,8 @@ func (h *Handler) HandleAnalyzeConception(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if req.AnthropicKey == "" {
-		http.Error(w, "anthropic_key is required", http.StatusBadRequest)
+	apiKey, ok := h.requireAnthropicKey(w, r)
+	if !ok {
 		return
 	}
 
@@ -5230,6 +5051,9 @@ func (h *Handler) HandleAnalyzeConception(w http.ResponseWriter, r *http.Request
 
 	// Build path to conception folder
 	conceptionPath := filepath.Join(cwd, req.WorkspacePath, "conception")
+	if !h.confinePath(w, r, conceptionPath) {
+		return
+	}
 
 	// Check if conception folder exists
 	if _, err := os.Stat(conceptionPath); os.IsNotExist(err) {
@@ -5337,7 +5161,7 @@ func (h *Handler) HandleAnalyzeConception(w http.ResponseWriter, r *http.Request
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", req.AnthropicKey)
+	httpReq.Header.Set("x-api-key", apiKey)
 	httpReq.Header.Set("anthropic-version", "2023-06-01")
 
 	client := &http.Client{Timeout: 120 * time.Second}
@@ -5393,7 +5217,6 @@ func (h *Handler) HandleAnalyzeConception(w http.ResponseWriter, r *http.Request
 func (h *Handler) HandleAnalyzeCapabilities(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		WorkspacePath     string   `json:"workspacePath"`
-		AnthropicKey      string   `json:"anthropic_key"`
 		ExistingEnablers  []string `json:"existingEnablers"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -5406,8 +5229,8 @@ func (h *Handler) HandleAnalyzeCapabilities(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if req.AnthropicKey == "" {
-		http.Error(w, "anthropic_key is required", http.StatusBadRequest)
+	apiKey, ok := h.requireAnthropicKey(w, r)
+	if !ok {
 		return
 	}
 
@@ -5420,6 +5243,9 @@ func (h *Handler) HandleAnalyzeCapabilities(w http.ResponseWriter, r *http.Reque
 
 	// Build path to definition folder where CAP-*.md files are stored
 	definitionPath := filepath.Join(cwd, req.WorkspacePath, "definition")
+	if !h.confinePath(w, r, definitionPath) {
+		return
+	}
 
 	// Check if definition folder exists
 	if _, err := os.Stat(definitionPath); os.IsNotExist(err) {
@@ -5567,7 +5393,7 @@ Provide ONLY valid JSON in your response, no additional text or markdown formatt
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", req.AnthropicKey)
+	httpReq.Header.Set("x-api-key", apiKey)
 	httpReq.Header.Set("anthropic-version", "2023-06-01")
 
 	client := &http.Client{Timeout: 120 * time.Second}
@@ -5659,6 +5485,9 @@ func (h *Handler) HandleSaveSpecifications(w http.ResponseWriter, r *http.Reques
 		return
 	}
 	targetPath := filepath.Join(cwd, workspacePath, targetSubfolder)
+	if !h.confinePath(w, r, targetPath) {
+		return
+	}
 
 	// Ensure target folder exists
 	if err := os.MkdirAll(targetPath, 0755); err != nil {
@@ -5666,14 +5495,43 @@ func (h *Handler) HandleSaveSpecifications(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	for _, file := range req.Files {
+		if !h.confinePath(w, r, filepath.Join(targetPath, file.FileName)) {
+			return
+		}
+	}
+
 	// Save all files
 	savedFiles := []string{}
+	assignedIDs := map[string]string{}
 	for _, file := range req.Files {
 		if file.FileName == "" || file.Content == "" {
 			continue
 		}
 		filePath := filepath.Join(targetPath, file.FileName)
-		if err := os.WriteFile(filePath, []byte(file.Content), 0644); err != nil {
+		content := file.Content
+		// Enablers and capabilities written without an ID keep the one already on
+		// disk, or get the next free ID for the workspace
+		if prefix := specIDPrefix(file.FileName); prefix != "" {
+			doc := spec.Parse(content)
+			if doc.FieldValue("ID") == "" {
+				id := ""
+				if existing, err := os.ReadFile(filePath); err == nil {
+					id = spec.Parse(string(existing)).FieldValue("ID")
+				}
+				if id == "" {
+					id, err = h.allocateID(filepath.Join(cwd, workspacePath), prefix)
+					if err != nil {
+						http.Error(w, fmt.Sprintf("failed to allocate ID for %s: %v", file.FileName, err), http.StatusInternalServerError)
+						return
+					}
+					assignedIDs[file.FileName] = id
+				}
+				doc.SetField("ID", id)
+				content = doc.String()
+			}
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
 			http.Error(w, fmt.Sprintf("failed to write file %s: %v", file.FileName, err), http.StatusInternalServerError)
 			return
 		}
@@ -5685,9 +5543,21 @@ func (h *Handler) HandleSaveSpecifications(w http.ResponseWriter, r *http.Reques
 		"success": true,
 		"message": fmt.Sprintf("%d files saved successfully", len(savedFiles)),
 		"files":   savedFiles,
+		"ids":     assignedIDs,
 	})
 }
 
+// specIDPrefix returns the ID prefix for an enabler or capability file name, or ""
+func specIDPrefix(filename string) string {
+	switch {
+	case spec.IsEnablerFile(filename):
+		return idalloc.PrefixEnabler
+	case spec.IsCapabilityFile(filename):
+		return idalloc.PrefixCapability
+	}
+	return ""
+}
+
 // DeleteSpecificationRequest represents a request to delete a specification file
 type DeleteSpecificationRequest struct {
 	Path          string `json:"path"`          // Legacy: full path
@@ -5737,6 +5607,9 @@ func (h *Handler) HandleDeleteSpecification(w http.ResponseWriter, r *http.Reque
 
 	// Construct absolute path
 	absolutePath := filepath.Join(cwd, filePath)
+	if !h.confinePath(w, r, absolutePath) {
+		return
+	}
 
 	// Log the delete operation
 	log.Printf("[DeleteSpecification] Attempting to delete: %s", absolutePath)
@@ -5811,6 +5684,9 @@ func (h *Handler) HandleReadSpecification(w http.ResponseWriter, r *http.Request
 
 	// Construct absolute path to the file
 	filePath := filepath.Join(cwd, workspacePath, targetSubfolder, req.FileName)
+	if !h.confinePath(w, r, filePath) {
+		return
+	}
 
 	// Verify the file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
@@ -5875,6 +5751,9 @@ func (h *Handler) HandleReadStoryboardFiles(w http.ResponseWriter, r *http.Reque
 
 	// Look in conception folder for STORY-*.md files
 	conceptionPath := filepath.Join(cwd, workspacePath, "conception")
+	if !h.confinePath(w, r, conceptionPath) {
+		return
+	}
 
 	// Check if conception folder exists
 	if _, err := os.Stat(conceptionPath); os.IsNotExist(err) {
@@ -6026,97 +5905,21 @@ func (h *Handler) HandleEpicFiles(w http.ResponseWriter, r *http.Request) {
 
 // parseEpicMarkdown parses a markdown file to extract epic information
 func parseEpicMarkdown(filename, path, content string) FileEpic {
-	epic := FileEpic{
-		Filename: filename,
-		Path:     path,
-		Content:  content,
-		Fields:   make(map[string]string),
-		Status:   "Funnel",
-	}
-
-	lines := strings.Split(content, "\n")
-	var currentSection string
-	var sectionContent []string
-
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-
-		// Extract title from # heading
-		if strings.HasPrefix(trimmedLine, "# ") {
-			epic.Name = strings.TrimPrefix(trimmedLine, "# ")
-			continue
-		}
-
-		// Extract section headers
-		if strings.HasPrefix(trimmedLine, "## ") {
-			// Save previous section
-			if currentSection != "" {
-				epic.Fields[currentSection] = strings.TrimSpace(strings.Join(sectionContent, "\n"))
-			}
-			currentSection = strings.TrimPrefix(trimmedLine, "## ")
-			sectionContent = []string{}
-			continue
-		}
-
-		// Extract metadata fields
-		if strings.HasPrefix(trimmedLine, "**Status:**") {
-			epic.Status = strings.TrimSpace(strings.TrimPrefix(trimmedLine, "**Status:**"))
-			continue
-		}
-
-		if strings.HasPrefix(trimmedLine, "**User Value:**") {
-			val := strings.TrimSpace(strings.TrimPrefix(trimmedLine, "**User Value:**"))
-			if v, err := strconv.Atoi(val); err == nil {
-				epic.UserValue = v
-			}
-			continue
-		}
-
-		if strings.HasPrefix(trimmedLine, "**Time Criticality:**") {
-			val := strings.TrimSpace(strings.TrimPrefix(trimmedLine, "**Time Criticality:**"))
-			if v, err := strconv.Atoi(val); err == nil {
-				epic.TimeCriticality = v
-			}
-			continue
-		}
-
-		if strings.HasPrefix(trimmedLine, "**Risk Reduction:**") {
-			val := strings.TrimSpace(strings.TrimPrefix(trimmedLine, "**Risk Reduction:**"))
-			if v, err := strconv.Atoi(val); err == nil {
-				epic.RiskReduction = v
-			}
-			continue
-		}
-
-		if strings.HasPrefix(trimmedLine, "**Job Size:**") {
-			val := strings.TrimSpace(strings.TrimPrefix(trimmedLine, "**Job Size:**"))
-			if v, err := strconv.Atoi(val); err == nil {
-				epic.JobSize = v
-			}
-			continue
-		}
-
-		// Collect section content
-		if currentSection != "" {
-			sectionContent = append(sectionContent, line)
-		} else if epic.Description == "" && trimmedLine != "" && !strings.HasPrefix(trimmedLine, "**") {
-			// First non-metadata paragraph is the description
-			epic.Description = trimmedLine
-		}
-	}
-
-	// Save last section
-	if currentSection != "" {
-		epic.Fields[currentSection] = strings.TrimSpace(strings.Join(sectionContent, "\n"))
-	}
-
-	// Calculate WSJF score
-	if epic.JobSize > 0 {
-		costOfDelay := float64(epic.UserValue + epic.TimeCriticality + epic.RiskReduction)
-		epic.WsjfScore = costOfDelay / float64(epic.JobSize)
+	e := spec.ParseEpic(content)
+	return FileEpic{
+		Filename:        filename,
+		Path:            path,
+		Name:            e.Name,
+		Description:     e.Description,
+		Status:          e.Status,
+		Content:         content,
+		Fields:          e.Sections,
+		UserValue:       e.UserValue,
+		TimeCriticality: e.TimeCriticality,
+		RiskReduction:   e.RiskReduction,
+		JobSize:         e.JobSize,
+		WsjfScore:       e.WSJF(),
 	}
-
-	return epic
 }
 
 // SaveEpicRequest represents the request to save an epic file
@@ -6155,7 +5958,26 @@ func (h *Handler) HandleSaveEpic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Build markdown content
+	// Existing files are edited in place so hand-written sections survive the save
+	content := renderEpicMarkdown(req)
+	if existing, err := os.ReadFile(req.Path); err == nil {
+		content = mergeEpicMarkdown(string(existing), req)
+	}
+
+	if err := os.WriteFile(req.Path, []byte(content), 0644); err != nil {
+		http.Error(w, fmt.Sprintf("failed to save file: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Epic saved successfully",
+	})
+}
+
+// renderEpicMarkdown builds a new epic file
+func renderEpicMarkdown(req SaveEpicRequest) string {
 	var content strings.Builder
 	content.WriteString(fmt.Sprintf("# %s\n\n", req.Name))
 
@@ -6207,16 +6029,45 @@ func (h *Handler) HandleSaveEpic(w http.ResponseWriter, r *http.Request) {
 		content.WriteString(req.Content + "\n")
 	}
 
-	if err := os.WriteFile(req.Path, []byte(content.String()), 0644); err != nil {
-		http.Error(w, fmt.Sprintf("failed to save file: %v", err), http.StatusInternalServerError)
-		return
+	return content.String()
+}
+
+// mergeEpicMarkdown applies a save request to an existing epic file, leaving
+// sections the request does not cover untouched
+func mergeEpicMarkdown(existing string, req SaveEpicRequest) string {
+	doc := spec.Parse(existing)
+	doc.SetTitle(req.Name)
+	doc.SetField("Status", req.Status)
+
+	doc.SetFieldIn(spec.WSJFSection, "User Value", strconv.Itoa(req.UserValue))
+	doc.SetFieldIn(spec.WSJFSection, "Time Criticality", strconv.Itoa(req.TimeCriticality))
+	doc.SetFieldIn(spec.WSJFSection, "Risk Reduction", strconv.Itoa(req.RiskReduction))
+	doc.SetFieldIn(spec.WSJFSection, "Job Size", strconv.Itoa(req.JobSize))
+	wsjf := "N/A"
+	if req.JobSize > 0 {
+		wsjf = fmt.Sprintf("%.1f", spec.WSJFScore(req.UserValue, req.TimeCriticality, req.RiskReduction, req.JobSize))
 	}
+	doc.SetFieldIn(spec.WSJFSection, "WSJF Score", wsjf)
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": "Epic saved successfully",
+	setSectionBodies(doc, [][2]string{
+		{"Description", req.Description},
+		{"Business Outcome", req.BusinessOutcome},
+		{"MVP Definition", req.MvpDefinition},
+		{"Acceptance Criteria", req.AcceptanceCriteria},
+		{"Additional Notes", req.Content},
 	})
+	return doc.String()
+}
+
+// setSectionBodies writes each {title, body} pair as a ## section. An empty
+// body clears a section the file has, but adds none, matching the templates,
+// which omit empty sections.
+func setSectionBodies(doc *spec.Document, sections [][2]string) {
+	for _, section := range sections {
+		if _, exists := doc.Section(section[0]); exists || section[1] != "" {
+			doc.SetSectionBody(section[0], 2, section[1])
+		}
+	}
 }
 
 // DeleteEpicRequest represents the request to delete an epic file
@@ -6302,6 +6153,9 @@ func (h *Handler) HandleThemeFiles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	specsPath := filepath.Join(cwd, workspacePath, "conception")
+	if !h.confinePath(w, r, specsPath) {
+		return
+	}
 
 	if _, err := os.Stat(specsPath); os.IsNotExist(err) {
 		w.Header().Set("Content-Type", "application/json")
@@ -6364,83 +6218,29 @@ func (h *Handler) HandleThemeFiles(w http.ResponseWriter, r *http.Request) {
 
 // parseThemeMarkdown parses a markdown file to extract theme information
 func parseThemeMarkdown(filename, path, content string) FileTheme {
+	t := spec.ParseTheme(content)
 	theme := FileTheme{
-		Filename:  filename,
-		Path:      path,
-		Content:   content,
-		Fields:    make(map[string]string),
-		ThemeType: "strategic-theme",
-	}
-
-	// Infer type from filename prefix
-	filenameUpper := strings.ToUpper(filename)
-	if strings.HasPrefix(filenameUpper, "VIS-") || strings.HasPrefix(filenameUpper, "VISION") {
-		theme.ThemeType = "vision"
-	} else if strings.HasPrefix(filenameUpper, "MKT-") {
-		theme.ThemeType = "market-context"
-	} else if strings.HasPrefix(filenameUpper, "STRAT-") || strings.HasPrefix(filenameUpper, "THEME") {
-		theme.ThemeType = "strategic-theme"
-	}
-
-	lines := strings.Split(content, "\n")
-	var currentSection string
-	var sectionContent []string
-
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-
-		// Extract title from # heading
-		if strings.HasPrefix(trimmedLine, "# ") {
-			theme.Name = strings.TrimPrefix(trimmedLine, "# ")
-			continue
-		}
-
-		// Extract section headers
-		if strings.HasPrefix(trimmedLine, "## ") {
-			// Save previous section
-			if currentSection != "" {
-				theme.Fields[currentSection] = strings.TrimSpace(strings.Join(sectionContent, "\n"))
-			}
-			currentSection = strings.TrimPrefix(trimmedLine, "## ")
-			sectionContent = []string{}
-			continue
-		}
-
-		// Extract metadata fields
-		if strings.HasPrefix(trimmedLine, "**Type:**") {
-			typeVal := strings.TrimSpace(strings.TrimPrefix(trimmedLine, "**Type:**"))
-			// Map display names back to internal type names
-			switch typeVal {
-			case "Vision Statement":
-				theme.ThemeType = "vision"
-			case "Market Context":
-				theme.ThemeType = "market-context"
-			case "Strategic Theme":
-				theme.ThemeType = "strategic-theme"
-			default:
-				theme.ThemeType = strings.ToLower(strings.ReplaceAll(typeVal, " ", "-"))
-			}
-			continue
-		}
-
-		if strings.HasPrefix(trimmedLine, "**Status:**") {
-			theme.Status = strings.TrimSpace(strings.TrimPrefix(trimmedLine, "**Status:**"))
-			continue
-		}
-
-		// Collect section content
-		if currentSection != "" {
-			sectionContent = append(sectionContent, line)
-		} else if theme.Description == "" && trimmedLine != "" && !strings.HasPrefix(trimmedLine, "**") {
-			theme.Description = trimmedLine
+		Filename:    filename,
+		Path:        path,
+		Name:        t.Name,
+		Description: t.Description,
+		Status:      t.Status,
+		Content:     content,
+		Fields:      t.Sections,
+		ThemeType:   t.ThemeType,
+	}
+
+	// Infer type from filename prefix when the file has no **Type:** field
+	if theme.ThemeType == "" {
+		filenameUpper := strings.ToUpper(filename)
+		theme.ThemeType = spec.ThemeTypeStrategic
+		if strings.HasPrefix(filenameUpper, "VIS-") || strings.HasPrefix(filenameUpper, "VISION") {
+			theme.ThemeType = spec.ThemeTypeVision
+		} else if strings.HasPrefix(filenameUpper, "MKT-") {
+			theme.ThemeType = spec.ThemeTypeMarketContext
 		}
 	}
 
-	// Save last section
-	if currentSection != "" {
-		theme.Fields[currentSection] = strings.TrimSpace(strings.Join(sectionContent, "\n"))
-	}
-
 	return theme
 }
 
@@ -6483,6 +6283,9 @@ func (h *Handler) HandleSaveTheme(w http.ResponseWriter, r *http.Request) {
 		}
 		filePath = filepath.Join(cwd, relativePath)
 	}
+	if !h.confinePath(w, r, filePath) {
+		return
+	}
 
 	// Ensure parent directory exists
 	dir := filepath.Dir(filePath)
@@ -6491,22 +6294,33 @@ func (h *Handler) HandleSaveTheme(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Format theme type for display
-	themeTypeDisplay := "Strategic Theme"
-	switch req.ThemeType {
-	case "vision":
-		themeTypeDisplay = "Vision Statement"
-	case "market-context":
-		themeTypeDisplay = "Market Context"
+	// Existing files are edited in place so hand-written sections survive the save
+	content := renderThemeMarkdown(req)
+	if existing, err := os.ReadFile(filePath); err == nil {
+		content = mergeThemeMarkdown(string(existing), req)
+	}
+
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		http.Error(w, fmt.Sprintf("failed to save file: %v", err), http.StatusInternalServerError)
+		return
 	}
 
-	// Build markdown content
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Theme saved successfully",
+		"path":    filePath,
+	})
+}
+
+// renderThemeMarkdown builds a new theme file
+func renderThemeMarkdown(req SaveThemeRequest) string {
 	var content strings.Builder
 	content.WriteString(fmt.Sprintf("# %s\n\n", req.Name))
 
 	// Metadata section
 	content.WriteString("## Metadata\n")
-	content.WriteString(fmt.Sprintf("**Type:** %s\n", themeTypeDisplay))
+	content.WriteString(fmt.Sprintf("**Type:** %s\n", spec.ThemeTypeDisplay(req.ThemeType)))
 	content.WriteString(fmt.Sprintf("**Generated:** %s\n\n", time.Now().Format("01/02/2006")))
 
 	// Description
@@ -6545,17 +6359,25 @@ func (h *Handler) HandleSaveTheme(w http.ResponseWriter, r *http.Request) {
 		content.WriteString(req.Content + "\n")
 	}
 
-	if err := os.WriteFile(filePath, []byte(content.String()), 0644); err != nil {
-		http.Error(w, fmt.Sprintf("failed to save file: %v", err), http.StatusInternalServerError)
-		return
-	}
+	return content.String()
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": "Theme saved successfully",
-		"path":    filePath,
+// mergeThemeMarkdown applies a save request to an existing theme file, leaving
+// sections the request does not cover untouched
+func mergeThemeMarkdown(existing string, req SaveThemeRequest) string {
+	doc := spec.Parse(existing)
+	doc.SetTitle(req.Name)
+	doc.SetField("Type", spec.ThemeTypeDisplay(req.ThemeType))
+
+	setSectionBodies(doc, [][2]string{
+		{"Description", req.Description},
+		{"Target Outcomes", req.TargetOutcomes},
+		{"Key Metrics", req.KeyMetrics},
+		{"Time Horizon", req.TimeHorizon},
+		{"Stakeholders", req.Stakeholders},
+		{"Additional Notes", req.Content},
 	})
+	return doc.String()
 }
 
 // DeleteThemeRequest represents the request to delete a theme file
@@ -6589,6 +6411,9 @@ func (h *Handler) HandleDeleteTheme(w http.ResponseWriter, r *http.Request) {
 		}
 		filePath = filepath.Join(cwd, relativePath)
 	}
+	if !h.confinePath(w, r, filePath) {
+		return
+	}
 
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		http.Error(w, "file not found", http.StatusNotFound)
@@ -6697,68 +6522,18 @@ func (h *Handler) HandleFeatureFiles(w http.ResponseWriter, r *http.Request) {
 
 // parseFeatureMarkdown parses a markdown file to extract feature information
 func parseFeatureMarkdown(filename, path, content string) FileFeature {
-	feature := FileFeature{
-		Filename: filename,
-		Path:     path,
-		Content:  content,
-		Fields:   make(map[string]string),
-		Status:   "Planned",
-	}
-
-	lines := strings.Split(content, "\n")
-	var currentSection string
-	var sectionContent []string
-
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-
-		// Extract title from # heading
-		if strings.HasPrefix(trimmedLine, "# ") {
-			feature.Name = strings.TrimPrefix(trimmedLine, "# ")
-			continue
-		}
-
-		// Extract section headers
-		if strings.HasPrefix(trimmedLine, "## ") {
-			// Save previous section
-			if currentSection != "" {
-				feature.Fields[currentSection] = strings.TrimSpace(strings.Join(sectionContent, "\n"))
-			}
-			currentSection = strings.TrimPrefix(trimmedLine, "## ")
-			sectionContent = []string{}
-			continue
-		}
-
-		// Extract metadata fields
-		if strings.HasPrefix(trimmedLine, "**Status:**") {
-			feature.Status = strings.TrimSpace(strings.TrimPrefix(trimmedLine, "**Status:**"))
-			continue
-		}
-
-		if strings.HasPrefix(trimmedLine, "**Parent Epic:**") {
-			feature.ParentEpic = strings.TrimSpace(strings.TrimPrefix(trimmedLine, "**Parent Epic:**"))
-			continue
-		}
-
-		// Collect section content
-		if currentSection != "" {
-			sectionContent = append(sectionContent, line)
-		} else if feature.Description == "" && trimmedLine != "" && !strings.HasPrefix(trimmedLine, "**") {
-			feature.Description = trimmedLine
-		}
-	}
-
-	// Save last section
-	if currentSection != "" {
-		feature.Fields[currentSection] = strings.TrimSpace(strings.Join(sectionContent, "\n"))
-	}
-
-	// Extract benefit hypothesis from fields if present
-	if bh, ok := feature.Fields["Benefit Hypothesis"]; ok {
-		feature.BenefitHypothesis = bh
+	f := spec.ParseFeature(content)
+	return FileFeature{
+		Filename:          filename,
+		Path:              path,
+		Name:              f.Name,
+		Description:       f.Description,
+		Status:            f.Status,
+		Content:           content,
+		Fields:            f.Sections,
+		ParentEpic:        f.ParentEpic,
+		BenefitHypothesis: f.BenefitHypothesis,
 	}
-
-	return feature
 }
 
 // SaveFeatureRequest represents the request to save a feature file
@@ -6793,7 +6568,26 @@ func (h *Handler) HandleSaveFeature(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Build markdown content
+	// Existing files are edited in place so hand-written sections survive the save
+	content := renderFeatureMarkdown(req)
+	if existing, err := os.ReadFile(req.Path); err == nil {
+		content = mergeFeatureMarkdown(string(existing), req)
+	}
+
+	if err := os.WriteFile(req.Path, []byte(content), 0644); err != nil {
+		http.Error(w, fmt.Sprintf("failed to save file: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Feature saved successfully",
+	})
+}
+
+// renderFeatureMarkdown builds a new feature file
+func renderFeatureMarkdown(req SaveFeatureRequest) string {
 	var content strings.Builder
 	content.WriteString(fmt.Sprintf("# %s\n\n", req.Name))
 
@@ -6829,16 +6623,26 @@ func (h *Handler) HandleSaveFeature(w http.ResponseWriter, r *http.Request) {
 		content.WriteString(req.Content + "\n")
 	}
 
-	if err := os.WriteFile(req.Path, []byte(content.String()), 0644); err != nil {
-		http.Error(w, fmt.Sprintf("failed to save file: %v", err), http.StatusInternalServerError)
-		return
+	return content.String()
+}
+
+// mergeFeatureMarkdown applies a save request to an existing feature file,
+// leaving sections the request does not cover untouched
+func mergeFeatureMarkdown(existing string, req SaveFeatureRequest) string {
+	doc := spec.Parse(existing)
+	doc.SetTitle(req.Name)
+	doc.SetField("Status", req.Status)
+	if req.ParentEpic != "" {
+		doc.SetField("Parent Epic", req.ParentEpic)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": "Feature saved successfully",
+	setSectionBodies(doc, [][2]string{
+		{"Description", req.Description},
+		{"Benefit Hypothesis", req.BenefitHypothesis},
+		{"Acceptance Criteria", req.AcceptanceCriteria},
+		{"Additional Notes", req.Content},
 	})
+	return doc.String()
 }
 
 // DeleteFeatureRequest represents the request to delete a feature file
@@ -6920,11 +6724,53 @@ func (h *Handler) HandleSaveWorkspaceConfig(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Ensure path is within workspaces directory for security
-	if !strings.HasPrefix(req.Config.ProjectFolder, "workspaces") {
+	if h.withinWorkspacesRoot(req.Config.ProjectFolder) != nil {
 		http.Error(w, "projectFolder must be within workspaces directory", http.StatusBadRequest)
 		return
 	}
 
+	// Workspace roles are keyed by the ID, so only admins may change it
+	if existing := workspaceIDForPath(req.Config.ProjectFolder); existing != "" && existing != req.Config.ID {
+		if claims, ok := r.Context().Value("claims").(*auth.Claims); !ok || !claims.IsAdmin() {
+			http.Error(w, fmt.Sprintf("workspace ID %s cannot be changed", existing), http.StatusConflict)
+			return
+		}
+	}
+
+	// Writing the first .intentrworkspace file creates the workspace, which
+	// its creator owns. A folder can only be added to an existing workspace
+	// by its owners, and an ID already used by another folder or by rows in
+	// the database is an existing workspace even if it has no members yet.
+	if workspaceIDForPath(req.Config.ProjectFolder) == "" {
+		claims, ok := r.Context().Value("claims").(*auth.Claims)
+		if !ok {
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+		if req.Config.ID == "" {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+		var err error
+		if h.workspaces != nil && h.workspaceFolder(req.Config.ID) != "" {
+			err = h.workspaces.Require(req.Config.ID, claims.UserID, models.WorkspaceRoleOwner)
+		} else if h.workspaces != nil {
+			err = h.workspaces.ClaimWorkspace(req.Config.ID, claims.UserID)
+		} else if !claims.IsAdmin() {
+			http.Error(w, errNoWorkspaceRoles, http.StatusServiceUnavailable)
+			return
+		}
+		var accessErr *models.WorkspaceAccessError
+		if errors.As(err, &accessErr) {
+			http.Error(w, accessErr.Error(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to record workspace owner: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	// Set version if not provided
 	if req.Config.Version == "" {
 		req.Config.Version = "1.0"
@@ -6977,7 +6823,7 @@ type ScanWorkspacesResponse struct {
 // HandleScanWorkspaces handles GET /workspace-config/scan
 // Scans the ./workspaces folder for subfolders with .intentrworkspace files
 func (h *Handler) HandleScanWorkspaces(w http.ResponseWriter, r *http.Request) {
-	basePath := "workspaces"
+	basePath := h.workspacesRoot
 
 	// Check if workspaces folder exists
 	if _, err := os.Stat(basePath); os.IsNotExist(err) {
@@ -7026,6 +6872,10 @@ func (h *Handler) HandleScanWorkspaces(w http.ResponseWriter, r *http.Request) {
 			if err == nil {
 				var config WorkspaceConfig
 				if err := json.Unmarshal(configData, &config); err == nil {
+					// Only list workspaces the user may access
+					if config.ID != "" && !h.canAccessWorkspace(r, config.ID) {
+						continue
+					}
 					scannedWorkspace.Config = &config
 					scannedWorkspace.HasConfig = true
 				}
@@ -7052,7 +6902,7 @@ func (h *Handler) HandleGetWorkspaceConfig(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Ensure path is within workspaces directory for security
-	if !strings.HasPrefix(folderPath, "workspaces") {
+	if h.withinWorkspacesRoot(folderPath) != nil {
 		http.Error(w, "path must be within workspaces directory", http.StatusBadRequest)
 		return
 	}
@@ -7082,154 +6932,50 @@ func (h *Handler) HandleGetWorkspaceConfig(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(config)
 }
 
+var (
+	capabilityIDPattern  = regexp.MustCompile(`^CAP-\d+`)
+	enablerIDPattern     = regexp.MustCompile(`^ENB-\d+`)
+	capabilityRefPattern = regexp.MustCompile(`CAP-\d+`)
+	fallbackIDPattern    = regexp.MustCompile(`(\d{4,})`)
+)
+
 // parseCapabilityFromContent extracts capability information from markdown content
 func parseCapabilityFromContent(filename, content string) CapabilitySpec {
+	parsed := spec.ParseCapability(content)
 	cap := CapabilitySpec{
 		Type:                 "Capability",
-		Status:               "Planned",
+		Name:                 specName(parsed.Doc, filename),
+		ID:                   capabilityIDPattern.FindString(parsed.ID),
+		Status:               parsed.Status,
 		Enablers:             []string{},
 		UpstreamDependencies: []string{},
 		DownstreamImpacts:    []string{},
 	}
-
-	lines := strings.Split(content, "\n")
-
-	// Extract name from first # heading
-	for _, line := range lines {
-		if strings.HasPrefix(line, "# ") {
-			cap.Name = strings.TrimPrefix(line, "# ")
-			cap.Name = strings.TrimSpace(cap.Name)
-			break
-		}
-	}
-
-	// If no heading found, use filename
-	if cap.Name == "" {
-		baseName := strings.TrimSuffix(filename, ".md")
-		cap.Name = strings.ReplaceAll(baseName, "-", " ")
-		cap.Name = strings.ReplaceAll(cap.Name, "_", " ")
-	}
-
-	// Extract metadata fields using regex
-	idPattern := regexp.MustCompile(`\*\*ID\*\*:\s*(CAP-\d+)`)
-	statusPattern := regexp.MustCompile(`\*\*Status\*\*:\s*(.+)`)
-	namePattern := regexp.MustCompile(`\*\*Name\*\*:\s*(.+)`)
-
-	if match := idPattern.FindStringSubmatch(content); len(match) > 1 {
-		cap.ID = strings.TrimSpace(match[1])
-	}
-
-	if match := statusPattern.FindStringSubmatch(content); len(match) > 1 {
-		cap.Status = strings.TrimSpace(match[1])
-	}
-
-	if match := namePattern.FindStringSubmatch(content); len(match) > 1 {
-		cap.Name = strings.TrimSpace(match[1])
+	if cap.Status == "" {
+		cap.Status = "Planned"
 	}
-
-	// If no ID found, generate from filename
 	if cap.ID == "" {
-		// Extract numeric part from filename like "123456-capability.md" or "CAP-123456.md"
-		numPattern := regexp.MustCompile(`(\d{4,})`)
-		if match := numPattern.FindStringSubmatch(filename); len(match) > 1 {
-			cap.ID = "CAP-" + match[1]
-		} else {
-			// Fallback: generate a hash-based ID
-			cap.ID = fmt.Sprintf("CAP-%06d", hashString(filename)%1000000)
-		}
+		cap.ID = fallbackSpecID("CAP", filename)
 	}
 
-	// Parse enablers table
-	// Look for ## Enablers section and parse the table
-	inEnablersSection := false
-	inTable := false
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-
-		if strings.HasPrefix(trimmedLine, "## Enablers") {
-			inEnablersSection = true
-			continue
-		}
-
-		if inEnablersSection && strings.HasPrefix(trimmedLine, "##") && !strings.HasPrefix(trimmedLine, "## Enablers") {
-			inEnablersSection = false
-			continue
-		}
-
-		if inEnablersSection && strings.HasPrefix(trimmedLine, "|") {
-			inTable = true
-			// Skip header and separator rows
-			if strings.Contains(trimmedLine, "---") || strings.Contains(strings.ToLower(trimmedLine), "| id") {
-				continue
-			}
-
-			// Parse table row for enabler IDs
-			parts := strings.Split(trimmedLine, "|")
-			for _, part := range parts {
-				part = strings.TrimSpace(part)
-				if strings.HasPrefix(part, "ENB-") {
-					cap.Enablers = append(cap.Enablers, part)
-				}
+	// Enabler IDs are the ENB- cells of the Enablers table
+	for _, row := range tableRows(parsed.Doc, func(title string) bool { return strings.HasPrefix(title, "Enablers") }, "| id") {
+		for _, cell := range strings.Split(row, "|") {
+			if cell = strings.TrimSpace(cell); strings.HasPrefix(cell, "ENB-") {
+				cap.Enablers = append(cap.Enablers, cell)
 			}
-		} else if inTable && !strings.HasPrefix(trimmedLine, "|") {
-			inTable = false
 		}
 	}
 
-	// Parse upstream dependencies table
-	inUpstreamSection := false
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-
-		if strings.Contains(trimmedLine, "Internal Upstream Dependency") || strings.Contains(trimmedLine, "Upstream Dependencies") {
-			inUpstreamSection = true
-			continue
-		}
-
-		if inUpstreamSection && strings.HasPrefix(trimmedLine, "###") && !strings.Contains(trimmedLine, "Upstream") {
-			inUpstreamSection = false
-			continue
-		}
-
-		if inUpstreamSection && strings.HasPrefix(trimmedLine, "|") {
-			// Skip header and separator rows
-			if strings.Contains(trimmedLine, "---") || strings.Contains(strings.ToLower(trimmedLine), "capability id") {
-				continue
-			}
-
-			// Parse table row for capability IDs
-			capIDPattern := regexp.MustCompile(`CAP-\d+`)
-			matches := capIDPattern.FindAllString(trimmedLine, -1)
-			cap.UpstreamDependencies = append(cap.UpstreamDependencies, matches...)
-		}
+	for _, row := range tableRows(parsed.Doc, func(title string) bool {
+		return strings.Contains(title, "Internal Upstream Dependency") || strings.Contains(title, "Upstream Dependencies")
+	}, "capability id") {
+		cap.UpstreamDependencies = append(cap.UpstreamDependencies, capabilityRefPattern.FindAllString(row, -1)...)
 	}
-
-	// Parse downstream impacts table
-	inDownstreamSection := false
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-
-		if strings.Contains(trimmedLine, "Internal Downstream Impact") || strings.Contains(trimmedLine, "Downstream Impacts") {
-			inDownstreamSection = true
-			continue
-		}
-
-		if inDownstreamSection && strings.HasPrefix(trimmedLine, "###") && !strings.Contains(trimmedLine, "Downstream") {
-			inDownstreamSection = false
-			continue
-		}
-
-		if inDownstreamSection && strings.HasPrefix(trimmedLine, "|") {
-			// Skip header and separator rows
-			if strings.Contains(trimmedLine, "---") || strings.Contains(strings.ToLower(trimmedLine), "capability id") {
-				continue
-			}
-
-			// Parse table row for capability IDs
-			capIDPattern := regexp.MustCompile(`CAP-\d+`)
-			matches := capIDPattern.FindAllString(trimmedLine, -1)
-			cap.DownstreamImpacts = append(cap.DownstreamImpacts, matches...)
-		}
+	for _, row := range tableRows(parsed.Doc, func(title string) bool {
+		return strings.Contains(title, "Internal Downstream Impact") || strings.Contains(title, "Downstream Impacts")
+	}, "capability id") {
+		cap.DownstreamImpacts = append(cap.DownstreamImpacts, capabilityRefPattern.FindAllString(row, -1)...)
 	}
 
 	return cap
@@ -7237,64 +6983,64 @@ func parseCapabilityFromContent(filename, content string) CapabilitySpec {
 
 // parseEnablerFromContent extracts enabler information from markdown content
 func parseEnablerFromContent(filename, content string) EnablerSpec {
+	parsed := spec.ParseEnabler(content)
 	enb := EnablerSpec{
-		Type:   "Enabler",
-		Status: "Planned",
-	}
-
-	lines := strings.Split(content, "\n")
-
-	// Extract name from first # heading
-	for _, line := range lines {
-		if strings.HasPrefix(line, "# ") {
-			enb.Name = strings.TrimPrefix(line, "# ")
-			enb.Name = strings.TrimSpace(enb.Name)
-			break
-		}
+		Type:         "Enabler",
+		Name:         specName(parsed.Doc, filename),
+		ID:           enablerIDPattern.FindString(parsed.ID),
+		CapabilityID: capabilityIDPattern.FindString(parsed.CapabilityID),
+		Status:       parsed.Status,
 	}
-
-	// If no heading found, use filename
-	if enb.Name == "" {
-		baseName := strings.TrimSuffix(filename, ".md")
-		enb.Name = strings.ReplaceAll(baseName, "-", " ")
-		enb.Name = strings.ReplaceAll(enb.Name, "_", " ")
+	if enb.Status == "" {
+		enb.Status = "Planned"
 	}
-
-	// Extract metadata fields using regex
-	idPattern := regexp.MustCompile(`\*\*ID\*\*:\s*(ENB-\d+)`)
-	capIDPattern := regexp.MustCompile(`\*\*Capability ID\*\*:\s*(CAP-\d+)`)
-	statusPattern := regexp.MustCompile(`\*\*Status\*\*:\s*(.+)`)
-	namePattern := regexp.MustCompile(`\*\*Name\*\*:\s*(.+)`)
-
-	if match := idPattern.FindStringSubmatch(content); len(match) > 1 {
-		enb.ID = strings.TrimSpace(match[1])
+	if enb.ID == "" {
+		enb.ID = fallbackSpecID("ENB", filename)
 	}
+	return enb
+}
 
-	if match := capIDPattern.FindStringSubmatch(content); len(match) > 1 {
-		enb.CapabilityID = strings.TrimSpace(match[1])
+// specName returns a specification's Name field, its # heading, or else a
+// name made from its filename
+func specName(doc *spec.Document, filename string) string {
+	if name := doc.FieldValue("Name"); name != "" {
+		return name
 	}
-
-	if match := statusPattern.FindStringSubmatch(content); len(match) > 1 {
-		enb.Status = strings.TrimSpace(match[1])
+	if title := doc.Title(); title != "" {
+		return title
 	}
+	name := strings.TrimSuffix(filename, ".md")
+	name = strings.ReplaceAll(name, "-", " ")
+	return strings.ReplaceAll(name, "_", " ")
+}
 
-	if match := namePattern.FindStringSubmatch(content); len(match) > 1 {
-		enb.Name = strings.TrimSpace(match[1])
+// fallbackSpecID makes an ID for a specification without one from the number
+// in its filename, like "123456-capability.md", or from a hash of the filename
+func fallbackSpecID(prefix, filename string) string {
+	if match := fallbackIDPattern.FindStringSubmatch(filename); len(match) > 1 {
+		return prefix + "-" + match[1]
 	}
+	return fmt.Sprintf("%s-%06d", prefix, hashString(filename)%1000000)
+}
 
-	// If no ID found, generate from filename
-	if enb.ID == "" {
-		// Extract numeric part from filename like "654321-enabler.md" or "ENB-654321.md"
-		numPattern := regexp.MustCompile(`(\d{4,})`)
-		if match := numPattern.FindStringSubmatch(filename); len(match) > 1 {
-			enb.ID = "ENB-" + match[1]
-		} else {
-			// Fallback: generate a hash-based ID
-			enb.ID = fmt.Sprintf("ENB-%06d", hashString(filename)%1000000)
+// tableRows returns the table rows in the own body of every section whose
+// title matches, skipping separator rows and header rows containing header
+func tableRows(doc *spec.Document, match func(title string) bool, header string) []string {
+	lines := doc.Lines()
+	var rows []string
+	for _, s := range doc.Sections() {
+		if s.Level < 2 || !match(s.Title) {
+			continue
+		}
+		for _, line := range lines[s.Line+1 : s.Own] {
+			line = strings.TrimSpace(line)
+			if !strings.HasPrefix(line, "|") || strings.Contains(line, "---") || strings.Contains(strings.ToLower(line), header) {
+				continue
+			}
+			rows = append(rows, line)
 		}
 	}
-
-	return enb
+	return rows
 }
 
 // hashString creates a simple hash for generating fallback IDs
@@ -7328,10 +7074,14 @@ func (h *Handler) HandleFetchJiraEpics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Credentials == nil || len(req.Credentials) == 0 {
-		http.Error(w, "credentials are required", http.StatusBadRequest)
+	if req.IntegrationName == "" {
+		req.IntegrationName = "Jira"
+	}
+	credentials, ok := h.requireCredentials(w, r, req.IntegrationName)
+	if !ok {
 		return
 	}
+	req.Credentials = credentials
 
 	resp, err := FetchJiraEpics(r.Context(), req)
 	if err != nil {
@@ -7345,10 +7095,9 @@ func (h *Handler) HandleFetchJiraEpics(w http.ResponseWriter, r *http.Request) {
 
 // ImportJiraEpicsRequest is the request to import Jira Epics as Capabilities
 type ImportJiraEpicsRequest struct {
-	WorkspacePath string            `json:"workspace_path"`
-	ProjectKey    string            `json:"project_key"`
-	Epics         []JiraEpic        `json:"epics"`
-	Credentials   map[string]string `json:"credentials"`
+	WorkspacePath string `json:"workspace_path"`
+	ProjectKey    string `json:"project_key"`
+	Epics         []Epic `json:"epics"`
 }
 
 // ImportJiraEpicsResponse is the response from importing Jira Epics
@@ -7391,12 +7140,18 @@ func (h *Handler) HandleImportJiraEpics(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Ensure definition directory exists
-	defsDir := filepath.Join(req.WorkspacePath, "definition")
-	if err := os.MkdirAll(defsDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Join(req.WorkspacePath, "definition"), 0755); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to create definitions directory: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	// Imported capabilities are linked to their epics for Jira sync
+	workspaceID := workspaceIDForPath(req.WorkspacePath)
+	var userID *int
+	if claims, ok := r.Context().Value("claims").(*auth.Claims); ok {
+		userID = &claims.UserID
+	}
+
 	response := ImportJiraEpicsResponse{
 		Imported: make([]struct {
 			JiraKey      string `json:"jira_key"`
@@ -7410,42 +7165,14 @@ func (h *Handler) HandleImportJiraEpics(w http.ResponseWriter, r *http.Request)
 	}
 
 	for _, epic := range req.Epics {
-		// Generate a unique capability ID
-		capID := generateCapabilityID()
-
-		// Map Jira status to INTENT status
-		intentStatus := mapJiraStatusToINTENT(epic.Status)
-
-		// Map Jira priority to INTENT priority
-		intentPriority := mapJiraPriorityToINTENT(epic.Priority)
-
-		// Create capability markdown content
-		content := generateCapabilityMarkdown(capID, epic, intentStatus, intentPriority)
-
-		// Generate filename (format: CAP-XXXXXX.md)
-		filename := fmt.Sprintf("%s.md", capID)
-		filePath := filepath.Join(defsDir, filename)
-
-		// Check if file already exists
-		if _, err := os.Stat(filePath); err == nil {
-			response.Errors = append(response.Errors, struct {
-				JiraKey string `json:"jira_key"`
-				Error   string `json:"error"`
-			}{
-				JiraKey: epic.Key,
-				Error:   fmt.Sprintf("File already exists: %s", filename),
-			})
-			continue
-		}
-
-		// Write the file
-		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		capID, filename, err := h.importEpic(req.WorkspacePath, epic, JiraIntegration)
+		if err != nil {
 			response.Errors = append(response.Errors, struct {
 				JiraKey string `json:"jira_key"`
 				Error   string `json:"error"`
 			}{
 				JiraKey: epic.Key,
-				Error:   fmt.Sprintf("Failed to write file: %v", err),
+				Error:   err.Error(),
 			})
 			continue
 		}
@@ -7459,6 +7186,8 @@ func (h *Handler) HandleImportJiraEpics(w http.ResponseWriter, r *http.Request)
 			CapabilityID: capID,
 			Filename:     filename,
 		})
+
+		h.linkImportedJiraEpic(workspaceID, capID, epic, userID)
 	}
 
 	response.TotalImported = len(response.Imported)
@@ -7468,37 +7197,118 @@ func (h *Handler) HandleImportJiraEpics(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(response)
 }
 
-// generateCapabilityID generates a unique CAP-XXXXXX ID
-func generateCapabilityID() string {
-	// Use timestamp + random component for uniqueness
-	now := time.Now()
-	timeComponent := now.UnixNano() % 10000
-	randomComponent := now.Nanosecond() % 100
-	combined := (int(timeComponent)*100 + randomComponent) % 1000000
-	return fmt.Sprintf("CAP-%06d", combined)
+// allocateID reserves the next free ID for prefix, above the IDs in a
+// workspace folder's files
+func (h *Handler) allocateID(workspaceDir, prefix string) (string, error) {
+	if abs, err := filepath.Abs(workspaceDir); err == nil {
+		workspaceDir = abs
+	}
+	return h.ids.Next(workspaceDir, prefix)
+}
+
+// workspaceDirForSpec returns the workspace folder that holds a spec file,
+// i.e. the parent of its definition/, conception/, test/ or specifications/ folder
+func workspaceDirForSpec(path string) string {
+	dir := filepath.Dir(path)
+	for d := dir; ; {
+		switch filepath.Base(d) {
+		case "definition", "conception", "test", "specifications", "implementation":
+			return filepath.Dir(d)
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// AllocateIDsRequest represents a request to reserve new specification IDs
+type AllocateIDsRequest struct {
+	WorkspacePath string `json:"workspacePath"`
+	Prefix        string `json:"prefix"` // CAP, ENB, FR, NFR or TS
+	Count         int    `json:"count"`  // Defaults to 1
+}
+
+// HandleAllocateIDs handles POST /ids/allocate
+func (h *Handler) HandleAllocateIDs(w http.ResponseWriter, r *http.Request) {
+	var req AllocateIDsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.WorkspacePath == "" {
+		http.Error(w, "workspacePath is required", http.StatusBadRequest)
+		return
+	}
+
+	req.Prefix = strings.ToUpper(req.Prefix)
+	if !idalloc.ValidPrefix(req.Prefix) {
+		http.Error(w, fmt.Sprintf("prefix must be one of: %s", strings.Join(idalloc.Prefixes, ", ")), http.StatusBadRequest)
+		return
+	}
+
+	if req.Count <= 0 {
+		req.Count = 1
+	}
+	if req.Count > 100 {
+		http.Error(w, "count must be at most 100", http.StatusBadRequest)
+		return
+	}
+
+	// If path contains "workspaces/", extract the relative part
+	workspacePath := req.WorkspacePath
+	if idx := strings.Index(workspacePath, "workspaces/"); idx != -1 {
+		workspacePath = workspacePath[idx:]
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get working directory: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if !h.confinePath(w, r, filepath.Join(cwd, workspacePath)) {
+		return
+	}
+	ids, err := h.ids.NextN(filepath.Join(cwd, workspacePath), req.Prefix, req.Count)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to allocate IDs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"ids":     ids,
+	})
 }
 
-// mapJiraStatusToINTENT maps Jira status to INTENT capability status
-func mapJiraStatusToINTENT(jiraStatus string) string {
-	statusLower := strings.ToLower(jiraStatus)
+// mapEpicStatusToINTENT maps a Jira status, GitLab epic state or Azure DevOps
+// work item state to INTENT capability status
+func mapEpicStatusToINTENT(epicStatus string) string {
+	statusLower := strings.ToLower(epicStatus)
 
 	switch {
-	case strings.Contains(statusLower, "done") || strings.Contains(statusLower, "complete"):
+	case strings.Contains(statusLower, "done") || strings.Contains(statusLower, "complete") ||
+		strings.Contains(statusLower, "closed") || strings.Contains(statusLower, "resolved"):
 		return "Implemented"
 	case strings.Contains(statusLower, "in progress") || strings.Contains(statusLower, "active"):
 		return "In Implementation"
 	case strings.Contains(statusLower, "review"):
 		return "Ready for Design"
-	case strings.Contains(statusLower, "backlog") || strings.Contains(statusLower, "to do"):
+	case strings.Contains(statusLower, "backlog") || strings.Contains(statusLower, "to do") ||
+		statusLower == "new" || statusLower == "opened":
 		return "Ready for Analysis"
 	default:
 		return "In Draft"
 	}
 }
 
-// mapJiraPriorityToINTENT maps Jira priority to INTENT priority
-func mapJiraPriorityToINTENT(jiraPriority string) string {
-	priorityLower := strings.ToLower(jiraPriority)
+// mapEpicPriorityToINTENT maps an epic's priority to INTENT priority
+func mapEpicPriorityToINTENT(epicPriority string) string {
+	priorityLower := strings.ToLower(epicPriority)
 
 	switch {
 	case strings.Contains(priorityLower, "highest") || strings.Contains(priorityLower, "critical"):
@@ -7512,11 +7322,17 @@ func mapJiraPriorityToINTENT(jiraPriority string) string {
 	}
 }
 
-// generateCapabilityMarkdown generates the capability markdown content from a Jira Epic
-func generateCapabilityMarkdown(capID string, epic JiraEpic, status, priority string) string {
+// generateCapabilityMarkdown generates the capability markdown content from
+// an epic of source, the integration it was imported from
+func generateCapabilityMarkdown(capID string, epic Epic, source, status, priority string) string {
 	// Escape any markdown special characters in the description
 	description := strings.ReplaceAll(epic.Description, "\n", "\n\n")
 
+	issueType := epic.IssueType
+	if issueType == "" {
+		issueType = "Epic"
+	}
+
 	// Format labels as comma-separated
 	labels := strings.Join(epic.Labels, ", ")
 	if labels == "" {
@@ -7529,13 +7345,13 @@ func generateCapabilityMarkdown(capID string, epic JiraEpic, status, priority st
 - **Name**: %s
 - **Type**: Capability
 - **ID**: %s
-- **Owner**: Imported from Jira
+- **Owner**: Imported from %s
 - **Status**: %s
 - **Approval**: Pending
 - **Priority**: %s
 - **Analysis Review**: Required
-- **Jira Key**: %s
-- **Jira URL**: %s
+- **%s Key**: %s
+- **%s URL**: %s
 - **Labels**: %s
 
 ## Business Context
@@ -7572,7 +7388,7 @@ func generateCapabilityMarkdown(capID string, epic JiraEpic, status, priority st
 - [What is NOT included]
 
 ### Assumptions
-- Imported from Jira Epic: %s
+- Imported from %s %s: %s
 
 ### Constraints
 - [Technical, business, or regulatory limits]
@@ -7608,20 +7424,21 @@ func generateCapabilityMarkdown(capID string, epic JiraEpic, status, priority st
 ## Approval History
 | Date | Stage | Decision | By | Feedback |
 |------|-------|----------|-----|----------|
-| %s | Import | Created | System | Imported from Jira Epic %s |
+| %s | Import | Created | System | Imported from %s %s %s |
 `,
 		epic.Summary,                              // Title
 		epic.Summary,                              // Name in metadata
 		capID,                                     // ID
+		source,                                    // Owner
 		status,                                    // Status
 		priority,                                  // Priority
-		epic.Key,                                  // Jira Key
-		epic.URL,                                  // Jira URL
+		source, epic.Key,                          // Source Key
+		source, epic.URL,                          // Source URL
 		labels,                                    // Labels
 		description,                               // Problem Statement / Description
-		epic.Key,                                  // Assumptions - Jira Key reference
+		source, issueType, epic.Key,               // Assumptions - source key reference
 		time.Now().Format("2006-01-02"),           // Approval History date
-		epic.Key,                                  // Approval History - Jira key
+		source, issueType, epic.Key,               // Approval History - source key
 	)
 }
 
@@ -7630,7 +7447,6 @@ type SyncCode2SpecRequest struct {
 	WorkspacePath string   `json:"workspacePath"`
 	CodeChanges   string   `json:"codeChanges"`
 	FileList      []string `json:"fileList"`
-	AnthropicKey  string   `json:"anthropicKey"`
 }
 
 // SyncCode2SpecResponse represents the response from the sync analysis
@@ -7659,8 +7475,8 @@ func (h *Handler) HandleSyncCode2Spec(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.AnthropicKey == "" {
-		http.Error(w, "anthropicKey is required", http.StatusBadRequest)
+	apiKey, ok := h.requireAnthropicKey(w, r)
+	if !ok {
 		return
 	}
 
@@ -7672,6 +7488,9 @@ func (h *Handler) HandleSyncCode2Spec(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Read enabler specifications
+	if !h.confinePath(w, r, filepath.Join(cwd, req.WorkspacePath)) {
+		return
+	}
 	enablerSpecs, err := h.readSpecificationFiles(filepath.Join(cwd, req.WorkspacePath, "definition"), "enabler")
 	if err != nil {
 		log.Printf("Warning: Failed to read enabler specs: %v", err)
@@ -7689,7 +7508,7 @@ func (h *Handler) HandleSyncCode2Spec(w http.ResponseWriter, r *http.Request) {
 	prompt := h.loadSyncCode2SpecPrompt(cwd, req.WorkspacePath, enablerSpecs, capabilitySpecs, req.CodeChanges, strings.Join(req.FileList, "\n"))
 
 	// Create Anthropic client and call API
-	client := NewAnthropicClient(req.AnthropicKey)
+	client := NewAnthropicClient(apiKey)
 	response, err := client.SendMessage(r.Context(), prompt)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
@@ -7879,6 +7698,9 @@ func (h *Handler) HandleGetCodeDiff(w http.ResponseWriter, r *http.Request) {
 	}
 
 	codePath := filepath.Join(cwd, req.WorkspacePath, codeFolder)
+	if !h.confinePath(w, r, codePath) {
+		return
+	}
 
 	// Check if code folder exists
 	if _, err := os.Stat(codePath); os.IsNotExist(err) {
@@ -7991,6 +7813,9 @@ func (h *Handler) HandleApplySpecUpdate(w http.ResponseWriter, r *http.Request)
 
 	// Ensure definition directory exists
 	defsDir := filepath.Join(cwd, req.WorkspacePath, "definition")
+	if !h.confinePath(w, r, defsDir) {
+		return
+	}
 	if err := os.MkdirAll(defsDir, 0755); err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(ApplySpecUpdateResponse{
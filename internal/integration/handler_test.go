@@ -0,0 +1,94 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeMarkdownClearsSections(t *testing.T) {
+	existing := "# Checkout\n\n## Metadata\n\n- **Status**: Draft\n\n## Description\n\nOld description\n\n## Business Outcome\n\nMore sales\n\n## Notes\n\nmine\n"
+	got := mergeEpicMarkdown(existing, SaveEpicRequest{Name: "Checkout", Status: "Draft", Description: "New description"})
+
+	if !strings.Contains(got, "## Description\n\nNew description\n") {
+		t.Errorf("description was not replaced:\n%s", got)
+	}
+	if !strings.Contains(got, "## Business Outcome\n\n## ") || strings.Contains(got, "More sales") {
+		t.Errorf("emptied business outcome was not cleared:\n%s", got)
+	}
+	if strings.Contains(got, "## MVP Definition") {
+		t.Errorf("empty section missing from the file was added:\n%s", got)
+	}
+	if !strings.Contains(got, "## Notes\n\nmine\n") {
+		t.Errorf("section outside the request was lost:\n%s", got)
+	}
+}
+
+func TestMergeTestScenarioClearsEmptiedLists(t *testing.T) {
+	existing := "# Login\n\n## Metadata\n\n- **ID**: TS-100001\n- **Requirement IDs**: FR-100001, FR-100002\n- **Tags**: smoke, auth\n- **Owner**: sam\n"
+	got := mergeTestScenarioMarkdown(existing, SaveTestScenarioRequest{ScenarioID: "TS-100001", ScenarioName: "Login", RequirementIDs: []string{"FR-100002"}})
+
+	if !strings.Contains(got, "- **Requirement IDs**: FR-100002\n") {
+		t.Errorf("requirement IDs were not replaced:\n%s", got)
+	}
+	if strings.Contains(got, "Tags") {
+		t.Errorf("emptied tags were kept:\n%s", got)
+	}
+	if !strings.Contains(got, "- **Owner**: sam\n") {
+		t.Errorf("field outside the request was lost:\n%s", got)
+	}
+}
+
+func TestSaveSpecificationsReplacesFiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+	folder := filepath.Join("workspaces", "w", "specifications")
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(folder, "notes.md"), []byte("# Notes\n\n## Kept\n\nold\n\n## Deleted\n\ngone\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(nil)
+	h.SetWorkspacesRoot("workspaces")
+
+	want := "# Notes\n\n## Kept\n\nnew\n"
+	body := `{"workspacePath":"workspaces/w","files":[{"fileName":"notes.md","content":"# Notes\n\n## Kept\n\nnew\n"}]}`
+	w := httptest.NewRecorder()
+	h.HandleSaveSpecifications(w, httptest.NewRequest("POST", "/save-specifications", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if got, _ := os.ReadFile(filepath.Join(folder, "notes.md")); string(got) != want {
+		t.Errorf("saved file = %q, want %q", got, want)
+	}
+}
+
+func TestParseSpecificationsFromContent(t *testing.T) {
+	capability := parseCapabilityFromContent("CAP-123456.md", "# Checkout\n\n## Metadata\n\n- **ID**: CAP-123456\n- **Status**: Implemented\n\n## Notes\n\nStatus: waiting on legal\n\n## Enablers\n\n| ID | Name |\n|----|------|\n| ENB-200001 | Cart |\n\n## Dependencies\n\n### Internal Upstream Dependency\n\n| Capability ID | Description |\n|---|---|\n| CAP-100001 | Login |\n")
+
+	if capability.ID != "CAP-123456" || capability.Name != "Checkout" || capability.Status != "Implemented" {
+		t.Errorf("capability = %+v", capability)
+	}
+	if len(capability.Enablers) != 1 || capability.Enablers[0] != "ENB-200001" {
+		t.Errorf("Enablers = %v", capability.Enablers)
+	}
+	if len(capability.UpstreamDependencies) != 1 || capability.UpstreamDependencies[0] != "CAP-100001" {
+		t.Errorf("UpstreamDependencies = %v", capability.UpstreamDependencies)
+	}
+
+	enabler := parseEnablerFromContent("cart-enabler.md", "# Cart\n\n## Metadata\n\n- **Capability**: Checkout (CAP-123456)\n")
+	if enabler.ID != fallbackSpecID("ENB", "cart-enabler.md") || enabler.CapabilityID != "CAP-123456" || enabler.Status != "Planned" {
+		t.Errorf("enabler = %+v", enabler)
+	}
+}
@@ -0,0 +1,348 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package integration
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jareynolds/intentr/pkg/jirasync"
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/jareynolds/intentr/pkg/spec"
+	"github.com/jareynolds/intentr/pkg/statemodel"
+)
+
+// JiraIntegration is the stored integration Jira sync uses by default
+const JiraIntegration = "Jira"
+
+// jiraClient creates a Jira API client from stored Jira credentials
+func jiraClient(credentials map[string]string) (*jirasync.Client, error) {
+	apiToken, ok := getCredential(credentials, "api_token", "access_token", "token", "api_key", "apiToken", "accessToken")
+	if !ok {
+		return nil, fmt.Errorf("API token not found in credentials. Expected one of: api_token, access_token, token, api_key. Got fields: %v", getCredentialKeys(credentials))
+	}
+	email, ok := getCredential(credentials, "email", "username", "user", "user_email", "userEmail")
+	if !ok {
+		return nil, fmt.Errorf("email not found in credentials. Expected one of: email, username, user. Got fields: %v", getCredentialKeys(credentials))
+	}
+	domain, ok := getCredential(credentials, "domain", "site", "site_url", "siteUrl", "jira_domain", "jiraDomain", "host", "instance", "base_url", "baseUrl")
+	if !ok {
+		return nil, fmt.Errorf("domain not found in credentials. Got fields: %v", getCredentialKeys(credentials))
+	}
+	return jirasync.NewClient(siteURL(domain), email, apiToken), nil
+}
+
+// errNotInWorkspace is returned for entities outside the workspace a link
+// belongs to
+var errNotInWorkspace = errors.New("not in the link's workspace")
+
+// entityWorkspace returns the workspace a capability or enabler is in
+func (h *Handler) entityWorkspace(entityType, entityID string) (string, error) {
+	if entityType == models.EntityTypeEnabler {
+		e, err := h.state.GetEnablerState(entityID)
+		if err != nil {
+			return "", err
+		}
+		return e.WorkspaceID, nil
+	}
+	c, err := h.state.GetCapabilityState(entityID)
+	if err != nil {
+		return "", err
+	}
+	return c.WorkspaceID, nil
+}
+
+// jiraSyncStore is the jirasync.Store of the entity state tables, limited to
+// one workspace's entities. Renames also retitle the entity's specification
+// file, if it is inside the workspaces root.
+type jiraSyncStore struct {
+	h           *Handler
+	workspaceID string
+	userID      int
+}
+
+// Item is read before a sync changes anything, so refusing entities of other
+// workspaces here keeps UpdateState and Rename to the store's workspace
+func (s jiraSyncStore) Item(entityType, entityID string) (*jirasync.Item, error) {
+	switch entityType {
+	case models.EntityTypeCapability:
+		c, err := s.h.state.GetCapabilityState(entityID)
+		if err != nil {
+			return nil, err
+		}
+		if c.WorkspaceID != s.workspaceID {
+			return nil, errNotInWorkspace
+		}
+		return &jirasync.Item{
+			Name:    c.Name,
+			State:   statemodel.State{LifecycleState: c.LifecycleState, WorkflowStage: c.WorkflowStage, StageStatus: c.StageStatus, ApprovalStatus: c.ApprovalStatus},
+			Version: c.Version,
+		}, nil
+	case models.EntityTypeEnabler:
+		e, err := s.h.state.GetEnablerState(entityID)
+		if err != nil {
+			return nil, err
+		}
+		if e.WorkspaceID != s.workspaceID {
+			return nil, errNotInWorkspace
+		}
+		return &jirasync.Item{
+			Name:    e.Name,
+			State:   statemodel.State{LifecycleState: e.LifecycleState, WorkflowStage: e.WorkflowStage, StageStatus: e.StageStatus, ApprovalStatus: e.ApprovalStatus},
+			Version: e.Version,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown entity type %q", entityType)
+}
+
+func (s jiraSyncStore) UpdateState(entityType, entityID string, req models.UpdateEntityStateRequest) error {
+	var err error
+	if entityType == models.EntityTypeEnabler {
+		_, err = s.h.state.UpdateEnablerState(entityID, req, &s.userID)
+	} else {
+		_, err = s.h.state.UpdateCapabilityState(entityID, req, &s.userID)
+	}
+	return err
+}
+
+func (s jiraSyncStore) Rename(entityType, entityID, name string) error {
+	var filePath string
+	if entityType == models.EntityTypeEnabler {
+		if err := s.h.state.SetEnablerName(entityID, name); err != nil {
+			return err
+		}
+		if e, err := s.h.state.GetEnablerState(entityID); err == nil {
+			filePath = e.FilePath
+		}
+	} else {
+		if err := s.h.state.SetCapabilityName(entityID, name); err != nil {
+			return err
+		}
+		if c, err := s.h.state.GetCapabilityState(entityID); err == nil {
+			filePath = c.FilePath
+		}
+	}
+
+	if filePath == "" || s.h.withinWorkspacesRoot(filePath) != nil {
+		return nil
+	}
+	content, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+	doc := spec.Parse(string(content))
+	if doc.Title() != "" {
+		doc.SetTitle(name)
+	}
+	if _, ok := doc.Field("Name"); ok {
+		doc.SetField("Name", name)
+	}
+	return os.WriteFile(filePath, []byte(doc.String()), 0644)
+}
+
+// requireJiraLinks writes an error and returns false if links cannot be stored
+func (h *Handler) requireJiraLinks(w http.ResponseWriter) bool {
+	if h.jiraLinks == nil {
+		http.Error(w, "Jira sync requires a database", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+// HandleListJiraLinks handles GET /jira/links?workspace_id=
+func (h *Handler) HandleListJiraLinks(w http.ResponseWriter, r *http.Request) {
+	if !h.requireJiraLinks(w) {
+		return
+	}
+	workspaceID := r.URL.Query().Get("workspace_id")
+	if _, ok := h.requireWorkspaceID(w, r, workspaceID, models.WorkspaceRoleViewer); !ok {
+		return
+	}
+
+	links, err := h.jiraLinks.ListLinks(workspaceID)
+	if err != nil {
+		log.Printf("[HandleListJiraLinks] FAILED: %v", err)
+		http.Error(w, "failed to list Jira links", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"links": links,
+	})
+}
+
+// HandleCreateJiraLink handles POST /jira/links. The first sync of a new
+// link records both sides without changing either.
+func (h *Handler) HandleCreateJiraLink(w http.ResponseWriter, r *http.Request) {
+	if !h.requireJiraLinks(w) {
+		return
+	}
+
+	var req models.CreateJiraSyncLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	claims, ok := h.requireWorkspaceID(w, r, req.WorkspaceID, models.WorkspaceRoleContributor)
+	if !ok {
+		return
+	}
+	if req.EntityType != models.EntityTypeCapability && req.EntityType != models.EntityTypeEnabler {
+		http.Error(w, "entity_type must be capability or enabler", http.StatusBadRequest)
+		return
+	}
+	if req.EntityID == "" || req.IssueKey == "" {
+		http.Error(w, "entity_id and issue_key are required", http.StatusBadRequest)
+		return
+	}
+
+	// Linked entities are synced with the workspace's role checks, so they
+	// must belong to it
+	workspaceID, err := h.entityWorkspace(req.EntityType, req.EntityID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("[HandleCreateJiraLink] FAILED to get %s %s: %v", req.EntityType, req.EntityID, err)
+		http.Error(w, "failed to create Jira link", http.StatusInternalServerError)
+		return
+	}
+	if err != nil || workspaceID != req.WorkspaceID {
+		http.Error(w, fmt.Sprintf("%s %s not found in workspace %s", req.EntityType, req.EntityID, req.WorkspaceID), http.StatusNotFound)
+		return
+	}
+
+	link, err := h.jiraLinks.CreateLink(models.JiraSyncLink{
+		WorkspaceID: req.WorkspaceID,
+		EntityType:  req.EntityType,
+		EntityID:    req.EntityID,
+		IssueKey:    strings.ToUpper(strings.TrimSpace(req.IssueKey)),
+		CreatedBy:   &claims.UserID,
+	})
+	if err != nil {
+		log.Printf("[HandleCreateJiraLink] FAILED: %v", err)
+		http.Error(w, "failed to create Jira link", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(link)
+}
+
+// HandleDeleteJiraLink handles DELETE /jira/links/{id}
+func (h *Handler) HandleDeleteJiraLink(w http.ResponseWriter, r *http.Request) {
+	if !h.requireJiraLinks(w) {
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid link ID", http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.jiraLinks.GetLink(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Jira link not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("[HandleDeleteJiraLink] FAILED: %v", err)
+		http.Error(w, "failed to delete Jira link", http.StatusInternalServerError)
+		return
+	}
+	if _, ok := h.requireWorkspaceID(w, r, link.WorkspaceID, models.WorkspaceRoleContributor); !ok {
+		return
+	}
+
+	if err := h.jiraLinks.DeleteLink(id); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("[HandleDeleteJiraLink] FAILED: %v", err)
+		http.Error(w, "failed to delete Jira link", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleJiraSync handles POST /jira/sync. It syncs every link of a
+// workspace with the user's stored Jira credentials and reports each link's
+// outcome; conflicts are left for the user to resolve with "resolve".
+func (h *Handler) HandleJiraSync(w http.ResponseWriter, r *http.Request) {
+	if !h.requireJiraLinks(w) {
+		return
+	}
+
+	var req models.JiraSyncRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	claims, ok := h.requireWorkspaceID(w, r, req.WorkspaceID, models.WorkspaceRoleContributor)
+	if !ok {
+		return
+	}
+	for entityID, side := range req.Resolve {
+		if side != models.JiraSyncPreferIntentR && side != models.JiraSyncPreferJira {
+			http.Error(w, fmt.Sprintf("resolve[%s] must be %q or %q", entityID, models.JiraSyncPreferIntentR, models.JiraSyncPreferJira), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.IntegrationName == "" {
+		req.IntegrationName = JiraIntegration
+	}
+	credentials, ok := h.requireCredentials(w, r, req.IntegrationName)
+	if !ok {
+		return
+	}
+	client, err := jiraClient(credentials)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	links, err := h.jiraLinks.ListLinks(req.WorkspaceID)
+	if err != nil {
+		log.Printf("[HandleJiraSync] FAILED to list links: %v", err)
+		http.Error(w, "failed to list Jira links", http.StatusInternalServerError)
+		return
+	}
+
+	store := jiraSyncStore{h: h, workspaceID: req.WorkspaceID, userID: claims.UserID}
+	resp := models.JiraSyncResponse{WorkspaceID: req.WorkspaceID, Results: []models.JiraSyncResult{}}
+	for i := range links {
+		link := &links[i]
+		result := jirasync.Sync(r.Context(), client, store, link, req.Resolve[link.EntityID])
+		if err := h.jiraLinks.SaveSyncState(link); err != nil {
+			log.Printf("[HandleJiraSync] FAILED to save link %d: %v", link.ID, err)
+		}
+
+		switch result.Result {
+		case models.JiraSyncResultSynced:
+			resp.Synced++
+		case models.JiraSyncResultConflict:
+			resp.Conflicts++
+		case models.JiraSyncResultError:
+			resp.Errors++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	log.Printf("[HandleJiraSync] Workspace %s: %d links, %d synced, %d conflicts, %d errors",
+		req.WorkspaceID, len(links), resp.Synced, resp.Conflicts, resp.Errors)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
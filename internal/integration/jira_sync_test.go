@@ -0,0 +1,60 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jareynolds/intentr/internal/auth"
+	"github.com/jareynolds/intentr/internal/testdb"
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/jareynolds/intentr/pkg/repository"
+)
+
+func TestJiraLinksStayInTheirWorkspace(t *testing.T) {
+	db := testdb.Open(t)
+	h := NewHandler(nil)
+	h.UseDatabase(db, nil)
+
+	user := testdb.CreateUser(t, db, "contributor", "user")
+	workspaces := repository.NewWorkspaceMembershipRepository(db)
+	if err := workspaces.ClaimWorkspace("workspace-a", user); err != nil {
+		t.Fatal(err)
+	}
+	for id, workspace := range map[string]string{"CAP-100001": "workspace-a", "CAP-100002": "workspace-b"} {
+		if _, err := db.Exec(`INSERT INTO capabilities (capability_id, name, workspace_id) VALUES ($1, $1, $2)`, id, workspace); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for entityID, want := range map[string]int{"CAP-100001": http.StatusCreated, "CAP-100002": http.StatusNotFound, "CAP-999999": http.StatusNotFound} {
+		body := `{"workspace_id":"workspace-a","entity_type":"capability","entity_id":"` + entityID + `","issue_key":"ABC-1"}`
+		r := httptest.NewRequest(http.MethodPost, "/jira/links", strings.NewReader(body))
+		r = r.WithContext(context.WithValue(r.Context(), "claims", &auth.Claims{UserID: user}))
+		w := httptest.NewRecorder()
+		h.HandleCreateJiraLink(w, r)
+		if w.Code != want {
+			t.Errorf("HandleCreateJiraLink(%s) = %d %s, want %d", entityID, w.Code, w.Body, want)
+		}
+	}
+
+	// Links made before the check are refused before anything is synced
+	store := jiraSyncStore{h: h, workspaceID: "workspace-a", userID: user}
+	if _, err := store.Item(models.EntityTypeCapability, "CAP-100002"); !errors.Is(err, errNotInWorkspace) {
+		t.Errorf("Item(other workspace) error = %v, want errNotInWorkspace", err)
+	}
+	if _, err := store.Item(models.EntityTypeCapability, "CAP-100001"); err != nil {
+		t.Errorf("Item(own workspace) error = %v", err)
+	}
+}
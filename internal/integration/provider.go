@@ -0,0 +1,183 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotSupported is returned by a provider for an operation it does not
+// implement
+var ErrNotSupported = errors.New("operation not supported by this integration")
+
+// IntegrationProvider is an external tool IntentR integrates with. Providers
+// are registered by name with RegisterProvider, usually from an init
+// function, and requests select them by their integration_name. Embed
+// BaseProvider to implement only the operations the tool supports.
+type IntegrationProvider interface {
+	// Name is the integration name the provider is registered under
+	Name() string
+	// TestConnection checks that the credentials are accepted, calling
+	// baseURL if it is set and the provider's own API otherwise
+	TestConnection(ctx context.Context, baseURL string, credentials map[string]string) (*TestConnectionResponse, error)
+	// FetchResources lists the projects, repositories, teams, etc. that
+	// files can be fetched from
+	FetchResources(ctx context.Context, req FetchResourcesRequest) (*FetchResourcesResponse, error)
+	// FetchFiles lists the files, issues, etc. of a resource
+	FetchFiles(ctx context.Context, req FetchFilesRequest) (*FetchFilesResponse, error)
+	// FetchFileMeta fetches the metadata of one file
+	FetchFileMeta(ctx context.Context, req FetchFileMetaRequest) (map[string]interface{}, error)
+	// FetchEpics fetches the work items that can be imported as capabilities
+	FetchEpics(ctx context.Context, req FetchEpicsRequest) ([]Epic, error)
+}
+
+// URLMatcher is implemented by providers whose APIs need their own
+// authentication when connections are tested by URL alone
+type URLMatcher interface {
+	MatchesURL(rawURL string) bool
+}
+
+// BaseProvider implements every IntegrationProvider operation other than Name
+// by returning ErrNotSupported
+type BaseProvider struct{}
+
+func (BaseProvider) TestConnection(context.Context, string, map[string]string) (*TestConnectionResponse, error) {
+	return nil, ErrNotSupported
+}
+
+func (BaseProvider) FetchResources(context.Context, FetchResourcesRequest) (*FetchResourcesResponse, error) {
+	return nil, ErrNotSupported
+}
+
+func (BaseProvider) FetchFiles(context.Context, FetchFilesRequest) (*FetchFilesResponse, error) {
+	return nil, ErrNotSupported
+}
+
+func (BaseProvider) FetchFileMeta(context.Context, FetchFileMetaRequest) (map[string]interface{}, error) {
+	return nil, ErrNotSupported
+}
+
+func (BaseProvider) FetchEpics(context.Context, FetchEpicsRequest) ([]Epic, error) {
+	return nil, ErrNotSupported
+}
+
+// providerRegistry holds the registered providers by name and alias
+var providerRegistry = struct {
+	sync.RWMutex
+	byName    map[string]IntegrationProvider
+	providers []IntegrationProvider
+}{byName: map[string]IntegrationProvider{}}
+
+// RegisterProvider registers a provider under its name and any aliases.
+// Registering a name twice panics, as with database/sql drivers.
+func RegisterProvider(p IntegrationProvider, aliases ...string) {
+	providerRegistry.Lock()
+	defer providerRegistry.Unlock()
+
+	names := append([]string{p.Name()}, aliases...)
+	for _, name := range names {
+		if name == "" {
+			panic("integration: RegisterProvider with an empty name")
+		}
+		if _, dup := providerRegistry.byName[name]; dup {
+			panic("integration: RegisterProvider called twice for " + name)
+		}
+	}
+	for _, name := range names {
+		providerRegistry.byName[name] = p
+	}
+	providerRegistry.providers = append(providerRegistry.providers, p)
+}
+
+// LookupProvider returns the provider registered under a name or alias
+func LookupProvider(name string) (IntegrationProvider, bool) {
+	providerRegistry.RLock()
+	defer providerRegistry.RUnlock()
+	p, ok := providerRegistry.byName[name]
+	return p, ok
+}
+
+// Providers returns the registered providers, sorted by name
+func Providers() []IntegrationProvider {
+	providerRegistry.RLock()
+	providers := append([]IntegrationProvider(nil), providerRegistry.providers...)
+	providerRegistry.RUnlock()
+
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Name() < providers[j].Name() })
+	return providers
+}
+
+// lookupProvider is LookupProvider with the error requests for an unknown
+// integration fail with
+func lookupProvider(name string) (IntegrationProvider, error) {
+	p, ok := LookupProvider(name)
+	if !ok {
+		return nil, fmt.Errorf("unsupported integration: %s", name)
+	}
+	return p, nil
+}
+
+// providerForURL returns the registered provider that claims a URL
+func providerForURL(rawURL string) (IntegrationProvider, bool) {
+	for _, p := range Providers() {
+		if m, ok := p.(URLMatcher); ok && m.MatchesURL(rawURL) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// probeConnection sends an authorized GET to a URL and reports the response.
+// Failures to connect are reported in the response rather than returned.
+func probeConnection(ctx context.Context, rawURL string, authorize func(*http.Request)) *TestConnectionResponse {
+	testReq, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return &TestConnectionResponse{ErrorMessage: fmt.Sprintf("Invalid URL: %v", err)}
+	}
+	if authorize != nil {
+		authorize(testReq)
+	}
+	testReq.Header.Set("User-Agent", "IntentR-Integration-Test/1.0")
+	testReq.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(testReq)
+	if err != nil {
+		return &TestConnectionResponse{ErrorMessage: fmt.Sprintf("Connection failed: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	// Read response body (limited to 10KB for safety)
+	bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 10240))
+
+	respHeaders := make(map[string]string)
+	for key, values := range resp.Header {
+		if len(values) > 0 {
+			respHeaders[key] = values[0]
+		}
+	}
+
+	response := &TestConnectionResponse{
+		Success:         resp.StatusCode >= 200 && resp.StatusCode < 300,
+		StatusCode:      resp.StatusCode,
+		ResponseHeaders: respHeaders,
+		ResponseBody:    string(bodyBytes),
+	}
+	if !response.Success {
+		response.ErrorMessage = fmt.Sprintf("HTTP %d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return response
+}
@@ -0,0 +1,87 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeProvider supports listing resources and testing connections to URLs
+// under its prefix
+type fakeProvider struct {
+	BaseProvider
+	prefix string
+}
+
+func (fakeProvider) Name() string { return "Fake Tracker" }
+
+func (p fakeProvider) MatchesURL(rawURL string) bool { return strings.HasPrefix(rawURL, p.prefix) }
+
+func (fakeProvider) TestConnection(ctx context.Context, baseURL string, credentials map[string]string) (*TestConnectionResponse, error) {
+	return probeConnection(ctx, baseURL, func(r *http.Request) { r.Header.Set("X-Fake-Key", credentials["key"]) }), nil
+}
+
+func (fakeProvider) FetchResources(ctx context.Context, req FetchResourcesRequest) (*FetchResourcesResponse, error) {
+	return &FetchResourcesResponse{IntegrationName: "Fake Tracker", Resources: []IntegrationResource{{ID: "1", Name: "Board"}}}, nil
+}
+
+func TestProviderRegistry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Fake-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+	RegisterProvider(fakeProvider{prefix: server.URL}, "Fake")
+
+	for _, name := range []string{"Figma API", "Figma", "GitHub", "Jira", "GitLab", "Azure DevOps", "Fake"} {
+		if _, ok := LookupProvider(name); !ok {
+			t.Errorf("LookupProvider(%q) found no provider", name)
+		}
+	}
+
+	ctx := context.Background()
+	resources, err := FetchResources(ctx, FetchResourcesRequest{IntegrationName: "Fake"})
+	if err != nil || len(resources.Resources) != 1 {
+		t.Fatalf("FetchResources() = %+v, %v", resources, err)
+	}
+	if _, err := FetchFiles(ctx, FetchFilesRequest{IntegrationName: "Fake Tracker"}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("FetchFiles() error = %v, want ErrNotSupported", err)
+	}
+	if _, err := FetchEpics(ctx, FetchEpicsRequest{IntegrationName: "Trello"}); err == nil || !strings.Contains(err.Error(), "unsupported integration") {
+		t.Errorf("FetchEpics(unregistered) error = %v", err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("RegisterProvider() of a registered name did not panic")
+			}
+		}()
+		RegisterProvider(fakeProvider{}, "Fake")
+	}()
+
+	// Connections to a URL the provider claims are tested by the provider
+	h := NewHandler(nil)
+	for key, want := range map[string]bool{"secret": true, "wrong": false} {
+		body := `{"base_url":"` + server.URL + `/me","credentials":{"key":"` + key + `"}}`
+		w := httptest.NewRecorder()
+		h.HandleTestConnection(w, httptest.NewRequest(http.MethodPost, "/test-connection", strings.NewReader(body)))
+		var resp TestConnectionResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Success != want {
+			t.Errorf("HandleTestConnection(key %q) = %+v, %v; want success %v", key, resp, err, want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGitLabProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "glpat-test" {
+			http.Error(w, `{"message":"401 Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects":
+			io.WriteString(w, `[{"id":7,"path_with_namespace":"acme/shop","web_url":"https://gitlab.example/acme/shop","default_branch":"main","namespace":{"id":3,"kind":"group","full_path":"acme"}}]`)
+		case "/api/v4/projects/7":
+			io.WriteString(w, `{"path_with_namespace":"acme/shop","web_url":"https://gitlab.example/acme/shop","default_branch":"main"}`)
+		case "/api/v4/projects/7/repository/tree":
+			io.WriteString(w, `[{"id":"a1","name":"README.md","type":"blob","path":"README.md"},{"id":"b2","name":"src","type":"tree","path":"src"}]`)
+		case "/api/v4/groups/acme%2Fteam/epics":
+			io.WriteString(w, `[{"id":41,"iid":2,"title":"Checkout","description":"Pay for orders","state":"closed","labels":["web"],"web_url":"https://gitlab.example/groups/acme/-/epics/2","references":{"full":"acme/team&2"}}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	credentials := map[string]string{"access_token": "glpat-test", "base_url": server.URL}
+
+	resources, err := FetchResources(ctx, FetchResourcesRequest{IntegrationName: GitLabIntegration, Credentials: credentials})
+	if err != nil || len(resources.Resources) != 1 || resources.Resources[0].Name != "acme/shop" {
+		t.Fatalf("FetchResources() = %+v, %v", resources, err)
+	}
+
+	files, err := FetchFiles(ctx, FetchFilesRequest{IntegrationName: GitLabIntegration, ResourceID: "7", Credentials: credentials})
+	if err != nil || len(files.Files) != 1 || files.Files[0].Name != "README.md" {
+		t.Fatalf("FetchFiles() = %+v, %v", files, err)
+	}
+
+	epics, err := FetchEpics(ctx, FetchEpicsRequest{IntegrationName: GitLabIntegration, Project: "acme/team", Credentials: credentials})
+	if err != nil || epics.Total != 1 {
+		t.Fatalf("FetchEpics() = %+v, %v", epics, err)
+	}
+	epic := epics.Epics[0]
+	if epic.Key != "acme/team&2" || epic.Summary != "Checkout" || mapEpicStatusToINTENT(epic.Status) != "Implemented" {
+		t.Errorf("FetchEpics() epic = %+v", epic)
+	}
+
+	content := generateCapabilityMarkdown("CAP-000001", epic, GitLabIntegration, "Implemented", "Medium")
+	for _, want := range []string{"- **Owner**: Imported from GitLab", "- **GitLab Key**: acme/team&2", "Imported from GitLab Epic: acme/team&2"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("generateCapabilityMarkdown() is missing %q", want)
+		}
+	}
+
+	credentials["access_token"] = "wrong"
+	if _, err := FetchResources(ctx, FetchResourcesRequest{IntegrationName: GitLabIntegration, Credentials: credentials}); err == nil {
+		t.Error("FetchResources() with a bad token succeeded")
+	}
+}
+
+func TestAzureDevOpsProvider(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pat, _ := r.BasicAuth(); pat != "ado-pat" || r.URL.Query().Get("api-version") == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/contoso/_apis/projects":
+			io.WriteString(w, `{"value":[{"id":"p1","name":"Shop","state":"wellFormed"}]}`)
+		case "/contoso/Shop/_apis/wit/wiql":
+			var body struct {
+				Query string `json:"query"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			query = body.Query
+			io.WriteString(w, `{"workItems":[{"id":12}]}`)
+		case "/contoso/Shop/_apis/wit/workitems":
+			io.WriteString(w, `{"value":[{"id":12,"fields":{"System.Title":"Checkout","System.WorkItemType":"Epic","System.State":"Active",
+				"System.Description":"<div>Pay for orders</div>","System.Tags":"web; payments","Microsoft.VSTS.Common.Priority":1}}]}`)
+		case "/contoso/Shop/_apis/work/boards":
+			io.WriteString(w, `{"value":[{"id":"b1","name":"Epics"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	credentials := map[string]string{"personal_access_token": "ado-pat", "organization": server.URL + "/contoso"}
+
+	resources, err := FetchResources(ctx, FetchResourcesRequest{IntegrationName: AzureDevOpsIntegration, Credentials: credentials})
+	if err != nil || len(resources.Resources) != 1 || resources.Resources[0].Name != "Shop" {
+		t.Fatalf("FetchResources() = %+v, %v", resources, err)
+	}
+
+	boards, err := FetchFiles(ctx, FetchFilesRequest{IntegrationName: AzureDevOpsIntegration, ResourceID: "Shop", ResourceType: "boards", Credentials: credentials})
+	if err != nil || len(boards.Files) != 1 || boards.Files[0].Type != "board" {
+		t.Fatalf("FetchFiles(boards) = %+v, %v", boards, err)
+	}
+
+	epics, err := FetchEpics(ctx, FetchEpicsRequest{IntegrationName: AzureDevOpsIntegration, Project: "Shop", Credentials: credentials})
+	if err != nil || epics.Total != 1 {
+		t.Fatalf("FetchEpics() = %+v, %v", epics, err)
+	}
+	if !strings.Contains(query, "[System.WorkItemType] = 'Epic'") {
+		t.Errorf("WIQL query = %q, want Epic work items", query)
+	}
+	epic := epics.Epics[0]
+	if epic.Key != "12" || epic.Description != "Pay for orders" || epic.Priority != "High" || len(epic.Labels) != 2 {
+		t.Errorf("FetchEpics() epic = %+v", epic)
+	}
+	if mapEpicStatusToINTENT(epic.Status) != "In Implementation" {
+		t.Errorf("mapEpicStatusToINTENT(%q) = %q", epic.Status, mapEpicStatusToINTENT(epic.Status))
+	}
+
+	if _, err := FetchEpics(ctx, FetchEpicsRequest{IntegrationName: AzureDevOpsIntegration, Project: "Shop", WorkItemType: "Epic' OR 1=1", Credentials: credentials}); err == nil {
+		t.Error("FetchEpics() accepted a work item type that is not a name")
+	}
+}
+
+func TestIsAzureDevOpsURL(t *testing.T) {
+	for url, want := range map[string]bool{
+		"https://dev.azure.com/contoso/_apis/projects": true,
+		"https://contoso.visualstudio.com/":            true,
+		"https://gitlab.com/api/v4/user":               false,
+	} {
+		if got := isAzureDevOpsURL(url); got != want {
+			t.Errorf("isAzureDevOpsURL(%q) = %v, want %v", url, got, want)
+		}
+	}
+}
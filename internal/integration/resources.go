@@ -33,9 +33,10 @@ type IntegrationResource struct {
 // FetchResourcesRequest represents the request to fetch integration resources
 type FetchResourcesRequest struct {
 	IntegrationName string            `json:"integration_name"`
-	Credentials     map[string]string `json:"credentials"` // API keys, tokens, etc.
+	Credentials     map[string]string `json:"-"` // API keys, tokens, etc., loaded from the credential vault
 	ResourceType    string            `json:"resource_type,omitempty"` // Optional: specific resource type to fetch
 	ParentID        string            `json:"parent_id,omitempty"` // Optional: fetch children of a specific resource
+	TeamURL         string            `json:"team_url,omitempty"` // Optional: Figma team to list projects of
 }
 
 // FetchResourcesResponse represents the response containing integration resources
@@ -50,7 +51,7 @@ type FetchFilesRequest struct {
 	IntegrationName string            `json:"integration_name"`
 	ResourceID      string            `json:"resource_id"`
 	ResourceType    string            `json:"resource_type"`
-	Credentials     map[string]string `json:"credentials"`
+	Credentials     map[string]string `json:"-"` // Loaded from the credential vault
 }
 
 // IntegrationFile represents a file/asset from an integration
@@ -78,21 +79,16 @@ type FetchFilesResponse struct {
 type FetchFileMetaRequest struct {
 	IntegrationName string            `json:"integration_name"`
 	FileKey         string            `json:"file_key"`
-	Credentials     map[string]string `json:"credentials"`
+	Credentials     map[string]string `json:"-"` // Loaded from the credential vault
 }
 
 // FetchResources fetches available resources from an integration using provided credentials
 func FetchResources(ctx context.Context, req FetchResourcesRequest) (*FetchResourcesResponse, error) {
-	switch req.IntegrationName {
-	case "Figma API", "Figma":
-		return fetchFigmaResources(ctx, req)
-	case "GitHub":
-		return fetchGitHubResources(ctx, req)
-	case "Jira":
-		return fetchJiraResources(ctx, req)
-	default:
-		return nil, fmt.Errorf("unsupported integration: %s", req.IntegrationName)
+	provider, err := lookupProvider(req.IntegrationName)
+	if err != nil {
+		return nil, err
 	}
+	return provider.FetchResources(ctx, req)
 }
 
 // extractFigmaTeamID parses the team ID from a Figma URL
@@ -137,8 +133,11 @@ func fetchFigmaResources(ctx context.Context, req FetchResourcesRequest) (*Fetch
 		return nil, fmt.Errorf("access_token not found in credentials")
 	}
 
-	// Check if team_url is provided in credentials
-	teamURL, hasTeamURL := req.Credentials["team_url"]
+	// Check if team_url is provided in the request or the stored credentials
+	teamURL, hasTeamURL := req.TeamURL, req.TeamURL != ""
+	if !hasTeamURL {
+		teamURL, hasTeamURL = req.Credentials["team_url"]
+	}
 
 	var resources []IntegrationResource
 	var metadata map[string]interface{}
@@ -266,14 +265,9 @@ func fetchFigmaResources(ctx context.Context, req FetchResourcesRequest) (*Fetch
 
 // fetchGitHubResources fetches repositories from GitHub
 func fetchGitHubResources(ctx context.Context, req FetchResourcesRequest) (*FetchResourcesResponse, error) {
-	accessToken, ok := req.Credentials["access_token"]
+	accessToken, ok := githubToken(req.Credentials)
 	if !ok {
-		// Try alternative field names
-		if token, exists := req.Credentials["API Key"]; exists {
-			accessToken = token
-		} else {
-			return nil, fmt.Errorf("access_token not found in credentials")
-		}
+		return nil, fmt.Errorf("access_token not found in credentials")
 	}
 
 	// GitHub API: List user repositories
@@ -302,6 +296,7 @@ func fetchGitHubResources(ctx context.Context, req FetchResourcesRequest) (*Fetc
 		FullName    string `json:"full_name"`
 		Description string `json:"description"`
 		HTMLURL     string `json:"html_url"`
+		CloneURL    string `json:"clone_url"`
 		Private     bool   `json:"private"`
 		Language    string `json:"language"`
 		UpdatedAt   string `json:"updated_at"`
@@ -323,6 +318,7 @@ func fetchGitHubResources(ctx context.Context, req FetchResourcesRequest) (*Fetc
 				"private":    repo.Private,
 				"language":   repo.Language,
 				"updated_at": repo.UpdatedAt,
+				"clone_url":  repo.CloneURL,
 			},
 		}
 	}
@@ -333,6 +329,73 @@ func fetchGitHubResources(ctx context.Context, req FetchResourcesRequest) (*Fetc
 	}, nil
 }
 
+// githubToken returns the personal access token of stored GitHub credentials,
+// whose field name depends on how the integration was configured
+func githubToken(credentials map[string]string) (string, bool) {
+	return getCredential(credentials, "access_token", "personal_access_token", "Personal Access Token",
+		"token", "Token", "Access Token", "api_key", "API Key", "apiKey", "pat", "PAT")
+}
+
+// CreateGitHubRepoRequest is the request to create a GitHub repository with
+// the user's stored GitHub credentials
+type CreateGitHubRepoRequest struct {
+	IntegrationName string            `json:"integration_name,omitempty"` // Stored integration to use; defaults to "GitHub"
+	Name            string            `json:"name"`
+	Private         bool              `json:"private"`
+	Credentials     map[string]string `json:"-"` // Loaded from the credential vault
+}
+
+// CreateGitHubRepoResponse describes the created repository
+type CreateGitHubRepoResponse struct {
+	URL      string `json:"url"` // Clone URL
+	HTMLURL  string `json:"htmlUrl"`
+	FullName string `json:"full_name"`
+}
+
+// CreateGitHubRepo creates a repository for the authenticated GitHub user
+func CreateGitHubRepo(ctx context.Context, req CreateGitHubRepoRequest) (*CreateGitHubRepoResponse, error) {
+	accessToken, ok := githubToken(req.Credentials)
+	if !ok {
+		return nil, fmt.Errorf("access_token not found in credentials")
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"name":      req.Name,
+		"private":   req.Private,
+		"auto_init": false,
+	})
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://api.github.com/user/repos", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	httpReq.Header.Set("Accept", "application/vnd.github.v3+json")
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub repository: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GitHub API error (%d): %s", resp.StatusCode, string(body))
+	}
+
+	var repo struct {
+		FullName string `json:"full_name"`
+		HTMLURL  string `json:"html_url"`
+		CloneURL string `json:"clone_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&repo); err != nil {
+		return nil, err
+	}
+
+	return &CreateGitHubRepoResponse{URL: repo.CloneURL, HTMLURL: repo.HTMLURL, FullName: repo.FullName}, nil
+}
+
 // getCredential looks for a credential value using multiple possible field names
 func getCredential(credentials map[string]string, fieldNames ...string) (string, bool) {
 	for _, name := range fieldNames {
@@ -371,13 +434,10 @@ func fetchJiraResources(ctx context.Context, req FetchResourcesRequest) (*FetchR
 		return nil, fmt.Errorf("domain not found in credentials (e.g., yourcompany.atlassian.net). Expected one of: domain, site, site_url, base_url. Got fields: %v", getCredentialKeys(req.Credentials))
 	}
 
-	// Clean up domain - remove protocol if present
-	domain = strings.TrimPrefix(domain, "https://")
-	domain = strings.TrimPrefix(domain, "http://")
-	domain = strings.TrimSuffix(domain, "/")
+	site := siteURL(domain)
 
 	// Jira API: List projects
-	url := fmt.Sprintf("https://%s/rest/api/3/project", domain)
+	url := fmt.Sprintf("%s/rest/api/3/project", site)
 	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
@@ -416,7 +476,7 @@ func fetchJiraResources(ctx context.Context, req FetchResourcesRequest) (*FetchR
 			Name:        fmt.Sprintf("%s - %s", project.Key, project.Name),
 			Type:        "project",
 			Description: project.Description,
-			URL:         fmt.Sprintf("https://%s/browse/%s", domain, project.Key),
+			URL:         fmt.Sprintf("%s/browse/%s", site, project.Key),
 			Metadata: map[string]interface{}{
 				"key":          project.Key,
 				"project_type": project.ProjectTypeKey,
@@ -432,16 +492,11 @@ func fetchJiraResources(ctx context.Context, req FetchResourcesRequest) (*FetchR
 
 // FetchFiles fetches files/assets from a specific resource
 func FetchFiles(ctx context.Context, req FetchFilesRequest) (*FetchFilesResponse, error) {
-	switch req.IntegrationName {
-	case "Figma API", "Figma":
-		return fetchFigmaFiles(ctx, req)
-	case "GitHub":
-		return fetchGitHubFiles(ctx, req)
-	case "Jira":
-		return fetchJiraFiles(ctx, req)
-	default:
-		return nil, fmt.Errorf("unsupported integration: %s", req.IntegrationName)
+	provider, err := lookupProvider(req.IntegrationName)
+	if err != nil {
+		return nil, err
 	}
+	return provider.FetchFiles(ctx, req)
 }
 
 // fetchFigmaFiles fetches files from a Figma project
@@ -543,13 +598,9 @@ func fetchFigmaFiles(ctx context.Context, req FetchFilesRequest) (*FetchFilesRes
 
 // fetchGitHubFiles fetches files from a GitHub repository
 func fetchGitHubFiles(ctx context.Context, req FetchFilesRequest) (*FetchFilesResponse, error) {
-	accessToken, ok := req.Credentials["access_token"]
+	accessToken, ok := githubToken(req.Credentials)
 	if !ok {
-		if token, exists := req.Credentials["API Key"]; exists {
-			accessToken = token
-		} else {
-			return nil, fmt.Errorf("access_token not found in credentials")
-		}
+		return nil, fmt.Errorf("access_token not found in credentials")
 	}
 
 	// Get repo info from resource_id (it's the repo ID)
@@ -644,12 +695,11 @@ func fetchGitHubFiles(ctx context.Context, req FetchFilesRequest) (*FetchFilesRe
 
 // FetchFileMeta fetches metadata for a specific file (for refreshing thumbnails)
 func FetchFileMeta(ctx context.Context, req FetchFileMetaRequest) (map[string]interface{}, error) {
-	switch req.IntegrationName {
-	case "Figma API", "Figma":
-		return fetchFigmaFileMeta(ctx, req)
-	default:
-		return nil, fmt.Errorf("unsupported integration: %s", req.IntegrationName)
+	provider, err := lookupProvider(req.IntegrationName)
+	if err != nil {
+		return nil, err
 	}
+	return provider.FetchFileMeta(ctx, req)
 }
 
 // fetchFigmaFileMeta fetches metadata for a specific Figma file
@@ -728,13 +778,10 @@ func fetchJiraFiles(ctx context.Context, req FetchFilesRequest) (*FetchFilesResp
 		return nil, fmt.Errorf("domain not found in credentials. Got fields: %v", getCredentialKeys(req.Credentials))
 	}
 
-	// Clean up domain - remove protocol if present
-	domain = strings.TrimPrefix(domain, "https://")
-	domain = strings.TrimPrefix(domain, "http://")
-	domain = strings.TrimSuffix(domain, "/")
+	site := siteURL(domain)
 
 	// Fetch issues from the project using the new /search/jql API (POST)
-	searchURL := fmt.Sprintf("https://%s/rest/api/3/search/jql", domain)
+	searchURL := fmt.Sprintf("%s/rest/api/3/search/jql", site)
 	searchBody := map[string]interface{}{
 		"jql":        fmt.Sprintf("project=%s", req.ResourceID),
 		"maxResults": 50,
@@ -785,7 +832,7 @@ func fetchJiraFiles(ctx context.Context, req FetchFilesRequest) (*FetchFilesResp
 			ID:        issue.ID,
 			Name:      fmt.Sprintf("%s - %s", issue.Key, issue.Fields.Summary),
 			Type:      "issue",
-			URL:       fmt.Sprintf("https://%s/browse/%s", domain, issue.Key),
+			URL:       fmt.Sprintf("%s/browse/%s", site, issue.Key),
 			UpdatedAt: issue.Fields.Updated,
 			Metadata: map[string]interface{}{
 				"key": issue.Key,
@@ -801,8 +848,9 @@ func fetchJiraFiles(ctx context.Context, req FetchFilesRequest) (*FetchFilesResp
 	}, nil
 }
 
-// JiraEpic represents an Epic from Jira that can be imported as a Capability
-type JiraEpic struct {
+// Epic represents an Epic from Jira, a GitLab epic or an Azure DevOps work
+// item, which can be imported as a Capability
+type Epic struct {
 	ID          string                 `json:"id"`
 	Key         string                 `json:"key"`
 	Summary     string                 `json:"summary"`
@@ -819,18 +867,31 @@ type JiraEpic struct {
 
 // FetchJiraEpicsRequest is the request for fetching Jira Epics
 type FetchJiraEpicsRequest struct {
-	ProjectKey  string            `json:"project_key"`
-	Credentials map[string]string `json:"credentials"`
+	IntegrationName string            `json:"integration_name,omitempty"` // Stored integration to use; defaults to "Jira"
+	ProjectKey      string            `json:"project_key"`
+	Credentials     map[string]string `json:"-"` // Loaded from the credential vault
 }
 
 // FetchJiraEpicsResponse is the response containing Jira Epics
 type FetchJiraEpicsResponse struct {
 	ProjectKey  string      `json:"project_key"`
 	ProjectName string      `json:"project_name"`
-	Epics       []JiraEpic  `json:"epics"`
+	Epics       []Epic  `json:"epics"`
 	Total       int         `json:"total"`
 }
 
+// siteURL returns the URL of the site named by a domain credential, such as
+// a Jira site or a self-managed GitLab instance. Domains without a scheme use
+// https; an explicit http:// URL is kept so that a local stub such as
+// cmd/mock-jira can be used.
+func siteURL(domain string) string {
+	domain = strings.TrimSuffix(domain, "/")
+	if strings.HasPrefix(domain, "https://") || strings.HasPrefix(domain, "http://") {
+		return domain
+	}
+	return "https://" + domain
+}
+
 // FetchJiraEpics fetches all Epics (and optionally Sagas) from a Jira project
 func FetchJiraEpics(ctx context.Context, req FetchJiraEpicsRequest) (*FetchJiraEpicsResponse, error) {
 	// Get credentials with fallback field names
@@ -849,17 +910,14 @@ func FetchJiraEpics(ctx context.Context, req FetchJiraEpicsRequest) (*FetchJiraE
 		return nil, fmt.Errorf("domain not found in credentials. Got fields: %v", getCredentialKeys(req.Credentials))
 	}
 
-	// Clean up domain
-	domain = strings.TrimPrefix(domain, "https://")
-	domain = strings.TrimPrefix(domain, "http://")
-	domain = strings.TrimSuffix(domain, "/")
+	site := siteURL(domain)
 
 	// JQL to find Epics and custom issue types that might be Sagas
 	// Standard Epic type is "Epic", but some orgs have custom types
 	jql := fmt.Sprintf("project=%s AND (issuetype=Epic OR issuetype=Saga OR issuetype~epic) ORDER BY created DESC", req.ProjectKey)
 
 	// Use the new /search/jql POST API
-	searchURL := fmt.Sprintf("https://%s/rest/api/3/search/jql", domain)
+	searchURL := fmt.Sprintf("%s/rest/api/3/search/jql", site)
 	searchBody := map[string]interface{}{
 		"jql":        jql,
 		"maxResults": 100,
@@ -928,12 +986,12 @@ func FetchJiraEpics(ctx context.Context, req FetchJiraEpicsRequest) (*FetchJiraE
 		return nil, fmt.Errorf("failed to decode Jira response: %w", err)
 	}
 
-	epics := make([]JiraEpic, len(searchResult.Issues))
+	epics := make([]Epic, len(searchResult.Issues))
 	for i, issue := range searchResult.Issues {
 		// Extract plain text from Atlassian Document Format (ADF)
 		description := extractADFText(issue.Fields.Description)
 
-		epics[i] = JiraEpic{
+		epics[i] = Epic{
 			ID:          issue.ID,
 			Key:         issue.Key,
 			Summary:     issue.Fields.Summary,
@@ -943,7 +1001,7 @@ func FetchJiraEpics(ctx context.Context, req FetchJiraEpicsRequest) (*FetchJiraE
 			Labels:      issue.Fields.Labels,
 			Created:     issue.Fields.Created,
 			Updated:     issue.Fields.Updated,
-			URL:         fmt.Sprintf("https://%s/browse/%s", domain, issue.Key),
+			URL:         fmt.Sprintf("%s/browse/%s", site, issue.Key),
 			IssueType:   issue.Fields.IssueType.Name,
 			Metadata: map[string]interface{}{
 				"jira_id":  issue.ID,
@@ -0,0 +1,445 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jareynolds/intentr/internal/auth"
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// DefaultWorkspacesRoot is the folder, relative to the working directory,
+// that workspace folders are created in
+const DefaultWorkspacesRoot = "workspaces"
+
+// ErrOutsideWorkspacesRoot is returned for paths that resolve outside the
+// workspaces root
+var ErrOutsideWorkspacesRoot = errors.New("path is outside the workspaces root")
+
+// workspaceRoles looks up users' workspace roles; it is implemented by
+// repository.WorkspaceMembershipRepository
+type workspaceRoles interface {
+	Role(workspaceID string, userID int) (models.WorkspaceRole, error)
+	Require(workspaceID string, userID int, required models.WorkspaceRole) error
+	ClaimWorkspace(workspaceID string, userID int) error
+	ClaimWorkspaceOwner(workspaceID, ownerEmail string) (bool, error)
+}
+
+// errNoWorkspaceRoles is the response for non-admins when no database is
+// configured: their workspace roles cannot be checked, so access is refused
+const errNoWorkspaceRoles = "workspace roles cannot be checked without a database"
+
+// workspacePathFields are the request body fields that name a workspace
+// folder or a file inside one
+var workspacePathFields = []string{"workspacePath", "workspace_path", "path", "filePath", "sourcePath"}
+
+// requestWorkspacePaths returns the workspace folders and files a request
+// names in its query or JSON body, the workspace folder first. File names
+// given relative to the workspace folder are returned joined to it. The body
+// is left readable for the handler.
+func requestWorkspacePaths(r *http.Request) []string {
+	var paths []string
+	for _, key := range []string{"path", "workspace"} {
+		if p := r.URL.Query().Get(key); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	if r.Body == nil {
+		return paths
+	}
+
+	body, err := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return paths
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil {
+		return paths
+	}
+	str := func(raw json.RawMessage) string {
+		var s string
+		json.Unmarshal(raw, &s)
+		return s
+	}
+
+	for _, key := range workspacePathFields {
+		if p := str(fields[key]); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	var config struct {
+		ProjectFolder string `json:"projectFolder"`
+	}
+	if json.Unmarshal(fields["config"], &config) == nil && config.ProjectFolder != "" {
+		paths = append(paths, config.ProjectFolder)
+	}
+
+	// Files named relative to the workspace folder
+	if base := str(fields["workspacePath"]); base != "" {
+		subfolder := str(fields["subfolder"])
+		if name := str(fields["fileName"]); name != "" || subfolder != "" {
+			paths = append(paths, filepath.Join(base, subfolder, name))
+		}
+		var files []struct {
+			FileName string `json:"fileName"`
+		}
+		json.Unmarshal(fields["files"], &files)
+		for _, f := range files {
+			paths = append(paths, filepath.Join(base, subfolder, f.FileName))
+		}
+	}
+	return paths
+}
+
+// canonicalPath resolves a path, relative to the working directory, to an
+// absolute path without symbolic links. Missing trailing parts of the path
+// are kept as given.
+func canonicalPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	existing, rest := abs, ""
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return abs, nil
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+}
+
+// withinWorkspacesRoot returns ErrOutsideWorkspacesRoot unless path resolves
+// to the workspaces root or a path inside it
+func (h *Handler) withinWorkspacesRoot(path string) error {
+	root, err := canonicalPath(h.workspacesRoot)
+	if err != nil {
+		return fmt.Errorf("failed to resolve workspaces root: %w", err)
+	}
+	p, err := canonicalPath(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	rel, err := filepath.Rel(root, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ErrOutsideWorkspacesRoot
+	}
+	return nil
+}
+
+// workspaceIDForPath returns the ID in the .intentrworkspace file of the
+// nearest folder containing path, or "" if path is not in a workspace
+func workspaceIDForPath(path string) string {
+	dir := filepath.Clean(path)
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, ".intentrworkspace"))
+		if err == nil {
+			var config WorkspaceConfig
+			if json.Unmarshal(data, &config) != nil {
+				return ""
+			}
+			return config.ID
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// workspaceConfigs returns the folders directly under the workspaces root
+// that have a readable .intentrworkspace file, with their configs
+func (h *Handler) workspaceConfigs() []ScannedWorkspace {
+	entries, err := os.ReadDir(h.workspacesRoot)
+	if err != nil {
+		return nil
+	}
+	var workspaces []ScannedWorkspace
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		folder := filepath.Join(h.workspacesRoot, entry.Name())
+		data, err := os.ReadFile(filepath.Join(folder, ".intentrworkspace"))
+		if err != nil {
+			continue
+		}
+		var config WorkspaceConfig
+		if json.Unmarshal(data, &config) != nil {
+			continue
+		}
+		workspaces = append(workspaces, ScannedWorkspace{FolderName: entry.Name(), FolderPath: folder, Config: &config, HasConfig: true})
+	}
+	return workspaces
+}
+
+// workspaceFolder returns the folder under the workspaces root whose
+// .intentrworkspace file has the given ID, or "" if there is none
+func (h *Handler) workspaceFolder(workspaceID string) string {
+	if workspaceID == "" {
+		return ""
+	}
+	for _, ws := range h.workspaceConfigs() {
+		if ws.Config.ID == workspaceID {
+			return ws.FolderPath
+		}
+	}
+	return ""
+}
+
+// ClaimWorkspaceOwners records the owners that the .intentrworkspace files
+// of workspaces without members name in ownerId. Such workspaces predate
+// workspace roles and are only open to admins until they have members. It
+// returns the number of owners recorded.
+func (h *Handler) ClaimWorkspaceOwners() (int, error) {
+	if h.workspaces == nil {
+		return 0, nil
+	}
+	claimed := 0
+	for _, ws := range h.workspaceConfigs() {
+		ok, err := h.workspaces.ClaimWorkspaceOwner(ws.Config.ID, ws.Config.OwnerID)
+		if err != nil {
+			return claimed, fmt.Errorf("failed to record the owner of %s: %w", ws.FolderName, err)
+		}
+		if ok {
+			log.Printf("Recorded %s as the owner of workspace %s", ws.Config.OwnerID, ws.Config.ID)
+			claimed++
+		}
+	}
+	return claimed, nil
+}
+
+// requiredRoleKey is the context key RequireWorkspaceRole stores the role a
+// route requires under, for confinePath
+type requiredRoleKey struct{}
+
+// checkWithinRoot checks that path is inside the workspaces root, writing
+// the error response if not
+func (h *Handler) checkWithinRoot(w http.ResponseWriter, path string) bool {
+	if err := h.withinWorkspacesRoot(path); err != nil {
+		if errors.Is(err, ErrOutsideWorkspacesRoot) {
+			http.Error(w, fmt.Sprintf("%s: %s", err, path), http.StatusBadRequest)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return false
+	}
+	return true
+}
+
+// checkWorkspacePath checks that path is inside the workspaces root and that
+// the user has at least the required role in the workspace it is in, writing
+// the error response if not. Workspaces already checked are skipped.
+func (h *Handler) checkWorkspacePath(w http.ResponseWriter, claims *auth.Claims, path string, required models.WorkspaceRole, checked map[string]bool) bool {
+	if !h.checkWithinRoot(w, path) {
+		return false
+	}
+
+	workspaceID := workspaceIDForPath(path)
+	if workspaceID == "" {
+		// Without a workspace there is no membership to check, so only
+		// admins may use such paths, beyond browsing the root and setting up
+		// a new workspace's folder
+		if claims.IsAdmin() || h.unclaimedFolder(path, required) {
+			return true
+		}
+		http.Error(w, fmt.Sprintf("%s is not in a workspace", path), http.StatusForbidden)
+		return false
+	}
+	if checked[workspaceID] {
+		return true
+	}
+	if h.workspaces == nil {
+		if claims.IsAdmin() {
+			return true
+		}
+		http.Error(w, errNoWorkspaceRoles, http.StatusServiceUnavailable)
+		return false
+	}
+	checked[workspaceID] = true
+
+	err := h.workspaces.Require(workspaceID, claims.UserID, required)
+	var accessErr *models.WorkspaceAccessError
+	if errors.As(err, &accessErr) {
+		http.Error(w, accessErr.Error(), http.StatusForbidden)
+		return false
+	}
+	if err != nil {
+		log.Printf("[RequireWorkspaceRole] FAILED to check role of user %d in workspace %q: %v", claims.UserID, workspaceID, err)
+		http.Error(w, "failed to check workspace access", http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// errFolderHasFiles stops the walk in unclaimedFolder
+var errFolderHasFiles = errors.New("folder has files")
+
+// unclaimedFolder reports whether a path outside any workspace may be used
+// without admin rights: the workspaces root itself, only to view it, or a
+// folder directly under the root that does not exist yet or holds no files,
+// such as a new workspace's folder before its .intentrworkspace file is
+// written. Existing folders with files, e.g. a workspace whose
+// .intentrworkspace file was removed, are not.
+func (h *Handler) unclaimedFolder(path string, required models.WorkspaceRole) bool {
+	root, err := canonicalPath(h.workspacesRoot)
+	if err != nil {
+		return false
+	}
+	p, err := canonicalPath(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(root, p)
+	if err != nil {
+		return false
+	}
+	if rel == "." {
+		return required == models.WorkspaceRoleViewer
+	}
+
+	folder := filepath.Join(root, strings.Split(rel, string(filepath.Separator))[0])
+	err = filepath.WalkDir(folder, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return errFolderHasFiles
+		}
+		return nil
+	})
+	return err == nil || errors.Is(err, fs.ErrNotExist)
+}
+
+// RequireWorkspaceRole wraps a workspace file handler so that it only runs if
+// every path the request names is inside the workspaces root, and the
+// authenticated user has at least the required role in the workspaces those
+// paths are in. Paths outside any workspace are refused unless the user is
+// an admin or unclaimedFolder allows them. Handlers that resolve request
+// paths further, e.g. from host to container paths, check the result with
+// confinePath.
+func (h *Handler) RequireWorkspaceRole(required models.WorkspaceRole, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := r.Context().Value("claims").(*auth.Claims)
+		if !ok {
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		checked := map[string]bool{}
+		for _, path := range requestWorkspacePaths(r) {
+			if !h.checkWorkspacePath(w, claims, path, required, checked) {
+				return
+			}
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), requiredRoleKey{}, required)))
+	}
+}
+
+// confinePath checks a path exactly as a handler is about to open it, after
+// any translation: it must be inside the workspaces root, and the user must
+// have the role the route requires in its workspace. The error response is
+// written if not.
+func (h *Handler) confinePath(w http.ResponseWriter, r *http.Request, path string) bool {
+	required, ok := r.Context().Value(requiredRoleKey{}).(models.WorkspaceRole)
+	if !ok {
+		// Not behind RequireWorkspaceRole; the root still applies
+		return h.checkWithinRoot(w, filepath.Clean(path))
+	}
+	return h.requirePathRole(w, r, path, required)
+}
+
+// requirePathRole is confinePath for a role other than the route's, such as
+// a contributor creating a folder in one they may only view
+func (h *Handler) requirePathRole(w http.ResponseWriter, r *http.Request, path string, required models.WorkspaceRole) bool {
+	claims, ok := r.Context().Value("claims").(*auth.Claims)
+	if !ok {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return false
+	}
+	return h.checkWorkspacePath(w, claims, filepath.Clean(path), required, map[string]bool{})
+}
+
+// canAccessWorkspace reports whether the user of a request may view a
+// workspace
+func (h *Handler) canAccessWorkspace(r *http.Request, workspaceID string) bool {
+	claims, ok := r.Context().Value("claims").(*auth.Claims)
+	if !ok {
+		return false
+	}
+	if h.workspaces == nil {
+		return claims.IsAdmin()
+	}
+	role, err := h.workspaces.Role(workspaceID, claims.UserID)
+	if err != nil {
+		log.Printf("[canAccessWorkspace] FAILED to get role of user %d in workspace %q: %v", claims.UserID, workspaceID, err)
+		return false
+	}
+	return role.Allows(models.WorkspaceRoleViewer)
+}
+
+// requireWorkspaceID checks that the user of a request has at least the
+// required role in a workspace named by ID, writing the error response if
+// not
+func (h *Handler) requireWorkspaceID(w http.ResponseWriter, r *http.Request, workspaceID string, required models.WorkspaceRole) (*auth.Claims, bool) {
+	claims, ok := r.Context().Value("claims").(*auth.Claims)
+	if !ok {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return nil, false
+	}
+	if workspaceID == "" {
+		http.Error(w, "workspace_id is required", http.StatusBadRequest)
+		return nil, false
+	}
+	if h.workspaces == nil {
+		if claims.IsAdmin() {
+			return claims, true
+		}
+		http.Error(w, errNoWorkspaceRoles, http.StatusServiceUnavailable)
+		return nil, false
+	}
+
+	err := h.workspaces.Require(workspaceID, claims.UserID, required)
+	var accessErr *models.WorkspaceAccessError
+	if errors.As(err, &accessErr) {
+		http.Error(w, accessErr.Error(), http.StatusForbidden)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("[requireWorkspaceID] FAILED to check role of user %d in workspace %q: %v", claims.UserID, workspaceID, err)
+		http.Error(w, "failed to check workspace access", http.StatusInternalServerError)
+		return nil, false
+	}
+	return claims, true
+}
@@ -0,0 +1,466 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package integration
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jareynolds/intentr/internal/auth"
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/jareynolds/intentr/pkg/repository"
+)
+
+// fakeWorkspaceRoles gives every user the same role in each workspace
+type fakeWorkspaceRoles map[string]models.WorkspaceRole
+
+func (f fakeWorkspaceRoles) Role(workspaceID string, userID int) (models.WorkspaceRole, error) {
+	return f[workspaceID], nil
+}
+
+func (f fakeWorkspaceRoles) Require(workspaceID string, userID int, required models.WorkspaceRole) error {
+	if role := f[workspaceID]; !role.Allows(required) {
+		return &models.WorkspaceAccessError{WorkspaceID: workspaceID, UserID: userID, Role: role, RequiredRole: required}
+	}
+	return nil
+}
+
+func (f fakeWorkspaceRoles) ClaimWorkspace(workspaceID string, userID int) error {
+	return f.Require(workspaceID, userID, models.WorkspaceRoleOwner)
+}
+
+func (f fakeWorkspaceRoles) ClaimWorkspaceOwner(workspaceID, ownerEmail string) (bool, error) {
+	return false, nil
+}
+
+func TestRequestWorkspacePaths(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		want   []string
+	}{
+		{"workspacePath", "/save-epic", `{"workspacePath":"workspaces/a","path":"workspaces/b"}`, []string{"workspaces/a", "workspaces/b"}},
+		{"filePath", "/read-file", `{"filePath":"workspaces/a/x.md"}`, []string{"workspaces/a/x.md"}},
+		{"config", "/workspace-config/save", `{"config":{"projectFolder":"workspaces/a"}}`, []string{"workspaces/a"}},
+		{"query", "/folders/list?path=workspaces/a", "", []string{"workspaces/a"}},
+		{"file name", "/save-image", `{"workspacePath":"workspaces/a","fileName":"../../x.png"}`, []string{"workspaces/a", "x.png"}},
+		{"files", "/save-specifications", `{"workspacePath":"workspaces/a","subfolder":"specifications","files":[{"fileName":"CAP-1.md"}]}`,
+			[]string{"workspaces/a", "workspaces/a/specifications", "workspaces/a/specifications/CAP-1.md"}},
+		{"none", "/ai-chat", `{"message":"hi"}`, nil},
+		{"invalid JSON", "/save-epic", `{`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", tt.target, strings.NewReader(tt.body))
+			if got := requestWorkspacePaths(r); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requestWorkspacePaths() = %q, want %q", got, tt.want)
+			}
+			if body, _ := io.ReadAll(r.Body); string(body) != tt.body {
+				t.Errorf("body after requestWorkspacePaths() = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestWithinWorkspacesRoot(t *testing.T) {
+	root := t.TempDir()
+	h := NewHandler(nil)
+	h.SetWorkspacesRoot(filepath.Join(root, "workspaces"))
+	if err := os.MkdirAll(filepath.Join(root, "workspaces", "demo"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(root, filepath.Join(root, "workspaces", "demo", "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		ok   bool
+	}{
+		{filepath.Join(root, "workspaces"), true},
+		{filepath.Join(root, "workspaces", "demo", "specifications", "CAP-1.md"), true},
+		{filepath.Join(root, "workspaces", "new-workspace"), true},
+		{filepath.Join(root, "workspaces", "demo", "..", "..", "etc"), false},
+		{filepath.Join(root, "workspaces-other"), false},
+		{filepath.Join(root, "workspaces", "demo", "escape", "secrets"), false},
+		{"/etc/passwd", false},
+	}
+	for _, tt := range tests {
+		if err := h.withinWorkspacesRoot(tt.path); (err == nil) != tt.ok {
+			t.Errorf("withinWorkspacesRoot(%q) = %v, want ok %v", tt.path, err, tt.ok)
+		}
+	}
+}
+
+func TestWorkspaceIDForPath(t *testing.T) {
+	root := t.TempDir()
+	workspace := filepath.Join(root, "workspaces", "demo")
+	if err := os.MkdirAll(filepath.Join(workspace, "specifications"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workspace, ".intentrworkspace"), []byte(`{"id":"ws-1"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{workspace, "ws-1"},
+		{filepath.Join(workspace, "specifications"), "ws-1"},
+		{filepath.Join(workspace, "specifications", "CAP-1.md"), "ws-1"},
+		{filepath.Join(root, "workspaces"), ""},
+	}
+	for _, tt := range tests {
+		if got := workspaceIDForPath(tt.path); got != tt.want {
+			t.Errorf("workspaceIDForPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRequireWorkspaceRole(t *testing.T) {
+	root := t.TempDir()
+	h := NewHandler(nil)
+	h.SetWorkspacesRoot(root)
+	handler := h.RequireWorkspaceRole(models.WorkspaceRoleOwner, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name   string
+		claims bool
+		body   string
+		want   int
+	}{
+		{"no user", false, `{}`, http.StatusUnauthorized},
+		{"inside root", true, `{"workspacePath":"` + filepath.Join(root, "demo") + `"}`, http.StatusNoContent},
+		{"traversal", true, `{"workspacePath":"` + filepath.Join(root, "demo", "..", "..") + `"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/save-epic", strings.NewReader(tt.body))
+			if tt.claims {
+				r = r.WithContext(context.WithValue(r.Context(), "claims", &auth.Claims{UserID: 1}))
+			}
+			w := httptest.NewRecorder()
+			handler(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfinePathAfterTranslation(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+	if err := os.MkdirAll(filepath.Join("workspaces", "w", "code"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(nil)
+	h.SetWorkspacesRoot("workspaces")
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		body    string
+		want    int
+	}{
+		// The raw path is inside the root, but translatePathForDocker maps
+		// it to /root/AI_Principles/../../../../etc/passwd
+		{"docker translation", h.RequireWorkspaceRole(models.WorkspaceRoleContributor, h.HandleDeleteCapability),
+			`{"path":"workspaces/a/b/c/AI_Principles/../../../../etc/passwd"}`, http.StatusBadRequest},
+		{"code folder", h.RequireWorkspaceRole(models.WorkspaceRoleViewer, h.HandleGetCodeDiff),
+			`{"workspacePath":"workspaces/w","codeFolder":"../../../../../etc"}`, http.StatusBadRequest},
+		{"code folder inside", h.RequireWorkspaceRole(models.WorkspaceRoleViewer, h.HandleGetCodeDiff),
+			`{"workspacePath":"workspaces/w","codeFolder":"code"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			r = r.WithContext(context.WithValue(r.Context(), "claims", &auth.Claims{UserID: 1}))
+			w := httptest.NewRecorder()
+			tt.handler(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestRequireWorkspaceRoleOutsideWorkspaces(t *testing.T) {
+	root := t.TempDir()
+	h := NewHandler(nil)
+	h.SetWorkspacesRoot(root)
+	h.workspaces = fakeWorkspaceRoles{"ws-1": models.WorkspaceRoleContributor}
+	for name, marker := range map[string]string{"legacy": "", "corrupt": "{", "managed": `{"id":"ws-1"}`} {
+		if err := os.MkdirAll(filepath.Join(root, name, "definition"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name, "definition", "CAP-1.md"), []byte("# CAP-1"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if marker != "" {
+			if err := os.WriteFile(filepath.Join(root, name, ".intentrworkspace"), []byte(marker), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, "empty", "specifications"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		role     models.WorkspaceRole
+		path     string
+		userRole string
+		want     int
+	}{
+		{"no marker", models.WorkspaceRoleViewer, filepath.Join(root, "legacy", "definition"), "user", http.StatusForbidden},
+		{"no marker, admin", models.WorkspaceRoleContributor, filepath.Join(root, "legacy", "definition"), "admin", http.StatusNoContent},
+		{"corrupt marker", models.WorkspaceRoleContributor, filepath.Join(root, "corrupt"), "user", http.StatusForbidden},
+		{"workspace", models.WorkspaceRoleContributor, filepath.Join(root, "managed", "definition"), "user", http.StatusNoContent},
+		{"new folder", models.WorkspaceRoleContributor, filepath.Join(root, "new-workspace"), "user", http.StatusNoContent},
+		{"folder without files", models.WorkspaceRoleContributor, filepath.Join(root, "empty"), "user", http.StatusNoContent},
+		{"view root", models.WorkspaceRoleViewer, root, "user", http.StatusNoContent},
+		{"write root", models.WorkspaceRoleContributor, root, "user", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := h.RequireWorkspaceRole(tt.role, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			})
+			r := httptest.NewRequest("POST", "/", strings.NewReader(`{"workspacePath":"`+tt.path+`"}`))
+			r = r.WithContext(context.WithValue(r.Context(), "claims", &auth.Claims{UserID: 1, Role: tt.userRole}))
+			w := httptest.NewRecorder()
+			handler(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestSaveWorkspaceConfigKeepsID(t *testing.T) {
+	root := t.TempDir()
+	h := NewHandler(nil)
+	h.SetWorkspacesRoot(root)
+	folder := filepath.Join(root, "managed")
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(folder, ".intentrworkspace"), []byte(`{"id":"ws-1"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		id, userRole string
+		want         int
+	}{
+		{"ws-1", "user", http.StatusOK},
+		{"ws-2", "user", http.StatusConflict},
+		{"ws-2", "admin", http.StatusOK},
+	} {
+		body := `{"config":{"id":"` + tt.id + `","projectFolder":"` + folder + `"}}`
+		r := httptest.NewRequest("POST", "/workspace-config/save", strings.NewReader(body))
+		r = r.WithContext(context.WithValue(r.Context(), "claims", &auth.Claims{UserID: 1, Role: tt.userRole}))
+		w := httptest.NewRecorder()
+		h.HandleSaveWorkspaceConfig(w, r)
+		if w.Code != tt.want {
+			t.Errorf("save ID %s as %s: status = %d, want %d: %s", tt.id, tt.userRole, w.Code, tt.want, w.Body)
+		}
+	}
+}
+
+func TestSaveWorkspaceConfigCannotClaimExistingID(t *testing.T) {
+	root := t.TempDir()
+	h := NewHandler(nil)
+	h.SetWorkspacesRoot(root)
+	h.workspaces = fakeWorkspaceRoles{"ws-owned": models.WorkspaceRoleOwner}
+	for folder, id := range map[string]string{"legacy": "ws-legacy", "owned": "ws-owned"} {
+		if err := os.MkdirAll(filepath.Join(root, folder), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, folder, ".intentrworkspace"), []byte(`{"id":"`+id+`"}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A new folder naming the ID of a workspace without members would
+	// otherwise make its creator that workspace's owner
+	for _, tt := range []struct {
+		id, folder string
+		want       int
+	}{
+		{"ws-legacy", "takeover", http.StatusForbidden},
+		{"ws-owned", "second", http.StatusOK},
+	} {
+		folder := filepath.Join(root, tt.folder)
+		body := `{"config":{"id":"` + tt.id + `","projectFolder":"` + folder + `"}}`
+		r := httptest.NewRequest("POST", "/workspace-config/save", strings.NewReader(body))
+		r = r.WithContext(context.WithValue(r.Context(), "claims", &auth.Claims{UserID: 1, Role: "user"}))
+		w := httptest.NewRecorder()
+		h.HandleSaveWorkspaceConfig(w, r)
+		if w.Code != tt.want {
+			t.Errorf("save %s in %s: status = %d, want %d: %s", tt.id, tt.folder, w.Code, tt.want, w.Body)
+		}
+		_, err := os.Stat(filepath.Join(folder, ".intentrworkspace"))
+		if written := err == nil; written != (tt.want == http.StatusOK) {
+			t.Errorf("save %s in %s: .intentrworkspace written = %v", tt.id, tt.folder, written)
+		}
+	}
+}
+
+func TestRequireWorkspaceRoleWithoutDatabase(t *testing.T) {
+	root := t.TempDir()
+	h := NewHandler(nil)
+	h.SetWorkspacesRoot(root)
+	if err := os.MkdirAll(filepath.Join(root, "managed"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "managed", ".intentrworkspace"), []byte(`{"id":"ws-1"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	handler := h.RequireWorkspaceRole(models.WorkspaceRoleViewer, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	// Roles cannot be checked, so only admins get in
+	for userRole, want := range map[string]int{"user": http.StatusServiceUnavailable, "admin": http.StatusNoContent} {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(`{"workspacePath":"`+filepath.Join(root, "managed")+`"}`))
+		r = r.WithContext(context.WithValue(r.Context(), "claims", &auth.Claims{UserID: 1, Role: userRole}))
+		w := httptest.NewRecorder()
+		handler(w, r)
+		if w.Code != want {
+			t.Errorf("%s: status = %d, want %d: %s", userRole, w.Code, want, w.Body)
+		}
+	}
+}
+
+func TestSaveTestScenarioConfinedBeforeWriting(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+	h := NewHandler(nil)
+	h.SetWorkspacesRoot("workspaces")
+
+	r := httptest.NewRequest("POST", "/save-test-scenario", strings.NewReader(`{"workspacePath":"workspaces/../outside","name":"Login"}`))
+	r = r.WithContext(context.WithValue(r.Context(), "claims", &auth.Claims{UserID: 1}))
+	w := httptest.NewRecorder()
+	h.HandleSaveTestScenario(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body)
+	}
+	if _, err := os.Stat(filepath.Join(root, "outside")); !os.IsNotExist(err) {
+		t.Errorf("folder created outside the workspaces root: %v", err)
+	}
+}
+
+func TestMoveToDeletedRequiresOwner(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+	folder := filepath.Join("workspaces", "managed")
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(folder, ".intentrworkspace"), []byte(`{"id":"ws-1"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(nil)
+	h.SetWorkspacesRoot("workspaces")
+
+	for _, tt := range []struct {
+		role models.WorkspaceRole
+		want int
+	}{
+		{models.WorkspaceRoleContributor, http.StatusForbidden},
+		{models.WorkspaceRoleOwner, http.StatusOK},
+	} {
+		h.workspaces = fakeWorkspaceRoles{"ws-1": tt.role}
+		handler := h.RequireWorkspaceRole(models.WorkspaceRoleContributor, h.HandleMoveToDeleted)
+		r := httptest.NewRequest("POST", "/folders/move-to-deleted", strings.NewReader(`{"sourcePath":"workspaces/managed"}`))
+		r = r.WithContext(context.WithValue(r.Context(), "claims", &auth.Claims{UserID: 1}))
+		w := httptest.NewRecorder()
+		handler(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d: %s", tt.role, w.Code, tt.want, w.Body)
+		}
+		_, err := os.Stat(folder)
+		if archived := os.IsNotExist(err); archived != (tt.want == http.StatusOK) {
+			t.Errorf("%s: workspace archived = %v", tt.role, archived)
+		}
+	}
+}
+
+func TestGraphWorkspaceIDFromPath(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+	folder := filepath.Join("workspaces", "managed")
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(folder, ".intentrworkspace"), []byte(`{"id":"ws-1"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(nil)
+	h.SetWorkspacesRoot("workspaces")
+	h.workspaces = fakeWorkspaceRoles{"ws-1": models.WorkspaceRoleViewer}
+	handler := h.RequireWorkspaceRole(models.WorkspaceRoleViewer, h.HandleGraph)
+
+	// A viewer of one workspace cannot name another one for its relationships
+	for body, want := range map[string]int{
+		`{"workspacePath":"workspaces/managed"}`:                      http.StatusOK,
+		`{"workspacePath":"workspaces/managed","workspaceId":"ws-1"}`: http.StatusOK,
+		`{"workspacePath":"workspaces/managed","workspaceId":"ws-2"}`: http.StatusBadRequest,
+	} {
+		r := httptest.NewRequest("POST", "/graph", strings.NewReader(body))
+		r = r.WithContext(context.WithValue(r.Context(), "claims", &auth.Claims{UserID: 1}))
+		w := httptest.NewRecorder()
+		handler(w, r)
+		if w.Code != want {
+			t.Errorf("%s: status = %d, want %d: %s", body, w.Code, want, w.Body)
+		}
+	}
+}
+
+func TestLocalDiagramChecksWorkspaceID(t *testing.T) {
+	h := NewHandler(nil)
+	h.SetWorkspacesRoot(t.TempDir())
+	// Refused before the database is read, so none is needed
+	h.deps = repository.NewDependencyRepository(nil)
+	h.state = repository.NewEntityStateRepository(nil)
+	h.workspaces = fakeWorkspaceRoles{"ws-1": models.WorkspaceRoleViewer}
+
+	files := `"files":[{"filename":"CAP-1.md","content":"# Checkout"}]`
+	for _, tt := range []struct {
+		body string
+		want int
+	}{
+		{`{"diagram_type":"capability-map",` + files + `}`, http.StatusOK},
+		{`{"diagram_type":"capability-map","workspaceId":"ws-2",` + files + `}`, http.StatusForbidden},
+		{`{"diagram_type":"state-model","workspaceId":"ws-2"}`, http.StatusForbidden},
+	} {
+		r := httptest.NewRequest("POST", "/specifications/generate-diagram", strings.NewReader(tt.body))
+		r = r.WithContext(context.WithValue(r.Context(), "claims", &auth.Claims{UserID: 1}))
+		w := httptest.NewRecorder()
+		h.RequireWorkspaceRole(models.WorkspaceRoleViewer, h.HandleGenerateDiagram)(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d: %s", tt.body, w.Code, tt.want, w.Body)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Package testdb sets up Postgres databases for tests that need one
+package testdb
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// Open returns a connection to a new schema in the Postgres database named by
+// INTENTR_TEST_DATABASE_URL, set up as docker-compose sets up the database
+// and then migrated. The test is skipped without a database, and the schema
+// is dropped when it ends.
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("INTENTR_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("INTENTR_TEST_DATABASE_URL not set")
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	// lib/pq passes unknown settings on as run-time parameters
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			t.Fatalf("invalid INTENTR_TEST_DATABASE_URL: %v", err)
+		}
+		q := u.Query()
+		q.Set("search_path", schema)
+		u.RawQuery = q.Encode()
+		dsn = u.String()
+	} else {
+		dsn += " search_path=" + schema
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open test schema: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	root := moduleRoot(t)
+	files := []string{filepath.Join(root, "scripts", "init-db.sql")}
+	for _, pattern := range []string{"scripts/migration_*.sql", "migrations/*.sql"} {
+		matches, err := filepath.Glob(filepath.Join(root, pattern))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	for _, file := range files {
+		script, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(string(script)); err != nil {
+			t.Fatalf("failed to apply %s: %v", filepath.Base(file), err)
+		}
+	}
+	return db
+}
+
+// moduleRoot returns the folder holding go.mod, above the test's package
+func moduleRoot(t testing.TB) string {
+	t.Helper()
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			t.Fatal("go.mod not found")
+		}
+		dir = parent
+	}
+}
+
+// CreateUser adds an active user with a global role and returns its ID
+func CreateUser(t testing.TB, db *sql.DB, name, role string) int {
+	t.Helper()
+	var id int
+	err := db.QueryRow(`
+		INSERT INTO users (email, password_hash, name, role, is_active)
+		VALUES ($1, 'x', $2, $3, true)
+		RETURNING id
+	`, name+"@example.com", name, role).Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to create user %s: %v", name, err)
+	}
+	return id
+}
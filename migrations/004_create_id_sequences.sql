@@ -0,0 +1,13 @@
+-- Migration: Create ID Sequences
+-- Backs the server-side ID allocator (pkg/idalloc). Each row holds the last
+-- number handed out for one prefix (CAP, ENB, FR, NFR, TS), so concurrent
+-- requests, services and restarts never reuse an ID. The ID columns are
+-- unique across workspaces, so every workspace shares one sequence per prefix.
+
+CREATE TABLE IF NOT EXISTS id_sequences (
+    prefix VARCHAR(10) PRIMARY KEY,      -- CAP, ENB, FR, NFR, TS
+    last_value INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE id_sequences IS 'Last allocated numeric ID per prefix, shared by all workspaces';
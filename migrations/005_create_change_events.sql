@@ -0,0 +1,117 @@
+-- Migration: Create Change Events
+-- Backs the workspace change feed (GET /state/events/{workspaceId}). Triggers
+-- copy entity state changes, phase approval changes and story card moves into
+-- change_events, and every insert is announced with NOTIFY so all
+-- capability-service replicas can push it to their subscribers. The event id
+-- doubles as the SSE Last-Event-ID used to resume a stream.
+-- Requires scripts/migration_intent_state.sql and scripts/migration_phase_approval.sql.
+
+CREATE TABLE IF NOT EXISTS change_events (
+    id BIGSERIAL PRIMARY KEY,
+    workspace_id VARCHAR(255) NOT NULL DEFAULT '',
+    event_type VARCHAR(50) NOT NULL,   -- entity_state_change, phase_approval, story_card_moved
+    entity_type VARCHAR(50),           -- capability, enabler, story_card (NULL for phase approvals)
+    entity_id VARCHAR(100),
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_change_events_workspace_id ON change_events(workspace_id, id);
+CREATE INDEX IF NOT EXISTS idx_change_events_created_at ON change_events(created_at);
+
+-- Event ids must become visible in order within a workspace, or a client
+-- resuming from id N could skip an id below N that commits later. Taking a
+-- per-workspace transaction lock before drawing the id serialises writers.
+CREATE OR REPLACE FUNCTION order_change_event()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('change_events:' || NEW.workspace_id));
+    NEW.id := nextval(pg_get_serial_sequence('change_events', 'id'));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS order_change_events ON change_events;
+CREATE TRIGGER order_change_events
+    BEFORE INSERT ON change_events
+    FOR EACH ROW
+    EXECUTE FUNCTION order_change_event();
+
+-- Announce each event; the payload stays small, listeners read the row itself
+CREATE OR REPLACE FUNCTION notify_change_event()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('intentr_change_events',
+        json_build_object('id', NEW.id, 'workspace_id', NEW.workspace_id)::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS notify_change_events ON change_events;
+CREATE TRIGGER notify_change_events
+    AFTER INSERT ON change_events
+    FOR EACH ROW
+    EXECUTE FUNCTION notify_change_event();
+
+-- entity_state_changes -> entity_state_change
+CREATE OR REPLACE FUNCTION record_entity_state_change_event()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO change_events (workspace_id, event_type, entity_type, entity_id, payload)
+    VALUES (COALESCE(NEW.workspace_id, ''), 'entity_state_change', NEW.entity_type, NEW.entity_id, to_jsonb(NEW));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS record_entity_state_change_event ON entity_state_changes;
+CREATE TRIGGER record_entity_state_change_event
+    AFTER INSERT ON entity_state_changes
+    FOR EACH ROW
+    EXECUTE FUNCTION record_entity_state_change_event();
+
+-- phase_approvals -> phase_approval (approve and revoke)
+CREATE OR REPLACE FUNCTION record_phase_approval_event()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD.is_approved IS NOT DISTINCT FROM NEW.is_approved
+        AND OLD.approved_at IS NOT DISTINCT FROM NEW.approved_at THEN
+        RETURN NEW;
+    END IF;
+    INSERT INTO change_events (workspace_id, event_type, payload)
+    VALUES (NEW.workspace_id, 'phase_approval', to_jsonb(NEW));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS record_phase_approval_event ON phase_approvals;
+CREATE TRIGGER record_phase_approval_event
+    AFTER INSERT OR UPDATE ON phase_approvals
+    FOR EACH ROW
+    EXECUTE FUNCTION record_phase_approval_event();
+
+-- story_cards position change -> story_card_moved
+CREATE OR REPLACE FUNCTION record_story_card_moved_event()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO change_events (workspace_id, event_type, entity_type, entity_id, payload)
+    VALUES (NEW.workspace_id, 'story_card_moved', 'story_card', NEW.card_id, jsonb_build_object(
+        'card_id', NEW.card_id,
+        'position_x', NEW.position_x,
+        'position_y', NEW.position_y,
+        'old_position_x', OLD.position_x,
+        'old_position_y', OLD.position_y,
+        'version', NEW.version,
+        'updated_by', NEW.updated_by
+    ));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS record_story_card_moved_event ON story_cards;
+CREATE TRIGGER record_story_card_moved_event
+    AFTER UPDATE OF position_x, position_y ON story_cards
+    FOR EACH ROW
+    WHEN (OLD.position_x IS DISTINCT FROM NEW.position_x OR OLD.position_y IS DISTINCT FROM NEW.position_y)
+    EXECUTE FUNCTION record_story_card_moved_event();
+
+COMMENT ON TABLE change_events IS 'Workspace change feed: entity state changes, phase approvals and story card moves';
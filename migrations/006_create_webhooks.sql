@@ -0,0 +1,52 @@
+-- Migration: Create Webhook Tables
+-- Per-workspace outbound webhooks (pkg/webhook). Each event that matches a
+-- subscription becomes a row in webhook_deliveries, which the capability-service
+-- dispatcher sends with an HMAC signature and retries with exponential backoff.
+-- The rows double as the delivery log; replaying a delivery inserts a new row.
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id SERIAL PRIMARY KEY,
+    workspace_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,              -- HMAC-SHA256 signing key
+    events TEXT[] NOT NULL DEFAULT '{}',       -- Event types to send; empty means all
+    format VARCHAR(20) NOT NULL DEFAULT 'json', -- json, slack, teams
+    is_active BOOLEAN DEFAULT true,
+    created_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_workspace_id ON webhook_subscriptions(workspace_id);
+
+DROP TRIGGER IF EXISTS update_webhook_subscriptions_updated_at ON webhook_subscriptions;
+CREATE TRIGGER update_webhook_subscriptions_updated_at
+    BEFORE UPDATE ON webhook_subscriptions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id VARCHAR(36) NOT NULL,             -- Same for every delivery (and replay) of one event
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,                    -- The event envelope
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, sending, retrying, succeeded, failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_attempt_at TIMESTAMP,
+    response_status INTEGER,
+    response_body TEXT,                        -- Truncated
+    error TEXT,
+    duration_ms INTEGER,
+    replay_of BIGINT REFERENCES webhook_deliveries(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries(subscription_id, id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at)
+    WHERE status IN ('pending', 'sending', 'retrying');
+
+COMMENT ON TABLE webhook_subscriptions IS 'Outbound webhook endpoints per workspace';
+COMMENT ON TABLE webhook_deliveries IS 'Webhook delivery queue and log';
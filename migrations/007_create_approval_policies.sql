@@ -0,0 +1,36 @@
+-- Migration: Create Approval Policies
+-- Approval policies decide when a capability approval request is approved:
+-- a quorum of N approvals, an ordered chain of roles that must approve in
+-- sequence (e.g. architect then product_owner) and roles whose rejection is a
+-- veto. Individual votes are recorded in approval_audit_log (action 'vote');
+-- the request only becomes approved/rejected once the policy is satisfied.
+-- Without a matching policy the first decision settles the request, as before.
+
+CREATE TABLE IF NOT EXISTS approval_policies (
+    id SERIAL PRIMARY KEY,
+    workspace_id VARCHAR(255) NOT NULL DEFAULT '', -- '' applies to every workspace
+    stage VARCHAR(50) NOT NULL DEFAULT 'all',      -- specification, definition, design, execution, or 'all'
+    name VARCHAR(255) NOT NULL,
+    required_approvals INTEGER NOT NULL DEFAULT 1 CHECK (required_approvals >= 1),
+    eligible_roles TEXT[] NOT NULL DEFAULT '{}',   -- Roles that may vote; empty means any role
+    approval_chain TEXT[] NOT NULL DEFAULT '{}',   -- Roles that must approve, in order
+    veto_roles TEXT[] NOT NULL DEFAULT '{}',       -- A rejection from one of these rejects the request; '*' means any role
+    is_active BOOLEAN DEFAULT true,
+    created_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (workspace_id, stage)
+);
+
+DROP TRIGGER IF EXISTS update_approval_policies_updated_at ON approval_policies;
+CREATE TRIGGER update_approval_policies_updated_at
+    BEFORE UPDATE ON approval_policies
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- The policy in force when approval was requested, so editing a policy does
+-- not change the outcome of requests that are already being voted on
+ALTER TABLE capability_approvals
+    ADD COLUMN IF NOT EXISTS policy JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_approval_audit_log_votes ON approval_audit_log(approval_id) WHERE action = 'vote';
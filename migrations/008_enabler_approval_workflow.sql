@@ -0,0 +1,38 @@
+-- Migration: Enabler Approval Workflow
+-- Brings enabler_approvals (migration 003) to parity with capability_approvals:
+-- enablers and individual enabler requirements are submitted per stage and
+-- decided by the same approval policies, with votes and decisions recorded in
+-- approval_audit_log. Enabler rows in the audit log keep the enabler's
+-- capability_id and reference the request through enabler_approval_id.
+
+-- A request for a single requirement of the enabler; NULL means the whole enabler
+ALTER TABLE enabler_approvals
+    ADD COLUMN IF NOT EXISTS requirement_id INTEGER REFERENCES enabler_requirements(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS policy JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_enabler_approvals_requirement_id ON enabler_approvals(requirement_id);
+
+-- Only one pending request per enabler (or requirement) and stage
+CREATE UNIQUE INDEX IF NOT EXISTS idx_enabler_approvals_one_pending
+    ON enabler_approvals(enabler_id, COALESCE(requirement_id, 0), stage)
+    WHERE status = 'pending_approval';
+
+ALTER TABLE enablers
+    ADD COLUMN IF NOT EXISTS approved_by INTEGER REFERENCES users(id),
+    ADD COLUMN IF NOT EXISTS approved_at TIMESTAMP;
+
+ALTER TABLE approval_audit_log
+    ADD COLUMN IF NOT EXISTS enabler_approval_id INTEGER REFERENCES enabler_approvals(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS enabler_id INTEGER REFERENCES enablers(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS requirement_id INTEGER REFERENCES enabler_requirements(id);
+
+-- Audit rows never change once written, so a requirement with approval
+-- history cannot be deleted (no ON DELETE SET NULL). Databases migrated
+-- before this change get the same constraint.
+ALTER TABLE approval_audit_log
+    DROP CONSTRAINT IF EXISTS approval_audit_log_requirement_id_fkey,
+    ADD CONSTRAINT approval_audit_log_requirement_id_fkey
+        FOREIGN KEY (requirement_id) REFERENCES enabler_requirements(id);
+
+CREATE INDEX IF NOT EXISTS idx_approval_audit_log_enabler_id ON approval_audit_log(enabler_id);
+CREATE INDEX IF NOT EXISTS idx_approval_audit_log_enabler_votes ON approval_audit_log(enabler_approval_id) WHERE action = 'vote';
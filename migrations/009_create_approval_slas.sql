@@ -0,0 +1,38 @@
+-- Migration: Create Approval SLAs
+-- Per-stage service levels for capability approval requests. The
+-- capability-service SLA scheduler (pkg/approvalsla) sends a reminder once a
+-- pending request is remind_after_hours old and, once it is due_hours old,
+-- flags it overdue and escalates it to escalation_role, which may then vote in
+-- place of the next role of the approval chain. Reminders and escalations are
+-- recorded in approval_audit_log with no performer (performed_by NULL).
+
+CREATE TABLE IF NOT EXISTS approval_slas (
+    id SERIAL PRIMARY KEY,
+    workspace_id VARCHAR(255) NOT NULL DEFAULT '', -- '' applies to every workspace
+    stage VARCHAR(50) NOT NULL DEFAULT 'all',      -- specification, definition, design, execution, or 'all'
+    due_hours INTEGER NOT NULL CHECK (due_hours > 0),
+    remind_after_hours INTEGER CHECK (remind_after_hours > 0),
+    escalation_role VARCHAR(50),                   -- Fallback approver role; NULL only flags the request
+    is_active BOOLEAN DEFAULT true,
+    created_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (workspace_id, stage)
+);
+
+DROP TRIGGER IF EXISTS update_approval_slas_updated_at ON approval_slas;
+CREATE TRIGGER update_approval_slas_updated_at
+    BEFORE UPDATE ON approval_slas
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE capability_approvals
+    ADD COLUMN IF NOT EXISTS reminded_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS overdue_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS escalated_to_role VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_capability_approvals_pending_requested_at
+    ON capability_approvals(requested_at) WHERE status = 'pending_approval';
+
+-- Scheduler entries have no performing user
+ALTER TABLE approval_audit_log ALTER COLUMN performed_by DROP NOT NULL;
@@ -0,0 +1,38 @@
+-- Migration: Create Approval Delegations
+-- Time-bounded hand-over of a user's approve/reject rights to another user,
+-- e.g. while the delegator is out of office. A delegate votes on behalf of the
+-- delegator by naming them in the vote (on_behalf_of); the vote then counts
+-- as the delegator's, with the delegator's role. Empty stages or
+-- workspace_ids cover every stage or workspace.
+
+CREATE TABLE IF NOT EXISTS approval_delegations (
+    id SERIAL PRIMARY KEY,
+    delegator_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    delegate_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    stages TEXT[] NOT NULL DEFAULT '{}',
+    workspace_ids TEXT[] NOT NULL DEFAULT '{}',
+    starts_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ends_at TIMESTAMP NOT NULL,
+    reason TEXT,
+    revoked_at TIMESTAMP,
+    created_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (delegator_id <> delegate_id),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_approval_delegations_delegate
+    ON approval_delegations(delegate_id, ends_at) WHERE revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_approval_delegations_delegator
+    ON approval_delegations(delegator_id, ends_at) WHERE revoked_at IS NULL;
+
+DROP TRIGGER IF EXISTS update_approval_delegations_updated_at ON approval_delegations;
+CREATE TRIGGER update_approval_delegations_updated_at
+    BEFORE UPDATE ON approval_delegations
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Set when performed_by acted as a delegate of this user
+ALTER TABLE approval_audit_log
+    ADD COLUMN IF NOT EXISTS on_behalf_of INTEGER REFERENCES users(id);
@@ -0,0 +1,183 @@
+-- Migration: Audit Hash Chain
+-- Makes approval_audit_log and entity_state_changes tamper-evident. Every row
+-- is linked into a per-workspace hash chain as it is inserted:
+--
+--   record_hash = hex(sha256(prev_hash || '|' || content))
+--
+-- where content is the row's canonical text (approval_audit_content and
+-- entity_state_change_content below) and prev_hash is the record_hash of the
+-- previous record of the workspace, '' for the first. chain_seq numbers the
+-- records of a workspace from 1 without gaps across both tables, and
+-- audit_chain_heads holds the latest link so deleting the newest records is
+-- detected too. GET /audit/verify (pkg/auditchain) recomputes the chain from
+-- the current rows and reports the first broken link.
+--
+-- Audit rows are append-only from here on. They are still removed when their
+-- capability is deleted (ON DELETE CASCADE), which verification reports as a
+-- missing record; export the trail first (GET /audit/export). The enabler,
+-- enabler request and requirement an audit row refers to can no longer be
+-- deleted on their own: those foreign keys are NO ACTION below, since a
+-- cascade would silently drop chained rows and SET NULL would rewrite them.
+
+ALTER TABLE approval_audit_log
+    ADD COLUMN IF NOT EXISTS workspace_id VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS chain_seq BIGINT,
+    ADD COLUMN IF NOT EXISTS prev_hash VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS record_hash VARCHAR(64);
+
+ALTER TABLE entity_state_changes
+    ADD COLUMN IF NOT EXISTS chain_seq BIGINT,
+    ADD COLUMN IF NOT EXISTS prev_hash VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS record_hash VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_approval_audit_log_chain ON approval_audit_log(workspace_id, chain_seq);
+CREATE INDEX IF NOT EXISTS idx_entity_state_changes_chain ON entity_state_changes(COALESCE(workspace_id, ''), chain_seq);
+
+CREATE TABLE IF NOT EXISTS audit_chain_heads (
+    workspace_id VARCHAR(255) PRIMARY KEY,
+    last_seq BIGINT NOT NULL DEFAULT 0,
+    last_hash VARCHAR(64) NOT NULL DEFAULT '',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Canonical content of an audit row. Fields are '|'-separated, NULL is ''.
+CREATE OR REPLACE FUNCTION approval_audit_content(a approval_audit_log)
+RETURNS TEXT AS $$
+    SELECT concat_ws('|', 'approval_audit_log', a.id,
+        COALESCE(a.workspace_id, ''), COALESCE(a.approval_id::text, ''), COALESCE(a.enabler_approval_id::text, ''),
+        a.capability_id, COALESCE(a.enabler_id::text, ''), COALESCE(a.requirement_id::text, ''),
+        a.action, a.stage, COALESCE(a.performed_by::text, ''), COALESCE(a.on_behalf_of::text, ''),
+        COALESCE(to_char(a.performed_at, 'YYYY-MM-DD"T"HH24:MI:SS.US'), ''), COALESCE(a.details::text, ''));
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION entity_state_change_content(e entity_state_changes)
+RETURNS TEXT AS $$
+    SELECT concat_ws('|', 'entity_state_changes', e.id,
+        COALESCE(e.workspace_id, ''), e.entity_type, e.entity_id, e.field_changed,
+        COALESCE(e.old_value, ''), COALESCE(e.new_value, ''), COALESCE(e.change_reason, ''),
+        COALESCE(e.changed_by::text, ''), COALESCE(to_char(e.changed_at, 'YYYY-MM-DD"T"HH24:MI:SS.US'), ''));
+$$ LANGUAGE sql STABLE;
+
+-- Appends content to a workspace chain. The head row lock serialises writers
+-- of the same workspace.
+CREATE OR REPLACE FUNCTION audit_chain_link(ws TEXT, content TEXT, OUT seq BIGINT, OUT prev TEXT, OUT hash TEXT)
+AS $$
+BEGIN
+    INSERT INTO audit_chain_heads (workspace_id) VALUES (ws) ON CONFLICT (workspace_id) DO NOTHING;
+    SELECT last_seq + 1, last_hash INTO seq, prev FROM audit_chain_heads WHERE workspace_id = ws FOR UPDATE;
+    hash := encode(sha256(convert_to(prev || '|' || content, 'UTF8')), 'hex');
+    UPDATE audit_chain_heads SET last_seq = seq, last_hash = hash, updated_at = CURRENT_TIMESTAMP
+    WHERE workspace_id = ws;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION chain_approval_audit_log()
+RETURNS TRIGGER AS $$
+DECLARE
+    link RECORD;
+BEGIN
+    IF NEW.workspace_id IS NULL THEN
+        SELECT COALESCE(workspace_id, '') INTO NEW.workspace_id FROM capabilities WHERE id = NEW.capability_id;
+        NEW.workspace_id := COALESCE(NEW.workspace_id, '');
+    END IF;
+    SELECT * INTO link FROM audit_chain_link(NEW.workspace_id, approval_audit_content(NEW));
+    NEW.chain_seq := link.seq;
+    NEW.prev_hash := link.prev;
+    NEW.record_hash := link.hash;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION chain_entity_state_change()
+RETURNS TRIGGER AS $$
+DECLARE
+    link RECORD;
+BEGIN
+    SELECT * INTO link FROM audit_chain_link(COALESCE(NEW.workspace_id, ''), entity_state_change_content(NEW));
+    NEW.chain_seq := link.seq;
+    NEW.prev_hash := link.prev;
+    NEW.record_hash := link.hash;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Link the rows written before this migration, oldest first
+UPDATE approval_audit_log a
+SET workspace_id = COALESCE(c.workspace_id, '')
+FROM capabilities c
+WHERE c.id = a.capability_id AND a.workspace_id IS NULL;
+
+DO $$
+DECLARE
+    r RECORD;
+    ws TEXT;
+    content TEXT;
+    link RECORD;
+BEGIN
+    FOR r IN
+        SELECT 'approval_audit_log' AS source, id, performed_at AS at FROM approval_audit_log WHERE chain_seq IS NULL
+        UNION ALL
+        SELECT 'entity_state_changes', id, changed_at FROM entity_state_changes WHERE chain_seq IS NULL
+        ORDER BY at, source, id
+    LOOP
+        IF r.source = 'approval_audit_log' THEN
+            SELECT COALESCE(a.workspace_id, ''), approval_audit_content(a) INTO ws, content
+            FROM approval_audit_log a WHERE a.id = r.id;
+            SELECT * INTO link FROM audit_chain_link(ws, content);
+            UPDATE approval_audit_log SET chain_seq = link.seq, prev_hash = link.prev, record_hash = link.hash
+            WHERE id = r.id;
+        ELSE
+            SELECT COALESCE(e.workspace_id, ''), entity_state_change_content(e) INTO ws, content
+            FROM entity_state_changes e WHERE e.id = r.id;
+            SELECT * INTO link FROM audit_chain_link(ws, content);
+            UPDATE entity_state_changes SET chain_seq = link.seq, prev_hash = link.prev, record_hash = link.hash
+            WHERE id = r.id;
+        END IF;
+    END LOOP;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS chain_approval_audit_log ON approval_audit_log;
+CREATE TRIGGER chain_approval_audit_log
+    BEFORE INSERT ON approval_audit_log
+    FOR EACH ROW
+    EXECUTE FUNCTION chain_approval_audit_log();
+
+DROP TRIGGER IF EXISTS chain_entity_state_change ON entity_state_changes;
+CREATE TRIGGER chain_entity_state_change
+    BEFORE INSERT ON entity_state_changes
+    FOR EACH ROW
+    EXECUTE FUNCTION chain_entity_state_change();
+
+-- Rows of a deleted capability still cascade (see above); everything else an
+-- audit row refers to is kept while the row exists
+ALTER TABLE approval_audit_log
+    DROP CONSTRAINT IF EXISTS approval_audit_log_enabler_approval_id_fkey,
+    ADD CONSTRAINT approval_audit_log_enabler_approval_id_fkey
+        FOREIGN KEY (enabler_approval_id) REFERENCES enabler_approvals(id),
+    DROP CONSTRAINT IF EXISTS approval_audit_log_enabler_id_fkey,
+    ADD CONSTRAINT approval_audit_log_enabler_id_fkey
+        FOREIGN KEY (enabler_id) REFERENCES enablers(id),
+    DROP CONSTRAINT IF EXISTS approval_audit_log_requirement_id_fkey,
+    ADD CONSTRAINT approval_audit_log_requirement_id_fkey
+        FOREIGN KEY (requirement_id) REFERENCES enabler_requirements(id);
+
+-- Audit rows are append-only
+CREATE OR REPLACE FUNCTION reject_audit_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION '% rows are append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS reject_approval_audit_log_update ON approval_audit_log;
+CREATE TRIGGER reject_approval_audit_log_update
+    BEFORE UPDATE ON approval_audit_log
+    FOR EACH ROW
+    EXECUTE FUNCTION reject_audit_update();
+
+DROP TRIGGER IF EXISTS reject_entity_state_changes_update ON entity_state_changes;
+CREATE TRIGGER reject_entity_state_changes_update
+    BEFORE UPDATE ON entity_state_changes
+    FOR EACH ROW
+    EXECUTE FUNCTION reject_audit_update();
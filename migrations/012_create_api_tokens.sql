@@ -0,0 +1,31 @@
+-- Migration: Create API Tokens and Service Accounts
+-- Personal access tokens and service account tokens let CI and other
+-- non-interactive clients call the APIs without a password login. Tokens are
+-- shown once when created and stored as a SHA-256 hash; token_prefix keeps
+-- enough of the token to recognise it in listings. Service accounts are users
+-- without a password (they cannot log in) marked in service_accounts, so
+-- everything they do is attributed like any other user.
+
+CREATE TABLE IF NOT EXISTS service_accounts (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    description TEXT,
+    created_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    token_prefix VARCHAR(32) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{}', -- read, write, admin
+    expires_at TIMESTAMP,                -- NULL never expires (service accounts only)
+    revoked_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    last_used_ip VARCHAR(64),
+    created_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens(user_id);
@@ -0,0 +1,31 @@
+-- Migration: Refresh Token Sessions
+-- Logins now return a short-lived access token (JWT) and a refresh token.
+-- Each login is a row in sessions holding the hash of its current refresh
+-- token; every refresh rotates it and keeps the previous hash, so a rotated
+-- token presented again (a stolen copy) revokes the whole session.
+--
+-- users.token_version is carried in access tokens and checked by
+-- VerifyToken. Bumping it (logout everywhere, password, role or status
+-- changes) rejects every access token issued before.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    is_active BOOLEAN DEFAULT true
+);
+
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS previous_token_hash VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS refreshed_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS user_agent TEXT,
+    ADD COLUMN IF NOT EXISTS ip_address VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_token_hash ON sessions(token_hash);
+CREATE INDEX IF NOT EXISTS idx_sessions_previous_token_hash ON sessions(previous_token_hash);
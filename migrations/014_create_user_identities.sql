@@ -0,0 +1,18 @@
+-- Migration: Create User Identities
+-- Links users to their accounts at OpenID Connect providers (Keycloak, Okta,
+-- ...). A provider login finds its user by (provider, subject), so changing
+-- the email address at the provider does not create a second user. The first
+-- login of an existing user is linked by verified email address.
+
+CREATE TABLE IF NOT EXISTS user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(100) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMP,
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
@@ -0,0 +1,41 @@
+-- Migration: Create Workspace Memberships
+-- Workspace-scoped roles replace the yes/no workspace_shares list:
+--   viewer      - read the workspace's specifications and files
+--   contributor - also edit them and request approvals
+--   approver    - also approve and reject approval requests
+--   owner       - also manage the workspace's members
+-- New workspaces get their creator as owner. A workspace without any
+-- membership, which predates this table, can be viewed by everyone until an
+-- admin adds its members. Global admins act as owners everywhere.
+
+CREATE TABLE IF NOT EXISTS workspace_memberships (
+    id SERIAL PRIMARY KEY,
+    workspace_id VARCHAR(255) NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('viewer', 'contributor', 'approver', 'owner')),
+    granted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(workspace_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workspace_memberships_user_id ON workspace_memberships(user_id);
+
+-- Existing shares let users edit the workspace, so they become contributors;
+-- the sharing user owns it. workspace_shares is kept but no longer written.
+DO $$
+BEGIN
+    IF to_regclass('workspace_shares') IS NOT NULL THEN
+        INSERT INTO workspace_memberships (workspace_id, user_id, role, granted_by, created_at)
+        SELECT DISTINCT ON (workspace_id, shared_by_user_id)
+               workspace_id, shared_by_user_id, 'owner', shared_by_user_id, created_at
+        FROM workspace_shares
+        ORDER BY workspace_id, shared_by_user_id, created_at
+        ON CONFLICT (workspace_id, user_id) DO NOTHING;
+
+        INSERT INTO workspace_memberships (workspace_id, user_id, role, granted_by, created_at)
+        SELECT workspace_id, shared_with_user_id, 'contributor', shared_by_user_id, created_at
+        FROM workspace_shares
+        ON CONFLICT (workspace_id, user_id) DO NOTHING;
+    END IF;
+END $$;
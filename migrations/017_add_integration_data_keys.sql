@@ -0,0 +1,13 @@
+-- Migration: Add Integration Data Keys
+-- Integration credentials are encrypted with envelope encryption (pkg/vault):
+-- each integration gets its own random data key, which encrypts its
+-- integration_fields values and is stored wrapped with the master key
+-- (INTENTR_MASTER_KEY). master_key_id names the master key that wrapped it,
+-- so the master key can be rotated with `intentrcli vault-rotate`.
+-- Existing plain-text fields (is_encrypted = false) are encrypted when the
+-- integration-service next starts with a master key, or by vault-rotate.
+
+ALTER TABLE integrations ADD COLUMN IF NOT EXISTS data_key TEXT;
+ALTER TABLE integrations ADD COLUMN IF NOT EXISTS master_key_id VARCHAR(32);
+
+CREATE INDEX IF NOT EXISTS idx_integrations_master_key_id ON integrations(master_key_id);
@@ -0,0 +1,34 @@
+-- Migration: Create Jira Sync Links
+-- Links capabilities and enablers to Jira issues for two-way sync. The last_*
+-- columns hold both sides as of the last sync: a sync compares each side
+-- with them to tell which side changed, and reports a conflict when the same
+-- field changed on both. NULL means that side has not been recorded yet.
+
+CREATE TABLE IF NOT EXISTS jira_sync_links (
+    id SERIAL PRIMARY KEY,
+    workspace_id VARCHAR(255) NOT NULL,
+    entity_type VARCHAR(20) NOT NULL CHECK (entity_type IN ('capability', 'enabler')),
+    entity_id VARCHAR(100) NOT NULL,
+    issue_key VARCHAR(100) NOT NULL,
+
+    -- Jira side
+    last_summary TEXT,
+    last_jira_status VARCHAR(255),
+
+    -- IntentR side
+    last_name TEXT,
+    last_lifecycle_state VARCHAR(50),
+    last_workflow_stage VARCHAR(50),
+    last_stage_status VARCHAR(50),
+    last_approval_status VARCHAR(50),
+
+    last_synced_at TIMESTAMP,
+    last_result VARCHAR(20),
+    last_message TEXT,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(entity_type, entity_id),
+    UNIQUE(workspace_id, issue_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_jira_sync_links_workspace_id ON jira_sync_links(workspace_id);
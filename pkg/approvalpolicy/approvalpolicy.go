@@ -0,0 +1,182 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Package approvalpolicy computes the status of an approval request from the
+// individual votes cast on it. A policy can require N approvals (out of the
+// M users allowed to vote), an ordered chain of roles that must approve in
+// sequence, and veto roles whose rejection rejects the request outright.
+package approvalpolicy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// Validate checks a policy before it is saved
+func Validate(p models.ApprovalPolicy) error {
+	if p.Stage != "all" && !models.IsValidStage(p.Stage) {
+		return fmt.Errorf("invalid stage %q: must be 'all' or one of specification, definition, design, execution", p.Stage)
+	}
+	if p.RequiredApprovals < 1 {
+		return fmt.Errorf("required_approvals must be at least 1")
+	}
+	if len(p.EligibleRoles) > 0 {
+		for _, role := range p.ApprovalChain {
+			if !contains(p.EligibleRoles, role) {
+				return fmt.Errorf("approval chain role %q is not an eligible role", role)
+			}
+		}
+	}
+	for _, roles := range [][]string{p.EligibleRoles, p.ApprovalChain, p.VetoRoles} {
+		for _, role := range roles {
+			if strings.TrimSpace(role) == "" {
+				return fmt.Errorf("role names cannot be empty")
+			}
+		}
+	}
+	return nil
+}
+
+// VoterRoles returns the roles that may vote under p, or nil when any role may
+func VoterRoles(p models.ApprovalPolicy) []string {
+	if len(p.EligibleRoles) == 0 {
+		return nil
+	}
+	roles := append([]string{}, p.EligibleRoles...)
+	for _, role := range append(append([]string{}, p.VetoRoles...), p.FallbackRole) {
+		if role != "" && role != models.AnyRole && !contains(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// CheckVote reports whether vote may be cast given the votes already cast.
+// It returns a *models.ApprovalVoteError when the policy does not allow it.
+func CheckVote(p models.ApprovalPolicy, votes []models.ApprovalVote, vote models.ApprovalVote) error {
+	if vote.Decision != models.VoteApprove && vote.Decision != models.VoteReject {
+		return &models.ApprovalVoteError{
+			Code:    models.VoteErrorInvalidDecision,
+			Message: fmt.Sprintf("invalid vote %q: must be approve or reject", vote.Decision),
+		}
+	}
+	for _, v := range votes {
+		if v.UserID == vote.UserID {
+			return &models.ApprovalVoteError{
+				Code:    models.VoteErrorAlreadyVoted,
+				Role:    vote.Role,
+				Message: "you have already voted on this approval request",
+			}
+		}
+	}
+	if roles := VoterRoles(p); roles != nil && !contains(roles, vote.Role) {
+		return &models.ApprovalVoteError{
+			Code:    models.VoteErrorRoleNotEligible,
+			Role:    vote.Role,
+			Message: fmt.Sprintf("role %q may not vote under the %q policy (eligible: %s)", vote.Role, p.Name, strings.Join(roles, ", ")),
+		}
+	}
+
+	// A chain role approving before the roles ahead of it in the chain
+	if vote.Decision == models.VoteApprove {
+		done := chainProgress(p, votes)
+		if done < len(p.ApprovalChain) && !chainStep(p, done, vote.Role) && contains(p.ApprovalChain[done:], vote.Role) {
+			next := p.ApprovalChain[done]
+			return &models.ApprovalVoteError{
+				Code:     models.VoteErrorOutOfOrder,
+				Role:     vote.Role,
+				NextRole: next,
+				Message:  fmt.Sprintf("waiting for %s approval before %s can approve", next, vote.Role),
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluate computes the status of a request from its votes, in the order
+// they were cast. eligible is the number of users who may vote (M); when it
+// is known a request is rejected as soon as the quorum can no longer be met.
+func Evaluate(p models.ApprovalPolicy, votes []models.ApprovalVote, eligible int) models.ApprovalProgress {
+	required := p.RequiredApprovals
+	if required < 1 {
+		required = 1
+	}
+	progress := models.ApprovalProgress{
+		Status:            models.ApprovalStatusPending,
+		RequiredApprovals: required,
+		EligibleVoters:    eligible,
+		ChainApproved:     []string{},
+	}
+
+	for _, v := range votes {
+		switch v.Decision {
+		case models.VoteApprove:
+			progress.Approvals++
+			if n := len(progress.ChainApproved); n < len(p.ApprovalChain) && chainStep(p, n, v.Role) {
+				progress.ChainApproved = append(progress.ChainApproved, p.ApprovalChain[n])
+			}
+		case models.VoteReject:
+			progress.Rejections++
+			if progress.VetoedBy == "" && isVeto(p, v.Role) {
+				progress.VetoedBy = v.Role
+			}
+		}
+	}
+	if n := len(progress.ChainApproved); n < len(p.ApprovalChain) {
+		progress.NextRole = p.ApprovalChain[n]
+	}
+
+	switch {
+	case progress.VetoedBy != "":
+		progress.Status = models.ApprovalStatusRejected
+		progress.Reason = fmt.Sprintf("vetoed by %s", progress.VetoedBy)
+	case eligible > 0 && progress.Approvals+eligible-len(votes) < required:
+		progress.Status = models.ApprovalStatusRejected
+		progress.Reason = fmt.Sprintf("%d of %d approvals can no longer be reached", required, eligible)
+	case progress.Approvals >= required && progress.NextRole == "":
+		progress.Status = models.ApprovalStatusApproved
+		progress.Reason = fmt.Sprintf("%d of %d approvals", progress.Approvals, required)
+	case progress.NextRole != "":
+		progress.Reason = fmt.Sprintf("waiting for %s approval", progress.NextRole)
+	default:
+		progress.Reason = fmt.Sprintf("%d of %d approvals", progress.Approvals, required)
+	}
+	return progress
+}
+
+// chainProgress returns how many chain roles have approved, in order
+func chainProgress(p models.ApprovalPolicy, votes []models.ApprovalVote) int {
+	done := 0
+	for _, v := range votes {
+		if v.Decision == models.VoteApprove && done < len(p.ApprovalChain) && chainStep(p, done, v.Role) {
+			done++
+		}
+	}
+	return done
+}
+
+// chainStep reports whether an approval from role completes step n of the
+// chain. After escalation the fallback role stands in for any step.
+func chainStep(p models.ApprovalPolicy, n int, role string) bool {
+	return p.ApprovalChain[n] == role || (p.FallbackRole != "" && p.FallbackRole == role)
+}
+
+func isVeto(p models.ApprovalPolicy, role string) bool {
+	return contains(p.VetoRoles, models.AnyRole) || contains(p.VetoRoles, role)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package approvalpolicy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+func vote(user int, role, decision string) models.ApprovalVote {
+	return models.ApprovalVote{UserID: user, Role: role, Decision: decision}
+}
+
+func TestEvaluate(t *testing.T) {
+	chain := models.ApprovalPolicy{
+		Name:              "Architecture review",
+		RequiredApprovals: 3,
+		EligibleRoles:     []string{"architect", "product_owner", "engineer"},
+		ApprovalChain:     []string{"architect", "product_owner"},
+		VetoRoles:         []string{"architect"},
+	}
+
+	escalated := chain
+	escalated.FallbackRole = "admin"
+
+	tests := []struct {
+		name     string
+		policy   models.ApprovalPolicy
+		votes    []models.ApprovalVote
+		eligible int
+		status   models.ApprovalStatus
+		next     string
+	}{
+		{name: "default approves on first approval", policy: models.DefaultApprovalPolicy(), votes: []models.ApprovalVote{vote(1, "admin", models.VoteApprove)}, status: models.ApprovalStatusApproved},
+		{name: "default rejects on first rejection", policy: models.DefaultApprovalPolicy(), votes: []models.ApprovalVote{vote(1, "designer", models.VoteReject)}, status: models.ApprovalStatusRejected},
+		{name: "quorum not yet met", policy: chain, votes: []models.ApprovalVote{vote(1, "architect", models.VoteApprove), vote(2, "product_owner", models.VoteApprove)}, eligible: 5, status: models.ApprovalStatusPending},
+		{name: "quorum met but chain incomplete", policy: chain, votes: []models.ApprovalVote{vote(1, "architect", models.VoteApprove), vote(2, "engineer", models.VoteApprove), vote(3, "engineer", models.VoteApprove)}, eligible: 5, status: models.ApprovalStatusPending, next: "product_owner"},
+		{name: "quorum and chain met", policy: chain, votes: []models.ApprovalVote{vote(1, "architect", models.VoteApprove), vote(2, "engineer", models.VoteApprove), vote(3, "product_owner", models.VoteApprove)}, eligible: 5, status: models.ApprovalStatusApproved},
+		{name: "non-veto rejection keeps voting open", policy: chain, votes: []models.ApprovalVote{vote(1, "engineer", models.VoteReject)}, eligible: 5, status: models.ApprovalStatusPending, next: "architect"},
+		{name: "veto rejects", policy: chain, votes: []models.ApprovalVote{vote(1, "engineer", models.VoteApprove), vote(2, "architect", models.VoteReject)}, eligible: 5, status: models.ApprovalStatusRejected, next: "architect"},
+		{name: "fallback role stands in after escalation", policy: escalated, votes: []models.ApprovalVote{vote(1, "admin", models.VoteApprove), vote(2, "product_owner", models.VoteApprove), vote(3, "engineer", models.VoteApprove)}, eligible: 6, status: models.ApprovalStatusApproved},
+		{name: "quorum unreachable", policy: chain, votes: []models.ApprovalVote{vote(1, "engineer", models.VoteReject), vote(2, "product_owner", models.VoteReject)}, eligible: 4, status: models.ApprovalStatusRejected, next: "architect"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Evaluate(tt.policy, tt.votes, tt.eligible)
+			if got.Status != tt.status || got.NextRole != tt.next {
+				t.Errorf("Evaluate() = %s (next %q, %s), want %s (next %q)", got.Status, got.NextRole, got.Reason, tt.status, tt.next)
+			}
+		})
+	}
+}
+
+func TestCheckVote(t *testing.T) {
+	p := models.ApprovalPolicy{
+		Name:              "Sign-off",
+		RequiredApprovals: 2,
+		EligibleRoles:     []string{"architect", "product_owner"},
+		ApprovalChain:     []string{"architect", "product_owner"},
+		VetoRoles:         []string{"security"},
+	}
+	cast := []models.ApprovalVote{vote(1, "architect", models.VoteApprove)}
+
+	tests := []struct {
+		name  string
+		votes []models.ApprovalVote
+		vote  models.ApprovalVote
+		code  string
+	}{
+		{name: "first in chain", vote: vote(1, "architect", models.VoteApprove)},
+		{name: "next in chain", votes: cast, vote: vote(2, "product_owner", models.VoteApprove)},
+		{name: "out of order", vote: vote(2, "product_owner", models.VoteApprove), code: models.VoteErrorOutOfOrder},
+		{name: "rejection out of order is allowed", vote: vote(2, "product_owner", models.VoteReject)},
+		{name: "veto role may vote", vote: vote(3, "security", models.VoteReject)},
+		{name: "ineligible role", vote: vote(4, "designer", models.VoteApprove), code: models.VoteErrorRoleNotEligible},
+		{name: "second vote", votes: cast, vote: vote(1, "architect", models.VoteReject), code: models.VoteErrorAlreadyVoted},
+		{name: "unknown decision", vote: vote(1, "architect", "abstain"), code: models.VoteErrorInvalidDecision},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckVote(p, tt.votes, tt.vote)
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("CheckVote() = %v, want nil", err)
+				}
+				return
+			}
+			var voteErr *models.ApprovalVoteError
+			if !errors.As(err, &voteErr) || voteErr.Code != tt.code {
+				t.Fatalf("CheckVote() = %v, want %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(models.DefaultApprovalPolicy()); err != nil {
+		t.Errorf("Validate(default) = %v", err)
+	}
+	bad := models.ApprovalPolicy{Stage: "design", RequiredApprovals: 1, EligibleRoles: []string{"engineer"}, ApprovalChain: []string{"architect"}}
+	if err := Validate(bad); err == nil {
+		t.Error("Validate() accepted a chain role that cannot vote")
+	}
+	if err := Validate(models.ApprovalPolicy{Stage: "review", RequiredApprovals: 1}); err == nil {
+		t.Error("Validate() accepted an unknown stage")
+	}
+}
@@ -0,0 +1,180 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Package approvalsla tracks pending approval requests against their stage
+// SLAs. A Scheduler periodically sends reminders for requests that reach
+// their reminder time and flags requests past their due time as overdue,
+// escalating them to the SLA's fallback approver role.
+package approvalsla
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// Actions taken by the scheduler, also used as approval_audit_log actions
+const (
+	ActionRemind      = "reminder"
+	ActionEscalate    = "escalated"
+	ActionFlagOverdue = "overdue" // Overdue without an escalation role
+)
+
+// Bucket is an age range of pending requests; Max is exclusive and zero for the last bucket
+type Bucket struct {
+	Name string
+	Max  time.Duration
+}
+
+const day = 24 * time.Hour
+
+// Buckets are the age ranges reported by GroupByAge, youngest first
+var Buckets = []Bucket{
+	{Name: "0-1d", Max: day},
+	{Name: "1-3d", Max: 3 * day},
+	{Name: "3-7d", Max: 7 * day},
+	{Name: "7-14d", Max: 14 * day},
+	{Name: "14d+"},
+}
+
+// BucketFor returns the name of the bucket for a request of the given age
+func BucketFor(age time.Duration) string {
+	for _, b := range Buckets {
+		if b.Max == 0 || age < b.Max {
+			return b.Name
+		}
+	}
+	return Buckets[len(Buckets)-1].Name
+}
+
+// Refresh sets the age and overdue flag of a request as of now
+func Refresh(a *models.PendingApprovalAge, now time.Time) {
+	a.AgeHours = now.Sub(a.RequestedAt).Hours()
+	a.Overdue = a.OverdueAt != nil || (a.DueAt != nil && !now.Before(*a.DueAt))
+}
+
+// GroupByAge sorts requests into Buckets as of now. Every bucket is
+// returned, including empty ones.
+func GroupByAge(approvals []models.PendingApprovalAge, now time.Time) []models.ApprovalAgeBucket {
+	buckets := make([]models.ApprovalAgeBucket, len(Buckets))
+	index := make(map[string]int, len(Buckets))
+	for i, b := range Buckets {
+		buckets[i] = models.ApprovalAgeBucket{Bucket: b.Name, Approvals: []models.PendingApprovalAge{}}
+		index[b.Name] = i
+	}
+	for _, a := range approvals {
+		Refresh(&a, now)
+		b := &buckets[index[BucketFor(now.Sub(a.RequestedAt))]]
+		b.Count++
+		if a.Overdue {
+			b.Overdue++
+		}
+		b.Approvals = append(b.Approvals, a)
+	}
+	return buckets
+}
+
+// NextAction returns what the scheduler should do with a request now, or ""
+func NextAction(a models.PendingApprovalAge, now time.Time) string {
+	if a.DueAt != nil && a.OverdueAt == nil && !now.Before(*a.DueAt) {
+		if a.EscalationRole != "" {
+			return ActionEscalate
+		}
+		return ActionFlagOverdue
+	}
+	if a.RemindAt != nil && a.RemindedAt == nil && a.OverdueAt == nil && !now.Before(*a.RemindAt) {
+		return ActionRemind
+	}
+	return ""
+}
+
+// Store is where the scheduler finds pending requests and records its actions.
+// Remind and MarkOverdue report false when the request was no longer
+// pending or another scheduler already acted on it.
+type Store interface {
+	ListPendingApprovalAges(workspaceID string) ([]models.PendingApprovalAge, error)
+	Remind(approvalID int, now time.Time) (bool, error)
+	MarkOverdue(approvalID int, escalateTo string, now time.Time) (bool, error)
+}
+
+// Scheduler applies SLAs to pending approval requests
+type Scheduler struct {
+	store Store
+
+	// Interval is how often pending requests are checked
+	Interval time.Duration
+	// Now is the scheduler's clock
+	Now func() time.Time
+	// Notify, if set, is called after each action
+	Notify func(action string, approval models.PendingApprovalAge)
+}
+
+// NewScheduler creates a scheduler for store
+func NewScheduler(store Store) *Scheduler {
+	return &Scheduler{store: store, Interval: 5 * time.Minute, Now: time.Now}
+}
+
+// Run checks pending requests until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Tick(); err != nil {
+			log.Printf("Approval SLA scheduler: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick takes the actions due now and returns how many were taken
+func (s *Scheduler) Tick() (int, error) {
+	now := s.Now()
+	approvals, err := s.store.ListPendingApprovalAges("")
+	if err != nil {
+		return 0, err
+	}
+
+	taken := 0
+	for _, a := range approvals {
+		action := NextAction(a, now)
+		var done bool
+		switch action {
+		case ActionRemind:
+			done, err = s.store.Remind(a.ApprovalID, now)
+			a.RemindedAt = &now
+		case ActionEscalate, ActionFlagOverdue:
+			escalateTo := ""
+			if action == ActionEscalate {
+				escalateTo = a.EscalationRole
+			}
+			done, err = s.store.MarkOverdue(a.ApprovalID, escalateTo, now)
+			a.OverdueAt, a.EscalatedToRole = &now, escalateTo
+		default:
+			continue
+		}
+		if err != nil {
+			log.Printf("Approval SLA scheduler: %s approval %d: %v", action, a.ApprovalID, err)
+			continue
+		}
+		if !done {
+			continue
+		}
+		taken++
+		if s.Notify != nil {
+			Refresh(&a, now)
+			s.Notify(action, a)
+		}
+	}
+	return taken, nil
+}
@@ -0,0 +1,127 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package approvalsla
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+var now = time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+
+func pending(id int, age, remindAfter, dueAfter time.Duration, role string) models.PendingApprovalAge {
+	requested := now.Add(-age)
+	a := models.PendingApprovalAge{ApprovalID: id, RequestedAt: requested, EscalationRole: role}
+	if remindAfter > 0 {
+		t := requested.Add(remindAfter)
+		a.RemindAt = &t
+	}
+	if dueAfter > 0 {
+		t := requested.Add(dueAfter)
+		a.DueAt = &t
+	}
+	return a
+}
+
+func TestBucketFor(t *testing.T) {
+	tests := map[time.Duration]string{
+		time.Hour: "0-1d",
+		day:       "1-3d",
+		5 * day:   "3-7d",
+		13 * day:  "7-14d",
+		90 * day:  "14d+",
+	}
+	for age, want := range tests {
+		if got := BucketFor(age); got != want {
+			t.Errorf("BucketFor(%v) = %q, want %q", age, got, want)
+		}
+	}
+}
+
+func TestGroupByAge(t *testing.T) {
+	buckets := GroupByAge([]models.PendingApprovalAge{
+		pending(1, time.Hour, 0, 0, ""),
+		pending(2, 2*day, 0, day, ""),
+		pending(3, 2*day, 0, 3*day, ""),
+	}, now)
+
+	if len(buckets) != len(Buckets) {
+		t.Fatalf("got %d buckets, want %d", len(buckets), len(Buckets))
+	}
+	if b := buckets[1]; b.Bucket != "1-3d" || b.Count != 2 || b.Overdue != 1 {
+		t.Errorf("1-3d bucket = %+v, want 2 approvals with 1 overdue", b)
+	}
+	if got := buckets[0].Approvals[0].AgeHours; got != 1 {
+		t.Errorf("age = %v hours, want 1", got)
+	}
+}
+
+type fakeStore struct {
+	approvals []models.PendingApprovalAge
+	reminded  []int
+	overdue   map[int]string
+}
+
+func (f *fakeStore) ListPendingApprovalAges(string) ([]models.PendingApprovalAge, error) {
+	return f.approvals, nil
+}
+
+func (f *fakeStore) Remind(id int, _ time.Time) (bool, error) {
+	f.reminded = append(f.reminded, id)
+	return true, nil
+}
+
+func (f *fakeStore) MarkOverdue(id int, role string, _ time.Time) (bool, error) {
+	f.overdue[id] = role
+	return true, nil
+}
+
+func TestSchedulerTick(t *testing.T) {
+	flagged := pending(5, 3*day, 0, day, "")
+	flagged.OverdueAt = &now
+
+	store := &fakeStore{
+		approvals: []models.PendingApprovalAge{
+			pending(1, time.Hour, 4*time.Hour, day, "admin"), // Nothing due yet
+			pending(2, 5*time.Hour, 4*time.Hour, day, "admin"),
+			pending(3, 2*day, 4*time.Hour, day, "admin"), // Overdue beats reminder
+			pending(4, 2*day, 0, day, ""),
+			flagged,
+		},
+		overdue: map[int]string{},
+	}
+	var notified []string
+	s := NewScheduler(store)
+	s.Now = func() time.Time { return now }
+	s.Notify = func(action string, a models.PendingApprovalAge) {
+		notified = append(notified, action)
+	}
+
+	n, err := s.Tick()
+	if err != nil || n != 3 {
+		t.Fatalf("Tick() = %d, %v; want 3 actions", n, err)
+	}
+	if len(store.reminded) != 1 || store.reminded[0] != 2 {
+		t.Errorf("reminded %v, want [2]", store.reminded)
+	}
+	if role, ok := store.overdue[3]; !ok || role != "admin" {
+		t.Errorf("approval 3 escalated to %q, want admin", role)
+	}
+	if role, ok := store.overdue[4]; !ok || role != "" {
+		t.Errorf("approval 4 = %q, %v; want flagged without escalation", role, ok)
+	}
+	if _, ok := store.overdue[5]; ok {
+		t.Error("approval 5 was flagged twice")
+	}
+	if len(notified) != 3 {
+		t.Errorf("notified %v, want 3 actions", notified)
+	}
+}
@@ -0,0 +1,173 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Package auditchain verifies the per-workspace hash chain that links
+// approval_audit_log and entity_state_changes rows (migration 011) and signs
+// audit trail exports.
+//
+// Postgres links each record as it is inserted:
+//
+//	hash = hex(sha256(prev_hash + "|" + content))
+//
+// Verify recomputes the chain from the current content of the rows, so an
+// edited row breaks its own hash and a deleted one leaves a gap in the
+// sequence. Exports are signed with Ed25519; receivers check the signature
+// with VerifyExport against the service's published public key.
+package auditchain
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// Break kinds
+const (
+	BreakMissingRecord   = "missing-record"   // A sequence number has no record; it was deleted
+	BreakDuplicateRecord = "duplicate-record" // Two records share a sequence number
+	BreakLinkMismatch    = "link-mismatch"    // prev_hash is not the previous record's hash
+	BreakHashMismatch    = "hash-mismatch"    // The record's content was changed after it was written
+	BreakHeadMismatch    = "head-mismatch"    // The newest records were removed or replaced
+)
+
+// SignatureAlgorithm is the algorithm of export signatures
+const SignatureAlgorithm = "ed25519"
+
+// Link is a position in a chain: a record's sequence number and hash
+type Link struct {
+	Seq  int64
+	Hash string
+}
+
+// Hash returns the hash of a record with the given content following prevHash
+func Hash(prevHash, content string) string {
+	sum := sha256.Sum256([]byte(prevHash + "|" + content))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify walks records, ordered by sequence number, starting after from and
+// returns the first broken link, or nil if the chain is intact. The whole
+// chain of a workspace starts after Link{}. With head, the last record must
+// be the chain head.
+func Verify(records []models.AuditChainRecord, from Link, head *Link) *models.AuditChainBreak {
+	prev := from
+	for _, r := range records {
+		breakAt := func(kind, message string) *models.AuditChainBreak {
+			return &models.AuditChainBreak{
+				Seq: r.Seq, Source: r.Source, SourceID: r.SourceID, Kind: kind, Message: message,
+				ExpectedPrevHash: prev.Hash, RecordedAt: &r.RecordedAt,
+			}
+		}
+		switch {
+		case r.Seq > prev.Seq+1:
+			return &models.AuditChainBreak{
+				Seq: prev.Seq + 1, Kind: BreakMissingRecord, ExpectedPrevHash: prev.Hash,
+				Message: fmt.Sprintf("records %d to %d are missing", prev.Seq+1, r.Seq-1),
+			}
+		case r.Seq <= prev.Seq:
+			return breakAt(BreakDuplicateRecord, fmt.Sprintf("record %d appears more than once", r.Seq))
+		case r.PrevHash != prev.Hash:
+			return breakAt(BreakLinkMismatch, "the record does not link to the previous record")
+		case Hash(r.PrevHash, r.Content) != r.Hash:
+			return breakAt(BreakHashMismatch, "the record was changed after it was written")
+		}
+		prev = Link{Seq: r.Seq, Hash: r.Hash}
+	}
+
+	if head != nil && *head != prev {
+		if head.Seq > prev.Seq {
+			return &models.AuditChainBreak{
+				Seq: prev.Seq + 1, Kind: BreakMissingRecord, ExpectedPrevHash: prev.Hash,
+				Message: fmt.Sprintf("records %d to %d are missing", prev.Seq+1, head.Seq),
+			}
+		}
+		return &models.AuditChainBreak{
+			Seq: prev.Seq, Kind: BreakHeadMismatch, ExpectedPrevHash: prev.Hash,
+			Message: fmt.Sprintf("record %d does not match the chain head (record %d, hash %s)", prev.Seq, head.Seq, head.Hash),
+		}
+	}
+	return nil
+}
+
+// GenerateKey returns a new export signing key, encoded for LoadKey
+func GenerateKey() (ed25519.PrivateKey, string, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	return key, base64.StdEncoding.EncodeToString(key.Seed()), nil
+}
+
+// LoadKey decodes a base64 Ed25519 seed into an export signing key
+func LoadKey(encoded string) (ed25519.PrivateKey, error) {
+	seed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid signing key: want a %d byte seed, got %d bytes", ed25519.SeedSize, len(seed))
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// PublicKey returns the base64 public key of a signing key
+func PublicKey(key ed25519.PrivateKey) string {
+	return base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+}
+
+// signedBytes is what an export signature covers: the export without its signature
+func signedBytes(export models.AuditExport) ([]byte, error) {
+	export.Signature = ""
+	return json.Marshal(export)
+}
+
+// Sign sets the algorithm, public key and signature of an export
+func Sign(key ed25519.PrivateKey, export *models.AuditExport) error {
+	export.Algorithm = SignatureAlgorithm
+	export.PublicKey = PublicKey(key)
+	payload, err := signedBytes(*export)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit export: %w", err)
+	}
+	export.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))
+	return nil
+}
+
+// VerifyExport checks an export's signature against publicKey, the base64
+// public key published by the service
+func VerifyExport(export models.AuditExport, publicKey string) error {
+	if export.Algorithm != SignatureAlgorithm {
+		return fmt.Errorf("unsupported signature algorithm %q", export.Algorithm)
+	}
+	if export.PublicKey != publicKey {
+		return errors.New("export was signed with a different key")
+	}
+	pub, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return errors.New("invalid public key")
+	}
+	signature, err := base64.StdEncoding.DecodeString(export.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	payload, err := signedBytes(export)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit export: %w", err)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(pub), payload, signature) {
+		return errors.New("signature does not match the export")
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package auditchain
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// chain builds n linked records the way the database trigger does
+func chain(n int) []models.AuditChainRecord {
+	records := make([]models.AuditChainRecord, n)
+	prev := ""
+	for i := range records {
+		content := fmt.Sprintf("approval_audit_log|%d|ws-1|vote", i+1)
+		records[i] = models.AuditChainRecord{
+			Seq: int64(i + 1), Source: models.AuditSourceApprovalAuditLog, SourceID: i + 1,
+			Content: content, PrevHash: prev, Hash: Hash(prev, content),
+		}
+		prev = records[i].Hash
+	}
+	return records
+}
+
+func headOf(records []models.AuditChainRecord) *Link {
+	last := records[len(records)-1]
+	return &Link{Seq: last.Seq, Hash: last.Hash}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func([]models.AuditChainRecord) ([]models.AuditChainRecord, *Link)
+		kind   string
+		seq    int64
+	}{
+		{name: "intact", tamper: func(r []models.AuditChainRecord) ([]models.AuditChainRecord, *Link) { return r, headOf(r) }},
+		{name: "edited content", kind: BreakHashMismatch, seq: 3, tamper: func(r []models.AuditChainRecord) ([]models.AuditChainRecord, *Link) {
+			head := headOf(r)
+			r[2].Content += "|approved"
+			return r, head
+		}},
+		{name: "edited and rehashed", kind: BreakLinkMismatch, seq: 4, tamper: func(r []models.AuditChainRecord) ([]models.AuditChainRecord, *Link) {
+			head := headOf(r)
+			r[2].Content += "|approved"
+			r[2].Hash = Hash(r[2].PrevHash, r[2].Content)
+			return r, head
+		}},
+		{name: "deleted record", kind: BreakMissingRecord, seq: 2, tamper: func(r []models.AuditChainRecord) ([]models.AuditChainRecord, *Link) {
+			return append(r[:1:1], r[2:]...), headOf(r)
+		}},
+		{name: "deleted newest record", kind: BreakMissingRecord, seq: 5, tamper: func(r []models.AuditChainRecord) ([]models.AuditChainRecord, *Link) {
+			return r[:4], headOf(r)
+		}},
+		{name: "replaced newest record", kind: BreakHeadMismatch, seq: 5, tamper: func(r []models.AuditChainRecord) ([]models.AuditChainRecord, *Link) {
+			head := headOf(r)
+			r[4].Content += "|forged"
+			r[4].Hash = Hash(r[4].PrevHash, r[4].Content)
+			return r, head
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, head := tt.tamper(chain(5))
+			got := Verify(records, Link{}, head)
+			if tt.kind == "" {
+				if got != nil {
+					t.Fatalf("Verify() = %+v, want intact", got)
+				}
+				return
+			}
+			if got == nil || got.Kind != tt.kind || got.Seq != tt.seq {
+				t.Fatalf("Verify() = %+v, want %s at %d", got, tt.kind, tt.seq)
+			}
+		})
+	}
+}
+
+func TestVerifySegment(t *testing.T) {
+	records := chain(5)[2:]
+	from := Link{Seq: records[0].Seq - 1, Hash: records[0].PrevHash}
+	if got := Verify(records, from, nil); got != nil {
+		t.Errorf("Verify(segment) = %+v, want intact", got)
+	}
+}
+
+func TestSignExport(t *testing.T) {
+	key, encoded, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadKey(encoded)
+	if err != nil || !loaded.Equal(key) {
+		t.Fatalf("LoadKey() did not round-trip: %v", err)
+	}
+
+	export := models.AuditExport{
+		WorkspaceID: "ws-1",
+		From:        time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		To:          time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		Records:     chain(3),
+		RecordCount: 3,
+	}
+	if err := Sign(key, &export); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyExport(export, PublicKey(key)); err != nil {
+		t.Fatalf("VerifyExport() = %v", err)
+	}
+
+	export.Records[1].Content += "|approved"
+	if err := VerifyExport(export, PublicKey(key)); err == nil {
+		t.Error("VerifyExport() accepted an altered export")
+	}
+
+	other, _, _ := GenerateKey()
+	if err := VerifyExport(export, PublicKey(other)); err == nil {
+		t.Error("VerifyExport() accepted an export signed with another key")
+	}
+}
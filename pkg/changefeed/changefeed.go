@@ -0,0 +1,224 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Package changefeed wakes server-sent event streams when a workspace's
+// change_events table grows. Database triggers announce every new event with
+// NOTIFY, so a Feed listening on each replica sees writes made through any
+// of them. Subscribers are only woken; they read the events themselves from
+// the last ID they sent, which makes live delivery and resuming one path.
+package changefeed
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/lib/pq"
+)
+
+// Channel is the NOTIFY channel used by migration 005
+const Channel = "intentr_change_events"
+
+// Feed fans notifications out to the subscribers of each workspace
+type Feed struct {
+	mu       sync.Mutex
+	subs     map[string]map[chan struct{}]struct{}
+	all      map[chan struct{}]struct{} // Subscribers to every workspace
+	closed   bool
+	listener *pq.Listener
+}
+
+// New creates a Feed that is only woken through Notify
+func New() *Feed {
+	return &Feed{
+		subs: make(map[string]map[chan struct{}]struct{}),
+		all:  make(map[chan struct{}]struct{}),
+	}
+}
+
+// Listen creates a Feed that LISTENs on Channel using its own connection.
+// The connection is re-established automatically; after a reconnect every
+// subscriber is woken in case a notification was lost.
+func Listen(dsn string) (*Feed, error) {
+	f := New()
+	f.listener = pq.NewListener(dsn, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Change feed listener: %v", err)
+		}
+	})
+	if err := f.listener.Listen(Channel); err != nil {
+		f.listener.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %w", Channel, err)
+	}
+	go f.run()
+	return f, nil
+}
+
+func (f *Feed) run() {
+	for {
+		select {
+		case n, ok := <-f.listener.NotificationChannel():
+			if !ok {
+				return
+			}
+			if n == nil {
+				f.notifyAll() // Reconnected
+				continue
+			}
+			f.handle(n.Extra)
+		case <-time.After(90 * time.Second):
+			go f.listener.Ping()
+		}
+	}
+}
+
+// handle wakes the workspace named in a notification payload
+func (f *Feed) handle(payload string) {
+	var msg struct {
+		WorkspaceID string `json:"workspace_id"`
+	}
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		log.Printf("Change feed: ignoring malformed notification %q: %v", payload, err)
+		return
+	}
+	f.Notify(msg.WorkspaceID)
+}
+
+// Subscribe returns a channel that receives a value whenever the workspace
+// may have new events, and a function to unsubscribe. Wake-ups are
+// coalesced. The channel is closed when the Feed is closed.
+func (f *Feed) Subscribe(workspaceID string) (<-chan struct{}, func()) {
+	wake := make(chan struct{}, 1)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		close(wake)
+		return wake, func() {}
+	}
+	if f.subs[workspaceID] == nil {
+		f.subs[workspaceID] = make(map[chan struct{}]struct{})
+	}
+	f.subs[workspaceID][wake] = struct{}{}
+
+	return wake, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.subs[workspaceID][wake]; !ok {
+			return // Already closed
+		}
+		delete(f.subs[workspaceID], wake)
+		if len(f.subs[workspaceID]) == 0 {
+			delete(f.subs, workspaceID)
+		}
+	}
+}
+
+// SubscribeAll is Subscribe for the events of every workspace, for
+// background workers that act on changes wherever they are made
+func (f *Feed) SubscribeAll() (<-chan struct{}, func()) {
+	wake := make(chan struct{}, 1)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		close(wake)
+		return wake, func() {}
+	}
+	f.all[wake] = struct{}{}
+
+	return wake, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.all, wake)
+	}
+}
+
+// Notify wakes every subscriber of a workspace
+func (f *Feed) Notify(workspaceID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for wake := range f.subs[workspaceID] {
+		signal(wake)
+	}
+	for wake := range f.all {
+		signal(wake)
+	}
+}
+
+func (f *Feed) notifyAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, subs := range f.subs {
+		for wake := range subs {
+			signal(wake)
+		}
+	}
+	for wake := range f.all {
+		signal(wake)
+	}
+}
+
+func signal(wake chan struct{}) {
+	select {
+	case wake <- struct{}{}:
+	default: // Already pending
+	}
+}
+
+// Subscribers returns the number of open subscriptions
+func (f *Feed) Subscribers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := len(f.all)
+	for _, subs := range f.subs {
+		n += len(subs)
+	}
+	return n
+}
+
+// Close ends every subscription and stops listening
+func (f *Feed) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	for _, subs := range f.subs {
+		for wake := range subs {
+			close(wake)
+		}
+	}
+	for wake := range f.all {
+		close(wake)
+	}
+	f.subs = make(map[string]map[chan struct{}]struct{})
+	f.all = make(map[chan struct{}]struct{})
+	f.mu.Unlock()
+
+	if f.listener != nil {
+		return f.listener.Close()
+	}
+	return nil
+}
+
+// WriteEvent writes e in server-sent event format, with the event ID as the
+// SSE id so a reconnecting EventSource sends it back as Last-Event-ID
+func WriteEvent(w io.Writer, e models.ChangeEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode change event: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.EventType, data)
+	return err
+}
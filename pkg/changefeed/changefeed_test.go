@@ -0,0 +1,123 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package changefeed
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+func woken(ch <-chan struct{}) bool {
+	select {
+	case _, ok := <-ch:
+		return ok
+	default:
+		return false
+	}
+}
+
+func TestNotifyWakesOnlyThatWorkspace(t *testing.T) {
+	f := New()
+	a, cancelA := f.Subscribe("ws-a")
+	defer cancelA()
+	b, cancelB := f.Subscribe("ws-b")
+	defer cancelB()
+
+	f.handle(`{"id":7,"workspace_id":"ws-a"}`)
+	f.handle(`{"id":8,"workspace_id":"ws-a"}`)
+
+	if !woken(a) {
+		t.Error("ws-a subscriber was not woken")
+	}
+	if woken(a) {
+		t.Error("wake-ups were not coalesced")
+	}
+	if woken(b) {
+		t.Error("ws-b subscriber was woken by a ws-a event")
+	}
+
+	f.handle("not json")
+	if woken(a) || woken(b) {
+		t.Error("malformed notification woke a subscriber")
+	}
+}
+
+func TestSubscribeAllWakesOnEveryWorkspace(t *testing.T) {
+	f := New()
+	all, cancel := f.SubscribeAll()
+
+	f.handle(`{"id":7,"workspace_id":"ws-a"}`)
+	if !woken(all) {
+		t.Error("SubscribeAll subscriber was not woken by a ws-a event")
+	}
+	f.Notify("ws-b")
+	if !woken(all) {
+		t.Error("SubscribeAll subscriber was not woken by a ws-b event")
+	}
+
+	cancel()
+	if n := f.Subscribers(); n != 0 {
+		t.Errorf("Subscribers() after cancel = %d, want 0", n)
+	}
+	f.Notify("ws-a")
+	if woken(all) {
+		t.Error("cancelled subscriber was woken")
+	}
+
+	kept, _ := f.SubscribeAll()
+	f.Close()
+	if _, ok := <-kept; ok {
+		t.Error("SubscribeAll subscription still open after Close")
+	}
+}
+
+func TestUnsubscribeAndClose(t *testing.T) {
+	f := New()
+	_, cancel := f.Subscribe("ws")
+	kept, _ := f.Subscribe("ws")
+	cancel()
+	cancel()
+	if n := f.Subscribers(); n != 1 {
+		t.Fatalf("Subscribers() = %d, want 1", n)
+	}
+
+	f.Close()
+	if _, ok := <-kept; ok {
+		t.Error("subscription still open after Close")
+	}
+	late, _ := f.Subscribe("ws")
+	if _, ok := <-late; ok {
+		t.Error("Subscribe after Close returned an open channel")
+	}
+}
+
+func TestWriteEvent(t *testing.T) {
+	var b strings.Builder
+	err := WriteEvent(&b, models.ChangeEvent{
+		ID:          42,
+		WorkspaceID: "ws",
+		EventType:   models.ChangeEventPhaseApproval,
+		Payload:     json.RawMessage(`{"phase":"specification","is_approved":true}`),
+		CreatedAt:   time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "id: 42\nevent: phase_approval\ndata: " +
+		`{"id":42,"workspace_id":"ws","event_type":"phase_approval","payload":{"phase":"specification","is_approved":true},"created_at":"2025-01-02T03:04:05Z"}` +
+		"\n\n"
+	if b.String() != want {
+		t.Errorf("WriteEvent() =\n%q\nwant\n%q", b.String(), want)
+	}
+}
@@ -0,0 +1,224 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Package diagram renders capability maps, enabler dependency diagrams and
+// the INTENT state model as Mermaid, PlantUML or Graphviz DOT. Output is
+// built directly from the dependency graph, so the same workspace always
+// produces byte-identical text.
+package diagram
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jareynolds/intentr/pkg/graph"
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// Kind selects which diagram to build
+type Kind string
+
+const (
+	KindCapabilityMap       Kind = "capability-map"
+	KindEnablerDependencies Kind = "enabler-dependencies"
+	KindStateModel          Kind = "state-model"
+)
+
+// Format selects the output syntax
+type Format string
+
+const (
+	FormatMermaid  Format = "mermaid"
+	FormatPlantUML Format = "plantuml"
+	FormatDOT      Format = "dot"
+)
+
+// ParseKind reports whether s names a diagram this package can build
+func ParseKind(s string) (Kind, bool) {
+	switch Kind(s) {
+	case KindCapabilityMap, KindEnablerDependencies, KindStateModel:
+		return Kind(s), true
+	}
+	return "", false
+}
+
+// ParseFormat accepts a Format, defaulting to Mermaid when s is empty
+func ParseFormat(s string) (Format, bool) {
+	switch Format(s) {
+	case "":
+		return FormatMermaid, true
+	case FormatMermaid, FormatPlantUML, FormatDOT:
+		return Format(s), true
+	case "graphviz":
+		return FormatDOT, true
+	}
+	return "", false
+}
+
+// Stages is the INTENT workflow in order
+var Stages = []string{
+	string(models.INTENTStageIntent),
+	string(models.INTENTStageSpecification),
+	string(models.INTENTStageUIDesign),
+	string(models.INTENTStageImplementation),
+	string(models.INTENTStageControlLoop),
+}
+
+// diagram is the format-independent form every renderer consumes
+type diagram struct {
+	name   string
+	state  bool // Render as a state machine rather than a flowchart
+	nodes  []node
+	groups []group
+	edges  []edge
+}
+
+type node struct {
+	id    string
+	label []string // One entry per line
+	class string   // graph.KindCapability, graph.KindEnabler or "" for states
+}
+
+type group struct {
+	id    string
+	label string
+	nodes []node
+}
+
+type edge struct {
+	from   string
+	to     string
+	label  string
+	dashed bool
+}
+
+// Generate renders the requested diagram. stages maps a workflow stage to
+// the IDs of the entities currently in it and is only used by the state model.
+func Generate(g *graph.Graph, kind Kind, format Format, stages map[string][]string) (string, error) {
+	var d *diagram
+	switch kind {
+	case KindCapabilityMap:
+		d = capabilityMap(g)
+	case KindEnablerDependencies:
+		d = enablerDependencies(g)
+	case KindStateModel:
+		d = stateModel(stages)
+	default:
+		return "", fmt.Errorf("unsupported diagram kind %q", kind)
+	}
+
+	switch format {
+	case FormatMermaid:
+		return renderMermaid(d), nil
+	case FormatPlantUML:
+		return renderPlantUML(d), nil
+	case FormatDOT:
+		return renderDOT(d), nil
+	}
+	return "", fmt.Errorf("unsupported diagram format %q", format)
+}
+
+func entityNode(n graph.Node) node {
+	label := []string{n.ID}
+	if n.Name != "" {
+		label = append(label, n.Name)
+	}
+	return node{id: n.ID, label: label, class: n.Kind}
+}
+
+func edgeLabel(kind string) string {
+	if kind == graph.EdgeImplementedBy {
+		return "implemented by"
+	}
+	return "depends on"
+}
+
+// capabilityMap shows every capability and enabler, the dependencies between
+// them and which enablers implement which capability
+func capabilityMap(g *graph.Graph) *diagram {
+	d := &diagram{name: string(KindCapabilityMap)}
+	for _, n := range g.Nodes() {
+		d.nodes = append(d.nodes, entityNode(n))
+	}
+	for _, e := range g.Edges() {
+		d.edges = append(d.edges, edge{from: e.From, to: e.To, label: edgeLabel(e.Kind), dashed: e.Kind == graph.EdgeImplementedBy})
+	}
+	return d
+}
+
+// enablerDependencies shows the depends_on edges that involve an enabler,
+// with enablers grouped under the capability they implement
+func enablerDependencies(g *graph.Graph) *diagram {
+	d := &diagram{name: string(KindEnablerDependencies)}
+
+	used := make(map[string]bool)
+	owner := make(map[string]string)
+	for _, e := range g.Edges() {
+		if e.Kind == graph.EdgeImplementedBy {
+			owner[e.To] = e.From
+			continue
+		}
+		if graph.KindOf(e.From) != graph.KindEnabler && graph.KindOf(e.To) != graph.KindEnabler {
+			continue
+		}
+		used[e.From] = true
+		used[e.To] = true
+		d.edges = append(d.edges, edge{from: e.From, to: e.To, label: edgeLabel(e.Kind)})
+	}
+
+	groups := make(map[string]*group)
+	for _, n := range g.Nodes() {
+		if !used[n.ID] {
+			continue
+		}
+		capID, ok := owner[n.ID]
+		if !ok || used[capID] {
+			// Capabilities on an edge, and enablers without one, stand alone
+			d.nodes = append(d.nodes, entityNode(n))
+			continue
+		}
+		grp, ok := groups[capID]
+		if !ok {
+			label := capID
+			if c, found := g.Node(capID); found && c.Name != "" {
+				label += " " + c.Name
+			}
+			grp = &group{id: "group_" + capID, label: label}
+			groups[capID] = grp
+		}
+		grp.nodes = append(grp.nodes, entityNode(n))
+	}
+
+	capIDs := make([]string, 0, len(groups))
+	for id := range groups {
+		capIDs = append(capIDs, id)
+	}
+	sort.Strings(capIDs)
+	for _, id := range capIDs {
+		d.groups = append(d.groups, *groups[id])
+	}
+	return d
+}
+
+// stateModel shows the INTENT workflow stages in order, labelling each with
+// the entities currently in it
+func stateModel(stages map[string][]string) *diagram {
+	d := &diagram{name: string(KindStateModel), state: true}
+	for i, stage := range Stages {
+		label := []string{stage}
+		if ids := append([]string{}, stages[stage]...); len(ids) > 0 {
+			sort.Strings(ids)
+			label = append(label, ids...)
+		}
+		d.nodes = append(d.nodes, node{id: stage, label: label})
+		if i > 0 {
+			d.edges = append(d.edges, edge{from: Stages[i-1], to: stage, label: "approved"})
+		}
+	}
+	return d
+}
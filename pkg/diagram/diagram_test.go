@@ -0,0 +1,95 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package diagram
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jareynolds/intentr/pkg/graph"
+)
+
+func testGraph() *graph.Graph {
+	g := graph.New()
+	g.AddNode(graph.Node{ID: "CAP-000001", Name: "Auth"})
+	g.AddNode(graph.Node{ID: "CAP-000002", Name: "Workspace"})
+	g.AddNode(graph.Node{ID: "ENB-000010", Name: "Login"})
+	g.AddNode(graph.Node{ID: "ENB-000011", Name: "Folders"})
+	g.AddEdge("CAP-000002", "CAP-000001", graph.EdgeDependsOn, graph.SourceSpec)
+	g.AddEdge("CAP-000001", "ENB-000010", graph.EdgeImplementedBy, graph.SourceSpec)
+	g.AddEdge("CAP-000002", "ENB-000011", graph.EdgeImplementedBy, graph.SourceSpec)
+	g.AddEdge("ENB-000011", "ENB-000010", graph.EdgeDependsOn, graph.SourceSpec)
+	return g
+}
+
+func TestCapabilityMapMermaid(t *testing.T) {
+	got, err := Generate(testGraph(), KindCapabilityMap, FormatMermaid, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `flowchart TD
+    CAP_000001["CAP-000001<br/>Auth"]
+    CAP_000002["CAP-000002<br/>Workspace"]
+    ENB_000010["ENB-000010<br/>Login"]
+    ENB_000011["ENB-000011<br/>Folders"]
+    CAP_000001 -.->|implemented by| ENB_000010
+    CAP_000002 -->|depends on| CAP_000001
+    CAP_000002 -.->|implemented by| ENB_000011
+    ENB_000011 -->|depends on| ENB_000010
+
+    classDef capability fill:#f3e5f5,stroke:#7b1fa2,stroke-width:2px
+    classDef enabler fill:#e3f2fd,stroke:#1976d2,stroke-width:2px
+    class CAP_000001,CAP_000002 capability
+    class ENB_000010,ENB_000011 enabler
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	again, _ := Generate(testGraph(), KindCapabilityMap, FormatMermaid, nil)
+	if again != got {
+		t.Error("output must be deterministic")
+	}
+}
+
+func TestEnablerDependenciesGroupByCapability(t *testing.T) {
+	got, err := Generate(testGraph(), KindEnablerDependencies, FormatDOT, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`subgraph "cluster_group_CAP_000001" {`,
+		`label="CAP-000002 Workspace";`,
+		`"ENB-000011" -> "ENB-000010" [label="depends on"];`,
+	} {
+		if !strings.Contains(got, line) {
+			t.Errorf("missing %q in:\n%s", line, got)
+		}
+	}
+	if strings.Contains(got, "implemented by") {
+		t.Errorf("enabler diagram should only show dependencies:\n%s", got)
+	}
+}
+
+func TestStateModelPlantUML(t *testing.T) {
+	got, err := Generate(nil, KindStateModel, FormatPlantUML, map[string][]string{"specification": {"CAP-000002", "CAP-000001"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"[*] --> intent\n",
+		"specification : CAP-000001\nspecification : CAP-000002\n",
+		"implementation --> control_loop : approved\n",
+		"control_loop --> [*]\n",
+	} {
+		if !strings.Contains(got, line) {
+			t.Errorf("missing %q in:\n%s", line, got)
+		}
+	}
+}
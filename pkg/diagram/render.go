@@ -0,0 +1,203 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package diagram
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jareynolds/intentr/pkg/graph"
+)
+
+// classColors matches the enabler style used in the spec templates
+var classColors = map[string][2]string{
+	graph.KindCapability: {"#f3e5f5", "#7b1fa2"},
+	graph.KindEnabler:    {"#e3f2fd", "#1976d2"},
+}
+
+var unsafeID = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// safeID turns an ID such as CAP-000001 into CAP_000001
+func safeID(id string) string {
+	return unsafeID.ReplaceAllString(id, "_")
+}
+
+func quote(s string) string {
+	return strings.ReplaceAll(s, `"`, `'`)
+}
+
+func renderMermaid(d *diagram) string {
+	var b strings.Builder
+	if d.state {
+		b.WriteString("stateDiagram-v2\n")
+		if len(d.nodes) > 0 {
+			fmt.Fprintf(&b, "    [*] --> %s\n", safeID(d.nodes[0].id))
+		}
+		for _, n := range d.nodes {
+			fmt.Fprintf(&b, "    state \"%s\" as %s\n", quote(strings.Join(n.label, " · ")), safeID(n.id))
+		}
+		for _, e := range d.edges {
+			fmt.Fprintf(&b, "    %s --> %s : %s\n", safeID(e.from), safeID(e.to), e.label)
+		}
+		if len(d.nodes) > 0 {
+			fmt.Fprintf(&b, "    %s --> [*]\n", safeID(d.nodes[len(d.nodes)-1].id))
+		}
+		return b.String()
+	}
+
+	b.WriteString("flowchart TD\n")
+	mermaidNode := func(indent string, n node) {
+		fmt.Fprintf(&b, "%s%s[\"%s\"]\n", indent, safeID(n.id), quote(strings.Join(n.label, "<br/>")))
+	}
+	for _, n := range d.nodes {
+		mermaidNode("    ", n)
+	}
+	for _, grp := range d.groups {
+		fmt.Fprintf(&b, "    subgraph %s[\"%s\"]\n", safeID(grp.id), quote(grp.label))
+		for _, n := range grp.nodes {
+			mermaidNode("        ", n)
+		}
+		b.WriteString("    end\n")
+	}
+	for _, e := range d.edges {
+		arrow := "-->"
+		if e.dashed {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "    %s %s|%s| %s\n", safeID(e.from), arrow, e.label, safeID(e.to))
+	}
+
+	b.WriteString("\n")
+	for _, class := range []string{graph.KindCapability, graph.KindEnabler} {
+		c := classColors[class]
+		fmt.Fprintf(&b, "    classDef %s fill:%s,stroke:%s,stroke-width:2px\n", class, c[0], c[1])
+	}
+	for _, class := range []string{graph.KindCapability, graph.KindEnabler} {
+		var ids []string
+		for _, n := range allNodes(d) {
+			if n.class == class {
+				ids = append(ids, safeID(n.id))
+			}
+		}
+		if len(ids) > 0 {
+			fmt.Fprintf(&b, "    class %s %s\n", strings.Join(ids, ","), class)
+		}
+	}
+	return b.String()
+}
+
+func renderPlantUML(d *diagram) string {
+	var b strings.Builder
+	b.WriteString("@startuml\n")
+	fmt.Fprintf(&b, "title %s\n", d.name)
+
+	if d.state {
+		for _, n := range d.nodes {
+			fmt.Fprintf(&b, "state \"%s\" as %s\n", quote(n.label[0]), safeID(n.id))
+			for _, line := range n.label[1:] {
+				fmt.Fprintf(&b, "%s : %s\n", safeID(n.id), line)
+			}
+		}
+		if len(d.nodes) > 0 {
+			fmt.Fprintf(&b, "[*] --> %s\n", safeID(d.nodes[0].id))
+		}
+		for _, e := range d.edges {
+			fmt.Fprintf(&b, "%s --> %s : %s\n", safeID(e.from), safeID(e.to), e.label)
+		}
+		if len(d.nodes) > 0 {
+			fmt.Fprintf(&b, "%s --> [*]\n", safeID(d.nodes[len(d.nodes)-1].id))
+		}
+		b.WriteString("@enduml\n")
+		return b.String()
+	}
+
+	for _, class := range []string{graph.KindCapability, graph.KindEnabler} {
+		c := classColors[class]
+		fmt.Fprintf(&b, "skinparam rectangle<<%s>> {\n  BackgroundColor %s\n  BorderColor %s\n}\n", class, c[0], c[1])
+	}
+	plantNode := func(indent string, n node) {
+		fmt.Fprintf(&b, "%srectangle \"%s\" as %s <<%s>>\n", indent, quote(strings.Join(n.label, `\n`)), safeID(n.id), n.class)
+	}
+	for _, n := range d.nodes {
+		plantNode("", n)
+	}
+	for _, grp := range d.groups {
+		fmt.Fprintf(&b, "package \"%s\" {\n", quote(grp.label))
+		for _, n := range grp.nodes {
+			plantNode("  ", n)
+		}
+		b.WriteString("}\n")
+	}
+	for _, e := range d.edges {
+		arrow := "-->"
+		if e.dashed {
+			arrow = "..>"
+		}
+		fmt.Fprintf(&b, "%s %s %s : %s\n", safeID(e.from), arrow, safeID(e.to), e.label)
+	}
+	b.WriteString("@enduml\n")
+	return b.String()
+}
+
+func renderDOT(d *diagram) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph \"%s\" {\n", d.name)
+	b.WriteString("  rankdir=LR;\n")
+
+	if d.state {
+		b.WriteString("  node [shape=box, style=rounded, fontname=\"Helvetica\"];\n")
+		b.WriteString("  __start [shape=point];\n")
+		b.WriteString("  __end [shape=doublecircle, label=\"\", width=0.2];\n")
+	} else {
+		b.WriteString("  node [shape=box, style=\"rounded,filled\", fontname=\"Helvetica\"];\n")
+	}
+
+	dotNode := func(indent string, n node) {
+		attrs := fmt.Sprintf("label=\"%s\"", quote(strings.Join(n.label, `\n`)))
+		if c, ok := classColors[n.class]; ok {
+			attrs += fmt.Sprintf(", fillcolor=\"%s\", color=\"%s\"", c[0], c[1])
+		}
+		fmt.Fprintf(&b, "%s\"%s\" [%s];\n", indent, n.id, attrs)
+	}
+	for _, n := range d.nodes {
+		dotNode("  ", n)
+	}
+	for _, grp := range d.groups {
+		fmt.Fprintf(&b, "  subgraph \"cluster_%s\" {\n    label=\"%s\";\n", safeID(grp.id), quote(grp.label))
+		for _, n := range grp.nodes {
+			dotNode("    ", n)
+		}
+		b.WriteString("  }\n")
+	}
+
+	if d.state && len(d.nodes) > 0 {
+		fmt.Fprintf(&b, "  __start -> \"%s\";\n", d.nodes[0].id)
+	}
+	for _, e := range d.edges {
+		attrs := fmt.Sprintf("label=\"%s\"", e.label)
+		if e.dashed {
+			attrs += ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  \"%s\" -> \"%s\" [%s];\n", e.from, e.to, attrs)
+	}
+	if d.state && len(d.nodes) > 0 {
+		fmt.Fprintf(&b, "  \"%s\" -> __end;\n", d.nodes[len(d.nodes)-1].id)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func allNodes(d *diagram) []node {
+	nodes := append([]node{}, d.nodes...)
+	for _, grp := range d.groups {
+		nodes = append(nodes, grp.nodes...)
+	}
+	return nodes
+}
@@ -0,0 +1,158 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package githubissues
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the GitHub REST and GraphQL API
+const DefaultBaseURL = "https://api.github.com"
+
+// Issue states
+const (
+	StateOpen   = "open"
+	StateClosed = "closed"
+)
+
+// Issue is the part of a GitHub issue the exporter reads
+type Issue struct {
+	Number  int    `json:"number"`
+	NodeID  string `json:"node_id"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+}
+
+// IssueUpdate is an edit of an issue; empty fields are left unchanged
+type IssueUpdate struct {
+	Title       string `json:"title,omitempty"`
+	Body        string `json:"body,omitempty"`
+	State       string `json:"state,omitempty"`
+	StateReason string `json:"state_reason,omitempty"` // completed, not_planned or reopened
+}
+
+// Client calls the GitHub API with a personal access token
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a client; an empty baseURL means api.github.com
+func NewClient(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do sends a request and decodes a JSON response into out, if given
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal GitHub request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call GitHub: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("GitHub API error (%d): %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode GitHub response: %w", err)
+	}
+	return nil
+}
+
+// GetIssue returns an issue of a repository ("owner/repo")
+func (c *Client) GetIssue(ctx context.Context, repo string, number int) (*Issue, error) {
+	var issue Issue
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/issues/%d", repo, number), nil, &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+// CreateIssue opens an issue in a repository
+func (c *Client) CreateIssue(ctx context.Context, repo, title, body string) (*Issue, error) {
+	var issue Issue
+	req := map[string]string{"title": title, "body": body}
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/issues", repo), req, &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+// UpdateIssue edits an issue, which also closes and reopens it
+func (c *Client) UpdateIssue(ctx context.Context, repo string, number int, update IssueUpdate) (*Issue, error) {
+	var issue Issue
+	if err := c.do(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/issues/%d", repo, number), update, &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+// AddToProject adds an issue, by node ID, to a Projects (v2) board. Adding
+// an issue that is already on the board is a no-op.
+func (c *Client) AddToProject(ctx context.Context, projectID, contentID string) error {
+	req := map[string]interface{}{
+		"query": `mutation($project: ID!, $content: ID!) {
+  addProjectV2ItemById(input: {projectId: $project, contentId: $content}) { item { id } }
+}`,
+		"variables": map[string]string{"project": projectID, "content": contentID},
+	}
+	// GraphQL reports errors with status 200
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/graphql", req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("GitHub GraphQL error: %s", resp.Errors[0].Message)
+	}
+	return nil
+}
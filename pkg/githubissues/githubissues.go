@@ -0,0 +1,302 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Package githubissues publishes enablers as GitHub issues. An issue's body
+// is built from its enabler's specification, with the functional and
+// non-functional requirements as task lists and the acceptance criteria as a
+// checklist, and the issue is open or closed according to the enabler's
+// lifecycle state. The spec records its issue in the "GitHub Issue" metadata
+// field, so exporting again updates the same issue instead of opening a new
+// one.
+package githubissues
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/jareynolds/intentr/pkg/spec"
+)
+
+// IssueField is the enabler metadata field holding its issue, "owner/repo#N"
+const IssueField = "GitHub Issue"
+
+// issueRefPattern matches "owner/repo#N"
+var issueRefPattern = regexp.MustCompile(`^([\w.-]+/[\w.-]+)#(\d+)$`)
+
+// FormatIssueRef formats an issue reference as "owner/repo#N"
+func FormatIssueRef(repo string, number int) string {
+	return fmt.Sprintf("%s#%d", repo, number)
+}
+
+// ParseIssueRef splits "owner/repo#N" into the repository and issue number
+func ParseIssueRef(ref string) (string, int, bool) {
+	m := issueRefPattern.FindStringSubmatch(strings.TrimSpace(ref))
+	if m == nil {
+		return "", 0, false
+	}
+	number, err := strconv.Atoi(m[2])
+	if err != nil {
+		return "", 0, false
+	}
+	return m[1], number, true
+}
+
+// IssueState returns the issue state, and the reason for closing, that
+// corresponds to a lifecycle state. Implemented and maintained enablers are
+// completed, retired ones were not planned; everything else is open.
+func IssueState(lifecycle string) (state, reason string) {
+	switch models.LifecycleState(lifecycle) {
+	case models.LifecycleStateImplemented, models.LifecycleStateMaintained:
+		return StateClosed, "completed"
+	case models.LifecycleStateRetired:
+		return StateClosed, "not_planned"
+	}
+	return StateOpen, ""
+}
+
+// Requirement is a row of a requirements table
+type Requirement struct {
+	ID     string
+	Name   string
+	Text   string
+	Status string
+}
+
+// Done reports whether a requirement's status marks it as met
+func (r Requirement) Done() bool {
+	switch strings.ToLower(r.Status) {
+	case "implemented", "verified", "done", "complete", "completed":
+		return true
+	}
+	return false
+}
+
+// Requirements parses the table of a requirements section, such as
+// "Functional Requirements". Columns are found by their header, so the
+// table's column order does not matter.
+func Requirements(doc *spec.Document, section string) []Requirement {
+	body, ok := doc.SectionBody(section)
+	if !ok {
+		return nil
+	}
+
+	var columns map[string]int
+	var requirements []Requirement
+	for _, line := range strings.Split(body, "\n") {
+		cells, ok := tableCells(line)
+		if !ok {
+			continue
+		}
+		if columns == nil {
+			columns = make(map[string]int)
+			for i, cell := range cells {
+				columns[strings.ToLower(cell)] = i
+			}
+			continue
+		}
+		if isSeparatorRow(cells) {
+			continue
+		}
+		cell := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(cells) {
+				return cells[i]
+			}
+			return ""
+		}
+		req := Requirement{ID: cell("id"), Name: cell("name"), Text: cell("requirement"), Status: cell("status")}
+		if req.ID != "" || req.Text != "" {
+			requirements = append(requirements, req)
+		}
+	}
+	return requirements
+}
+
+// tableCells splits a "| a | b |" table row into trimmed cells
+func tableCells(line string) ([]string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "|") {
+		return nil, false
+	}
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+	cells := strings.Split(line, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells, true
+}
+
+func isSeparatorRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.Trim(cell, "-: ") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// AcceptanceCriteria returns the acceptance criteria of a spec as checklist
+// items, without the template's placeholder items
+func AcceptanceCriteria(doc *spec.Document) []string {
+	body, ok := doc.SectionBody("Acceptance Criteria")
+	if !ok {
+		return nil
+	}
+	var items []string
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		text := strings.TrimSpace(strings.TrimLeft(line, "-*"))
+		checked := false
+		switch {
+		case strings.HasPrefix(text, "[ ]"):
+			text = strings.TrimSpace(text[3:])
+		case strings.HasPrefix(text, "[x]"), strings.HasPrefix(text, "[X]"):
+			text, checked = strings.TrimSpace(text[3:]), true
+		}
+		if text == "" || (strings.HasPrefix(text, "_") && strings.HasSuffix(text, "_")) {
+			continue
+		}
+		items = append(items, checkbox(checked)+text)
+	}
+	return items
+}
+
+func checkbox(checked bool) string {
+	if checked {
+		return "- [x] "
+	}
+	return "- [ ] "
+}
+
+// IssueBody builds the body of an enabler's issue
+func IssueBody(e *spec.Enabler) string {
+	var b strings.Builder
+	for _, section := range []string{"Purpose", "Description"} {
+		if text, ok := e.Doc.SectionBody(section); ok && text != "" {
+			b.WriteString(text + "\n\n")
+			break
+		}
+	}
+
+	for _, section := range []string{"Functional Requirements", "Non-Functional Requirements"} {
+		requirements := Requirements(e.Doc, section)
+		if len(requirements) == 0 {
+			continue
+		}
+		b.WriteString("### " + section + "\n\n")
+		for _, r := range requirements {
+			line := r.Text
+			if r.Name != "" {
+				line = r.Name + ": " + line
+			}
+			if r.ID != "" {
+				line = "**" + r.ID + "** " + line
+			}
+			b.WriteString(checkbox(r.Done()) + strings.TrimSpace(line) + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if criteria := AcceptanceCriteria(e.Doc); len(criteria) > 0 {
+		b.WriteString("### Acceptance Criteria\n\n")
+		b.WriteString(strings.Join(criteria, "\n") + "\n\n")
+	}
+
+	source := e.ID
+	if e.CapabilityID != "" {
+		source += " of capability " + e.CapabilityID
+	}
+	b.WriteString("---\n")
+	b.WriteString(fmt.Sprintf("_Exported from IntentR enabler %s. Edit the specification rather than this issue: the next export replaces this description._\n", source))
+	return b.String()
+}
+
+// Export publishes an enabler as an issue. If the enabler's spec names an
+// issue, that issue's title, body and state are brought up to date;
+// otherwise an issue is opened in repo and recorded in the spec's
+// IssueField, and the caller saves the spec. With a projectID the issue is
+// also added to that Projects (v2) board.
+func Export(ctx context.Context, c *Client, e *spec.Enabler, repo, lifecycle, projectID string) models.GitHubExportResult {
+	result := models.GitHubExportResult{EnablerID: e.ID}
+	fail := func(err error) models.GitHubExportResult {
+		result.Result = models.GitHubExportError
+		result.Error = err.Error()
+		return result
+	}
+
+	title := e.Name
+	body := IssueBody(e)
+	state, reason := IssueState(lifecycle)
+
+	var issue *Issue
+	var err error
+	if ref := e.Doc.FieldValue(IssueField); ref != "" {
+		var number int
+		var ok bool
+		repo, number, ok = ParseIssueRef(ref)
+		if !ok {
+			return fail(fmt.Errorf("invalid %s %q, expected owner/repo#number", IssueField, ref))
+		}
+		if issue, err = c.GetIssue(ctx, repo, number); err != nil {
+			return fail(err)
+		}
+
+		var update IssueUpdate
+		if issue.Title != title {
+			update.Title = title
+		}
+		if strings.TrimSpace(issue.Body) != strings.TrimSpace(body) {
+			update.Body = body
+		}
+		if issue.State != state {
+			update.State, update.StateReason = state, reason
+			if state == StateOpen {
+				update.StateReason = "reopened"
+			}
+		}
+		result.Result = models.GitHubExportUnchanged
+		if update != (IssueUpdate{}) {
+			if issue, err = c.UpdateIssue(ctx, repo, number, update); err != nil {
+				return fail(err)
+			}
+			result.Result = models.GitHubExportUpdated
+		}
+	} else {
+		if repo == "" {
+			return fail(fmt.Errorf("repository is required to open an issue"))
+		}
+		if issue, err = c.CreateIssue(ctx, repo, title, body); err != nil {
+			return fail(err)
+		}
+		// Issues are opened open; close those of finished enablers
+		if state == StateClosed {
+			if issue, err = c.UpdateIssue(ctx, repo, issue.Number, IssueUpdate{State: state, StateReason: reason}); err != nil {
+				return fail(err)
+			}
+		}
+		e.Doc.SetField(IssueField, FormatIssueRef(repo, issue.Number))
+		result.Result = models.GitHubExportCreated
+	}
+
+	result.Issue = FormatIssueRef(repo, issue.Number)
+	result.URL = issue.HTMLURL
+	result.State = issue.State
+
+	if projectID != "" {
+		if err := c.AddToProject(ctx, projectID, issue.NodeID); err != nil {
+			return fail(fmt.Errorf("failed to add %s to project: %w", result.Issue, err))
+		}
+	}
+	return result
+}
@@ -0,0 +1,178 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package githubissues
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/jareynolds/intentr/pkg/spec"
+)
+
+const enablerSpec = `# Markdown Export
+
+## Metadata
+
+- **Name**: Markdown Export
+- **Type**: Enabler
+- **ID**: ENB-673305
+- **Capability ID**: CAP-759314
+
+## Purpose
+
+Exports storyboards to markdown.
+
+## Functional Requirements
+
+| ID | Name | Requirement | Priority | Status | Approval |
+|----|------|-------------|----------|--------|----------|
+| FR-673305-001 | Export Button | Provide an export button | High | Implemented | Approved |
+| FR-673305-002 | Mermaid Diagrams | Include a flowchart | High | Planned | Pending |
+
+## Non-Functional Requirements
+
+| ID | Name | Requirement | Type | Status | Priority | Approval |
+|----|------|-------------|------|--------|----------|----------|
+| NFR-673305-001 | Export Speed | Export within 2 seconds | Performance | Planned | Medium | Approved |
+
+## Acceptance Criteria
+
+- [ ] Exported files open in any markdown viewer
+- [x] The export button is in the header
+- [ ] _Define acceptance criteria_
+`
+
+// stubGitHub serves the issues of one repository and the GraphQL endpoint
+type stubGitHub struct {
+	mu       sync.Mutex
+	issues   map[int]*Issue
+	projects map[string]bool // project/content node IDs added
+}
+
+func (s *stubGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer token" {
+		http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+		return
+	}
+
+	if r.URL.Path == "/graphql" {
+		var req struct {
+			Variables map[string]string `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		s.projects[req.Variables["project"]+"/"+req.Variables["content"]] = true
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{}})
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/repos/acme/app/issues")
+	switch {
+	case path == "" && r.Method == http.MethodPost:
+		var req IssueUpdate
+		json.NewDecoder(r.Body).Decode(&req)
+		n := len(s.issues) + 1
+		s.issues[n] = &Issue{Number: n, NodeID: fmt.Sprintf("I_%d", n), Title: req.Title, Body: req.Body, State: StateOpen,
+			HTMLURL: fmt.Sprintf("https://github.com/acme/app/issues/%d", n)}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(s.issues[n])
+	case strings.HasPrefix(path, "/"):
+		n, _ := strconv.Atoi(path[1:])
+		issue, ok := s.issues[n]
+		if !ok {
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodPatch {
+			var req IssueUpdate
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Title != "" {
+				issue.Title = req.Title
+			}
+			if req.Body != "" {
+				issue.Body = req.Body
+			}
+			if req.State != "" {
+				issue.State = req.State
+			}
+		}
+		json.NewEncoder(w).Encode(issue)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestIssueBody(t *testing.T) {
+	body := IssueBody(spec.ParseEnabler(enablerSpec))
+	for _, want := range []string{
+		"Exports storyboards to markdown.",
+		"### Functional Requirements\n\n- [x] **FR-673305-001** Export Button: Provide an export button\n- [ ] **FR-673305-002** Mermaid Diagrams: Include a flowchart\n",
+		"### Non-Functional Requirements\n\n- [ ] **NFR-673305-001** Export Speed: Export within 2 seconds\n",
+		"### Acceptance Criteria\n\n- [ ] Exported files open in any markdown viewer\n- [x] The export button is in the header\n\n",
+		"enabler ENB-673305 of capability CAP-759314",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("IssueBody() is missing %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "Define acceptance criteria") {
+		t.Errorf("IssueBody() kept the placeholder criterion:\n%s", body)
+	}
+}
+
+func TestExport(t *testing.T) {
+	stub := &stubGitHub{issues: map[int]*Issue{}, projects: map[string]bool{}}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	client := NewClient(server.URL, "token")
+	ctx := context.Background()
+
+	e := spec.ParseEnabler(enablerSpec)
+	got := Export(ctx, client, e, "acme/app", "active", "PVT_1")
+	if got.Result != models.GitHubExportCreated || got.Issue != "acme/app#1" || got.State != StateOpen {
+		t.Fatalf("first Export() = %+v", got)
+	}
+	if ref := e.Doc.FieldValue(IssueField); ref != "acme/app#1" {
+		t.Errorf("spec %s = %q, want acme/app#1", IssueField, ref)
+	}
+	if !stub.projects["PVT_1/I_1"] {
+		t.Errorf("issue was not added to the project")
+	}
+
+	// Exporting the saved spec again updates the same issue
+	e = spec.ParseEnabler(e.Doc.String())
+	if got := Export(ctx, client, e, "acme/other", "active", ""); got.Result != models.GitHubExportUnchanged || got.Issue != "acme/app#1" {
+		t.Fatalf("second Export() = %+v, want unchanged acme/app#1", got)
+	}
+
+	// Implementing the enabler closes its issue; reactivating reopens it
+	if got := Export(ctx, client, e, "acme/app", "implemented", ""); got.Result != models.GitHubExportUpdated || got.State != StateClosed {
+		t.Fatalf("Export(implemented) = %+v, want closed", got)
+	}
+	if got := Export(ctx, client, e, "acme/app", "active", ""); got.State != StateOpen {
+		t.Fatalf("Export(active) = %+v, want reopened", got)
+	}
+	if len(stub.issues) != 1 {
+		t.Errorf("%d issues were opened, want 1", len(stub.issues))
+	}
+
+	e.Doc.SetField(IssueField, "not a reference")
+	if got := Export(ctx, client, e, "acme/app", "active", ""); got.Result != models.GitHubExportError {
+		t.Errorf("Export(invalid reference) = %+v, want error", got)
+	}
+}
@@ -0,0 +1,324 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Package graph computes over the dependencies between capabilities and
+// enablers: upstream/downstream closure, implementation order, cycles and
+// the blast radius of a change. Edges come from the capability_dependencies
+// and enabler_dependencies tables and from the Dependencies sections of the
+// Markdown specs; nodes are keyed by their CAP-/ENB- identifiers.
+package graph
+
+import (
+	"sort"
+	"strings"
+)
+
+// Node kinds
+const (
+	KindCapability = "capability"
+	KindEnabler    = "enabler"
+)
+
+// Edge kinds. Every edge points from an entity to something that must be in
+// place before it: From depends on To.
+const (
+	// EdgeDependsOn is an explicit upstream/downstream dependency
+	EdgeDependsOn = "depends_on"
+	// EdgeImplementedBy links a capability to an enabler that realizes it
+	EdgeImplementedBy = "implemented_by"
+)
+
+// Edge sources
+const (
+	SourceDatabase = "database"
+	SourceSpec     = "spec"
+)
+
+// Node is a capability or enabler
+type Node struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	Kind string `json:"kind"`
+	File string `json:"file,omitempty"`
+}
+
+// Edge records that From depends on To
+type Edge struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Kind    string   `json:"kind"`
+	Sources []string `json:"sources"`
+}
+
+// Graph is a directed dependency graph. The zero value is not usable; call New.
+type Graph struct {
+	nodes map[string]*Node
+	edges map[[2]string]*Edge
+	out   map[string][]string // From -> To, sorted
+	in    map[string][]string // To -> From, sorted
+}
+
+// New creates an empty graph
+func New() *Graph {
+	return &Graph{
+		nodes: make(map[string]*Node),
+		edges: make(map[[2]string]*Edge),
+		out:   make(map[string][]string),
+		in:    make(map[string][]string),
+	}
+}
+
+// KindOf infers a node kind from an ID prefix
+func KindOf(id string) string {
+	if strings.HasPrefix(id, "ENB-") {
+		return KindEnabler
+	}
+	return KindCapability
+}
+
+// AddNode adds a node or fills in details missing from an existing one
+func (g *Graph) AddNode(n Node) {
+	if n.Kind == "" {
+		n.Kind = KindOf(n.ID)
+	}
+	existing, ok := g.nodes[n.ID]
+	if !ok {
+		g.nodes[n.ID] = &n
+		return
+	}
+	if existing.Name == "" {
+		existing.Name = n.Name
+	}
+	if existing.File == "" {
+		existing.File = n.File
+	}
+}
+
+// AddEdge records that from depends on to. Both nodes are created if
+// needed; adding the same edge again only merges its source.
+func (g *Graph) AddEdge(from, to, kind, source string) {
+	g.AddNode(Node{ID: from})
+	g.AddNode(Node{ID: to})
+
+	key := [2]string{from, to}
+	if e, ok := g.edges[key]; ok {
+		for _, s := range e.Sources {
+			if s == source {
+				return
+			}
+		}
+		e.Sources = append(e.Sources, source)
+		sort.Strings(e.Sources)
+		return
+	}
+
+	g.edges[key] = &Edge{From: from, To: to, Kind: kind, Sources: []string{source}}
+	g.out[from] = insertSorted(g.out[from], to)
+	g.in[to] = insertSorted(g.in[to], from)
+}
+
+func insertSorted(list []string, id string) []string {
+	i := sort.SearchStrings(list, id)
+	list = append(list, "")
+	copy(list[i+1:], list[i:])
+	list[i] = id
+	return list
+}
+
+// Node returns the node with the given ID
+func (g *Graph) Node(id string) (*Node, bool) {
+	n, ok := g.nodes[id]
+	return n, ok
+}
+
+// Nodes returns every node sorted by ID
+func (g *Graph) Nodes() []Node {
+	nodes := make([]Node, 0, len(g.nodes))
+	for _, id := range g.ids() {
+		nodes = append(nodes, *g.nodes[id])
+	}
+	return nodes
+}
+
+// Edges returns every edge sorted by From, then To
+func (g *Graph) Edges() []Edge {
+	edges := make([]Edge, 0, len(g.edges))
+	for _, from := range g.ids() {
+		for _, to := range g.out[from] {
+			edges = append(edges, *g.edges[[2]string{from, to}])
+		}
+	}
+	return edges
+}
+
+func (g *Graph) ids() []string {
+	ids := make([]string, 0, len(g.nodes))
+	for id := range g.nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Reached is a node found by a traversal, with its distance from the start
+// and the neighbour it was first reached through
+type Reached struct {
+	ID       string `json:"id"`
+	Name     string `json:"name,omitempty"`
+	Kind     string `json:"kind"`
+	Distance int    `json:"distance"`
+	Via      string `json:"via"`
+}
+
+// Upstream returns everything id transitively depends on, nearest first
+func (g *Graph) Upstream(id string) []Reached {
+	return g.walk([]string{id}, func(n string) []string { return g.out[n] })
+}
+
+// Downstream returns everything that transitively depends on id, nearest first
+func (g *Graph) Downstream(id string) []Reached {
+	return g.walk([]string{id}, func(n string) []string { return g.in[n] })
+}
+
+// BlastRadius returns everything affected by changing id: every entity
+// downstream of it, plus the enablers implementing id or any affected
+// capability, since their requirements derive from the capability.
+func (g *Graph) BlastRadius(id string) []Reached {
+	return g.walk([]string{id}, func(n string) []string {
+		next := append([]string{}, g.in[n]...)
+		for _, to := range g.out[n] {
+			if g.edges[[2]string{n, to}].Kind == EdgeImplementedBy {
+				next = append(next, to)
+			}
+		}
+		return next
+	})
+}
+
+// walk is a breadth-first traversal from start, excluding the start nodes.
+// Neighbours are visited in ID order so results are deterministic.
+func (g *Graph) walk(start []string, neighbours func(string) []string) []Reached {
+	seen := make(map[string]bool)
+	for _, id := range start {
+		seen[id] = true
+	}
+
+	var reached []Reached
+	queue := start
+	distance := 0
+	for len(queue) > 0 {
+		distance++
+		var next []string
+		for _, from := range queue {
+			ids := neighbours(from)
+			sort.Strings(ids)
+			for _, id := range ids {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				n := g.nodes[id]
+				reached = append(reached, Reached{ID: id, Name: n.Name, Kind: n.Kind, Distance: distance, Via: from})
+				next = append(next, id)
+			}
+		}
+		queue = next
+	}
+	return reached
+}
+
+// Order returns a topological implementation order in which every entity
+// comes after everything it depends on. Ties are broken by ID. Entities on
+// a cycle, or depending on one, cannot be ordered and are returned in
+// blocked instead.
+func (g *Graph) Order() (order []string, blocked []string) {
+	pending := make(map[string]int, len(g.nodes))
+	var ready []string
+	for _, id := range g.ids() {
+		pending[id] = len(g.out[id])
+		if pending[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, id)
+		for _, dependent := range g.in[id] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = insertSorted(ready, dependent)
+			}
+		}
+	}
+
+	for _, id := range g.ids() {
+		if pending[id] > 0 {
+			blocked = append(blocked, id)
+		}
+	}
+	return order, blocked
+}
+
+// Cycles returns every dependency cycle as the sorted IDs of a strongly
+// connected component, ordered by their first ID
+func (g *Graph) Cycles() [][]string {
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+	next := 0
+
+	var connect func(id string)
+	connect = func(id string) {
+		index[id] = next
+		low[id] = next
+		next++
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, to := range g.out[id] {
+			if _, visited := index[to]; !visited {
+				connect(to)
+				low[id] = min(low[id], low[to])
+			} else if onStack[to] {
+				low[id] = min(low[id], index[to])
+			}
+		}
+
+		if low[id] != index[id] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == id {
+				break
+			}
+		}
+		_, selfLoop := g.edges[[2]string{id, id}]
+		if len(component) > 1 || selfLoop {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, id := range g.ids() {
+		if _, visited := index[id]; !visited {
+			connect(id)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
@@ -0,0 +1,90 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package graph
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+func ids(reached []Reached) []string {
+	var out []string
+	for _, r := range reached {
+		out = append(out, r.ID)
+	}
+	return out
+}
+
+func TestSpecsAndDatabaseEdgesCombine(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"definition/CAP-auth.md": "# Auth\n\n- **ID**: CAP-000001\n",
+		"definition/CAP-workspace.md": "# Workspace\n\n- **ID**: CAP-000002\n\n## Dependencies\n\n" +
+			"### Internal Upstream Dependency\n\n| Capability ID | Description |\n|---|---|\n| CAP-000001 | Auth |\n",
+		"definition/ENB-login.md": "# Login\n\n- **ID**: ENB-000010\n- **Capability**: Auth (CAP-000001)\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	g := New()
+	if err := LoadSpecs(g, root); err != nil {
+		t.Fatal(err)
+	}
+	AddDependencies(g, []models.DependencyEdge{
+		{FromID: "CAP-000003", FromName: "Reports", ToID: "CAP-000002", Kind: EdgeDependsOn},
+		{FromID: "CAP-000002", ToID: "CAP-000001", Kind: EdgeDependsOn},
+	})
+
+	if got := ids(g.Downstream("CAP-000001")); !reflect.DeepEqual(got, []string{"CAP-000002", "CAP-000003"}) {
+		t.Errorf("downstream: got %v", got)
+	}
+	if got := ids(g.Upstream("CAP-000003")); !reflect.DeepEqual(got, []string{"CAP-000002", "CAP-000001", "ENB-000010"}) {
+		t.Errorf("upstream: got %v", got)
+	}
+	if got := ids(g.BlastRadius("CAP-000001")); !reflect.DeepEqual(got, []string{"CAP-000002", "ENB-000010", "CAP-000003"}) {
+		t.Errorf("blast radius: got %v", got)
+	}
+
+	edges := g.Edges()
+	if len(edges) != 3 || !reflect.DeepEqual(edges[1].Sources, []string{SourceDatabase, SourceSpec}) {
+		t.Errorf("expected the spec and database edge to merge, got %+v", edges)
+	}
+	if n, _ := g.Node("CAP-000002"); n.File != "definition/CAP-workspace.md" || n.Name != "Workspace" {
+		t.Errorf("unexpected node %+v", n)
+	}
+}
+
+func TestOrderAndCycles(t *testing.T) {
+	g := New()
+	g.AddEdge("CAP-2", "CAP-1", EdgeDependsOn, SourceSpec)
+	g.AddEdge("CAP-3", "CAP-4", EdgeDependsOn, SourceSpec)
+	g.AddEdge("CAP-4", "CAP-3", EdgeDependsOn, SourceSpec)
+	g.AddEdge("CAP-5", "CAP-3", EdgeDependsOn, SourceSpec)
+	g.AddEdge("CAP-6", "CAP-6", EdgeDependsOn, SourceSpec)
+
+	order, blocked := g.Order()
+	if !reflect.DeepEqual(order, []string{"CAP-1", "CAP-2"}) {
+		t.Errorf("order: got %v", order)
+	}
+	if !reflect.DeepEqual(blocked, []string{"CAP-3", "CAP-4", "CAP-5", "CAP-6"}) {
+		t.Errorf("blocked: got %v", blocked)
+	}
+
+	want := [][]string{{"CAP-3", "CAP-4"}, {"CAP-6"}}
+	if got := g.Cycles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("cycles: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package graph
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/jareynolds/intentr/pkg/spec"
+)
+
+// specFolders are the workspace folders holding capability and enabler specs
+var specFolders = []string{"definition", "specifications"}
+
+// entityIDPattern matches capability and enabler IDs in free text
+var entityIDPattern = regexp.MustCompile(`\b(?:CAP|ENB)-\d+\b`)
+
+// LoadSpecs adds the capabilities and enablers under root to g, with an
+// edge for each enabler's Capability field and for every ID listed under an
+// upstream or downstream heading such as "Internal Upstream Dependency"
+func LoadSpecs(g *Graph, root string) error {
+	seen := make(map[string]bool)
+	for _, folder := range specFolders {
+		dir := filepath.Join(root, folder)
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, walkErr error) error {
+			if walkErr != nil || info.IsDir() || seen[path] {
+				return nil // Skip errors
+			}
+			seen[path] = true
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil // Skip files we can't read
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				rel = path
+			}
+			AddSpec(g, filepath.ToSlash(rel), info.Name(), string(content))
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scan %s folder: %w", folder, err)
+		}
+	}
+	return nil
+}
+
+// AddSpec adds one spec file, using the same file patterns as the
+// /capability-files and /enabler-files endpoints. Files that are not
+// capability or enabler specs are ignored.
+func AddSpec(g *Graph, rel, filename, content string) {
+	var id, name, kind string
+	var doc *spec.Document
+	switch {
+	case spec.IsEnablerFile(filename):
+		e := spec.ParseEnabler(content)
+		id, name, kind, doc = e.ID, e.Name, KindEnabler, e.Doc
+		if id != "" && strings.HasPrefix(e.CapabilityID, "CAP-") {
+			g.AddEdge(e.CapabilityID, id, EdgeImplementedBy, SourceSpec)
+		}
+	case spec.IsCapabilityFile(filename):
+		c := spec.ParseCapability(content)
+		id, name, kind, doc = c.ID, c.Name, KindCapability, c.Doc
+	default:
+		return
+	}
+	if id == "" {
+		return
+	}
+	g.AddNode(Node{ID: id, Name: name, Kind: kind, File: rel})
+
+	for _, s := range doc.Sections() {
+		title := strings.ToLower(s.Title)
+		upstream := strings.Contains(title, "upstream")
+		if !upstream && !strings.Contains(title, "downstream") {
+			continue
+		}
+		for _, ref := range entityIDPattern.FindAllString(doc.OwnBody(s), -1) {
+			if ref == id {
+				continue
+			}
+			if upstream {
+				g.AddEdge(id, ref, EdgeDependsOn, SourceSpec)
+			} else {
+				g.AddEdge(ref, id, EdgeDependsOn, SourceSpec)
+			}
+		}
+	}
+}
+
+// AddDependencies adds edges loaded from the database
+func AddDependencies(g *Graph, edges []models.DependencyEdge) {
+	for _, e := range edges {
+		g.AddNode(Node{ID: e.FromID, Name: e.FromName})
+		g.AddNode(Node{ID: e.ToID, Name: e.ToName})
+		g.AddEdge(e.FromID, e.ToID, e.Kind, SourceDatabase)
+	}
+}
@@ -0,0 +1,164 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Package idalloc hands out collision-free CAP/ENB/FR/NFR/TS identifiers.
+// The next number is one above the highest already in use, as reported by the
+// configured sources (a workspace's spec files, database tables), and never
+// repeats a number this allocator has already handed out. Numbers come from
+// one sequence per prefix shared by every workspace, as the database ID
+// columns are unique across workspaces.
+package idalloc
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// ID prefixes the allocator manages
+const (
+	PrefixCapability    = "CAP"
+	PrefixEnabler       = "ENB"
+	PrefixFunctional    = "FR"
+	PrefixNonFunctional = "NFR"
+	PrefixTestScenario  = "TS"
+)
+
+// Prefixes lists every supported prefix
+var Prefixes = []string{PrefixCapability, PrefixEnabler, PrefixFunctional, PrefixNonFunctional, PrefixTestScenario}
+
+// ValidPrefix reports whether prefix is one the allocator manages
+func ValidPrefix(prefix string) bool {
+	for _, p := range Prefixes {
+		if p == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// Source reports the highest number already used for prefix in a workspace
+type Source interface {
+	MaxID(workspace, prefix string) (int, error)
+}
+
+// Sequencer durably reserves the next number above floor for prefix so that
+// separate processes sharing the same store never hand out the same ID
+type Sequencer interface {
+	Reserve(prefix string, floor int) (int, error)
+}
+
+// Allocator reserves IDs. It is safe for concurrent use.
+type Allocator struct {
+	mu        sync.Mutex
+	keys      map[string]*keyState
+	sources   []Source
+	sequencer Sequencer
+}
+
+// keyState serializes allocation for one prefix
+type keyState struct {
+	mu   sync.Mutex
+	last int
+}
+
+// New creates an allocator. sequencer may be nil, in which case uniqueness is
+// only guaranteed within this process.
+func New(sequencer Sequencer, sources ...Source) *Allocator {
+	return &Allocator{
+		keys:      make(map[string]*keyState),
+		sources:   sources,
+		sequencer: sequencer,
+	}
+}
+
+// Next reserves and returns the next free ID for prefix. workspace is passed
+// to the sources, e.g. the folder FileSource scans.
+func (a *Allocator) Next(workspace, prefix string) (string, error) {
+	ids, err := a.NextN(workspace, prefix, 1)
+	if err != nil {
+		return "", err
+	}
+	return ids[0], nil
+}
+
+// NextN reserves count consecutive allocations for prefix in workspace. The
+// sources are scanned once for the whole batch.
+func (a *Allocator) NextN(workspace, prefix string, count int) ([]string, error) {
+	if !ValidPrefix(prefix) {
+		return nil, fmt.Errorf("unsupported ID prefix %q", prefix)
+	}
+
+	key := a.key(prefix)
+	key.mu.Lock()
+	defer key.mu.Unlock()
+
+	floor := key.last
+	for _, src := range a.sources {
+		n, err := src.MaxID(workspace, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan existing %s IDs: %w", prefix, err)
+		}
+		if n > floor {
+			floor = n
+		}
+	}
+
+	ids := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		next := floor + 1
+		if a.sequencer != nil {
+			n, err := a.sequencer.Reserve(prefix, floor)
+			if err != nil {
+				return nil, fmt.Errorf("failed to reserve %s ID: %w", prefix, err)
+			}
+			next = n
+		}
+		key.last = next
+		floor = next
+		ids = append(ids, Format(prefix, next))
+	}
+	return ids, nil
+}
+
+func (a *Allocator) key(prefix string) *keyState {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if st, ok := a.keys[prefix]; ok {
+		return st
+	}
+	st := &keyState{}
+	a.keys[prefix] = st
+	return st
+}
+
+// Format renders an ID such as CAP-000042
+func Format(prefix string, n int) string {
+	return fmt.Sprintf("%s-%06d", prefix, n)
+}
+
+// idPatterns matches IDs in free text; \b keeps FR- from matching inside NFR-
+var idPatterns = map[string]*regexp.Regexp{}
+
+func init() {
+	for _, p := range Prefixes {
+		idPatterns[p] = regexp.MustCompile(`\b` + p + `-(\d+)`)
+	}
+}
+
+// MaxInText returns the highest number used with prefix anywhere in text
+func MaxInText(text, prefix string) int {
+	max := 0
+	for _, m := range idPatterns[prefix].FindAllStringSubmatch(text, -1) {
+		if n, err := strconv.Atoi(m[1]); err == nil && n > max {
+			max = n
+		}
+	}
+	return max
+}
@@ -0,0 +1,170 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package idalloc
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestNextStartsAboveExistingFiles(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "definition"), 0755)
+	os.WriteFile(filepath.Join(root, "definition", "CAP-000041.md"), []byte("# A\n\n- **ID**: CAP-000041\n"), 0644)
+	os.WriteFile(filepath.Join(root, "definition", "ENB-x.md"), []byte("- **Capability ID**: CAP-000097\n| NFR-000500 | x |\n"), 0644)
+
+	a := New(nil, FileSource{})
+
+	if id, err := a.Next(root, PrefixCapability); err != nil || id != "CAP-000098" {
+		t.Fatalf("expected CAP-000098, got %q (%v)", id, err)
+	}
+	if id, _ := a.Next(root, PrefixFunctional); id != "FR-000001" {
+		t.Errorf("NFR- IDs must not count as FR- IDs, got %q", id)
+	}
+	if _, err := a.Next(root, "XYZ"); err == nil {
+		t.Error("expected an error for an unknown prefix")
+	}
+}
+
+func TestNextIsUniqueUnderConcurrency(t *testing.T) {
+	a := New(nil, FileSource{})
+	root := t.TempDir()
+
+	const n = 50
+	ids := make(chan string, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := a.Next(root, PrefixEnabler)
+			if err != nil {
+				t.Error(err)
+			}
+			ids <- id
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[string]bool)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("duplicate ID %s", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != n {
+		t.Errorf("expected %d IDs, got %d", n, len(seen))
+	}
+}
+
+// fakeSequencer is a shared store such as the id_sequences table
+type fakeSequencer struct {
+	mu   sync.Mutex
+	last map[string]int
+}
+
+func (f *fakeSequencer) Reserve(prefix string, floor int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if floor > f.last[prefix] {
+		f.last[prefix] = floor
+	}
+	f.last[prefix]++
+	return f.last[prefix], nil
+}
+
+func TestSequencerIsSharedAcrossWorkspaces(t *testing.T) {
+	seq := &fakeSequencer{last: map[string]int{"TS": 7}}
+	a := New(seq)
+
+	if id, _ := a.Next("ws-a", PrefixTestScenario); id != "TS-000008" {
+		t.Errorf("expected TS-000008, got %q", id)
+	}
+	if id, _ := a.Next("ws-b", PrefixTestScenario); id != "TS-000009" {
+		t.Errorf("expected TS-000009, got %q", id)
+	}
+}
+
+// TestServicesNeverShareAnID allocates concurrently the way the capability
+// service (no workspace) and the integration service (workspace folders, with
+// their files as a source) do, against one store
+func TestServicesNeverShareAnID(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "definition"), 0755)
+	os.WriteFile(filepath.Join(root, "definition", "CAP-000010.md"), []byte("- **ID**: CAP-000010\n"), 0644)
+
+	seq := &fakeSequencer{last: map[string]int{}}
+	capabilityService := New(seq)
+	integrationService := New(seq, FileSource{})
+
+	const n = 40
+	ids := make(chan string, 2*n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			id, err := capabilityService.Next("", PrefixCapability)
+			if err != nil {
+				t.Error(err)
+			}
+			ids <- id
+		}()
+		go func() {
+			defer wg.Done()
+			id, err := integrationService.Next(root, PrefixCapability)
+			if err != nil {
+				t.Error(err)
+			}
+			ids <- id
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[string]bool)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("duplicate ID %s", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != 2*n {
+		t.Errorf("expected %d IDs, got %d", 2*n, len(seen))
+	}
+}
+
+// countingSource counts the scans it is asked for
+type countingSource struct{ scans int }
+
+func (c *countingSource) MaxID(workspace, prefix string) (int, error) {
+	c.scans++
+	return 4, nil
+}
+
+func TestNextNScansOnce(t *testing.T) {
+	src := &countingSource{}
+	a := New(&fakeSequencer{last: map[string]int{}}, src)
+
+	ids, err := a.NextN("ws-a", PrefixFunctional, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"FR-000005", "FR-000006", "FR-000007"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("NextN() = %q, want %q", ids, want)
+	}
+	if src.scans != 1 {
+		t.Errorf("sources scanned %d times, want once", src.scans)
+	}
+}
@@ -0,0 +1,64 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package idalloc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileSource scans a workspace folder's Markdown files, names and contents,
+// for IDs already in use. The workspace key is the workspace folder path.
+type FileSource struct{}
+
+// skipDirs are never scanned for IDs
+var skipDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// MaxID implements Source
+func (FileSource) MaxID(workspace, prefix string) (int, error) {
+	if workspace == "" {
+		return 0, nil
+	}
+	if _, err := os.Stat(workspace); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	max := 0
+	err := filepath.Walk(workspace, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return nil // Skip errors
+		}
+		if info.IsDir() {
+			if path != workspace && skipDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(strings.ToLower(info.Name()), ".md") {
+			return nil
+		}
+		if n := MaxInText(info.Name(), prefix); n > max {
+			max = n
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil // Skip files we can't read
+		}
+		if n := MaxInText(string(content), prefix); n > max {
+			max = n
+		}
+		return nil
+	})
+	return max, err
+}
@@ -0,0 +1,178 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package jirasync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Jira status categories, which every Jira workflow status belongs to
+const (
+	CategoryToDo       = "new"
+	CategoryInProgress = "indeterminate"
+	CategoryDone       = "done"
+)
+
+// Issue is the part of a Jira issue the sync reads
+type Issue struct {
+	Key            string
+	Summary        string
+	Status         string
+	StatusCategory string
+}
+
+// Transition is a workflow transition available on an issue
+type Transition struct {
+	ID         string
+	Name       string
+	ToStatus   string
+	ToCategory string
+}
+
+// Client calls the Jira Cloud REST API (v3) with basic auth
+type Client struct {
+	BaseURL    string // e.g. https://example.atlassian.net
+	Email      string
+	APIToken   string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a client for a Jira site
+func NewClient(baseURL, email, apiToken string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Email:      email,
+		APIToken:   apiToken,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do sends a request and decodes a JSON response into out, if given
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal Jira request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.Email, c.APIToken)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Jira: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Jira API error (%d): %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode Jira response: %w", err)
+	}
+	return nil
+}
+
+type jiraStatus struct {
+	Name           string `json:"name"`
+	StatusCategory struct {
+		Key string `json:"key"`
+	} `json:"statusCategory"`
+}
+
+// GetIssue returns an issue's summary and status
+func (c *Client) GetIssue(ctx context.Context, key string) (*Issue, error) {
+	var issue struct {
+		Key    string `json:"key"`
+		Fields struct {
+			Summary string     `json:"summary"`
+			Status  jiraStatus `json:"status"`
+		} `json:"fields"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/rest/api/3/issue/"+url.PathEscape(key)+"?fields=summary,status", nil, &issue); err != nil {
+		return nil, err
+	}
+	return &Issue{
+		Key:            issue.Key,
+		Summary:        issue.Fields.Summary,
+		Status:         issue.Fields.Status.Name,
+		StatusCategory: issue.Fields.Status.StatusCategory.Key,
+	}, nil
+}
+
+// Transitions returns the transitions the issue's workflow allows from its
+// current status
+func (c *Client) Transitions(ctx context.Context, key string) ([]Transition, error) {
+	var resp struct {
+		Transitions []struct {
+			ID   string     `json:"id"`
+			Name string     `json:"name"`
+			To   jiraStatus `json:"to"`
+		} `json:"transitions"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/rest/api/3/issue/"+url.PathEscape(key)+"/transitions", nil, &resp); err != nil {
+		return nil, err
+	}
+	transitions := make([]Transition, len(resp.Transitions))
+	for i, t := range resp.Transitions {
+		transitions[i] = Transition{ID: t.ID, Name: t.Name, ToStatus: t.To.Name, ToCategory: t.To.StatusCategory.Key}
+	}
+	return transitions, nil
+}
+
+// Transition moves an issue along a workflow transition
+func (c *Client) Transition(ctx context.Context, key, transitionID string) error {
+	body := map[string]interface{}{"transition": map[string]string{"id": transitionID}}
+	return c.do(ctx, http.MethodPost, "/rest/api/3/issue/"+url.PathEscape(key)+"/transitions", body, nil)
+}
+
+// SetSummary changes an issue's summary
+func (c *Client) SetSummary(ctx context.Context, key, summary string) error {
+	body := map[string]interface{}{"fields": map[string]string{"summary": summary}}
+	return c.do(ctx, http.MethodPut, "/rest/api/3/issue/"+url.PathEscape(key), body, nil)
+}
+
+// AddComment adds a plain text comment to an issue
+func (c *Client) AddComment(ctx context.Context, key, text string) error {
+	// Comment bodies are Atlassian Document Format, one paragraph per line
+	var paragraphs []interface{}
+	for _, line := range strings.Split(text, "\n") {
+		paragraph := map[string]interface{}{"type": "paragraph"}
+		if line != "" {
+			paragraph["content"] = []interface{}{map[string]string{"type": "text", "text": line}}
+		}
+		paragraphs = append(paragraphs, paragraph)
+	}
+	body := map[string]interface{}{
+		"body": map[string]interface{}{"type": "doc", "version": 1, "content": paragraphs},
+	}
+	return c.do(ctx, http.MethodPost, "/rest/api/3/issue/"+url.PathEscape(key)+"/comment", body, nil)
+}
@@ -0,0 +1,254 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Package jirasync keeps capabilities and enablers in step with the Jira
+// issues they are linked to. Each link records both sides as of its last
+// sync, so a sync can tell which side changed since: Jira summary and status
+// changes are pulled into IntentR, and IntentR name, state and approval
+// changes are pushed to Jira as summary edits, workflow transitions and
+// comments. A field changed on both sides is a conflict; nothing is applied
+// to that link until the conflict is resolved in favour of one side.
+package jirasync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/jareynolds/intentr/pkg/statemodel"
+)
+
+// Item is the IntentR side of a link
+type Item struct {
+	Name    string
+	State   statemodel.State
+	Version int // For optimistic locking of state updates
+}
+
+// Store reads and updates linked capabilities and enablers
+type Store interface {
+	Item(entityType, entityID string) (*Item, error)
+	UpdateState(entityType, entityID string, req models.UpdateEntityStateRequest) error
+	Rename(entityType, entityID, name string) error
+}
+
+// lifecycleCategories maps lifecycle states to the Jira status category
+// that corresponds to them
+var lifecycleCategories = map[string]string{
+	string(models.LifecycleStateDraft):       CategoryToDo,
+	string(models.LifecycleStateActive):      CategoryInProgress,
+	string(models.LifecycleStateImplemented): CategoryDone,
+	string(models.LifecycleStateMaintained):  CategoryDone,
+	string(models.LifecycleStateRetired):     CategoryDone,
+}
+
+// categoryLifecycles maps Jira status categories to the lifecycle state an
+// item is moved to when its issue enters that category
+var categoryLifecycles = map[string]string{
+	CategoryToDo:       string(models.LifecycleStateDraft),
+	CategoryInProgress: string(models.LifecycleStateActive),
+	CategoryDone:       string(models.LifecycleStateImplemented),
+}
+
+// stateLabels names the state dimensions in comments
+var stateLabels = map[string]string{
+	statemodel.FieldLifecycleState: "Lifecycle state",
+	statemodel.FieldWorkflowStage:  "Workflow stage",
+	statemodel.FieldStageStatus:    "Stage status",
+	statemodel.FieldApprovalStatus: "Approval status",
+}
+
+// agrees reports whether an item's lifecycle state matches its issue's
+// status category. Unknown values never disagree.
+func agrees(state statemodel.State, issue *Issue) bool {
+	category, ok := lifecycleCategories[state.LifecycleState]
+	return !ok || issue.StatusCategory == "" || category == issue.StatusCategory
+}
+
+func lastState(link *models.JiraSyncLink) statemodel.State {
+	return statemodel.State{
+		LifecycleState: link.LastLifecycleState,
+		WorkflowStage:  link.LastWorkflowStage,
+		StageStatus:    link.LastStageStatus,
+		ApprovalStatus: link.LastApprovalStatus,
+	}
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+// Sync syncs one link. prefer resolves conflicts in favour of
+// models.JiraSyncPreferIntentR or models.JiraSyncPreferJira; empty reports
+// them. The link's Last* fields are updated for the caller to save.
+func Sync(ctx context.Context, jira *Client, store Store, link *models.JiraSyncLink, prefer string) models.JiraSyncResult {
+	result := models.JiraSyncResult{
+		LinkID:     link.ID,
+		EntityType: link.EntityType,
+		EntityID:   link.EntityID,
+		IssueKey:   link.IssueKey,
+	}
+	finish := func(outcome, message string) models.JiraSyncResult {
+		result.Result = outcome
+		link.LastResult = outcome
+		link.LastMessage = message
+		return result
+	}
+	fail := func(err error) models.JiraSyncResult {
+		result.Error = err.Error()
+		return finish(models.JiraSyncResultError, err.Error())
+	}
+
+	item, err := store.Item(link.EntityType, link.EntityID)
+	if err != nil {
+		return fail(fmt.Errorf("failed to read %s %s: %w", link.EntityType, link.EntityID, err))
+	}
+	issue, err := jira.GetIssue(ctx, link.IssueKey)
+	if err != nil {
+		return fail(fmt.Errorf("failed to read %s: %w", link.IssueKey, err))
+	}
+
+	// A side without a record counts as unchanged
+	localRecorded := link.LastName != ""
+	jiraRecorded := link.LastJiraStatus != ""
+	nameChanged := localRecorded && item.Name != link.LastName
+	stateChanged := localRecorded && item.State != lastState(link)
+	summaryChanged := jiraRecorded && issue.Summary != link.LastSummary
+	statusChanged := jiraRecorded && issue.Status != link.LastJiraStatus
+
+	var pullName, pushName, pullStatus, pushStatus bool
+	switch {
+	case nameChanged && summaryChanged && item.Name != issue.Summary:
+		result.Conflicts = append(result.Conflicts, models.JiraSyncConflict{
+			Field: "summary", Last: link.LastSummary, IntentR: item.Name, Jira: issue.Summary,
+		})
+		pullName, pushName = prefer == models.JiraSyncPreferJira, prefer == models.JiraSyncPreferIntentR
+	case summaryChanged:
+		pullName = item.Name != issue.Summary
+	case nameChanged:
+		pushName = item.Name != issue.Summary
+	}
+	switch {
+	case stateChanged && statusChanged && !agrees(item.State, issue):
+		result.Conflicts = append(result.Conflicts, models.JiraSyncConflict{
+			Field: "status", Last: link.LastJiraStatus, IntentR: item.State.LifecycleState, Jira: issue.Status,
+		})
+		pullStatus, pushStatus = prefer == models.JiraSyncPreferJira, prefer == models.JiraSyncPreferIntentR
+	default:
+		pullStatus = statusChanged && !stateChanged && !agrees(item.State, issue)
+		pushStatus = stateChanged
+	}
+
+	if len(result.Conflicts) > 0 && prefer == "" {
+		var fields []string
+		for _, c := range result.Conflicts {
+			fields = append(fields, c.Field)
+		}
+		return finish(models.JiraSyncResultConflict, fmt.Sprintf("%s changed in both IntentR and Jira", strings.Join(fields, " and ")))
+	}
+
+	// Pull
+	if pullStatus {
+		lifecycle, ok := categoryLifecycles[issue.StatusCategory]
+		if ok && lifecycle != item.State.LifecycleState {
+			err := store.UpdateState(link.EntityType, link.EntityID, models.UpdateEntityStateRequest{
+				LifecycleState: &lifecycle,
+				Version:        item.Version,
+				ChangeReason:   fmt.Sprintf("Synced from Jira: %s moved to %s", link.IssueKey, issue.Status),
+			})
+			if err != nil {
+				return fail(fmt.Errorf("failed to apply %s status %s: %w", link.IssueKey, issue.Status, err))
+			}
+			result.Pulled = append(result.Pulled, fmt.Sprintf("Lifecycle state: %s → %s", orNone(item.State.LifecycleState), lifecycle))
+			item.State.LifecycleState = lifecycle
+		}
+	}
+	if pullName {
+		if err := store.Rename(link.EntityType, link.EntityID, issue.Summary); err != nil {
+			return fail(fmt.Errorf("failed to rename %s: %w", link.EntityID, err))
+		}
+		result.Pulled = append(result.Pulled, fmt.Sprintf("Name: %s → %s", item.Name, issue.Summary))
+		item.Name = issue.Summary
+	}
+
+	// Push
+	if pushName {
+		if err := jira.SetSummary(ctx, link.IssueKey, item.Name); err != nil {
+			return fail(fmt.Errorf("failed to update %s summary: %w", link.IssueKey, err))
+		}
+		result.Pushed = append(result.Pushed, fmt.Sprintf("Summary: %s → %s", issue.Summary, item.Name))
+		issue.Summary = item.Name
+	}
+	if pushStatus {
+		var lines []string
+		if !agrees(item.State, issue) {
+			transition, err := pushTransition(ctx, jira, link.IssueKey, lifecycleCategories[item.State.LifecycleState])
+			if err != nil {
+				return fail(err)
+			}
+			result.Pushed = append(result.Pushed, fmt.Sprintf("Status: %s → %s", issue.Status, transition.ToStatus))
+			issue.Status, issue.StatusCategory = transition.ToStatus, transition.ToCategory
+		}
+
+		last := lastState(link)
+		for _, field := range statemodel.Fields {
+			if from, to := last.Get(field), item.State.Get(field); localRecorded && from != to {
+				lines = append(lines, fmt.Sprintf("- %s: %s → %s", stateLabels[field], orNone(from), orNone(to)))
+			}
+		}
+		if len(lines) > 0 {
+			comment := fmt.Sprintf("IntentR updated %s (%s):\n%s", link.EntityID, item.Name, strings.Join(lines, "\n"))
+			if err := jira.AddComment(ctx, link.IssueKey, comment); err != nil {
+				return fail(fmt.Errorf("failed to comment on %s: %w", link.IssueKey, err))
+			}
+			result.Pushed = append(result.Pushed, "Comment: "+strings.Join(lines, "; "))
+		}
+	}
+
+	// Record both sides as they are now
+	now := time.Now()
+	link.LastName = item.Name
+	link.LastLifecycleState = item.State.LifecycleState
+	link.LastWorkflowStage = item.State.WorkflowStage
+	link.LastStageStatus = item.State.StageStatus
+	link.LastApprovalStatus = item.State.ApprovalStatus
+	link.LastSummary = issue.Summary
+	link.LastJiraStatus = issue.Status
+	link.LastSyncedAt = &now
+
+	changes := append(append([]string{}, result.Pulled...), result.Pushed...)
+	switch {
+	case len(changes) > 0:
+		return finish(models.JiraSyncResultSynced, strings.Join(changes, "; "))
+	case !localRecorded || !jiraRecorded:
+		return finish(models.JiraSyncResultBaseline, "")
+	}
+	return finish(models.JiraSyncResultUnchanged, "")
+}
+
+// pushTransition moves an issue to a status in category
+func pushTransition(ctx context.Context, jira *Client, key, category string) (*Transition, error) {
+	transitions, err := jira.Transitions(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s transitions: %w", key, err)
+	}
+	for _, t := range transitions {
+		if t.ToCategory == category {
+			if err := jira.Transition(ctx, key, t.ID); err != nil {
+				return nil, fmt.Errorf("failed to transition %s to %s: %w", key, t.ToStatus, err)
+			}
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no transition to a %q status", key, category)
+}
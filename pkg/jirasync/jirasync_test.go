@@ -0,0 +1,148 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package jirasync
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jareynolds/intentr/pkg/jirasync/jiratest"
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/jareynolds/intentr/pkg/statemodel"
+)
+
+// memStore holds one item in memory
+type memStore struct {
+	item Item
+}
+
+func (s *memStore) Item(entityType, entityID string) (*Item, error) {
+	item := s.item
+	return &item, nil
+}
+
+func (s *memStore) UpdateState(entityType, entityID string, req models.UpdateEntityStateRequest) error {
+	next := s.item.State.Apply(req)
+	if err := statemodel.Validate(s.item.State, next, statemodel.Context{}); err != nil {
+		return err
+	}
+	s.item.State = next
+	s.item.Version++
+	return nil
+}
+
+func (s *memStore) Rename(entityType, entityID, name string) error {
+	s.item.Name = name
+	return nil
+}
+
+func setup(t *testing.T) (*jiratest.Server, *Client, *memStore, *models.JiraSyncLink) {
+	t.Helper()
+	stub := jiratest.NewServer("user@example.com", "token")
+	stub.AddIssue("PROJ-1", "Checkout")
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+
+	store := &memStore{item: Item{
+		Name: "Checkout",
+		State: statemodel.State{
+			LifecycleState: "draft",
+			WorkflowStage:  "implementation",
+			StageStatus:    "in_progress",
+			ApprovalStatus: "pending",
+		},
+		Version: 1,
+	}}
+	link := &models.JiraSyncLink{ID: 1, EntityType: "capability", EntityID: "CAP-000001", IssueKey: "PROJ-1"}
+	return stub, NewClient(server.URL, "user@example.com", "token"), store, link
+}
+
+func TestSync(t *testing.T) {
+	stub, client, store, link := setup(t)
+	ctx := context.Background()
+
+	if got := Sync(ctx, client, store, link, ""); got.Result != models.JiraSyncResultBaseline {
+		t.Fatalf("first Sync() = %+v, want baseline", got)
+	}
+	if got := Sync(ctx, client, store, link, ""); got.Result != models.JiraSyncResultUnchanged {
+		t.Fatalf("second Sync() = %+v, want unchanged", got)
+	}
+
+	// Pull: Jira moves the issue and renames it
+	stub.SetStatus("PROJ-1", "In Progress")
+	stub.SetSummary("PROJ-1", "Checkout v2")
+	got := Sync(ctx, client, store, link, "")
+	if got.Result != models.JiraSyncResultSynced || len(got.Pulled) != 2 || len(got.Pushed) != 0 {
+		t.Fatalf("Sync(after Jira change) = %+v", got)
+	}
+	if store.item.State.LifecycleState != "active" || store.item.Name != "Checkout v2" {
+		t.Errorf("Sync() left item %+v, want active and renamed", store.item)
+	}
+
+	// Push: IntentR implements and approves it
+	store.item.State.LifecycleState = "implemented"
+	store.item.State.ApprovalStatus = "approved"
+	got = Sync(ctx, client, store, link, "")
+	if got.Result != models.JiraSyncResultSynced || len(got.Pushed) != 2 {
+		t.Fatalf("Sync(after IntentR change) = %+v", got)
+	}
+	issue, _ := stub.Issue("PROJ-1")
+	if issue.Status != "Done" {
+		t.Errorf("issue status = %q, want Done", issue.Status)
+	}
+	if len(issue.Comments) != 1 || !strings.Contains(issue.Comments[0], "Approval status: pending → approved") {
+		t.Errorf("issue comments = %q", issue.Comments)
+	}
+	if link.LastJiraStatus != "Done" || link.LastApprovalStatus != "approved" {
+		t.Errorf("link = %+v, want both sides recorded", link)
+	}
+}
+
+func TestSyncConflict(t *testing.T) {
+	stub, client, store, link := setup(t)
+	ctx := context.Background()
+	Sync(ctx, client, store, link, "")
+
+	stub.SetStatus("PROJ-1", "Done")
+	store.item.State.LifecycleState = "retired"
+	store.item.State.StageStatus = "blocked"
+
+	// Done and retired agree, so this is no conflict
+	got := Sync(ctx, client, store, link, "")
+	if got.Result != models.JiraSyncResultSynced || len(got.Conflicts) != 0 {
+		t.Fatalf("Sync(agreeing changes) = %+v, want synced", got)
+	}
+
+	stub.SetStatus("PROJ-1", "To Do")
+	store.item.State.LifecycleState = "draft"
+	store.item.State.StageStatus = "in_progress"
+	Sync(ctx, client, store, link, "")
+
+	// Both sides move the item, to disagreeing states
+	stub.SetStatus("PROJ-1", "In Progress")
+	store.item.State.LifecycleState = "retired"
+	store.item.State.StageStatus = "blocked"
+	got = Sync(ctx, client, store, link, "")
+	if got.Result != models.JiraSyncResultConflict || len(got.Conflicts) != 1 || got.Conflicts[0].Field != "status" {
+		t.Fatalf("Sync(conflict) = %+v", got)
+	}
+	if issue, _ := stub.Issue("PROJ-1"); issue.Status != "In Progress" || store.item.State.LifecycleState != "retired" {
+		t.Errorf("Sync(conflict) changed a side: issue %q, item %q", issue.Status, store.item.State.LifecycleState)
+	}
+
+	got = Sync(ctx, client, store, link, models.JiraSyncPreferIntentR)
+	if got.Result != models.JiraSyncResultSynced {
+		t.Fatalf("Sync(prefer IntentR) = %+v", got)
+	}
+	if issue, _ := stub.Issue("PROJ-1"); issue.Status != "Done" {
+		t.Errorf("Sync(prefer IntentR) left issue in %q, want Done", issue.Status)
+	}
+}
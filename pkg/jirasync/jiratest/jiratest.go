@@ -0,0 +1,281 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Package jiratest is a local stub of the Jira Cloud REST API for tests and
+// local development (cmd/mock-jira). It keeps issues in memory, with the
+// default "To Do", "In Progress" and "Done" workflow, and serves the calls
+// IntentR makes: epic search, issue reads, summary edits, transitions and
+// comments. Every request must use the server's basic auth credentials.
+package jiratest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Status is a workflow status and its status category
+type Status struct {
+	ID       string
+	Name     string
+	Category string // new, indeterminate or done
+}
+
+// Statuses is the stub's workflow. Every status can move to every other one
+// through the transition with the target status's ID.
+var Statuses = []Status{
+	{ID: "11", Name: "To Do", Category: "new"},
+	{ID: "21", Name: "In Progress", Category: "indeterminate"},
+	{ID: "31", Name: "Done", Category: "done"},
+}
+
+// Issue is an issue held by the stub
+type Issue struct {
+	ID       string
+	Key      string
+	Type     string // e.g. Epic
+	Summary  string
+	Status   string
+	Priority string
+	Labels   []string
+	Comments []string // Plain text of each comment
+}
+
+// Server is a stub Jira site
+type Server struct {
+	Email    string
+	APIToken string
+
+	mu     sync.Mutex
+	issues map[string]*Issue
+	nextID int
+}
+
+// NewServer creates a stub that accepts one user's credentials
+func NewServer(email, apiToken string) *Server {
+	return &Server{Email: email, APIToken: apiToken, issues: map[string]*Issue{}}
+}
+
+func statusNamed(name string) (Status, bool) {
+	for _, s := range Statuses {
+		if strings.EqualFold(s.Name, name) {
+			return s, true
+		}
+	}
+	return Status{}, false
+}
+
+// AddIssue adds or replaces an epic in status "To Do"
+func (s *Server) AddIssue(key, summary string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	s.issues[key] = &Issue{ID: fmt.Sprint(10000 + s.nextID), Key: key, Type: "Epic", Summary: summary, Status: "To Do", Priority: "Medium"}
+}
+
+// SetStatus changes an issue's status as a Jira user would
+func (s *Server) SetStatus(key, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if issue, ok := s.issues[key]; ok {
+		issue.Status = status
+	}
+}
+
+// SetSummary changes an issue's summary as a Jira user would
+func (s *Server) SetSummary(key, summary string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if issue, ok := s.issues[key]; ok {
+		issue.Summary = summary
+	}
+}
+
+// Issue returns a copy of an issue
+func (s *Server) Issue(key string) (Issue, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	issue, ok := s.issues[key]
+	if !ok {
+		return Issue{}, false
+	}
+	c := *issue
+	c.Comments = append([]string(nil), issue.Comments...)
+	return c, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{"errorMessages": []string{message}})
+}
+
+func statusJSON(status Status) map[string]interface{} {
+	return map[string]interface{}{
+		"id":             status.ID,
+		"name":           status.Name,
+		"statusCategory": map[string]string{"key": status.Category},
+	}
+}
+
+func issueJSON(r *http.Request, issue *Issue) map[string]interface{} {
+	status, _ := statusNamed(issue.Status)
+	return map[string]interface{}{
+		"id":   issue.ID,
+		"key":  issue.Key,
+		"self": "http://" + r.Host + "/rest/api/3/issue/" + issue.Key,
+		"fields": map[string]interface{}{
+			"summary":     issue.Summary,
+			"description": map[string]interface{}{"type": "doc", "version": 1, "content": []interface{}{}},
+			"status":      statusJSON(status),
+			"priority":    map[string]string{"name": issue.Priority},
+			"labels":      append([]string{}, issue.Labels...),
+			"issuetype":   map[string]string{"name": issue.Type},
+		},
+	}
+}
+
+// ServeHTTP implements the Jira REST API subset
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if email, token, ok := r.BasicAuth(); !ok || email != s.Email || token != s.APIToken {
+		writeError(w, http.StatusUnauthorized, "Client must be authenticated to access this resource.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/rest/api/3/search/jql" && r.Method == http.MethodPost {
+		s.search(w, r)
+		return
+	}
+
+	rest, ok := strings.CutPrefix(r.URL.Path, "/rest/api/3/issue/")
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	key, action, _ := strings.Cut(rest, "/")
+	issue, ok := s.issues[key]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.")
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, issueJSON(r, issue))
+	case action == "" && r.Method == http.MethodPut:
+		var req struct {
+			Fields struct {
+				Summary *string `json:"summary"`
+			} `json:"fields"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if req.Fields.Summary != nil {
+			issue.Summary = *req.Fields.Summary
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case action == "transitions" && r.Method == http.MethodGet:
+		var transitions []interface{}
+		for _, status := range Statuses {
+			if status.Name != issue.Status {
+				transitions = append(transitions, map[string]interface{}{
+					"id":   status.ID,
+					"name": status.Name,
+					"to":   statusJSON(status),
+				})
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"transitions": transitions})
+	case action == "transitions" && r.Method == http.MethodPost:
+		var req struct {
+			Transition struct {
+				ID string `json:"id"`
+			} `json:"transition"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		for _, status := range Statuses {
+			if status.ID == req.Transition.ID && status.Name != issue.Status {
+				issue.Status = status.Name
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Transition id '%s' is not valid for this issue.", req.Transition.ID))
+	case action == "comment" && r.Method == http.MethodPost:
+		var req struct {
+			Body struct {
+				Content []struct {
+					Content []struct {
+						Text string `json:"text"`
+					} `json:"content"`
+				} `json:"content"`
+			} `json:"body"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		var lines []string
+		for _, paragraph := range req.Body.Content {
+			var line string
+			for _, text := range paragraph.Content {
+				line += text.Text
+			}
+			lines = append(lines, line)
+		}
+		issue.Comments = append(issue.Comments, strings.Join(lines, "\n"))
+		writeJSON(w, http.StatusCreated, map[string]string{"id": fmt.Sprint(len(issue.Comments))})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// search serves JQL searches, returning every issue of the project named by
+// "project=KEY"; other clauses are ignored
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		JQL string `json:"jql"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	project := ""
+	if _, rest, ok := strings.Cut(req.JQL, "project="); ok {
+		project, _, _ = strings.Cut(rest, " ")
+	}
+
+	keys := make([]string, 0, len(s.issues))
+	for key := range s.issues {
+		if project == "" || strings.HasPrefix(key, project+"-") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	issues := make([]interface{}, len(keys))
+	for i, key := range keys {
+		issues[i] = issueJSON(r, s.issues[key])
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"total": len(issues), "issues": issues})
+}
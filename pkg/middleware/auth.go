@@ -16,7 +16,9 @@ import (
 	"github.com/jareynolds/intentr/internal/auth"
 )
 
-// AuthMiddleware creates middleware that validates JWT tokens
+// AuthMiddleware creates middleware that validates JWTs and API tokens
+// (personal access tokens and service account tokens). API tokens must have
+// the scope the request method needs.
 func AuthMiddleware(service *auth.Service) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -33,12 +35,17 @@ func AuthMiddleware(service *auth.Service) func(http.Handler) http.Handler {
 			}
 
 			token := parts[1]
-			claims, err := service.VerifyToken(token)
+			claims, err := service.VerifyBearerToken(token, r.RemoteAddr)
 			if err != nil {
 				http.Error(w, `{"error":"Invalid or expired token"}`, http.StatusUnauthorized)
 				return
 			}
 
+			if !claims.HasScope(auth.ScopeForMethod(r.Method)) {
+				http.Error(w, `{"error":"Token scope does not allow this request"}`, http.StatusForbidden)
+				return
+			}
+
 			// Add claims to request context
 			ctx := context.WithValue(r.Context(), "claims", claims)
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -55,7 +62,7 @@ func AdminOnlyMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		if claims.Role != "admin" {
+		if claims.Role != "admin" || !claims.HasScope(auth.ScopeAdmin) {
 			http.Error(w, `{"error":"Admin access required"}`, http.StatusForbidden)
 			return
 		}
@@ -71,6 +71,9 @@ type CapabilityApproval struct {
 	DeciderName  string     `json:"decider_name,omitempty"` // Joined from users table
 	Feedback     *string    `json:"feedback,omitempty"`
 
+	// Approval policy evaluation, returned after a vote
+	Progress *ApprovalProgress `json:"progress,omitempty"`
+
 	// Timestamps
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -93,12 +96,24 @@ type ApprovalAuditLog struct {
 	ID           int             `json:"id"`
 	ApprovalID   *int            `json:"approval_id,omitempty"`
 	CapabilityID int             `json:"capability_id"`
-	Action       string          `json:"action"` // 'requested', 'approved', 'rejected', 'withdrawn'
+	Action       string          `json:"action"` // 'requested', 'vote', 'approved', 'rejected', 'withdrawn'
 	Stage        string          `json:"stage"`
-	PerformedBy  int             `json:"performed_by"`
+	PerformedBy  int             `json:"performed_by"` // 0 for the approval SLA scheduler
 	PerformerName string         `json:"performer_name,omitempty"` // Joined from users table
 	PerformedAt  time.Time       `json:"performed_at"`
 	Details      json.RawMessage `json:"details,omitempty"`
+
+	// Set on entries for enabler approvals; CapabilityID is then the enabler's capability
+	EnablerApprovalID *int `json:"enabler_approval_id,omitempty"`
+	EnablerID         *int `json:"enabler_id,omitempty"`
+	RequirementID     *int `json:"requirement_id,omitempty"`
+
+	// Set when PerformedBy acted as a delegate of this user
+	OnBehalfOf     *int   `json:"on_behalf_of,omitempty"`
+	OnBehalfOfName string `json:"on_behalf_of_name,omitempty"`
+
+	// Human-readable entry, e.g. "approved by Alice on behalf of Bob"
+	Summary string `json:"summary,omitempty"`
 }
 
 // UserPermissions represents what a user can do for approvals
@@ -108,6 +123,9 @@ type UserPermissions struct {
 	CanRequestApproval map[string]bool   `json:"can_request_approval"` // stage -> bool
 	CanApprove         map[string]bool   `json:"can_approve"`          // stage -> bool
 	CanReject          map[string]bool   `json:"can_reject"`           // stage -> bool
+
+	// Active delegations to the user, whose rights are merged into the maps above
+	Delegations []ApprovalDelegation `json:"delegations,omitempty"`
 }
 
 // RequestApprovalRequest is the request body for requesting approval
@@ -118,7 +136,8 @@ type RequestApprovalRequest struct {
 
 // ApprovalDecisionRequest is the request body for approving/rejecting
 type ApprovalDecisionRequest struct {
-	Feedback string `json:"feedback,omitempty"`
+	Feedback   string `json:"feedback,omitempty"`
+	OnBehalfOf *int   `json:"on_behalf_of,omitempty"` // Delegator when voting as their delegate
 }
 
 // ApprovalResponse wraps approval data with additional context
@@ -0,0 +1,54 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package models
+
+import "time"
+
+// ApprovalDelegation hands a user's approve/reject rights to another user for
+// a period of time, optionally limited to some stages and workspaces
+type ApprovalDelegation struct {
+	ID            int        `json:"id"`
+	DelegatorID   int        `json:"delegator_id"`
+	DelegatorName string     `json:"delegator_name,omitempty"` // Joined from users table
+	DelegateID    int        `json:"delegate_id"`
+	DelegateName  string     `json:"delegate_name,omitempty"` // Joined from users table
+	Stages        []string   `json:"stages"`                  // Empty means every stage
+	WorkspaceIDs  []string   `json:"workspace_ids"`           // Empty means every workspace
+	StartsAt      time.Time  `json:"starts_at"`
+	EndsAt        time.Time  `json:"ends_at"`
+	Reason        string     `json:"reason,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy     *int       `json:"created_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// CoversStage reports whether the delegation includes a stage
+func (d *ApprovalDelegation) CoversStage(stage string) bool {
+	if len(d.Stages) == 0 {
+		return true
+	}
+	for _, s := range d.Stages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateApprovalDelegationRequest is the request body for creating a delegation
+type CreateApprovalDelegationRequest struct {
+	DelegatorID  int        `json:"delegator_id,omitempty"` // Defaults to the current user
+	DelegateID   int        `json:"delegate_id"`
+	Stages       []string   `json:"stages,omitempty"`
+	WorkspaceIDs []string   `json:"workspace_ids,omitempty"`
+	StartsAt     *time.Time `json:"starts_at,omitempty"` // Defaults to now
+	EndsAt       time.Time  `json:"ends_at"`
+	Reason       string     `json:"reason,omitempty"`
+}
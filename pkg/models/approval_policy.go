@@ -0,0 +1,122 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package models
+
+import "time"
+
+// Vote decisions
+const (
+	VoteApprove = "approve"
+	VoteReject  = "reject"
+)
+
+// AnyRole in ApprovalPolicy.VetoRoles makes every rejection a veto
+const AnyRole = "*"
+
+// ApprovalPolicy decides when a pending approval request is approved or
+// rejected. The most specific active policy for the capability's workspace
+// and the requested stage applies.
+type ApprovalPolicy struct {
+	ID                int       `json:"id"`
+	WorkspaceID       string    `json:"workspace_id"` // '' applies to every workspace
+	Stage             string    `json:"stage"`        // A workflow stage or 'all'
+	Name              string    `json:"name"`
+	RequiredApprovals int       `json:"required_approvals"`      // Approvals needed (N of M)
+	EligibleRoles     []string  `json:"eligible_roles"`          // Roles that may vote; empty means any role
+	ApprovalChain     []string  `json:"approval_chain"`          // Roles that must approve, in this order
+	VetoRoles         []string  `json:"veto_roles"`              // A rejection from one of these rejects the request
+	FallbackRole      string    `json:"fallback_role,omitempty"` // Set on escalation: may vote and stands in for the next chain role
+	IsActive          bool      `json:"is_active"`
+	CreatedAt         time.Time `json:"created_at,omitempty"`
+	UpdatedAt         time.Time `json:"updated_at,omitempty"`
+}
+
+// DefaultApprovalPolicy is used when no policy matches: the first approval
+// approves the request and the first rejection rejects it
+func DefaultApprovalPolicy() ApprovalPolicy {
+	return ApprovalPolicy{
+		Stage:             "all",
+		Name:              "Single approver",
+		RequiredApprovals: 1,
+		EligibleRoles:     []string{},
+		ApprovalChain:     []string{},
+		VetoRoles:         []string{AnyRole},
+		IsActive:          true,
+	}
+}
+
+// ApprovalVote is one approver's decision on an approval request. Votes are
+// stored in approval_audit_log with action 'vote'.
+type ApprovalVote struct {
+	UserID   int       `json:"user_id"`
+	UserName string    `json:"user_name,omitempty"`
+	Role     string    `json:"role"`
+	Decision string    `json:"decision"` // VoteApprove or VoteReject
+	Feedback string    `json:"feedback,omitempty"`
+	VotedAt  time.Time `json:"voted_at"`
+
+	// Set when a delegate cast the vote on behalf of UserID
+	DelegateID   *int   `json:"delegate_id,omitempty"`
+	DelegateName string `json:"delegate_name,omitempty"`
+}
+
+// ApprovalProgress is the outcome of evaluating the votes on a request
+// against its policy
+type ApprovalProgress struct {
+	Status            ApprovalStatus `json:"status"`
+	Approvals         int            `json:"approvals"`
+	Rejections        int            `json:"rejections"`
+	RequiredApprovals int            `json:"required_approvals"`
+	EligibleVoters    int            `json:"eligible_voters,omitempty"` // M, when the policy limits who may vote
+	ChainApproved     []string       `json:"chain_approved"`            // Chain roles that have approved, in order
+	NextRole          string         `json:"next_role,omitempty"`       // Next role in the approval chain
+	VetoedBy          string         `json:"vetoed_by,omitempty"`       // Role whose rejection was a veto
+	Reason            string         `json:"reason,omitempty"`
+}
+
+// ApprovalVotesResponse lists the votes on an approval request with the policy and progress
+type ApprovalVotesResponse struct {
+	ApprovalID int              `json:"approval_id"`
+	Policy     ApprovalPolicy   `json:"policy"`
+	Progress   ApprovalProgress `json:"progress"`
+	Votes      []ApprovalVote   `json:"votes"`
+}
+
+// SaveApprovalPolicyRequest creates or replaces the policy for a workspace and stage
+type SaveApprovalPolicyRequest struct {
+	WorkspaceID       string   `json:"workspace_id"`
+	Stage             string   `json:"stage"`
+	Name              string   `json:"name"`
+	RequiredApprovals int      `json:"required_approvals"`
+	EligibleRoles     []string `json:"eligible_roles"`
+	ApprovalChain     []string `json:"approval_chain"`
+	VetoRoles         []string `json:"veto_roles"`
+	IsActive          *bool    `json:"is_active,omitempty"` // Defaults to true
+}
+
+// Approval vote error codes
+const (
+	VoteErrorInvalidDecision = "invalid-decision"
+	VoteErrorAlreadyVoted    = "already-voted"
+	VoteErrorRoleNotEligible = "role-not-eligible"
+	VoteErrorOutOfOrder      = "out-of-chain-order"
+	VoteErrorNoDelegation    = "no-delegation" // on_behalf_of without an active delegation
+)
+
+// ApprovalVoteError is returned when a vote is not allowed by the approval policy
+type ApprovalVoteError struct {
+	Code     string `json:"error"`
+	Role     string `json:"role,omitempty"`
+	NextRole string `json:"next_role,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (e *ApprovalVoteError) Error() string {
+	return e.Message
+}
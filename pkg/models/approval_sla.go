@@ -0,0 +1,71 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package models
+
+import "time"
+
+// ApprovalSLA is the service level for pending approval requests of a stage.
+// The most specific active SLA for the capability's workspace and the
+// requested stage applies.
+type ApprovalSLA struct {
+	ID               int       `json:"id"`
+	WorkspaceID      string    `json:"workspace_id"` // '' applies to every workspace
+	Stage            string    `json:"stage"`        // A workflow stage or 'all'
+	DueHours         int       `json:"due_hours"`
+	RemindAfterHours *int      `json:"remind_after_hours,omitempty"`
+	EscalationRole   string    `json:"escalation_role,omitempty"` // Fallback approver role once overdue
+	IsActive         bool      `json:"is_active"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// SaveApprovalSLARequest creates or replaces the SLA for a workspace and stage
+type SaveApprovalSLARequest struct {
+	WorkspaceID      string `json:"workspace_id"`
+	Stage            string `json:"stage"`
+	DueHours         int    `json:"due_hours"`
+	RemindAfterHours *int   `json:"remind_after_hours,omitempty"`
+	EscalationRole   string `json:"escalation_role,omitempty"`
+	IsActive         *bool  `json:"is_active,omitempty"` // Defaults to true
+}
+
+// PendingApprovalAge is a pending capability approval request with its SLA state
+type PendingApprovalAge struct {
+	ApprovalID      int        `json:"approval_id"`
+	CapabilityID    int        `json:"capability_id"`
+	CapabilityName  string     `json:"capability_name"`
+	WorkspaceID     string     `json:"workspace_id,omitempty"`
+	Stage           string     `json:"stage"`
+	RequestedAt     time.Time  `json:"requested_at"`
+	AgeHours        float64    `json:"age_hours"`
+	RemindAt        *time.Time `json:"remind_at,omitempty"` // Nil without an SLA reminder
+	DueAt           *time.Time `json:"due_at,omitempty"`    // Nil without an SLA
+	Overdue         bool       `json:"overdue"`
+	RemindedAt      *time.Time `json:"reminded_at,omitempty"`
+	OverdueAt       *time.Time `json:"overdue_at,omitempty"` // When the scheduler flagged it
+	EscalationRole  string     `json:"escalation_role,omitempty"`
+	EscalatedToRole string     `json:"escalated_to_role,omitempty"`
+}
+
+// ApprovalAgeBucket groups pending approval requests by age
+type ApprovalAgeBucket struct {
+	Bucket    string               `json:"bucket"` // e.g. "1-3d"
+	Count     int                  `json:"count"`
+	Overdue   int                  `json:"overdue"`
+	Approvals []PendingApprovalAge `json:"approvals"`
+}
+
+// ApprovalAgingResponse lists pending approval requests by age bucket
+type ApprovalAgingResponse struct {
+	WorkspaceID  string              `json:"workspace_id,omitempty"`
+	GeneratedAt  time.Time           `json:"generated_at"`
+	TotalCount   int                 `json:"total_count"`
+	OverdueCount int                 `json:"overdue_count"`
+	Buckets      []ApprovalAgeBucket `json:"buckets"`
+}
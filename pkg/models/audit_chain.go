@@ -0,0 +1,65 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package models
+
+import "time"
+
+// Audit chain record sources
+const (
+	AuditSourceApprovalAuditLog   = "approval_audit_log"
+	AuditSourceEntityStateChanges = "entity_state_changes"
+)
+
+// AuditChainRecord is one link of a workspace's audit hash chain
+type AuditChainRecord struct {
+	Seq        int64     `json:"seq"`
+	Source     string    `json:"source"`    // AuditSourceApprovalAuditLog or AuditSourceEntityStateChanges
+	SourceID   int       `json:"source_id"` // Row id in Source
+	Content    string    `json:"content"`   // Canonical text of the row, as hashed
+	PrevHash   string    `json:"prev_hash"`
+	Hash       string    `json:"hash"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// AuditChainBreak is the first point where an audit chain fails verification
+type AuditChainBreak struct {
+	Seq              int64      `json:"seq"`
+	Source           string     `json:"source,omitempty"`
+	SourceID         int        `json:"source_id,omitempty"`
+	Kind             string     `json:"kind"` // See the auditchain Break* constants
+	Message          string     `json:"message"`
+	ExpectedPrevHash string     `json:"expected_prev_hash"`
+	RecordedAt       *time.Time `json:"recorded_at,omitempty"`
+}
+
+// AuditChainVerification is the result of walking a workspace's audit chain
+type AuditChainVerification struct {
+	WorkspaceID string           `json:"workspace_id"`
+	Verified    bool             `json:"verified"`
+	RecordCount int              `json:"record_count"`
+	HeadSeq     int64            `json:"head_seq"`
+	HeadHash    string           `json:"head_hash"`
+	FirstBreak  *AuditChainBreak `json:"first_break,omitempty"`
+	VerifiedAt  time.Time        `json:"verified_at"`
+}
+
+// AuditExport is a signed extract of a workspace's audit chain for a date range
+type AuditExport struct {
+	WorkspaceID   string             `json:"workspace_id"`
+	From          time.Time          `json:"from"`
+	To            time.Time          `json:"to"`
+	GeneratedAt   time.Time          `json:"generated_at"`
+	RecordCount   int                `json:"record_count"`
+	ChainVerified bool               `json:"chain_verified"` // The records link up from the first one's prev_hash
+	FirstBreak    *AuditChainBreak   `json:"first_break,omitempty"`
+	Records       []AuditChainRecord `json:"records"`
+	Algorithm     string             `json:"algorithm"`
+	PublicKey     string             `json:"public_key"`
+	Signature     string             `json:"signature"` // Over the export with an empty signature
+}
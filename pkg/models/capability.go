@@ -49,6 +49,16 @@ type CapabilityDependency struct {
 	CreatedAt      time.Time `json:"created_at"`
 }
 
+// DependencyEdge is a dependency between two capabilities or enablers,
+// resolved to their CAP-/ENB- identifiers: From depends on To
+type DependencyEdge struct {
+	FromID   string `json:"from_id"`
+	FromName string `json:"from_name"`
+	ToID     string `json:"to_id"`
+	ToName   string `json:"to_name"`
+	Kind     string `json:"kind"` // 'depends_on' or 'implemented_by'
+}
+
 // CapabilityAsset represents an asset associated with a capability
 type CapabilityAsset struct {
 	ID           int       `json:"id"`
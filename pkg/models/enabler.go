@@ -84,9 +84,48 @@ type EnablerApproval struct {
 	Feedback      *string    `json:"feedback,omitempty"`
 	CreatedAt     time.Time  `json:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at"`
+	// Set when a single requirement of the enabler was submitted
+	RequirementID *int `json:"requirement_id,omitempty"`
 	// Resolved names for display
 	RequesterName string `json:"requester_name,omitempty"`
 	DeciderName   string `json:"decider_name,omitempty"`
+	// Approval policy evaluation, returned after a vote
+	Progress *ApprovalProgress `json:"progress,omitempty"`
+}
+
+// RequestEnablerApprovalRequest is the request body for submitting an enabler,
+// or one of its requirements, for approval
+type RequestEnablerApprovalRequest struct {
+	EnablerID     int    `json:"enabler_id"`
+	RequirementID *int   `json:"requirement_id,omitempty"`
+	Stage         string `json:"stage"`
+}
+
+// EnablerApprovalResponse wraps an enabler approval with additional context
+type EnablerApprovalResponse struct {
+	Approval        *EnablerApproval `json:"approval"`
+	EnablerName     string           `json:"enabler_name"`
+	CapabilityName  string           `json:"capability_name,omitempty"`
+	RequirementName string           `json:"requirement_name,omitempty"`
+	CanApprove      bool             `json:"can_approve"`
+	CanReject       bool             `json:"can_reject"`
+	CanWithdraw     bool             `json:"can_withdraw"`
+}
+
+// PendingEnablerApprovalsResponse lists pending enabler approvals with counts
+type PendingEnablerApprovalsResponse struct {
+	Approvals  []EnablerApprovalResponse `json:"approvals"`
+	TotalCount int                       `json:"total_count"`
+	ByStage    map[string]int            `json:"by_stage"` // stage -> count
+}
+
+// EnablerApprovalHistoryResponse wraps approval history for an enabler
+type EnablerApprovalHistoryResponse struct {
+	EnablerID      int                `json:"enabler_id"`
+	EnablerName    string             `json:"enabler_name"`
+	ApprovalStatus string             `json:"approval_status"`
+	Approvals      []EnablerApproval  `json:"approvals"`
+	AuditLog       []ApprovalAuditLog `json:"audit_log"`
 }
 
 // CreateEnablerRequest represents the request to create a new enabler
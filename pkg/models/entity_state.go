@@ -8,7 +8,10 @@
 
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // EntityType represents the type of entity for polymorphic state management
 // Note: This is a typed version; acceptance_criteria.go has untyped string constants
@@ -214,3 +217,65 @@ type OptimisticLockError struct {
 func (e *OptimisticLockError) Error() string {
 	return "concurrent update detected: entity was modified by another user"
 }
+
+// StateTransitionRejected is the error code of a StateTransitionError
+const StateTransitionRejected = "state_transition_rejected"
+
+// StateTransitionError is returned when a state update breaks a rule of the
+// INTENT state machine (see pkg/statemodel)
+type StateTransitionError struct {
+	Code       string   `json:"error"` // Always StateTransitionRejected
+	EntityType string   `json:"entity_type"`
+	EntityID   string   `json:"entity_id"`
+	Rule       string   `json:"rule"`
+	Field      string   `json:"field,omitempty"`
+	From       string   `json:"from,omitempty"`
+	To         string   `json:"to,omitempty"`
+	Allowed    []string `json:"allowed,omitempty"`
+	Message    string   `json:"message"`
+}
+
+func (e *StateTransitionError) Error() string {
+	return e.Message
+}
+
+// IDConflictError is returned when renaming to an ID that is already in use
+type IDConflictError struct {
+	ID    string `json:"id"`
+	Where string `json:"where"` // File or table that already uses the ID
+}
+
+func (e *IDConflictError) Error() string {
+	return "ID " + e.ID + " is already in use by " + e.Where
+}
+
+// IDRenameRow is a database row changed (or, in a dry run, that would change) by an ID rename
+type IDRenameRow struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	RowID  int    `json:"row_id"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// ChangeEventType identifies what a ChangeEvent reports
+type ChangeEventType string
+
+const (
+	ChangeEventEntityState    ChangeEventType = "entity_state_change" // Payload is an EntityStateChange
+	ChangeEventPhaseApproval  ChangeEventType = "phase_approval"      // Payload is a PhaseApproval
+	ChangeEventStoryCardMoved ChangeEventType = "story_card_moved"    // Payload holds card_id, position_x/y and old_position_x/y
+)
+
+// ChangeEvent is one entry of a workspace's change feed. Events are written
+// by database triggers (migration 005) that keep IDs in commit order within
+// a workspace, so the last ID seen is enough to resume a stream.
+type ChangeEvent struct {
+	ID          int64           `json:"id"`
+	WorkspaceID string          `json:"workspace_id"`
+	EventType   ChangeEventType `json:"event_type"`
+	EntityType  string          `json:"entity_type,omitempty"`
+	EntityID    string          `json:"entity_id,omitempty"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
@@ -0,0 +1,47 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package models
+
+// Outcomes of exporting one enabler to GitHub
+const (
+	GitHubExportCreated   = "created"   // A new issue was opened and recorded in the spec
+	GitHubExportUpdated   = "updated"   // The issue's title, body or state was changed
+	GitHubExportUnchanged = "unchanged" // The issue already matched the enabler
+	GitHubExportError     = "error"
+)
+
+// GitHubExportRequest is the request to publish a workspace's enablers as
+// GitHub issues
+type GitHubExportRequest struct {
+	WorkspacePath   string   `json:"workspace_path"`
+	IntegrationName string   `json:"integration_name,omitempty"` // Stored integration to use; defaults to "GitHub"
+	Repository      string   `json:"repository"`                 // owner/repo that new issues are opened in
+	ProjectID       string   `json:"project_id,omitempty"`       // Node ID of a Projects (v2) board to add the issues to
+	EnablerIDs      []string `json:"enabler_ids,omitempty"`      // Only these enablers; all when empty
+}
+
+// GitHubExportResult is the outcome of exporting one enabler
+type GitHubExportResult struct {
+	EnablerID string `json:"enabler_id"`
+	File      string `json:"file"`
+	Issue     string `json:"issue,omitempty"` // owner/repo#number
+	URL       string `json:"url,omitempty"`
+	State     string `json:"state,omitempty"` // open or closed
+	Result    string `json:"result"`
+	Error     string `json:"error,omitempty"`
+}
+
+// GitHubExportResponse reports the outcome of an export
+type GitHubExportResponse struct {
+	Repository string               `json:"repository"`
+	Results    []GitHubExportResult `json:"results"`
+	Created    int                  `json:"created"`
+	Updated    int                  `json:"updated"`
+	Errors     int                  `json:"errors"`
+}
@@ -48,3 +48,19 @@ type IntegrationWithFields struct {
 	Integration
 	Fields map[string]string `json:"fields"`
 }
+
+// IntegrationSummary describes a stored integration without its secret values
+type IntegrationSummary struct {
+	IntegrationName string    `json:"integration_name"`
+	ProviderURL     string    `json:"provider_url"`
+	FieldNames      []string  `json:"field_names"`
+	Encrypted       bool      `json:"encrypted"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// VaultRotationResult counts the integrations touched by a master key rotation
+type VaultRotationResult struct {
+	Rewrapped int `json:"rewrapped"` // Data keys re-wrapped with the current master key
+	Encrypted int `json:"encrypted"` // Plain-text integrations encrypted for the first time
+	Unchanged int `json:"unchanged"` // Integrations already using the current master key
+}
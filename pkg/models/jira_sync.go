@@ -0,0 +1,101 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package models
+
+import "time"
+
+// Outcomes of syncing one Jira link
+const (
+	JiraSyncResultBaseline  = "baseline"  // First sync; both sides recorded, nothing changed
+	JiraSyncResultUnchanged = "unchanged" // Nothing to apply to either side
+	JiraSyncResultSynced    = "synced"    // Changes were applied to one or both sides
+	JiraSyncResultConflict  = "conflict"  // Both sides changed the same field; nothing was applied
+	JiraSyncResultError     = "error"
+)
+
+// Sides a conflict can be resolved in favour of
+const (
+	JiraSyncPreferIntentR = "intentr"
+	JiraSyncPreferJira    = "jira"
+)
+
+// JiraSyncLink links a capability or enabler to a Jira issue. The Last*
+// fields are both sides as of the last sync, which tells which side changed
+// since; empty means that side has not been recorded yet.
+type JiraSyncLink struct {
+	ID          int    `json:"id"`
+	WorkspaceID string `json:"workspace_id"`
+	EntityType  string `json:"entity_type"` // capability or enabler
+	EntityID    string `json:"entity_id"`
+	IssueKey    string `json:"issue_key"`
+
+	// Jira side
+	LastSummary    string `json:"last_summary,omitempty"`
+	LastJiraStatus string `json:"last_jira_status,omitempty"`
+
+	// IntentR side
+	LastName           string `json:"last_name,omitempty"`
+	LastLifecycleState string `json:"last_lifecycle_state,omitempty"`
+	LastWorkflowStage  string `json:"last_workflow_stage,omitempty"`
+	LastStageStatus    string `json:"last_stage_status,omitempty"`
+	LastApprovalStatus string `json:"last_approval_status,omitempty"`
+
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	LastResult   string     `json:"last_result,omitempty"` // One of the JiraSync outcomes
+	LastMessage  string     `json:"last_message,omitempty"`
+	CreatedBy    *int       `json:"created_by,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// CreateJiraSyncLinkRequest links a capability or enabler to a Jira issue
+type CreateJiraSyncLinkRequest struct {
+	WorkspaceID string `json:"workspace_id"`
+	EntityType  string `json:"entity_type"`
+	EntityID    string `json:"entity_id"`
+	IssueKey    string `json:"issue_key"`
+}
+
+// JiraSyncRequest syncs every link of a workspace
+type JiraSyncRequest struct {
+	WorkspaceID     string `json:"workspace_id"`
+	IntegrationName string `json:"integration_name,omitempty"` // Stored Jira credentials; defaults to "Jira"
+	// Resolve maps entity IDs in conflict to the side that wins
+	// (JiraSyncPreferIntentR or JiraSyncPreferJira)
+	Resolve map[string]string `json:"resolve,omitempty"`
+}
+
+// JiraSyncConflict is a field both sides changed since the last sync
+type JiraSyncConflict struct {
+	Field   string `json:"field"`
+	Last    string `json:"last"`
+	IntentR string `json:"intentr"`
+	Jira    string `json:"jira"`
+}
+
+// JiraSyncResult is the outcome of syncing one link
+type JiraSyncResult struct {
+	LinkID     int                `json:"link_id"`
+	EntityType string             `json:"entity_type"`
+	EntityID   string             `json:"entity_id"`
+	IssueKey   string             `json:"issue_key"`
+	Result     string             `json:"result"`
+	Pulled     []string           `json:"pulled,omitempty"` // Changes applied to IntentR
+	Pushed     []string           `json:"pushed,omitempty"` // Changes applied to Jira
+	Conflicts  []JiraSyncConflict `json:"conflicts,omitempty"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// JiraSyncResponse is the outcome of syncing a workspace
+type JiraSyncResponse struct {
+	WorkspaceID string           `json:"workspace_id"`
+	Results     []JiraSyncResult `json:"results"`
+	Synced      int              `json:"synced"`
+	Conflicts   int              `json:"conflicts"`
+	Errors      int              `json:"errors"`
+}
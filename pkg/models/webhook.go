@@ -0,0 +1,146 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook event types
+const (
+	WebhookEventApprovalApproved  = "approval.approved"
+	WebhookEventApprovalRejected  = "approval.rejected"
+	WebhookEventApprovalWithdrawn = "approval.withdrawn"
+	WebhookEventApprovalReminder  = "approval.reminder"  // Sent by the SLA scheduler
+	WebhookEventApprovalEscalated = "approval.escalated" // Sent by the SLA scheduler when a request becomes overdue
+	WebhookEventStateUpdated      = "state.updated"
+	WebhookEventStageChanged      = "state.stage_changed" // Sent alongside state.updated when workflow_stage changes
+	WebhookEventPhaseApproved     = "phase.approved"
+	WebhookEventPhaseRevoked      = "phase.revoked"
+	WebhookEventPing              = "ping"
+)
+
+// WebhookEventTypes lists the event types a subscription can filter on
+var WebhookEventTypes = []string{
+	WebhookEventApprovalApproved,
+	WebhookEventApprovalRejected,
+	WebhookEventApprovalWithdrawn,
+	WebhookEventApprovalReminder,
+	WebhookEventApprovalEscalated,
+	WebhookEventStateUpdated,
+	WebhookEventStageChanged,
+	WebhookEventPhaseApproved,
+	WebhookEventPhaseRevoked,
+}
+
+// Webhook payload formats
+const (
+	WebhookFormatJSON  = "json"  // The WebhookEvent envelope
+	WebhookFormatSlack = "slack" // {"text": summary} for Slack incoming webhooks
+	WebhookFormatTeams = "teams" // {"text": summary} for Teams incoming webhooks
+)
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySending   = "sending"
+	WebhookDeliveryRetrying  = "retrying"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookSubscription is an outbound webhook endpoint of a workspace
+type WebhookSubscription struct {
+	ID          int       `json:"id"`
+	WorkspaceID string    `json:"workspace_id"`
+	Name        string    `json:"name"`
+	URL         string    `json:"url"`
+	Secret      string    `json:"secret,omitempty"` // Only returned when created
+	Events      []string  `json:"events"`           // Empty means every event
+	Format      string    `json:"format"`
+	IsActive    bool      `json:"is_active"`
+	CreatedBy   *int      `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Wants reports whether the subscription receives eventType
+func (s *WebhookSubscription) Wants(eventType string) bool {
+	if len(s.Events) == 0 || eventType == WebhookEventPing {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == eventType || e == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEvent is the JSON envelope sent to subscribers
+type WebhookEvent struct {
+	ID          string          `json:"id"` // Stable across retries and replays, for de-duplication
+	Type        string          `json:"type"`
+	WorkspaceID string          `json:"workspace_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Summary     string          `json:"summary"` // One line for chat integrations
+	Data        json.RawMessage `json:"data"`
+}
+
+// WebhookDelivery is one attempt to send an event to a subscription, and its log
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int             `json:"subscription_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty"`
+	ResponseStatus *int            `json:"response_status,omitempty"`
+	ResponseBody   string          `json:"response_body,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	DurationMs     *int            `json:"duration_ms,omitempty"`
+	ReplayOf       *int64          `json:"replay_of,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+
+	// Subscription is loaded when a delivery is claimed for sending
+	Subscription *WebhookSubscription `json:"-"`
+}
+
+// WebhookAttempt is the outcome of sending a delivery once
+type WebhookAttempt struct {
+	Status         string // WebhookDeliverySucceeded, WebhookDeliveryRetrying or WebhookDeliveryFailed
+	ResponseStatus int    // 0 when no response was received
+	ResponseBody   string
+	Error          string
+	Duration       time.Duration
+	RetryIn        time.Duration // Delay before the next attempt when Status is WebhookDeliveryRetrying
+}
+
+// CreateWebhookRequest is the request body for creating a subscription
+type CreateWebhookRequest struct {
+	WorkspaceID string   `json:"workspace_id"`
+	Name        string   `json:"name"`
+	URL         string   `json:"url"`
+	Secret      string   `json:"secret,omitempty"` // Generated when empty
+	Events      []string `json:"events,omitempty"`
+	Format      string   `json:"format,omitempty"`
+}
+
+// UpdateWebhookRequest is the request body for updating a subscription
+type UpdateWebhookRequest struct {
+	Name     *string   `json:"name,omitempty"`
+	URL      *string   `json:"url,omitempty"`
+	Events   *[]string `json:"events,omitempty"`
+	Format   *string   `json:"format,omitempty"`
+	IsActive *bool     `json:"is_active,omitempty"`
+}
@@ -0,0 +1,58 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package models
+
+import "fmt"
+
+// WorkspaceRole is a user's role in one workspace. Each role includes the
+// rights of the roles before it.
+type WorkspaceRole string
+
+const (
+	WorkspaceRoleViewer      WorkspaceRole = "viewer"      // Read specifications and files
+	WorkspaceRoleContributor WorkspaceRole = "contributor" // Edit them and request approvals
+	WorkspaceRoleApprover    WorkspaceRole = "approver"    // Approve and reject requests
+	WorkspaceRoleOwner       WorkspaceRole = "owner"       // Manage members
+)
+
+var workspaceRoleRanks = map[WorkspaceRole]int{
+	WorkspaceRoleViewer:      1,
+	WorkspaceRoleContributor: 2,
+	WorkspaceRoleApprover:    3,
+	WorkspaceRoleOwner:       4,
+}
+
+// IsValidWorkspaceRole checks if a role is a valid workspace role
+func IsValidWorkspaceRole(role string) bool {
+	_, ok := workspaceRoleRanks[WorkspaceRole(role)]
+	return ok
+}
+
+// Allows reports whether the role includes the rights of another role. The
+// empty role (no access) allows nothing.
+func (r WorkspaceRole) Allows(required WorkspaceRole) bool {
+	rank, ok := workspaceRoleRanks[r]
+	return ok && rank >= workspaceRoleRanks[required]
+}
+
+// WorkspaceAccessError is returned when a user's workspace role does not
+// allow an action
+type WorkspaceAccessError struct {
+	WorkspaceID  string        `json:"workspace_id"`
+	UserID       int           `json:"user_id"`
+	Role         WorkspaceRole `json:"role,omitempty"` // Empty without access
+	RequiredRole WorkspaceRole `json:"required_role"`
+}
+
+func (e *WorkspaceAccessError) Error() string {
+	if e.Role == "" {
+		return fmt.Sprintf("user %d has no access to workspace %s", e.UserID, e.WorkspaceID)
+	}
+	return fmt.Sprintf("user %d is %s in workspace %s, %s required", e.UserID, e.Role, e.WorkspaceID, e.RequiredRole)
+}
@@ -0,0 +1,280 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/lib/pq"
+)
+
+const approvalDelegationColumns = `d.id, d.delegator_id, COALESCE(u1.name, ''), d.delegate_id, COALESCE(u2.name, ''),
+	d.stages, d.workspace_ids, d.starts_at, d.ends_at, COALESCE(d.reason, ''), d.revoked_at, d.created_by,
+	d.created_at, d.updated_at`
+
+const approvalDelegationJoins = `
+	LEFT JOIN users u1 ON d.delegator_id = u1.id
+	LEFT JOIN users u2 ON d.delegate_id = u2.id`
+
+func scanApprovalDelegation(row rowScanner) (*models.ApprovalDelegation, error) {
+	var d models.ApprovalDelegation
+	var stages, workspaceIDs pq.StringArray
+	err := row.Scan(
+		&d.ID, &d.DelegatorID, &d.DelegatorName, &d.DelegateID, &d.DelegateName,
+		&stages, &workspaceIDs, &d.StartsAt, &d.EndsAt, &d.Reason, &d.RevokedAt, &d.CreatedBy,
+		&d.CreatedAt, &d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	d.Stages, d.WorkspaceIDs = []string(stages), []string(workspaceIDs)
+	return &d, nil
+}
+
+// ListDelegations returns the delegations given or received by a user, newest
+// first. A userID of 0 lists every user's delegations; activeOnly leaves out
+// revoked and expired ones.
+func (r *ApprovalRepository) ListDelegations(userID int, activeOnly bool) ([]models.ApprovalDelegation, error) {
+	query := `SELECT ` + approvalDelegationColumns + ` FROM approval_delegations d` + approvalDelegationJoins + ` WHERE true`
+	var args []interface{}
+	if userID != 0 {
+		args = append(args, userID)
+		query += ` AND (d.delegator_id = $1 OR d.delegate_id = $1)`
+	}
+	if activeOnly {
+		query += ` AND d.revoked_at IS NULL AND d.ends_at > CURRENT_TIMESTAMP`
+	}
+	query += ` ORDER BY d.starts_at DESC, d.id DESC`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query approval delegations: %w", err)
+	}
+	defer rows.Close()
+
+	delegations := []models.ApprovalDelegation{}
+	for rows.Next() {
+		d, err := scanApprovalDelegation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan approval delegation: %w", err)
+		}
+		delegations = append(delegations, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read approval delegations: %w", err)
+	}
+	return delegations, nil
+}
+
+// CreateDelegation hands the delegator's approve/reject rights to the delegate
+func (r *ApprovalRepository) CreateDelegation(req models.CreateApprovalDelegationRequest, userID int) (*models.ApprovalDelegation, error) {
+	startsAt := time.Now()
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO approval_delegations (delegator_id, delegate_id, stages, workspace_ids, starts_at, ends_at, reason, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
+		RETURNING id
+	`, req.DelegatorID, req.DelegateID, pq.Array(cleanRoles(req.Stages)), pq.Array(cleanRoles(req.WorkspaceIDs)),
+		startsAt, req.EndsAt, req.Reason, userID).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create approval delegation: %w", err)
+	}
+	return r.GetDelegation(id)
+}
+
+// GetDelegation returns a delegation by its ID
+func (r *ApprovalRepository) GetDelegation(id int) (*models.ApprovalDelegation, error) {
+	d, err := scanApprovalDelegation(r.db.QueryRow(`
+		SELECT `+approvalDelegationColumns+` FROM approval_delegations d`+approvalDelegationJoins+`
+		WHERE d.id = $1
+	`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval delegation: %w", err)
+	}
+	return d, nil
+}
+
+// RevokeDelegation ends a delegation early. Votes already cast by the
+// delegate are kept.
+func (r *ApprovalRepository) RevokeDelegation(id int) (*models.ApprovalDelegation, error) {
+	result, err := r.db.Exec(`
+		UPDATE approval_delegations SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND revoked_at IS NULL
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke approval delegation: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("approval delegation not found or already revoked")
+	}
+	return r.GetDelegation(id)
+}
+
+// AddDelegatedPermissions merges the rights delegated to a user into their
+// role-based permissions and lists the delegations in effect
+func (r *ApprovalRepository) AddDelegatedPermissions(permissions *models.UserPermissions, userID int) error {
+	rows, err := r.db.Query(`
+		SELECT `+approvalDelegationColumns+`, u1.role
+		FROM approval_delegations d`+approvalDelegationJoins+`
+		WHERE d.delegate_id = $1 AND d.revoked_at IS NULL
+		  AND d.starts_at <= CURRENT_TIMESTAMP AND d.ends_at > CURRENT_TIMESTAMP
+		ORDER BY d.ends_at
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to query approval delegations: %w", err)
+	}
+	defer rows.Close()
+
+	var delegations []models.ApprovalDelegation
+	var roles []string
+	for rows.Next() {
+		var d models.ApprovalDelegation
+		var stages, workspaceIDs pq.StringArray
+		var role string
+		err := rows.Scan(
+			&d.ID, &d.DelegatorID, &d.DelegatorName, &d.DelegateID, &d.DelegateName,
+			&stages, &workspaceIDs, &d.StartsAt, &d.EndsAt, &d.Reason, &d.RevokedAt, &d.CreatedBy,
+			&d.CreatedAt, &d.UpdatedAt, &role,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan approval delegation: %w", err)
+		}
+		d.Stages, d.WorkspaceIDs = []string(stages), []string(workspaceIDs)
+		delegations = append(delegations, d)
+		roles = append(roles, role)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read approval delegations: %w", err)
+	}
+
+	for i, d := range delegations {
+		delegated, err := r.GetUserPermissions(roles[i])
+		if err != nil {
+			return err
+		}
+		for _, stage := range models.ValidStages() {
+			s := string(stage)
+			if !d.CoversStage(s) {
+				continue
+			}
+			permissions.CanApprove[s] = permissions.CanApprove[s] || delegated.CanApprove[s]
+			permissions.CanReject[s] = permissions.CanReject[s] || delegated.CanReject[s]
+		}
+	}
+	permissions.UserID = userID
+	permissions.Delegations = delegations
+	return nil
+}
+
+// approvalVoter is who a vote counts for. When a delegate votes on behalf of
+// a delegator, the vote is the delegator's and carries the delegator's role.
+type approvalVoter struct {
+	UserID       int // Whose vote it is
+	Role         string
+	ActorID      int  // Who cast it
+	DelegationID *int // Set when ActorID is a delegate of UserID
+}
+
+// onBehalfOf returns the delegator for approval_audit_log.on_behalf_of
+func (v approvalVoter) onBehalfOf() *int {
+	if v.DelegationID == nil {
+		return nil
+	}
+	id := v.UserID
+	return &id
+}
+
+// resolveVoter checks that userID may vote on behalf of onBehalfOf for a stage
+// and workspace now. Without onBehalfOf the user votes for themselves. The
+// voter must be an approver in the workspace; a delegate votes with the
+// delegator's workspace role but must at least be able to view the workspace.
+func resolveVoter(q querier, userID int, onBehalfOf *int, stage, workspaceID string, now time.Time) (approvalVoter, error) {
+	voter := approvalVoter{UserID: userID, ActorID: userID}
+	if onBehalfOf == nil || *onBehalfOf == userID {
+		if err := q.QueryRow("SELECT role FROM users WHERE id = $1", userID).Scan(&voter.Role); err != nil {
+			return voter, fmt.Errorf("failed to get approver role: %w", err)
+		}
+		return voter, requireWorkspaceRole(q, workspaceID, userID, models.WorkspaceRoleApprover)
+	}
+
+	var delegationID int
+	err := q.QueryRow(`
+		SELECT d.id, u.role
+		FROM approval_delegations d
+		JOIN users u ON u.id = d.delegator_id
+		WHERE d.delegator_id = $1 AND d.delegate_id = $2 AND d.revoked_at IS NULL
+		  AND d.starts_at <= $3 AND d.ends_at > $3
+		  AND (cardinality(d.stages) = 0 OR $4 = ANY(d.stages))
+		  AND (cardinality(d.workspace_ids) = 0 OR $5 = ANY(d.workspace_ids))
+		ORDER BY d.ends_at DESC
+		LIMIT 1
+	`, *onBehalfOf, userID, now, stage, workspaceID).Scan(&delegationID, &voter.Role)
+	if err == sql.ErrNoRows {
+		return voter, &models.ApprovalVoteError{
+			Code:    models.VoteErrorNoDelegation,
+			Message: fmt.Sprintf("user %d has no active delegation from user %d for %s approvals", userID, *onBehalfOf, stage),
+		}
+	}
+	if err != nil {
+		return voter, fmt.Errorf("failed to check approval delegation: %w", err)
+	}
+	voter.UserID, voter.DelegationID = *onBehalfOf, &delegationID
+	if err := requireWorkspaceRole(q, workspaceID, voter.UserID, models.WorkspaceRoleApprover); err != nil {
+		return voter, err
+	}
+	return voter, requireWorkspaceRole(q, workspaceID, userID, models.WorkspaceRoleViewer)
+}
+
+// describeAuditEntry sets the human-readable summary of an audit log entry,
+// e.g. "approved by Alice on behalf of Bob"
+func describeAuditEntry(l *models.ApprovalAuditLog) {
+	verb := l.Action
+	switch l.Action {
+	case "vote":
+		var details struct {
+			Decision string `json:"decision"`
+		}
+		json.Unmarshal(l.Details, &details)
+		verb = "approved"
+		if details.Decision == models.VoteReject {
+			verb = "rejected"
+		}
+	case "approved", "rejected":
+		verb = "request " + l.Action
+	case "reminder":
+		verb = "reminder sent"
+	case "overdue":
+		verb = "flagged overdue"
+	}
+
+	by := l.PerformerName
+	switch {
+	case l.PerformedBy == 0:
+		by = "the SLA scheduler"
+	case by == "":
+		by = fmt.Sprintf("user %d", l.PerformedBy)
+	}
+	l.Summary = verb + " by " + by
+	if l.OnBehalfOf != nil {
+		name := l.OnBehalfOfName
+		if name == "" {
+			name = fmt.Sprintf("user %d", *l.OnBehalfOf)
+		}
+		l.Summary += " on behalf of " + name
+	}
+}
@@ -0,0 +1,144 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jareynolds/intentr/internal/testdb"
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+func TestResolveVoter(t *testing.T) {
+	f := newApprovalFixture(t)
+	now := time.Now()
+	stage := string(models.StageSpecification)
+
+	// Each delegate holds one delegation from the approver
+	tests := []struct {
+		name    string
+		req     models.CreateApprovalDelegationRequest
+		revoke  bool
+		wantErr bool
+	}{
+		{"active", models.CreateApprovalDelegationRequest{Stages: []string{stage}, WorkspaceIDs: []string{"workspace-a"}, EndsAt: now.Add(time.Hour)}, false, false},
+		{"expired", models.CreateApprovalDelegationRequest{StartsAt: timePtr(now.Add(-2 * time.Hour)), EndsAt: now.Add(-time.Hour)}, false, true},
+		{"revoked", models.CreateApprovalDelegationRequest{EndsAt: now.Add(time.Hour)}, true, true},
+		{"other stage", models.CreateApprovalDelegationRequest{Stages: []string{string(models.StageDesign)}, EndsAt: now.Add(time.Hour)}, false, true},
+		{"other workspace", models.CreateApprovalDelegationRequest{WorkspaceIDs: []string{"workspace-b"}, EndsAt: now.Add(time.Hour)}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delegate := testdb.CreateUser(t, f.db, "delegate-"+tt.name, "user")
+			f.addMember(t, delegate, models.WorkspaceRoleViewer)
+			tt.req.DelegatorID, tt.req.DelegateID = f.approver, delegate
+			d, err := f.repo.CreateDelegation(tt.req, f.approver)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.revoke {
+				if _, err := f.repo.RevokeDelegation(d.ID); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			voter, err := resolveVoter(f.db, delegate, &f.approver, stage, "workspace-a", now)
+			if tt.wantErr {
+				var voteErr *models.ApprovalVoteError
+				if !errors.As(err, &voteErr) || voteErr.Code != models.VoteErrorNoDelegation {
+					t.Errorf("resolveVoter() error = %v, want %s", err, models.VoteErrorNoDelegation)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveVoter() error = %v", err)
+			}
+			if voter.UserID != f.approver || voter.ActorID != delegate || voter.DelegationID == nil || *voter.DelegationID != d.ID {
+				t.Errorf("voter = %+v, want the approver's vote cast by the delegate", voter)
+			}
+		})
+	}
+}
+
+func TestDelegatedVoteIsAudited(t *testing.T) {
+	f := newApprovalFixture(t)
+	delegate := testdb.CreateUser(t, f.db, "delegate", "user")
+	f.addMember(t, delegate, models.WorkspaceRoleViewer)
+	_, err := f.repo.CreateDelegation(models.CreateApprovalDelegationRequest{
+		DelegatorID: f.approver, DelegateID: delegate, EndsAt: time.Now().Add(time.Hour),
+	}, f.approver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	approval, err := f.repo.RequestEnablerApproval(f.enabler, nil, string(models.StageSpecification), f.contributor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.repo.ApproveEnabler(approval.ID, delegate, &f.approver, ""); err != nil {
+		t.Fatalf("ApproveEnabler(on behalf) error = %v", err)
+	}
+
+	logs, err := f.repo.GetEnablerAuditLog(f.enabler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, l := range logs {
+		if l.Action == "vote" {
+			found = true
+			if l.Summary != "approved by delegate on behalf of approver" {
+				t.Errorf("vote summary = %q", l.Summary)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("no vote in audit log %+v", logs)
+	}
+}
+
+func TestAddDelegatedPermissions(t *testing.T) {
+	f := newApprovalFixture(t)
+	productOwner := testdb.CreateUser(t, f.db, "product-owner", "product_owner")
+	delegate := testdb.CreateUser(t, f.db, "delegate", "user")
+	now := time.Now()
+
+	for _, req := range []models.CreateApprovalDelegationRequest{
+		{Stages: []string{string(models.StageDesign)}, EndsAt: now.Add(time.Hour)},
+		{Stages: []string{string(models.StageExecution)}, StartsAt: timePtr(now.Add(-2 * time.Hour)), EndsAt: now.Add(-time.Hour)},
+	} {
+		req.DelegatorID, req.DelegateID = productOwner, delegate
+		if _, err := f.repo.CreateDelegation(req, productOwner); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	permissions, err := f.repo.GetUserPermissions("user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.repo.AddDelegatedPermissions(permissions, delegate); err != nil {
+		t.Fatal(err)
+	}
+	if !permissions.CanApprove[string(models.StageDesign)] || !permissions.CanReject[string(models.StageDesign)] {
+		t.Errorf("delegated design rights missing: %+v", permissions)
+	}
+	if permissions.CanApprove[string(models.StageExecution)] || permissions.CanApprove[string(models.StageSpecification)] {
+		t.Errorf("rights granted beyond the active delegation: %+v", permissions)
+	}
+	if len(permissions.Delegations) != 1 {
+		t.Errorf("delegations = %+v, want only the active one", permissions.Delegations)
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
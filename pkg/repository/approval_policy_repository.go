@@ -0,0 +1,323 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jareynolds/intentr/pkg/approvalpolicy"
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/lib/pq"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx
+type querier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+const approvalPolicyColumns = `id, workspace_id, stage, name, required_approvals, eligible_roles,
+	approval_chain, veto_roles, is_active, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanApprovalPolicy(row rowScanner) (*models.ApprovalPolicy, error) {
+	var p models.ApprovalPolicy
+	var eligible, chain, veto pq.StringArray
+	err := row.Scan(
+		&p.ID, &p.WorkspaceID, &p.Stage, &p.Name, &p.RequiredApprovals, &eligible,
+		&chain, &veto, &p.IsActive, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	p.EligibleRoles, p.ApprovalChain, p.VetoRoles = []string(eligible), []string(chain), []string(veto)
+	return &p, nil
+}
+
+// ListPolicies returns the approval policies for a workspace, including the
+// ones that apply to every workspace. An empty workspaceID lists all policies.
+func (r *ApprovalRepository) ListPolicies(workspaceID string) ([]models.ApprovalPolicy, error) {
+	query := `SELECT ` + approvalPolicyColumns + ` FROM approval_policies`
+	var args []interface{}
+	if workspaceID != "" {
+		query += ` WHERE workspace_id IN ($1, '')`
+		args = append(args, workspaceID)
+	}
+	query += ` ORDER BY workspace_id, stage`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query approval policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []models.ApprovalPolicy{}
+	for rows.Next() {
+		p, err := scanApprovalPolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan approval policy: %w", err)
+		}
+		policies = append(policies, *p)
+	}
+	return policies, nil
+}
+
+// SavePolicy creates the policy for a workspace and stage, replacing any existing one
+func (r *ApprovalRepository) SavePolicy(req models.SaveApprovalPolicyRequest, userID int) (*models.ApprovalPolicy, error) {
+	active := true
+	if req.IsActive != nil {
+		active = *req.IsActive
+	}
+	policy, err := scanApprovalPolicy(r.db.QueryRow(`
+		INSERT INTO approval_policies (workspace_id, stage, name, required_approvals, eligible_roles,
+			approval_chain, veto_roles, is_active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (workspace_id, stage) DO UPDATE SET
+			name = EXCLUDED.name,
+			required_approvals = EXCLUDED.required_approvals,
+			eligible_roles = EXCLUDED.eligible_roles,
+			approval_chain = EXCLUDED.approval_chain,
+			veto_roles = EXCLUDED.veto_roles,
+			is_active = EXCLUDED.is_active
+		RETURNING `+approvalPolicyColumns,
+		req.WorkspaceID, req.Stage, req.Name, req.RequiredApprovals, pq.Array(cleanRoles(req.EligibleRoles)),
+		pq.Array(cleanRoles(req.ApprovalChain)), pq.Array(cleanRoles(req.VetoRoles)), active, userID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save approval policy: %w", err)
+	}
+	return policy, nil
+}
+
+// DeletePolicy removes an approval policy. Requests already being voted on
+// keep the policy they were requested under.
+func (r *ApprovalRepository) DeletePolicy(id int) error {
+	result, err := r.db.Exec(`DELETE FROM approval_policies WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete approval policy: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("approval policy not found")
+	}
+	return nil
+}
+
+// GetPolicy returns an approval policy by its ID
+func (r *ApprovalRepository) GetPolicy(id int) (*models.ApprovalPolicy, error) {
+	policy, err := scanApprovalPolicy(r.db.QueryRow(`SELECT `+approvalPolicyColumns+` FROM approval_policies WHERE id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval policy: %w", err)
+	}
+	return policy, nil
+}
+
+// resolvePolicy returns the active policy for a workspace and stage. A
+// workspace policy beats a global one, and a stage policy beats an 'all' one.
+// Without a match the single-approver default applies.
+func resolvePolicy(q querier, workspaceID, stage string) (models.ApprovalPolicy, error) {
+	policy, err := scanApprovalPolicy(q.QueryRow(`
+		SELECT `+approvalPolicyColumns+`
+		FROM approval_policies
+		WHERE is_active = true AND workspace_id IN ($1, '') AND stage IN ($2, 'all')
+		ORDER BY workspace_id = '', stage = 'all'
+		LIMIT 1
+	`, workspaceID, stage))
+	if err == sql.ErrNoRows {
+		return models.DefaultApprovalPolicy(), nil
+	}
+	if err != nil {
+		return models.ApprovalPolicy{}, fmt.Errorf("failed to resolve approval policy: %w", err)
+	}
+	return *policy, nil
+}
+
+// approvalPolicy returns the policy snapshot stored with an approval request,
+// resolving the current policy for requests made before policies existed
+func approvalPolicy(q querier, snapshot []byte, workspaceID, stage string) (models.ApprovalPolicy, error) {
+	if len(snapshot) == 0 {
+		return resolvePolicy(q, workspaceID, stage)
+	}
+	var policy models.ApprovalPolicy
+	if err := json.Unmarshal(snapshot, &policy); err != nil {
+		return models.ApprovalPolicy{}, fmt.Errorf("failed to decode approval policy: %w", err)
+	}
+	return policy, nil
+}
+
+// auditRef identifies the request an approval_audit_log entry belongs to.
+// Exactly one of ApprovalID and EnablerApprovalID is set.
+type auditRef struct {
+	ApprovalID        *int // capability_approvals
+	EnablerApprovalID *int // enabler_approvals
+	CapabilityID      int
+	EnablerID         *int
+	RequirementID     *int
+	Stage             string
+	OnBehalfOf        *int // Delegator when a delegate performed the action
+}
+
+// logApprovalAction inserts an approval_audit_log entry
+func logApprovalAction(tx *sql.Tx, ref auditRef, action string, userID int, details map[string]interface{}) error {
+	detailsJSON, _ := json.Marshal(details)
+	_, err := tx.Exec(`
+		INSERT INTO approval_audit_log (approval_id, enabler_approval_id, capability_id, enabler_id, requirement_id,
+			action, stage, performed_by, on_behalf_of, details)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, ref.ApprovalID, ref.EnablerApprovalID, ref.CapabilityID, ref.EnablerID, ref.RequirementID,
+		action, ref.Stage, userID, ref.OnBehalfOf, detailsJSON)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return nil
+}
+
+// castVote checks a vote against the policy, records it in the audit log and
+// returns the policy's evaluation of all votes cast so far. A delegate's vote
+// counts as the delegator's.
+func castVote(tx *sql.Tx, policy models.ApprovalPolicy, workspaceID string, ref auditRef, voter approvalVoter, decision, feedback string, now time.Time) (models.ApprovalProgress, error) {
+	votes, err := listVotes(tx, ref)
+	if err != nil {
+		return models.ApprovalProgress{}, err
+	}
+	vote := models.ApprovalVote{UserID: voter.UserID, Role: voter.Role, Decision: decision, Feedback: feedback, VotedAt: now}
+	if err := approvalpolicy.CheckVote(policy, votes, vote); err != nil {
+		return models.ApprovalProgress{}, err
+	}
+
+	eligible, err := countVoters(tx, approvalpolicy.VoterRoles(policy), workspaceID)
+	if err != nil {
+		return models.ApprovalProgress{}, err
+	}
+	progress := approvalpolicy.Evaluate(policy, append(votes, vote), eligible)
+
+	details := map[string]interface{}{
+		"stage":     ref.Stage,
+		"decision":  decision,
+		"role":      voter.Role,
+		"feedback":  feedback,
+		"policy":    policy.Name,
+		"approvals": progress.Approvals,
+		"required":  progress.RequiredApprovals,
+		"status":    string(progress.Status),
+	}
+	if voter.DelegationID != nil {
+		details["delegation_id"] = *voter.DelegationID
+	}
+	ref.OnBehalfOf = voter.onBehalfOf()
+	err = logApprovalAction(tx, ref, "vote", voter.ActorID, details)
+	return progress, err
+}
+
+// listVotes returns the votes cast on an approval request, oldest first
+func listVotes(q querier, ref auditRef) ([]models.ApprovalVote, error) {
+	column, id := "approval_id", ref.ApprovalID
+	if ref.EnablerApprovalID != nil {
+		column, id = "enabler_approval_id", ref.EnablerApprovalID
+	}
+	rows, err := q.Query(`
+		SELECT COALESCE(al.on_behalf_of, al.performed_by), al.performed_at, al.details, COALESCE(u.name, ''),
+		       CASE WHEN al.on_behalf_of IS NOT NULL THEN al.performed_by END, COALESCE(d.name, '')
+		FROM approval_audit_log al
+		LEFT JOIN users u ON COALESCE(al.on_behalf_of, al.performed_by) = u.id
+		LEFT JOIN users d ON al.on_behalf_of IS NOT NULL AND al.performed_by = d.id
+		WHERE al.`+column+` = $1 AND al.action = 'vote'
+		ORDER BY al.id ASC
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query votes: %w", err)
+	}
+	defer rows.Close()
+
+	votes := []models.ApprovalVote{}
+	for rows.Next() {
+		var v models.ApprovalVote
+		var details []byte
+		if err := rows.Scan(&v.UserID, &v.VotedAt, &details, &v.UserName, &v.DelegateID, &v.DelegateName); err != nil {
+			return nil, fmt.Errorf("failed to scan vote: %w", err)
+		}
+		if err := json.Unmarshal(details, &v); err != nil {
+			return nil, fmt.Errorf("failed to decode vote: %w", err)
+		}
+		votes = append(votes, v)
+	}
+	return votes, rows.Err()
+}
+
+// countVoters returns how many active users hold one of roles (M) and may
+// approve in the workspace, or 0 when any role may vote. Workspace roles are
+// resolved as in workspaceRole.
+func countVoters(q querier, roles []string, workspaceID string) (int, error) {
+	if roles == nil {
+		return 0, nil
+	}
+	var n int
+	err := q.QueryRow(`
+		SELECT COUNT(*) FROM users u
+		WHERE u.is_active = true AND u.role = ANY($1)
+		  AND (u.role = 'admin'
+		       OR EXISTS (SELECT 1 FROM workspace_memberships m
+		                  WHERE m.workspace_id = $2 AND m.user_id = u.id AND m.role = ANY($3)))
+	`, pq.Array(roles), workspaceID, pq.Array(approverWorkspaceRoles)).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count eligible approvers: %w", err)
+	}
+	return n, nil
+}
+
+// GetVotes returns the votes on an approval request with its policy and progress
+func (r *ApprovalRepository) GetVotes(approvalID int) (*models.ApprovalVotesResponse, error) {
+	var stage, workspaceID string
+	var snapshot []byte
+	err := r.db.QueryRow(`
+		SELECT ca.stage, ca.policy, COALESCE(c.workspace_id, '')
+		FROM capability_approvals ca
+		JOIN capabilities c ON c.id = ca.capability_id
+		WHERE ca.id = $1
+	`, approvalID).Scan(&stage, &snapshot, &workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval: %w", err)
+	}
+
+	policy, err := approvalPolicy(r.db, snapshot, workspaceID, stage)
+	if err != nil {
+		return nil, err
+	}
+	votes, err := listVotes(r.db, auditRef{ApprovalID: &approvalID})
+	if err != nil {
+		return nil, err
+	}
+	eligible, err := countVoters(r.db, approvalpolicy.VoterRoles(policy), workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ApprovalVotesResponse{
+		ApprovalID: approvalID,
+		Policy:     policy,
+		Progress:   approvalpolicy.Evaluate(policy, votes, eligible),
+		Votes:      votes,
+	}, nil
+}
+
+func cleanRoles(roles []string) []string {
+	out := []string{}
+	for _, role := range roles {
+		if role = strings.TrimSpace(role); role != "" {
+			out = append(out, role)
+		}
+	}
+	return out
+}
@@ -27,7 +27,8 @@ func NewApprovalRepository(db *sql.DB) *ApprovalRepository {
 	return &ApprovalRepository{db: db}
 }
 
-// RequestApproval creates a new approval request for a capability
+// RequestApproval creates a new approval request for a capability. The
+// requester must be a contributor in the capability's workspace.
 func (r *ApprovalRepository) RequestApproval(capabilityID int, stage string, userID int) (*models.CapabilityApproval, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -48,13 +49,28 @@ func (r *ApprovalRepository) RequestApproval(capabilityID int, stage string, use
 		return nil, fmt.Errorf("failed to check existing approvals: %w", err)
 	}
 
+	// Snapshot the approval policy that will decide this request
+	var workspaceID string
+	err = tx.QueryRow(`SELECT COALESCE(workspace_id, '') FROM capabilities WHERE id = $1`, capabilityID).Scan(&workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get capability: %w", err)
+	}
+	if err := requireWorkspaceRole(tx, workspaceID, userID, models.WorkspaceRoleContributor); err != nil {
+		return nil, err
+	}
+	policy, err := resolvePolicy(tx, workspaceID, stage)
+	if err != nil {
+		return nil, err
+	}
+	policyJSON, _ := json.Marshal(policy)
+
 	// Create the approval request
 	var approval models.CapabilityApproval
 	err = tx.QueryRow(`
-		INSERT INTO capability_approvals (capability_id, stage, status, requested_by)
-		VALUES ($1, $2, 'pending_approval', $3)
+		INSERT INTO capability_approvals (capability_id, stage, status, requested_by, policy)
+		VALUES ($1, $2, 'pending_approval', $3, $4)
 		RETURNING id, capability_id, stage, status, requested_by, requested_at, created_at, updated_at
-	`, capabilityID, stage, userID).Scan(
+	`, capabilityID, stage, userID, policyJSON).Scan(
 		&approval.ID, &approval.CapabilityID, &approval.Stage, &approval.Status,
 		&approval.RequestedBy, &approval.RequestedAt, &approval.CreatedAt, &approval.UpdatedAt,
 	)
@@ -74,7 +90,8 @@ func (r *ApprovalRepository) RequestApproval(capabilityID int, stage string, use
 
 	// Log the action
 	details := map[string]interface{}{
-		"stage": stage,
+		"stage":  stage,
+		"policy": policy.Name,
 	}
 	detailsJSON, _ := json.Marshal(details)
 	_, err = tx.Exec(`
@@ -95,22 +112,45 @@ func (r *ApprovalRepository) RequestApproval(capabilityID int, stage string, use
 	return &approval, nil
 }
 
-// Approve approves an approval request
-func (r *ApprovalRepository) Approve(approvalID int, userID int, feedback string) (*models.CapabilityApproval, error) {
+// Approve records an approval vote. The request is approved once its approval
+// policy is satisfied; until then it stays pending. With onBehalfOf the user
+// votes as that user's delegate.
+func (r *ApprovalRepository) Approve(approvalID int, userID int, onBehalfOf *int, feedback string) (*models.CapabilityApproval, error) {
+	return r.vote(approvalID, userID, onBehalfOf, models.VoteApprove, feedback)
+}
+
+// Reject records a rejection vote. The request is rejected when the voter
+// holds a veto role or the policy's quorum can no longer be reached.
+func (r *ApprovalRepository) Reject(approvalID int, userID int, onBehalfOf *int, feedback string) (*models.CapabilityApproval, error) {
+	if feedback == "" {
+		return nil, fmt.Errorf("feedback is required when rejecting")
+	}
+	return r.vote(approvalID, userID, onBehalfOf, models.VoteReject, feedback)
+}
+
+// vote records one approver's decision in the audit log and settles the
+// request when its policy decides it
+func (r *ApprovalRepository) vote(approvalID int, userID int, onBehalfOf *int, decision string, feedback string) (*models.CapabilityApproval, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Get current approval
+	// Get current approval, locked so concurrent votes are counted one at a time
 	var approval models.CapabilityApproval
+	var snapshot []byte
+	var workspaceID string
 	err = tx.QueryRow(`
-		SELECT id, capability_id, stage, status, requested_by, requested_at
-		FROM capability_approvals WHERE id = $1
+		SELECT ca.id, ca.capability_id, ca.stage, ca.status, ca.requested_by, ca.requested_at,
+		       ca.policy, COALESCE(c.workspace_id, '')
+		FROM capability_approvals ca
+		JOIN capabilities c ON c.id = ca.capability_id
+		WHERE ca.id = $1
+		FOR UPDATE OF ca
 	`, approvalID).Scan(
 		&approval.ID, &approval.CapabilityID, &approval.Stage, &approval.Status,
-		&approval.RequestedBy, &approval.RequestedAt,
+		&approval.RequestedBy, &approval.RequestedAt, &snapshot, &workspaceID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get approval: %w", err)
@@ -120,134 +160,97 @@ func (r *ApprovalRepository) Approve(approvalID int, userID int, feedback string
 		return nil, fmt.Errorf("approval is not in pending status")
 	}
 
-	// Update approval
-	now := time.Now()
-	var feedbackPtr *string
-	if feedback != "" {
-		feedbackPtr = &feedback
-	}
-
-	err = tx.QueryRow(`
-		UPDATE capability_approvals
-		SET status = 'approved', decided_by = $1, decided_at = $2, feedback = $3, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $4
-		RETURNING id, capability_id, stage, status, requested_by, requested_at, decided_by, decided_at, feedback, created_at, updated_at
-	`, userID, now, feedbackPtr, approvalID).Scan(
-		&approval.ID, &approval.CapabilityID, &approval.Stage, &approval.Status,
-		&approval.RequestedBy, &approval.RequestedAt, &approval.DecidedBy, &approval.DecidedAt,
-		&approval.Feedback, &approval.CreatedAt, &approval.UpdatedAt,
-	)
+	policy, err := approvalPolicy(tx, snapshot, workspaceID, string(approval.Stage))
 	if err != nil {
-		return nil, fmt.Errorf("failed to update approval: %w", err)
+		return nil, err
 	}
 
-	// Update capability
-	_, err = tx.Exec(`
-		UPDATE capabilities
-		SET approval_status = 'approved', approved_by = $1, approved_at = $2, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $3
-	`, userID, now, approval.CapabilityID)
+	now := time.Now()
+	voter, err := resolveVoter(tx, userID, onBehalfOf, string(approval.Stage), workspaceID, now)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update capability status: %w", err)
+		return nil, err
 	}
-
-	// Log the action
-	details := map[string]interface{}{
-		"stage":    string(approval.Stage),
-		"feedback": feedback,
+	ref := auditRef{ApprovalID: &approval.ID, CapabilityID: approval.CapabilityID, Stage: string(approval.Stage)}
+	progress, err := castVote(tx, policy, workspaceID, ref, voter, decision, feedback, now)
+	if err != nil {
+		return nil, err
+	}
+
+	switch progress.Status {
+	case models.ApprovalStatusApproved, models.ApprovalStatusRejected:
+		ref.OnBehalfOf = voter.onBehalfOf()
+		err = r.settle(tx, &approval, progress, ref, userID, feedback, now)
+	default:
+		err = tx.QueryRow(`
+			UPDATE capability_approvals SET updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+			RETURNING created_at, updated_at
+		`, approvalID).Scan(&approval.CreatedAt, &approval.UpdatedAt)
+		if err != nil {
+			err = fmt.Errorf("failed to update approval: %w", err)
+		}
 	}
-	detailsJSON, _ := json.Marshal(details)
-	_, err = tx.Exec(`
-		INSERT INTO approval_audit_log (approval_id, capability_id, action, stage, performed_by, details)
-		VALUES ($1, $2, 'approved', $3, $4, $5)
-	`, approval.ID, approval.CapabilityID, approval.Stage, userID, detailsJSON)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create audit log: %w", err)
+		return nil, err
 	}
 
 	if err = tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	approval.Progress = &progress
 	r.enrichApprovalWithNames(&approval)
 	return &approval, nil
 }
 
-// Reject rejects an approval request
-func (r *ApprovalRepository) Reject(approvalID int, userID int, feedback string) (*models.CapabilityApproval, error) {
-	if feedback == "" {
-		return nil, fmt.Errorf("feedback is required when rejecting")
-	}
-
-	tx, err := r.db.Begin()
-	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
-
-	// Get current approval
-	var approval models.CapabilityApproval
-	err = tx.QueryRow(`
-		SELECT id, capability_id, stage, status, requested_by, requested_at
-		FROM capability_approvals WHERE id = $1
-	`, approvalID).Scan(
-		&approval.ID, &approval.CapabilityID, &approval.Stage, &approval.Status,
-		&approval.RequestedBy, &approval.RequestedAt,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get approval: %w", err)
-	}
-
-	if approval.Status != models.ApprovalStatusPending {
-		return nil, fmt.Errorf("approval is not in pending status")
+// settle records the decision of the approval policy on the request and its capability
+func (r *ApprovalRepository) settle(tx *sql.Tx, approval *models.CapabilityApproval, progress models.ApprovalProgress, ref auditRef, userID int, feedback string, now time.Time) error {
+	status := progress.Status
+	var feedbackPtr *string
+	if feedback != "" {
+		feedbackPtr = &feedback
 	}
 
 	// Update approval
-	now := time.Now()
-	err = tx.QueryRow(`
+	err := tx.QueryRow(`
 		UPDATE capability_approvals
-		SET status = 'rejected', decided_by = $1, decided_at = $2, feedback = $3, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $4
+		SET status = $1, decided_by = $2, decided_at = $3, feedback = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5
 		RETURNING id, capability_id, stage, status, requested_by, requested_at, decided_by, decided_at, feedback, created_at, updated_at
-	`, userID, now, feedback, approvalID).Scan(
+	`, string(status), userID, now, feedbackPtr, approval.ID).Scan(
 		&approval.ID, &approval.CapabilityID, &approval.Stage, &approval.Status,
 		&approval.RequestedBy, &approval.RequestedAt, &approval.DecidedBy, &approval.DecidedAt,
 		&approval.Feedback, &approval.CreatedAt, &approval.UpdatedAt,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update approval: %w", err)
+		return fmt.Errorf("failed to update approval: %w", err)
+	}
+
+	// Update capability; a rejected capability can be edited again
+	if status == models.ApprovalStatusApproved {
+		_, err = tx.Exec(`
+			UPDATE capabilities
+			SET approval_status = 'approved', approved_by = $1, approved_at = $2, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $3
+		`, userID, now, approval.CapabilityID)
+	} else {
+		_, err = tx.Exec(`
+			UPDATE capabilities
+			SET approval_status = 'rejected', updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+		`, approval.CapabilityID)
 	}
-
-	// Update capability status back to draft (can be edited again)
-	_, err = tx.Exec(`
-		UPDATE capabilities
-		SET approval_status = 'rejected', updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1
-	`, approval.CapabilityID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update capability status: %w", err)
+		return fmt.Errorf("failed to update capability status: %w", err)
 	}
 
-	// Log the action
-	details := map[string]interface{}{
-		"stage":    string(approval.Stage),
-		"feedback": feedback,
-	}
-	detailsJSON, _ := json.Marshal(details)
-	_, err = tx.Exec(`
-		INSERT INTO approval_audit_log (approval_id, capability_id, action, stage, performed_by, details)
-		VALUES ($1, $2, 'rejected', $3, $4, $5)
-	`, approval.ID, approval.CapabilityID, approval.Stage, userID, detailsJSON)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create audit log: %w", err)
-	}
-
-	if err = tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
-	r.enrichApprovalWithNames(&approval)
-	return &approval, nil
+	// Log the outcome
+	return logApprovalAction(tx, ref, string(status), userID, map[string]interface{}{
+		"stage":     string(approval.Stage),
+		"feedback":  feedback,
+		"reason":    progress.Reason,
+		"approvals": progress.Approvals,
+	})
 }
 
 // Withdraw withdraws an approval request (only by the requester)
@@ -401,10 +404,12 @@ func (r *ApprovalRepository) GetApprovalHistory(capabilityID int) ([]models.Capa
 func (r *ApprovalRepository) GetAuditLog(capabilityID int) ([]models.ApprovalAuditLog, error) {
 	rows, err := r.db.Query(`
 		SELECT al.id, al.approval_id, al.capability_id, al.action, al.stage,
-		       al.performed_by, al.performed_at, al.details, u.name as performer_name
+		       COALESCE(al.performed_by, 0), al.performed_at, al.details, COALESCE(u.name, '') as performer_name,
+		       al.on_behalf_of, COALESCE(ob.name, '') as on_behalf_of_name
 		FROM approval_audit_log al
 		LEFT JOIN users u ON al.performed_by = u.id
-		WHERE al.capability_id = $1
+		LEFT JOIN users ob ON al.on_behalf_of = ob.id
+		WHERE al.capability_id = $1 AND al.enabler_id IS NULL
 		ORDER BY al.performed_at DESC
 	`, capabilityID)
 	if err != nil {
@@ -418,10 +423,12 @@ func (r *ApprovalRepository) GetAuditLog(capabilityID int) ([]models.ApprovalAud
 		err := rows.Scan(
 			&l.ID, &l.ApprovalID, &l.CapabilityID, &l.Action, &l.Stage,
 			&l.PerformedBy, &l.PerformedAt, &l.Details, &l.PerformerName,
+			&l.OnBehalfOf, &l.OnBehalfOfName,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan audit log: %w", err)
 		}
+		describeAuditEntry(&l)
 		logs = append(logs, l)
 	}
 
@@ -0,0 +1,270 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jareynolds/intentr/pkg/approvalsla"
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+const approvalSLAColumns = `id, workspace_id, stage, due_hours, remind_after_hours,
+	COALESCE(escalation_role, ''), is_active, created_at, updated_at`
+
+func scanApprovalSLA(row rowScanner) (*models.ApprovalSLA, error) {
+	var s models.ApprovalSLA
+	err := row.Scan(
+		&s.ID, &s.WorkspaceID, &s.Stage, &s.DueHours, &s.RemindAfterHours,
+		&s.EscalationRole, &s.IsActive, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListSLAs returns the approval SLAs for a workspace, including the ones that
+// apply to every workspace. An empty workspaceID lists all SLAs.
+func (r *ApprovalRepository) ListSLAs(workspaceID string) ([]models.ApprovalSLA, error) {
+	query := `SELECT ` + approvalSLAColumns + ` FROM approval_slas`
+	var args []interface{}
+	if workspaceID != "" {
+		query += ` WHERE workspace_id IN ($1, '')`
+		args = append(args, workspaceID)
+	}
+	query += ` ORDER BY workspace_id, stage`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query approval SLAs: %w", err)
+	}
+	defer rows.Close()
+
+	slas := []models.ApprovalSLA{}
+	for rows.Next() {
+		s, err := scanApprovalSLA(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan approval SLA: %w", err)
+		}
+		slas = append(slas, *s)
+	}
+	return slas, nil
+}
+
+// SaveSLA creates the SLA for a workspace and stage, replacing any existing one
+func (r *ApprovalRepository) SaveSLA(req models.SaveApprovalSLARequest, userID int) (*models.ApprovalSLA, error) {
+	active := true
+	if req.IsActive != nil {
+		active = *req.IsActive
+	}
+	var escalationRole *string
+	if req.EscalationRole != "" {
+		escalationRole = &req.EscalationRole
+	}
+	sla, err := scanApprovalSLA(r.db.QueryRow(`
+		INSERT INTO approval_slas (workspace_id, stage, due_hours, remind_after_hours, escalation_role,
+			is_active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (workspace_id, stage) DO UPDATE SET
+			due_hours = EXCLUDED.due_hours,
+			remind_after_hours = EXCLUDED.remind_after_hours,
+			escalation_role = EXCLUDED.escalation_role,
+			is_active = EXCLUDED.is_active
+		RETURNING `+approvalSLAColumns,
+		req.WorkspaceID, req.Stage, req.DueHours, req.RemindAfterHours, escalationRole, active, userID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save approval SLA: %w", err)
+	}
+	return sla, nil
+}
+
+// DeleteSLA removes an approval SLA
+func (r *ApprovalRepository) DeleteSLA(id int) error {
+	result, err := r.db.Exec(`DELETE FROM approval_slas WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete approval SLA: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("approval SLA not found")
+	}
+	return nil
+}
+
+// ListPendingApprovalAges returns the pending capability approval requests
+// with the SLA that applies to each, oldest first. As with approval policies,
+// a workspace SLA beats a global one and a stage SLA beats an 'all' one. An
+// empty workspaceID lists requests in every workspace.
+func (r *ApprovalRepository) ListPendingApprovalAges(workspaceID string) ([]models.PendingApprovalAge, error) {
+	query := `
+		SELECT ca.id, ca.capability_id, c.name, COALESCE(c.workspace_id, ''), ca.stage, ca.requested_at,
+		       ca.reminded_at, ca.overdue_at, COALESCE(ca.escalated_to_role, ''),
+		       sla.due_hours, sla.remind_after_hours, COALESCE(sla.escalation_role, '')
+		FROM capability_approvals ca
+		JOIN capabilities c ON c.id = ca.capability_id
+		LEFT JOIN LATERAL (
+			SELECT due_hours, remind_after_hours, escalation_role
+			FROM approval_slas
+			WHERE is_active = true
+			  AND workspace_id IN (COALESCE(c.workspace_id, ''), '')
+			  AND stage IN (ca.stage, 'all')
+			ORDER BY workspace_id = '', stage = 'all'
+			LIMIT 1
+		) sla ON true
+		WHERE ca.status = $1`
+	args := []interface{}{models.ApprovalStatusPending}
+	if workspaceID != "" {
+		query += ` AND c.workspace_id = $2`
+		args = append(args, workspaceID)
+	}
+	query += ` ORDER BY ca.requested_at`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending approvals: %w", err)
+	}
+	defer rows.Close()
+
+	approvals := []models.PendingApprovalAge{}
+	for rows.Next() {
+		var a models.PendingApprovalAge
+		var dueHours, remindHours sql.NullInt64
+		err := rows.Scan(
+			&a.ApprovalID, &a.CapabilityID, &a.CapabilityName, &a.WorkspaceID, &a.Stage, &a.RequestedAt,
+			&a.RemindedAt, &a.OverdueAt, &a.EscalatedToRole,
+			&dueHours, &remindHours, &a.EscalationRole,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pending approval: %w", err)
+		}
+		if dueHours.Valid {
+			due := a.RequestedAt.Add(time.Duration(dueHours.Int64) * time.Hour)
+			a.DueAt = &due
+		}
+		if remindHours.Valid {
+			remind := a.RequestedAt.Add(time.Duration(remindHours.Int64) * time.Hour)
+			a.RemindAt = &remind
+		}
+		approvals = append(approvals, a)
+	}
+	return approvals, nil
+}
+
+// Remind records that a reminder was sent for a pending request. It reports
+// false if the request is no longer pending or was already reminded.
+func (r *ApprovalRepository) Remind(approvalID int, now time.Time) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var capabilityID int
+	var stage string
+	err = tx.QueryRow(`
+		UPDATE capability_approvals SET reminded_at = $2
+		WHERE id = $1 AND status = $3 AND reminded_at IS NULL
+		RETURNING capability_id, stage
+	`, approvalID, now, models.ApprovalStatusPending).Scan(&capabilityID, &stage)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to record reminder: %w", err)
+	}
+
+	ref := auditRef{ApprovalID: &approvalID, CapabilityID: capabilityID, Stage: stage}
+	if err := logSchedulerAction(tx, ref, approvalsla.ActionRemind, nil); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// MarkOverdue flags a pending request as overdue. With an escalation role, the
+// role is added to the request's policy snapshot as its fallback approver so
+// that it may vote in place of the next role of the approval chain. It reports
+// false if the request is no longer pending or was already flagged.
+func (r *ApprovalRepository) MarkOverdue(approvalID int, escalateTo string, now time.Time) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var capabilityID int
+	var stage, workspaceID string
+	var requestedAt time.Time
+	var snapshot []byte
+	err = tx.QueryRow(`
+		SELECT ca.capability_id, ca.stage, ca.requested_at, ca.policy, COALESCE(c.workspace_id, '')
+		FROM capability_approvals ca
+		JOIN capabilities c ON c.id = ca.capability_id
+		WHERE ca.id = $1 AND ca.status = $2 AND ca.overdue_at IS NULL
+		FOR UPDATE OF ca
+	`, approvalID, models.ApprovalStatusPending).Scan(&capabilityID, &stage, &requestedAt, &snapshot, &workspaceID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get approval: %w", err)
+	}
+
+	details := map[string]interface{}{"requested_at": requestedAt}
+	action := approvalsla.ActionFlagOverdue
+	var escalatedTo *string
+	if escalateTo != "" {
+		policy, err := approvalPolicy(tx, snapshot, workspaceID, stage)
+		if err != nil {
+			return false, err
+		}
+		policy.FallbackRole = escalateTo
+		snapshot, _ = json.Marshal(policy)
+		action, escalatedTo = approvalsla.ActionEscalate, &escalateTo
+		details["escalated_to"] = escalateTo
+		details["policy"] = policy.Name
+	}
+
+	_, err = tx.Exec(`
+		UPDATE capability_approvals
+		SET overdue_at = $2, escalated_to_role = $3, policy = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, approvalID, now, escalatedTo, snapshot)
+	if err != nil {
+		return false, fmt.Errorf("failed to flag overdue approval: %w", err)
+	}
+
+	ref := auditRef{ApprovalID: &approvalID, CapabilityID: capabilityID, Stage: stage}
+	if err := logSchedulerAction(tx, ref, action, details); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// logSchedulerAction inserts an approval_audit_log entry with no performer
+func logSchedulerAction(tx *sql.Tx, ref auditRef, action string, details map[string]interface{}) error {
+	detailsJSON, _ := json.Marshal(details)
+	_, err := tx.Exec(`
+		INSERT INTO approval_audit_log (approval_id, capability_id, action, stage, performed_by, details)
+		VALUES ($1, $2, $3, $4, NULL, $5)
+	`, ref.ApprovalID, ref.CapabilityID, action, ref.Stage, detailsJSON)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// AuditChainRepository reads the per-workspace audit hash chain. Records are
+// linked by database triggers as they are inserted, never by this repository.
+type AuditChainRepository struct {
+	db *sql.DB
+}
+
+// NewAuditChainRepository creates a new audit chain repository
+func NewAuditChainRepository(db *sql.DB) *AuditChainRepository {
+	return &AuditChainRepository{db: db}
+}
+
+// ListRecords returns the chain records of a workspace ordered by sequence
+// number, with their content recomputed from the rows as they are now. from
+// and to, when set, limit the records to those recorded in [from, to).
+func (r *AuditChainRepository) ListRecords(workspaceID string, from, to *time.Time) ([]models.AuditChainRecord, error) {
+	rows, err := r.db.Query(`
+		SELECT seq, source, source_id, content, prev_hash, hash, recorded_at FROM (
+			SELECT a.chain_seq AS seq, 'approval_audit_log' AS source, a.id AS source_id,
+			       approval_audit_content(a) AS content, a.prev_hash, a.record_hash AS hash,
+			       a.performed_at AS recorded_at
+			FROM approval_audit_log a
+			WHERE a.workspace_id = $1 AND a.chain_seq IS NOT NULL
+			UNION ALL
+			SELECT e.chain_seq, 'entity_state_changes', e.id,
+			       entity_state_change_content(e), e.prev_hash, e.record_hash,
+			       e.changed_at
+			FROM entity_state_changes e
+			WHERE COALESCE(e.workspace_id, '') = $1 AND e.chain_seq IS NOT NULL
+		) chain
+		WHERE ($2::timestamp IS NULL OR recorded_at >= $2) AND ($3::timestamp IS NULL OR recorded_at < $3)
+		ORDER BY seq
+	`, workspaceID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit chain: %w", err)
+	}
+	defer rows.Close()
+
+	records := []models.AuditChainRecord{}
+	for rows.Next() {
+		var rec models.AuditChainRecord
+		err := rows.Scan(&rec.Seq, &rec.Source, &rec.SourceID, &rec.Content, &rec.PrevHash, &rec.Hash, &rec.RecordedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit chain record: %w", err)
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// Head returns the sequence number and hash of the newest record of a
+// workspace's chain, or zeros when nothing has been recorded
+func (r *AuditChainRepository) Head(workspaceID string) (int64, string, error) {
+	var seq int64
+	var hash string
+	err := r.db.QueryRow(`
+		SELECT last_seq, last_hash FROM audit_chain_heads WHERE workspace_id = $1
+	`, workspaceID).Scan(&seq, &hash)
+	if err == sql.ErrNoRows {
+		return 0, "", nil
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to get audit chain head: %w", err)
+	}
+	return seq, hash, nil
+}
@@ -341,3 +341,19 @@ func (r *CapabilityRepository) Delete(id int) error {
 	}
 	return nil
 }
+
+// GetRef returns only the identifying fields of a capability (ID, capability
+// ID, name and workspace), e.g. to address a notification
+func (r *CapabilityRepository) GetRef(id int) (*models.Capability, error) {
+	var cap models.Capability
+	var capabilityID, workspaceID sql.NullString
+	err := r.db.QueryRow(`
+		SELECT id, capability_id, name, workspace_id FROM capabilities WHERE id = $1
+	`, id).Scan(&cap.ID, &capabilityID, &cap.Name, &workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get capability: %w", err)
+	}
+	cap.CapabilityID = capabilityID.String
+	cap.WorkspaceID = workspaceID.String
+	return &cap, nil
+}
@@ -0,0 +1,95 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// ChangeEventRepository reads the workspace change feed. Events are written
+// by database triggers, never by this repository.
+type ChangeEventRepository struct {
+	db *sql.DB
+}
+
+// NewChangeEventRepository creates a new change event repository
+func NewChangeEventRepository(db *sql.DB) *ChangeEventRepository {
+	return &ChangeEventRepository{db: db}
+}
+
+// ListSince returns up to limit events of a workspace with an ID above afterID, oldest first
+func (r *ChangeEventRepository) ListSince(workspaceID string, afterID int64, limit int) ([]models.ChangeEvent, error) {
+	rows, err := r.db.Query(`
+		SELECT id, workspace_id, event_type, COALESCE(entity_type, ''), COALESCE(entity_id, ''), payload, created_at
+		FROM change_events
+		WHERE workspace_id = $1 AND id > $2
+		ORDER BY id
+		LIMIT $3
+	`, workspaceID, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query change events: %w", err)
+	}
+	return scanChangeEvents(rows)
+}
+
+// ListAllSince returns up to limit events of every workspace with an ID above afterID, oldest first
+func (r *ChangeEventRepository) ListAllSince(afterID int64, limit int) ([]models.ChangeEvent, error) {
+	rows, err := r.db.Query(`
+		SELECT id, workspace_id, event_type, COALESCE(entity_type, ''), COALESCE(entity_id, ''), payload, created_at
+		FROM change_events
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+	`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query change events: %w", err)
+	}
+	return scanChangeEvents(rows)
+}
+
+func scanChangeEvents(rows *sql.Rows) ([]models.ChangeEvent, error) {
+	defer rows.Close()
+
+	var events []models.ChangeEvent
+	for rows.Next() {
+		var event models.ChangeEvent
+		var payload []byte
+		if err := rows.Scan(&event.ID, &event.WorkspaceID, &event.EventType, &event.EntityType, &event.EntityID, &payload, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan change event: %w", err)
+		}
+		event.Payload = payload
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read change events: %w", err)
+	}
+	return events, nil
+}
+
+// LatestID returns the ID of the newest event of a workspace, or 0 if it has none
+func (r *ChangeEventRepository) LatestID(workspaceID string) (int64, error) {
+	var id int64
+	err := r.db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM change_events WHERE workspace_id = $1`, workspaceID).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest change event: %w", err)
+	}
+	return id, nil
+}
+
+// LatestIDAll returns the ID of the newest event of any workspace, or 0 if there are none
+func (r *ChangeEventRepository) LatestIDAll() (int64, error) {
+	var id int64
+	if err := r.db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM change_events`).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to get latest change event: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,122 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// DependencyRepository reads the dependency relationships between
+// capabilities and enablers
+type DependencyRepository struct {
+	db *sql.DB
+}
+
+// NewDependencyRepository creates a new dependency repository
+func NewDependencyRepository(db *sql.DB) *DependencyRepository {
+	return &DependencyRepository{db: db}
+}
+
+// ListEdges returns every capability and enabler dependency, plus an
+// implemented_by edge from each capability to its enablers. An "upstream"
+// row means the row's entity depends on the referenced one; a "downstream"
+// row means the reverse. Only edges between entities of the workspace are
+// returned; the workspace ID is required.
+func (r *DependencyRepository) ListEdges(workspaceID string) ([]models.DependencyEdge, error) {
+	if workspaceID == "" {
+		return nil, fmt.Errorf("workspace ID is required")
+	}
+	edges, err := r.queryEdges(`
+		SELECT a.capability_id, a.name, b.capability_id, b.name, cd.dependency_type
+		FROM capability_dependencies cd
+		JOIN capabilities a ON a.id = cd.capability_id
+		JOIN capabilities b ON b.id = cd.depends_on_id
+		WHERE a.is_active = true AND b.is_active = true
+		  AND a.workspace_id = $1 AND b.workspace_id = $1
+	`, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query capability dependencies: %w", err)
+	}
+
+	// Enabler tables are created by a later migration
+	var hasEnablers, hasEnablerDeps bool
+	err = r.db.QueryRow(`SELECT to_regclass('enablers') IS NOT NULL, to_regclass('enabler_dependencies') IS NOT NULL`).Scan(&hasEnablers, &hasEnablerDeps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check enabler tables: %w", err)
+	}
+
+	if hasEnablers {
+		rows, err := r.db.Query(`
+			SELECT c.capability_id, c.name, e.enabler_id, e.name
+			FROM enablers e
+			JOIN capabilities c ON c.id = e.capability_id
+			WHERE e.is_active = true AND c.is_active = true
+			  AND e.workspace_id = $1 AND c.workspace_id = $1
+		`, workspaceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query enablers: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			e := models.DependencyEdge{Kind: "implemented_by"}
+			if err := rows.Scan(&e.FromID, &e.FromName, &e.ToID, &e.ToName); err != nil {
+				return nil, fmt.Errorf("failed to scan enabler: %w", err)
+			}
+			edges = append(edges, e)
+		}
+	}
+
+	if hasEnablerDeps {
+		enablerEdges, err := r.queryEdges(`
+			SELECT a.enabler_id, a.name,
+			       COALESCE(b.enabler_id, c.capability_id), COALESCE(b.name, c.name),
+			       ed.dependency_type
+			FROM enabler_dependencies ed
+			JOIN enablers a ON a.id = ed.enabler_id
+			LEFT JOIN enablers b ON b.id = ed.depends_on_enabler_id
+			LEFT JOIN capabilities c ON c.id = ed.depends_on_capability_id
+			WHERE a.is_active = true
+			  AND (b.id IS NOT NULL OR c.id IS NOT NULL)
+			  AND a.workspace_id = $1 AND COALESCE(b.workspace_id, c.workspace_id) = $1
+		`, workspaceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query enabler dependencies: %w", err)
+		}
+		edges = append(edges, enablerEdges...)
+	}
+
+	return edges, nil
+}
+
+// queryEdges scans (id, name, other id, other name, dependency_type) rows,
+// orienting each edge so that From depends on To
+func (r *DependencyRepository) queryEdges(query, workspaceID string) ([]models.DependencyEdge, error) {
+	rows, err := r.db.Query(query, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edges []models.DependencyEdge
+	for rows.Next() {
+		var id, name, otherID, otherName, depType string
+		if err := rows.Scan(&id, &name, &otherID, &otherName, &depType); err != nil {
+			return nil, err
+		}
+		e := models.DependencyEdge{FromID: id, FromName: name, ToID: otherID, ToName: otherName, Kind: "depends_on"}
+		if depType == "downstream" {
+			e = models.DependencyEdge{FromID: otherID, FromName: otherName, ToID: id, ToName: name, Kind: "depends_on"}
+		}
+		edges = append(edges, e)
+	}
+	return edges, rows.Err()
+}
@@ -0,0 +1,488 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jareynolds/intentr/pkg/approvalpolicy"
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/jareynolds/intentr/pkg/statemodel"
+)
+
+// Enabler approval requests work like capability approval requests: they are
+// decided by the same approval policies and logged to the same audit log. A
+// request covers the whole enabler, or one of its requirements when
+// RequirementID is set. Enablers and requirements use the INTENT approval
+// status values (pending, approved, rejected).
+
+const enablerApprovalColumns = `ea.id, ea.enabler_id, ea.requirement_id, ea.stage, ea.status, ea.requested_by,
+	ea.requested_at, ea.decided_by, ea.decided_at, ea.feedback, ea.created_at, ea.updated_at`
+
+func scanEnablerApproval(row rowScanner, extra ...interface{}) (*models.EnablerApproval, error) {
+	var a models.EnablerApproval
+	dest := []interface{}{
+		&a.ID, &a.EnablerID, &a.RequirementID, &a.Stage, &a.Status, &a.RequestedBy,
+		&a.RequestedAt, &a.DecidedBy, &a.DecidedAt, &a.Feedback, &a.CreatedAt, &a.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// enablerApprovalSubject is the enabler (and requirement) an approval request is about
+type enablerApprovalSubject struct {
+	ID             int
+	EnablerID      string // e.g. ENB-582341
+	CapabilityID   int
+	WorkspaceID    string
+	ApprovalStatus string
+	RequirementID  *int
+}
+
+func loadEnablerApprovalSubject(tx *sql.Tx, enablerID int, requirementID *int) (*enablerApprovalSubject, error) {
+	s := &enablerApprovalSubject{ID: enablerID, RequirementID: requirementID}
+	err := tx.QueryRow(`
+		SELECT enabler_id, capability_id, COALESCE(workspace_id, ''), COALESCE(approval_status, '')
+		FROM enablers WHERE id = $1
+	`, enablerID).Scan(&s.EnablerID, &s.CapabilityID, &s.WorkspaceID, &s.ApprovalStatus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enabler: %w", err)
+	}
+	if requirementID != nil {
+		err = tx.QueryRow(`
+			SELECT COALESCE(approval_status, '') FROM enabler_requirements WHERE id = $1 AND enabler_id = $2
+		`, *requirementID, enablerID).Scan(&s.ApprovalStatus)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("requirement %d does not belong to enabler %d", *requirementID, enablerID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get requirement: %w", err)
+		}
+	}
+	return s, nil
+}
+
+func (s *enablerApprovalSubject) auditRef(approvalID *int, stage string) auditRef {
+	return auditRef{
+		EnablerApprovalID: approvalID,
+		CapabilityID:      s.CapabilityID,
+		EnablerID:         &s.ID,
+		RequirementID:     s.RequirementID,
+		Stage:             stage,
+	}
+}
+
+// setStatus updates the approval status of the requirement, or of the enabler
+// (recording the change in the enabler's state history). Returns a
+// *models.StateTransitionError if the state model does not allow the change.
+func (s *enablerApprovalSubject) setStatus(tx *sql.Tx, status models.INTENTApprovalStatus, userID int, now time.Time, reason string) error {
+	from := statemodel.State{ApprovalStatus: s.ApprovalStatus}
+	to := statemodel.State{ApprovalStatus: string(status)}
+	entityType, entityID := models.EntityTypeEnablerState, s.EnablerID
+	if s.RequirementID != nil {
+		entityType, entityID = models.EntityType(models.EntityTypeRequirement), fmt.Sprintf("%d", *s.RequirementID)
+	}
+	if err := checkTransition(tx, entityType, entityID, s.WorkspaceID, from, to); err != nil {
+		return err
+	}
+	s.ApprovalStatus = string(status)
+
+	if s.RequirementID != nil {
+		_, err := tx.Exec(`
+			UPDATE enabler_requirements SET approval_status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+		`, string(status), *s.RequirementID)
+		if err != nil {
+			return fmt.Errorf("failed to update requirement status: %w", err)
+		}
+		return nil
+	}
+
+	var err error
+	if status == models.INTENTApprovalApproved {
+		_, err = tx.Exec(`
+			UPDATE enablers
+			SET approval_status = $1, approved_by = $2, approved_at = $3, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $4
+		`, string(status), userID, now, s.ID)
+	} else {
+		_, err = tx.Exec(`
+			UPDATE enablers SET approval_status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+		`, string(status), s.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update enabler status: %w", err)
+	}
+	return logStateChanges(tx, models.EntityTypeEnablerState, s.EnablerID, s.WorkspaceID, from, to, reason, &userID)
+}
+
+// RequestEnablerApproval submits an enabler, or one of its requirements, for approval at a stage
+func (r *ApprovalRepository) RequestEnablerApproval(enablerID int, requirementID *int, stage string, userID int) (*models.EnablerApproval, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	subject, err := loadEnablerApprovalSubject(tx, enablerID, requirementID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireWorkspaceRole(tx, subject.WorkspaceID, userID, models.WorkspaceRoleContributor); err != nil {
+		return nil, err
+	}
+
+	// Check if there's already a pending approval for this enabler (or requirement) and stage
+	var existingID int
+	err = tx.QueryRow(`
+		SELECT id FROM enabler_approvals
+		WHERE enabler_id = $1 AND requirement_id IS NOT DISTINCT FROM $2 AND stage = $3 AND status = 'pending_approval'
+	`, enablerID, requirementID, stage).Scan(&existingID)
+	if err == nil {
+		return nil, fmt.Errorf("approval request already pending for this enabler and stage")
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to check existing approvals: %w", err)
+	}
+
+	// Snapshot the approval policy that will decide this request
+	policy, err := resolvePolicy(tx, subject.WorkspaceID, stage)
+	if err != nil {
+		return nil, err
+	}
+	policyJSON, _ := json.Marshal(policy)
+
+	approval, err := scanEnablerApproval(tx.QueryRow(`
+		INSERT INTO enabler_approvals AS ea (enabler_id, requirement_id, stage, status, requested_by, policy)
+		VALUES ($1, $2, $3, 'pending_approval', $4, $5)
+		RETURNING `+enablerApprovalColumns,
+		enablerID, requirementID, stage, userID, policyJSON,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create approval request: %w", err)
+	}
+
+	if err := subject.setStatus(tx, models.INTENTApprovalPending, userID, approval.RequestedAt, "Submitted for "+stage+" approval"); err != nil {
+		return nil, err
+	}
+
+	err = logApprovalAction(tx, subject.auditRef(&approval.ID, stage), "requested", userID, map[string]interface{}{
+		"stage":  stage,
+		"policy": policy.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.enrichEnablerApprovalWithNames(approval)
+	return approval, nil
+}
+
+// ApproveEnabler records an approval vote on an enabler approval request
+func (r *ApprovalRepository) ApproveEnabler(approvalID int, userID int, onBehalfOf *int, feedback string) (*models.EnablerApproval, error) {
+	return r.voteEnabler(approvalID, userID, onBehalfOf, models.VoteApprove, feedback)
+}
+
+// RejectEnabler records a rejection vote on an enabler approval request
+func (r *ApprovalRepository) RejectEnabler(approvalID int, userID int, onBehalfOf *int, feedback string) (*models.EnablerApproval, error) {
+	if feedback == "" {
+		return nil, fmt.Errorf("feedback is required when rejecting")
+	}
+	return r.voteEnabler(approvalID, userID, onBehalfOf, models.VoteReject, feedback)
+}
+
+// lockPendingEnablerApproval loads a pending enabler approval request for update
+func lockPendingEnablerApproval(tx *sql.Tx, approvalID int) (*models.EnablerApproval, []byte, error) {
+	var snapshot []byte
+	approval, err := scanEnablerApproval(tx.QueryRow(`
+		SELECT `+enablerApprovalColumns+`, ea.policy
+		FROM enabler_approvals ea
+		WHERE ea.id = $1
+		FOR UPDATE
+	`, approvalID), &snapshot)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get approval: %w", err)
+	}
+	if approval.Status != string(models.ApprovalStatusPending) {
+		return nil, nil, fmt.Errorf("approval is not in pending status")
+	}
+	return approval, snapshot, nil
+}
+
+func (r *ApprovalRepository) voteEnabler(approvalID int, userID int, onBehalfOf *int, decision string, feedback string) (*models.EnablerApproval, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	approval, snapshot, err := lockPendingEnablerApproval(tx, approvalID)
+	if err != nil {
+		return nil, err
+	}
+	subject, err := loadEnablerApprovalSubject(tx, approval.EnablerID, approval.RequirementID)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := approvalPolicy(tx, snapshot, subject.WorkspaceID, approval.Stage)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	voter, err := resolveVoter(tx, userID, onBehalfOf, approval.Stage, subject.WorkspaceID, now)
+	if err != nil {
+		return nil, err
+	}
+	ref := subject.auditRef(&approval.ID, approval.Stage)
+	progress, err := castVote(tx, policy, subject.WorkspaceID, ref, voter, decision, feedback, now)
+	if err != nil {
+		return nil, err
+	}
+	ref.OnBehalfOf = voter.onBehalfOf()
+
+	if progress.Status == models.ApprovalStatusPending {
+		err = tx.QueryRow(`
+			UPDATE enabler_approvals SET updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+			RETURNING updated_at
+		`, approvalID).Scan(&approval.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update approval: %w", err)
+		}
+	} else {
+		var feedbackPtr *string
+		if feedback != "" {
+			feedbackPtr = &feedback
+		}
+		approval, err = decideEnablerApproval(tx, approvalID, progress.Status, userID, now, feedbackPtr)
+		if err != nil {
+			return nil, err
+		}
+
+		status := models.INTENTApprovalApproved
+		if progress.Status == models.ApprovalStatusRejected {
+			status = models.INTENTApprovalRejected
+		}
+		reason := fmt.Sprintf("%s approval %s: %s", approval.Stage, progress.Status, progress.Reason)
+		if err := subject.setStatus(tx, status, userID, now, reason); err != nil {
+			return nil, err
+		}
+
+		err = logApprovalAction(tx, ref, string(progress.Status), userID, map[string]interface{}{
+			"stage":     approval.Stage,
+			"feedback":  feedback,
+			"reason":    progress.Reason,
+			"approvals": progress.Approvals,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	approval.Progress = &progress
+	r.enrichEnablerApprovalWithNames(approval)
+	return approval, nil
+}
+
+func decideEnablerApproval(tx *sql.Tx, approvalID int, status models.ApprovalStatus, userID int, now time.Time, feedback *string) (*models.EnablerApproval, error) {
+	approval, err := scanEnablerApproval(tx.QueryRow(`
+		UPDATE enabler_approvals AS ea
+		SET status = $1, decided_by = $2, decided_at = $3, feedback = COALESCE($4, ea.feedback), updated_at = CURRENT_TIMESTAMP
+		WHERE ea.id = $5
+		RETURNING `+enablerApprovalColumns,
+		string(status), userID, now, feedback, approvalID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update approval: %w", err)
+	}
+	return approval, nil
+}
+
+// WithdrawEnabler withdraws an enabler approval request (only by the requester)
+func (r *ApprovalRepository) WithdrawEnabler(approvalID int, userID int) (*models.EnablerApproval, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	approval, _, err := lockPendingEnablerApproval(tx, approvalID)
+	if err != nil {
+		return nil, err
+	}
+	if approval.RequestedBy != userID {
+		return nil, fmt.Errorf("only the requester can withdraw an approval request")
+	}
+	subject, err := loadEnablerApprovalSubject(tx, approval.EnablerID, approval.RequirementID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	approval, err = decideEnablerApproval(tx, approvalID, models.ApprovalStatusWithdrawn, userID, now, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Withdrawn work goes back to pending so it can be edited and resubmitted
+	if err := subject.setStatus(tx, models.INTENTApprovalPending, userID, now, approval.Stage+" approval request withdrawn"); err != nil {
+		return nil, err
+	}
+
+	err = logApprovalAction(tx, subject.auditRef(&approval.ID, approval.Stage), "withdrawn", userID, map[string]interface{}{
+		"stage": approval.Stage,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.enrichEnablerApprovalWithNames(approval)
+	return approval, nil
+}
+
+// GetPendingEnablerApprovals returns all pending enabler approval requests
+func (r *ApprovalRepository) GetPendingEnablerApprovals() ([]models.EnablerApproval, error) {
+	return r.queryEnablerApprovals(`WHERE ea.status = 'pending_approval' ORDER BY ea.requested_at ASC`)
+}
+
+// GetEnablerApprovalHistory returns all approval requests for an enabler and its requirements
+func (r *ApprovalRepository) GetEnablerApprovalHistory(enablerID int) ([]models.EnablerApproval, error) {
+	return r.queryEnablerApprovals(`WHERE ea.enabler_id = $1 ORDER BY ea.created_at DESC`, enablerID)
+}
+
+// GetEnablerApprovalByID returns an enabler approval request by its ID
+func (r *ApprovalRepository) GetEnablerApprovalByID(id int) (*models.EnablerApproval, error) {
+	approvals, err := r.queryEnablerApprovals(`WHERE ea.id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(approvals) == 0 {
+		return nil, fmt.Errorf("failed to get approval: %w", sql.ErrNoRows)
+	}
+	return &approvals[0], nil
+}
+
+func (r *ApprovalRepository) queryEnablerApprovals(where string, args ...interface{}) ([]models.EnablerApproval, error) {
+	rows, err := r.db.Query(`
+		SELECT `+enablerApprovalColumns+`, COALESCE(u1.name, ''), COALESCE(u2.name, '')
+		FROM enabler_approvals ea
+		LEFT JOIN users u1 ON ea.requested_by = u1.id
+		LEFT JOIN users u2 ON ea.decided_by = u2.id
+		`+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query enabler approvals: %w", err)
+	}
+	defer rows.Close()
+
+	approvals := []models.EnablerApproval{}
+	for rows.Next() {
+		var requester, decider string
+		a, err := scanEnablerApproval(rows, &requester, &decider)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan approval: %w", err)
+		}
+		a.RequesterName, a.DeciderName = requester, decider
+		approvals = append(approvals, *a)
+	}
+	return approvals, nil
+}
+
+// GetEnablerAuditLog returns the audit log for an enabler and its requirements
+func (r *ApprovalRepository) GetEnablerAuditLog(enablerID int) ([]models.ApprovalAuditLog, error) {
+	rows, err := r.db.Query(`
+		SELECT al.id, al.enabler_approval_id, al.capability_id, al.enabler_id, al.requirement_id,
+		       al.action, al.stage, COALESCE(al.performed_by, 0), al.performed_at, al.details, COALESCE(u.name, ''),
+		       al.on_behalf_of, COALESCE(ob.name, '')
+		FROM approval_audit_log al
+		LEFT JOIN users u ON al.performed_by = u.id
+		LEFT JOIN users ob ON al.on_behalf_of = ob.id
+		WHERE al.enabler_id = $1
+		ORDER BY al.performed_at DESC
+	`, enablerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	logs := []models.ApprovalAuditLog{}
+	for rows.Next() {
+		var l models.ApprovalAuditLog
+		err := rows.Scan(
+			&l.ID, &l.EnablerApprovalID, &l.CapabilityID, &l.EnablerID, &l.RequirementID,
+			&l.Action, &l.Stage, &l.PerformedBy, &l.PerformedAt, &l.Details, &l.PerformerName,
+			&l.OnBehalfOf, &l.OnBehalfOfName,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		describeAuditEntry(&l)
+		logs = append(logs, l)
+	}
+	return logs, nil
+}
+
+// GetEnablerVotes returns the votes on an enabler approval request with its policy and progress
+func (r *ApprovalRepository) GetEnablerVotes(approvalID int) (*models.ApprovalVotesResponse, error) {
+	var stage, workspaceID string
+	var snapshot []byte
+	err := r.db.QueryRow(`
+		SELECT ea.stage, ea.policy, COALESCE(e.workspace_id, '')
+		FROM enabler_approvals ea
+		JOIN enablers e ON e.id = ea.enabler_id
+		WHERE ea.id = $1
+	`, approvalID).Scan(&stage, &snapshot, &workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval: %w", err)
+	}
+
+	policy, err := approvalPolicy(r.db, snapshot, workspaceID, stage)
+	if err != nil {
+		return nil, err
+	}
+	votes, err := listVotes(r.db, auditRef{EnablerApprovalID: &approvalID})
+	if err != nil {
+		return nil, err
+	}
+	eligible, err := countVoters(r.db, approvalpolicy.VoterRoles(policy), workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ApprovalVotesResponse{
+		ApprovalID: approvalID,
+		Policy:     policy,
+		Progress:   approvalpolicy.Evaluate(policy, votes, eligible),
+		Votes:      votes,
+	}, nil
+}
+
+// enrichEnablerApprovalWithNames adds user names to an enabler approval
+func (r *ApprovalRepository) enrichEnablerApprovalWithNames(approval *models.EnablerApproval) {
+	r.db.QueryRow("SELECT name FROM users WHERE id = $1", approval.RequestedBy).Scan(&approval.RequesterName)
+	if approval.DecidedBy != nil {
+		r.db.QueryRow("SELECT name FROM users WHERE id = $1", *approval.DecidedBy).Scan(&approval.DeciderName)
+	}
+}
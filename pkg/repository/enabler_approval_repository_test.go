@@ -0,0 +1,256 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/jareynolds/intentr/internal/testdb"
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/jareynolds/intentr/pkg/statemodel"
+)
+
+// approvalFixture is a workspace with an enabler and a requirement, and users
+// holding each workspace role an approval needs
+type approvalFixture struct {
+	db          *sql.DB
+	repo        *ApprovalRepository
+	owner       int
+	contributor int
+	approver    int
+	enabler     int
+	requirement int
+}
+
+func newApprovalFixture(t *testing.T) approvalFixture {
+	t.Helper()
+	db := testdb.Open(t)
+	f := approvalFixture{db: db, repo: NewApprovalRepository(db)}
+
+	f.owner = testdb.CreateUser(t, db, "owner", "user")
+	if err := NewWorkspaceMembershipRepository(db).ClaimWorkspace("workspace-a", f.owner); err != nil {
+		t.Fatal(err)
+	}
+	f.contributor = testdb.CreateUser(t, db, "contributor", "user")
+	f.approver = testdb.CreateUser(t, db, "approver", "user")
+	f.addMember(t, f.contributor, models.WorkspaceRoleContributor)
+	f.addMember(t, f.approver, models.WorkspaceRoleApprover)
+
+	var capability int
+	if err := db.QueryRow(`INSERT INTO capabilities (capability_id, name, workspace_id) VALUES ('CAP-100001', 'Checkout', 'workspace-a') RETURNING id`).Scan(&capability); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow(`INSERT INTO enablers (enabler_id, capability_id, name, workspace_id) VALUES ('ENB-100001', $1, 'Payments', 'workspace-a') RETURNING id`, capability).Scan(&f.enabler); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow(`
+		INSERT INTO enabler_requirements (requirement_id, enabler_id, name, description, requirement_type)
+		VALUES ('FR-100001', $1, 'Card payments', 'Accept cards', 'functional') RETURNING id
+	`, f.enabler).Scan(&f.requirement); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// addMember gives a user a role in the fixture's workspace
+func (f approvalFixture) addMember(t *testing.T, user int, role models.WorkspaceRole) {
+	t.Helper()
+	if _, err := f.db.Exec(`INSERT INTO workspace_memberships (workspace_id, user_id, role, granted_by) VALUES ('workspace-a', $1, $2, $3)`, user, role, f.owner); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// approvalStatus returns the approval_status of the enabler, or of the
+// requirement when requirement is set
+func (f approvalFixture) approvalStatus(t *testing.T, requirement bool) string {
+	t.Helper()
+	query, id := `SELECT approval_status FROM enablers WHERE id = $1`, f.enabler
+	if requirement {
+		query, id = `SELECT approval_status FROM enabler_requirements WHERE id = $1`, f.requirement
+	}
+	var status string
+	if err := f.db.QueryRow(query, id).Scan(&status); err != nil {
+		t.Fatal(err)
+	}
+	return status
+}
+
+func TestEnablerApprovalFlow(t *testing.T) {
+	f := newApprovalFixture(t)
+
+	approval, err := f.repo.RequestEnablerApproval(f.enabler, nil, string(models.StageSpecification), f.contributor)
+	if err != nil {
+		t.Fatalf("RequestEnablerApproval() error = %v", err)
+	}
+	var accessErr *models.WorkspaceAccessError
+	if _, err := f.repo.ApproveEnabler(approval.ID, f.contributor, nil, ""); !errors.As(err, &accessErr) {
+		t.Errorf("ApproveEnabler(contributor) error = %v, want a WorkspaceAccessError", err)
+	}
+	approval, err = f.repo.ApproveEnabler(approval.ID, f.approver, nil, "")
+	if err != nil {
+		t.Fatalf("ApproveEnabler() error = %v", err)
+	}
+	if approval.Status != string(models.ApprovalStatusApproved) || f.approvalStatus(t, false) != string(models.INTENTApprovalApproved) {
+		t.Errorf("after approval: request %s, enabler %s", approval.Status, f.approvalStatus(t, false))
+	}
+
+	// Resubmitting returns the enabler to pending until it is decided again
+	approval, err = f.repo.RequestEnablerApproval(f.enabler, nil, string(models.StageDefinition), f.contributor)
+	if err != nil {
+		t.Fatalf("RequestEnablerApproval(again) error = %v", err)
+	}
+	if status := f.approvalStatus(t, false); status != string(models.INTENTApprovalPending) {
+		t.Errorf("after resubmitting: enabler %s, want pending", status)
+	}
+	if _, err := f.repo.RejectEnabler(approval.ID, f.approver, nil, ""); err == nil {
+		t.Error("RejectEnabler() without feedback succeeded")
+	}
+	approval, err = f.repo.RejectEnabler(approval.ID, f.approver, nil, "Needs a threat model")
+	if err != nil {
+		t.Fatalf("RejectEnabler() error = %v", err)
+	}
+	if approval.Status != string(models.ApprovalStatusRejected) || f.approvalStatus(t, false) != string(models.INTENTApprovalRejected) {
+		t.Errorf("after rejection: request %s, enabler %s", approval.Status, f.approvalStatus(t, false))
+	}
+
+	// Requirements are decided on their own
+	approval, err = f.repo.RequestEnablerApproval(f.enabler, &f.requirement, string(models.StageSpecification), f.contributor)
+	if err != nil {
+		t.Fatalf("RequestEnablerApproval(requirement) error = %v", err)
+	}
+	if _, err := f.repo.ApproveEnabler(approval.ID, f.approver, nil, ""); err != nil {
+		t.Fatalf("ApproveEnabler(requirement) error = %v", err)
+	}
+	if f.approvalStatus(t, true) != string(models.INTENTApprovalApproved) || f.approvalStatus(t, false) != string(models.INTENTApprovalRejected) {
+		t.Errorf("after requirement approval: requirement %s, enabler %s", f.approvalStatus(t, true), f.approvalStatus(t, false))
+	}
+}
+
+func TestEnablerApprovalFollowsStateModel(t *testing.T) {
+	f := newApprovalFixture(t)
+
+	approval, err := f.repo.RequestEnablerApproval(f.enabler, nil, string(models.StageSpecification), f.contributor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The enabler is rejected elsewhere while the request is pending; a
+	// rejected enabler must be resubmitted before it can be approved
+	if _, err := f.db.Exec(`UPDATE enablers SET approval_status = 'rejected' WHERE id = $1`, f.enabler); err != nil {
+		t.Fatal(err)
+	}
+
+	var transitionErr *models.StateTransitionError
+	_, err = f.repo.ApproveEnabler(approval.ID, f.approver, nil, "")
+	if !errors.As(err, &transitionErr) {
+		t.Fatalf("ApproveEnabler() error = %v, want a StateTransitionError", err)
+	}
+	if transitionErr.Rule != statemodel.RuleTransitionNotAllowed || transitionErr.Field != statemodel.FieldApprovalStatus {
+		t.Errorf("error = %+v, want %s on %s", transitionErr, statemodel.RuleTransitionNotAllowed, statemodel.FieldApprovalStatus)
+	}
+
+	// Nothing is recorded when the transition is refused
+	if status := f.approvalStatus(t, false); status != string(models.INTENTApprovalRejected) {
+		t.Errorf("enabler %s, want rejected", status)
+	}
+	pending, err := f.repo.GetEnablerApprovalByID(approval.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending.Status != string(models.ApprovalStatusPending) {
+		t.Errorf("request %s, want it still pending", pending.Status)
+	}
+}
+
+func TestDeleteRequirementKeepsApprovalHistory(t *testing.T) {
+	f := newApprovalFixture(t)
+	enablers := NewEnablerRepository(f.db)
+
+	approval, err := f.repo.RequestEnablerApproval(f.enabler, &f.requirement, string(models.StageSpecification), f.contributor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.repo.ApproveEnabler(approval.ID, f.approver, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	auditRows := func() int {
+		t.Helper()
+		var n int
+		if err := f.db.QueryRow(`SELECT COUNT(*) FROM approval_audit_log WHERE requirement_id = $1`, f.requirement).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	before := auditRows()
+	if before == 0 {
+		t.Fatal("approving the requirement wrote no audit rows")
+	}
+
+	if err := enablers.DeleteRequirement(f.requirement); !errors.Is(err, ErrRequirementHasApprovalHistory) {
+		t.Fatalf("DeleteRequirement() error = %v, want ErrRequirementHasApprovalHistory", err)
+	}
+	if _, err := enablers.GetRequirementByID(f.requirement); err != nil {
+		t.Errorf("requirement is gone after a refused delete: %v", err)
+	}
+	if after := auditRows(); after != before {
+		t.Errorf("audit rows = %d after a refused delete, want %d", after, before)
+	}
+
+	// Requirements without approval history are still deleted
+	var other int
+	if err := f.db.QueryRow(`
+		INSERT INTO enabler_requirements (requirement_id, enabler_id, name, description, requirement_type)
+		VALUES ('FR-100002', $1, 'Refunds', 'Refund cards', 'functional') RETURNING id
+	`, f.enabler).Scan(&other); err != nil {
+		t.Fatal(err)
+	}
+	if err := enablers.DeleteRequirement(other); err != nil {
+		t.Errorf("DeleteRequirement(no history) error = %v", err)
+	}
+}
+
+func TestAuditedEnablerRowsAreNotDeleted(t *testing.T) {
+	f := newApprovalFixture(t)
+
+	approval, err := f.repo.RequestEnablerApproval(f.enabler, &f.requirement, string(models.StageSpecification), f.contributor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var before int
+	if err := f.db.QueryRow(`SELECT COUNT(*) FROM approval_audit_log WHERE enabler_id = $1`, f.enabler).Scan(&before); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleting what an audit row refers to would drop or rewrite chained rows
+	for _, stmt := range []struct {
+		query string
+		id    int
+	}{
+		{`DELETE FROM enabler_requirements WHERE id = $1`, f.requirement},
+		{`DELETE FROM enabler_approvals WHERE id = $1`, approval.ID},
+		{`DELETE FROM enablers WHERE id = $1`, f.enabler},
+	} {
+		if _, err := f.db.Exec(stmt.query, stmt.id); err == nil {
+			t.Errorf("%s succeeded for an audited row", stmt.query)
+		}
+	}
+	var after int
+	if err := f.db.QueryRow(`SELECT COUNT(*) FROM approval_audit_log WHERE enabler_id = $1`, f.enabler).Scan(&after); err != nil {
+		t.Fatal(err)
+	}
+	if after != before {
+		t.Errorf("audit rows = %d after refused deletes, want %d", after, before)
+	}
+
+	// Deleting the capability still removes its whole trail
+	if _, err := f.db.Exec(`DELETE FROM capabilities WHERE workspace_id = 'workspace-a'`); err != nil {
+		t.Errorf("deleting the capability: %v", err)
+	}
+}
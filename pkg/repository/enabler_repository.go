@@ -10,11 +10,17 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/jareynolds/intentr/pkg/models"
 )
 
+// ErrRequirementHasApprovalHistory is returned when deleting a requirement
+// that approval_audit_log rows refer to. Audit rows are append-only, so the
+// requirement is kept for as long as its history is.
+var ErrRequirementHasApprovalHistory = errors.New("requirement has approval history and cannot be deleted")
+
 // EnablerRepository handles database operations for enablers
 type EnablerRepository struct {
 	db *sql.DB
@@ -209,6 +215,21 @@ func (r *EnablerRepository) GetByID(id int) (*models.EnablerWithDetails, error)
 	return &e, nil
 }
 
+// GetRef returns only the identifying fields of an enabler (ID, enabler ID,
+// capability, name and workspace), e.g. to address a notification
+func (r *EnablerRepository) GetRef(id int) (*models.Enabler, error) {
+	var e models.Enabler
+	var workspaceID sql.NullString
+	err := r.db.QueryRow(`
+		SELECT id, enabler_id, capability_id, name, workspace_id FROM enablers WHERE id = $1
+	`, id).Scan(&e.ID, &e.EnablerID, &e.CapabilityID, &e.Name, &workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enabler: %w", err)
+	}
+	e.WorkspaceID = workspaceID.String
+	return &e, nil
+}
+
 // Update updates an enabler
 func (r *EnablerRepository) Update(id int, req models.UpdateEnablerRequest) (*models.EnablerWithDetails, error) {
 	// Build dynamic update query
@@ -406,8 +427,16 @@ func (r *EnablerRepository) GetRequirementByID(id int) (*models.EnablerRequireme
 	return &req, nil
 }
 
-// DeleteRequirement deletes a requirement
+// DeleteRequirement deletes a requirement. It returns
+// ErrRequirementHasApprovalHistory when the requirement has audit records.
 func (r *EnablerRepository) DeleteRequirement(id int) error {
+	var audited bool
+	if err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM approval_audit_log WHERE requirement_id = $1)`, id).Scan(&audited); err != nil {
+		return fmt.Errorf("failed to check requirement approval history: %w", err)
+	}
+	if audited {
+		return ErrRequirementHasApprovalHistory
+	}
 	_, err := r.db.Exec("DELETE FROM enabler_requirements WHERE id = $1", id)
 	if err != nil {
 		return fmt.Errorf("failed to delete requirement: %w", err)
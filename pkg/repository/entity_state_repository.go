@@ -11,8 +11,10 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/jareynolds/intentr/pkg/statemodel"
 )
 
 // EntityStateRepository handles database operations for entity state management
@@ -25,6 +27,79 @@ func NewEntityStateRepository(db *sql.DB) *EntityStateRepository {
 	return &EntityStateRepository{db: db}
 }
 
+// ============================================================================
+// STATE MACHINE
+// ============================================================================
+
+// currentState reads the four state dimensions, version and workspace of an
+// entity inside tx. table and idColumn are fixed identifiers, never user input.
+func currentState(tx *sql.Tx, table, idColumn, entityID string) (statemodel.State, int, string, error) {
+	var lifecycleState, workflowStage, stageStatus, approvalStatus, workspaceID sql.NullString
+	var version sql.NullInt64
+	err := tx.QueryRow(fmt.Sprintf(`
+		SELECT lifecycle_state, workflow_stage, stage_status, approval_status, version, workspace_id
+		FROM %s WHERE %s = $1 AND is_active = true
+	`, table, idColumn), entityID).Scan(&lifecycleState, &workflowStage, &stageStatus, &approvalStatus, &version, &workspaceID)
+	if err != nil {
+		return statemodel.State{}, 0, "", err
+	}
+	state := statemodel.State{
+		LifecycleState: lifecycleState.String,
+		WorkflowStage:  workflowStage.String,
+		StageStatus:    stageStatus.String,
+		ApprovalStatus: approvalStatus.String,
+	}
+	return state, int(version.Int64), workspaceID.String, nil
+}
+
+// checkTransition validates a state change against the INTENT state machine,
+// returning a *models.StateTransitionError when a rule blocks it
+func checkTransition(tx *sql.Tx, entityType models.EntityType, entityID, workspaceID string, from, to statemodel.State) error {
+	phases := make(map[string]bool)
+	if workspaceID != "" {
+		rows, err := tx.Query(`SELECT phase FROM phase_approvals WHERE workspace_id = $1 AND is_approved = true`, workspaceID)
+		if err != nil {
+			return fmt.Errorf("failed to query phase approvals: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var phase string
+			if err := rows.Scan(&phase); err != nil {
+				return fmt.Errorf("failed to scan phase approval: %w", err)
+			}
+			phases[phase] = true
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to read phase approvals: %w", err)
+		}
+	}
+
+	return statemodel.Validate(from, to, statemodel.Context{
+		EntityType:     string(entityType),
+		EntityID:       entityID,
+		ApprovedPhases: phases,
+	})
+}
+
+// logStateChanges records one entity_state_changes row per dimension that
+// differs between from and to. The change feed is driven from these rows.
+func logStateChanges(tx *sql.Tx, entityType models.EntityType, entityID, workspaceID string, from, to statemodel.State, reason string, userID *int) error {
+	for _, field := range statemodel.Fields {
+		oldValue, newValue := from.Get(field), to.Get(field)
+		if newValue == "" || newValue == oldValue {
+			continue
+		}
+		_, err := tx.Exec(`
+			INSERT INTO entity_state_changes (entity_type, entity_id, field_changed, old_value, new_value, change_reason, changed_by, workspace_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, string(entityType), entityID, field, oldValue, newValue, reason, userID, workspaceID)
+		if err != nil {
+			return fmt.Errorf("failed to log state change: %w", err)
+		}
+	}
+	return nil
+}
+
 // ============================================================================
 // CAPABILITY STATE OPERATIONS
 // ============================================================================
@@ -120,9 +195,7 @@ func (r *EntityStateRepository) UpdateCapabilityState(capabilityID string, req m
 	defer tx.Rollback()
 
 	// Check current version for optimistic locking
-	var currentVersion int
-	var dbID int
-	err = tx.QueryRow(`SELECT id, version FROM capabilities WHERE capability_id = $1 AND is_active = true`, capabilityID).Scan(&dbID, &currentVersion)
+	current, currentVersion, workspaceID, err := currentState(tx, "capabilities", "capability_id", capabilityID)
 	if err != nil {
 		return nil, fmt.Errorf("capability not found: %w", err)
 	}
@@ -136,18 +209,15 @@ func (r *EntityStateRepository) UpdateCapabilityState(capabilityID string, req m
 		}
 	}
 
+	if err := checkTransition(tx, models.EntityTypeCapabilityState, capabilityID, workspaceID, current, current.Apply(req)); err != nil {
+		return nil, err
+	}
+
 	// Build dynamic update query
 	query := "UPDATE capabilities SET updated_at = CURRENT_TIMESTAMP"
 	args := []interface{}{}
 	argPos := 1
 
-	// Track changes for audit log
-	var changes []struct {
-		field    string
-		oldValue string
-		newValue string
-	}
-
 	if req.LifecycleState != nil {
 		query += fmt.Sprintf(", lifecycle_state = $%d", argPos)
 		args = append(args, *req.LifecycleState)
@@ -178,16 +248,8 @@ func (r *EntityStateRepository) UpdateCapabilityState(capabilityID string, req m
 		return nil, fmt.Errorf("failed to update capability state: %w", err)
 	}
 
-	// Log state changes (simplified - just log the update for now)
-	if len(changes) > 0 || req.ChangeReason != "" {
-		_, err = tx.Exec(`
-			INSERT INTO entity_state_changes (entity_type, entity_id, field_changed, old_value, new_value, change_reason, changed_by)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)
-		`, string(models.EntityTypeCapabilityState), capabilityID, "state_update", "", "", req.ChangeReason, userID)
-		if err != nil {
-			// Log error but don't fail the transaction
-			fmt.Printf("Warning: failed to log state change: %v\n", err)
-		}
+	if err := logStateChanges(tx, models.EntityTypeCapabilityState, capabilityID, workspaceID, current, current.Apply(req), req.ChangeReason, userID); err != nil {
+		return nil, err
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -230,6 +292,19 @@ func (r *EntityStateRepository) UpsertCapabilityFromFile(cap models.Capability,
 		return nil, fmt.Errorf("failed to check existing capability: %w", err)
 	} else {
 		// Update existing capability
+		// Inactive rows are revived without a transition check
+		current, _, workspaceID, err := currentState(tx, "capabilities", "capability_id", cap.CapabilityID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to read capability state: %w", err)
+		}
+		active := err == nil
+		next := statemodel.State{LifecycleState: cap.LifecycleState, WorkflowStage: cap.WorkflowStage, StageStatus: cap.StageStatus, ApprovalStatus: cap.ApprovalStatus}
+		if active {
+			if err := checkTransition(tx, models.EntityTypeCapabilityState, cap.CapabilityID, workspaceID, current, next); err != nil {
+				return nil, err
+			}
+		}
+
 		_, err = tx.Exec(`
 			UPDATE capabilities SET
 				name = $1, status = $2, description = $3, purpose = $4, storyboard_reference = $5,
@@ -243,6 +318,12 @@ func (r *EntityStateRepository) UpsertCapabilityFromFile(cap models.Capability,
 		if err != nil {
 			return nil, fmt.Errorf("failed to update capability: %w", err)
 		}
+
+		if active {
+			if err := logStateChanges(tx, models.EntityTypeCapabilityState, cap.CapabilityID, workspaceID, current, next, "Synced from file", userID); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -252,6 +333,18 @@ func (r *EntityStateRepository) UpsertCapabilityFromFile(cap models.Capability,
 	return r.GetCapabilityState(cap.CapabilityID)
 }
 
+// SetCapabilityName renames a capability
+func (r *EntityStateRepository) SetCapabilityName(capabilityID, name string) error {
+	result, err := r.db.Exec(`UPDATE capabilities SET name = $1, updated_at = CURRENT_TIMESTAMP WHERE capability_id = $2 AND is_active = true`, name, capabilityID)
+	if err != nil {
+		return fmt.Errorf("failed to rename capability: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("capability %s not found", capabilityID)
+	}
+	return nil
+}
+
 // ============================================================================
 // ENABLER STATE OPERATIONS
 // ============================================================================
@@ -357,8 +450,7 @@ func (r *EntityStateRepository) UpdateEnablerState(enablerID string, req models.
 	defer tx.Rollback()
 
 	// Check current version for optimistic locking
-	var currentVersion int
-	err = tx.QueryRow(`SELECT version FROM enablers WHERE enabler_id = $1 AND is_active = true`, enablerID).Scan(&currentVersion)
+	current, currentVersion, workspaceID, err := currentState(tx, "enablers", "enabler_id", enablerID)
 	if err != nil {
 		return nil, fmt.Errorf("enabler not found: %w", err)
 	}
@@ -372,6 +464,10 @@ func (r *EntityStateRepository) UpdateEnablerState(enablerID string, req models.
 		}
 	}
 
+	if err := checkTransition(tx, models.EntityTypeEnablerState, enablerID, workspaceID, current, current.Apply(req)); err != nil {
+		return nil, err
+	}
+
 	// Build dynamic update query
 	query := "UPDATE enablers SET updated_at = CURRENT_TIMESTAMP"
 	args := []interface{}{}
@@ -406,6 +502,10 @@ func (r *EntityStateRepository) UpdateEnablerState(enablerID string, req models.
 		return nil, fmt.Errorf("failed to update enabler state: %w", err)
 	}
 
+	if err := logStateChanges(tx, models.EntityTypeEnablerState, enablerID, workspaceID, current, current.Apply(req), req.ChangeReason, userID); err != nil {
+		return nil, err
+	}
+
 	if err = tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -491,6 +591,19 @@ func (r *EntityStateRepository) UpsertEnablerFromFile(enb models.Enabler, userID
 	} else if err != nil {
 		return nil, fmt.Errorf("failed to check existing enabler: %w", err)
 	} else {
+		// Inactive rows are revived without a transition check
+		current, _, workspaceID, err := currentState(tx, "enablers", "enabler_id", enb.EnablerID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to read enabler state: %w", err)
+		}
+		active := err == nil
+		next := statemodel.State{LifecycleState: enb.LifecycleState, WorkflowStage: enb.WorkflowStage, StageStatus: enb.StageStatus, ApprovalStatus: enb.ApprovalStatus}
+		if active {
+			if err := checkTransition(tx, models.EntityTypeEnablerState, enb.EnablerID, workspaceID, current, next); err != nil {
+				return nil, err
+			}
+		}
+
 		// Update existing enabler - only update state fields; preserve structural fields if not provided
 		// If capability_id is 0 (not provided), keep the existing value
 		var capabilityIDToUse interface{}
@@ -534,6 +647,12 @@ func (r *EntityStateRepository) UpsertEnablerFromFile(enb models.Enabler, userID
 		if err != nil {
 			return nil, fmt.Errorf("failed to update enabler: %w", err)
 		}
+
+		if active {
+			if err := logStateChanges(tx, models.EntityTypeEnablerState, enb.EnablerID, workspaceID, current, next, "Synced from file", userID); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -543,6 +662,18 @@ func (r *EntityStateRepository) UpsertEnablerFromFile(enb models.Enabler, userID
 	return r.GetEnablerState(enb.EnablerID)
 }
 
+// SetEnablerName renames an enabler
+func (r *EntityStateRepository) SetEnablerName(enablerID, name string) error {
+	result, err := r.db.Exec(`UPDATE enablers SET name = $1, updated_at = CURRENT_TIMESTAMP WHERE enabler_id = $2 AND is_active = true`, name, enablerID)
+	if err != nil {
+		return fmt.Errorf("failed to rename enabler: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("enabler %s not found", enablerID)
+	}
+	return nil
+}
+
 // ============================================================================
 // STORY CARD STATE OPERATIONS
 // ============================================================================
@@ -614,8 +745,7 @@ func (r *EntityStateRepository) UpdateStoryCardState(cardID string, req models.U
 	defer tx.Rollback()
 
 	// Check current version for optimistic locking
-	var currentVersion int
-	err = tx.QueryRow(`SELECT version FROM story_cards WHERE card_id = $1 AND is_active = true`, cardID).Scan(&currentVersion)
+	current, currentVersion, workspaceID, err := currentState(tx, "story_cards", "card_id", cardID)
 	if err != nil {
 		return nil, fmt.Errorf("story card not found: %w", err)
 	}
@@ -629,6 +759,10 @@ func (r *EntityStateRepository) UpdateStoryCardState(cardID string, req models.U
 		}
 	}
 
+	if err := checkTransition(tx, models.EntityTypeStoryCardState, cardID, workspaceID, current, current.Apply(req)); err != nil {
+		return nil, err
+	}
+
 	// Build dynamic update query
 	query := "UPDATE story_cards SET updated_at = CURRENT_TIMESTAMP"
 	args := []interface{}{}
@@ -663,6 +797,10 @@ func (r *EntityStateRepository) UpdateStoryCardState(cardID string, req models.U
 		return nil, fmt.Errorf("failed to update story card state: %w", err)
 	}
 
+	if err := logStateChanges(tx, models.EntityTypeStoryCardState, cardID, workspaceID, current, current.Apply(req), req.ChangeReason, userID); err != nil {
+		return nil, err
+	}
+
 	if err = tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -737,6 +875,19 @@ func (r *EntityStateRepository) UpsertStoryCard(card models.StoryCard, userID *i
 		return nil, fmt.Errorf("failed to check existing story card: %w", err)
 	} else {
 		// Update existing story card
+		// Inactive rows are revived without a transition check
+		current, _, workspaceID, err := currentState(tx, "story_cards", "card_id", card.CardID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to read story card state: %w", err)
+		}
+		active := err == nil
+		next := statemodel.State{LifecycleState: card.LifecycleState, WorkflowStage: card.WorkflowStage, StageStatus: card.StageStatus, ApprovalStatus: card.ApprovalStatus}
+		if active {
+			if err := checkTransition(tx, models.EntityTypeStoryCardState, card.CardID, workspaceID, current, next); err != nil {
+				return nil, err
+			}
+		}
+
 		_, err = tx.Exec(`
 			UPDATE story_cards SET
 				title = $1, description = $2, card_type = $3, image_url = $4, position_x = $5, position_y = $6,
@@ -750,6 +901,12 @@ func (r *EntityStateRepository) UpsertStoryCard(card models.StoryCard, userID *i
 		if err != nil {
 			return nil, fmt.Errorf("failed to update story card: %w", err)
 		}
+
+		if active {
+			if err := logStateChanges(tx, models.EntityTypeStoryCardState, card.CardID, workspaceID, current, next, "Synced from file", userID); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -791,16 +948,23 @@ func (r *EntityStateRepository) GetAllStateByWorkspace(workspaceID string) (*mod
 // AUDIT LOG OPERATIONS
 // ============================================================================
 
-// GetStateChangeHistory retrieves the state change history for an entity
+// GetStateChangeHistory retrieves the state change history for an entity,
+// including the changes recorded under the IDs it was renamed from
 func (r *EntityStateRepository) GetStateChangeHistory(entityType, entityID string, limit int) ([]models.EntityStateChange, error) {
 	rows, err := r.db.Query(`
+		WITH RECURSIVE ids(id) AS (
+			SELECT $2::text
+			UNION
+			SELECT c.old_value FROM entity_state_changes c JOIN ids ON c.entity_id = ids.id
+			WHERE c.entity_type = $1 AND c.field_changed = $4
+		)
 		SELECT id, entity_type, entity_id, field_changed, old_value, new_value,
 		       change_reason, changed_by, changed_at, workspace_id
 		FROM entity_state_changes
-		WHERE entity_type = $1 AND entity_id = $2
-		ORDER BY changed_at DESC
+		WHERE entity_type = $1 AND entity_id IN (SELECT id FROM ids)
+		ORDER BY changed_at DESC, id DESC
 		LIMIT $3
-	`, entityType, entityID, limit)
+	`, entityType, entityID, limit, renamedField)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query state changes: %w", err)
 	}
@@ -823,6 +987,155 @@ func (r *EntityStateRepository) GetStateChangeHistory(entityType, entityID strin
 	return changes, nil
 }
 
+// ============================================================================
+// ID RENAME OPERATIONS
+// ============================================================================
+
+// idRenameTarget is the unique column holding a CAP/ENB/FR/NFR identifier.
+// Dependencies reference rows by integer id, so only this column changes.
+// scope is an SQL condition over $3 = workspace ID selecting the rows of
+// one workspace.
+type idRenameTarget struct {
+	table  string
+	column string
+	scope  string
+}
+
+// idRenameTargets maps each ID prefix to the column that stores it.
+// Requirements have no workspace of their own and take their enabler's.
+var idRenameTargets = map[string]idRenameTarget{
+	"CAP": {table: "capabilities", column: "capability_id", scope: `workspace_id = $3`},
+	"ENB": {table: "enablers", column: "enabler_id", scope: `workspace_id = $3`},
+	"FR":  {table: "enabler_requirements", column: "requirement_id", scope: `enabler_id IN (SELECT id FROM enablers WHERE workspace_id = $3)`},
+	"NFR": {table: "enabler_requirements", column: "requirement_id", scope: `enabler_id IN (SELECT id FROM enablers WHERE workspace_id = $3)`},
+}
+
+// idPathPattern matches a whole ID inside a file path, mirroring the
+// boundaries used when renaming spec files (see pkg/spec/rename)
+const idPathPattern = `(^|[^A-Za-z0-9_-])' || $1 || '(?![A-Za-z0-9_]|-[0-9])`
+
+// renamedField is the field_changed of the entity_state_changes row that
+// records an ID rename; old_value and new_value hold the two IDs
+const renamedField = "entity_id"
+
+// RenameEntityID changes oldID to newID in the capabilities, enablers and
+// requirements rows of workspaceID. The entity_state_changes history is
+// append-only, so its rows keep oldID and a rename row linking the IDs is
+// appended instead; GetStateChangeHistory follows it. When dryRun is true
+// nothing is written and the rows that would change are returned. Returns
+// *models.IDConflictError if newID is already in use.
+func (r *EntityStateRepository) RenameEntityID(workspaceID, oldID, newID string, userID *int, dryRun bool) ([]models.IDRenameRow, error) {
+	prefix := oldID
+	if i := strings.Index(oldID, "-"); i > 0 {
+		prefix = oldID[:i]
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	changes := []models.IDRenameRow{}
+	if t, ok := idRenameTargets[prefix]; ok {
+		// Tables from later migrations may not exist in every deployment
+		var hasTable, hasPath bool
+		err := tx.QueryRow(`
+			SELECT to_regclass($1) IS NOT NULL,
+			       EXISTS(SELECT 1 FROM information_schema.columns WHERE table_name = $1 AND column_name = 'file_path')
+		`, t.table).Scan(&hasTable, &hasPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect table %s: %w", t.table, err)
+		}
+
+		if hasTable {
+			// The ID columns are unique across workspaces
+			var taken bool
+			query := fmt.Sprintf(`SELECT EXISTS(SELECT 1 FROM %s WHERE %s = $1)`, t.table, t.column)
+			if err := tx.QueryRow(query, newID).Scan(&taken); err != nil {
+				return nil, fmt.Errorf("failed to check %s.%s: %w", t.table, t.column, err)
+			}
+			if taken {
+				return nil, &models.IDConflictError{ID: newID, Where: t.table}
+			}
+
+			found, err := r.renameColumn(tx, t, t.column, `$1`, workspaceID, oldID, newID, dryRun)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, found...)
+		}
+
+		if hasTable && hasPath {
+			found, err := r.renameColumn(tx, t, "file_path", fmt.Sprintf(`regexp_replace(file_path, '%s', '\1' || $2, 'g')`, idPathPattern), workspaceID, oldID, newID, dryRun)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, found...)
+		}
+	}
+
+	if dryRun {
+		return changes, nil
+	}
+
+	// Entities with a history get a rename row per entity type
+	_, err = tx.Exec(`
+		INSERT INTO entity_state_changes (entity_type, entity_id, field_changed, old_value, new_value, change_reason, changed_by, workspace_id)
+		SELECT DISTINCT ON (entity_type) entity_type, $2, $4, $1, $2, 'ID renamed', $3, workspace_id
+		FROM entity_state_changes
+		WHERE entity_id = $1 AND workspace_id = $5
+		ORDER BY entity_type, changed_at DESC, id DESC
+	`, oldID, newID, userID, renamedField, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record rename in state history: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return changes, nil
+}
+
+// renameColumn rewrites column in the rows of t within workspaceID where
+// match (an SQL expression over $1 = oldID, $2 = newID) evaluates to a
+// different value
+func (r *EntityStateRepository) renameColumn(tx *sql.Tx, t idRenameTarget, column, match, workspaceID, oldID, newID string, dryRun bool) ([]models.IDRenameRow, error) {
+	table := t.table
+	after := `$2`
+	where := fmt.Sprintf(`%s = $1`, column)
+	if match != `$1` {
+		after = match
+		where = fmt.Sprintf(`%s IS DISTINCT FROM %s`, column, match)
+	}
+	where += " AND " + t.scope
+
+	rows, err := tx.Query(fmt.Sprintf(`SELECT id, %s, %s FROM %s WHERE %s`, column, after, table, where), oldID, newID, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s.%s: %w", table, column, err)
+	}
+	var changes []models.IDRenameRow
+	for rows.Next() {
+		change := models.IDRenameRow{Table: table, Column: column}
+		if err := rows.Scan(&change.RowID, &change.Before, &change.After); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan %s row: %w", table, err)
+		}
+		changes = append(changes, change)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s rows: %w", table, err)
+	}
+
+	if dryRun || len(changes) == 0 {
+		return changes, nil
+	}
+	if _, err := tx.Exec(fmt.Sprintf(`UPDATE %s SET %s = %s WHERE %s`, table, column, after, where), oldID, newID, workspaceID); err != nil {
+		return nil, fmt.Errorf("failed to update %s.%s: %w", table, column, err)
+	}
+	return changes, nil
+}
+
 // ============================================================================
 // PHASE APPROVAL OPERATIONS
 // ============================================================================
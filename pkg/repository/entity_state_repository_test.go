@@ -0,0 +1,78 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package repository
+
+import (
+	"testing"
+
+	"github.com/jareynolds/intentr/internal/testdb"
+)
+
+func TestRenameEntityIDKeepsHistoryAppendOnly(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewEntityStateRepository(db)
+	user := testdb.CreateUser(t, db, "renamer", "user")
+
+	if _, err := db.Exec(`INSERT INTO capabilities (capability_id, name, workspace_id) VALUES ('CAP-100001', 'Checkout', 'workspace-a')`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`
+		INSERT INTO entity_state_changes (entity_type, entity_id, field_changed, old_value, new_value, change_reason, workspace_id)
+		VALUES ('capability', 'CAP-100001', 'lifecycle_state', 'draft', 'active', '', 'workspace-a')
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	preview, err := repo.RenameEntityID("workspace-a", "CAP-100001", "CAP-100002", &user, true)
+	if err != nil {
+		t.Fatalf("RenameEntityID(dry run) error = %v", err)
+	}
+	renamed, err := repo.RenameEntityID("workspace-a", "CAP-100001", "CAP-100002", &user, false)
+	if err != nil {
+		t.Fatalf("RenameEntityID() error = %v", err)
+	}
+	if len(renamed) != len(preview) {
+		t.Errorf("renamed %d rows, preview listed %d", len(renamed), len(preview))
+	}
+
+	if _, err := repo.GetCapabilityState("CAP-100002"); err != nil {
+		t.Errorf("renamed capability not found: %v", err)
+	}
+	history, err := repo.GetStateChangeHistory("capability", "CAP-100002", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].FieldChanged != renamedField || history[1].EntityID != "CAP-100001" {
+		t.Errorf("history = %+v, want the rename row then the original row under the old ID", history)
+	}
+}
+
+func TestRenameEntityIDStaysInWorkspace(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewEntityStateRepository(db)
+
+	if _, err := db.Exec(`
+		INSERT INTO capabilities (capability_id, name, workspace_id, file_path)
+		VALUES ('CAP-100001', 'Checkout', 'workspace-a', 'definition/CAP-100001.md'),
+		       ('CAP-100003', 'Refunds', 'workspace-b', 'definition/CAP-100001-refunds.md')
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	renamed, err := repo.RenameEntityID("workspace-b", "CAP-100001", "CAP-100002", nil, false)
+	if err != nil {
+		t.Fatalf("RenameEntityID() error = %v", err)
+	}
+	if len(renamed) != 1 || renamed[0].Column != "file_path" {
+		t.Errorf("renamed = %+v, want only the workspace-b file path", renamed)
+	}
+	if _, err := repo.GetCapabilityState("CAP-100001"); err != nil {
+		t.Errorf("workspace-a capability renamed from another workspace: %v", err)
+	}
+}
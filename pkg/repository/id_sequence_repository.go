@@ -0,0 +1,73 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// IDSequenceRepository stores ID allocator state and scans the entity tables
+// for IDs already in use. It implements idalloc.Source and idalloc.Sequencer.
+type IDSequenceRepository struct {
+	db *sql.DB
+}
+
+// NewIDSequenceRepository creates a new ID sequence repository
+func NewIDSequenceRepository(db *sql.DB) *IDSequenceRepository {
+	return &IDSequenceRepository{db: db}
+}
+
+// idColumns maps an ID prefix to the table and column holding those IDs
+var idColumns = map[string]struct{ table, column string }{
+	"CAP": {"capabilities", "capability_id"},
+	"ENB": {"enablers", "enabler_id"},
+	"FR":  {"enabler_requirements", "requirement_id"},
+	"NFR": {"enabler_requirements", "requirement_id"},
+}
+
+// MaxID returns the highest number used for prefix in the entity tables.
+// The ID columns are unique across the whole table, so the scan is not
+// limited to the workspace.
+func (r *IDSequenceRepository) MaxID(workspace, prefix string) (int, error) {
+	col, ok := idColumns[prefix]
+	if !ok {
+		return 0, nil // Test scenarios only live in files
+	}
+
+	var max int
+	err := r.db.QueryRow(fmt.Sprintf(`
+		SELECT COALESCE(MAX(CAST(substring(%[2]s from $1) AS INTEGER)), 0)
+		FROM %[1]s
+		WHERE %[2]s ~ $1
+	`, col.table, col.column), "^"+prefix+"-([0-9]{1,9})").Scan(&max)
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan %s IDs: %w", prefix, err)
+	}
+	return max, nil
+}
+
+// Reserve atomically stores and returns the next number above both floor and
+// the last number reserved for prefix. Every workspace and service shares the
+// prefix's sequence.
+func (r *IDSequenceRepository) Reserve(prefix string, floor int) (int, error) {
+	var next int
+	err := r.db.QueryRow(`
+		INSERT INTO id_sequences (prefix, last_value)
+		VALUES ($1, $2 + 1)
+		ON CONFLICT (prefix) DO UPDATE
+		SET last_value = GREATEST(id_sequences.last_value, $2) + 1,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING last_value
+	`, prefix, floor).Scan(&next)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reserve %s ID: %w", prefix, err)
+	}
+	return next, nil
+}
@@ -11,27 +11,39 @@ package repository
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/jareynolds/intentr/pkg/vault"
+	"github.com/lib/pq"
 )
 
-// IntegrationRepository handles database operations for integrations
+// IntegrationRepository handles database operations for integrations.
+// Integration field values are credentials, so they are encrypted with a
+// per-integration data key from the vault. Fields saved before encryption was
+// introduced are encrypted by EncryptPlaintextIntegrations, which the
+// integration service runs at startup.
 type IntegrationRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	vault *vault.Vault
 }
 
-// NewIntegrationRepository creates a new integration repository
-func NewIntegrationRepository(db *sql.DB) *IntegrationRepository {
-	return &IntegrationRepository{db: db}
+// NewIntegrationRepository creates a new integration repository. Without a
+// vault, stored integrations can be read but not saved.
+func NewIntegrationRepository(db *sql.DB, v *vault.Vault) *IntegrationRepository {
+	return &IntegrationRepository{db: db, vault: v}
 }
 
 // GetIntegration retrieves an integration by user ID and integration name
 func (r *IntegrationRepository) GetIntegration(userID int, integrationName string) (*models.IntegrationWithFields, error) {
 	// Get the integration
 	var integration models.Integration
+	var dataKey, masterKeyID sql.NullString
 	err := r.db.QueryRow(`
-		SELECT id, user_id, integration_name, provider_url, configured_at, updated_at, is_active
+		SELECT id, user_id, integration_name, provider_url, configured_at, updated_at, is_active,
+		       data_key, master_key_id
 		FROM integrations
 		WHERE user_id = $1 AND integration_name = $2 AND is_active = true
 	`, userID, integrationName).Scan(
@@ -42,6 +54,8 @@ func (r *IntegrationRepository) GetIntegration(userID int, integrationName strin
 		&integration.ConfiguredAt,
 		&integration.UpdatedAt,
 		&integration.IsActive,
+		&dataKey,
+		&masterKeyID,
 	)
 
 	if err == sql.ErrNoRows {
@@ -53,7 +67,7 @@ func (r *IntegrationRepository) GetIntegration(userID int, integrationName strin
 
 	// Get the fields
 	rows, err := r.db.Query(`
-		SELECT field_name, field_value
+		SELECT field_name, field_value, is_encrypted
 		FROM integration_fields
 		WHERE integration_id = $1
 	`, integration.ID)
@@ -62,14 +76,29 @@ func (r *IntegrationRepository) GetIntegration(userID int, integrationName strin
 	}
 	defer rows.Close()
 
+	var key []byte
 	fields := make(map[string]string)
 	for rows.Next() {
 		var name, value string
-		if err := rows.Scan(&name, &value); err != nil {
+		var encrypted bool
+		if err := rows.Scan(&name, &value, &encrypted); err != nil {
 			return nil, err
 		}
+		if encrypted {
+			if key == nil {
+				if key, err = r.unwrapDataKey(dataKey, masterKeyID); err != nil {
+					return nil, fmt.Errorf("integration %s: %w", integrationName, err)
+				}
+			}
+			if value, err = vault.Decrypt(key, value, name); err != nil {
+				return nil, fmt.Errorf("integration %s field %s: %w", integrationName, name, err)
+			}
+		}
 		fields[name] = value
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
 	return &models.IntegrationWithFields{
 		Integration: integration,
@@ -77,8 +106,55 @@ func (r *IntegrationRepository) GetIntegration(userID int, integrationName strin
 	}, nil
 }
 
-// SaveIntegration creates or updates an integration
+// unwrapDataKey returns an integration's data key
+func (r *IntegrationRepository) unwrapDataKey(dataKey, masterKeyID sql.NullString) ([]byte, error) {
+	if r.vault == nil {
+		return nil, vault.ErrNoMasterKey
+	}
+	if !dataKey.Valid || !masterKeyID.Valid {
+		return nil, errors.New("encrypted fields without a data key")
+	}
+	return r.vault.UnwrapDataKey(masterKeyID.String, dataKey.String)
+}
+
+// ListIntegrations returns a user's active integrations without field values
+func (r *IntegrationRepository) ListIntegrations(userID int) ([]models.IntegrationSummary, error) {
+	rows, err := r.db.Query(`
+		SELECT i.integration_name, i.provider_url, i.updated_at, i.data_key IS NOT NULL,
+		       COALESCE(array_agg(f.field_name ORDER BY f.field_name) FILTER (WHERE f.field_name IS NOT NULL), '{}')
+		FROM integrations i
+		LEFT JOIN integration_fields f ON f.integration_id = i.id
+		WHERE i.user_id = $1 AND i.is_active = true
+		GROUP BY i.id
+		ORDER BY i.integration_name
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []models.IntegrationSummary{}
+	for rows.Next() {
+		var s models.IntegrationSummary
+		if err := rows.Scan(&s.IntegrationName, &s.ProviderURL, &s.UpdatedAt, &s.Encrypted, pq.Array(&s.FieldNames)); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
+
+// SaveIntegration creates or updates an integration, encrypting its fields
+// with a new data key
 func (r *IntegrationRepository) SaveIntegration(userID int, integrationName, providerURL string, fields map[string]string) error {
+	if r.vault == nil {
+		return vault.ErrNoMasterKey
+	}
+	key, wrapped, masterKeyID, err := r.vault.NewDataKey()
+	if err != nil {
+		return err
+	}
+
 	tx, err := r.db.Begin()
 	if err != nil {
 		return err
@@ -88,12 +164,12 @@ func (r *IntegrationRepository) SaveIntegration(userID int, integrationName, pro
 	// Upsert integration
 	var integrationID int
 	err = tx.QueryRow(`
-		INSERT INTO integrations (user_id, integration_name, provider_url, updated_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO integrations (user_id, integration_name, provider_url, updated_at, data_key, master_key_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		ON CONFLICT (user_id, integration_name)
-		DO UPDATE SET provider_url = $3, updated_at = $4, is_active = true
+		DO UPDATE SET provider_url = $3, updated_at = $4, is_active = true, data_key = $5, master_key_id = $6
 		RETURNING id
-	`, userID, integrationName, providerURL, time.Now()).Scan(&integrationID)
+	`, userID, integrationName, providerURL, time.Now(), wrapped, masterKeyID).Scan(&integrationID)
 	if err != nil {
 		return err
 	}
@@ -106,11 +182,7 @@ func (r *IntegrationRepository) SaveIntegration(userID int, integrationName, pro
 
 	// Insert new fields
 	for name, value := range fields {
-		_, err = tx.Exec(`
-			INSERT INTO integration_fields (integration_id, field_name, field_value, updated_at)
-			VALUES ($1, $2, $3, $4)
-		`, integrationID, name, value, time.Now())
-		if err != nil {
+		if err := insertEncryptedField(tx, integrationID, key, name, value); err != nil {
 			return err
 		}
 	}
@@ -118,6 +190,187 @@ func (r *IntegrationRepository) SaveIntegration(userID int, integrationName, pro
 	return tx.Commit()
 }
 
+// insertEncryptedField stores a field value encrypted with a data key
+func insertEncryptedField(tx *sql.Tx, integrationID int, key []byte, name, value string) error {
+	sealed, err := vault.Encrypt(key, value, name)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO integration_fields (integration_id, field_name, field_value, is_encrypted, updated_at)
+		VALUES ($1, $2, $3, true, $4)
+	`, integrationID, name, sealed, time.Now())
+	return err
+}
+
+// DeleteIntegration removes an integration and its fields. It returns
+// sql.ErrNoRows if the user has no such integration.
+func (r *IntegrationRepository) DeleteIntegration(userID int, integrationName string) error {
+	result, err := r.db.Exec(`
+		DELETE FROM integrations WHERE user_id = $1 AND integration_name = $2
+	`, userID, integrationName)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RotateMasterKey re-wraps every data key with the vault's current master
+// key and encrypts integrations still stored in plain text. Each integration
+// is updated in its own transaction, so an interrupted rotation can simply be
+// run again.
+func (r *IntegrationRepository) RotateMasterKey() (*models.VaultRotationResult, error) {
+	if r.vault == nil {
+		return nil, vault.ErrNoMasterKey
+	}
+
+	type stored struct {
+		id          int
+		name        string
+		dataKey     sql.NullString
+		masterKeyID sql.NullString
+	}
+	rows, err := r.db.Query(`SELECT id, integration_name, data_key, master_key_id FROM integrations ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	var integrations []stored
+	for rows.Next() {
+		var s stored
+		if err := rows.Scan(&s.id, &s.name, &s.dataKey, &s.masterKeyID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		integrations = append(integrations, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &models.VaultRotationResult{}
+	for _, s := range integrations {
+		switch {
+		case s.dataKey.Valid && s.masterKeyID.String == r.vault.CurrentKeyID():
+			result.Unchanged++
+		case s.dataKey.Valid:
+			wrapped, masterKeyID, err := r.vault.Rewrap(s.masterKeyID.String, s.dataKey.String)
+			if err != nil {
+				return result, fmt.Errorf("integration %d (%s): %w", s.id, s.name, err)
+			}
+			if _, err := r.db.Exec(`
+				UPDATE integrations SET data_key = $1, master_key_id = $2 WHERE id = $3 AND master_key_id = $4
+			`, wrapped, masterKeyID, s.id, s.masterKeyID.String); err != nil {
+				return result, err
+			}
+			result.Rewrapped++
+		default:
+			if err := r.encryptPlaintextIntegration(s.id); err != nil {
+				return result, fmt.Errorf("integration %d (%s): %w", s.id, s.name, err)
+			}
+			result.Encrypted++
+		}
+	}
+	return result, nil
+}
+
+// EncryptPlaintextIntegrations encrypts every integration still stored in
+// plain text and returns how many it encrypted. Each integration is updated in
+// its own transaction, so an interrupted run can simply be repeated.
+func (r *IntegrationRepository) EncryptPlaintextIntegrations() (int, error) {
+	if r.vault == nil {
+		return 0, vault.ErrNoMasterKey
+	}
+
+	rows, err := r.db.Query(`SELECT id, integration_name FROM integrations WHERE data_key IS NULL ORDER BY id`)
+	if err != nil {
+		return 0, err
+	}
+	type stored struct {
+		id   int
+		name string
+	}
+	var plaintext []stored
+	for rows.Next() {
+		var s stored
+		if err := rows.Scan(&s.id, &s.name); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		plaintext = append(plaintext, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i, s := range plaintext {
+		if err := r.encryptPlaintextIntegration(s.id); err != nil {
+			return i, fmt.Errorf("integration %d (%s): %w", s.id, s.name, err)
+		}
+	}
+	return len(plaintext), nil
+}
+
+// encryptPlaintextIntegration gives an integration saved before encryption
+// was introduced a data key and encrypts its fields
+func (r *IntegrationRepository) encryptPlaintextIntegration(integrationID int) error {
+	key, wrapped, masterKeyID, err := r.vault.NewDataKey()
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT field_name, field_value FROM integration_fields
+		WHERE integration_id = $1 AND is_encrypted = false
+	`, integrationID)
+	if err != nil {
+		return err
+	}
+	fields := map[string]string{}
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			rows.Close()
+			return err
+		}
+		fields[name] = value
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Another instance may have encrypted the integration since it was listed
+	result, err := tx.Exec(`
+		UPDATE integrations SET data_key = $1, master_key_id = $2 WHERE id = $3 AND data_key IS NULL
+	`, wrapped, masterKeyID, integrationID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(`DELETE FROM integration_fields WHERE integration_id = $1`, integrationID); err != nil {
+		return err
+	}
+	for name, value := range fields {
+		if err := insertEncryptedField(tx, integrationID, key, name, value); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
 // GetAnalysisCache retrieves cached analysis for a provider URL
 func (r *IntegrationRepository) GetAnalysisCache(providerURL string) (string, error) {
 	var analysisData string
@@ -0,0 +1,129 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// JiraSyncRepository stores links between capabilities or enablers and Jira
+// issues, with each link's last synced state
+type JiraSyncRepository struct {
+	db *sql.DB
+}
+
+// NewJiraSyncRepository creates a new Jira sync repository
+func NewJiraSyncRepository(db *sql.DB) *JiraSyncRepository {
+	return &JiraSyncRepository{db: db}
+}
+
+const jiraSyncLinkColumns = `id, workspace_id, entity_type, entity_id, issue_key,
+	COALESCE(last_summary, ''), COALESCE(last_jira_status, ''),
+	COALESCE(last_name, ''), COALESCE(last_lifecycle_state, ''), COALESCE(last_workflow_stage, ''),
+	COALESCE(last_stage_status, ''), COALESCE(last_approval_status, ''),
+	last_synced_at, COALESCE(last_result, ''), COALESCE(last_message, ''), created_by, created_at`
+
+func scanJiraSyncLink(row interface{ Scan(...interface{}) error }) (*models.JiraSyncLink, error) {
+	var link models.JiraSyncLink
+	err := row.Scan(&link.ID, &link.WorkspaceID, &link.EntityType, &link.EntityID, &link.IssueKey,
+		&link.LastSummary, &link.LastJiraStatus,
+		&link.LastName, &link.LastLifecycleState, &link.LastWorkflowStage,
+		&link.LastStageStatus, &link.LastApprovalStatus,
+		&link.LastSyncedAt, &link.LastResult, &link.LastMessage, &link.CreatedBy, &link.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// CreateLink links an entity to a Jira issue, replacing the entity's
+// previous link. LastSummary and LastJiraStatus may be set when the Jira
+// side is already known, as for imported epics.
+func (r *JiraSyncRepository) CreateLink(link models.JiraSyncLink) (*models.JiraSyncLink, error) {
+	created, err := scanJiraSyncLink(r.db.QueryRow(`
+		INSERT INTO jira_sync_links (workspace_id, entity_type, entity_id, issue_key, last_summary, last_jira_status, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (entity_type, entity_id) DO UPDATE SET
+			workspace_id = EXCLUDED.workspace_id, issue_key = EXCLUDED.issue_key,
+			last_summary = EXCLUDED.last_summary, last_jira_status = EXCLUDED.last_jira_status,
+			last_name = NULL, last_lifecycle_state = NULL, last_workflow_stage = NULL,
+			last_stage_status = NULL, last_approval_status = NULL,
+			last_synced_at = NULL, last_result = NULL, last_message = NULL,
+			created_by = EXCLUDED.created_by, created_at = CURRENT_TIMESTAMP
+		RETURNING `+jiraSyncLinkColumns,
+		link.WorkspaceID, link.EntityType, link.EntityID, link.IssueKey,
+		nullIfEmpty(link.LastSummary), nullIfEmpty(link.LastJiraStatus), link.CreatedBy,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Jira link: %w", err)
+	}
+	return created, nil
+}
+
+// GetLink returns a link by ID
+func (r *JiraSyncRepository) GetLink(id int) (*models.JiraSyncLink, error) {
+	link, err := scanJiraSyncLink(r.db.QueryRow(`SELECT `+jiraSyncLinkColumns+` FROM jira_sync_links WHERE id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Jira link: %w", err)
+	}
+	return link, nil
+}
+
+// ListLinks returns the links of a workspace
+func (r *JiraSyncRepository) ListLinks(workspaceID string) ([]models.JiraSyncLink, error) {
+	rows, err := r.db.Query(`SELECT `+jiraSyncLinkColumns+` FROM jira_sync_links WHERE workspace_id = $1 ORDER BY entity_type, entity_id`, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Jira links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []models.JiraSyncLink{}
+	for rows.Next() {
+		link, err := scanJiraSyncLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan Jira link: %w", err)
+		}
+		links = append(links, *link)
+	}
+	return links, rows.Err()
+}
+
+// DeleteLink deletes a link; it returns sql.ErrNoRows if there is none
+func (r *JiraSyncRepository) DeleteLink(id int) error {
+	result, err := r.db.Exec(`DELETE FROM jira_sync_links WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete Jira link: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SaveSyncState stores a link's last synced state and result
+func (r *JiraSyncRepository) SaveSyncState(link *models.JiraSyncLink) error {
+	_, err := r.db.Exec(`
+		UPDATE jira_sync_links SET
+			last_summary = $1, last_jira_status = $2,
+			last_name = $3, last_lifecycle_state = $4, last_workflow_stage = $5,
+			last_stage_status = $6, last_approval_status = $7,
+			last_synced_at = $8, last_result = $9, last_message = $10
+		WHERE id = $11
+	`, nullIfEmpty(link.LastSummary), nullIfEmpty(link.LastJiraStatus),
+		nullIfEmpty(link.LastName), nullIfEmpty(link.LastLifecycleState), nullIfEmpty(link.LastWorkflowStage),
+		nullIfEmpty(link.LastStageStatus), nullIfEmpty(link.LastApprovalStatus),
+		link.LastSyncedAt, nullIfEmpty(link.LastResult), nullIfEmpty(link.LastMessage), link.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save Jira sync state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,318 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/lib/pq"
+)
+
+// WebhookRepository stores webhook subscriptions and their delivery queue.
+// It implements webhook.Store.
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// subscriptionColumns excludes the secret, which is only returned on create
+const subscriptionColumns = `id, workspace_id, name, url, events, format, is_active, created_by, created_at, updated_at`
+
+func scanSubscription(row interface{ Scan(...interface{}) error }) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	var events pq.StringArray
+	err := row.Scan(&sub.ID, &sub.WorkspaceID, &sub.Name, &sub.URL, &events, &sub.Format,
+		&sub.IsActive, &sub.CreatedBy, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	sub.Events = []string(events)
+	return &sub, nil
+}
+
+// CreateSubscription creates a webhook subscription. The returned
+// subscription is the only one that carries the secret.
+func (r *WebhookRepository) CreateSubscription(req models.CreateWebhookRequest, userID *int) (*models.WebhookSubscription, error) {
+	events := req.Events
+	if events == nil {
+		events = []string{}
+	}
+	sub, err := scanSubscription(r.db.QueryRow(`
+		INSERT INTO webhook_subscriptions (workspace_id, name, url, secret, events, format, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+subscriptionColumns,
+		req.WorkspaceID, req.Name, req.URL, req.Secret, pq.Array(events), req.Format, userID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	sub.Secret = req.Secret
+	return sub, nil
+}
+
+// GetSubscription returns a webhook subscription by ID
+func (r *WebhookRepository) GetSubscription(id int) (*models.WebhookSubscription, error) {
+	sub, err := scanSubscription(r.db.QueryRow(`SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return sub, nil
+}
+
+// ListSubscriptions returns the webhook subscriptions of a workspace
+func (r *WebhookRepository) ListSubscriptions(workspaceID string) ([]models.WebhookSubscription, error) {
+	rows, err := r.db.Query(`SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE workspace_id = $1 ORDER BY id`, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []models.WebhookSubscription{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		subs = append(subs, *sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read webhooks: %w", err)
+	}
+	return subs, nil
+}
+
+// UpdateSubscription changes the fields set in req
+func (r *WebhookRepository) UpdateSubscription(id int, req models.UpdateWebhookRequest) (*models.WebhookSubscription, error) {
+	query := "UPDATE webhook_subscriptions SET updated_at = CURRENT_TIMESTAMP"
+	args := []interface{}{}
+	argPos := 1
+
+	if req.Name != nil {
+		query += fmt.Sprintf(", name = $%d", argPos)
+		args = append(args, *req.Name)
+		argPos++
+	}
+	if req.URL != nil {
+		query += fmt.Sprintf(", url = $%d", argPos)
+		args = append(args, *req.URL)
+		argPos++
+	}
+	if req.Events != nil {
+		events := *req.Events
+		if events == nil {
+			events = []string{}
+		}
+		query += fmt.Sprintf(", events = $%d", argPos)
+		args = append(args, pq.Array(events))
+		argPos++
+	}
+	if req.Format != nil {
+		query += fmt.Sprintf(", format = $%d", argPos)
+		args = append(args, *req.Format)
+		argPos++
+	}
+	if req.IsActive != nil {
+		query += fmt.Sprintf(", is_active = $%d", argPos)
+		args = append(args, *req.IsActive)
+		argPos++
+	}
+
+	query += fmt.Sprintf(" WHERE id = $%d RETURNING %s", argPos, subscriptionColumns)
+	args = append(args, id)
+
+	sub, err := scanSubscription(r.db.QueryRow(query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return sub, nil
+}
+
+// DeleteSubscription deletes a webhook subscription and its delivery log
+func (r *WebhookRepository) DeleteSubscription(id int) error {
+	result, err := r.db.Exec(`DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("webhook %d not found", id)
+	}
+	return nil
+}
+
+// Enqueue queues event for every active subscription of its workspace that
+// wants its type, and returns the number of deliveries created
+func (r *WebhookRepository) Enqueue(event models.WebhookEvent, payload []byte) (int, error) {
+	result, err := r.db.Exec(`
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+		SELECT id, $2, $3::text, $4 FROM webhook_subscriptions
+		WHERE workspace_id = $1 AND is_active = true
+		  AND (cardinality(events) = 0 OR $3::text = ANY(events) OR '*' = ANY(events))
+	`, event.WorkspaceID, event.ID, event.Type, payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to queue webhook deliveries: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
+
+// EnqueueFor queues event for one subscription regardless of its filter
+func (r *WebhookRepository) EnqueueFor(subscriptionID int, event models.WebhookEvent, payload []byte) (*models.WebhookDelivery, error) {
+	var id int64
+	err := r.db.QueryRow(`
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, subscriptionID, event.ID, event.Type, payload).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue webhook delivery: %w", err)
+	}
+	return r.GetDelivery(id)
+}
+
+// ClaimDue locks up to limit deliveries that are due, marks them as sending
+// for lease and returns them with their subscription. A delivery whose lease
+// expires (the sender died) is claimed again. Safe across replicas.
+func (r *WebhookRepository) ClaimDue(limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	rows, err := r.db.Query(`
+		UPDATE webhook_deliveries d
+		SET status = 'sending', next_attempt_at = CURRENT_TIMESTAMP + $2::int * INTERVAL '1 second'
+		FROM (
+			SELECT id FROM webhook_deliveries
+			WHERE status IN ('pending', 'sending', 'retrying') AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		) due, webhook_subscriptions s
+		WHERE d.id = due.id AND s.id = d.subscription_id
+		RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.replay_of, d.created_at,
+		          s.workspace_id, s.name, s.url, s.secret, s.format
+	`, limit, int(lease.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		sub := &models.WebhookSubscription{}
+		var payload []byte
+		err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &payload, &d.Status, &d.Attempts, &d.ReplayOf, &d.CreatedAt,
+			&sub.WorkspaceID, &sub.Name, &sub.URL, &sub.Secret, &sub.Format)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		d.Payload = payload
+		sub.ID = d.SubscriptionID
+		d.Subscription = sub
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// RecordAttempt stores the outcome of sending a delivery
+func (r *WebhookRepository) RecordAttempt(deliveryID int64, a models.WebhookAttempt) error {
+	var responseStatus, retryIn *int
+	if a.ResponseStatus != 0 {
+		responseStatus = &a.ResponseStatus
+	}
+	if a.Status == models.WebhookDeliveryRetrying {
+		seconds := int(a.RetryIn.Seconds())
+		retryIn = &seconds
+	}
+	_, err := r.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = attempts + 1, last_attempt_at = CURRENT_TIMESTAMP,
+		    response_status = $3, response_body = $4, error = NULLIF($5, ''), duration_ms = $6,
+		    next_attempt_at = CURRENT_TIMESTAMP + $7::int * INTERVAL '1 second'
+		WHERE id = $1
+	`, deliveryID, a.Status, responseStatus, a.ResponseBody, a.Error, a.Duration.Milliseconds(), retryIn)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+	return nil
+}
+
+const deliveryColumns = `id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_attempt_at,
+	response_status, COALESCE(response_body, ''), COALESCE(error, ''), duration_ms, replay_of, created_at`
+
+func scanDelivery(row interface{ Scan(...interface{}) error }) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	var payload []byte
+	err := row.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &payload, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastAttemptAt, &d.ResponseStatus, &d.ResponseBody, &d.Error, &d.DurationMs,
+		&d.ReplayOf, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	d.Payload = payload
+	return &d, nil
+}
+
+// GetDelivery returns a delivery by ID
+func (r *WebhookRepository) GetDelivery(id int64) (*models.WebhookDelivery, error) {
+	d, err := scanDelivery(r.db.QueryRow(`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	return d, nil
+}
+
+// ListDeliveries returns the newest deliveries of a subscription, optionally
+// filtered by status
+func (r *WebhookRepository) ListDeliveries(subscriptionID int, status string, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := r.db.Query(`
+		SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE subscription_id = $1 AND ($2::text = '' OR status = $2::text)
+		ORDER BY id DESC
+		LIMIT $3
+	`, subscriptionID, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// ReplayDelivery queues a new delivery of the same event to the same
+// subscription. The event ID is kept so receivers can de-duplicate.
+func (r *WebhookRepository) ReplayDelivery(id int64) (*models.WebhookDelivery, error) {
+	var newID int64
+	err := r.db.QueryRow(`
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, replay_of)
+		SELECT subscription_id, event_id, event_type, payload, id FROM webhook_deliveries WHERE id = $1
+		RETURNING id
+	`, id).Scan(&newID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay webhook delivery: %w", err)
+	}
+	return r.GetDelivery(newID)
+}
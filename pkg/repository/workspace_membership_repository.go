@@ -0,0 +1,220 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jareynolds/intentr/pkg/models"
+	"github.com/lib/pq"
+)
+
+// unmanagedWorkspaceRole is everyone's role in a workspace without members.
+// Workspaces get an owner when they are created, and workspaces from before
+// workspace roles existed get the owner their .intentrworkspace file names
+// (see ClaimWorkspaceOwner), so any left are only for admins until they add
+// members.
+const unmanagedWorkspaceRole models.WorkspaceRole = ""
+
+// globalWorkspaceRole is everyone's role in data outside any workspace
+const globalWorkspaceRole = models.WorkspaceRoleViewer
+
+// approverWorkspaceRoles are the membership roles that allow approving
+var approverWorkspaceRoles = []string{string(models.WorkspaceRoleApprover), string(models.WorkspaceRoleOwner)}
+
+// WorkspaceMembershipRepository resolves users' roles in workspaces
+type WorkspaceMembershipRepository struct {
+	db *sql.DB
+}
+
+// NewWorkspaceMembershipRepository creates a new workspace membership repository
+func NewWorkspaceMembershipRepository(db *sql.DB) *WorkspaceMembershipRepository {
+	return &WorkspaceMembershipRepository{db: db}
+}
+
+// workspaceRole returns a user's role in a workspace: owner for global admins,
+// the membership's role for members, globalWorkspaceRole without a workspace
+// ID, unmanagedWorkspaceRole when the workspace has no members, and no role
+// otherwise. Inactive and unknown users have no role.
+func workspaceRole(q querier, workspaceID string, userID int) (models.WorkspaceRole, error) {
+	var isActive, isAdmin, managed bool
+	var role sql.NullString
+	err := q.QueryRow(`
+		SELECT u.is_active, u.role = 'admin',
+		       (SELECT m.role FROM workspace_memberships m WHERE m.workspace_id = $1 AND m.user_id = u.id),
+		       EXISTS (SELECT 1 FROM workspace_memberships m WHERE m.workspace_id = $1)
+		FROM users u
+		WHERE u.id = $2
+	`, workspaceID, userID).Scan(&isActive, &isAdmin, &role, &managed)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get workspace role: %w", err)
+	}
+
+	switch {
+	case !isActive:
+		return "", nil
+	case isAdmin:
+		return models.WorkspaceRoleOwner, nil
+	case role.Valid:
+		return models.WorkspaceRole(role.String), nil
+	case workspaceID == "":
+		return globalWorkspaceRole, nil
+	case !managed:
+		return unmanagedWorkspaceRole, nil
+	}
+	return "", nil
+}
+
+// requireWorkspaceRole returns a *models.WorkspaceAccessError unless the
+// user's role in the workspace includes the required role
+func requireWorkspaceRole(q querier, workspaceID string, userID int, required models.WorkspaceRole) error {
+	role, err := workspaceRole(q, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if !role.Allows(required) {
+		return &models.WorkspaceAccessError{WorkspaceID: workspaceID, UserID: userID, Role: role, RequiredRole: required}
+	}
+	return nil
+}
+
+// Role returns a user's role in a workspace; empty means no access
+func (r *WorkspaceMembershipRepository) Role(workspaceID string, userID int) (models.WorkspaceRole, error) {
+	return workspaceRole(r.db, workspaceID, userID)
+}
+
+// workspaceInUse reports whether any table but workspace_memberships holds
+// rows of a workspace, i.e. whether the workspace already exists
+func workspaceInUse(q querier, workspaceID string) (bool, error) {
+	rows, err := q.Query(`
+		SELECT table_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND column_name = 'workspace_id'
+		  AND data_type IN ('character varying', 'text') AND table_name <> 'workspace_memberships'
+	`)
+	if err != nil {
+		return false, fmt.Errorf("failed to list workspace tables: %w", err)
+	}
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			rows.Close()
+			return false, fmt.Errorf("failed to scan workspace table: %w", err)
+		}
+		tables = append(tables, table)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("failed to list workspace tables: %w", err)
+	}
+
+	for _, table := range tables {
+		var used bool
+		query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE workspace_id = $1)", pq.QuoteIdentifier(table))
+		if err := q.QueryRow(query, workspaceID).Scan(&used); err != nil {
+			return false, fmt.Errorf("failed to check %s for workspace rows: %w", table, err)
+		}
+		if used {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ClaimWorkspace records a user as the owner of a new workspace, one without
+// members or rows. For a workspace that has members it returns a
+// *models.WorkspaceAccessError unless the user already owns it; a workspace
+// that has rows but no members can only be claimed by admins, who are not
+// recorded as its owner.
+func (r *WorkspaceMembershipRepository) ClaimWorkspace(workspaceID string, userID int) error {
+	return r.claim(workspaceID, userID, false)
+}
+
+// ClaimWorkspaceOwner records the owner named by the .intentrworkspace file
+// of a workspace from before workspace roles existed, matched by email.
+// Workspaces with members, and emails of no active user, are left alone.
+// It reports whether an owner was recorded.
+func (r *WorkspaceMembershipRepository) ClaimWorkspaceOwner(workspaceID, ownerEmail string) (bool, error) {
+	if workspaceID == "" || ownerEmail == "" {
+		return false, nil
+	}
+	var userID int
+	err := r.db.QueryRow("SELECT id FROM users WHERE LOWER(email) = LOWER($1) AND is_active = true", ownerEmail).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to find workspace owner: %w", err)
+	}
+
+	err = r.claim(workspaceID, userID, true)
+	var accessErr *models.WorkspaceAccessError
+	if errors.As(err, &accessErr) {
+		return false, nil // Already has members
+	}
+	return err == nil, err
+}
+
+// claim records a user as the owner of a workspace without members. Unless
+// existing is set, a workspace that already has rows is treated as taken.
+func (r *WorkspaceMembershipRepository) claim(workspaceID string, userID int, existing bool) error {
+	if workspaceID == "" {
+		return fmt.Errorf("workspace ID is required")
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Serialize with other changes to the workspace's members
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('workspace_memberships:' || $1))", workspaceID); err != nil {
+		return fmt.Errorf("failed to lock workspace members: %w", err)
+	}
+
+	var managed bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM workspace_memberships WHERE workspace_id = $1)", workspaceID).Scan(&managed)
+	if err != nil {
+		return fmt.Errorf("failed to check workspace members: %w", err)
+	}
+	if managed {
+		return requireWorkspaceRole(tx, workspaceID, userID, models.WorkspaceRoleOwner)
+	}
+	if !existing {
+		// Reusing the ID of a workspace from before workspace roles
+		// existed would take it over
+		inUse, err := workspaceInUse(tx, workspaceID)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return requireWorkspaceRole(tx, workspaceID, userID, models.WorkspaceRoleOwner)
+		}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO workspace_memberships (workspace_id, user_id, role, granted_by)
+		SELECT $1, id, $3, id FROM users WHERE id = $2 AND is_active = true
+	`, workspaceID, userID, models.WorkspaceRoleOwner)
+	if err != nil {
+		return fmt.Errorf("failed to record workspace owner: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Require returns a *models.WorkspaceAccessError unless the user's role in
+// the workspace includes the required role
+func (r *WorkspaceMembershipRepository) Require(workspaceID string, userID int, required models.WorkspaceRole) error {
+	return requireWorkspaceRole(r.db, workspaceID, userID, required)
+}
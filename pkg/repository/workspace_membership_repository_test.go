@@ -0,0 +1,86 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jareynolds/intentr/internal/testdb"
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+func TestClaimWorkspace(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewWorkspaceMembershipRepository(db)
+	creator := testdb.CreateUser(t, db, "creator", "user")
+	other := testdb.CreateUser(t, db, "other", "user")
+
+	// A workspace without members is only open to admins
+	if role, err := repo.Role("workspace-1", other); err != nil || role != "" {
+		t.Fatalf("Role(unmanaged) = %q, %v; want no access", role, err)
+	}
+
+	if err := repo.ClaimWorkspace("workspace-1", creator); err != nil {
+		t.Fatalf("ClaimWorkspace(new) error = %v", err)
+	}
+	if role, _ := repo.Role("workspace-1", creator); role != models.WorkspaceRoleOwner {
+		t.Errorf("creator role = %q, want owner", role)
+	}
+	if role, _ := repo.Role("workspace-1", other); role != "" {
+		t.Errorf("other role = %q, want no access", role)
+	}
+
+	// Claiming again is a no-op for owners and refused for anyone else
+	if err := repo.ClaimWorkspace("workspace-1", creator); err != nil {
+		t.Errorf("ClaimWorkspace(owner) error = %v", err)
+	}
+	var accessErr *models.WorkspaceAccessError
+	if err := repo.ClaimWorkspace("workspace-1", other); !errors.As(err, &accessErr) {
+		t.Errorf("ClaimWorkspace(taken) error = %v, want a WorkspaceAccessError", err)
+	}
+}
+
+func TestClaimWorkspaceInUse(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewWorkspaceMembershipRepository(db)
+	owner := testdb.CreateUser(t, db, "owner", "user")
+	attacker := testdb.CreateUser(t, db, "attacker", "user")
+	admin := testdb.CreateUser(t, db, "admin", "admin")
+
+	// A workspace from before workspace roles existed has rows but no members
+	if _, err := db.Exec(`INSERT INTO capabilities (capability_id, name, workspace_id) VALUES ('CAP-100001', 'Checkout', 'legacy')`); err != nil {
+		t.Fatal(err)
+	}
+
+	var accessErr *models.WorkspaceAccessError
+	if err := repo.ClaimWorkspace("legacy", attacker); !errors.As(err, &accessErr) {
+		t.Fatalf("ClaimWorkspace(in use) error = %v, want a WorkspaceAccessError", err)
+	}
+	if err := repo.ClaimWorkspace("legacy", admin); err != nil {
+		t.Errorf("ClaimWorkspace(in use) by an admin error = %v", err)
+	}
+	if role, _ := repo.Role("legacy", attacker); role != "" {
+		t.Errorf("attacker role = %q, want no access", role)
+	}
+
+	// The owner its .intentrworkspace file names is recorded once
+	if ok, err := repo.ClaimWorkspaceOwner("legacy", "OWNER@example.com"); err != nil || !ok {
+		t.Fatalf("ClaimWorkspaceOwner() = %v, %v; want true", ok, err)
+	}
+	if role, _ := repo.Role("legacy", owner); role != models.WorkspaceRoleOwner {
+		t.Errorf("owner role = %q, want owner", role)
+	}
+	if ok, err := repo.ClaimWorkspaceOwner("legacy", "attacker@example.com"); err != nil || ok {
+		t.Errorf("ClaimWorkspaceOwner(managed) = %v, %v; want false", ok, err)
+	}
+	if ok, err := repo.ClaimWorkspaceOwner("other", "nobody@example.com"); err != nil || ok {
+		t.Errorf("ClaimWorkspaceOwner(unknown email) = %v, %v; want false", ok, err)
+	}
+}
@@ -0,0 +1,100 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package spec
+
+import "strings"
+
+// Capability is the typed view of a CAP-*.md specification
+type Capability struct {
+	Doc                 *Document
+	Name                string
+	ID                  string
+	Status              string
+	Description         string
+	StoryboardReference string
+	PrimaryPersona      string
+	// Sections maps every ## and ### heading to its own body text
+	Sections map[string]string
+}
+
+// capabilityFieldNames are metadata lines lifted out of section text
+var capabilityFieldNames = []string{"Status", "Storyboard Reference", "Primary Persona"}
+
+// ParseCapability parses a capability document. Content may be a whole file
+// or a block split from a multi-capability file (without its # heading).
+func ParseCapability(content string) *Capability {
+	doc := Parse(content)
+	c := &Capability{
+		Doc:      doc,
+		Name:     doc.Title(),
+		ID:       doc.FieldValue("ID"),
+		Sections: make(map[string]string),
+	}
+
+	code := doc.codeLines()
+	fieldLines := doc.fieldLines()
+	var current string
+	var body []string
+	flush := func() {
+		if current != "" && len(body) > 0 {
+			c.Sections[current] = strings.TrimSpace(strings.Join(body, "\n"))
+		}
+	}
+
+	for i, line := range doc.lines {
+		trimmed := strings.TrimSpace(line)
+		if !code[i] {
+			if level, title, ok := parseHeading(line); ok && (level == 2 || level == 3) {
+				flush()
+				current = title
+				body = nil
+				continue
+			}
+			if name, value, ok := matchAnyField(line, capabilityFieldNames); ok && fieldLines[i] {
+				switch name {
+				case "Status":
+					c.Status = value
+				case "Storyboard Reference":
+					c.StoryboardReference = value
+				case "Primary Persona":
+					c.PrimaryPersona = value
+				}
+				continue
+			}
+			// Inline "**Description:** ..." lines (legacy format)
+			if at, ok := matchField(line, "Description"); ok && fieldLines[i] {
+				c.Description = strings.TrimSpace(line[at:])
+				continue
+			}
+		}
+
+		if current != "" {
+			body = append(body, line)
+		} else if c.Description == "" && trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			// Use first non-empty, non-header line as description if not set
+			c.Description = trimmed
+		}
+	}
+	flush()
+
+	if c.Description == "" {
+		c.Description = c.Sections["Description"]
+	}
+	return c
+}
+
+// matchAnyField reports which of names (if any) the line holds, with its value
+func matchAnyField(line string, names []string) (string, string, bool) {
+	for _, name := range names {
+		if at, ok := matchField(line, name); ok {
+			return name, strings.TrimSpace(line[at:]), true
+		}
+	}
+	return "", "", false
+}
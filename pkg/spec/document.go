@@ -0,0 +1,606 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Package spec provides a lossless document model for INTENT specification
+// files (capabilities, enablers, epics, themes, features, stories and test
+// scenarios). A Document keeps every source line verbatim, so serializing an
+// unmodified document reproduces the input byte-for-byte, and edits only touch
+// the lines that belong to the field or section being changed.
+package spec
+
+import (
+	"strings"
+)
+
+// MetadataSection is the heading under which metadata fields are written
+const MetadataSection = "Metadata"
+
+// Document is a Markdown specification file held as raw lines
+type Document struct {
+	lines []string
+}
+
+// Section describes a heading and the line range it covers
+type Section struct {
+	Level int    // Heading level (1 for "#", 2 for "##", ...)
+	Title string // Heading text without the leading hashes
+	Line  int    // Index of the heading line
+	End   int    // Exclusive end, including nested subsections
+	Own   int    // Exclusive end of the section's own body (before the first child heading)
+}
+
+// Field is a metadata line such as "- **Status**: Draft"
+type Field struct {
+	Name  string
+	Value string
+	Line  int // Index of the line the field was found on
+}
+
+// Parse builds a Document from Markdown content
+func Parse(content string) *Document {
+	return &Document{lines: strings.Split(content, "\n")}
+}
+
+// String serializes the document back to Markdown
+func (d *Document) String() string {
+	return strings.Join(d.lines, "\n")
+}
+
+// Lines returns a copy of the document's raw lines
+func (d *Document) Lines() []string {
+	out := make([]string, len(d.lines))
+	copy(out, d.lines)
+	return out
+}
+
+// parseHeading reports the level and title of an ATX heading line
+func parseHeading(line string) (int, string, bool) {
+	trimmed := strings.TrimSpace(line)
+	level := 0
+	for level < len(trimmed) && trimmed[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0, "", false
+	}
+	if level == len(trimmed) {
+		return level, "", true
+	}
+	if trimmed[level] != ' ' && trimmed[level] != '\t' {
+		return 0, "", false
+	}
+	return level, strings.TrimSpace(trimmed[level:]), true
+}
+
+// isFence reports whether a line opens or closes a fenced code block
+func isFence(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// isBlank reports whether a line contains only whitespace
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// codeLines marks every line that sits inside (or delimits) a fenced code block
+func (d *Document) codeLines() []bool {
+	marks := make([]bool, len(d.lines))
+	inFence := false
+	for i, line := range d.lines {
+		if isFence(line) {
+			marks[i] = true
+			inFence = !inFence
+			continue
+		}
+		marks[i] = inFence
+	}
+	return marks
+}
+
+// Sections returns every heading in document order. Headings inside fenced
+// code blocks are ignored.
+func (d *Document) Sections() []Section {
+	code := d.codeLines()
+	var sections []Section
+	for i, line := range d.lines {
+		if code[i] {
+			continue
+		}
+		if level, title, ok := parseHeading(line); ok {
+			sections = append(sections, Section{Level: level, Title: title, Line: i, End: len(d.lines), Own: len(d.lines)})
+		}
+	}
+	for i := range sections {
+		if i+1 < len(sections) {
+			sections[i].Own = sections[i+1].Line
+		}
+		for j := i + 1; j < len(sections); j++ {
+			if sections[j].Level <= sections[i].Level {
+				sections[i].End = sections[j].Line
+				break
+			}
+		}
+	}
+	return sections
+}
+
+// Title returns the text of the first level-1 heading
+func (d *Document) Title() string {
+	for _, s := range d.Sections() {
+		if s.Level == 1 {
+			return s.Title
+		}
+	}
+	return ""
+}
+
+// SetTitle replaces the first level-1 heading, inserting one if the document has none
+func (d *Document) SetTitle(title string) {
+	for _, s := range d.Sections() {
+		if s.Level == 1 {
+			d.lines[s.Line] = "# " + title
+			return
+		}
+	}
+	d.insert(0, "# "+title, "")
+}
+
+// Section returns the first section whose title matches (case-insensitive)
+func (d *Document) Section(title string) (Section, bool) {
+	for _, s := range d.Sections() {
+		if strings.EqualFold(s.Title, title) {
+			return s, true
+		}
+	}
+	return Section{}, false
+}
+
+// Subsection returns the first section titled title nested under parent
+func (d *Document) Subsection(parent, title string) (Section, bool) {
+	p, ok := d.Section(parent)
+	if !ok {
+		return Section{}, false
+	}
+	for _, s := range d.Sections() {
+		if s.Line > p.Line && s.Line < p.End && strings.EqualFold(s.Title, title) {
+			return s, true
+		}
+	}
+	return Section{}, false
+}
+
+// Body returns the trimmed text of a section, including nested subsections
+func (d *Document) Body(s Section) string {
+	return strings.TrimSpace(strings.Join(d.lines[s.Line+1:s.End], "\n"))
+}
+
+// OwnBody returns the trimmed text of a section up to its first child heading
+func (d *Document) OwnBody(s Section) string {
+	return strings.TrimSpace(strings.Join(d.lines[s.Line+1:s.Own], "\n"))
+}
+
+// SectionBody returns the body of the first section with the given title
+func (d *Document) SectionBody(title string) (string, bool) {
+	s, ok := d.Section(title)
+	if !ok {
+		return "", false
+	}
+	return d.Body(s), true
+}
+
+// SetSectionBody replaces the body of a section, appending a new section at
+// the given level when no section with that title exists. Blank lines that
+// separate the section from its neighbours are preserved.
+func (d *Document) SetSectionBody(title string, level int, body string) {
+	if s, ok := d.Section(title); ok {
+		d.replaceBody(s, body)
+		return
+	}
+	d.appendSection(len(d.lines), level, title, body)
+}
+
+// SetSubsectionBody replaces the body of title nested under parent. Missing
+// subsections are added at the end of the parent; a missing parent is
+// appended to the document first.
+func (d *Document) SetSubsectionBody(parent, title, body string) {
+	if s, ok := d.Subsection(parent, title); ok {
+		d.replaceBody(s, body)
+		return
+	}
+	p, ok := d.Section(parent)
+	if !ok {
+		d.appendSection(len(d.lines), 2, parent, "")
+		p, _ = d.Section(parent)
+	}
+	d.appendSection(p.End, p.Level+1, title, body)
+}
+
+// RemoveSection deletes the first section with the given title, including its subsections
+func (d *Document) RemoveSection(title string) bool {
+	s, ok := d.Section(title)
+	if !ok {
+		return false
+	}
+	d.lines = append(d.lines[:s.Line], d.lines[s.End:]...)
+	return true
+}
+
+// replaceBody swaps the lines under a heading while keeping the blank-line
+// layout around the old body
+func (d *Document) replaceBody(s Section, body string) {
+	old := d.lines[s.Line+1 : s.End]
+	lead, trail := 0, 0
+	for lead < len(old) && isBlank(old[lead]) {
+		lead++
+	}
+	if lead == len(old) {
+		// Body was empty: keep one separating blank line before the next heading
+		trail = lead
+		lead = 0
+	} else {
+		for trail < len(old)-lead && isBlank(old[len(old)-1-trail]) {
+			trail++
+		}
+		if lead > 1 {
+			lead = 1
+		}
+	}
+	if trail == 0 && s.End < len(d.lines) {
+		trail = 1
+	}
+
+	var replacement []string
+	if body = strings.Trim(body, "\n"); body == "" {
+		// A cleared section keeps a single blank line before the next heading
+		lead = 0
+		if trail > 1 {
+			trail = 1
+		}
+	}
+	for i := 0; i < lead; i++ {
+		replacement = append(replacement, "")
+	}
+	if body != "" {
+		replacement = append(replacement, strings.Split(body, "\n")...)
+	}
+	for i := 0; i < trail; i++ {
+		replacement = append(replacement, "")
+	}
+	d.splice(s.Line+1, s.End, replacement)
+}
+
+// appendSection inserts a new heading and body at line index at
+func (d *Document) appendSection(at, level int, title, body string) {
+	if at == len(d.lines) && at > 0 && d.lines[at-1] == "" {
+		at-- // keep the file's trailing newline at the very end
+	}
+	var block []string
+	if at > 0 && !isBlank(d.lines[at-1]) {
+		block = append(block, "")
+	}
+	block = append(block, strings.Repeat("#", level)+" "+title)
+	if level <= 2 {
+		block = append(block, "")
+	}
+	if body = strings.Trim(body, "\n"); body != "" {
+		block = append(block, strings.Split(body, "\n")...)
+	}
+	if at < len(d.lines) && !isBlank(d.lines[at]) {
+		block = append(block, "")
+	}
+	d.insert(at, block...)
+}
+
+// insert places lines before index at
+func (d *Document) insert(at int, lines ...string) {
+	d.splice(at, at, lines)
+}
+
+// splice replaces lines[from:to] with replacement
+func (d *Document) splice(from, to int, replacement []string) {
+	out := make([]string, 0, len(d.lines)-(to-from)+len(replacement))
+	out = append(out, d.lines[:from]...)
+	out = append(out, replacement...)
+	out = append(out, d.lines[to:]...)
+	d.lines = out
+}
+
+// fieldStyle captures how a metadata line is written so new fields can match it
+type fieldStyle struct {
+	bullet      string // "- ", "* " or ""
+	colonInside bool   // "**Name:**" instead of "**Name**:"
+}
+
+func (st fieldStyle) format(name, value string) string {
+	if st.colonInside {
+		return st.bullet + "**" + name + ":** " + value
+	}
+	return st.bullet + "**" + name + "**: " + value
+}
+
+// isListItem reports whether a line is a "- " or "* " bullet
+func isListItem(line string) bool {
+	trimmed := strings.TrimLeft(line, " \t")
+	return strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ")
+}
+
+// fieldSections are the sections whose lines are all fields
+var fieldSections = []string{MetadataSection, WSJFSection}
+
+// fieldLines marks the lines that may hold fields: list items, the text above
+// the first ## heading and the lines of the fieldSections. Other prose such as
+// "Note: ..." is never read as a field.
+func (d *Document) fieldLines() []bool {
+	code := d.codeLines()
+	preamble := len(d.lines)
+	for _, s := range d.Sections() {
+		if s.Level > 1 {
+			preamble = s.Line
+			break
+		}
+	}
+
+	marks := make([]bool, len(d.lines))
+	for i, line := range d.lines {
+		marks[i] = i < preamble || isListItem(line)
+	}
+	for _, name := range fieldSections {
+		if s, ok := d.Section(name); ok {
+			for i := s.Line + 1; i < s.End; i++ {
+				marks[i] = true
+			}
+		}
+	}
+	for i := range marks {
+		marks[i] = marks[i] && !code[i]
+	}
+	return marks
+}
+
+// matchField checks whether line holds the named field. It accepts
+// "**Name**: v", "**Name:** v" and "Name: v", each optionally bulleted, and
+// returns the byte offset where the value starts. Callers check the line is
+// one fieldLines allows.
+func matchField(line, name string) (int, bool) {
+	i := len(line) - len(strings.TrimLeft(line, " \t"))
+	rest := line[i:]
+	if strings.HasPrefix(rest, "- ") || strings.HasPrefix(rest, "* ") {
+		i += 2
+		rest = line[i:]
+	}
+	for _, marker := range []string{"**" + name + "**:", "**" + name + ":**", name + ":"} {
+		if len(rest) >= len(marker) && strings.EqualFold(rest[:len(marker)], marker) {
+			j := i + len(marker)
+			for j < len(line) && (line[j] == ' ' || line[j] == '\t') {
+				j++
+			}
+			return j, true
+		}
+	}
+	return 0, false
+}
+
+// parseBoldField extracts the name and value from "**Name**: v" or "**Name:** v"
+func parseBoldField(line string) (string, string, bool) {
+	trimmed := strings.TrimSpace(line)
+	trimmed = strings.TrimPrefix(strings.TrimPrefix(trimmed, "- "), "* ")
+	if !strings.HasPrefix(trimmed, "**") {
+		return "", "", false
+	}
+	rest := trimmed[2:]
+	end := strings.Index(rest, "**")
+	if end <= 0 {
+		return "", "", false
+	}
+	name := rest[:end]
+	after := rest[end+2:]
+	switch {
+	case strings.HasSuffix(name, ":"):
+		name = strings.TrimSuffix(name, ":")
+	case strings.HasPrefix(after, ":"):
+		after = after[1:]
+	default:
+		return "", "", false
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", "", false
+	}
+	return name, strings.TrimSpace(after), true
+}
+
+// styleOf returns the formatting of an existing field line
+func styleOf(line string) fieldStyle {
+	trimmed := strings.TrimSpace(line)
+	st := fieldStyle{}
+	if strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") {
+		st.bullet = trimmed[:2]
+		trimmed = trimmed[2:]
+	}
+	if strings.HasPrefix(trimmed, "**") {
+		if end := strings.Index(trimmed[2:], "**"); end > 0 && strings.HasSuffix(trimmed[2:2+end], ":") {
+			st.colonInside = true
+		}
+	}
+	return st
+}
+
+// fieldLine returns the index of the first line holding the named field
+func (d *Document) fieldLine(name string) (int, int, bool) {
+	allowed := d.fieldLines()
+	for i, line := range d.lines {
+		if !allowed[i] {
+			continue
+		}
+		if at, ok := matchField(line, name); ok {
+			return i, at, true
+		}
+	}
+	return 0, 0, false
+}
+
+// Field returns the value of the first metadata line for name
+func (d *Document) Field(name string) (string, bool) {
+	i, at, ok := d.fieldLine(name)
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSpace(d.lines[i][at:]), true
+}
+
+// FieldLine returns the index of the first line holding the named field
+func (d *Document) FieldLine(name string) (int, bool) {
+	i, _, ok := d.fieldLine(name)
+	return i, ok
+}
+
+// FieldValue returns the value for name, or "" when it is not present
+func (d *Document) FieldValue(name string) string {
+	v, _ := d.Field(name)
+	return v
+}
+
+// Fields returns every bold "**Name**: value" line outside code blocks
+func (d *Document) Fields() []Field {
+	code := d.codeLines()
+	var fields []Field
+	for i, line := range d.lines {
+		if code[i] {
+			continue
+		}
+		if name, value, ok := parseBoldField(line); ok {
+			fields = append(fields, Field{Name: name, Value: value, Line: i})
+		}
+	}
+	return fields
+}
+
+// SetField updates a metadata field in place, keeping the line's formatting.
+// New fields are added to the end of the Metadata section.
+func (d *Document) SetField(name, value string) {
+	d.SetFieldIn(MetadataSection, name, value)
+}
+
+// SetFieldIn updates a field in place or adds it to the end of section
+func (d *Document) SetFieldIn(section, name, value string) {
+	if i, at, ok := d.fieldLine(name); ok {
+		prefix := d.lines[i][:at]
+		if !strings.HasSuffix(prefix, " ") && !strings.HasSuffix(prefix, "\t") {
+			prefix += " "
+		}
+		eol := ""
+		if strings.HasSuffix(d.lines[i], "\r") {
+			eol = "\r" // keep CRLF line endings
+		}
+		d.lines[i] = prefix + value + eol
+		return
+	}
+
+	style := fieldStyle{bullet: "- "}
+	if fields := d.Fields(); len(fields) > 0 {
+		style = styleOf(d.lines[fields[0].Line])
+	}
+	line := style.format(name, value)
+
+	s, ok := d.Section(section)
+	if !ok {
+		if section != MetadataSection {
+			d.appendSection(len(d.lines), 2, section, line)
+			return
+		}
+		// Metadata goes directly below the title
+		at := 0
+		for _, h := range d.Sections() {
+			if h.Level == 1 {
+				at = h.Line + 1
+				break
+			}
+		}
+		if at == 0 {
+			d.insert(0, "## "+MetadataSection, "", line, "")
+		} else {
+			d.insert(at, "", "## "+MetadataSection, "", line)
+		}
+		return
+	}
+
+	// Insert after the last field line in the section's own body, or at the
+	// top of the body when the section has no fields yet
+	at := -1
+	for _, f := range d.Fields() {
+		if f.Line > s.Line && f.Line < s.Own {
+			at = f.Line + 1
+		}
+	}
+	if at != -1 {
+		d.insert(at, line)
+		return
+	}
+	at = s.Line + 1
+	if at < len(d.lines)-1 && isBlank(d.lines[at]) {
+		at++
+	}
+	block := []string{line}
+	if at < len(d.lines) && !isBlank(d.lines[at]) {
+		block = append(block, "")
+	}
+	d.insert(at, block...)
+}
+
+// RemoveField deletes the first line holding the named field
+func (d *Document) RemoveField(name string) bool {
+	i, _, ok := d.fieldLine(name)
+	if !ok {
+		return false
+	}
+	d.splice(i, i+1, nil)
+	return true
+}
+
+// CodeBlock returns the contents of the first fenced block with the given
+// info string (for example "gherkin")
+func (d *Document) CodeBlock(lang string) (string, bool) {
+	start, end, ok := d.codeBlock(lang)
+	if !ok {
+		return "", false
+	}
+	return strings.Join(d.lines[start+1:end], "\n"), true
+}
+
+// SetCodeBlock replaces the contents of the first fenced block with the given info string
+func (d *Document) SetCodeBlock(lang, content string) bool {
+	start, end, ok := d.codeBlock(lang)
+	if !ok {
+		return false
+	}
+	d.splice(start+1, end, strings.Split(strings.Trim(content, "\n"), "\n"))
+	return true
+}
+
+// codeBlock locates the opening and closing fence lines of a fenced block
+func (d *Document) codeBlock(lang string) (int, int, bool) {
+	start := -1
+	for i := 0; i < len(d.lines); i++ {
+		if !isFence(d.lines[i]) {
+			continue
+		}
+		if start != -1 {
+			return start, i, true
+		}
+		info := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(d.lines[i]), "`~"))
+		if strings.EqualFold(info, lang) {
+			start = i
+			continue
+		}
+		// Skip over unrelated blocks
+		for i++; i < len(d.lines) && !isFence(d.lines[i]); i++ {
+		}
+	}
+	return 0, 0, false
+}
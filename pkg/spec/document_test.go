@@ -0,0 +1,246 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package spec
+
+import (
+	"strings"
+	"testing"
+)
+
+const capabilityFixture = `# User Authentication
+
+## Metadata
+
+- **ID**: CAP-100001
+- **Name**: User Authentication
+- **Type**: Capability
+- **Status**: Draft
+
+## Business Context
+
+### Description
+Users sign in with email and password.
+
+### Value Proposition
+Secure access.
+
+## Hand-written Notes
+
+Keep this paragraph exactly as it is.
+
+` + "```bash\n# not a heading\n**Status**: not a field\n```" + `
+`
+
+func TestRoundTripIsLossless(t *testing.T) {
+	inputs := []string{
+		capabilityFixture,
+		"",
+		"no newline at end",
+		"# Title\r\n\r\n- **ID**: CAP-1\r\n",
+		"\n\n## Only Section\n\n\n",
+	}
+	for _, in := range inputs {
+		if out := Parse(in).String(); out != in {
+			t.Errorf("round trip changed content:\nwant %q\ngot  %q", in, out)
+		}
+	}
+}
+
+func TestEditsKeepSurroundingLines(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(*Document)
+		old  string
+		new  string
+	}{
+		{
+			name: "field",
+			edit: func(d *Document) { d.SetField("Status", "Approved") },
+			old:  "- **Status**: Draft\n",
+			new:  "- **Status**: Approved\n",
+		},
+		{
+			name: "subsection",
+			edit: func(d *Document) {
+				d.SetSubsectionBody("Business Context", "Description", "Users sign in with a passkey.")
+			},
+			old: "Users sign in with email and password.\n",
+			new: "Users sign in with a passkey.\n",
+		},
+		{
+			name: "section",
+			edit: func(d *Document) { d.SetSectionBody("Hand-written Notes", 2, "Replaced.") },
+			old:  "\nKeep this paragraph exactly as it is.\n\n```bash\n# not a heading\n**Status**: not a field\n```\n",
+			new:  "\nReplaced.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := Parse(capabilityFixture)
+			tt.edit(doc)
+			want := strings.Replace(capabilityFixture, tt.old, tt.new, 1)
+			if got := doc.String(); got != want {
+				t.Errorf("edit changed other lines:\nwant %q\ngot  %q", want, got)
+			}
+		})
+	}
+
+	// Editing a CRLF document keeps its line endings
+	doc := Parse("# Title\r\n\r\n- **ID**: CAP-1\r\n- **Status**: Draft\r\n\r\nNotes\r\n")
+	doc.SetField("Status", "Approved")
+	if got, want := doc.String(), "# Title\r\n\r\n- **ID**: CAP-1\r\n- **Status**: Approved\r\n\r\nNotes\r\n"; got != want {
+		t.Errorf("CRLF edit = %q, want %q", got, want)
+	}
+}
+
+func TestFieldReadAndWritePreservesFormat(t *testing.T) {
+	doc := Parse(capabilityFixture)
+
+	if got := doc.FieldValue("ID"); got != "CAP-100001" {
+		t.Errorf("expected ID CAP-100001, got %q", got)
+	}
+
+	doc.SetField("Status", "Approved")
+	doc.SetField("Storyboard Reference", "SB-1")
+
+	out := doc.String()
+	if !strings.Contains(out, "- **Status**: Approved\n- **Storyboard Reference**: SB-1\n") {
+		t.Errorf("expected updated and appended metadata fields, got:\n%s", out)
+	}
+	if !strings.Contains(out, "**Status**: not a field") {
+		t.Error("field inside a code block must not be modified")
+	}
+	if strings.Count(out, "## Metadata") != 1 {
+		t.Error("expected a single Metadata section")
+	}
+}
+
+func TestSetFieldCreatesMetadataSection(t *testing.T) {
+	doc := Parse("# Title\n\nSome text.\n")
+	doc.SetField("ID", "ENB-1")
+
+	want := "# Title\n\n## Metadata\n\n- **ID**: ENB-1\n\nSome text.\n"
+	if got := doc.String(); got != want {
+		t.Errorf("unexpected document:\nwant %q\ngot  %q", want, got)
+	}
+}
+
+func TestSectionEditsKeepHandWrittenContent(t *testing.T) {
+	doc := Parse(capabilityFixture)
+
+	doc.SetSubsectionBody("Business Context", "Description", "Updated description.")
+	doc.SetSubsectionBody("Business Context", "Success Metrics", "- 99% sign-in success")
+
+	out := doc.String()
+	if !strings.Contains(out, "### Description\nUpdated description.\n\n### Value Proposition") {
+		t.Errorf("description not replaced in place:\n%s", out)
+	}
+	if !strings.Contains(out, "Secure access.\n\n### Success Metrics\n- 99% sign-in success\n\n## Hand-written Notes") {
+		t.Errorf("new subsection not added at the end of its parent:\n%s", out)
+	}
+	if !strings.Contains(out, "## Hand-written Notes\n\nKeep this paragraph exactly as it is.\n") {
+		t.Error("hand-written section was modified")
+	}
+	if !strings.HasSuffix(out, "```\n") {
+		t.Error("trailing newline was lost")
+	}
+}
+
+func TestClearedSectionKeepsHeading(t *testing.T) {
+	doc := Parse("# Checkout\n\n## Outcome\n\nMore sales\n\n## Notes\n\nmine\n")
+
+	doc.SetSectionBody("Outcome", 2, "")
+
+	if got, want := doc.String(), "# Checkout\n\n## Outcome\n\n## Notes\n\nmine\n"; got != want {
+		t.Errorf("cleared section = %q, want %q", got, want)
+	}
+}
+
+func TestFieldsOnlyReadFromMetadataOrListItems(t *testing.T) {
+	doc := Parse("# Checkout\n\nOwner: Sam\n\n## Notes\n\nStatus: waiting on legal\n\n## WSJF Scoring\n\n- **Job Size**: 3\n")
+
+	if got := doc.FieldValue("Owner"); got != "Sam" {
+		t.Errorf("Owner = %q, want the metadata block value", got)
+	}
+	if got, ok := doc.Field("Status"); ok {
+		t.Errorf("Status = %q, read from prose", got)
+	}
+	if got := doc.FieldValue("Job Size"); got != "3" {
+		t.Errorf("Job Size = %q, want the list item value", got)
+	}
+	doc.SetField("Status", "Ready")
+	if !strings.Contains(doc.String(), "Status: waiting on legal") {
+		t.Error("SetField() overwrote a prose line")
+	}
+}
+
+func TestTypedViewsReadFieldsOnlyFromFieldLines(t *testing.T) {
+	st := ParseStory("# Login\n\n**Status:** Ready\n\n### Notes\nStatus: blocked on legal\nDescription: see ticket\n")
+	if st.Status != "Ready" || st.Description != "" {
+		t.Errorf("story status %q, description %q; want Ready and none", st.Status, st.Description)
+	}
+	if !strings.Contains(st.Sections["Notes"], "Status: blocked on legal") {
+		t.Errorf("story notes = %q, want the prose kept", st.Sections["Notes"])
+	}
+
+	c := ParseCapability("# Checkout\n\n## Metadata\n- **Status**: Draft\n\n## Notes\nDescription: not this\n")
+	if c.Description == "not this" {
+		t.Error("capability description read from prose")
+	}
+
+	e := ParseEpic("# Growth\n\n## Notes\nStatus: waiting on legal\n\n## WSJF Scoring\n**Job Size:** 2\n")
+	if e.Status != "Funnel" || e.JobSize != 2 {
+		t.Errorf("epic status %q, job size %d; want Funnel and 2", e.Status, e.JobSize)
+	}
+}
+
+func TestSectionsIgnoreCodeBlocks(t *testing.T) {
+	doc := Parse(capabilityFixture)
+	for _, s := range doc.Sections() {
+		if s.Title == "not a heading" {
+			t.Fatal("heading inside a code block was parsed as a section")
+		}
+	}
+}
+
+func TestParseEnabler(t *testing.T) {
+	e := ParseEnabler("# Login API\n\n## Metadata\n- **ID:** ENB-200\n- **Capability**: Auth (CAP-100001)\n\n## Purpose\nIssue tokens.\n\n### Detail\nnested\n\n## Runbook\nRestart.\n")
+
+	if e.ID != "ENB-200" || e.CapabilityID != "CAP-100001" {
+		t.Errorf("unexpected IDs: %q %q", e.ID, e.CapabilityID)
+	}
+	if e.Sections["Purpose"] != "Issue tokens.\n\n### Detail\nnested" {
+		t.Errorf("unexpected purpose: %q", e.Sections["Purpose"])
+	}
+	if e.Sections["Runbook"] != "Restart." {
+		t.Errorf("unexpected runbook: %q", e.Sections["Runbook"])
+	}
+}
+
+func TestTestScenarioGherkin(t *testing.T) {
+	ts := ParseTestScenario("# Login\n\n- **ID**: TS-1\n- **Tags**: smoke, auth\n\n## Gherkin Scenario\n\n```gherkin\nScenario: old\n```\n")
+	if ts.Gherkin != "Scenario: old" || len(ts.Tags) != 2 {
+		t.Fatalf("unexpected scenario: %+v", ts)
+	}
+
+	ts.SetGherkin("Scenario: new\n  Given a user")
+	if got := ts.Doc.String(); !strings.HasSuffix(got, "```gherkin\nScenario: new\n  Given a user\n```\n") {
+		t.Errorf("gherkin block not replaced:\n%s", got)
+	}
+}
+
+func TestParseEpicWSJF(t *testing.T) {
+	e := ParseEpic("# Checkout\n\nFaster checkout.\n\n## WSJF Scoring\n**User Value:** 8\n**Time Criticality:** 5\n**Risk Reduction:** 3\n**Job Size:** 4\n")
+	if e.Status != "Funnel" || e.Description != "Faster checkout." {
+		t.Errorf("unexpected epic: %+v", e)
+	}
+	if e.WSJF() != 4 {
+		t.Errorf("expected WSJF 4, got %v", e.WSJF())
+	}
+}
@@ -0,0 +1,95 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package spec
+
+import "strings"
+
+// EnablerSections lists the ## sections of the INTENT enabler template
+var EnablerSections = []string{
+	"Description",
+	"Purpose",
+	"Technical Specifications",
+	"Type",
+	"Responsibility",
+	"Public Interface",
+	"Internal Design",
+	"Dependencies",
+	"Configuration",
+	"Data Contracts",
+	"Operational Requirements",
+	"Security Controls",
+	"Testing Strategy",
+	"Observability",
+	"Deployment",
+	"Runbook",
+	"Cost Profile",
+}
+
+// Enabler is the typed view of an ENB-*.md specification
+type Enabler struct {
+	Doc          *Document
+	Name         string
+	ID           string
+	Status       string
+	Owner        string
+	Priority     string
+	Capability   string // Raw "Capability" field, usually "Name (CAP-XXXXXX)"
+	CapabilityID string
+	CreatedBy    string
+	// Sections holds the bodies of the template sections that are present
+	Sections map[string]string
+}
+
+// ParseEnabler parses an enabler document
+func ParseEnabler(content string) *Enabler {
+	doc := Parse(content)
+	e := &Enabler{
+		Doc:        doc,
+		Name:       doc.Title(),
+		ID:         doc.FieldValue("ID"),
+		Status:     doc.FieldValue("Status"),
+		Owner:      doc.FieldValue("Owner"),
+		Priority:   doc.FieldValue("Priority"),
+		Capability: doc.FieldValue("Capability"),
+		CreatedBy:  doc.FieldValue("Created By"),
+		Sections:   make(map[string]string),
+	}
+
+	// Prefer the explicit Capability ID; fall back to "Name (CAP-XXXXXX)"
+	e.CapabilityID = doc.FieldValue("Capability ID")
+	if e.CapabilityID == "" && e.Capability != "" {
+		e.CapabilityID = CapabilityIDFromReference(e.Capability)
+	}
+
+	for _, title := range EnablerSections {
+		if s, ok := doc.sectionAtLevel(title, 2); ok {
+			e.Sections[title] = doc.Body(s)
+		}
+	}
+	return e
+}
+
+// CapabilityIDFromReference extracts the ID from "Name (CAP-XXXXXX)", returning
+// the whole reference when it has no parenthesised ID
+func CapabilityIDFromReference(ref string) string {
+	if idx := strings.LastIndex(ref, "("); idx != -1 {
+		return strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(ref[idx:], "("), ")"))
+	}
+	return ref
+}
+
+// sectionAtLevel prefers a heading at level, falling back to any level
+func (d *Document) sectionAtLevel(title string, level int) (Section, bool) {
+	for _, s := range d.Sections() {
+		if s.Level == level && strings.EqualFold(s.Title, title) {
+			return s, true
+		}
+	}
+	return d.Section(title)
+}
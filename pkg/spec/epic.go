@@ -0,0 +1,64 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package spec
+
+import "strconv"
+
+// WSJFSection is the heading that holds an epic's WSJF scoring fields
+const WSJFSection = "WSJF Scoring"
+
+// Epic is the typed view of an EPIC-*.md specification
+type Epic struct {
+	Doc             *Document
+	Name            string
+	Description     string
+	Status          string
+	UserValue       int
+	TimeCriticality int
+	RiskReduction   int
+	JobSize         int
+	// Sections maps every ## heading to its body text
+	Sections map[string]string
+}
+
+var epicFieldNames = []string{"Status", "User Value", "Time Criticality", "Risk Reduction", "Job Size"}
+
+// ParseEpic parses an epic document. Epics without a status are in the Funnel.
+func ParseEpic(content string) *Epic {
+	doc := Parse(content)
+	o := parseOutline(doc, epicFieldNames)
+	e := &Epic{
+		Doc:         doc,
+		Name:        o.name,
+		Description: o.description,
+		Status:      "Funnel",
+		Sections:    o.sections,
+	}
+	if status, ok := o.fields["Status"]; ok {
+		e.Status = status
+	}
+	e.UserValue, _ = strconv.Atoi(o.fields["User Value"])
+	e.TimeCriticality, _ = strconv.Atoi(o.fields["Time Criticality"])
+	e.RiskReduction, _ = strconv.Atoi(o.fields["Risk Reduction"])
+	e.JobSize, _ = strconv.Atoi(o.fields["Job Size"])
+	return e
+}
+
+// WSJF returns the Weighted Shortest Job First score, or 0 without a job size
+func (e *Epic) WSJF() float64 {
+	return WSJFScore(e.UserValue, e.TimeCriticality, e.RiskReduction, e.JobSize)
+}
+
+// WSJFScore computes cost of delay divided by job size
+func WSJFScore(userValue, timeCriticality, riskReduction, jobSize int) float64 {
+	if jobSize <= 0 {
+		return 0
+	}
+	return float64(userValue+timeCriticality+riskReduction) / float64(jobSize)
+}
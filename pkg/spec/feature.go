@@ -0,0 +1,42 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package spec
+
+// Feature is the typed view of a FEAT-*.md specification
+type Feature struct {
+	Doc               *Document
+	Name              string
+	Description       string
+	Status            string
+	ParentEpic        string
+	BenefitHypothesis string
+	// Sections maps every ## heading to its body text
+	Sections map[string]string
+}
+
+var featureFieldNames = []string{"Status", "Parent Epic"}
+
+// ParseFeature parses a feature document. Features without a status are Planned.
+func ParseFeature(content string) *Feature {
+	doc := Parse(content)
+	o := parseOutline(doc, featureFieldNames)
+	f := &Feature{
+		Doc:               doc,
+		Name:              o.name,
+		Description:       o.description,
+		Status:            "Planned",
+		ParentEpic:        o.fields["Parent Epic"],
+		BenefitHypothesis: o.sections["Benefit Hypothesis"],
+		Sections:          o.sections,
+	}
+	if status, ok := o.fields["Status"]; ok {
+		f.Status = status
+	}
+	return f
+}
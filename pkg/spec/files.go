@@ -0,0 +1,56 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package spec
+
+import "strings"
+
+// File name patterns used to recognise specification files (case-insensitive)
+var (
+	capabilityPrefixes = []string{"capability", "CAP", "capabilities"}
+	capabilityContains = []string{"-capability"}
+	enablerPrefixes    = []string{"ENB-", "enabler"}
+	enablerContains    = []string{"-enabler"}
+	storyPrefixes      = []string{"story", "STORY", "SB-"}
+)
+
+// IsCapabilityFile reports whether filename is a capability Markdown file:
+// capability*, CAP*, capabilities* or the numeric form 112001-capability.md
+func IsCapabilityFile(filename string) bool {
+	return isMarkdown(filename) && matchesName(filename, capabilityPrefixes, capabilityContains)
+}
+
+// IsEnablerFile reports whether filename is an enabler Markdown file:
+// ENB-*, enabler* or the numeric form 112106-enabler.md
+func IsEnablerFile(filename string) bool {
+	return isMarkdown(filename) && matchesName(filename, enablerPrefixes, enablerContains)
+}
+
+// IsStoryFile reports whether filename is a storyboard card file: story*, STORY*, SB-*
+func IsStoryFile(filename string) bool {
+	return isMarkdown(filename) && matchesName(filename, storyPrefixes, nil)
+}
+
+func isMarkdown(filename string) bool {
+	return strings.HasSuffix(strings.ToLower(filename), ".md")
+}
+
+func matchesName(filename string, prefixes, contains []string) bool {
+	upper := strings.ToUpper(filename)
+	for _, p := range prefixes {
+		if strings.HasPrefix(upper, strings.ToUpper(p)) {
+			return true
+		}
+	}
+	for _, c := range contains {
+		if strings.Contains(upper, strings.ToUpper(c)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SettingsKey is the .intentrworkspace customSettings key holding lint configuration:
+//
+//	"customSettings": {
+//	  "lint": {
+//	    "rules": {
+//	      "dangling-storyboard-reference": "off",
+//	      "duplicate-id": "warning",
+//	      "missing-metadata": {"severity": "error", "enabler": ["ID", "Capability ID"]}
+//	    }
+//	  }
+//	}
+const SettingsKey = "lint"
+
+// Default required Metadata fields
+var (
+	DefaultCapabilityFields = []string{"ID", "Status"}
+	DefaultEnablerFields    = []string{"ID", "Status", "Capability ID"}
+)
+
+// Config controls which rules run and at what severity
+type Config struct {
+	// Rules overrides the default severity per rule ID
+	Rules            map[string]Severity
+	CapabilityFields []string
+	EnablerFields    []string
+}
+
+// DefaultConfig returns the configuration used when a workspace has no lint settings
+func DefaultConfig() Config {
+	return Config{
+		Rules:            make(map[string]Severity),
+		CapabilityFields: DefaultCapabilityFields,
+		EnablerFields:    DefaultEnablerFields,
+	}
+}
+
+// Severity returns the configured severity for rule
+func (c Config) Severity(rule string) Severity {
+	if s, ok := c.Rules[rule]; ok {
+		return s
+	}
+	for _, r := range Rules {
+		if r.ID == rule {
+			return r.Severity
+		}
+	}
+	return SeverityOff
+}
+
+// ruleSettings is the object form of a rule entry in customSettings
+type ruleSettings struct {
+	Severity   Severity `json:"severity"`
+	Capability []string `json:"capability"`
+	Enabler    []string `json:"enabler"`
+}
+
+// ConfigFromSettings builds a Config from a workspace's customSettings map
+func ConfigFromSettings(settings map[string]interface{}) (Config, error) {
+	cfg := DefaultConfig()
+	raw, ok := settings[SettingsKey]
+	if !ok || raw == nil {
+		return cfg, nil
+	}
+
+	// Round-trip through JSON so string and object rule forms decode uniformly
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read lint settings: %w", err)
+	}
+	var parsed struct {
+		Rules map[string]json.RawMessage `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return cfg, fmt.Errorf("invalid lint settings: %w", err)
+	}
+
+	for id, value := range parsed.Rules {
+		if !knownRule(id) {
+			return cfg, fmt.Errorf("unknown lint rule %q", id)
+		}
+		var rs ruleSettings
+		if err := json.Unmarshal(value, &rs.Severity); err != nil {
+			if err := json.Unmarshal(value, &rs); err != nil {
+				return cfg, fmt.Errorf("invalid settings for lint rule %q: %w", id, err)
+			}
+		}
+		if rs.Severity != "" {
+			if !validSeverity(rs.Severity) {
+				return cfg, fmt.Errorf("invalid severity %q for lint rule %q", rs.Severity, id)
+			}
+			cfg.Rules[id] = rs.Severity
+		}
+		if id == RuleMissingMetadata {
+			if rs.Capability != nil {
+				cfg.CapabilityFields = rs.Capability
+			}
+			if rs.Enabler != nil {
+				cfg.EnablerFields = rs.Enabler
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// LoadConfig reads lint settings from the workspace's .intentrworkspace file.
+// Workspaces without the file use the default configuration.
+func LoadConfig(root string) (Config, error) {
+	data, err := os.ReadFile(filepath.Join(root, ".intentrworkspace"))
+	if os.IsNotExist(err) {
+		return DefaultConfig(), nil
+	}
+	if err != nil {
+		return DefaultConfig(), fmt.Errorf("failed to read workspace config: %w", err)
+	}
+
+	var ws struct {
+		CustomSettings map[string]interface{} `json:"customSettings"`
+	}
+	if err := json.Unmarshal(data, &ws); err != nil {
+		return DefaultConfig(), fmt.Errorf("failed to parse workspace config: %w", err)
+	}
+	return ConfigFromSettings(ws.CustomSettings)
+}
+
+func knownRule(id string) bool {
+	for _, r := range Rules {
+		if r.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func validSeverity(s Severity) bool {
+	switch s {
+	case SeverityError, SeverityWarning, SeverityInfo, SeverityOff:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,335 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Package lint checks a workspace's specification files for problems the
+// parsers silently ignore, such as missing metadata or duplicate IDs.
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jareynolds/intentr/pkg/spec"
+)
+
+// Severity of a rule violation
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+	SeverityOff     Severity = "off"
+)
+
+// Rule IDs
+const (
+	RuleMissingMetadata             = "missing-metadata"
+	RuleDuplicateID                 = "duplicate-id"
+	RuleDanglingStoryboardReference = "dangling-storyboard-reference"
+)
+
+// Rule describes a lint rule and its default severity
+type Rule struct {
+	ID          string   `json:"id"`
+	Description string   `json:"description"`
+	Severity    Severity `json:"severity"`
+}
+
+// Rules is the catalog of every rule the linter knows about
+var Rules = []Rule{
+	{
+		ID:          RuleMissingMetadata,
+		Description: "Capability or enabler is missing a required Metadata field",
+		Severity:    SeverityError,
+	},
+	{
+		ID:          RuleDuplicateID,
+		Description: "The same CAP- or ENB- ID is declared by more than one file",
+		Severity:    SeverityError,
+	},
+	{
+		ID:          RuleDanglingStoryboardReference,
+		Description: "Storyboard Reference does not match any storyboard card in conception/",
+		Severity:    SeverityWarning,
+	},
+}
+
+// Issue is a single rule violation
+type Issue struct {
+	File     string   `json:"file"` // Relative to the workspace root
+	Line     int      `json:"line"` // 1-based
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"`
+	Message  string   `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s [%s]", i.File, i.Line, i.Severity, i.Message, i.Rule)
+}
+
+// specFolders are the workspace folders holding capability and enabler files.
+// "specifications" is the layout used by workspaces created before definition/.
+var specFolders = []string{"definition", "specifications"}
+
+// storyFolders are the workspace folders holding storyboard cards
+var storyFolders = []string{"conception", "specifications"}
+
+// specFile is a parsed capability or enabler
+type specFile struct {
+	rel  string
+	kind string // "capability" or "enabler"
+	doc  *spec.Document
+	id   string
+	ref  string // Storyboard Reference (capabilities only)
+}
+
+// Run lints the workspace at root and returns the issues sorted by file and line
+func Run(root string, cfg Config) ([]Issue, error) {
+	if _, err := os.Stat(root); err != nil {
+		return nil, fmt.Errorf("failed to open workspace: %w", err)
+	}
+
+	files, err := loadSpecFiles(root)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &linter{cfg: cfg}
+	for _, f := range files {
+		l.checkMetadata(f)
+	}
+	l.checkDuplicateIDs(files)
+	if l.enabled(RuleDanglingStoryboardReference) {
+		cards, err := loadStoryCards(root)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			l.checkStoryboardReference(f, cards)
+		}
+	}
+
+	sort.SliceStable(l.issues, func(a, b int) bool {
+		if l.issues[a].File != l.issues[b].File {
+			return l.issues[a].File < l.issues[b].File
+		}
+		return l.issues[a].Line < l.issues[b].Line
+	})
+	return l.issues, nil
+}
+
+// HasErrors reports whether any issue has error severity
+func HasErrors(issues []Issue) bool {
+	for _, i := range issues {
+		if i.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+type linter struct {
+	cfg    Config
+	issues []Issue
+}
+
+func (l *linter) enabled(rule string) bool {
+	return l.cfg.Severity(rule) != SeverityOff
+}
+
+func (l *linter) report(rule, file string, line int, format string, args ...interface{}) {
+	severity := l.cfg.Severity(rule)
+	if severity == SeverityOff {
+		return
+	}
+	l.issues = append(l.issues, Issue{
+		File:     file,
+		Line:     line + 1,
+		Severity: severity,
+		Rule:     rule,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) checkMetadata(f specFile) {
+	if !l.enabled(RuleMissingMetadata) {
+		return
+	}
+	required := l.cfg.CapabilityFields
+	if f.kind == "enabler" {
+		required = l.cfg.EnablerFields
+	}
+
+	// Point at the Metadata section when there is one, otherwise the title
+	line := 0
+	if s, ok := f.doc.Section(spec.MetadataSection); ok {
+		line = s.Line
+	} else if title, ok := firstHeading(f.doc); ok {
+		line = title
+	}
+
+	for _, name := range required {
+		if hasField(f.doc, name) {
+			continue
+		}
+		l.report(RuleMissingMetadata, f.rel, line, "%s is missing Metadata field %q", f.kind, name)
+	}
+}
+
+func (l *linter) checkDuplicateIDs(files []specFile) {
+	if !l.enabled(RuleDuplicateID) {
+		return
+	}
+	byID := make(map[string][]specFile)
+	var order []string
+	for _, f := range files {
+		if f.id == "" {
+			continue
+		}
+		key := strings.ToUpper(f.id)
+		if _, seen := byID[key]; !seen {
+			order = append(order, key)
+		}
+		byID[key] = append(byID[key], f)
+	}
+
+	for _, key := range order {
+		dups := byID[key]
+		if len(dups) < 2 {
+			continue
+		}
+		for i, f := range dups {
+			var others []string
+			for j, o := range dups {
+				if j != i {
+					others = append(others, o.rel)
+				}
+			}
+			line, _ := f.doc.FieldLine("ID")
+			l.report(RuleDuplicateID, f.rel, line, "ID %s is also declared in %s", f.id, strings.Join(others, ", "))
+		}
+	}
+}
+
+func (l *linter) checkStoryboardReference(f specFile, cards map[string]bool) {
+	if f.ref == "" || cards[normalizeReference(f.ref)] {
+		return
+	}
+	line, _ := f.doc.FieldLine("Storyboard Reference")
+	l.report(RuleDanglingStoryboardReference, f.rel, line, "Storyboard Reference %q does not match any storyboard card", f.ref)
+}
+
+// fieldAliases lists alternative field names accepted for a required field
+var fieldAliases = map[string][]string{
+	"Capability ID": {"Capability"},
+}
+
+func hasField(doc *spec.Document, name string) bool {
+	if v, ok := doc.Field(name); ok && v != "" {
+		return true
+	}
+	for _, alias := range fieldAliases[name] {
+		if v, ok := doc.Field(alias); ok && v != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func firstHeading(doc *spec.Document) (int, bool) {
+	for _, s := range doc.Sections() {
+		if s.Level == 1 {
+			return s.Line, true
+		}
+	}
+	return 0, false
+}
+
+// loadSpecFiles reads every capability and enabler file, using the same file
+// patterns and parsers as the /capability-files and /enabler-files endpoints
+func loadSpecFiles(root string) ([]specFile, error) {
+	var files []specFile
+	err := walkFolders(root, specFolders, func(rel, filename, content string) {
+		switch {
+		case spec.IsEnablerFile(filename):
+			e := spec.ParseEnabler(content)
+			files = append(files, specFile{rel: rel, kind: "enabler", doc: e.Doc, id: e.ID})
+		case spec.IsCapabilityFile(filename):
+			c := spec.ParseCapability(content)
+			files = append(files, specFile{rel: rel, kind: "capability", doc: c.Doc, id: c.ID, ref: c.StoryboardReference})
+		}
+	})
+	return files, err
+}
+
+// loadStoryCards returns the normalized titles, card IDs and file names of
+// every storyboard card a Storyboard Reference may point at
+func loadStoryCards(root string) (map[string]bool, error) {
+	cards := make(map[string]bool)
+	err := walkFolders(root, storyFolders, func(rel, filename, content string) {
+		if !spec.IsStoryFile(filename) {
+			return
+		}
+		cards[normalizeReference(filename)] = true
+		st := spec.ParseStory(content)
+		if st.CardID != "" {
+			cards[normalizeReference(st.CardID)] = true
+		}
+		// Multi-story files hold one card per # heading
+		for _, s := range st.Doc.Sections() {
+			if s.Level == 1 {
+				cards[normalizeReference(s.Title)] = true
+			}
+		}
+	})
+	return cards, err
+}
+
+func normalizeReference(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if strings.HasSuffix(strings.ToLower(ref), ".md") {
+		ref = ref[:len(ref)-3]
+	}
+	return strings.ToLower(ref)
+}
+
+// walkFolders calls fn for every readable Markdown file under root/folders.
+// A file reachable from two folders is visited once.
+func walkFolders(root string, folders []string, fn func(rel, filename, content string)) error {
+	seen := make(map[string]bool)
+	for _, folder := range folders {
+		dir := filepath.Join(root, folder)
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, walkErr error) error {
+			if walkErr != nil || info.IsDir() || seen[path] {
+				return nil // Skip errors
+			}
+			seen[path] = true
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil // Skip files we can't read
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				rel = path
+			}
+			fn(filepath.ToSlash(rel), info.Name(), string(content))
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scan %s folder: %w", folder, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeWorkspace(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+var workspaceFixture = map[string]string{
+	"definition/CAP-login.md":     "# Login\n\n## Metadata\n\n- **ID**: CAP-1\n- **Status**: Draft\n- **Storyboard Reference**: Sign In\n",
+	"definition/CAP-signup.md":    "# Signup\n\n## Metadata\n\n- **ID**: CAP-1\n- **Status**: Draft\n- **Storyboard Reference**: Missing Card\n",
+	"definition/ENB-token.md":     "# Token\n\n## Metadata\n\n- **ID**: ENB-1\n- **Capability**: Login (CAP-1)\n",
+	"conception/STORY-sign-in.md": "# Sign In\n\n**Card ID:** card-1\n",
+	"definition/notes.md":         "# Not a spec\n",
+}
+
+func TestRunReportsEachRule(t *testing.T) {
+	root := writeWorkspace(t, workspaceFixture)
+
+	issues, err := Run(root, DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Issue{
+		{File: "definition/CAP-login.md", Line: 5, Severity: SeverityError, Rule: RuleDuplicateID},
+		{File: "definition/CAP-signup.md", Line: 5, Severity: SeverityError, Rule: RuleDuplicateID},
+		{File: "definition/CAP-signup.md", Line: 7, Severity: SeverityWarning, Rule: RuleDanglingStoryboardReference},
+		{File: "definition/ENB-token.md", Line: 3, Severity: SeverityError, Rule: RuleMissingMetadata},
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("expected %d issues, got %d: %v", len(want), len(issues), issues)
+	}
+	for i, w := range want {
+		got := issues[i]
+		if got.File != w.File || got.Line != w.Line || got.Severity != w.Severity || got.Rule != w.Rule {
+			t.Errorf("issue %d: want %s:%d %s %s, got %s", i, w.File, w.Line, w.Severity, w.Rule, got)
+		}
+	}
+	if !HasErrors(issues) {
+		t.Error("expected HasErrors to be true")
+	}
+}
+
+func TestWorkspaceSettingsOverrideRules(t *testing.T) {
+	files := map[string]string{
+		".intentrworkspace": `{"name": "demo", "customSettings": {"lint": {"rules": {
+			"duplicate-id": "off",
+			"dangling-storyboard-reference": "error",
+			"missing-metadata": {"severity": "info", "enabler": ["ID"]}
+		}}}}`,
+	}
+	for name, content := range workspaceFixture {
+		files[name] = content
+	}
+	root := writeWorkspace(t, files)
+
+	cfg, err := LoadConfig(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := Run(root, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Rule != RuleDanglingStoryboardReference || issues[0].Severity != SeverityError {
+		t.Fatalf("unexpected issues: %v", issues)
+	}
+}
+
+func TestConfigFromSettingsRejectsUnknownRules(t *testing.T) {
+	_, err := ConfigFromSettings(map[string]interface{}{
+		"lint": map[string]interface{}{"rules": map[string]interface{}{"no-such-rule": "error"}},
+	})
+	if err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+	_, err = ConfigFromSettings(map[string]interface{}{
+		"lint": map[string]interface{}{"rules": map[string]interface{}{"duplicate-id": "fatal"}},
+	})
+	if err == nil {
+		t.Error("expected an error for an invalid severity")
+	}
+}
@@ -0,0 +1,69 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package spec
+
+import "strings"
+
+// outline is the shape shared by portfolio documents (epics, themes and
+// features): a # title, an optional lead paragraph, "**Field:** value"
+// metadata lines and ## sections.
+type outline struct {
+	name        string
+	description string
+	fields      map[string]string
+	sections    map[string]string
+}
+
+// parseOutline walks a portfolio document. Lines holding one of fieldNames are
+// recorded as fields and left out of the section text.
+func parseOutline(doc *Document, fieldNames []string) outline {
+	o := outline{
+		name:     doc.Title(),
+		fields:   make(map[string]string),
+		sections: make(map[string]string),
+	}
+
+	code := doc.codeLines()
+	fieldLines := doc.fieldLines()
+	var current string
+	var body []string
+	flush := func() {
+		if current != "" {
+			o.sections[current] = strings.TrimSpace(strings.Join(body, "\n"))
+		}
+	}
+
+	for i, line := range doc.lines {
+		trimmed := strings.TrimSpace(line)
+		if !code[i] {
+			if level, title, ok := parseHeading(line); ok && level <= 2 {
+				if level == 2 {
+					flush()
+					current = title
+					body = nil
+				}
+				continue
+			}
+			if name, value, ok := matchAnyField(line, fieldNames); ok && fieldLines[i] {
+				o.fields[name] = value
+				continue
+			}
+		}
+
+		if current != "" {
+			body = append(body, line)
+		} else if o.description == "" && trimmed != "" && !strings.HasPrefix(trimmed, "**") && !strings.HasPrefix(trimmed, "#") {
+			// First non-metadata paragraph is the description
+			o.description = trimmed
+		}
+	}
+	flush()
+
+	return o
+}
@@ -0,0 +1,319 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Package rename changes a CAP/ENB/FR/NFR/TS identifier everywhere it
+// appears in a workspace: spec file names, the spec's own metadata,
+// cross-references from other specs, storyboard references and test
+// scenario Enabler ID fields. PlanRename builds the full list of edits
+// without touching disk so callers can offer a dry-run preview.
+package rename
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jareynolds/intentr/pkg/idalloc"
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// LineChange is one edited line within a file
+type LineChange struct {
+	Line   int    `json:"line"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// FileChange describes the edits to one file. Paths are relative to the
+// workspace root; NewPath is set when the file itself is renamed.
+type FileChange struct {
+	Path    string       `json:"path"`
+	NewPath string       `json:"newPath,omitempty"`
+	Lines   []LineChange `json:"lines,omitempty"`
+
+	content  string // Rewritten file content, empty when only the name changes
+	original string // Content before the rename, kept to revert it
+}
+
+// Plan is the set of file edits needed to rename OldID to NewID
+type Plan struct {
+	OldID string       `json:"oldId"`
+	NewID string       `json:"newId"`
+	Files []FileChange `json:"files"`
+}
+
+// textExtensions are the file types searched for references
+var textExtensions = map[string]bool{
+	".md":      true,
+	".json":    true,
+	".feature": true,
+	".yaml":    true,
+	".yml":     true,
+}
+
+// skipDirs are never searched
+var skipDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// Validate checks that oldID and newID are well-formed IDs with the same prefix
+func Validate(oldID, newID string) error {
+	oldPrefix, ok := prefixOf(oldID)
+	if !ok {
+		return fmt.Errorf("invalid ID %q", oldID)
+	}
+	newPrefix, ok := prefixOf(newID)
+	if !ok {
+		return fmt.Errorf("invalid ID %q", newID)
+	}
+	if oldPrefix != newPrefix {
+		return fmt.Errorf("cannot rename %s to %s: prefixes differ", oldID, newID)
+	}
+	if oldID == newID {
+		return fmt.Errorf("old and new ID are both %s", oldID)
+	}
+	return nil
+}
+
+// prefixOf returns the prefix of an ID such as ENB-000042
+func prefixOf(id string) (string, bool) {
+	i := strings.Index(id, "-")
+	if i <= 0 || i == len(id)-1 || !idalloc.ValidPrefix(id[:i]) {
+		return "", false
+	}
+	for _, r := range id[i+1:] {
+		if !isWordChar(byte(r)) && r != '-' {
+			return "", false
+		}
+	}
+	return id[:i], true
+}
+
+// PlanRename finds every file under root that references oldID. It returns
+// a *models.IDConflictError if newID is already used anywhere in the workspace.
+func PlanRename(root, oldID, newID string) (*Plan, error) {
+	if err := Validate(oldID, newID); err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{OldID: oldID, NewID: newID, Files: []FileChange{}}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return nil // Skip errors
+		}
+		if info.IsDir() {
+			if path != root && skipDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !textExtensions[strings.ToLower(filepath.Ext(info.Name()))] {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil // Skip files we can't read
+		}
+		content := string(data)
+
+		if Contains(info.Name(), newID) || Contains(content, newID) {
+			return &models.IDConflictError{ID: newID, Where: rel}
+		}
+
+		change := FileChange{Path: rel}
+		if name, n := Replace(info.Name(), oldID, newID); n > 0 {
+			change.NewPath = filepath.ToSlash(filepath.Join(filepath.Dir(rel), name))
+		}
+		if updated, n := Replace(content, oldID, newID); n > 0 {
+			change.content = updated
+			change.original = content
+			change.Lines = diffLines(content, updated)
+		}
+		if change.NewPath != "" || change.content != "" {
+			plan.Files = append(plan.Files, change)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(plan.Files, func(i, j int) bool { return plan.Files[i].Path < plan.Files[j].Path })
+	return plan, nil
+}
+
+// Apply writes the planned edits under root. Contents are rewritten before
+// any file is renamed, and a rename never overwrites an existing file. If an
+// edit fails, the ones already made are undone.
+func (p *Plan) Apply(root string) error {
+	for _, f := range p.Files {
+		if f.NewPath == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(f.NewPath))); err == nil {
+			return &models.IDConflictError{ID: p.NewID, Where: f.NewPath}
+		}
+	}
+
+	var written, renamed []FileChange
+	fail := func(err error) error {
+		return errors.Join(err, undo(root, written, renamed))
+	}
+	for _, f := range p.Files {
+		if f.content == "" {
+			continue
+		}
+		if err := writeContent(root, f.Path, f.content); err != nil {
+			return fail(err)
+		}
+		written = append(written, f)
+	}
+
+	for _, f := range p.Files {
+		if f.NewPath == "" {
+			continue
+		}
+		from := filepath.Join(root, filepath.FromSlash(f.Path))
+		to := filepath.Join(root, filepath.FromSlash(f.NewPath))
+		if err := os.Rename(from, to); err != nil {
+			return fail(fmt.Errorf("failed to rename %s to %s: %w", f.Path, f.NewPath, err))
+		}
+		renamed = append(renamed, f)
+	}
+	return nil
+}
+
+// Revert undoes an applied plan, for callers whose own part of the rename
+// failed after the files changed
+func (p *Plan) Revert(root string) error {
+	var written, renamed []FileChange
+	for _, f := range p.Files {
+		if f.content != "" {
+			written = append(written, f)
+		}
+		if f.NewPath != "" {
+			renamed = append(renamed, f)
+		}
+	}
+	return undo(root, written, renamed)
+}
+
+// undo renames files back, last first, and then restores their contents
+func undo(root string, written, renamed []FileChange) error {
+	var errs []error
+	for i := len(renamed) - 1; i >= 0; i-- {
+		f := renamed[i]
+		from := filepath.Join(root, filepath.FromSlash(f.NewPath))
+		to := filepath.Join(root, filepath.FromSlash(f.Path))
+		if err := os.Rename(from, to); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore %s: %w", f.Path, err))
+		}
+	}
+	for _, f := range written {
+		if err := writeContent(root, f.Path, f.original); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeContent replaces the content of a file under root, keeping its mode
+func writeContent(root, rel, content string) error {
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", rel, err)
+	}
+	if err := os.WriteFile(path, []byte(content), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rel, err)
+	}
+	return nil
+}
+
+// Contains reports whether text references id as a whole ID
+func Contains(text, id string) bool {
+	_, n := Replace(text, id, id)
+	return n > 0
+}
+
+// Replace substitutes newID for every whole-ID occurrence of oldID in text.
+// An occurrence must not be part of a longer word or ID, so FR-000001 does
+// not match inside NFR-000001, FR-0000012 or the child ID FR-000001-01.
+func Replace(text, oldID, newID string) (string, int) {
+	var b strings.Builder
+	count := 0
+	rest := text
+	for {
+		i := strings.Index(rest, oldID)
+		if i < 0 {
+			break
+		}
+		end := i + len(oldID)
+		if isBoundaryBefore(rest, i) && isBoundaryAfter(rest, end) {
+			b.WriteString(rest[:i])
+			b.WriteString(newID)
+			count++
+		} else {
+			b.WriteString(rest[:end])
+		}
+		rest = rest[end:]
+	}
+	if count == 0 {
+		return text, 0
+	}
+	b.WriteString(rest)
+	return b.String(), count
+}
+
+func isBoundaryBefore(s string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	c := s[i-1]
+	return !isWordChar(c) && c != '-'
+}
+
+func isBoundaryAfter(s string, i int) bool {
+	if i == len(s) {
+		return true
+	}
+	c := s[i]
+	if isWordChar(c) {
+		return false
+	}
+	if c == '-' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9' {
+		return false
+	}
+	return true
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// diffLines lists the lines that differ between before and after, which
+// always have the same number of lines since IDs never span a newline
+func diffLines(before, after string) []LineChange {
+	oldLines := strings.Split(before, "\n")
+	newLines := strings.Split(after, "\n")
+	var changes []LineChange
+	for i := range oldLines {
+		if i < len(newLines) && oldLines[i] != newLines[i] {
+			changes = append(changes, LineChange{Line: i + 1, Before: oldLines[i], After: newLines[i]})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,115 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package rename
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+func TestReplaceMatchesWholeIDsOnly(t *testing.T) {
+	text := "FR-000001, NFR-000001, FR-0000012, FR-000001-01, (FR-000001)"
+	got, n := Replace(text, "FR-000001", "FR-000009")
+	want := "FR-000009, NFR-000001, FR-0000012, FR-000001-01, (FR-000009)"
+	if got != want || n != 2 {
+		t.Errorf("got %q (%d), want %q (2)", got, n, want)
+	}
+}
+
+func TestPlanAndApplyRename(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"definition/CAP-000001.md":    "# Login\n\n- **ID**: CAP-000001\n",
+		"definition/ENB-000003.md":    "# Token\n\n- **ID**: ENB-000003\n- **Capability**: Login (CAP-000001)\n",
+		"test/TS-000001.md":           "- **Enabler ID**: ENB-000003\n",
+		"definition/CAP-0000010.md":   "- **ID**: CAP-0000010\n",
+		"conception/STORY-sign-in.md": "# Sign In\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	plan, err := PlanRename(root, "CAP-000001", "CAP-000042")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Files) != 2 {
+		t.Fatalf("expected 2 file changes, got %+v", plan.Files)
+	}
+	if plan.Files[0].NewPath != "definition/CAP-000042.md" || plan.Files[1].NewPath != "" {
+		t.Errorf("unexpected renames: %+v", plan.Files)
+	}
+	if l := plan.Files[1].Lines; len(l) != 1 || l[0].Line != 4 || l[0].After != "- **Capability**: Login (CAP-000042)" {
+		t.Errorf("unexpected line changes: %+v", l)
+	}
+
+	// Planning is a dry run
+	if _, err := os.Stat(filepath.Join(root, "definition/CAP-000001.md")); err != nil {
+		t.Fatal("PlanRename must not touch disk")
+	}
+
+	if err := plan.Apply(root); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "definition/CAP-000042.md"))
+	if err != nil || string(data) != "# Login\n\n- **ID**: CAP-000042\n" {
+		t.Errorf("renamed spec not written: %q (%v)", data, err)
+	}
+
+	var conflict *models.IDConflictError
+	if _, err := PlanRename(root, "ENB-000003", "ENB-000003"); err == nil {
+		t.Error("expected an error renaming an ID to itself")
+	}
+	if _, err := PlanRename(root, "CAP-000042", "CAP-0000010"); !errors.As(err, &conflict) {
+		t.Errorf("expected an IDConflictError, got %v", err)
+	}
+	if _, err := PlanRename(root, "CAP-000042", "ENB-000099"); err == nil {
+		t.Error("expected an error for mismatched prefixes")
+	}
+}
+
+func TestRevertRestoresFiles(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"definition/ENB-000003.md": "# Token\n\n- **ID**: ENB-000003\n",
+		"test/TS-000001.md":        "- **Enabler ID**: ENB-000003\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	plan, err := PlanRename(root, "ENB-000003", "ENB-000004")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Apply(root); err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Revert(root); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range files {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q (%v), want %q", name, data, err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "definition/ENB-000004.md")); !os.IsNotExist(err) {
+		t.Errorf("renamed file still present: %v", err)
+	}
+}
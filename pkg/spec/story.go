@@ -0,0 +1,77 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package spec
+
+import "strings"
+
+// Story is the typed view of a STORY-*.md / SB-*.md storyboard card
+type Story struct {
+	Doc         *Document
+	Title       string
+	Status      string
+	Description string
+	CardID      string
+	// Sections maps every ### heading to its body text
+	Sections map[string]string
+}
+
+// ParseStory parses a story document. Content may be a whole file or a block
+// split from a multi-story file (without its # heading).
+func ParseStory(content string) *Story {
+	doc := Parse(content)
+	st := &Story{
+		Doc:      doc,
+		Title:    doc.Title(),
+		Sections: make(map[string]string),
+	}
+
+	code := doc.codeLines()
+	fieldLines := doc.fieldLines()
+	var current string
+	var body []string
+	flush := func() {
+		if current != "" && len(body) > 0 {
+			st.Sections[current] = strings.TrimSpace(strings.Join(body, "\n"))
+		}
+	}
+
+	for i, line := range doc.lines {
+		trimmed := strings.TrimSpace(line)
+		if !code[i] {
+			if level, title, ok := parseHeading(line); ok && level == 3 {
+				flush()
+				current = title
+				body = nil
+				continue
+			}
+			if at, ok := matchField(line, "Status"); ok && fieldLines[i] {
+				st.Status = strings.TrimSpace(line[at:])
+				continue
+			}
+			if at, ok := matchField(line, "Description"); ok && fieldLines[i] {
+				st.Description = strings.TrimSpace(line[at:])
+				continue
+			}
+			// Card ID is the primary identifier used by the Storyboard
+			if at, ok := matchField(line, "Card ID"); ok && fieldLines[i] {
+				st.CardID = strings.TrimSpace(line[at:])
+				continue
+			}
+		}
+
+		if current != "" {
+			body = append(body, line)
+		} else if st.Description == "" && trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			st.Description = trimmed
+		}
+	}
+	flush()
+
+	return st
+}
@@ -0,0 +1,85 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package spec
+
+import "strings"
+
+// GherkinSection is the heading that holds a scenario's fenced Gherkin block
+const GherkinSection = "Gherkin Scenario"
+
+// TestScenario is the typed view of a TS-*.md specification
+type TestScenario struct {
+	Doc            *Document
+	Name           string
+	ID             string
+	EnablerID      string
+	EnablerName    string
+	Feature        string
+	Priority       string
+	Status         string
+	Automation     string
+	RequirementIDs []string
+	Tags           []string
+	LastExecuted   string
+	ExecutionTime  string // Raw value, e.g. "12.50ms"
+	Gherkin        string
+}
+
+// ParseTestScenario parses a test scenario document
+func ParseTestScenario(content string) *TestScenario {
+	doc := Parse(content)
+	ts := &TestScenario{
+		Doc:           doc,
+		Name:          doc.Title(),
+		EnablerName:   doc.FieldValue("Enabler Name"),
+		Feature:       doc.FieldValue("Feature"),
+		Priority:      doc.FieldValue("Priority"),
+		Status:        doc.FieldValue("Status"),
+		Automation:    doc.FieldValue("Automation"),
+		LastExecuted:  doc.FieldValue("Last Executed"),
+		ExecutionTime: doc.FieldValue("Execution Time"),
+	}
+
+	ts.ID = doc.FieldValue("ID")
+	if ts.ID == "" {
+		ts.ID = doc.FieldValue("Scenario ID")
+	}
+	ts.EnablerID = doc.FieldValue("Enabler ID")
+	if ts.EnablerID == "" {
+		ts.EnablerID = doc.FieldValue("Enabler")
+	}
+	if v, ok := doc.Field("Requirement IDs"); ok {
+		ts.RequirementIDs = SplitList(v)
+	}
+	if v, ok := doc.Field("Tags"); ok {
+		ts.Tags = SplitList(v)
+	}
+	if g, ok := doc.CodeBlock("gherkin"); ok {
+		ts.Gherkin = strings.TrimSpace(g)
+	}
+	return ts
+}
+
+// SetGherkin replaces the scenario's Gherkin block, adding the section if needed
+func (ts *TestScenario) SetGherkin(gherkin string) {
+	ts.Gherkin = gherkin
+	if ts.Doc.SetCodeBlock("gherkin", gherkin) {
+		return
+	}
+	ts.Doc.SetSectionBody(GherkinSection, 2, "```gherkin\n"+gherkin+"\n```")
+}
+
+// SplitList splits a comma-separated metadata value into trimmed items
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(strings.TrimSpace(value), ",") {
+		items = append(items, strings.TrimSpace(item))
+	}
+	return items
+}
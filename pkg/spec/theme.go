@@ -0,0 +1,75 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package spec
+
+import "strings"
+
+// Theme types used by the UI
+const (
+	ThemeTypeVision        = "vision"
+	ThemeTypeStrategic     = "strategic-theme"
+	ThemeTypeMarketContext = "market-context"
+)
+
+// Theme is the typed view of a VIS-/STRAT-/MKT-*.md specification
+type Theme struct {
+	Doc         *Document
+	Name        string
+	Description string
+	Status      string
+	// ThemeType is only set when the document declares a **Type:** field
+	ThemeType string
+	// Sections maps every ## heading to its body text
+	Sections map[string]string
+}
+
+var themeFieldNames = []string{"Type", "Status"}
+
+// ParseTheme parses a theme document
+func ParseTheme(content string) *Theme {
+	doc := Parse(content)
+	o := parseOutline(doc, themeFieldNames)
+	t := &Theme{
+		Doc:         doc,
+		Name:        o.name,
+		Description: o.description,
+		Status:      o.fields["Status"],
+		Sections:    o.sections,
+	}
+	if display, ok := o.fields["Type"]; ok {
+		t.ThemeType = ThemeTypeFromDisplay(display)
+	}
+	return t
+}
+
+// ThemeTypeFromDisplay maps a display name such as "Vision Statement" to its type
+func ThemeTypeFromDisplay(display string) string {
+	switch display {
+	case "Vision Statement":
+		return ThemeTypeVision
+	case "Market Context":
+		return ThemeTypeMarketContext
+	case "Strategic Theme":
+		return ThemeTypeStrategic
+	default:
+		return strings.ToLower(strings.ReplaceAll(display, " ", "-"))
+	}
+}
+
+// ThemeTypeDisplay maps a theme type to the display name written to **Type:**
+func ThemeTypeDisplay(themeType string) string {
+	switch themeType {
+	case ThemeTypeVision:
+		return "Vision Statement"
+	case ThemeTypeMarketContext:
+		return "Market Context"
+	default:
+		return "Strategic Theme"
+	}
+}
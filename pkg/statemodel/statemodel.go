@@ -0,0 +1,269 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Package statemodel enforces the INTENT four-dimension state model. Each
+// dimension (lifecycle state, workflow stage, stage status, approval status)
+// has a table of allowed transitions, and a list of guards checks the
+// combination of all four. Every state update path validates against it.
+package statemodel
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// Field names, matching the JSON and column names
+const (
+	FieldLifecycleState = "lifecycle_state"
+	FieldWorkflowStage  = "workflow_stage"
+	FieldStageStatus    = "stage_status"
+	FieldApprovalStatus = "approval_status"
+)
+
+// Rule identifiers reported in models.StateTransitionError
+const (
+	RuleInvalidValue                   = "invalid-value"
+	RuleTransitionNotAllowed           = "transition-not-allowed"
+	RulePreviousPhaseApproved          = "previous-phase-approved"
+	RuleRetiredHasNoOpenWork           = "retired-has-no-open-work"
+	RuleImplementedNeedsImplementation = "implemented-requires-implementation-stage"
+	RuleRejectedNotStageApproved       = "rejected-cannot-be-stage-approved"
+)
+
+// State is the four-dimension state of a capability, enabler or story card.
+// An empty field means unknown (legacy rows) and is not checked.
+type State struct {
+	LifecycleState string `json:"lifecycle_state"`
+	WorkflowStage  string `json:"workflow_stage"`
+	StageStatus    string `json:"stage_status"`
+	ApprovalStatus string `json:"approval_status"`
+}
+
+// Apply returns s with the fields set in req
+func (s State) Apply(req models.UpdateEntityStateRequest) State {
+	if req.LifecycleState != nil {
+		s.LifecycleState = *req.LifecycleState
+	}
+	if req.WorkflowStage != nil {
+		s.WorkflowStage = *req.WorkflowStage
+	}
+	if req.StageStatus != nil {
+		s.StageStatus = *req.StageStatus
+	}
+	if req.ApprovalStatus != nil {
+		s.ApprovalStatus = *req.ApprovalStatus
+	}
+	return s
+}
+
+// Get returns the value of one of the Fields
+func (s State) Get(field string) string {
+	switch field {
+	case FieldLifecycleState:
+		return s.LifecycleState
+	case FieldWorkflowStage:
+		return s.WorkflowStage
+	case FieldStageStatus:
+		return s.StageStatus
+	case FieldApprovalStatus:
+		return s.ApprovalStatus
+	}
+	return ""
+}
+
+// Fields lists the dimensions in the order they are validated
+var Fields = []string{FieldLifecycleState, FieldWorkflowStage, FieldStageStatus, FieldApprovalStatus}
+
+// Stages is the INTENT workflow in order
+var Stages = []string{
+	string(models.INTENTStageIntent),
+	string(models.INTENTStageSpecification),
+	string(models.INTENTStageUIDesign),
+	string(models.INTENTStageImplementation),
+	string(models.INTENTStageControlLoop),
+}
+
+// Transitions maps each field to its allowed moves: current value -> values
+// it may change to. Keeping a value is always allowed. Workflow stages only
+// move forward one step (UI design may be skipped) but may return to any
+// earlier stage for rework.
+var Transitions = map[string]map[string][]string{
+	FieldLifecycleState: {
+		string(models.LifecycleStateDraft):       {string(models.LifecycleStateActive), string(models.LifecycleStateRetired)},
+		string(models.LifecycleStateActive):      {string(models.LifecycleStateDraft), string(models.LifecycleStateImplemented), string(models.LifecycleStateRetired)},
+		string(models.LifecycleStateImplemented): {string(models.LifecycleStateActive), string(models.LifecycleStateMaintained), string(models.LifecycleStateRetired)},
+		string(models.LifecycleStateMaintained):  {string(models.LifecycleStateActive), string(models.LifecycleStateRetired)},
+		string(models.LifecycleStateRetired):     {string(models.LifecycleStateDraft)},
+	},
+	FieldWorkflowStage: {
+		string(models.INTENTStageIntent):         {string(models.INTENTStageSpecification)},
+		string(models.INTENTStageSpecification):  {string(models.INTENTStageIntent), string(models.INTENTStageUIDesign), string(models.INTENTStageImplementation)},
+		string(models.INTENTStageUIDesign):       {string(models.INTENTStageIntent), string(models.INTENTStageSpecification), string(models.INTENTStageImplementation)},
+		string(models.INTENTStageImplementation): {string(models.INTENTStageIntent), string(models.INTENTStageSpecification), string(models.INTENTStageUIDesign), string(models.INTENTStageControlLoop)},
+		string(models.INTENTStageControlLoop):    {string(models.INTENTStageIntent), string(models.INTENTStageSpecification), string(models.INTENTStageUIDesign), string(models.INTENTStageImplementation)},
+	},
+	FieldStageStatus: {
+		string(models.StageStatusInProgress):       {string(models.StageStatusReadyForApproval), string(models.StageStatusBlocked)},
+		string(models.StageStatusReadyForApproval): {string(models.StageStatusInProgress), string(models.StageStatusApproved), string(models.StageStatusBlocked)},
+		string(models.StageStatusApproved):         {string(models.StageStatusInProgress)},
+		string(models.StageStatusBlocked):          {string(models.StageStatusInProgress)},
+	},
+	FieldApprovalStatus: {
+		string(models.INTENTApprovalPending):  {string(models.INTENTApprovalApproved), string(models.INTENTApprovalRejected)},
+		string(models.INTENTApprovalApproved): {string(models.INTENTApprovalPending)},
+		string(models.INTENTApprovalRejected): {string(models.INTENTApprovalPending)},
+	},
+}
+
+// Context carries what guards need beyond the entity's own state
+type Context struct {
+	EntityType string
+	EntityID   string
+	// ApprovedPhases holds the workflow stages approved for the workspace
+	ApprovedPhases map[string]bool
+}
+
+// Guard is a rule over the whole state change. Allows returns false when
+// the change from -> to must be rejected.
+type Guard struct {
+	Rule        string                                 `json:"rule"`
+	Field       string                                 `json:"field"` // Field reported as blocked
+	Description string                                 `json:"description"`
+	Allows      func(from, to State, ctx Context) bool `json:"-"`
+}
+
+// Guards are checked in order after the per-field transitions
+var Guards = []Guard{
+	{
+		Rule:        RulePreviousPhaseApproved,
+		Field:       FieldWorkflowStage,
+		Description: "A workflow stage can only be entered once the stage before it is approved, either for this entity or for the whole workspace",
+		Allows: func(from, to State, ctx Context) bool {
+			if from.WorkflowStage == "" || stageIndex(to.WorkflowStage) <= stageIndex(from.WorkflowStage) {
+				return true
+			}
+			return from.StageStatus == string(models.StageStatusApproved) || ctx.ApprovedPhases[from.WorkflowStage]
+		},
+	},
+	{
+		Rule:        RuleRetiredHasNoOpenWork,
+		Field:       FieldLifecycleState,
+		Description: "A retired entity cannot have a stage in progress or awaiting approval",
+		Allows: func(from, to State, ctx Context) bool {
+			return to.LifecycleState != string(models.LifecycleStateRetired) ||
+				(to.StageStatus != string(models.StageStatusInProgress) && to.StageStatus != string(models.StageStatusReadyForApproval))
+		},
+	},
+	{
+		Rule:        RuleImplementedNeedsImplementation,
+		Field:       FieldLifecycleState,
+		Description: "Only entities in the implementation or control loop stage can be implemented or maintained",
+		Allows: func(from, to State, ctx Context) bool {
+			if to.LifecycleState != string(models.LifecycleStateImplemented) && to.LifecycleState != string(models.LifecycleStateMaintained) {
+				return true
+			}
+			return to.WorkflowStage == "" || stageIndex(to.WorkflowStage) >= stageIndex(string(models.INTENTStageImplementation))
+		},
+	},
+	{
+		Rule:        RuleRejectedNotStageApproved,
+		Field:       FieldStageStatus,
+		Description: "A rejected entity cannot have an approved stage",
+		Allows: func(from, to State, ctx Context) bool {
+			return to.ApprovalStatus != string(models.INTENTApprovalRejected) || to.StageStatus != string(models.StageStatusApproved)
+		},
+	},
+}
+
+func stageIndex(stage string) int {
+	for i, s := range Stages {
+		if s == stage {
+			return i
+		}
+	}
+	return -1
+}
+
+// values returns every known value of field, in table order
+func values(field string) []string {
+	switch field {
+	case FieldLifecycleState:
+		return []string{
+			string(models.LifecycleStateDraft), string(models.LifecycleStateActive), string(models.LifecycleStateImplemented),
+			string(models.LifecycleStateMaintained), string(models.LifecycleStateRetired),
+		}
+	case FieldWorkflowStage:
+		return Stages
+	case FieldStageStatus:
+		return []string{
+			string(models.StageStatusInProgress), string(models.StageStatusReadyForApproval),
+			string(models.StageStatusApproved), string(models.StageStatusBlocked),
+		}
+	case FieldApprovalStatus:
+		return []string{string(models.INTENTApprovalPending), string(models.INTENTApprovalApproved), string(models.INTENTApprovalRejected)}
+	}
+	return nil
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks a change from -> to and returns a
+// *models.StateTransitionError naming the first rule it breaks
+func Validate(from, to State, ctx Context) error {
+	reject := func(rule, field, message string, allowed []string) error {
+		return &models.StateTransitionError{
+			Code:       models.StateTransitionRejected,
+			EntityType: ctx.EntityType,
+			EntityID:   ctx.EntityID,
+			Rule:       rule,
+			Field:      field,
+			From:       from.Get(field),
+			To:         to.Get(field),
+			Allowed:    allowed,
+			Message:    message,
+		}
+	}
+
+	for _, field := range Fields {
+		old, next := from.Get(field), to.Get(field)
+		if next == "" || next == old {
+			continue
+		}
+		if !contains(values(field), next) {
+			return reject(RuleInvalidValue, field,
+				fmt.Sprintf("%s %q is not a valid value; expected one of %s", field, next, strings.Join(values(field), ", ")),
+				values(field))
+		}
+		if old == "" || !contains(values(field), old) {
+			continue // Unknown current value, nothing to transition from
+		}
+		if allowed := Transitions[field][old]; !contains(allowed, next) {
+			return reject(RuleTransitionNotAllowed, field,
+				fmt.Sprintf("%s cannot change from %s to %s; allowed: %s", field, old, next, strings.Join(allowed, ", ")),
+				allowed)
+		}
+	}
+
+	// A guard the current state already breaks is not enforced, so legacy
+	// rows can still be moved towards a valid state
+	for _, g := range Guards {
+		if !g.Allows(from, to, ctx) && g.Allows(from, from, ctx) {
+			return reject(g.Rule, g.Field, g.Description, nil)
+		}
+	}
+	return nil
+}
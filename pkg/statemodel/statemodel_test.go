@@ -0,0 +1,58 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package statemodel
+
+import (
+	"testing"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+func TestValidate(t *testing.T) {
+	inSpec := State{LifecycleState: "active", WorkflowStage: "specification", StageStatus: "in_progress", ApprovalStatus: "pending"}
+	approvedSpec := State{LifecycleState: "active", WorkflowStage: "specification", StageStatus: "approved", ApprovalStatus: "approved"}
+
+	tests := []struct {
+		name   string
+		from   State
+		to     State
+		phases map[string]bool
+		rule   string
+		field  string
+	}{
+		{name: "no change", from: inSpec, to: inSpec},
+		{name: "unknown value", from: inSpec, to: State{LifecycleState: "archived"}, rule: RuleInvalidValue, field: FieldLifecycleState},
+		{name: "skip stages", from: State{WorkflowStage: "intent", StageStatus: "approved"}, to: State{WorkflowStage: "control_loop", StageStatus: "approved"}, rule: RuleTransitionNotAllowed, field: FieldWorkflowStage},
+		{name: "enter implementation unapproved", from: inSpec, to: State{LifecycleState: "active", WorkflowStage: "implementation", StageStatus: "in_progress", ApprovalStatus: "pending"}, rule: RulePreviousPhaseApproved, field: FieldWorkflowStage},
+		{name: "enter implementation after entity approval", from: approvedSpec, to: State{LifecycleState: "active", WorkflowStage: "implementation", StageStatus: "in_progress", ApprovalStatus: "approved"}},
+		{name: "enter implementation after phase approval", from: inSpec, to: State{LifecycleState: "active", WorkflowStage: "implementation", StageStatus: "in_progress", ApprovalStatus: "pending"}, phases: map[string]bool{"specification": true}},
+		{name: "rework to earlier stage", from: inSpec, to: State{LifecycleState: "active", WorkflowStage: "intent", StageStatus: "in_progress", ApprovalStatus: "pending"}},
+		{name: "retire with work in progress", from: inSpec, to: State{LifecycleState: "retired", WorkflowStage: "specification", StageStatus: "in_progress", ApprovalStatus: "pending"}, rule: RuleRetiredHasNoOpenWork, field: FieldLifecycleState},
+		{name: "legacy invalid row can be fixed", from: State{LifecycleState: "retired", StageStatus: "in_progress"}, to: State{LifecycleState: "retired", StageStatus: "blocked"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.from, tt.to, Context{EntityType: "capability", EntityID: "CAP-000001", ApprovedPhases: tt.phases})
+			if tt.rule == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			te, ok := err.(*models.StateTransitionError)
+			if !ok {
+				t.Fatalf("expected a StateTransitionError, got %v", err)
+			}
+			if te.Rule != tt.rule || te.Field != tt.field || te.Code != models.StateTransitionRejected || te.EntityID != "CAP-000001" {
+				t.Errorf("got rule %q field %q, want %q %q (%+v)", te.Rule, te.Field, tt.rule, tt.field, te)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Package vault encrypts stored secrets such as integration credentials with
+// envelope encryption. Each record is encrypted with its own random data key
+// (AES-256-GCM), and the data key is stored wrapped, i.e. encrypted, with a
+// master key that never leaves the service's configuration.
+//
+// Master keys are identified by a hash, stored next to each wrapped data key.
+// To rotate the master key, configure the new key as the master key and the
+// old one as a previous key, then re-wrap the stored data keys (intentrcli
+// vault-rotate); the encrypted records themselves are not touched.
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeySize is the size of master and data keys in bytes
+const KeySize = 32
+
+// Environment variables that configure the master keys
+const (
+	EnvMasterKey          = "INTENTR_MASTER_KEY"           // Base64 master key
+	EnvMasterKeyFile      = "INTENTR_MASTER_KEY_FILE"      // File holding the base64 master key
+	EnvPreviousMasterKeys = "INTENTR_PREVIOUS_MASTER_KEYS" // Comma-separated base64 keys still accepted for unwrapping
+)
+
+var (
+	ErrNoMasterKey      = errors.New("no master key configured")
+	ErrUnknownMasterKey = errors.New("data key is wrapped with an unknown master key")
+	ErrDecrypt          = errors.New("failed to decrypt")
+)
+
+// Vault wraps data keys with the current master key and unwraps them with
+// any configured master key
+type Vault struct {
+	current string            // ID of the master key new data keys are wrapped with
+	keys    map[string][]byte // Master keys by ID
+}
+
+// New creates a vault that wraps data keys with current and also unwraps
+// data keys wrapped with one of the previous master keys
+func New(current []byte, previous ...[]byte) (*Vault, error) {
+	v := &Vault{current: KeyID(current), keys: map[string][]byte{}}
+	for _, key := range append([][]byte{current}, previous...) {
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("master key must be %d bytes, got %d", KeySize, len(key))
+		}
+		v.keys[KeyID(key)] = key
+	}
+	return v, nil
+}
+
+// FromEnv creates a vault from the master key in INTENTR_MASTER_KEY or the
+// file named by INTENTR_MASTER_KEY_FILE, and the previous master keys in
+// INTENTR_PREVIOUS_MASTER_KEYS. It returns ErrNoMasterKey if no master key
+// is configured.
+func FromEnv() (*Vault, error) {
+	encoded := os.Getenv(EnvMasterKey)
+	if path := os.Getenv(EnvMasterKeyFile); encoded == "" && path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read master key file: %w", err)
+		}
+		encoded = string(data)
+	}
+	if strings.TrimSpace(encoded) == "" {
+		return nil, ErrNoMasterKey
+	}
+
+	current, err := decodeKey(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid master key: %w", err)
+	}
+	var previous [][]byte
+	for _, encoded := range strings.Split(os.Getenv(EnvPreviousMasterKeys), ",") {
+		if strings.TrimSpace(encoded) == "" {
+			continue
+		}
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid previous master key: %w", err)
+		}
+		previous = append(previous, key)
+	}
+	return New(current, previous...)
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+}
+
+// GenerateKey returns a new random base64 master key
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// KeyID returns the ID of a master key: the first 8 bytes of its SHA-256
+// hash, hex encoded
+func KeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// CurrentKeyID returns the ID of the master key new data keys are wrapped with
+func (v *Vault) CurrentKeyID() string {
+	return v.current
+}
+
+// NewDataKey returns a new data key, the key wrapped with the current master
+// key, and the master key's ID
+func (v *Vault) NewDataKey() (key []byte, wrapped, keyID string, err error) {
+	key = make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, "", "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+	wrapped, err = Encrypt(v.keys[v.current], string(key), "data-key")
+	if err != nil {
+		return nil, "", "", err
+	}
+	return key, wrapped, v.current, nil
+}
+
+// UnwrapDataKey returns the data key wrapped with the master key keyID
+func (v *Vault) UnwrapDataKey(keyID, wrapped string) ([]byte, error) {
+	master, ok := v.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMasterKey, keyID)
+	}
+	key, err := Decrypt(master, wrapped, "data-key")
+	if err != nil {
+		return nil, err
+	}
+	return []byte(key), nil
+}
+
+// Rewrap re-wraps a data key with the current master key and returns it with
+// the current master key's ID
+func (v *Vault) Rewrap(keyID, wrapped string) (string, string, error) {
+	key, err := v.UnwrapDataKey(keyID, wrapped)
+	if err != nil {
+		return "", "", err
+	}
+	rewrapped, err := Encrypt(v.keys[v.current], string(key), "data-key")
+	if err != nil {
+		return "", "", err
+	}
+	return rewrapped, v.current, nil
+}
+
+// Encrypt encrypts plaintext with a key using AES-256-GCM and returns the
+// nonce and ciphertext, base64 encoded. The ciphertext only decrypts with the
+// same context, so it cannot be moved to another field.
+func Encrypt(key []byte, plaintext, context string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(plaintext), []byte(context))), nil
+}
+
+// Decrypt decrypts a value returned by Encrypt
+func Decrypt(key []byte, ciphertext, context string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", ErrDecrypt
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(context))
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plaintext), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
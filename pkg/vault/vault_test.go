@@ -0,0 +1,108 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package vault
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func TestEncrypt(t *testing.T) {
+	key := testKey(1)
+
+	sealed, err := Encrypt(key, "ghp_secret", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Decrypt(key, sealed, "token"); err != nil || got != "ghp_secret" {
+		t.Errorf("Decrypt() = %q, %v", got, err)
+	}
+	if _, err := Decrypt(key, sealed, "email"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Decrypt(other context) = %v, want ErrDecrypt", err)
+	}
+	if _, err := Decrypt(testKey(2), sealed, "token"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Decrypt(other key) = %v, want ErrDecrypt", err)
+	}
+	if again, _ := Encrypt(key, "ghp_secret", "token"); again == sealed {
+		t.Error("Encrypt() reused a nonce")
+	}
+}
+
+func TestDataKeyRotation(t *testing.T) {
+	old, err := New(testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, wrapped, keyID, err := old.NewDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID != KeyID(testKey(1)) {
+		t.Errorf("NewDataKey() key ID = %s, want %s", keyID, KeyID(testKey(1)))
+	}
+
+	rotated, err := New(testKey(2), testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewrapped, newID, err := rotated.Rewrap(keyID, wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newID != rotated.CurrentKeyID() || newID == keyID {
+		t.Errorf("Rewrap() key ID = %s, want %s", newID, rotated.CurrentKeyID())
+	}
+
+	current, err := New(testKey(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := current.UnwrapDataKey(newID, rewrapped); err != nil || !bytes.Equal(got, key) {
+		t.Errorf("UnwrapDataKey(rewrapped) = %x, %v, want %x", got, err, key)
+	}
+	if _, err := current.UnwrapDataKey(keyID, wrapped); !errors.Is(err, ErrUnknownMasterKey) {
+		t.Errorf("UnwrapDataKey(retired key) = %v, want ErrUnknownMasterKey", err)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(EnvMasterKey, "")
+	t.Setenv(EnvMasterKeyFile, "")
+	t.Setenv(EnvPreviousMasterKeys, "")
+	if _, err := FromEnv(); !errors.Is(err, ErrNoMasterKey) {
+		t.Errorf("FromEnv(unset) = %v, want ErrNoMasterKey", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "master.key")
+	if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(testKey(2))+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvMasterKeyFile, path)
+	t.Setenv(EnvPreviousMasterKeys, base64.StdEncoding.EncodeToString(testKey(1)))
+	v, err := FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.CurrentKeyID() != KeyID(testKey(2)) || len(v.keys) != 2 {
+		t.Errorf("FromEnv() = current %s with %d keys", v.CurrentKeyID(), len(v.keys))
+	}
+
+	t.Setenv(EnvMasterKey, base64.StdEncoding.EncodeToString([]byte("short")))
+	if _, err := FromEnv(); err == nil {
+		t.Error("FromEnv() accepted a short master key")
+	}
+}
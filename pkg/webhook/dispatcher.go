@@ -0,0 +1,184 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// Store is the delivery queue a Dispatcher works from
+type Store interface {
+	ClaimDue(limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	RecordAttempt(deliveryID int64, a models.WebhookAttempt) error
+}
+
+// Dispatcher sends due deliveries and records the outcome
+type Dispatcher struct {
+	store  Store
+	client *http.Client
+	wake   chan struct{}
+
+	lastClaimErr string // Repeated claim errors (e.g. missing migration) are logged once
+
+	// Interval is how often the queue is checked when not woken
+	Interval time.Duration
+	// MaxAttempts is the number of attempts before a delivery is marked failed
+	MaxAttempts int
+	// BatchSize is the number of deliveries claimed at a time
+	BatchSize int
+	// Now is the clock used for signatures
+	Now func() time.Time
+	// AllowPrivateNetworks permits deliveries to loopback, link-local and
+	// private addresses, which are refused by default
+	AllowPrivateNetworks bool
+}
+
+// NewDispatcher creates a dispatcher for store
+func NewDispatcher(store Store) *Dispatcher {
+	d := &Dispatcher{
+		store:       store,
+		wake:        make(chan struct{}, 1),
+		Interval:    5 * time.Second,
+		MaxAttempts: 10,
+		BatchSize:   20,
+		Now:         time.Now,
+	}
+	// Connections go straight to the resolved address, never via a proxy,
+	// so that dialControl sees where deliveries are sent
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: d.dialControl}
+	d.client = &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: 10 * time.Second},
+	}
+	return d
+}
+
+// Backoff returns the delay after the given failed attempt (1-based):
+// 30s, 1m, 2m, 4m ... capped at one hour
+func Backoff(attempt int) time.Duration {
+	d := 30 * time.Second
+	for i := 1; i < attempt && d < time.Hour; i++ {
+		d *= 2
+	}
+	if d > time.Hour {
+		d = time.Hour
+	}
+	return d
+}
+
+// Wake makes Run check the queue now, e.g. right after an event is queued
+func (d *Dispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run sends deliveries until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		for d.DispatchDue() == d.BatchSize {
+			// A full batch: more may be waiting
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// DispatchDue sends one batch of due deliveries and returns its size
+func (d *Dispatcher) DispatchDue() int {
+	lease := d.client.Timeout + 30*time.Second
+	deliveries, err := d.store.ClaimDue(d.BatchSize, lease)
+	if err != nil {
+		if err.Error() != d.lastClaimErr {
+			log.Printf("Webhook dispatcher: %v", err)
+			d.lastClaimErr = err.Error()
+		}
+		return 0
+	}
+	d.lastClaimErr = ""
+	for _, delivery := range deliveries {
+		attempt := d.Send(delivery)
+		if err := d.store.RecordAttempt(delivery.ID, attempt); err != nil {
+			log.Printf("Webhook dispatcher: %v", err)
+		}
+	}
+	return len(deliveries)
+}
+
+// Send makes one attempt at a claimed delivery
+func (d *Dispatcher) Send(delivery models.WebhookDelivery) models.WebhookAttempt {
+	start := time.Now()
+	attempt := d.send(delivery)
+	attempt.Duration = time.Since(start)
+
+	if attempt.Status == models.WebhookDeliverySucceeded {
+		return attempt
+	}
+	if n := delivery.Attempts + 1; n < d.MaxAttempts {
+		attempt.Status = models.WebhookDeliveryRetrying
+		attempt.RetryIn = Backoff(n)
+	} else {
+		attempt.Status = models.WebhookDeliveryFailed
+	}
+	return attempt
+}
+
+func (d *Dispatcher) send(delivery models.WebhookDelivery) models.WebhookAttempt {
+	sub := delivery.Subscription
+	if sub == nil {
+		return models.WebhookAttempt{Error: "subscription not loaded"}
+	}
+	body, err := Body(delivery.Payload, sub.Format)
+	if err != nil {
+		return models.WebhookAttempt{Error: err.Error()}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return models.WebhookAttempt{Error: fmt.Sprintf("invalid webhook URL: %v", err)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "IntentR-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderEventID, delivery.EventID)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, d.Now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return models.WebhookAttempt{Error: err.Error()}
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+
+	attempt := models.WebhookAttempt{ResponseStatus: resp.StatusCode, ResponseBody: string(respBody)}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		attempt.Status = models.WebhookDeliverySucceeded
+	} else {
+		attempt.Error = fmt.Sprintf("endpoint returned %s", resp.Status)
+	}
+	return attempt
+}
@@ -0,0 +1,140 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+// Package webhook signs and delivers outbound webhook events. Events are
+// queued in Postgres (repository.WebhookRepository) and a Dispatcher sends
+// them, retrying failures with exponential backoff.
+//
+// Every request carries an X-IntentR-Signature header of the form
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">" computed with the
+// subscription secret. Receivers check it with Verify.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+// Request headers
+const (
+	HeaderSignature = "X-IntentR-Signature"
+	HeaderEvent     = "X-IntentR-Event"
+	HeaderEventID   = "X-IntentR-Event-ID"
+	HeaderDelivery  = "X-IntentR-Delivery"
+)
+
+// NewEvent builds an event envelope with a fresh ID
+func NewEvent(eventType, workspaceID, summary string, data interface{}) (models.WebhookEvent, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return models.WebhookEvent{}, fmt.Errorf("failed to encode webhook data: %w", err)
+	}
+	return models.WebhookEvent{
+		ID:          uuid.NewString(),
+		Type:        eventType,
+		WorkspaceID: workspaceID,
+		OccurredAt:  time.Now().UTC(),
+		Summary:     summary,
+		Data:        raw,
+	}, nil
+}
+
+// NewSecret returns a random signing secret
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// ValidFormat reports whether format is a supported payload format
+func ValidFormat(format string) bool {
+	switch format {
+	case models.WebhookFormatJSON, models.WebhookFormatSlack, models.WebhookFormatTeams:
+		return true
+	}
+	return false
+}
+
+// Body renders a queued event envelope in a subscription's format
+func Body(payload []byte, format string) ([]byte, error) {
+	if format == "" || format == models.WebhookFormatJSON {
+		return payload, nil
+	}
+	var event models.WebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook event: %w", err)
+	}
+	// Slack and Teams incoming webhooks both accept a plain text message
+	return json.Marshal(map[string]string{"text": event.Summary})
+}
+
+// Sign returns the signature header value for body sent at t
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + mac(secret, ts, body)
+}
+
+func mac(secret, ts string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Verify checks a signature header against body. Signatures older than
+// tolerance are rejected to limit replays; a zero tolerance skips that check.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			sigs = append(sigs, value)
+		}
+	}
+	if ts == "" || len(sigs) == 0 {
+		return fmt.Errorf("malformed signature header")
+	}
+
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("malformed signature timestamp: %w", err)
+	}
+	if tolerance > 0 {
+		if age := now.Sub(time.Unix(sec, 0)); age > tolerance || age < -tolerance {
+			return fmt.Errorf("signature timestamp outside tolerance")
+		}
+	}
+
+	want := mac(secret, ts, body)
+	for _, sig := range sigs {
+		if hmac.Equal([]byte(sig), []byte(want)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("signature mismatch")
+}
@@ -0,0 +1,179 @@
+// IntentR — Copyright © 2025 James Reynolds
+//
+// This file is part of IntentR.
+// You may use this file under either:
+//   • The AGPLv3 Open Source License, OR
+//   • The IntentR Commercial License
+// See the LICENSE.AGPL and LICENSE.COMMERCIAL files for details.
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jareynolds/intentr/pkg/models"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1735689600, 0)
+	body := []byte(`{"type":"phase.approved"}`)
+	header := Sign("s3cret", now, body)
+
+	if err := Verify("s3cret", header, body, 5*time.Minute, now.Add(time.Minute)); err != nil {
+		t.Fatalf("Verify() = %v", err)
+	}
+	if err := Verify("other", header, body, 5*time.Minute, now); err == nil {
+		t.Error("Verify() accepted the wrong secret")
+	}
+	if err := Verify("s3cret", header, []byte(`{"type":"phase.revoked"}`), 5*time.Minute, now); err == nil {
+		t.Error("Verify() accepted a modified body")
+	}
+	if err := Verify("s3cret", header, body, 5*time.Minute, now.Add(time.Hour)); err == nil {
+		t.Error("Verify() accepted an expired signature")
+	}
+	if err := Verify("s3cret", "v1=abc", body, 0, now); err == nil {
+		t.Error("Verify() accepted a header without a timestamp")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute}
+	for i, w := range want {
+		if got := Backoff(i + 1); got != w {
+			t.Errorf("Backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+	if got := Backoff(30); got != time.Hour {
+		t.Errorf("Backoff(30) = %v, want cap of 1h", got)
+	}
+}
+
+func TestBodyFormats(t *testing.T) {
+	event, err := NewEvent(models.WebhookEventPhaseApproved, "ws", "Specification phase approved", map[string]string{"phase": "specification"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, _ := json.Marshal(event)
+
+	got, err := Body(payload, models.WebhookFormatJSON)
+	if err != nil || string(got) != string(payload) {
+		t.Errorf("Body(json) = %s, %v; want the envelope", got, err)
+	}
+	got, err = Body(payload, models.WebhookFormatSlack)
+	if err != nil || string(got) != `{"text":"Specification phase approved"}` {
+		t.Errorf("Body(slack) = %s, %v", got, err)
+	}
+}
+
+type fakeStore struct {
+	queue    []models.WebhookDelivery
+	attempts map[int64]models.WebhookAttempt
+}
+
+func (f *fakeStore) ClaimDue(limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	claimed := f.queue
+	f.queue = nil
+	return claimed, nil
+}
+
+func (f *fakeStore) RecordAttempt(id int64, a models.WebhookAttempt) error {
+	f.attempts[id] = a
+	return nil
+}
+
+func TestDispatcher(t *testing.T) {
+	var gotSignature, gotEvent string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		gotSignature = r.Header.Get(HeaderSignature)
+		gotEvent = r.Header.Get(HeaderEvent)
+		gotBody, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	sub := func(path string) *models.WebhookSubscription {
+		return &models.WebhookSubscription{URL: srv.URL + path, Secret: "s3cret", Format: models.WebhookFormatJSON}
+	}
+	payload := json.RawMessage(`{"id":"e1","type":"approval.approved"}`)
+	store := &fakeStore{
+		queue: []models.WebhookDelivery{
+			{ID: 1, EventType: models.WebhookEventApprovalApproved, Payload: payload, Subscription: sub("/ok")},
+			{ID: 2, EventType: models.WebhookEventApprovalApproved, Payload: payload, Attempts: 2, Subscription: sub("/down")},
+			{ID: 3, EventType: models.WebhookEventApprovalApproved, Payload: payload, Attempts: 9, Subscription: sub("/down")},
+		},
+		attempts: map[int64]models.WebhookAttempt{},
+	}
+
+	d := NewDispatcher(store)
+	d.AllowPrivateNetworks = true // The test server listens on loopback
+	if n := d.DispatchDue(); n != 3 {
+		t.Fatalf("DispatchDue() = %d, want 3", n)
+	}
+
+	if a := store.attempts[1]; a.Status != models.WebhookDeliverySucceeded || a.ResponseStatus != http.StatusOK {
+		t.Errorf("delivery 1 = %+v, want succeeded", a)
+	}
+	if err := Verify("s3cret", gotSignature, gotBody, time.Minute, time.Now()); err != nil {
+		t.Errorf("received signature does not verify: %v", err)
+	}
+	if gotEvent != models.WebhookEventApprovalApproved {
+		t.Errorf("%s = %q", HeaderEvent, gotEvent)
+	}
+
+	if a := store.attempts[2]; a.Status != models.WebhookDeliveryRetrying || a.RetryIn != Backoff(3) || a.ResponseStatus != http.StatusServiceUnavailable {
+		t.Errorf("delivery 2 = %+v, want retrying after %v", a, Backoff(3))
+	}
+	if a := store.attempts[3]; a.Status != models.WebhookDeliveryFailed {
+		t.Errorf("delivery 3 = %+v, want failed after MaxAttempts", a)
+	}
+}
+
+func TestForbiddenDestinations(t *testing.T) {
+	for rawURL, forbidden := range map[string]bool{
+		"http://127.0.0.1:8080/hook":              true,
+		"http://localhost/hook":                   true,
+		"http://api.localhost/hook":               true,
+		"http://10.1.2.3/hook":                    true,
+		"http://192.168.0.10/hook":                true,
+		"http://169.254.169.254/latest/meta-data": true,
+		"http://[::1]/hook":                       true,
+		"http://[fe80::1]/hook":                   true,
+		"http://0.0.0.0/hook":                     true,
+		"https://93.184.216.34/hook":              false,
+		"https://[2606:4700::6810:84e5]/hook":     false,
+	} {
+		if err := CheckURL(context.Background(), rawURL); errors.Is(err, ErrForbiddenDestination) != forbidden {
+			t.Errorf("CheckURL(%q) = %v, want forbidden %v", rawURL, err, forbidden)
+		}
+	}
+	if err := CheckURL(context.Background(), "ftp://example.com/hook"); err == nil {
+		t.Error("CheckURL(ftp) = nil, want an error")
+	}
+
+	// Deliveries check the address they connect to, whatever the URL said
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("delivery reached a loopback address")
+	}))
+	defer srv.Close()
+	store := &fakeStore{
+		queue: []models.WebhookDelivery{{ID: 1, EventType: models.WebhookEventPing, Payload: json.RawMessage(`{}`),
+			Subscription: &models.WebhookSubscription{URL: srv.URL, Secret: "s3cret", Format: models.WebhookFormatJSON}}},
+		attempts: map[int64]models.WebhookAttempt{},
+	}
+	NewDispatcher(store).DispatchDue()
+	if a := store.attempts[1]; a.Status != models.WebhookDeliveryRetrying || !strings.Contains(a.Error, ErrForbiddenDestination.Error()) {
+		t.Errorf("delivery to loopback = %+v, want refused", a)
+	}
+}
@@ -833,7 +833,9 @@ app.post('/git/pull', async (req, res) => {
 // POST /git/push - Push changes to remote
 app.post('/git/push', async (req, res) => {
   try {
-    const { workspace, setUpstream = false, token } = req.body;
+    const { workspace, setUpstream = false } = req.body;
+    // Credentials stay on the servers: the browser no longer sends a token
+    const token = process.env.GITHUB_TOKEN;
     const cwd = getWorkspacePath(workspace);
 
     // Get current branch
@@ -1145,14 +1147,17 @@ app.post('/git/remote', async (req, res) => {
 // POST /git/create-repo - Create a new GitHub repository
 app.post('/git/create-repo', async (req, res) => {
   try {
-    const { workspace, name, private: isPrivate = true, token } = req.body;
+    const { workspace, name, private: isPrivate = true } = req.body;
+    // Repositories are created by the integration service with the user's
+    // stored GitHub token; this endpoint only works with the server's own
+    const token = process.env.GITHUB_TOKEN;
 
     if (!name) {
       return res.status(400).json({ error: 'Repository name is required' });
     }
 
     if (!token) {
-      return res.status(400).json({ error: 'GitHub token is required' });
+      return res.status(400).json({ error: 'GITHUB_TOKEN is not set on the spec server' });
     }
 
     // Create repo using GitHub API
@@ -1201,7 +1206,7 @@ app.post('/git/create-repo', async (req, res) => {
 // POST /generate-readme - Generate README.md from conception folder using AI
 app.post('/generate-readme', async (req, res) => {
   try {
-    const { workspace, apiKey } = req.body;
+    const { workspace } = req.body;
 
     if (!workspace) {
       return res.status(400).json({ error: 'Workspace path is required' });
@@ -1242,7 +1247,7 @@ app.post('/generate-readme', async (req, res) => {
     const combinedContent = conceptionContent.join('\n\n---\n\n');
 
     // Get API key from request or environment
-    const anthropicKey = apiKey || process.env.ANTHROPIC_API_KEY;
+    const anthropicKey = process.env.ANTHROPIC_API_KEY;
     if (!anthropicKey) {
       return res.status(400).json({
         error: 'Anthropic API key required',
@@ -343,6 +343,7 @@ function App() {
                       {/* Public routes */}
                       <Route path="/login" element={<Login />} />
                       <Route path="/auth/google/callback" element={<GoogleCallback />} />
+                      <Route path="/auth/oidc/:provider/callback" element={<GoogleCallback />} />
 
                       {/* Protected routes */}
                       <Route
@@ -53,17 +53,18 @@ export const COLLABORATION_URL = BASE_URLS.collaboration;
 export const CLAUDE_PROXY_URL = BASE_URLS.claudeProxy;
 
 // Create axios instances for each service
-const createClient = (baseURL: string): AxiosInstance => {
+const createClient = (baseURL: string, timeout = 30000): AxiosInstance => {
   return axios.create({
     baseURL,
-    timeout: 30000,
+    timeout,
     headers: {
       'Content-Type': 'application/json',
     },
   });
 };
 
-export const integrationClient = createClient(BASE_URLS.integration);
+// Integration AI analysis can take minutes, like the service's write timeout
+export const integrationClient = createClient(BASE_URLS.integration, 5 * 60 * 1000);
 export const designClient = createClient(BASE_URLS.design);
 export const capabilityClient = createClient(BASE_URLS.capability);
 export const authClient = createClient(BASE_URLS.auth);
@@ -80,8 +81,71 @@ export const workspaceClient = createClient(BASE_URLS.workspace);
     }
     return config;
   });
+
+  // Access tokens are short-lived: on a 401, renew the token once with the
+  // refresh token and retry the request
+  client.interceptors.response.use(undefined, async (error) => {
+    const config = error.config;
+    if (error.response?.status === 401 && config && !config._retried && !config.url?.includes('/api/auth/refresh')) {
+      config._retried = true;
+      const token = await refreshAccessToken();
+      if (token) {
+        config.headers.Authorization = `Bearer ${token}`;
+        return client.request(config);
+      }
+    }
+    return Promise.reject(error);
+  });
 });
 
+// Concurrent 401s share one refresh; the refresh token is single-use
+let refreshing: Promise<string | null> | null = null;
+
+export function refreshAccessToken(): Promise<string | null> {
+  if (!refreshing) {
+    const refreshToken = sessionStorage.getItem('refresh_token');
+    const request: Promise<string | null> = refreshToken
+      ? axios
+          .post(`${BASE_URLS.auth}/api/auth/refresh`, { refreshToken })
+          .then((response) => {
+            sessionStorage.setItem('auth_token', response.data.token);
+            sessionStorage.setItem('refresh_token', response.data.refreshToken);
+            return response.data.token as string;
+          })
+          .catch(() => {
+            sessionStorage.removeItem('auth_token');
+            sessionStorage.removeItem('refresh_token');
+            return null;
+          })
+      : Promise.resolve(null);
+    refreshing = request.finally(() => {
+      refreshing = null;
+    });
+  }
+  return refreshing;
+}
+
+/**
+ * fetch for the integration service, which requires authentication: sends
+ * the access token and, like the axios clients, renews it once on a 401
+ */
+export async function integrationFetch(input: string, init: RequestInit = {}): Promise<Response> {
+  const send = (token: string | null) => {
+    const headers = new Headers(init.headers);
+    if (token) {
+      headers.set('Authorization', `Bearer ${token}`);
+    }
+    return fetch(input, { ...init, headers });
+  };
+
+  const response = await send(sessionStorage.getItem('auth_token'));
+  if (response.status !== 401) {
+    return response;
+  }
+  const token = await refreshAccessToken();
+  return token ? send(token) : response;
+}
+
 // Generic request wrapper with error handling
 export async function apiRequest<T>(
   client: AxiosInstance,
@@ -0,0 +1,136 @@
+import { integrationClient, apiRequest } from './client';
+
+// Integration credentials are stored encrypted by the integration service and
+// referenced by integration name; they are never read back into the browser.
+
+/** Stored integration holding the Anthropic API key used for AI features */
+export const ANTHROPIC_INTEGRATION = 'Anthropic';
+export const ANTHROPIC_KEY_FIELD = 'api_key';
+
+export interface StoredIntegration {
+  integration_name: string;
+  provider_url: string;
+  field_names: string[];
+  encrypted: boolean;
+  updated_at: string;
+}
+
+export async function listCredentials(): Promise<StoredIntegration[]> {
+  const response = await apiRequest<{ integrations: StoredIntegration[] }>(integrationClient, {
+    method: 'GET',
+    url: '/credentials',
+  });
+  return response.integrations;
+}
+
+/** Stores an integration's credentials; fields sent empty keep their stored value */
+export async function saveCredentials(
+  integrationName: string,
+  providerUrl: string,
+  fields: Record<string, string>
+): Promise<void> {
+  await apiRequest<void>(integrationClient, {
+    method: 'PUT',
+    url: `/credentials/${encodeURIComponent(integrationName)}`,
+    data: { provider_url: providerUrl, fields },
+  });
+}
+
+export async function deleteCredentials(integrationName: string): Promise<void> {
+  await apiRequest<void>(integrationClient, {
+    method: 'DELETE',
+    url: `/credentials/${encodeURIComponent(integrationName)}`,
+  });
+}
+
+/** Whether the user has stored an Anthropic API key */
+export async function hasAnthropicKey(): Promise<boolean> {
+  const integrations = await listCredentials();
+  return integrations.some(
+    (i) => i.integration_name === ANTHROPIC_INTEGRATION && i.field_names.includes(ANTHROPIC_KEY_FIELD)
+  );
+}
+
+/**
+ * Non-secret part of an integration's configuration, kept in local storage
+ * under integrationConfigKey(name); the field values live in the vault
+ */
+export interface IntegrationConfigRecord {
+  integration_name: string;
+  provider_url: string;
+  configured_at: string;
+  auth_method?: string;
+  field_names: string[];
+  is_custom?: boolean;
+}
+
+export function integrationConfigKey(integrationName: string): string {
+  return `integration_config_${integrationName.toLowerCase().replace(/\s+/g, '_')}`;
+}
+
+export function getIntegrationConfig(integrationName: string): IntegrationConfigRecord | null {
+  const stored = localStorage.getItem(integrationConfigKey(integrationName));
+  if (!stored) return null;
+  try {
+    const config = JSON.parse(stored);
+    return { ...config, field_names: config.field_names || Object.keys(config.fields || {}) };
+  } catch {
+    return null;
+  }
+}
+
+/** Stores an integration's credentials in the vault and its configuration locally */
+export async function saveIntegrationConfig(
+  integrationName: string,
+  providerUrl: string,
+  fields: Record<string, string>,
+  options: { authMethod?: string; isCustom?: boolean } = {}
+): Promise<IntegrationConfigRecord> {
+  await saveCredentials(integrationName, providerUrl, fields);
+  const config: IntegrationConfigRecord = {
+    integration_name: integrationName,
+    provider_url: providerUrl,
+    configured_at: new Date().toISOString(),
+    auth_method: options.authMethod,
+    field_names: Object.keys(fields),
+    is_custom: options.isCustom || undefined,
+  };
+  localStorage.setItem(integrationConfigKey(integrationName), JSON.stringify(config));
+  return config;
+}
+
+/**
+ * Moves credentials that older versions kept in local storage (the Settings
+ * API key and integration configurations' field values) into the vault
+ */
+export async function migrateBrowserCredentials(): Promise<void> {
+  const legacyKey = localStorage.getItem('anthropic_api_key');
+  if (legacyKey) {
+    await saveCredentials(ANTHROPIC_INTEGRATION, 'https://api.anthropic.com', {
+      [ANTHROPIC_KEY_FIELD]: legacyKey,
+    });
+    localStorage.removeItem('anthropic_api_key');
+  }
+
+  for (const storageKey of Object.keys(localStorage)) {
+    if (!storageKey.startsWith('integration_config_')) continue;
+    try {
+      const config = JSON.parse(localStorage.getItem(storageKey) || '{}');
+      if (!config.fields || !config.integration_name) continue;
+      await saveIntegrationConfig(config.integration_name, config.provider_url || '', config.fields, {
+        authMethod: config.auth_method,
+        isCustom: config.is_custom,
+      });
+    } catch (err) {
+      console.warn(`Failed to move ${storageKey} to the credential vault:`, err);
+    }
+  }
+
+  const customIntegrations = localStorage.getItem('custom_integrations');
+  if (customIntegrations?.includes('"configuredFields"')) {
+    const integrations = JSON.parse(customIntegrations).map(
+      ({ configuredFields: _configuredFields, ...integration }: Record<string, unknown>) => integration
+    );
+    localStorage.setItem('custom_integrations', JSON.stringify(integrations));
+  }
+}
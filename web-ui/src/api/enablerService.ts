@@ -1,4 +1,4 @@
-import { capabilityClient, apiRequest, SPEC_URL } from './client';
+import { capabilityClient, integrationClient, apiRequest, SPEC_URL } from './client';
 
 // ========================
 // INTENT State Model Types (aligned with STATE_MODEL.md)
@@ -782,9 +782,27 @@ export function getCriteriaPriorityDisplayName(priority: CriteriaPriority): stri
 }
 
 /**
- * Generate a unique ID with prefix
+ * Reserve new specification IDs from the server's allocator, which never
+ * hands out an ID already used in any workspace
  */
-export function generateId(prefix: 'ENB' | 'FR' | 'NFR' | 'AC'): string {
+export async function allocateIds(
+  workspacePath: string,
+  prefix: 'CAP' | 'ENB' | 'FR' | 'NFR' | 'TS',
+  count = 1
+): Promise<string[]> {
+  const response = await apiRequest<{ ids: string[] }>(integrationClient, {
+    method: 'POST',
+    url: '/ids/allocate',
+    data: { workspacePath, prefix, count },
+  });
+  return response.ids;
+}
+
+/**
+ * Generate an acceptance criteria ID. Specification IDs come from
+ * allocateIds or are assigned by the server on save.
+ */
+export function generateId(prefix: 'AC'): string {
   const timestamp = Date.now().toString().slice(-4);
   const random = Math.floor(Math.random() * 100).toString().padStart(2, '0');
   return `${prefix}-${timestamp}${random}`;
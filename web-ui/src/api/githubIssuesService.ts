@@ -0,0 +1,35 @@
+import { integrationClient, apiRequest } from './client';
+
+// Publishes a workspace's enablers as GitHub issues. GitHub credentials come
+// from the stored "GitHub" integration.
+
+export interface GitHubExportResult {
+  enabler_id: string;
+  file: string;
+  issue?: string;
+  url?: string;
+  state?: 'open' | 'closed';
+  result: 'created' | 'updated' | 'unchanged' | 'error';
+  error?: string;
+}
+
+export interface GitHubExportResponse {
+  repository: string;
+  results: GitHubExportResult[];
+  created: number;
+  updated: number;
+  errors: number;
+}
+
+/** Opens or updates one issue per enabler; projectId adds them to a Projects board */
+export async function exportGitHubIssues(
+  workspacePath: string,
+  repository: string,
+  projectId?: string
+): Promise<GitHubExportResponse> {
+  return apiRequest<GitHubExportResponse>(integrationClient, {
+    method: 'POST',
+    url: '/github/export-issues',
+    data: { workspace_path: workspacePath, repository, project_id: projectId || undefined },
+  });
+}
@@ -0,0 +1,87 @@
+import { integrationClient, apiRequest } from './client';
+
+// Two-way sync between capabilities/enablers and the Jira issues they are
+// linked to. Jira credentials come from the stored "Jira" integration.
+
+export type JiraSyncSide = 'intentr' | 'jira';
+
+export interface JiraSyncLink {
+  id: number;
+  workspace_id: string;
+  entity_type: 'capability' | 'enabler';
+  entity_id: string;
+  issue_key: string;
+  last_summary?: string;
+  last_jira_status?: string;
+  last_synced_at?: string;
+  last_result?: string;
+  last_message?: string;
+}
+
+export interface JiraSyncConflict {
+  field: string;
+  last: string;
+  intentr: string;
+  jira: string;
+}
+
+export interface JiraSyncResult {
+  link_id: number;
+  entity_type: string;
+  entity_id: string;
+  issue_key: string;
+  result: 'baseline' | 'unchanged' | 'synced' | 'conflict' | 'error';
+  pulled?: string[];
+  pushed?: string[];
+  conflicts?: JiraSyncConflict[];
+  error?: string;
+}
+
+export interface JiraSyncResponse {
+  workspace_id: string;
+  results: JiraSyncResult[];
+  synced: number;
+  conflicts: number;
+  errors: number;
+}
+
+export async function listJiraLinks(workspaceId: string): Promise<JiraSyncLink[]> {
+  const response = await apiRequest<{ links: JiraSyncLink[] }>(integrationClient, {
+    method: 'GET',
+    url: '/jira/links',
+    params: { workspace_id: workspaceId },
+  });
+  return response.links;
+}
+
+export async function linkJiraIssue(
+  workspaceId: string,
+  entityType: 'capability' | 'enabler',
+  entityId: string,
+  issueKey: string
+): Promise<JiraSyncLink> {
+  return apiRequest<JiraSyncLink>(integrationClient, {
+    method: 'POST',
+    url: '/jira/links',
+    data: { workspace_id: workspaceId, entity_type: entityType, entity_id: entityId, issue_key: issueKey },
+  });
+}
+
+export async function unlinkJiraIssue(linkId: number): Promise<void> {
+  await apiRequest<void>(integrationClient, {
+    method: 'DELETE',
+    url: `/jira/links/${linkId}`,
+  });
+}
+
+/** Syncs every link of a workspace; resolve picks the winning side of conflicts by entity ID */
+export async function syncJira(
+  workspaceId: string,
+  resolve?: Record<string, JiraSyncSide>
+): Promise<JiraSyncResponse> {
+  return apiRequest<JiraSyncResponse>(integrationClient, {
+    method: 'POST',
+    url: '/jira/sync',
+    data: { workspace_id: workspaceId, resolve },
+  });
+}
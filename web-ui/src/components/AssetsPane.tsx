@@ -1,6 +1,7 @@
 import React, { useState, useEffect } from 'react';
 import { Button } from './Button';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
+import { getIntegrationConfig } from '../api/credentialService';
 
 interface IntegrationFile {
   id: string;
@@ -61,20 +62,18 @@ export const AssetsPane: React.FC<AssetsPaneProps> = ({ workspaceId, onClose, on
       if (figmaFiles.length > 0) {
         setRefreshingThumbnails(true);
         try {
-          const figmaToken = JSON.parse(localStorage.getItem('integration_config_figma_api') || '{}').fields?.access_token;
-
-          if (figmaToken) {
+          // The integration service uses the stored Figma token
+          if (getIntegrationConfig('Figma API')) {
             // Fetch fresh thumbnail URLs for each file
             const updatedFiles = await Promise.all(
               figmaFiles.map(async (file) => {
                 try {
-                  const response = await fetch(`${INTEGRATION_URL}/fetch-file-meta`, {
+                  const response = await integrationFetch(`${INTEGRATION_URL}/fetch-file-meta`, {
                     method: 'POST',
                     headers: { 'Content-Type': 'application/json' },
                     body: JSON.stringify({
                       integration_name: 'Figma API',
                       file_key: file.id,
-                      credentials: { access_token: figmaToken }
                     })
                   });
 
@@ -14,7 +14,7 @@ import type {
 import { capabilityService } from '../api/services';
 import { Button } from './Button';
 import { useWorkspace } from '../context/WorkspaceContext';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 
 interface StoryboardFile {
   fileName: string;
@@ -101,7 +101,7 @@ export const CapabilityForm: React.FC<CapabilityFormProps> = ({
     console.log('[CapabilityForm] Loading storyboard files from:', currentWorkspace.projectFolder);
     setLoadingStoryboards(true);
     try {
-      const response = await fetch(`${INTEGRATION_URL}/story-files`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/story-files`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -247,7 +247,7 @@ export const CapabilityForm: React.FC<CapabilityFormProps> = ({
       }
 
       // Save to definition folder
-      const response = await fetch(`${INTEGRATION_URL}/save-specifications`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/save-specifications`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
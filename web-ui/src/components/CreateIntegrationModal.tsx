@@ -1,7 +1,7 @@
 import React, { useState, useCallback } from 'react';
 import { Button, Alert } from './index';
-import axios from 'axios';
-import { INTEGRATION_URL } from '../api/client';
+import { integrationClient } from '../api/client';
+import { saveIntegrationConfig } from '../api/credentialService';
 
 interface DiscoveredField {
   name: string;
@@ -36,7 +36,6 @@ export interface CustomIntegration {
   description: string;
   authType: string;
   fields: DiscoveredField[];
-  configuredFields: Record<string, string>;
   createdAt: string;
   lastTestedAt?: string;
   status: 'configured' | 'needs_config' | 'error';
@@ -107,7 +106,7 @@ export const CreateIntegrationModal: React.FC<CreateIntegrationModalProps> = ({
 
     try {
       // Call backend to test the connection
-      const response = await axios.post(`${INTEGRATION_URL}/test-connection`, {
+      const response = await integrationClient.post('/test-connection', {
         base_url: baseUrl,
         credentials: fieldValues,
       }, {
@@ -162,22 +161,14 @@ export const CreateIntegrationModal: React.FC<CreateIntegrationModalProps> = ({
     setDiscoveryState(prev => ({ ...prev, step: 'analyzing' }));
 
     try {
-      // Get Anthropic API key
-      const anthropicKey = localStorage.getItem('anthropic_api_key');
-      if (!anthropicKey) {
-        setError('Anthropic API key not found. Please add it in Settings.');
-        setDiscoveryState(prev => ({ ...prev, step: 'configuring' }));
-        return;
-      }
-
-      // Call backend to analyze the error and suggest fields
-      const response = await axios.post(`${INTEGRATION_URL}/analyze-connection-error`, {
+      // Call backend to analyze the error and suggest fields (with the
+      // Anthropic API key stored in Settings)
+      const response = await integrationClient.post('/analyze-connection-error', {
         base_url: baseUrl,
         integration_name: integrationName || 'Unknown API',
         connection_result: connectionResult,
         current_fields: discoveryState.discoveredFields,
         current_values: fieldValues,
-        anthropic_key: anthropicKey,
       });
 
       const analysis = response.data;
@@ -213,12 +204,23 @@ export const CreateIntegrationModal: React.FC<CreateIntegrationModalProps> = ({
     }));
   };
 
-  const handleSaveIntegration = () => {
+  const handleSaveIntegration = async () => {
     if (!integrationName.trim()) {
       setError('Please enter an integration name');
       return;
     }
 
+    // The entered credentials go to the credential vault, not local storage
+    try {
+      await saveIntegrationConfig(integrationName, baseUrl, fieldValues, {
+        authMethod: discoveryState.authType,
+        isCustom: true,
+      });
+    } catch (err: any) {
+      setError(`Failed to save credentials: ${err.message}`);
+      return;
+    }
+
     const integration: CustomIntegration = {
       id: `custom_${Date.now()}`,
       name: integrationName,
@@ -226,7 +228,6 @@ export const CreateIntegrationModal: React.FC<CreateIntegrationModalProps> = ({
       description: description || `Custom integration for ${integrationName}`,
       authType: discoveryState.authType || 'Unknown',
       fields: discoveryState.discoveredFields,
-      configuredFields: fieldValues,
       createdAt: new Date().toISOString(),
       lastTestedAt: discoveryState.step === 'success' ? new Date().toISOString() : undefined,
       status: discoveryState.step === 'success' ? 'configured' : 'needs_config',
@@ -239,17 +240,6 @@ export const CreateIntegrationModal: React.FC<CreateIntegrationModalProps> = ({
     integrations.push(integration);
     localStorage.setItem(storageKey, JSON.stringify(integrations));
 
-    // Also save individual config
-    const configKey = `integration_config_${integrationName.toLowerCase().replace(/\s+/g, '_')}`;
-    localStorage.setItem(configKey, JSON.stringify({
-      integration_name: integrationName,
-      provider_url: baseUrl,
-      configured_at: new Date().toISOString(),
-      auth_method: discoveryState.authType,
-      fields: fieldValues,
-      is_custom: true,
-    }));
-
     onIntegrationCreated(integration);
     handleClose();
   };
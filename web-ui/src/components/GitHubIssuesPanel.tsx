@@ -0,0 +1,81 @@
+import React, { useEffect, useState } from 'react';
+import { Button, Alert } from './index';
+import { exportGitHubIssues, type GitHubExportResponse } from '../api/githubIssuesService';
+
+interface GitHubIssuesPanelProps {
+  workspacePath: string;
+  defaultRepository?: string;
+}
+
+/**
+ * Publishes the workspace's enablers as GitHub issues. Exporting again
+ * updates the same issues and closes or reopens them to match each
+ * enabler's lifecycle state.
+ */
+export const GitHubIssuesPanel: React.FC<GitHubIssuesPanelProps> = ({ workspacePath, defaultRepository }) => {
+  const [repository, setRepository] = useState(defaultRepository || '');
+  const [projectId, setProjectId] = useState('');
+  const [exporting, setExporting] = useState(false);
+  const [result, setResult] = useState<GitHubExportResponse | null>(null);
+  const [error, setError] = useState<string | null>(null);
+
+  // The workspace's remote is loaded after the panel mounts
+  useEffect(() => {
+    if (defaultRepository) {
+      setRepository(current => current || defaultRepository);
+    }
+  }, [defaultRepository]);
+
+  const runExport = async () => {
+    setExporting(true);
+    setError(null);
+    try {
+      setResult(await exportGitHubIssues(workspacePath, repository.trim(), projectId.trim()));
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'GitHub export failed');
+    } finally {
+      setExporting(false);
+    }
+  };
+
+  const inputStyle = { flex: 1, padding: '6px 8px', fontSize: '13px', border: '1px solid var(--color-border, #e5e7eb)', borderRadius: '6px' };
+
+  return (
+    <div style={{ marginTop: '16px', padding: '16px', border: '1px solid var(--color-border, #e5e7eb)', borderRadius: '8px' }}>
+      <h4 style={{ margin: '0 0 12px 0' }}>Export Enablers as GitHub Issues</h4>
+      <div style={{ display: 'flex', gap: '8px', marginBottom: '12px' }}>
+        <input style={inputStyle} placeholder="owner/repo" value={repository} onChange={e => setRepository(e.target.value)} />
+        <input style={inputStyle} placeholder="Project node ID (optional)" value={projectId} onChange={e => setProjectId(e.target.value)} />
+        <Button variant="secondary" onClick={runExport} disabled={exporting || !repository.trim()}>
+          {exporting ? 'Exporting...' : 'Export'}
+        </Button>
+      </div>
+
+      {error && (
+        <Alert type="error" style={{ marginBottom: '12px' }}>
+          {error}
+        </Alert>
+      )}
+
+      {result && (
+        <>
+          <Alert type={result.errors > 0 ? 'warning' : 'success'} style={{ marginBottom: '12px' }}>
+            {result.created} created, {result.updated} updated, {result.errors} error{result.errors !== 1 ? 's' : ''}
+          </Alert>
+          <table style={{ width: '100%', fontSize: '13px', borderCollapse: 'collapse' }}>
+            <tbody>
+              {result.results.map(r => (
+                <tr key={r.file}>
+                  <td>{r.enabler_id}</td>
+                  <td>{r.url ? <a href={r.url} target="_blank" rel="noopener noreferrer">{r.issue}</a> : r.issue || '—'}</td>
+                  <td>{r.state || '—'}</td>
+                  <td>{r.result === 'error' ? r.error : r.result}</td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        </>
+      )}
+    </div>
+  );
+};
@@ -1,6 +1,6 @@
 import React, { useState, useEffect } from 'react';
 import { Button, Alert } from './index';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 
 interface JiraEpic {
   id: string;
@@ -20,9 +20,11 @@ interface JiraEpic {
 interface JiraImportModalProps {
   isOpen: boolean;
   onClose: () => void;
+  /** Jira (default), GitLab or Azure DevOps */
+  integrationName?: string;
+  /** Jira project key, GitLab group path or Azure DevOps project */
   projectKey: string;
   projectName: string;
-  credentials: Record<string, string>;
   workspacePath: string;
   onImportComplete: (importedCount: number) => void;
 }
@@ -44,9 +46,9 @@ interface ImportResult {
 export const JiraImportModal: React.FC<JiraImportModalProps> = ({
   isOpen,
   onClose,
+  integrationName = 'Jira',
   projectKey,
   projectName,
-  credentials,
   workspacePath,
   onImportComplete,
 }) => {
@@ -57,6 +59,7 @@ export const JiraImportModal: React.FC<JiraImportModalProps> = ({
   const [importing, setImporting] = useState(false);
   const [importResult, setImportResult] = useState<ImportResult | null>(null);
   const [step, setStep] = useState<'loading' | 'select' | 'importing' | 'complete'>('loading');
+  const isJira = integrationName === 'Jira';
 
   useEffect(() => {
     if (isOpen && projectKey) {
@@ -70,14 +73,23 @@ export const JiraImportModal: React.FC<JiraImportModalProps> = ({
     setStep('loading');
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/fetch-jira-epics`, {
-        method: 'POST',
-        headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify({
-          project_key: projectKey,
-          credentials,
-        }),
-      });
+      const response = isJira
+        ? await integrationFetch(`${INTEGRATION_URL}/fetch-jira-epics`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({
+              integration_name: 'Jira',
+              project_key: projectKey,
+            }),
+          })
+        : await integrationFetch(`${INTEGRATION_URL}/fetch-epics`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({
+              integration_name: integrationName,
+              project: projectKey,
+            }),
+          });
 
       if (!response.ok) {
         const errorText = await response.text();
@@ -129,14 +141,14 @@ export const JiraImportModal: React.FC<JiraImportModalProps> = ({
     try {
       const epicsToImport = epics.filter(e => selectedEpics.has(e.key));
 
-      const response = await fetch(`${INTEGRATION_URL}/import-jira-epics`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/${isJira ? 'import-jira-epics' : 'import-epics'}`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
           workspace_path: workspacePath,
           project_key: projectKey,
+          integration_name: integrationName,
           epics: epicsToImport,
-          credentials,
         }),
       });
 
@@ -145,7 +157,13 @@ export const JiraImportModal: React.FC<JiraImportModalProps> = ({
         throw new Error(errorText || 'Failed to import epics');
       }
 
-      const result: ImportResult = await response.json();
+      // /import-epics names the epic key "key" rather than "jira_key"
+      const data = await response.json();
+      const result: ImportResult = {
+        ...data,
+        imported: (data.imported || []).map((i: { key?: string; jira_key?: string }) => ({ ...i, jira_key: i.jira_key ?? i.key })),
+        errors: (data.errors || []).map((e: { key?: string; jira_key?: string }) => ({ ...e, jira_key: e.jira_key ?? e.key })),
+      };
       setImportResult(result);
       setStep('complete');
 
@@ -206,7 +224,7 @@ export const JiraImportModal: React.FC<JiraImportModalProps> = ({
         }}>
           <div>
             <h2 className="text-title2" style={{ margin: 0 }}>
-              Import Jira Epics as Capabilities
+              Import {integrationName} Epics as Capabilities
             </h2>
             <p className="text-footnote text-secondary" style={{ margin: '4px 0 0 0' }}>
               Project: {projectName} ({projectKey})
@@ -250,9 +268,9 @@ export const JiraImportModal: React.FC<JiraImportModalProps> = ({
                 animation: 'spin 1s linear infinite',
                 margin: '0 auto 16px',
               }} />
-              <p className="text-body">Loading Epics from Jira...</p>
+              <p className="text-body">Loading Epics from {integrationName}...</p>
               <p className="text-footnote text-secondary">
-                Searching for Epics and Sagas in {projectKey}
+                {isJira ? 'Searching for Epics and Sagas' : 'Searching for Epics'} in {projectKey}
               </p>
             </div>
           )}
@@ -0,0 +1,134 @@
+import React, { useEffect, useState } from 'react';
+import { Button, Alert } from './index';
+import {
+  listJiraLinks,
+  syncJira,
+  unlinkJiraIssue,
+  type JiraSyncLink,
+  type JiraSyncResponse,
+  type JiraSyncSide,
+} from '../api/jiraSyncService';
+
+interface JiraSyncPanelProps {
+  workspaceId: string;
+}
+
+/**
+ * Lists a workspace's capabilities and enablers linked to Jira issues and
+ * syncs them both ways. Conflicts (a field changed in both IntentR and Jira)
+ * are shown with a choice of which side to keep.
+ */
+export const JiraSyncPanel: React.FC<JiraSyncPanelProps> = ({ workspaceId }) => {
+  const [links, setLinks] = useState<JiraSyncLink[]>([]);
+  const [syncing, setSyncing] = useState(false);
+  const [result, setResult] = useState<JiraSyncResponse | null>(null);
+  const [error, setError] = useState<string | null>(null);
+
+  const loadLinks = async () => {
+    try {
+      setLinks(await listJiraLinks(workspaceId));
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to load Jira links');
+    }
+  };
+
+  useEffect(() => {
+    loadLinks();
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [workspaceId]);
+
+  const runSync = async (resolve?: Record<string, JiraSyncSide>) => {
+    setSyncing(true);
+    setError(null);
+    try {
+      setResult(await syncJira(workspaceId, resolve));
+      await loadLinks();
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Jira sync failed');
+    } finally {
+      setSyncing(false);
+    }
+  };
+
+  const handleUnlink = async (link: JiraSyncLink) => {
+    try {
+      await unlinkJiraIssue(link.id);
+      await loadLinks();
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to unlink');
+    }
+  };
+
+  const conflicts = result?.results.filter(r => r.result === 'conflict') || [];
+
+  return (
+    <div style={{ marginTop: '16px', padding: '16px', border: '1px solid var(--color-border, #e5e7eb)', borderRadius: '8px' }}>
+      <div style={{ display: 'flex', justifyContent: 'space-between', alignItems: 'center', marginBottom: '12px' }}>
+        <h4 style={{ margin: 0 }}>Jira Sync ({links.length} linked)</h4>
+        <Button variant="secondary" onClick={() => runSync()} disabled={syncing || links.length === 0}>
+          {syncing ? 'Syncing...' : 'Sync with Jira'}
+        </Button>
+      </div>
+
+      {error && (
+        <Alert type="error" style={{ marginBottom: '12px' }}>
+          {error}
+        </Alert>
+      )}
+
+      {result && (
+        <Alert type={result.errors > 0 || result.conflicts > 0 ? 'warning' : 'success'} style={{ marginBottom: '12px' }}>
+          {result.synced} synced, {result.conflicts} conflict{result.conflicts !== 1 ? 's' : ''}, {result.errors} error{result.errors !== 1 ? 's' : ''}
+          {result.results.filter(r => r.result === 'error').map(r => (
+            <div key={r.link_id} style={{ fontSize: '12px', marginTop: '4px' }}>
+              {r.entity_id} ↔ {r.issue_key}: {r.error}
+            </div>
+          ))}
+        </Alert>
+      )}
+
+      {conflicts.map(c => (
+        <div key={c.link_id} style={{ padding: '8px', marginBottom: '8px', background: 'var(--color-warning-bg, #fffbeb)', borderRadius: '6px' }}>
+          <strong>{c.entity_id} ↔ {c.issue_key}</strong>
+          {c.conflicts?.map(f => (
+            <div key={f.field} style={{ fontSize: '13px' }}>
+              {f.field}: IntentR "{f.intentr}" / Jira "{f.jira}" (was "{f.last}")
+            </div>
+          ))}
+          <div style={{ display: 'flex', gap: '8px', marginTop: '6px' }}>
+            <Button variant="outline" onClick={() => runSync({ [c.entity_id]: 'intentr' })} disabled={syncing}>
+              Keep IntentR
+            </Button>
+            <Button variant="outline" onClick={() => runSync({ [c.entity_id]: 'jira' })} disabled={syncing}>
+              Keep Jira
+            </Button>
+          </div>
+        </div>
+      ))}
+
+      {links.length === 0 ? (
+        <p style={{ margin: 0, fontSize: '13px', color: 'var(--color-text-secondary, #6b7280)' }}>
+          Capabilities imported from Jira epics are linked automatically.
+        </p>
+      ) : (
+        <table style={{ width: '100%', fontSize: '13px', borderCollapse: 'collapse' }}>
+          <tbody>
+            {links.map(link => (
+              <tr key={link.id}>
+                <td>{link.entity_id}</td>
+                <td>{link.issue_key}</td>
+                <td>{link.last_jira_status || '—'}</td>
+                <td>{link.last_result || 'not synced'}</td>
+                <td style={{ textAlign: 'right' }}>
+                  <Button variant="outline" onClick={() => handleUnlink(link)} disabled={syncing}>
+                    Unlink
+                  </Button>
+                </td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      )}
+    </div>
+  );
+};
@@ -9,12 +9,17 @@ interface ShareableUser {
   name: string;
 }
 
+// Workspace roles, each including the rights of the ones before it
+type WorkspaceRole = 'viewer' | 'contributor' | 'approver' | 'owner';
+const WORKSPACE_ROLES: WorkspaceRole[] = ['viewer', 'contributor', 'approver', 'owner'];
+
 interface WorkspaceShare {
   id: number;
   workspaceId: string;
   sharedWithUserId: number;
   sharedWithUserEmail: string;
   sharedWithUserName: string;
+  role: WorkspaceRole;
   sharedByUserId: number;
   createdAt: string;
   updatedAt: string;
@@ -127,6 +132,24 @@ export const ShareWorkspaceModal: React.FC<ShareWorkspaceModalProps> = ({
     }
   };
 
+  const handleRoleChange = async (userId: number, role: WorkspaceRole) => {
+    setError(null);
+
+    try {
+      await authClient.put(`/api/workspaces/${workspaceId}/members/${userId}`, { role });
+
+      // Owners are members but not shares
+      setCurrentShares(prev =>
+        role === 'owner'
+          ? prev.filter(share => share.sharedWithUserId !== userId)
+          : prev.map(share => (share.sharedWithUserId === userId ? { ...share, role } : share))
+      );
+    } catch (err: any) {
+      console.error('Failed to change workspace role:', err);
+      setError(err.response?.data?.error || 'Failed to change workspace role');
+    }
+  };
+
   // Filter users based on search term
   const filteredUsers = users.filter(user => {
     if (!searchTerm) return true;
@@ -142,6 +165,10 @@ export const ShareWorkspaceModal: React.FC<ShareWorkspaceModalProps> = ({
     return currentShares.some(share => share.sharedWithUserId === userId);
   };
 
+  const sharedRole = (userId: number) => {
+    return currentShares.find(share => share.sharedWithUserId === userId)?.role;
+  };
+
   // Check if there are unsaved changes
   const hasChanges = () => {
     const currentIds = new Set(currentShares.map(s => s.sharedWithUserId));
@@ -414,19 +441,27 @@ export const ShareWorkspaceModal: React.FC<ShareWorkspaceModalProps> = ({
                         </div>
                       </div>
                       {wasShared && (
-                        <span
+                        <select
+                          value={sharedRole(user.id)}
+                          onChange={(e) => handleRoleChange(user.id, e.target.value as WorkspaceRole)}
+                          aria-label={`Role of ${user.name}`}
                           style={{
-                            fontSize: '11px',
+                            fontSize: '12px',
                             padding: '2px 8px',
                             borderRadius: '10px',
+                            border: '1px solid var(--color-systemGray4)',
                             backgroundColor: 'var(--color-systemGreen-light, rgba(52, 199, 89, 0.15))',
                             color: 'var(--color-systemGreen)',
                             fontWeight: 500,
                             flexShrink: 0,
                           }}
                         >
-                          Shared
-                        </span>
+                          {WORKSPACE_ROLES.map(role => (
+                            <option key={role} value={role}>
+                              {role.charAt(0).toUpperCase() + role.slice(1)}
+                            </option>
+                          ))}
+                        </select>
                       )}
                     </label>
                   );
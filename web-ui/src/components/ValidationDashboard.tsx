@@ -1,7 +1,7 @@
 import React, { useState, useEffect } from 'react';
 import { useNavigate } from 'react-router-dom';
 import { useWorkspace } from '../context/WorkspaceContext';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 
 interface ValidationIssue {
   id: string;
@@ -57,17 +57,17 @@ export const ValidationDashboard: React.FC = () => {
     try {
       // Fetch storyboards, capabilities, and enablers
       const [storyRes, capRes, enbRes] = await Promise.all([
-        fetch(`${INTEGRATION_URL}/story-files`, {
+        integrationFetch(`${INTEGRATION_URL}/story-files`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
         }),
-        fetch(`${INTEGRATION_URL}/capability-files`, {
+        integrationFetch(`${INTEGRATION_URL}/capability-files`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
         }),
-        fetch(`${INTEGRATION_URL}/enabler-files`, {
+        integrationFetch(`${INTEGRATION_URL}/enabler-files`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
@@ -1,9 +1,11 @@
 import React, { useState, useEffect } from 'react';
-import axios from 'axios';
 import { Button } from './Button';
 import { Alert } from './Alert';
 import { JiraImportModal } from './JiraImportModal';
-import { INTEGRATION_URL, SPEC_URL } from '../api/client';
+import { JiraSyncPanel } from './JiraSyncPanel';
+import { GitHubIssuesPanel } from './GitHubIssuesPanel';
+import { SPEC_URL, integrationClient } from '../api/client';
+import { getIntegrationConfig } from '../api/credentialService';
 
 interface Workspace {
   id: string;
@@ -47,7 +49,9 @@ const SPEC_API_URL = SPEC_URL;
 const AVAILABLE_INTEGRATIONS = [
   { name: 'Figma API', icon: '🎨' },
   { name: 'GitHub', icon: '💻' },
-  { name: 'Jira', icon: '📋' }
+  { name: 'Jira', icon: '📋' },
+  { name: 'GitLab', icon: '🦊' },
+  { name: 'Azure DevOps', icon: '🔷' }
 ];
 
 export const WorkspaceIntegrations: React.FC<WorkspaceIntegrationsProps> = ({ workspace, onClose }) => {
@@ -107,18 +111,24 @@ export const WorkspaceIntegrations: React.FC<WorkspaceIntegrationsProps> = ({ wo
       fieldLabels: {
         'access_token': 'Personal Access Token',
       }
+    },
+    'GitLab': {
+      fields: ['access_token'],
+      fieldLabels: {
+        'access_token': 'Personal Access Token (scope read_api)',
+      }
+    },
+    'Azure DevOps': {
+      fields: ['organization', 'access_token'],
+      fieldLabels: {
+        'organization': 'Organization (e.g., contoso or https://dev.azure.com/contoso)',
+        'access_token': 'Personal Access Token (Code, Work Items and Project read)',
+      }
     }
   };
 
-  const getIntegrationConfig = (integrationName: string) => {
-    const configKey = `integration_config_${integrationName.toLowerCase().replace(/\s+/g, '_')}`;
-    const config = localStorage.getItem(configKey);
-    if (!config) return null;
-    return JSON.parse(config);
-  };
-
   // Validate that all required fields are present for known integrations
-  const validateIntegrationConfig = (integrationName: string, fields: Record<string, string>): { valid: boolean, missingFields: string[] } => {
+  const validateIntegrationConfig = (integrationName: string, fieldNames: string[]): { valid: boolean, missingFields: string[] } => {
     const requirements = KNOWN_INTEGRATION_REQUIREMENTS[integrationName];
     if (!requirements) {
       // Unknown integration, assume it's valid
@@ -128,9 +138,9 @@ export const WorkspaceIntegrations: React.FC<WorkspaceIntegrationsProps> = ({ wo
     const missingFields: string[] = [];
     for (const field of requirements.fields) {
       // Check for the exact field name or common variations
-      const hasField = fields[field] ||
-        fields[field.replace(/_/g, '')] || // api_token -> apitoken
-        fields[field.replace(/_([a-z])/g, (_, c) => c.toUpperCase())]; // api_token -> apiToken
+      const hasField = fieldNames.includes(field) ||
+        fieldNames.includes(field.replace(/_/g, '')) || // api_token -> apitoken
+        fieldNames.includes(field.replace(/_([a-z])/g, (_, c) => c.toUpperCase())); // api_token -> apiToken
 
       if (!hasField) {
         missingFields.push(requirements.fieldLabels[field] || field);
@@ -140,34 +150,10 @@ export const WorkspaceIntegrations: React.FC<WorkspaceIntegrationsProps> = ({ wo
     return { valid: missingFields.length === 0, missingFields };
   };
 
-  // GitHub token helper - looks for token in various field names
-  const getGitHubToken = (): string | null => {
-    const config = localStorage.getItem('integration_config_github');
-    if (!config) return null;
-
-    try {
-      const parsed = JSON.parse(config);
-      if (!parsed.fields) return null;
-
-      const tokenFieldNames = [
-        'personal_access_token', 'Personal Access Token', 'token', 'Token',
-        'access_token', 'Access Token', 'api_key', 'API Key', 'apiKey', 'pat', 'PAT',
-      ];
-
-      for (const fieldName of tokenFieldNames) {
-        if (parsed.fields[fieldName] && typeof parsed.fields[fieldName] === 'string') {
-          return parsed.fields[fieldName];
-        }
-      }
-
-      const values = Object.values(parsed.fields);
-      const tokenValue = values.find(
-        (value): value is string => typeof value === 'string' && value.length > 0
-      );
-      return tokenValue || null;
-    } catch {
-      return null;
-    }
+  // Whether GitHub credentials are stored; the token itself stays on the server
+  const hasGitHubCredentials = (): boolean => {
+    const config = getIntegrationConfig('GitHub');
+    return !!config && config.field_names.length > 0;
   };
 
   // Check git status for the workspace
@@ -312,35 +298,36 @@ export const WorkspaceIntegrations: React.FC<WorkspaceIntegrationsProps> = ({ wo
     setSuccess(null);
 
     try {
-      const token = getGitHubToken();
-
-      if (!token) {
+      if (!hasGitHubCredentials()) {
         throw new Error('GitHub token not found. Please configure GitHub in the Integrations page first.');
       }
 
-      const response = await fetch(`${SPEC_API_URL}/git/create-repo`, {
+      // The integration service creates the repository with the stored token
+      const { data } = await integrationClient.post('/github/create-repo', {
+        name: newRepoName,
+        private: newRepoPrivate,
+      });
+
+      const response = await fetch(`${SPEC_API_URL}/git/remote`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
           workspace: workspace.projectFolder,
-          name: newRepoName,
-          private: newRepoPrivate,
-          token,
+          url: data.url,
         }),
       });
 
       if (!response.ok) {
-        const data = await response.json();
-        throw new Error(data.error || 'Failed to create repository');
+        const remoteData = await response.json();
+        throw new Error(remoteData.error || 'Failed to set repository remote');
       }
 
-      const data = await response.json();
       setRemoteUrl(data.url);
       setSuccess(`Repository created: ${data.url}`);
       setCreateNewRepo(false);
       await checkGitStatus();
-    } catch (err) {
-      setError(err instanceof Error ? err.message : 'Failed to create repository');
+    } catch (err: any) {
+      setError(err.response?.data || (err instanceof Error ? err.message : 'Failed to create repository'));
     } finally {
       setSaving(false);
     }
@@ -348,8 +335,7 @@ export const WorkspaceIntegrations: React.FC<WorkspaceIntegrationsProps> = ({ wo
 
   // Fetch user's GitHub repositories
   const fetchGitHubRepos = async () => {
-    const token = getGitHubToken();
-    if (!token) {
+    if (!hasGitHubCredentials()) {
       setError('GitHub token not found. Please configure GitHub in the Integrations page first.');
       return;
     }
@@ -358,25 +344,22 @@ export const WorkspaceIntegrations: React.FC<WorkspaceIntegrationsProps> = ({ wo
     setError(null);
 
     try {
-      // Fetch repos directly from GitHub API
-      const response = await fetch('https://api.github.com/user/repos?sort=updated&per_page=100', {
-        headers: {
-          'Authorization': `token ${token}`,
-          'Accept': 'application/vnd.github.v3+json',
-        },
+      // Fetch repos through the integration service, which holds the token
+      const response = await integrationClient.post('/fetch-resources', {
+        integration_name: 'GitHub',
       });
 
-      if (!response.ok) {
-        if (response.status === 401) {
-          throw new Error('GitHub token is invalid or expired. Please update your GitHub configuration.');
-        }
-        throw new Error(`Failed to fetch repositories: ${response.statusText}`);
-      }
-
-      const repos = await response.json();
-      setGithubRepos(repos);
-    } catch (err) {
-      setError(err instanceof Error ? err.message : 'Failed to fetch GitHub repositories');
+      setGithubRepos(response.data.resources.map((repo: IntegrationResource) => ({
+        id: Number(repo.id),
+        name: repo.name.split('/').pop() || repo.name,
+        full_name: repo.name,
+        html_url: repo.url || '',
+        clone_url: repo.metadata?.clone_url || '',
+        private: !!repo.metadata?.private,
+        description: repo.description || null,
+      })));
+    } catch (err: any) {
+      setError(err.response?.data || (err instanceof Error ? err.message : 'Failed to fetch GitHub repositories'));
     } finally {
       setLoadingRepos(false);
     }
@@ -395,12 +378,12 @@ export const WorkspaceIntegrations: React.FC<WorkspaceIntegrationsProps> = ({ wo
 
     try {
       const config = getIntegrationConfig(integrationName);
-      if (!config || !config.fields) {
+      if (!config || config.field_names.length === 0) {
         throw new Error(`${integrationName} is not configured. Please configure it in the Integrations page first.`);
       }
 
       // Validate required fields for known integrations
-      const validation = validateIntegrationConfig(integrationName, config.fields);
+      const validation = validateIntegrationConfig(integrationName, config.field_names);
       if (!validation.valid) {
         throw new Error(
           `${integrationName} configuration is incomplete. Missing required fields:\n` +
@@ -409,10 +392,9 @@ export const WorkspaceIntegrations: React.FC<WorkspaceIntegrationsProps> = ({ wo
         );
       }
 
-      // Fetch resources from integration
-      const response = await axios.post(`${INTEGRATION_URL}/fetch-resources`, {
+      // Fetch resources from integration with its stored credentials
+      const response = await integrationClient.post('/fetch-resources', {
         integration_name: integrationName,
-        credentials: config.fields
       });
 
       setResources(response.data.resources);
@@ -429,18 +411,11 @@ export const WorkspaceIntegrations: React.FC<WorkspaceIntegrationsProps> = ({ wo
   const fetchSuggestions = async (integrationName: string, resourcesList: IntegrationResource[]) => {
     setLoadingSuggestions(true);
     try {
-      const anthropicKey = localStorage.getItem('anthropic_api_key');
-      if (!anthropicKey) {
-        console.warn('Anthropic API key not found, skipping suggestions');
-        return;
-      }
-
-      const response = await axios.post(`${INTEGRATION_URL}/suggest-resources`, {
+      const response = await integrationClient.post('/suggest-resources', {
         workspace_name: workspace.name,
         workspace_description: workspace.description || '',
         integration_name: integrationName,
         resources: resourcesList,
-        anthropic_key: anthropicKey
       });
 
       setSuggestions(response.data.suggestions);
@@ -507,17 +482,16 @@ export const WorkspaceIntegrations: React.FC<WorkspaceIntegrationsProps> = ({ wo
         console.log('[WorkspaceIntegrations] Fetching Figma files from selected teams/users...');
         const config = getIntegrationConfig('Figma API');
 
-        if (config?.fields?.access_token) {
+        if (config) {
           try {
             // Fetch files for each selected team/user
             const allFiles: any[] = [];
             for (const resource of dataToSave) {
               if (resource.type === 'user' || resource.type === 'team') {
                 console.log(`[WorkspaceIntegrations] Fetching files for ${resource.name}...`);
-                const response = await axios.post(`${INTEGRATION_URL}/fetch-team-files`, {
+                const response = await integrationClient.post('/fetch-team-files', {
                   integration_name: 'Figma API',
                   team_id: resource.id,
-                  credentials: { access_token: config.fields.access_token }
                 });
 
                 if (response.data && response.data.files) {
@@ -895,7 +869,7 @@ export const WorkspaceIntegrations: React.FC<WorkspaceIntegrationsProps> = ({ wo
                             GitHub Repository
                           </h4>
 
-                          {!getGitHubToken() && (
+                          {!hasGitHubCredentials() && (
                             <div
                               style={{
                                 padding: '20px',
@@ -985,15 +959,15 @@ export const WorkspaceIntegrations: React.FC<WorkspaceIntegrationsProps> = ({ wo
                                 </button>
                                 <button
                                   onClick={() => setCreateNewRepo(true)}
-                                  disabled={!getGitHubToken()}
+                                  disabled={!hasGitHubCredentials()}
                                   style={{
                                     flex: 1,
                                     padding: '12px',
                                     border: `2px solid ${createNewRepo ? 'var(--color-blue-500)' : 'var(--color-grey-200)'}`,
                                     borderRadius: '8px',
                                     backgroundColor: createNewRepo ? 'var(--color-blue-50)' : 'white',
-                                    cursor: getGitHubToken() ? 'pointer' : 'not-allowed',
-                                    opacity: getGitHubToken() ? 1 : 0.5,
+                                    cursor: hasGitHubCredentials() ? 'pointer' : 'not-allowed',
+                                    opacity: hasGitHubCredentials() ? 1 : 0.5,
                                     transition: 'all 0.15s',
                                   }}
                                 >
@@ -1283,8 +1257,8 @@ export const WorkspaceIntegrations: React.FC<WorkspaceIntegrationsProps> = ({ wo
                   >
                     {saving ? 'Saving...' : saveSuccess ? 'Saved!' : `Save ${selectedResources.size} Resource${selectedResources.size !== 1 ? 's' : ''}`}
                   </Button>
-                  {/* Show Import button only for Jira when a project is selected */}
-                  {selectedIntegration === 'Jira' && selectedResources.size === 1 && (
+                  {/* Show Import button for issue trackers when a project is selected */}
+                  {(selectedIntegration === 'Jira' || selectedIntegration === 'GitLab' || selectedIntegration === 'Azure DevOps') && selectedResources.size === 1 && (
                     <Button
                       variant="secondary"
                       onClick={() => {
@@ -1313,6 +1287,17 @@ export const WorkspaceIntegrations: React.FC<WorkspaceIntegrationsProps> = ({ wo
           </div>
         )}
 
+        {/* Enablers published as GitHub issues */}
+        {selectedIntegration === 'GitHub' && workspace.projectFolder && (
+          <GitHubIssuesPanel
+            workspacePath={workspace.projectFolder}
+            defaultRepository={gitConfig.remoteUrl?.match(/github\.com[:/]([^/]+\/[^/]+?)(\.git)?$/)?.[1]}
+          />
+        )}
+
+        {/* Two-way sync of linked capabilities and enablers */}
+        {selectedIntegration === 'Jira' && <JiraSyncPanel workspaceId={workspace.id} />}
+
         {/* Jira Import Modal */}
         {showJiraImport && selectedJiraProject && (
           <JiraImportModal
@@ -1321,12 +1306,18 @@ export const WorkspaceIntegrations: React.FC<WorkspaceIntegrationsProps> = ({ wo
               setShowJiraImport(false);
               setSelectedJiraProject(null);
             }}
-            projectKey={selectedJiraProject.metadata?.key as string || selectedJiraProject.id}
+            integrationName={selectedIntegration || 'Jira'}
+            projectKey={
+              selectedIntegration === 'GitLab'
+                ? selectedJiraProject.metadata?.namespace_path as string // Epics belong to the project's group
+                : selectedIntegration === 'Azure DevOps'
+                  ? selectedJiraProject.name
+                  : selectedJiraProject.metadata?.key as string || selectedJiraProject.id
+            }
             projectName={selectedJiraProject.name}
-            credentials={getIntegrationConfig('Jira')?.fields || {}}
             workspacePath={workspace.projectFolder || ''}
             onImportComplete={(count) => {
-              console.log(`Imported ${count} capabilities from Jira`);
+              console.log(`Imported ${count} capabilities from ${selectedIntegration}`);
               // Optionally refresh capabilities list or show notification
             }}
           />
@@ -1,7 +1,8 @@
 import React, { useState, useEffect } from 'react';
 import { Button } from './Button';
 import { Alert } from './Alert';
-import { SPEC_URL } from '../api/client';
+import { SPEC_URL, integrationClient } from '../api/client';
+import { getIntegrationConfig } from '../api/credentialService';
 
 interface WorkspaceVersionControlProps {
   workspace: {
@@ -51,50 +52,10 @@ export const WorkspaceVersionControl: React.FC<WorkspaceVersionControlProps> = (
     checkGitHubIntegration();
   }, [workspace.projectFolder]);
 
-  // Helper to get GitHub token from config (field name varies based on AI analysis)
-  const getGitHubToken = (): string | null => {
-    const config = localStorage.getItem('integration_config_github');
-    if (!config) return null;
-
-    try {
-      const parsed = JSON.parse(config);
-      if (!parsed.fields) return null;
-
-      // Look for token in common field names
-      const tokenFieldNames = [
-        'personal_access_token',
-        'Personal Access Token',
-        'token',
-        'Token',
-        'access_token',
-        'Access Token',
-        'api_key',
-        'API Key',
-        'apiKey',
-        'pat',
-        'PAT',
-      ];
-
-      for (const fieldName of tokenFieldNames) {
-        if (parsed.fields[fieldName] && typeof parsed.fields[fieldName] === 'string') {
-          return parsed.fields[fieldName];
-        }
-      }
-
-      // If no common name found, return the first non-empty string field
-      const values = Object.values(parsed.fields);
-      const tokenValue = values.find(
-        (value): value is string => typeof value === 'string' && value.length > 0
-      );
-      return tokenValue || null;
-    } catch {
-      return null;
-    }
-  };
-
   const checkGitHubIntegration = () => {
-    const token = getGitHubToken();
-    setGithubConfigured(!!token);
+    // The token stays in the credential vault; only whether it is stored is known here
+    const config = getIntegrationConfig('GitHub');
+    setGithubConfigured(!!config && config.field_names.length > 0);
   };
 
   const checkGitStatus = async () => {
@@ -236,36 +197,36 @@ export const WorkspaceVersionControl: React.FC<WorkspaceVersionControlProps> = (
     setSuccess(null);
 
     try {
-      // Get GitHub token from integrations
-      const token = getGitHubToken();
-
-      if (!token) {
+      if (!githubConfigured) {
         throw new Error('GitHub token not found. Please configure GitHub in the Admin Panel > Integrations.');
       }
 
-      const response = await fetch(`${SPEC_API_URL}/git/create-repo`, {
+      // The integration service creates the repository with the stored token
+      const { data } = await integrationClient.post('/github/create-repo', {
+        name: newRepoName,
+        private: newRepoPrivate,
+      });
+
+      const response = await fetch(`${SPEC_API_URL}/git/remote`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
           workspace: workspace.projectFolder,
-          name: newRepoName,
-          private: newRepoPrivate,
-          token,
+          url: data.url,
         }),
       });
 
       if (!response.ok) {
-        const data = await response.json();
-        throw new Error(data.error || 'Failed to create repository');
+        const remoteData = await response.json();
+        throw new Error(remoteData.error || 'Failed to set repository remote');
       }
 
-      const data = await response.json();
       setRemoteUrl(data.url);
       setSuccess(`Repository created: ${data.url}`);
       setCreateNewRepo(false);
       await checkGitStatus();
-    } catch (err) {
-      setError(err instanceof Error ? err.message : 'Failed to create repository');
+    } catch (err: any) {
+      setError(err.response?.data || (err instanceof Error ? err.message : 'Failed to create repository'));
     } finally {
       setSaving(false);
     }
@@ -1,5 +1,6 @@
 import React, { createContext, useContext, useState, useEffect, useCallback, type ReactNode } from 'react';
 import { authClient } from '../api/client';
+import { migrateBrowserCredentials } from '../api/credentialService';
 
 interface User {
   id: number;
@@ -19,10 +20,28 @@ interface AuthState {
   isLoading: boolean;
 }
 
+// Returned by login when a second factor is needed; with setupRequired the
+// user's role requires two-factor login and the user enrolls first
+export interface TwoFactorChallenge {
+  twoFactorRequired: boolean;
+  setupRequired: boolean;
+  challengeToken: string;
+  expiresAt: string;
+}
+
+export interface TOTPEnrollment {
+  secret: string;
+  otpauthUrl: string;
+}
+
 interface AuthContextType extends AuthState {
-  login: (email: string, password: string) => Promise<void>;
+  login: (email: string, password: string) => Promise<TwoFactorChallenge | null>;
+  completeTwoFactorLogin: (challengeToken: string, code: string, isRecoveryCode?: boolean) => Promise<string[]>;
+  beginTwoFactorSetup: (challengeToken: string) => Promise<TOTPEnrollment>;
   loginWithGoogle: () => Promise<void>;
-  handleGoogleCallback: (code: string, state: string) => Promise<void>;
+  handleGoogleCallback: (code: string, state: string) => Promise<TwoFactorChallenge | null>;
+  loginWithOIDC: (provider: string) => Promise<void>;
+  handleOIDCCallback: (provider: string, code: string, state: string) => Promise<TwoFactorChallenge | null>;
   logout: () => void;
   verifyToken: () => Promise<boolean>;
 }
@@ -37,6 +56,13 @@ export const AuthProvider: React.FC<{ children: ReactNode }> = ({ children }) =>
     isLoading: true,
   });
 
+  useEffect(() => {
+    // Credentials older versions kept in the browser move to the vault
+    if (state.isAuthenticated) {
+      migrateBrowserCredentials().catch((err) => console.warn('Failed to migrate stored credentials:', err));
+    }
+  }, [state.isAuthenticated]);
+
   useEffect(() => {
     // Check for existing token on mount
     // Use sessionStorage for tab-specific authentication (allows multiple users in different tabs)
@@ -48,15 +74,20 @@ export const AuthProvider: React.FC<{ children: ReactNode }> = ({ children }) =>
     }
   }, []);
 
-  const login = async (email: string, password: string): Promise<void> => {
+  const login = async (email: string, password: string): Promise<TwoFactorChallenge | null> => {
     try {
       setState((prev) => ({ ...prev, isLoading: true }));
 
       const response = await authClient.post('/api/auth/login', { email, password });
-      const { token, user } = response.data;
+      if (response.data.twoFactorRequired) {
+        setState((prev) => ({ ...prev, isLoading: false }));
+        return response.data as TwoFactorChallenge;
+      }
+      const { token, refreshToken, user } = response.data;
 
       // Use sessionStorage for tab-specific authentication (allows multiple users in different tabs)
       sessionStorage.setItem('auth_token', token);
+      sessionStorage.setItem('refresh_token', refreshToken);
       sessionStorage.setItem('user', JSON.stringify(user));
 
       setState({
@@ -65,6 +96,7 @@ export const AuthProvider: React.FC<{ children: ReactNode }> = ({ children }) =>
         isAuthenticated: true,
         isLoading: false,
       });
+      return null;
     } catch (error: any) {
       setState((prev) => ({ ...prev, isLoading: false }));
       const message = error.response?.data?.error || 'Login failed. Please check your credentials.';
@@ -72,9 +104,54 @@ export const AuthProvider: React.FC<{ children: ReactNode }> = ({ children }) =>
     }
   };
 
+  // Completes a login with a TOTP or recovery code. Returns the recovery
+  // codes issued when the user enrolled during this login.
+  const completeTwoFactorLogin = async (challengeToken: string, code: string, isRecoveryCode = false): Promise<string[]> => {
+    try {
+      setState((prev) => ({ ...prev, isLoading: true }));
+
+      const response = await authClient.post('/api/auth/login/2fa', isRecoveryCode
+        ? { challengeToken, recoveryCode: code }
+        : { challengeToken, code });
+      const { token, refreshToken, user, recoveryCodes } = response.data;
+
+      sessionStorage.setItem('auth_token', token);
+      sessionStorage.setItem('refresh_token', refreshToken);
+      sessionStorage.setItem('user', JSON.stringify(user));
+
+      setState({
+        user,
+        token,
+        isAuthenticated: true,
+        isLoading: false,
+      });
+      return recoveryCodes || [];
+    } catch (error: any) {
+      setState((prev) => ({ ...prev, isLoading: false }));
+      const message = error.response?.data?.error || 'Invalid two-factor code';
+      throw new Error(message);
+    }
+  };
+
+  const beginTwoFactorSetup = async (challengeToken: string): Promise<TOTPEnrollment> => {
+    try {
+      const response = await authClient.post('/api/auth/login/2fa/setup', { challengeToken });
+      return response.data as TOTPEnrollment;
+    } catch (error: any) {
+      const message = error.response?.data?.error || 'Failed to start two-factor setup';
+      throw new Error(message);
+    }
+  };
+
   const logout = (): void => {
+    // End the session server-side; the local state is cleared regardless
+    if (sessionStorage.getItem('auth_token')) {
+      authClient.post('/api/auth/logout').catch(() => undefined);
+    }
+
     // Use sessionStorage for tab-specific authentication
     sessionStorage.removeItem('auth_token');
+    sessionStorage.removeItem('refresh_token');
     sessionStorage.removeItem('user');
     setState({
       user: null,
@@ -104,7 +181,8 @@ export const AuthProvider: React.FC<{ children: ReactNode }> = ({ children }) =>
 
       setState({
         user,
-        token,
+        // The token may have been renewed while verifying
+        token: sessionStorage.getItem('auth_token'),
         isAuthenticated: true,
         isLoading: false,
       });
@@ -112,6 +190,7 @@ export const AuthProvider: React.FC<{ children: ReactNode }> = ({ children }) =>
       return true;
     } catch (error) {
       sessionStorage.removeItem('auth_token');
+      sessionStorage.removeItem('refresh_token');
       sessionStorage.removeItem('user');
       setState({
         user: null,
@@ -140,16 +219,21 @@ export const AuthProvider: React.FC<{ children: ReactNode }> = ({ children }) =>
     }
   };
 
-  const handleGoogleCallback = useCallback(async (code: string, state: string): Promise<void> => {
+  const handleGoogleCallback = useCallback(async (code: string, state: string): Promise<TwoFactorChallenge | null> => {
     try {
       setState((prev) => ({ ...prev, isLoading: true }));
 
       // Send code and state to backend
       const response = await authClient.get(`/api/auth/google/callback?code=${code}&state=${state}`);
-      const { token, user } = response.data;
+      if (response.data.twoFactorRequired) {
+        setState((prev) => ({ ...prev, isLoading: false }));
+        return response.data as TwoFactorChallenge;
+      }
+      const { token, refreshToken, user } = response.data;
 
-      // Store token and user
+      // Store tokens and user
       sessionStorage.setItem('auth_token', token);
+      sessionStorage.setItem('refresh_token', refreshToken);
       sessionStorage.setItem('user', JSON.stringify(user));
 
       setState({
@@ -158,6 +242,7 @@ export const AuthProvider: React.FC<{ children: ReactNode }> = ({ children }) =>
         isAuthenticated: true,
         isLoading: false,
       });
+      return null;
     } catch (error: any) {
       setState((prev) => ({ ...prev, isLoading: false }));
       const message = error.response?.data?.error || 'Google login failed';
@@ -165,8 +250,58 @@ export const AuthProvider: React.FC<{ children: ReactNode }> = ({ children }) =>
     }
   }, []);
 
+  const loginWithOIDC = async (provider: string): Promise<void> => {
+    try {
+      setState((prev) => ({ ...prev, isLoading: true }));
+
+      // Get the provider's login URL from backend
+      const response = await authClient.get(`/api/auth/oidc/${encodeURIComponent(provider)}/login`);
+      const { url } = response.data;
+
+      // Redirect to the provider
+      window.location.href = url;
+    } catch (error: any) {
+      setState((prev) => ({ ...prev, isLoading: false }));
+      const message = error.response?.data?.error || 'Failed to initiate single sign-on';
+      throw new Error(message);
+    }
+  };
+
+  const handleOIDCCallback = useCallback(async (provider: string, code: string, state: string): Promise<TwoFactorChallenge | null> => {
+    try {
+      setState((prev) => ({ ...prev, isLoading: true }));
+
+      // Send code and state to backend
+      const response = await authClient.get(`/api/auth/oidc/${encodeURIComponent(provider)}/callback`, {
+        params: { code, state },
+      });
+      if (response.data.twoFactorRequired) {
+        setState((prev) => ({ ...prev, isLoading: false }));
+        return response.data as TwoFactorChallenge;
+      }
+      const { token, refreshToken, user } = response.data;
+
+      // Store tokens and user
+      sessionStorage.setItem('auth_token', token);
+      sessionStorage.setItem('refresh_token', refreshToken);
+      sessionStorage.setItem('user', JSON.stringify(user));
+
+      setState({
+        user,
+        token,
+        isAuthenticated: true,
+        isLoading: false,
+      });
+      return null;
+    } catch (error: any) {
+      setState((prev) => ({ ...prev, isLoading: false }));
+      const message = error.response?.data?.error || 'Single sign-on failed';
+      throw new Error(message);
+    }
+  }, []);
+
   return (
-    <AuthContext.Provider value={{ ...state, login, loginWithGoogle, handleGoogleCallback, logout, verifyToken }}>
+    <AuthContext.Provider value={{ ...state, login, completeTwoFactorLogin, beginTwoFactorSetup, loginWithGoogle, handleGoogleCallback, loginWithOIDC, handleOIDCCallback, logout, verifyToken }}>
       {children}
     </AuthContext.Provider>
   );
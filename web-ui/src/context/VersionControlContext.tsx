@@ -185,94 +185,6 @@ export const VersionControlProvider: React.FC<VersionControlProviderProps> = ({
     }
   }, [workspacePath]);
 
-  // Helper to get GitHub token from localStorage
-  const getGitHubToken = (): string | null => {
-    const config = localStorage.getItem('integration_config_github');
-    if (!config) return null;
-
-    try {
-      const parsed = JSON.parse(config);
-      if (!parsed.fields) return null;
-
-      // Look for token in common field names
-      const tokenFieldNames = [
-        'personal_access_token',
-        'Personal Access Token',
-        'token',
-        'Token',
-        'access_token',
-        'Access Token',
-        'api_key',
-        'API Key',
-        'apiKey',
-        'pat',
-        'PAT',
-      ];
-
-      for (const fieldName of tokenFieldNames) {
-        if (parsed.fields[fieldName] && typeof parsed.fields[fieldName] === 'string') {
-          return parsed.fields[fieldName];
-        }
-      }
-
-      // If no common name found, return the first non-empty string field
-      const values = Object.values(parsed.fields);
-      const tokenValue = values.find(
-        (value): value is string => typeof value === 'string' && value.length > 0
-      );
-      return tokenValue || null;
-    } catch {
-      return null;
-    }
-  };
-
-  // Helper to get Anthropic API key from localStorage
-  const getAnthropicApiKey = (): string | null => {
-    // First check the Settings page location (primary location)
-    const settingsKey = localStorage.getItem('anthropic_api_key');
-    if (settingsKey && settingsKey.trim()) {
-      return settingsKey;
-    }
-
-    // Then check the Integrations config format
-    const config = localStorage.getItem('integration_config_anthropic');
-    if (config) {
-      try {
-        const parsed = JSON.parse(config);
-        if (parsed.fields) {
-          // Look for API key in common field names
-          const keyFieldNames = [
-            'api_key',
-            'API Key',
-            'apiKey',
-            'Api Key',
-            'ANTHROPIC_API_KEY',
-            'anthropic_api_key',
-            'key',
-            'Key',
-          ];
-
-          for (const fieldName of keyFieldNames) {
-            if (parsed.fields[fieldName] && typeof parsed.fields[fieldName] === 'string') {
-              return parsed.fields[fieldName];
-            }
-          }
-
-          // If no common name found, return the first non-empty string field
-          const values = Object.values(parsed.fields);
-          const keyValue = values.find(
-            (value): value is string => typeof value === 'string' && value.length > 0
-          );
-          if (keyValue) return keyValue;
-        }
-      } catch {
-        // Ignore parse errors
-      }
-    }
-
-    return null;
-  };
-
   const saveVersion = useCallback(async (message: string): Promise<boolean> => {
     if (!workspacePath) return false;
 
@@ -280,8 +192,7 @@ export const VersionControlProvider: React.FC<VersionControlProviderProps> = ({
     try {
       // Step 0: Generate/Update README.md from conception folder
       try {
-        const apiKey = getAnthropicApiKey();
-        console.log('[Version Control] README generation - API key found:', apiKey ? 'Yes (length: ' + apiKey.length + ')' : 'No');
+        // The spec server uses its own ANTHROPIC_API_KEY; keys never leave the servers
         console.log('[Version Control] Workspace path:', workspacePath);
 
         const readmeResponse = await fetch(`${SPEC_API_URL}/generate-readme`, {
@@ -289,7 +200,6 @@ export const VersionControlProvider: React.FC<VersionControlProviderProps> = ({
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({
             workspace: workspacePath,
-            apiKey: apiKey || undefined,
           }),
         });
 
@@ -324,16 +234,13 @@ export const VersionControlProvider: React.FC<VersionControlProviderProps> = ({
 
       // Step 2: Push to remote (if remote is configured)
       try {
-        // Get GitHub token from integrations config
-        const token = getGitHubToken();
-
+        // The spec server authenticates with its GITHUB_TOKEN or git credentials
         const pushResponse = await fetch(`${SPEC_API_URL}/git/push`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({
             workspace: workspacePath,
             setUpstream: true, // Set upstream if not already set
-            token: token || undefined, // Pass token for authentication
           }),
         });
 
@@ -1,7 +1,7 @@
 import React, { createContext, useContext, useState, useEffect, type ReactNode } from 'react';
 import { useAuth } from './AuthContext';
 import axios from 'axios';
-import { INTEGRATION_URL, WORKSPACE_URL } from '../api/client';
+import { INTEGRATION_URL, WORKSPACE_URL, integrationFetch } from '../api/client';
 import type { PageLayoutConfig } from '../components/PageLayout';
 
 const SHARED_WORKSPACE_API = `${WORKSPACE_URL}/api`;
@@ -399,20 +399,10 @@ export const WorkspaceProvider: React.FC<{ children: ReactNode }> = ({ children
       isShared: false,
     };
 
-    // Ensure workspace folder structure exists and copy CODE_RULES
-    try {
-      await fetch(`${INTEGRATION_URL}/folders/ensure-workspace-structure`, {
-        method: 'POST',
-        headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify({ path: projectFolder }),
-      });
-    } catch (error) {
-      console.error('Error ensuring workspace structure:', error);
-    }
-
-    // Save .intentrworkspace configuration file
+    // Save .intentrworkspace configuration file first: it creates the folder
+    // and claims it for the workspace, which the other folder requests need
     try {
-      await fetch(`${INTEGRATION_URL}/workspace-config/save`, {
+      await integrationFetch(`${INTEGRATION_URL}/workspace-config/save`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
@@ -443,6 +433,17 @@ export const WorkspaceProvider: React.FC<{ children: ReactNode }> = ({ children
       console.error('Error saving workspace configuration file:', error);
     }
 
+    // Ensure workspace folder structure exists and copy CODE_RULES
+    try {
+      await integrationFetch(`${INTEGRATION_URL}/folders/ensure-workspace-structure`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ path: projectFolder }),
+      });
+    } catch (error) {
+      console.error('Error ensuring workspace structure:', error);
+    }
+
     const updatedWorkspaces = [...state.workspaces, newWorkspace];
     saveWorkspaces(updatedWorkspaces);
 
@@ -475,7 +476,7 @@ export const WorkspaceProvider: React.FC<{ children: ReactNode }> = ({ children
     const updatedWorkspace = updatedWorkspaces.find(w => w.id === id);
     if (updatedWorkspace?.projectFolder) {
       try {
-        await fetch(`${INTEGRATION_URL}/workspace-config/save`, {
+        await integrationFetch(`${INTEGRATION_URL}/workspace-config/save`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({
@@ -575,7 +576,7 @@ export const WorkspaceProvider: React.FC<{ children: ReactNode }> = ({ children
     // Move workspace folder to archived_workspaces instead of permanently deleting
     if (workspace?.projectFolder) {
       try {
-        await fetch(`${INTEGRATION_URL}/folders/move-to-deleted`, {
+        await integrationFetch(`${INTEGRATION_URL}/folders/move-to-deleted`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({
@@ -622,7 +623,7 @@ export const WorkspaceProvider: React.FC<{ children: ReactNode }> = ({ children
     const workspaceToInit = workspace || [...state.workspaces, ...state.sharedWithMeWorkspaces].find(w => w.id === id);
     if (workspaceToInit?.projectFolder) {
       try {
-        await fetch(`${INTEGRATION_URL}/workspace/init-files`, {
+        await integrationFetch(`${INTEGRATION_URL}/workspace/init-files`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({
@@ -678,7 +679,7 @@ export const WorkspaceProvider: React.FC<{ children: ReactNode }> = ({ children
 
       // Also save to .intentrworkspace config file for sharing (async, fire-and-forget)
       if (updatedWorkspace?.projectFolder) {
-        fetch(`${INTEGRATION_URL}/workspace-config/save`, {
+        integrationFetch(`${INTEGRATION_URL}/workspace-config/save`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({
@@ -788,7 +789,7 @@ export const WorkspaceProvider: React.FC<{ children: ReactNode }> = ({ children
       }
 
       // Step 2: Scan workspace folders to get full workspace configs
-      const scanResponse = await fetch(`${INTEGRATION_URL}/workspace-config/scan`);
+      const scanResponse = await integrationFetch(`${INTEGRATION_URL}/workspace-config/scan`);
       if (!scanResponse.ok) {
         console.error('Failed to scan workspace configs:', scanResponse.status);
         return;
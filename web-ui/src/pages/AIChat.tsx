@@ -3,7 +3,7 @@ import { Button } from '../components/Button';
 import { Card } from '../components/Card';
 import { useWorkspace } from '../context/WorkspaceContext';
 import { PageLayout } from '../components';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 
 interface Message {
   id: string;
@@ -49,13 +49,6 @@ export const AIChat: React.FC = () => {
       return;
     }
 
-    // Check for API key
-    const apiKey = localStorage.getItem('anthropic_api_key');
-    if (!apiKey) {
-      setError('No Anthropic API key found. Please set it in Settings.');
-      return;
-    }
-
     setError(null);
 
     const userMessage: Message = {
@@ -76,7 +69,7 @@ export const AIChat: React.FC = () => {
         content: msg.content,
       }));
 
-      const response = await fetch(`${INTEGRATION_URL}/ai-chat`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/ai-chat`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -84,7 +77,6 @@ export const AIChat: React.FC = () => {
         body: JSON.stringify({
           message: input,
           workspacePath: currentWorkspace.projectFolder,
-          apiKey: apiKey,
           history: history,
           aiPreset: currentWorkspace.activeAIPreset || 0,
         }),
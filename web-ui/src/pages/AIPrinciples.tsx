@@ -3,7 +3,7 @@ import { Card } from '../components/Card';
 import { Button } from '../components/Button';
 import { useWorkspace } from '../context/WorkspaceContext';
 import { PageLayout } from '../components';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 
 const presetDescriptions = [
   {
@@ -92,7 +92,7 @@ export const AIPrinciples: React.FC = () => {
     try {
       // Copy the preset policy file to the workspace specifications folder
       if (currentWorkspace.projectFolder) {
-        const response = await fetch(`${INTEGRATION_URL}/activate-ai-preset`, {
+        const response = await integrationFetch(`${INTEGRATION_URL}/activate-ai-preset`, {
           method: 'POST',
           headers: {
             'Content-Type': 'application/json',
@@ -150,6 +150,7 @@ const getRoleDisplayName = (role: string): string => {
     'designer': 'Designer',
     'engineer': 'Engineer',
     'devops': 'DevOps',
+    'compliance': 'Compliance',
     'admin': 'Administrator',
   };
   return roleMap[role] || role;
@@ -1067,6 +1068,7 @@ const Admin: React.FC = () => {
                   <option value="designer">Designer</option>
                   <option value="engineer">Engineer</option>
                   <option value="devops">DevOps</option>
+                  <option value="compliance">Compliance</option>
                   <option value="admin">Administrator</option>
                 </select>
               </div>
@@ -1126,6 +1128,7 @@ const Admin: React.FC = () => {
                             <option value="designer">Designer</option>
                             <option value="engineer">Engineer</option>
                             <option value="devops">DevOps</option>
+                            <option value="compliance">Compliance</option>
                             <option value="admin">Administrator</option>
                           </select>
                         </td>
@@ -3,7 +3,7 @@ import { Button } from '../components/Button';
 import { Card } from '../components/Card';
 import { PageLayout } from '../components/PageLayout';
 import { useWorkspace } from '../context/WorkspaceContext';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 
 interface AnalysisFile {
   name: string;
@@ -56,7 +56,7 @@ export const Analyze: React.FC = () => {
 
     try {
       // Fetch files from specifications, code, and assets folders
-      const response = await fetch(`${INTEGRATION_URL}/analysis-files`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/analysis-files`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -92,13 +92,6 @@ export const Analyze: React.FC = () => {
       return;
     }
 
-    const apiKey = localStorage.getItem('anthropic_api_key');
-    if (!apiKey) {
-      setError('No Anthropic API key found. Please set it in Settings.');
-      addLog('error', 'No Anthropic API key found');
-      return;
-    }
-
     setError(null);
     setIsAnalyzing(true);
     setOutput('Starting application analysis...\n');
@@ -112,14 +105,13 @@ export const Analyze: React.FC = () => {
     try {
       addLog('info', 'Sending request to /analyze-application endpoint...');
 
-      const response = await fetch(`${INTEGRATION_URL}/analyze-application`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/analyze-application`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
         },
         body: JSON.stringify({
           workspacePath: currentWorkspace.projectFolder,
-          apiKey: apiKey,
           aiPreset: currentWorkspace.activeAIPreset,
           prompt: `Claude, please follow the AI-Policy-Preset${currentWorkspace.activeAIPreset}.md and reverse engineer the application based on the screenshots and URLs provided in the ideation markdowns found in the ./specifications folder for this workspace. As a result of the reverse engineering effort, markdowns for capabilities, enablers, storyboards, dependencies and any graphical assets should be produced in the ./specifications folder. In addition if any code is created based on the analysis, the developed source code files shall be placed into a new ./code folder. Any graphical, UI or design assets shall be placed in a ./assets folder. If none of these folders exist, please make sure they are created.${additionalPrompt ? `\n\nAdditional Instructions:\n${additionalPrompt}` : ''}`,
         }),
@@ -6,7 +6,7 @@ import { StageBadge, ApprovalStatusBadge } from '../components/ApprovalStatusBad
 import { useWorkspace } from '../context/WorkspaceContext';
 import { useApproval } from '../context/ApprovalContext';
 import { useEntityState } from '../context/EntityStateContext';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 import { ReadOnlyStateFields } from '../components/ReadOnlyStateFields';
 import type {
   Capability,
@@ -199,7 +199,7 @@ export const Capabilities: React.FC = () => {
 
     setLoadingFiles(true);
     try {
-      const response = await fetch(`${INTEGRATION_URL}/capability-files`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/capability-files`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -227,7 +227,7 @@ export const Capabilities: React.FC = () => {
     if (!currentWorkspace?.projectFolder) return;
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/enabler-files`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/enabler-files`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -252,7 +252,7 @@ export const Capabilities: React.FC = () => {
     if (!currentWorkspace?.projectFolder) return;
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/story-files`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/story-files`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -344,7 +344,7 @@ ${storyboardList}
 Which storyboard card title is the BEST match for this capability?
 Respond with ONLY the exact storyboard card title (e.g., "User Login Flow") and nothing else. If no good match exists, respond with "NONE".`;
 
-      const response = await fetch(`${INTEGRATION_URL}/ai-chat`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/ai-chat`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -352,7 +352,6 @@ Respond with ONLY the exact storyboard card title (e.g., "User Login Flow") and
         body: JSON.stringify({
           message: prompt,
           workspacePath: currentWorkspace.projectFolder,
-          apiKey: localStorage.getItem('anthropic_api_key') || '',
         }),
       });
 
@@ -394,7 +393,7 @@ Respond with ONLY the exact storyboard card title (e.g., "User Login Flow") and
     // Save the storyboard reference to the file immediately
     if (selectedFileCapability && suggestedStoryboard) {
       try {
-        await fetch(`${INTEGRATION_URL}/update-capability-storyboard`, {
+        await integrationFetch(`${INTEGRATION_URL}/update-capability-storyboard`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({
@@ -539,7 +538,7 @@ Respond with ONLY the exact storyboard card title (e.g., "User Login Flow") and
     try {
       // Save content to markdown file (NO state fields - state is stored in database only)
       const capabilityId = selectedFileCapability.capabilityId || selectedFileCapability.fields?.['ID'] || '';
-      const response = await fetch(`${INTEGRATION_URL}/save-capability`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/save-capability`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -667,7 +666,7 @@ Respond with ONLY the exact storyboard card title (e.g., "User Login Flow") and
 
     setDeletingCapability(true);
     try {
-      const response = await fetch(`${INTEGRATION_URL}/delete-capability`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/delete-capability`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -702,7 +701,7 @@ Respond with ONLY the exact storyboard card title (e.g., "User Login Flow") and
     }
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/delete-enabler`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/delete-enabler`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -735,23 +734,14 @@ Respond with ONLY the exact storyboard card title (e.g., "User Login Flow") and
     setAnalysisInfo(null);
 
     try {
-      // Get API key
-      const anthropicKey = localStorage.getItem('anthropic_api_key') || '';
-      if (!anthropicKey) {
-        alert('Please add your Anthropic API key in the Settings page.');
-        setIsAnalyzing(false);
-        return;
-      }
-
       // Analyze conception folder files using Capability-Driven Architecture Map
-      const response = await fetch(`${INTEGRATION_URL}/analyze-conception`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/analyze-conception`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
         },
         body: JSON.stringify({
           workspacePath: currentWorkspace.projectFolder,
-          anthropic_key: anthropicKey,
           existingCapabilities: fileCapabilities.map(c => c.name),
         }),
       });
@@ -955,7 +945,7 @@ Respond with ONLY the exact storyboard card title (e.g., "User Login Flow") and
     markdown += `\n`;
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/save-specifications`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/save-specifications`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
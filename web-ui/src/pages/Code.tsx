@@ -4,7 +4,7 @@ import { Card } from '../components/Card';
 import { useWorkspace } from '../context/WorkspaceContext';
 import { usePhaseApprovals } from '../context/EntityStateContext';
 import { PageLayout } from '../components';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 
 interface CodeFile {
   name: string;
@@ -152,7 +152,7 @@ export const Code: React.FC = () => {
   // Poll job status
   const pollJobStatus = useCallback(async (jobId: string) => {
     try {
-      const response = await fetch(`${INTEGRATION_URL}/generate-code-status/${jobId}`);
+      const response = await integrationFetch(`${INTEGRATION_URL}/generate-code-status/${jobId}`);
 
       if (!response.ok) {
         if (response.status === 404) {
@@ -261,7 +261,7 @@ export const Code: React.FC = () => {
     if (!currentWorkspace?.projectFolder) return;
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/code-files`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/code-files`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -290,7 +290,7 @@ export const Code: React.FC = () => {
 
     setIsLoadingCommand(true);
     try {
-      const response = await fetch(`${INTEGRATION_URL}/read-file`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/read-file`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
@@ -397,7 +397,7 @@ export const Code: React.FC = () => {
 
     try {
       // Start the job via the new job-based endpoint
-      const response = await fetch(`${INTEGRATION_URL}/generate-code-job`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/generate-code-job`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -448,7 +448,7 @@ export const Code: React.FC = () => {
     addLog('info', 'Requesting job cancellation...');
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/generate-code-cancel/${currentJobId}`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/generate-code-cancel/${currentJobId}`, {
         method: 'POST',
       });
 
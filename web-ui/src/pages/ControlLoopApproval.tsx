@@ -4,7 +4,7 @@ import { Card, Alert, Button, PageLayout } from '../components';
 import { ValidationDashboard } from '../components/ValidationDashboard';
 import { useWorkspace } from '../context/WorkspaceContext';
 import { usePhaseApprovals } from '../context/EntityStateContext';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 
 interface ControlLoopItem {
   id: string;
@@ -136,7 +136,7 @@ export const ControlLoopApproval: React.FC = () => {
     try {
       // Try to load from file first (for shared/imported workspaces)
       try {
-        const response = await fetch(`${INTEGRATION_URL}/read-file`, {
+        const response = await integrationFetch(`${INTEGRATION_URL}/read-file`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({
@@ -255,7 +255,7 @@ export const ControlLoopApproval: React.FC = () => {
           lastUpdated: new Date().toISOString(),
         }, null, 2);
 
-        await fetch(`${INTEGRATION_URL}/save-specifications`, {
+        await integrationFetch(`${INTEGRATION_URL}/save-specifications`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({
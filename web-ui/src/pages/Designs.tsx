@@ -2,8 +2,8 @@ import React, { useState, useEffect } from 'react';
 import { useNavigate } from 'react-router-dom';
 import { Card, Button, PageLayout } from '../components';
 import { useWorkspace } from '../context/WorkspaceContext';
-import axios from 'axios';
-import { INTEGRATION_URL } from '../api/client';
+import { integrationClient } from '../api/client';
+import { getIntegrationConfig } from '../api/credentialService';
 
 interface FigmaProject {
   id: string;
@@ -32,17 +32,11 @@ export const Designs: React.FC = () => {
   const [loadingFiles, setLoadingFiles] = useState(false);
   const [error, setError] = useState<string | null>(null);
   const [showFilesModal, setShowFilesModal] = useState(false);
-  const [figmaToken, setFigmaToken] = useState('');
+  const [figmaConfigured, setFigmaConfigured] = useState(false);
 
-  // Check if Figma is configured
+  // Check if Figma is configured; its token is used by the integration service
   useEffect(() => {
-    const config = localStorage.getItem('integration_config_figma_api');
-    if (config) {
-      const parsed = JSON.parse(config);
-      if (parsed.fields?.access_token) {
-        setFigmaToken(parsed.fields.access_token);
-      }
-    }
+    setFigmaConfigured(!!getIntegrationConfig('Figma API')?.field_names.includes('access_token'));
   }, []);
 
   // Load saved files when workspace changes
@@ -59,13 +53,13 @@ export const Designs: React.FC = () => {
 
   // Fetch projects when workspace has team URL
   useEffect(() => {
-    if (!currentWorkspace?.figmaTeamUrl || !figmaToken) return;
+    if (!currentWorkspace?.figmaTeamUrl || !figmaConfigured) return;
 
     fetchProjects();
-  }, [currentWorkspace, figmaToken]);
+  }, [currentWorkspace, figmaConfigured]);
 
   const fetchProjects = async () => {
-    if (!currentWorkspace?.figmaTeamUrl || !figmaToken) return;
+    if (!currentWorkspace?.figmaTeamUrl || !figmaConfigured) return;
 
     setLoading(true);
     setError(null);
@@ -73,12 +67,9 @@ export const Designs: React.FC = () => {
     try {
       console.log('Fetching Figma projects with team URL:', currentWorkspace.figmaTeamUrl);
 
-      const response = await axios.post(`${INTEGRATION_URL}/fetch-resources`, {
+      const response = await integrationClient.post('/fetch-resources', {
         integration_name: 'Figma API',
-        credentials: {
-          access_token: figmaToken,
-          team_url: currentWorkspace.figmaTeamUrl,
-        },
+        team_url: currentWorkspace.figmaTeamUrl,
       });
 
       console.log('Figma API response:', response.data);
@@ -102,13 +93,10 @@ export const Designs: React.FC = () => {
     setSelectedFiles(new Set());
 
     try {
-      const response = await axios.post(`${INTEGRATION_URL}/fetch-files`, {
+      const response = await integrationClient.post('/fetch-files', {
         integration_name: 'Figma API',
         resource_id: project.id,
         resource_type: 'project',
-        credentials: {
-          access_token: figmaToken,
-        },
       });
 
       setFiles(response.data.files || []);
@@ -203,7 +191,7 @@ export const Designs: React.FC = () => {
     );
   }
 
-  if (!figmaToken) {
+  if (!figmaConfigured) {
     return (
       <div className="max-w-7xl mx-auto" style={{ padding: '16px' }}>
         <h1 className="text-large-title" style={{ marginBottom: '8px' }}>Design Artifacts</h1>
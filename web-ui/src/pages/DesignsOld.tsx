@@ -1,8 +1,8 @@
 import React, { useState, useEffect } from 'react';
 import { Card, Button } from '../components';
 import { useWorkspace } from '../context/WorkspaceContext';
-import axios from 'axios';
-import { INTEGRATION_URL } from '../api/client';
+import { integrationClient } from '../api/client';
+import { getIntegrationConfig } from '../api/credentialService';
 
 interface IntegrationResource {
   id: string;
@@ -41,13 +41,6 @@ export const Designs: React.FC = () => {
     setWorkspaceIntegrations(workspaceIntgs);
   }, [currentWorkspace]);
 
-  const getIntegrationConfig = (integrationName: string) => {
-    const configKey = `integration_config_${integrationName.toLowerCase().replace(/\s+/g, '_')}`;
-    const config = localStorage.getItem(configKey);
-    if (!config) return null;
-    return JSON.parse(config);
-  };
-
   const handleViewFiles = async (integrationName: string, resource: IntegrationResource) => {
     setSelectedIntegration(integrationName);
     setSelectedResource(resource);
@@ -58,15 +51,14 @@ export const Designs: React.FC = () => {
 
     try {
       const config = getIntegrationConfig(integrationName);
-      if (!config || !config.fields) {
+      if (!config || config.field_names.length === 0) {
         throw new Error(`${integrationName} is not configured`);
       }
 
-      const response = await axios.post(`${INTEGRATION_URL}/fetch-files`, {
+      const response = await integrationClient.post('/fetch-files', {
         integration_name: integrationName,
         resource_id: resource.id,
         resource_type: resource.type,
-        credentials: config.fields
       });
 
       setFiles(response.data.files || []);
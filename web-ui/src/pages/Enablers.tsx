@@ -4,7 +4,7 @@ import { Card, Alert, Button, ConfirmDialog, PageLayout } from '../components';
 import { useEnabler } from '../context/EnablerContext';
 import { useWorkspace } from '../context/WorkspaceContext';
 import { useEntityState } from '../context/EntityStateContext';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 import { ReadOnlyStateFields } from '../components/ReadOnlyStateFields';
 
 // File-based capability from workspace definition folder
@@ -81,6 +81,7 @@ import {
   getCriteriaStatusColor,
   getCriteriaPriorityDisplayName,
   generateId,
+  allocateIds,
   getSpecificationStatusColor,
   getSpecificationApprovalColor,
 } from '../api/enablerService';
@@ -292,13 +293,6 @@ export const Enablers: React.FC = () => {
       return;
     }
 
-    // Get API key
-    const anthropicKey = localStorage.getItem('anthropic_api_key') || '';
-    if (!anthropicKey) {
-      setAiRecommendationError('Please add your Anthropic API key in the Settings page.');
-      return;
-    }
-
     setIsLoadingAiRecommendation(true);
     setAiRecommendationError(null);
 
@@ -349,14 +343,13 @@ IMPORTANT: Respond ONLY with valid JSON in this exact format, no other text:
 }`;
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/ai-chat`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/ai-chat`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
           message: prompt,
           workspacePath: currentWorkspace?.projectFolder || '',
           history: [],
-          apiKey: anthropicKey,
         }),
       });
 
@@ -503,7 +496,7 @@ IMPORTANT: Respond ONLY with valid JSON in this exact format, no other text:
 
     setLoadingCapabilities(true);
     try {
-      const response = await fetch(`${INTEGRATION_URL}/capability-files`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/capability-files`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -580,7 +573,7 @@ IMPORTANT: Respond ONLY with valid JSON in this exact format, no other text:
 
     setLoadingFileEnablers(true);
     try {
-      const response = await fetch(`${INTEGRATION_URL}/enabler-files`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/enabler-files`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -638,7 +631,7 @@ IMPORTANT: Respond ONLY with valid JSON in this exact format, no other text:
     // For file-based capabilities, we store the capability ID string
     // The numeric capability_id is 0 since we're using file-based references
     setEnablerFormData({
-      enabler_id: generateId('ENB'),
+      enabler_id: '', // Assigned on save
       capability_id: 0, // Use 0 for file-based capabilities
       name: '',
       description: '',
@@ -791,7 +784,7 @@ IMPORTANT: Respond ONLY with valid JSON in this exact format, no other text:
   // Add inline requirement during enabler creation
   const handleAddInlineRequirement = () => {
     const newReq: InlineRequirement = {
-      id: generateId('FR'),
+      id: '', // Assigned on save
       name: '',
       description: '',
       type: 'functional',
@@ -805,13 +798,7 @@ IMPORTANT: Respond ONLY with valid JSON in this exact format, no other text:
   // Update inline requirement
   const handleUpdateInlineRequirement = (index: number, field: string, value: string) => {
     const updated = [...inlineRequirements];
-    if (field === 'type') {
-      // Update ID prefix when type changes
-      const newId = value === 'functional' ? generateId('FR') : generateId('NFR');
-      updated[index] = { ...updated[index], [field]: value, id: newId };
-    } else {
-      updated[index] = { ...updated[index], [field]: value };
-    }
+    updated[index] = { ...updated[index], [field]: value };
     setInlineRequirements(updated);
   };
 
@@ -849,7 +836,7 @@ ${capabilityList}
 Which capability ID is the BEST match for this enabler?
 Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no good match exists, respond with "NONE".`;
 
-      const response = await fetch(`${INTEGRATION_URL}/ai-chat`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/ai-chat`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -857,7 +844,6 @@ Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no
         body: JSON.stringify({
           message: prompt,
           workspacePath: currentWorkspace.projectFolder,
-          apiKey: localStorage.getItem('anthropic_api_key') || '',
         }),
       });
 
@@ -906,7 +892,7 @@ Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no
       const capabilityName = capability?.name || suggestedCapabilityId;
 
       try {
-        await fetch(`${INTEGRATION_URL}/update-enabler-capability`, {
+        await integrationFetch(`${INTEGRATION_URL}/update-enabler-capability`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({
@@ -1022,6 +1008,18 @@ Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no
         throw new Error('Workspace folder not configured. Please set a project folder in Workspaces.');
       }
 
+      // New enablers and requirements get their IDs from the server's allocator
+      const enablerId = enablerFormData.enabler_id
+        || (await allocateIds(currentWorkspace.projectFolder, 'ENB'))[0];
+      const requirements = inlineRequirements.map(r => ({ ...r }));
+      for (const [type, prefix] of [['functional', 'FR'], ['non_functional', 'NFR']] as const) {
+        const unassigned = requirements.filter(r => r.type === type && !r.id);
+        if (unassigned.length > 0) {
+          const ids = await allocateIds(currentWorkspace.projectFolder, prefix, unassigned.length);
+          unassigned.forEach((r, i) => { r.id = ids[i]; });
+        }
+      }
+
       // If editing existing enabler, use the original filename; otherwise generate new one
       let fileName: string;
       if (editingFileEnabler) {
@@ -1034,7 +1032,7 @@ Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no
           .slice(0, 3)
           .join('-')
           .replace(/[^a-z0-9-]/g, '');
-        fileName = `${enablerFormData.enabler_id}-${nameSlug}.md`;
+        fileName = `${enablerId}-${nameSlug}.md`;
       }
 
       // Find the capability name for reference - search by both ID and name
@@ -1049,7 +1047,7 @@ Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no
       // Generate markdown content (NO state fields - state is stored in database only)
       let markdown = `# ${enablerFormData.name}\n\n`;
       markdown += `## Metadata\n`;
-      markdown += `- **ID**: ${enablerFormData.enabler_id}\n`;
+      markdown += `- **ID**: ${enablerId}\n`;
       markdown += `- **Type**: Enabler\n`;
       markdown += `- **Capability ID**: ${capabilityId}\n`;  // Store the actual capability ID for reliable parsing
       markdown += `- **Capability**: ${capabilityName}\n`;
@@ -1127,7 +1125,7 @@ Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no
       }
 
       // Add Functional Requirements section
-      const functionalReqs = inlineRequirements.filter(r => r.type === 'functional');
+      const functionalReqs = requirements.filter(r => r.type === 'functional');
       markdown += `## Functional Requirements\n`;
       if (functionalReqs.length > 0) {
         markdown += `| ID | Name | Requirement | Status | Priority | Approval |\n`;
@@ -1141,7 +1139,7 @@ Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no
       markdown += `\n`;
 
       // Add Non-Functional Requirements section
-      const nonFunctionalReqs = inlineRequirements.filter(r => r.type === 'non_functional');
+      const nonFunctionalReqs = requirements.filter(r => r.type === 'non_functional');
       markdown += `## Non-Functional Requirements\n`;
       if (nonFunctionalReqs.length > 0) {
         markdown += `| ID | Name | Requirement | Type | Status | Priority | Approval |\n`;
@@ -1157,7 +1155,7 @@ Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no
       markdown += `## Acceptance Criteria\n_No acceptance criteria defined yet._\n`;
 
       // Save to definition folder
-      const response = await fetch(`${INTEGRATION_URL}/save-specifications`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/save-specifications`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -1175,7 +1173,7 @@ Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no
       }
 
       // Save state to DATABASE via EntityStateContext (single source of truth for state)
-      if (currentWorkspace?.id && enablerFormData.enabler_id) {
+      if (currentWorkspace?.id && enablerId) {
         try {
           // Find the parent capability - check both capabilityId formats
           console.log('Looking for capability with formCapabilityId:', formCapabilityId);
@@ -1241,7 +1239,7 @@ Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no
             }
 
             await syncEnabler({
-              enabler_id: enablerFormData.enabler_id,
+              enabler_id: enablerId,
               capability_id: capabilityDbId, // Use the database ID, not the string ID
               name: enablerFormData.name,
               description: enablerFormData.purpose || '',
@@ -1287,7 +1285,7 @@ Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no
       onConfirm: async () => {
         closeConfirmDialog();
         try {
-          const response = await fetch(`${INTEGRATION_URL}/delete-specification`, {
+          const response = await integrationFetch(`${INTEGRATION_URL}/delete-specification`, {
             method: 'POST',
             headers: {
               'Content-Type': 'application/json',
@@ -1336,7 +1334,7 @@ Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no
     if (!selectedEnabler) return;
     setEditingRequirement(null);
     setRequirementFormData({
-      requirement_id: generateId('FR'),
+      requirement_id: '', // Assigned by the server on create
       enabler_id: selectedEnabler.id,
       name: '',
       description: '',
@@ -1424,27 +1422,19 @@ Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no
       return;
     }
 
-    // Get API key
-    const anthropicKey = localStorage.getItem('anthropic_api_key') || '';
-    if (!anthropicKey) {
-      alert('Please add your Anthropic API key in the Settings page.');
-      return;
-    }
-
     setIsAnalyzingCapabilities(true);
     setProposedEnablers([]);
     setAnalysisInfo(null);
 
     try {
       // Call backend to analyze capabilities and propose enablers
-      const response = await fetch(`${INTEGRATION_URL}/analyze-capabilities`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/analyze-capabilities`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
         },
         body: JSON.stringify({
           workspacePath: currentWorkspace.projectFolder,
-          anthropic_key: anthropicKey,
           existingEnablers: fileEnablers.map(e => e.name),
         }),
       });
@@ -1544,7 +1534,7 @@ Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no
     markdown += `\`\`\`\n`;
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/save-specifications`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/save-specifications`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -1852,6 +1842,7 @@ Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no
               className="input"
               readOnly
               value={enablerFormData.enabler_id}
+              placeholder="Assigned on save"
               onChange={(e) => setEnablerFormData({ ...enablerFormData, enabler_id: e.target.value })}
               style={{
                 width: '100%',
@@ -2291,7 +2282,7 @@ Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no
                     }}
                   >
                     <div style={{ display: 'flex', justifyContent: 'space-between', alignItems: 'start', marginBottom: '8px' }}>
-                      <span className="text-footnote text-secondary">{req.id}</span>
+                      <span className="text-footnote text-secondary">{req.id || 'ID assigned on save'}</span>
                       <button
                         onClick={() => handleRemoveInlineRequirement(index)}
                         style={{ background: 'none', border: 'none', cursor: 'pointer', color: 'var(--color-systemRed)', fontSize: '12px' }}
@@ -2445,6 +2436,7 @@ Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no
               type="text"
               className="input"
               value={requirementFormData.requirement_id}
+              placeholder="Assigned on save"
               onChange={(e) => setRequirementFormData({ ...requirementFormData, requirement_id: e.target.value })}
               style={{ width: '100%', marginTop: '4px' }}
             />
@@ -2457,11 +2449,7 @@ Respond with ONLY the capability ID (e.g., "CAP-123456") and nothing else. If no
               value={requirementFormData.requirement_type}
               onChange={(e) => {
                 const type = e.target.value as RequirementType;
-                setRequirementFormData({
-                  ...requirementFormData,
-                  requirement_type: type,
-                  requirement_id: type === 'functional' ? generateId('FR') : generateId('NFR'),
-                });
+                setRequirementFormData({ ...requirementFormData, requirement_type: type });
               }}
               style={{ width: '100%', marginTop: '4px' }}
             >
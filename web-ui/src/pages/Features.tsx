@@ -1,7 +1,7 @@
 import React, { useState, useEffect } from 'react';
 import { Card, Alert, Button, PageLayout } from '../components';
 import { useWorkspace } from '../context/WorkspaceContext';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 
 interface FileFeature {
   filename: string;
@@ -51,7 +51,7 @@ export const Features: React.FC = () => {
 
     setLoading(true);
     try {
-      const response = await fetch(`${INTEGRATION_URL}/feature-files`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/feature-files`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -113,7 +113,7 @@ export const Features: React.FC = () => {
         path = `${currentWorkspace.projectFolder}/specifications/${fileName}`;
       }
 
-      const response = await fetch(`${INTEGRATION_URL}/save-feature`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/save-feature`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -152,7 +152,7 @@ export const Features: React.FC = () => {
 
     setDeletingFeature(true);
     try {
-      const response = await fetch(`${INTEGRATION_URL}/delete-feature`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/delete-feature`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
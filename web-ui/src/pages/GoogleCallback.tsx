@@ -1,11 +1,13 @@
 import React, { useEffect, useState, useRef } from 'react';
-import { useNavigate, useSearchParams } from 'react-router-dom';
-import { useAuth } from '../context/AuthContext';
+import { useNavigate, useParams, useSearchParams } from 'react-router-dom';
+import { useAuth, type TwoFactorChallenge } from '../context/AuthContext';
 
+// Completes Google sign-in, and OIDC sign-in on /auth/oidc/:provider/callback
 export const GoogleCallback: React.FC = () => {
   const [searchParams] = useSearchParams();
+  const { provider } = useParams();
   const [error, setError] = useState<string | null>(null);
-  const { handleGoogleCallback } = useAuth();
+  const { handleGoogleCallback, handleOIDCCallback } = useAuth();
   const navigate = useNavigate();
   const hasProcessed = useRef(false);
 
@@ -35,7 +37,14 @@ export const GoogleCallback: React.FC = () => {
       }
 
       try {
-        await handleGoogleCallback(code, state);
+        const challenge: TwoFactorChallenge | null = provider
+          ? await handleOIDCCallback(provider, code, state)
+          : await handleGoogleCallback(code, state);
+        if (challenge) {
+          // The second factor is entered on the login page
+          navigate('/login', { replace: true, state: { challenge } });
+          return;
+        }
         // Redirect to home page on success
         navigate('/');
       } catch (err) {
@@ -45,7 +54,7 @@ export const GoogleCallback: React.FC = () => {
     };
 
     processCallback();
-  }, [searchParams, handleGoogleCallback, navigate]);
+  }, [searchParams, provider, handleGoogleCallback, handleOIDCCallback, navigate]);
 
   return (
     <div className="min-h-screen flex items-center justify-center bg-gradient-to-br from-indigo-50 via-white to-purple-50">
@@ -101,7 +110,7 @@ export const GoogleCallback: React.FC = () => {
                 </h3>
               </div>
               <p className="text-sm text-grey-500 text-center">
-                Please wait while we complete your Google authentication...
+                Please wait while we complete your {provider ? 'single sign-on' : 'Google authentication'}...
               </p>
             </div>
           )}
@@ -3,7 +3,7 @@ import { useNavigate } from 'react-router-dom';
 import { Card, Alert, Button, PageLayout } from '../components';
 import { useWorkspace } from '../context/WorkspaceContext';
 import { usePhaseApprovals } from '../context/EntityStateContext';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 
 interface ImplementationItem {
   id: string;
@@ -133,7 +133,7 @@ export const ImplementationApproval: React.FC = () => {
       // Try to load from file first (for shared/imported workspaces)
       if (currentWorkspace?.projectFolder) {
         try {
-          const response = await fetch(`${INTEGRATION_URL}/read-file`, {
+          const response = await integrationFetch(`${INTEGRATION_URL}/read-file`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify({
@@ -215,7 +215,7 @@ export const ImplementationApproval: React.FC = () => {
           lastUpdated: new Date().toISOString(),
         }, null, 2);
 
-        await fetch(`${INTEGRATION_URL}/save-specifications`, {
+        await integrationFetch(`${INTEGRATION_URL}/save-specifications`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({
@@ -244,7 +244,7 @@ export const ImplementationApproval: React.FC = () => {
         lastUpdated: new Date().toISOString(),
       }, null, 2);
 
-      await fetch(`${INTEGRATION_URL}/save-specifications`, {
+      await integrationFetch(`${INTEGRATION_URL}/save-specifications`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
@@ -1,8 +1,13 @@
 import React, { useState, useEffect } from 'react';
 import { Card, Alert, Button, PageLayout, CreateIntegrationModal } from '../components';
 import type { CustomIntegration } from '../components';
-import axios from 'axios';
-import { INTEGRATION_URL, SPEC_URL } from '../api/client';
+import { SPEC_URL, integrationClient } from '../api/client';
+import {
+  deleteCredentials,
+  getIntegrationConfig,
+  integrationConfigKey,
+  saveIntegrationConfig,
+} from '../api/credentialService';
 import { useWorkspace } from '../context/WorkspaceContext';
 
 interface IntegrationConfig {
@@ -50,6 +55,18 @@ const INTEGRATIONS: IntegrationConfig[] = [
     providerURL: 'https://docs.github.com/en/rest',
     description: 'Code repository integration.',
     status: 'inactive'
+  },
+  {
+    name: 'GitLab',
+    providerURL: 'https://docs.gitlab.com/ee/api/rest/',
+    description: 'Repositories, issues and group epics.',
+    status: 'inactive'
+  },
+  {
+    name: 'Azure DevOps',
+    providerURL: 'https://learn.microsoft.com/en-us/rest/api/azure/devops/',
+    description: 'Repositories, work items and boards.',
+    status: 'inactive'
   }
 ];
 
@@ -104,6 +121,44 @@ const KNOWN_INTEGRATION_REQUIREMENTS: Record<string, {
       },
     ],
   },
+  'GitLab': {
+    authMethod: 'Personal Access Token',
+    fields: [
+      {
+        name: 'access_token',
+        type: 'password',
+        description: 'Personal Access Token with the read_api scope, from User Settings > Access Tokens',
+        example: 'glpat-xxxxxxxxxxxx',
+        required: true,
+      },
+      {
+        name: 'base_url',
+        type: 'text',
+        description: 'URL of a self-managed instance; leave empty for gitlab.com',
+        example: 'https://gitlab.example.com',
+        required: false,
+      },
+    ],
+  },
+  'Azure DevOps': {
+    authMethod: 'Personal Access Token (Basic Authentication)',
+    fields: [
+      {
+        name: 'organization',
+        type: 'text',
+        description: 'Organization name or URL',
+        example: 'https://dev.azure.com/contoso',
+        required: true,
+      },
+      {
+        name: 'access_token',
+        type: 'password',
+        description: 'Personal Access Token with Code, Work Items and Project and Team read scopes',
+        example: 'xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx',
+        required: true,
+      },
+    ],
+  },
   'Figma API': {
     authMethod: 'Personal Access Token',
     fields: [
@@ -126,6 +181,8 @@ export const Integrations: React.FC = () => {
   const [selectedIntegration, setSelectedIntegration] = useState<IntegrationConfig | null>(null);
   const [showConfigModal, setShowConfigModal] = useState(false);
   const [formValues, setFormValues] = useState<Record<string, string>>({});
+  // Fields with a value in the credential vault; left blank, they keep it
+  const [storedFieldNames, setStoredFieldNames] = useState<string[]>([]);
   const [saving, setSaving] = useState(false);
   const [saveSuccess, setSaveSuccess] = useState(false);
   const [providerURL, setProviderURL] = useState('');
@@ -155,19 +212,9 @@ export const Integrations: React.FC = () => {
     const checkConfigurations = () => {
       const configured: Record<string, boolean> = {};
       INTEGRATIONS.forEach(integration => {
-        const configKey = `integration_config_${integration.name.toLowerCase().replace(/\s+/g, '_')}`;
-        const config = localStorage.getItem(configKey);
-        if (config) {
-          try {
-            const parsed = JSON.parse(config);
-            // Check if the configuration has actual credentials
-            configured[integration.name] = !!(parsed.fields && Object.keys(parsed.fields).length > 0);
-          } catch {
-            configured[integration.name] = false;
-          }
-        } else {
-          configured[integration.name] = false;
-        }
+        // Check if the configuration has stored credentials
+        const config = getIntegrationConfig(integration.name);
+        configured[integration.name] = !!config && config.field_names.length > 0;
       });
       // Also check custom integrations
       customIntegrations.forEach(integration => {
@@ -272,19 +319,18 @@ export const Integrations: React.FC = () => {
     setShowConfigModal(true);
 
     // Check if configuration already exists
-    const configKey = `integration_config_${integration.name.toLowerCase().replace(/\s+/g, '_')}`;
-    const existingConfig = localStorage.getItem(configKey);
+    const configKey = integrationConfigKey(integration.name);
+    const config = getIntegrationConfig(integration.name);
 
-    if (existingConfig) {
-      // Load existing configuration
+    if (config) {
+      // Load existing configuration; the stored values stay in the vault
       try {
-        const config = JSON.parse(existingConfig);
+        setStoredFieldNames(config.field_names);
 
         // If no provider_url in saved config (old format), use default and migrate
         if (!config.provider_url) {
           console.warn('Found old configuration format without provider_url, migrating...');
           setProviderURL(integration.providerURL);
-          setFormValues(config.fields || {});
 
           // Update the stored config with the provider_url
           const updatedConfig = {
@@ -298,7 +344,6 @@ export const Integrations: React.FC = () => {
         } else {
           // Normal flow with provider_url present
           setProviderURL(config.provider_url);
-          setFormValues(config.fields || {});
 
           // For known integrations, always use our predefined requirements
           // to ensure all required fields are shown (fixes incomplete LLM analysis)
@@ -368,26 +413,23 @@ export const Integrations: React.FC = () => {
 
         // Optionally, still call LLM to get description and capabilities
         // but merge our known required fields
+        // (uses the Anthropic API key stored in Settings, if any)
         try {
-          const anthropicKey = localStorage.getItem('anthropic_api_key');
-          if (anthropicKey) {
-            const response = await axios.post(`${INTEGRATION_URL}/analyze-integration`, {
-              provider_url: url,
-              provider_name: name,
-              anthropic_key: anthropicKey
-            });
-
-            // Merge LLM analysis with known requirements
-            const mergedAnalysis = mergeWithKnownRequirements(response.data, name);
-
-            // Cache the merged analysis
-            const cacheKey = `integration_analysis_${url}`;
-            localStorage.setItem(cacheKey, JSON.stringify(mergedAnalysis));
-
-            setAnalysis(mergedAnalysis);
-            setShowURLInput(false);
-            return;
-          }
+          const response = await integrationClient.post('/analyze-integration', {
+            provider_url: url,
+            provider_name: name,
+          });
+
+          // Merge LLM analysis with known requirements
+          const mergedAnalysis = mergeWithKnownRequirements(response.data, name);
+
+          // Cache the merged analysis
+          const cacheKey = `integration_analysis_${url}`;
+          localStorage.setItem(cacheKey, JSON.stringify(mergedAnalysis));
+
+          setAnalysis(mergedAnalysis);
+          setShowURLInput(false);
+          return;
         } catch (llmErr) {
           console.warn('LLM analysis failed, using known requirements:', llmErr);
         }
@@ -399,12 +441,6 @@ export const Integrations: React.FC = () => {
       }
 
       // For unknown integrations, use LLM analysis
-      const anthropicKey = localStorage.getItem('anthropic_api_key');
-
-      if (!anthropicKey) {
-        throw new Error('Anthropic API key not found. Please add it in Settings.');
-      }
-
       // Check cache first
       const cacheKey = `integration_analysis_${url}`;
       const cachedAnalysis = localStorage.getItem(cacheKey);
@@ -416,10 +452,9 @@ export const Integrations: React.FC = () => {
       }
 
       // Call the integration analysis endpoint
-      const response = await axios.post(`${INTEGRATION_URL}/analyze-integration`, {
+      const response = await integrationClient.post('/analyze-integration', {
         provider_url: url,
         provider_name: name,
-        anthropic_key: anthropicKey
       });
 
       // Cache the analysis
@@ -449,26 +484,25 @@ export const Integrations: React.FC = () => {
     setError(null);
 
     try {
-      // Validate required fields
+      // Validate required fields; stored ones may be left blank to keep their value
       const missingFields = analysis.required_fields.filter(
-        field => !formValues[field.name] || formValues[field.name].trim() === ''
+        field => (!formValues[field.name] || formValues[field.name].trim() === '') && !storedFieldNames.includes(field.name)
       );
 
       if (missingFields.length > 0) {
         throw new Error(`Please fill in required fields: ${missingFields.map(f => f.name).join(', ')}`);
       }
 
-      // Save configuration to localStorage
-      const configKey = `integration_config_${selectedIntegration.name.toLowerCase().replace(/\s+/g, '_')}`;
-      const configData = {
-        integration_name: selectedIntegration.name,
-        provider_url: providerURL,
-        configured_at: new Date().toISOString(),
-        auth_method: analysis.auth_method,
-        fields: formValues,
-      };
-
-      localStorage.setItem(configKey, JSON.stringify(configData));
+      // Save the credentials to the vault; blank stored fields keep their value
+      const fields: Record<string, string> = {};
+      [...analysis.required_fields, ...analysis.optional_fields].forEach(field => {
+        if (formValues[field.name] || storedFieldNames.includes(field.name)) {
+          fields[field.name] = formValues[field.name] || '';
+        }
+      });
+      await saveIntegrationConfig(selectedIntegration.name, providerURL, fields, {
+        authMethod: analysis.auth_method,
+      });
 
       // Show success message
       setSaveSuccess(true);
@@ -490,6 +524,7 @@ export const Integrations: React.FC = () => {
     setError(null);
     setSelectedIntegration(null);
     setFormValues({});
+    setStoredFieldNames([]);
     setSaving(false);
     setSaveSuccess(false);
     setProviderURL('');
@@ -512,11 +547,11 @@ export const Integrations: React.FC = () => {
     setCustomIntegrations(updated);
     localStorage.setItem('custom_integrations', JSON.stringify(updated));
 
-    // Also remove the individual config
+    // Also remove the individual config and its stored credentials
     const integration = customIntegrations.find(i => i.id === integrationId);
     if (integration) {
-      const configKey = `integration_config_${integration.name.toLowerCase().replace(/\s+/g, '_')}`;
-      localStorage.removeItem(configKey);
+      localStorage.removeItem(integrationConfigKey(integration.name));
+      deleteCredentials(integration.name).catch(err => console.warn('Failed to delete stored credentials:', err));
     }
   };
 
@@ -526,7 +561,7 @@ export const Integrations: React.FC = () => {
       quickDescription="Manage connections to external design tools and services."
       detailedDescription="Configure integrations with external services like Figma, GitHub, and Jira.
 Integrations enable automatic synchronization of design assets, code repositories, and project management data.
-Each integration requires API credentials which are stored securely in your browser's local storage."
+Each integration requires API credentials which are stored encrypted by the integration service, never in your browser."
       actions={
         <Button
           variant="primary"
@@ -938,7 +973,7 @@ Each integration requires API credentials which are stored securely in your brow
                             </p>
                             <input
                               type={field.type === 'password' ? 'password' : 'text'}
-                              placeholder={field.example || `Enter ${field.name}`}
+                              placeholder={storedFieldNames.includes(field.name) ? '•••••••• (stored, leave blank to keep)' : field.example || `Enter ${field.name}`}
                               value={formValues[field.name] || ''}
                               onChange={(e) => handleFieldChange(field.name, e.target.value)}
                               disabled={saving || saveSuccess}
@@ -969,7 +1004,7 @@ Each integration requires API credentials which are stored securely in your brow
                             </p>
                             <input
                               type={field.type === 'password' ? 'password' : 'text'}
-                              placeholder={field.example || `Enter ${field.name}`}
+                              placeholder={storedFieldNames.includes(field.name) ? '•••••••• (stored, leave blank to keep)' : field.example || `Enter ${field.name}`}
                               value={formValues[field.name] || ''}
                               onChange={(e) => handleFieldChange(field.name, e.target.value)}
                               disabled={saving || saveSuccess}
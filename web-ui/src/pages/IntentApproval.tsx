@@ -3,7 +3,7 @@ import { useNavigate } from 'react-router-dom';
 import { Card, Alert, Button, PageLayout } from '../components';
 import { useWorkspace } from '../context/WorkspaceContext';
 import { useEntityState, usePhaseApprovals } from '../context/EntityStateContext';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 import {
   type LifecycleState,
   type WorkflowStage,
@@ -163,7 +163,7 @@ export const IntentApproval: React.FC = () => {
     setLoading(true);
     try {
       // Load vision items (using theme-files endpoint which handles VIS-*, VISION-*, THEME-* files)
-      const visionResponse = await fetch(`${INTEGRATION_URL}/theme-files`, {
+      const visionResponse = await integrationFetch(`${INTEGRATION_URL}/theme-files`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
@@ -181,7 +181,7 @@ export const IntentApproval: React.FC = () => {
       }));
 
       // Load ideation items (using ideation-files endpoint which handles IDEA-* files)
-      const ideationResponse = await fetch(`${INTEGRATION_URL}/ideation-files`, {
+      const ideationResponse = await integrationFetch(`${INTEGRATION_URL}/ideation-files`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
@@ -199,7 +199,7 @@ export const IntentApproval: React.FC = () => {
       }));
 
       // Load storyboard items (using story-files endpoint which handles story*, STORY*, SB-* files)
-      const storyboardResponse = await fetch(`${INTEGRATION_URL}/story-files`, {
+      const storyboardResponse = await integrationFetch(`${INTEGRATION_URL}/story-files`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
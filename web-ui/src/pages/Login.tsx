@@ -1,6 +1,7 @@
 import React, { useState, useEffect } from 'react';
-import { useNavigate } from 'react-router-dom';
-import { useAuth } from '../context/AuthContext';
+import { useLocation, useNavigate } from 'react-router-dom';
+import { useAuth, type TwoFactorChallenge, type TOTPEnrollment } from '../context/AuthContext';
+import { authClient } from '../api/client';
 
 interface LoginTextSection {
   title: string;
@@ -8,6 +9,11 @@ interface LoginTextSection {
   text: string;
 }
 
+interface OIDCProvider {
+  name: string;
+  displayName: string;
+}
+
 interface LoginConfig {
   aboveLogin: LoginTextSection;
   belowLogin: LoginTextSection;
@@ -18,8 +24,37 @@ export const Login: React.FC = () => {
   const [password, setPassword] = useState('');
   const [error, setError] = useState<string | null>(null);
   const [config, setConfig] = useState<LoginConfig | null>(null);
-  const { login, loginWithGoogle, isLoading } = useAuth();
+  const [oidcProviders, setOidcProviders] = useState<OIDCProvider[]>([]);
+  const [challenge, setChallenge] = useState<TwoFactorChallenge | null>(null);
+  const [enrollment, setEnrollment] = useState<TOTPEnrollment | null>(null);
+  const [twoFactorCode, setTwoFactorCode] = useState('');
+  const [useRecoveryCode, setUseRecoveryCode] = useState(false);
+  const [recoveryCodes, setRecoveryCodes] = useState<string[]>([]);
+  const { login, completeTwoFactorLogin, beginTwoFactorSetup, loginWithGoogle, loginWithOIDC, isLoading } = useAuth();
   const navigate = useNavigate();
+  const location = useLocation();
+
+  // Single sign-on hands over its two-factor challenge from the callback page
+  useEffect(() => {
+    const next = (location.state as { challenge?: TwoFactorChallenge } | null)?.challenge;
+    if (!next) {
+      return;
+    }
+    setChallenge(next);
+    if (next.setupRequired) {
+      beginTwoFactorSetup(next.challengeToken)
+        .then(setEnrollment)
+        .catch((err) => setError(err instanceof Error ? err.message : 'Failed to start two-factor setup'));
+    }
+  }, [location.state]);
+
+  // Load single sign-on providers on mount
+  useEffect(() => {
+    authClient
+      .get<OIDCProvider[]>('/api/auth/oidc/providers')
+      .then((response) => setOidcProviders(response.data))
+      .catch(() => setOidcProviders([]));
+  }, []);
 
   // Load login configuration on mount
   useEffect(() => {
@@ -65,13 +100,60 @@ export const Login: React.FC = () => {
     }
 
     try {
-      await login(email, password);
+      const next = await login(email, password);
+      if (next) {
+        setChallenge(next);
+        if (next.setupRequired) {
+          setEnrollment(await beginTwoFactorSetup(next.challengeToken));
+        }
+        return;
+      }
       navigate('/');
     } catch (err) {
       setError(err instanceof Error ? err.message : 'Login failed');
     }
   };
 
+  const handleTwoFactorSubmit = async (e: React.FormEvent): Promise<void> => {
+    e.preventDefault();
+    setError(null);
+
+    if (!challenge || !twoFactorCode) {
+      setError('Please enter your code');
+      return;
+    }
+
+    try {
+      const codes = await completeTwoFactorLogin(challenge.challengeToken, twoFactorCode.trim(), useRecoveryCode);
+      if (codes.length > 0) {
+        // Enrolled during this login; show the recovery codes once
+        setRecoveryCodes(codes);
+        return;
+      }
+      navigate('/');
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Invalid two-factor code');
+    }
+  };
+
+  const cancelTwoFactor = (): void => {
+    setChallenge(null);
+    setEnrollment(null);
+    setTwoFactorCode('');
+    setUseRecoveryCode(false);
+    setError(null);
+  };
+
+  const handleOIDCLogin = async (provider: string): Promise<void> => {
+    setError(null);
+    try {
+      await loginWithOIDC(provider);
+      // User will be redirected to the provider, so no need to navigate here
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Single sign-on failed');
+    }
+  };
+
   const handleGoogleLogin = async (): Promise<void> => {
     setError(null);
     try {
@@ -231,6 +313,104 @@ export const Login: React.FC = () => {
             padding: 'var(--spacing-4)',
           }}
         >
+          {recoveryCodes.length > 0 ? (
+            <div style={{ display: 'flex', flexDirection: 'column', gap: 'var(--spacing-4)' }}>
+              <p>
+                Two-factor authentication is now enabled. Store these recovery codes somewhere safe;
+                each one signs you in once if you lose your authenticator. They will not be shown again.
+              </p>
+              <pre style={{ fontFamily: 'monospace', lineHeight: '24px', margin: 0 }}>
+                {recoveryCodes.join('\n')}
+              </pre>
+              <button className="btn btn-primary" type="button" onClick={() => navigate('/')} style={{ width: '100%' }}>
+                Continue
+              </button>
+            </div>
+          ) : challenge ? (
+            <form
+              onSubmit={handleTwoFactorSubmit}
+              style={{
+                display: 'flex',
+                flexDirection: 'column',
+                gap: 'var(--spacing-4)',
+              }}
+            >
+              {error && (
+                <div className="alert alert-error">
+                  {error}
+                </div>
+              )}
+
+              {enrollment ? (
+                <div style={{ display: 'flex', flexDirection: 'column', gap: 'var(--spacing-2)' }}>
+                  <p>
+                    Your role requires two-factor authentication. Add this key to your authenticator app,
+                    then enter the code it shows.
+                  </p>
+                  <code style={{ wordBreak: 'break-all' }}>{enrollment.secret}</code>
+                  <a href={enrollment.otpauthUrl}>Open in authenticator app</a>
+                </div>
+              ) : (
+                <p>
+                  {useRecoveryCode
+                    ? 'Enter one of your recovery codes.'
+                    : 'Enter the code from your authenticator app.'}
+                </p>
+              )}
+
+              <div
+                style={{
+                  display: 'flex',
+                  flexDirection: 'column',
+                  gap: 'var(--spacing-2)',
+                }}
+              >
+                <label className="label" htmlFor="twoFactorCode">
+                  {useRecoveryCode ? 'Recovery code' : 'Authentication code'}
+                </label>
+                <input
+                  type="text"
+                  className="input"
+                  id="twoFactorCode"
+                  inputMode={useRecoveryCode ? 'text' : 'numeric'}
+                  autoComplete="one-time-code"
+                  placeholder={useRecoveryCode ? 'xxxxx-xxxxx' : '123456'}
+                  required
+                  autoFocus
+                  value={twoFactorCode}
+                  onChange={(e) => setTwoFactorCode(e.target.value)}
+                  disabled={isLoading}
+                />
+              </div>
+
+              <button
+                className="btn btn-primary"
+                type="submit"
+                disabled={isLoading}
+                style={{ width: '100%' }}
+              >
+                {isLoading ? 'Verifying...' : 'Verify'}
+              </button>
+
+              <div style={{ display: 'flex', justifyContent: 'space-between' }}>
+                {!enrollment && (
+                  <button
+                    type="button"
+                    className="btn btn-ghost"
+                    onClick={() => {
+                      setUseRecoveryCode(!useRecoveryCode);
+                      setTwoFactorCode('');
+                    }}
+                  >
+                    {useRecoveryCode ? 'Use authenticator code' : 'Use a recovery code'}
+                  </button>
+                )}
+                <button type="button" className="btn btn-ghost" onClick={cancelTwoFactor}>
+                  Back to sign in
+                </button>
+              </div>
+            </form>
+          ) : (
           <form
             onSubmit={handleSubmit}
             style={{
@@ -382,6 +562,26 @@ export const Login: React.FC = () => {
               <span>Sign in with Google</span>
             </button>
 
+            {/* Single Sign-On (OIDC) Buttons */}
+            {oidcProviders.map((provider) => (
+              <button
+                key={provider.name}
+                type="button"
+                onClick={() => handleOIDCLogin(provider.name)}
+                disabled={isLoading}
+                className="btn btn-outline"
+                style={{
+                  width: '100%',
+                  display: 'flex',
+                  alignItems: 'center',
+                  justifyContent: 'center',
+                  gap: 'var(--spacing-3)',
+                }}
+              >
+                <span>Sign in with {provider.displayName}</span>
+              </button>
+            ))}
+
             {/* Sign Up Link */}
             <div style={{ textAlign: 'center', marginTop: 'var(--spacing-2)' }}>
               <button
@@ -392,6 +592,7 @@ export const Login: React.FC = () => {
               </button>
             </div>
           </form>
+          )}
         </div>
       </div>
 
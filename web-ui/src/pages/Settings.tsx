@@ -5,6 +5,13 @@ import { Button } from '../components/Button';
 import { useTheme } from '../context/ThemeContext';
 import { Alert } from '../components/Alert';
 import { PageLayout } from '../components';
+import {
+  ANTHROPIC_INTEGRATION,
+  ANTHROPIC_KEY_FIELD,
+  deleteCredentials,
+  hasAnthropicKey,
+  saveCredentials,
+} from '../api/credentialService';
 
 export const Settings: React.FC = () => {
   const navigate = useNavigate();
@@ -17,12 +24,11 @@ export const Settings: React.FC = () => {
   const [showDiscoveredFolders, setShowDiscoveredFolders] = useState(false);
 
   useEffect(() => {
-    // Load saved API key on mount
-    const savedKey = localStorage.getItem('anthropic_api_key');
-    if (savedKey) {
-      setAnthropicApiKey(savedKey);
-      setApiKeySaved(true);
-    }
+    // The API key is kept in the credential vault; only whether one is stored
+    // is known here
+    hasAnthropicKey()
+      .then(setApiKeySaved)
+      .catch((err) => setApiKeyError(`Failed to load stored API key: ${err.message}`));
 
     // Load wizard mode preference
     const wizardMode = localStorage.getItem('wizard_mode_enabled');
@@ -45,7 +51,7 @@ export const Settings: React.FC = () => {
     localStorage.setItem('show_discovered_folders', String(newValue));
   };
 
-  const handleSaveApiKey = () => {
+  const handleSaveApiKey = async () => {
     if (!anthropicApiKey.trim()) {
       setApiKeyError('API key cannot be empty');
       return;
@@ -56,23 +62,34 @@ export const Settings: React.FC = () => {
       return;
     }
 
-    localStorage.setItem('anthropic_api_key', anthropicApiKey);
-    setApiKeySaved(true);
-    setApiKeyError(null);
+    try {
+      await saveCredentials(ANTHROPIC_INTEGRATION, 'https://api.anthropic.com', {
+        [ANTHROPIC_KEY_FIELD]: anthropicApiKey,
+      });
+      setAnthropicApiKey('');
+      setApiKeySaved(true);
+      setApiKeyError(null);
+    } catch (err) {
+      setApiKeyError(`Failed to save API key: ${err instanceof Error ? err.message : String(err)}`);
+    }
   };
 
-  const handleClearApiKey = () => {
-    localStorage.removeItem('anthropic_api_key');
-    setAnthropicApiKey('');
-    setApiKeySaved(false);
-    setApiKeyError(null);
+  const handleClearApiKey = async () => {
+    try {
+      await deleteCredentials(ANTHROPIC_INTEGRATION);
+      setAnthropicApiKey('');
+      setApiKeySaved(false);
+      setApiKeyError(null);
+    } catch (err) {
+      setApiKeyError(`Failed to remove API key: ${err instanceof Error ? err.message : String(err)}`);
+    }
   };
 
   return (
     <PageLayout
       title="Settings"
       quickDescription="Manage your application preferences and design system."
-      detailedDescription="Configure API keys for AI-powered features, manage your theme preferences, and adjust application settings. Your Anthropic API key is stored securely in your browser's local storage and is required for AI analysis features. You can also manage workspace data, clear integrations, and toggle wizard mode for guided workflows."
+      detailedDescription="Configure API keys for AI-powered features, manage your theme preferences, and adjust application settings. Your Anthropic API key is stored encrypted by the integration service and is required for AI analysis features. You can also manage workspace data, clear integrations, and toggle wizard mode for guided workflows."
     >
 
       <div className="space-y-6">
@@ -108,7 +125,7 @@ export const Settings: React.FC = () => {
 
               {apiKeySaved && !apiKeyError && (
                 <Alert variant="success" className="mb-3">
-                  API key saved successfully!
+                  An API key is stored. Enter a new key to replace it.
                 </Alert>
               )}
 
@@ -119,10 +136,9 @@ export const Settings: React.FC = () => {
                     value={anthropicApiKey}
                     onChange={(e) => {
                       setAnthropicApiKey(e.target.value);
-                      setApiKeySaved(false);
                       setApiKeyError(null);
                     }}
-                    placeholder="sk-ant-..."
+                    placeholder={apiKeySaved ? '•••••••• (stored)' : 'sk-ant-...'}
                     className="w-full px-4 py-2 border border-grey-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent font-mono text-sm"
                   />
                   <button
@@ -146,7 +162,7 @@ export const Settings: React.FC = () => {
                 <Button onClick={handleSaveApiKey} variant="primary">
                   Save
                 </Button>
-                {anthropicApiKey && (
+                {apiKeySaved && (
                   <Button onClick={handleClearApiKey} variant="outline">
                     Clear
                   </Button>
@@ -175,7 +191,8 @@ export const Settings: React.FC = () => {
                   Security Notice
                 </h4>
                 <p className="text-sm text-yellow-700">
-                  Your API key is stored locally in your browser and is only used to make requests to integration providers on your behalf.
+                  Your API key is encrypted and stored by the integration service, which uses it to make requests on your behalf.
+                  It is never sent back to your browser.
                   Never share your API key with others.
                 </p>
               </div>
@@ -3,7 +3,7 @@ import { useNavigate } from 'react-router-dom';
 import { Card, Alert, Button, PageLayout } from '../components';
 import { useWorkspace } from '../context/WorkspaceContext';
 import { useEntityState, usePhaseApprovals } from '../context/EntityStateContext';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 import {
   type LifecycleState,
   type WorkflowStage,
@@ -169,7 +169,7 @@ export const SpecificationApproval: React.FC = () => {
     setLoading(true);
     try {
       // Load capability items
-      const capabilityResponse = await fetch(`${INTEGRATION_URL}/capability-files`, {
+      const capabilityResponse = await integrationFetch(`${INTEGRATION_URL}/capability-files`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
@@ -187,7 +187,7 @@ export const SpecificationApproval: React.FC = () => {
       }));
 
       // Load enabler items
-      const enablerResponse = await fetch(`${INTEGRATION_URL}/enabler-files`, {
+      const enablerResponse = await integrationFetch(`${INTEGRATION_URL}/enabler-files`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
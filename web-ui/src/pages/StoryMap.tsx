@@ -1,7 +1,7 @@
 import React, { useState, useEffect, useCallback, useRef } from 'react';
 import { Card, Alert, Button, PageLayout } from '../components';
 import { useWorkspace } from '../context/WorkspaceContext';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 
 // Types for story map visualization
 interface StoryCard {
@@ -120,7 +120,7 @@ export const StoryMap: React.FC = () => {
     if (!currentWorkspace?.projectFolder) return;
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/story-files`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/story-files`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
@@ -186,7 +186,7 @@ export const StoryMap: React.FC = () => {
     if (!currentWorkspace?.projectFolder) return;
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/capability-files`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/capability-files`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
@@ -215,7 +215,7 @@ export const StoryMap: React.FC = () => {
     if (!currentWorkspace?.projectFolder) return;
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/enabler-files`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/enabler-files`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
@@ -278,14 +278,6 @@ export const StoryMap: React.FC = () => {
     setError(null);
 
     try {
-      // Get API key from localStorage
-      const apiKey = localStorage.getItem('anthropic_api_key') || '';
-      if (!apiKey) {
-        setError('Anthropic API key not configured. Please set it in settings.');
-        setIsAnalyzing(false);
-        return;
-      }
-
       // Build the prompt for AI analysis
       const storyCardsInfo = storyCards.map(s => ({
         id: s.id,
@@ -333,13 +325,12 @@ IMPORTANT: Return your response in the following JSON format ONLY (no markdown,
 Only include capabilities that have a clear relationship to a storyboard. If a capability doesn't relate to any storyboard, do not include it in the mappings.`;
 
       // Call AI endpoint
-      const aiResponse = await fetch(`${INTEGRATION_URL}/ai-chat`, {
+      const aiResponse = await integrationFetch(`${INTEGRATION_URL}/ai-chat`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
           message: prompt,
           workspacePath: currentWorkspace.projectFolder,
-          apiKey: apiKey,
         }),
       });
 
@@ -392,7 +383,7 @@ Only include capabilities that have a clear relationship to a storyboard. If a c
 
         if (capability && capability.path) {
           try {
-            const response = await fetch(`${INTEGRATION_URL}/update-capability-storyboard`, {
+            const response = await integrationFetch(`${INTEGRATION_URL}/update-capability-storyboard`, {
               method: 'POST',
               headers: { 'Content-Type': 'application/json' },
               body: JSON.stringify({
@@ -823,7 +814,7 @@ Only include capabilities that have a clear relationship to a storyboard. If a c
           const capability = capabilities.find(c => c.id === capId);
 
           if (capability?.path && targetNode.type === 'storyboard') {
-            await fetch(`${INTEGRATION_URL}/update-capability-storyboard`, {
+            await integrationFetch(`${INTEGRATION_URL}/update-capability-storyboard`, {
               method: 'POST',
               headers: { 'Content-Type': 'application/json' },
               body: JSON.stringify({
@@ -841,7 +832,7 @@ Only include capabilities that have a clear relationship to a storyboard. If a c
           const newCapability = capabilities.find(c => c.id === newCapId);
 
           if (newCapability?.path && toNode.type === 'storyboard') {
-            await fetch(`${INTEGRATION_URL}/update-capability-storyboard`, {
+            await integrationFetch(`${INTEGRATION_URL}/update-capability-storyboard`, {
               method: 'POST',
               headers: { 'Content-Type': 'application/json' },
               body: JSON.stringify({
@@ -865,7 +856,7 @@ Only include capabilities that have a clear relationship to a storyboard. If a c
           const newCap = capabilities.find(c => `cap-${c.id}` === newTargetId);
 
           if (enabler?.path && newCap) {
-            await fetch(`${INTEGRATION_URL}/update-enabler-capability`, {
+            await integrationFetch(`${INTEGRATION_URL}/update-enabler-capability`, {
               method: 'POST',
               headers: { 'Content-Type': 'application/json' },
               body: JSON.stringify({
@@ -884,7 +875,7 @@ Only include capabilities that have a clear relationship to a storyboard. If a c
           const cap = capabilities.find(c => `cap-${c.id}` === conn.to);
 
           if (newEnabler?.path && cap) {
-            await fetch(`${INTEGRATION_URL}/update-enabler-capability`, {
+            await integrationFetch(`${INTEGRATION_URL}/update-enabler-capability`, {
               method: 'POST',
               headers: { 'Content-Type': 'application/json' },
               body: JSON.stringify({
@@ -971,7 +962,7 @@ Only include capabilities that have a clear relationship to a storyboard. If a c
         endpoint = '/delete-enabler';
       }
 
-      const response = await fetch(`${INTEGRATION_URL}${endpoint}`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}${endpoint}`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ path: filePath }),
@@ -1069,7 +1060,7 @@ Only include capabilities that have a clear relationship to a storyboard. If a c
         const capability = capabilities.find(c => c.id === capId);
 
         if (capability?.path) {
-          await fetch(`${INTEGRATION_URL}/update-capability-storyboard`, {
+          await integrationFetch(`${INTEGRATION_URL}/update-capability-storyboard`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify({
@@ -1089,7 +1080,7 @@ Only include capabilities that have a clear relationship to a storyboard. If a c
         const capability = capabilities.find(c => c.id === capId);
 
         if (enabler?.path && capability) {
-          await fetch(`${INTEGRATION_URL}/update-enabler-capability`, {
+          await integrationFetch(`${INTEGRATION_URL}/update-enabler-capability`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify({
@@ -8,7 +8,7 @@ import RemoteCursors from '../components/RemoteCursors';
 import { AssetsPane } from '../components/AssetsPane';
 import { ConfirmDialog, PageLayout } from '../components';
 import { ReadOnlyStateFields } from '../components/ReadOnlyStateFields';
-import { INTEGRATION_URL, SPEC_URL } from '../api/client';
+import { INTEGRATION_URL, SPEC_URL, integrationFetch } from '../api/client';
 
 export const Storyboard: React.FC = () => {
   const { currentWorkspace, updateStoryboard } = useWorkspace();
@@ -400,7 +400,7 @@ export const Storyboard: React.FC = () => {
 
     // Save all files to ideation folder
     try {
-      await fetch(`${INTEGRATION_URL}/save-specifications`, {
+      await integrationFetch(`${INTEGRATION_URL}/save-specifications`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -571,7 +571,7 @@ export const Storyboard: React.FC = () => {
 
     try {
       // Fetch STORY-*.md files from conception folder
-      const response = await fetch(`${INTEGRATION_URL}/read-storyboard-files`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/read-storyboard-files`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -863,7 +863,7 @@ export const Storyboard: React.FC = () => {
 
     try {
       // First try to load from existing storyboards-full.md
-      let response = await fetch(`${INTEGRATION_URL}/analyze-storyboard`, {
+      let response = await integrationFetch(`${INTEGRATION_URL}/analyze-storyboard`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -878,22 +878,14 @@ export const Storyboard: React.FC = () => {
 
       // If no existing file or error, generate from specification files
       if (data.error || !data.cards || data.cards.length === 0) {
-        const apiKey = localStorage.getItem('anthropic_api_key');
-        if (!apiKey) {
-          setAnalyzeError('No existing storyboard found. Please set your Anthropic API key in Settings to generate one from specifications.');
-          setIsAnalyzing(false);
-          return;
-        }
-
         // Generate new analysis from STORY*.md, dependencies.md, site-architecture.md
-        response = await fetch(`${INTEGRATION_URL}/analyze-storyboard`, {
+        response = await integrationFetch(`${INTEGRATION_URL}/analyze-storyboard`, {
           method: 'POST',
           headers: {
             'Content-Type': 'application/json',
           },
           body: JSON.stringify({
             workspacePath: currentWorkspace.projectFolder,
-            apiKey: apiKey,
             forceRegenerate: true,
           }),
         });
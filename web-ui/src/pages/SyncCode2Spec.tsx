@@ -3,7 +3,7 @@ import { Button } from '../components/Button';
 import { Card } from '../components/Card';
 import { useWorkspace } from '../context/WorkspaceContext';
 import { PageLayout } from '../components';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 
 interface CategorizedChange {
   id: string;
@@ -124,7 +124,7 @@ export const SyncCode2Spec: React.FC = () => {
     setError(null);
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/get-code-diff`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/get-code-diff`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
@@ -159,12 +159,6 @@ export const SyncCode2Spec: React.FC = () => {
       return;
     }
 
-    const anthropicKey = localStorage.getItem('anthropic_api_key') || '';
-    if (!anthropicKey) {
-      setError('Please add your Anthropic API key in the Settings page.');
-      return;
-    }
-
     setIsLoading(true);
     setError(null);
     setAnalysis(null);
@@ -175,14 +169,13 @@ export const SyncCode2Spec: React.FC = () => {
     }
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/sync-code-to-spec`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/sync-code-to-spec`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
           workspacePath: currentWorkspace.projectFolder,
           codeChanges: codeContent,
           fileList: codeFiles,
-          anthropicKey,
         }),
       });
 
@@ -216,7 +209,7 @@ export const SyncCode2Spec: React.FC = () => {
     setError(null);
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/apply-spec-update`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/apply-spec-update`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
@@ -299,7 +292,7 @@ ${acceptanceCriteriaList}
       const filename = `${req.id}.md`;
       const content = generateEnablerMarkdown(req);
 
-      const response = await fetch(`${INTEGRATION_URL}/apply-spec-update`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/apply-spec-update`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
@@ -3,7 +3,7 @@ import mermaid from 'mermaid';
 import { Button } from '../components/Button';
 import { PageLayout } from '../components';
 import { useWorkspace, type SystemCapability, type SystemEnabler } from '../context/WorkspaceContext';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 
 // Initialize mermaid
 mermaid.initialize({
@@ -264,7 +264,7 @@ export const System: React.FC = () => {
 
     try {
       // Use /story-files endpoint like Capabilities page does
-      const response = await fetch(`${INTEGRATION_URL}/story-files`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/story-files`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -621,7 +621,7 @@ export const System: React.FC = () => {
 
       // Save files to definition folder (same folder as Import reads from)
       try {
-        const response = await fetch(`${INTEGRATION_URL}/save-specifications`, {
+        const response = await integrationFetch(`${INTEGRATION_URL}/save-specifications`, {
           method: 'POST',
           headers: {
             'Content-Type': 'application/json',
@@ -682,7 +682,7 @@ export const System: React.FC = () => {
     markdown += '\n```\n';
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/save-specifications`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/save-specifications`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -811,7 +811,7 @@ export const System: React.FC = () => {
       };
       console.log('[System] Request body:', JSON.stringify(requestBody, null, 2));
 
-      const response = await fetch(`${INTEGRATION_URL}/save-specifications`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/save-specifications`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -844,7 +844,7 @@ export const System: React.FC = () => {
     const fileName = 'STATE-STATE-DIAGRAM-1.md';
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/read-specification`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/read-specification`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -889,7 +889,7 @@ export const System: React.FC = () => {
     const fileName = 'SEQ-SEQUENCE-DIAGRAM-1.md';
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/read-specification`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/read-specification`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -933,7 +933,7 @@ export const System: React.FC = () => {
     const fileName = 'DATA-DATA-MODEL-1.md';
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/read-specification`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/read-specification`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -977,7 +977,7 @@ export const System: React.FC = () => {
     const fileName = 'CLASS-CLASS-DIAGRAM-1.md';
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/read-specification`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/read-specification`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -1118,7 +1118,7 @@ export const System: React.FC = () => {
 
       // Save capabilities and enablers to definition folder
       if (definitionFiles.length > 0) {
-        const defResponse = await fetch(`${INTEGRATION_URL}/save-specifications`, {
+        const defResponse = await integrationFetch(`${INTEGRATION_URL}/save-specifications`, {
           method: 'POST',
           headers: {
             'Content-Type': 'application/json',
@@ -1139,7 +1139,7 @@ export const System: React.FC = () => {
 
       // Save diagrams to implementation folder
       if (implementationFiles.length > 0) {
-        const implResponse = await fetch(`${INTEGRATION_URL}/save-specifications`, {
+        const implResponse = await integrationFetch(`${INTEGRATION_URL}/save-specifications`, {
           method: 'POST',
           headers: {
             'Content-Type': 'application/json',
@@ -1320,21 +1320,21 @@ export const System: React.FC = () => {
       console.log('[System] Fetching capabilities, enablers, and test scenarios from definition folder...');
 
       // Fetch capabilities from definition folder
-      const capResponse = await fetch(`${INTEGRATION_URL}/capability-files`, {
+      const capResponse = await integrationFetch(`${INTEGRATION_URL}/capability-files`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
       });
 
       // Fetch enablers from definition folder
-      const enbResponse = await fetch(`${INTEGRATION_URL}/enabler-files`, {
+      const enbResponse = await integrationFetch(`${INTEGRATION_URL}/enabler-files`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
       });
 
       // Fetch test scenarios from definition folder
-      const tsResponse = await fetch(`${INTEGRATION_URL}/test-scenario-files`, {
+      const tsResponse = await integrationFetch(`${INTEGRATION_URL}/test-scenario-files`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
@@ -1733,13 +1733,6 @@ export const System: React.FC = () => {
       return;
     }
 
-    // Get Anthropic API key
-    const anthropicKey = localStorage.getItem('anthropic_api_key') || import.meta.env.VITE_ANTHROPIC_API_KEY || '';
-    if (!anthropicKey) {
-      alert('Please add your Anthropic API key in the Settings page to enable AI analysis.');
-      return;
-    }
-
     setIsLoading(true);
     setCapabilities([]);
     setEnablers([]);
@@ -1751,17 +1744,17 @@ export const System: React.FC = () => {
     try {
       // Fetch capabilities, enablers, and test scenarios from workspace folders
       const [capResponse, enbResponse, tsResponse] = await Promise.all([
-        fetch(`${INTEGRATION_URL}/capability-files`, {
+        integrationFetch(`${INTEGRATION_URL}/capability-files`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
         }),
-        fetch(`${INTEGRATION_URL}/enabler-files`, {
+        integrationFetch(`${INTEGRATION_URL}/enabler-files`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
         }),
-        fetch(`${INTEGRATION_URL}/test-scenario-files`, {
+        integrationFetch(`${INTEGRATION_URL}/test-scenario-files`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
@@ -1796,12 +1789,11 @@ export const System: React.FC = () => {
       console.log('[System] Sending', files.length, 'files to Claude for AI analysis...');
 
       // Send to AI for analysis
-      const analyzeResponse = await fetch(`${INTEGRATION_URL}/specifications/analyze`, {
+      const analyzeResponse = await integrationFetch(`${INTEGRATION_URL}/specifications/analyze`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
           files,
-          anthropic_key: anthropicKey,
         }),
       });
 
@@ -2183,14 +2175,14 @@ export const System: React.FC = () => {
       console.log('[System] Generating diagram - fetching from definition folder...');
 
       // Fetch capabilities from definition folder
-      const capResponse = await fetch(`${INTEGRATION_URL}/capability-files`, {
+      const capResponse = await integrationFetch(`${INTEGRATION_URL}/capability-files`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
       });
 
       // Fetch enablers from definition folder
-      const enbResponse = await fetch(`${INTEGRATION_URL}/enabler-files`, {
+      const enbResponse = await integrationFetch(`${INTEGRATION_URL}/enabler-files`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
@@ -2222,15 +2214,6 @@ export const System: React.FC = () => {
         return;
       }
 
-      // Get Anthropic API key
-      const anthropicKey = localStorage.getItem('anthropic_api_key') || import.meta.env.VITE_ANTHROPIC_API_KEY || '';
-
-      if (!anthropicKey) {
-        alert('Please add your Anthropic API key in the Settings page to enable AI-powered generation.');
-        setLoading(false);
-        return;
-      }
-
       // Define prompts for each diagram type
       const prompts: Record<string, string> = {
         'state': `Analyze these specifications and generate a state diagram in Mermaid format.
@@ -2273,14 +2256,13 @@ export const System: React.FC = () => {
       };
 
       // Call backend to generate diagram
-      const generateResponse = await fetch(`${INTEGRATION_URL}/specifications/generate-diagram`, {
+      const generateResponse = await integrationFetch(`${INTEGRATION_URL}/specifications/generate-diagram`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
         },
         body: JSON.stringify({
           files,
-          anthropic_key: anthropicKey,
           diagram_type: diagramType,
           prompt: prompts[diagramType],
         }),
@@ -2332,7 +2314,7 @@ export const System: React.FC = () => {
         alert(`⚠️ Anthropic API Credit Issue\n\nYour Claude API account has insufficient credits. Please add credits to your Anthropic account at:\n\nhttps://console.anthropic.com/settings/plans\n\nThe diagram generation requires API access.`);
       } else if (errorMessage.includes('No specification files found') || errorMessage.includes('No capability')) {
         alert(`⚠️ No Definition Files Found\n\nPlease ensure your workspace has capability and enabler files in:\n${currentWorkspace?.projectFolder}/definition/\n\nYou can create capabilities and enablers using the Capabilities and Enablers pages.`);
-      } else if (errorMessage.includes('anthropic_key is required')) {
+      } else if (errorMessage.includes('no Anthropic API key configured')) {
         alert(`⚠️ API Key Missing\n\nPlease add your Anthropic API key in the Settings page to enable AI-powered diagram generation.`);
       } else {
         alert(`Failed to generate ${diagramType} diagram.\n\nError: ${errorMessage}\n\nPlease check:\n1. API key is configured in Settings\n2. Workspace has capability/enabler files in definition folder\n3. API credits are available`);
@@ -2456,7 +2438,7 @@ export const System: React.FC = () => {
     // Delete the file from the filesystem
     if (capability.filename) {
       try {
-        const response = await fetch(`${INTEGRATION_URL}/delete-specification`, {
+        const response = await integrationFetch(`${INTEGRATION_URL}/delete-specification`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({
@@ -2509,7 +2491,7 @@ export const System: React.FC = () => {
     // Delete the file from the filesystem
     if (enabler.filename) {
       try {
-        const response = await fetch(`${INTEGRATION_URL}/delete-specification`, {
+        const response = await integrationFetch(`${INTEGRATION_URL}/delete-specification`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({
@@ -3,7 +3,7 @@ import { useNavigate } from 'react-router-dom';
 import { Card, Alert, Button, PageLayout } from '../components';
 import { useWorkspace } from '../context/WorkspaceContext';
 import { usePhaseApprovals } from '../context/EntityStateContext';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 
 interface SystemItem {
   id: string;
@@ -144,7 +144,7 @@ export const SystemApproval: React.FC = () => {
       // Try to load from file first (for shared/imported workspaces)
       if (currentWorkspace?.projectFolder) {
         try {
-          const response = await fetch(`${INTEGRATION_URL}/read-file`, {
+          const response = await integrationFetch(`${INTEGRATION_URL}/read-file`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify({
@@ -250,7 +250,7 @@ export const SystemApproval: React.FC = () => {
           lastUpdated: new Date().toISOString(),
         }, null, 2);
 
-        await fetch(`${INTEGRATION_URL}/save-specifications`, {
+        await integrationFetch(`${INTEGRATION_URL}/save-specifications`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({
@@ -279,7 +279,7 @@ export const SystemApproval: React.FC = () => {
         lastUpdated: new Date().toISOString(),
       }, null, 2);
 
-      await fetch(`${INTEGRATION_URL}/save-specifications`, {
+      await integrationFetch(`${INTEGRATION_URL}/save-specifications`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
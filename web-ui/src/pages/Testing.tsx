@@ -1,7 +1,8 @@
 import React, { useState, useEffect, useCallback } from 'react';
 import { Card, Alert, Button, ConfirmDialog, PageLayout } from '../components';
 import { useWorkspace } from '../context/WorkspaceContext';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
+import { allocateIds } from '../api/enablerService';
 
 // Test Scenario interface
 interface TestScenario {
@@ -121,7 +122,7 @@ Scenario: [Scenario Name]
     setError(null);
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/enabler-files`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/enabler-files`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ workspacePath: currentWorkspace.projectFolder }),
@@ -183,13 +184,6 @@ Scenario: [Scenario Name]
     loadEnablers();
   }, [loadEnablers]);
 
-  // Generate unique ID
-  const generateId = () => {
-    const timestamp = Date.now().toString().slice(-4);
-    const random = Math.floor(Math.random() * 100).toString().padStart(2, '0');
-    return `${timestamp}${random}`;
-  };
-
   // Handle enabler selection
   const handleEnablerSelect = (enabler: EnablerWithTests) => {
     setSelectedEnabler(enabler);
@@ -212,13 +206,14 @@ Scenario: [Scenario Name]
     setSelectedScenario(null);
   };
 
-  // Save scenario to markdown file - throws on error
-  const saveScenarioToFile = async (scenario: TestScenario): Promise<void> => {
+  // Save scenario to markdown file and return its ID, which the server
+  // assigns to scenarios saved without one - throws on error
+  const saveScenarioToFile = async (scenario: TestScenario): Promise<string> => {
     if (!currentWorkspace?.projectFolder) {
       throw new Error('No workspace path available');
     }
 
-    const response = await fetch(`${INTEGRATION_URL}/save-test-scenario`, {
+    const response = await integrationFetch(`${INTEGRATION_URL}/save-test-scenario`, {
       method: 'POST',
       headers: { 'Content-Type': 'application/json' },
       body: JSON.stringify({
@@ -243,6 +238,8 @@ Scenario: [Scenario Name]
       const errorText = await response.text();
       throw new Error(`Failed to save scenario "${scenario.name}": ${errorText}`);
     }
+    const result = await response.json();
+    return result.scenarioId;
   };
 
   // Delete scenario file
@@ -252,7 +249,7 @@ Scenario: [Scenario Name]
     }
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/delete-test-scenario`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/delete-test-scenario`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
@@ -277,7 +274,7 @@ Scenario: [Scenario Name]
     }
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/list-test-scenarios`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/list-test-scenarios`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
@@ -362,14 +359,6 @@ Scenario: [Scenario Name]
     setError(null);
 
     try {
-      // Get API key from localStorage
-      const apiKey = localStorage.getItem('anthropic_api_key') || '';
-      if (!apiKey) {
-        setError('Anthropic API key not configured. Please set it in settings.');
-        setIsAnalyzing(false);
-        return;
-      }
-
       // Read enabler files to get their content
       const enablerContents: { enabler: EnablerWithTests; content: string }[] = [];
       const readErrors: string[] = [];
@@ -381,7 +370,7 @@ Scenario: [Scenario Name]
             continue;
           }
 
-          const response = await fetch(`${INTEGRATION_URL}/read-file`, {
+          const response = await integrationFetch(`${INTEGRATION_URL}/read-file`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify({
@@ -459,13 +448,12 @@ ${content}
 Generate test scenarios for ALL the enablers above. Remember to return ONLY valid JSON.`;
 
         // Call AI endpoint
-        const aiResponse = await fetch(`${INTEGRATION_URL}/ai-chat`, {
+        const aiResponse = await integrationFetch(`${INTEGRATION_URL}/ai-chat`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({
             message: prompt,
             workspacePath: currentWorkspace.projectFolder,
-            apiKey: apiKey,
           }),
         });
 
@@ -509,7 +497,7 @@ Generate test scenarios for ALL the enablers above. Remember to return ONLY vali
             const parsed = JSON.parse(jsonStr);
             if (parsed.scenarios && Array.isArray(parsed.scenarios)) {
               const batchScenarios = parsed.scenarios.map((s: Record<string, unknown>) => ({
-                id: (s.id as string) || `TS-${generateId()}`,
+                id: '', // Assigned by the server on save
                 name: (s.name as string) || 'Unnamed Scenario',
                 feature: (s.feature as string) || 'Generated Feature',
                 enablerId: (s.enablerId as string) || '',
@@ -570,7 +558,16 @@ Generate test scenarios for ALL the enablers above. Remember to return ONLY vali
 
     if (!selectedEnabler || !testSuite) return;
 
-    const scenarioId = editingScenario?.id || `TS-${generateId()}`;
+    // The ID is reserved before saving so the Gherkin tag can name it
+    let scenarioId = editingScenario?.id || '';
+    if (!scenarioId && currentWorkspace?.projectFolder) {
+      try {
+        [scenarioId] = await allocateIds(currentWorkspace.projectFolder, 'TS');
+      } catch (err) {
+        setError(err instanceof Error ? err.message : 'Failed to allocate a test scenario ID');
+        return;
+      }
+    }
 
     const newScenario: TestScenario = {
       id: scenarioId,
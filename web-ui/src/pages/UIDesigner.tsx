@@ -3,7 +3,7 @@ import { Card } from '../components/Card';
 import { Button } from '../components/Button';
 import { PageLayout } from '../components/PageLayout';
 import { useWorkspace } from '../context/WorkspaceContext';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 
 type ComponentType = 'button' | 'card' | 'input' | 'alert' | 'navbar' | 'avatar' | 'progress' | 'toggle';
 type ImageFormat = 'png' | 'jpeg' | 'svg';
@@ -347,7 +347,7 @@ export const UIDesigner: React.FC = () => {
     markdown += '\n```\n';
 
     try {
-      const response = await fetch(`${INTEGRATION_URL}/save-specifications`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/save-specifications`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -518,7 +518,7 @@ ${xmlEscaped}
       });
 
       // Save via backend
-      const response = await fetch(`${INTEGRATION_URL}/save-image`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/save-image`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -2,7 +2,7 @@ import React, { useState, useEffect } from 'react';
 import { useSearchParams } from 'react-router-dom';
 import { Card, Alert, Button, PageLayout } from '../components';
 import { useWorkspace } from '../context/WorkspaceContext';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 
 interface FileTheme {
   filename: string;
@@ -67,7 +67,7 @@ export const Vision: React.FC = () => {
 
     setLoading(true);
     try {
-      const response = await fetch(`${INTEGRATION_URL}/theme-files`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/theme-files`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -140,7 +140,7 @@ export const Vision: React.FC = () => {
         path = `${currentWorkspace.projectFolder}/conception/${fileName}`;
       }
 
-      const response = await fetch(`${INTEGRATION_URL}/save-theme`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/save-theme`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -181,7 +181,7 @@ export const Vision: React.FC = () => {
 
     setDeletingTheme(true);
     try {
-      const response = await fetch(`${INTEGRATION_URL}/delete-theme`, {
+      const response = await integrationFetch(`${INTEGRATION_URL}/delete-theme`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
@@ -7,7 +7,7 @@ import { WorkspaceIntegrations } from '../components/WorkspaceIntegrations';
 // WorkspaceVersionControl functionality has been merged into WorkspaceIntegrations (Integrations > GitHub)
 import { FolderBrowser } from '../components/FolderBrowser';
 import { ShareWorkspaceModal } from '../components/ShareWorkspaceModal';
-import { INTEGRATION_URL } from '../api/client';
+import { INTEGRATION_URL, integrationFetch } from '../api/client';
 
 // Types for scanned workspaces
 interface WorkspaceConfig {
@@ -90,7 +90,7 @@ export const Workspaces: React.FC = () => {
   const scanWorkspaceFolders = async () => {
     setIsScanning(true);
     try {
-      const response = await fetch(`${INTEGRATION_URL}/workspace-config/scan`);
+      const response = await integrationFetch(`${INTEGRATION_URL}/workspace-config/scan`);
       if (response.ok) {
         const data = await response.json();
         // Filter out workspaces that are already in the user's workspace list
@@ -220,9 +220,13 @@ export const Workspaces: React.FC = () => {
 
   const handleFolderSelect = async (path: string) => {
     if (selectingFolderFor) {
+      // Saving the folder writes its .intentrworkspace file, which claims the
+      // folder for the workspace before its structure is created
+      await updateWorkspace(selectingFolderFor, { projectFolder: path });
+
       try {
         // Ensure workspace folder structure exists (creates required subfolders)
-        const response = await fetch(`${INTEGRATION_URL}/folders/ensure-workspace-structure`, {
+        const response = await integrationFetch(`${INTEGRATION_URL}/folders/ensure-workspace-structure`, {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({ path }),
@@ -240,7 +244,6 @@ export const Workspaces: React.FC = () => {
         console.error('Error ensuring workspace structure:', error);
       }
 
-      await updateWorkspace(selectingFolderFor, { projectFolder: path });
       setShowFolderBrowser(false);
       setSelectingFolderFor(null);
     }